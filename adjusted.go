@@ -0,0 +1,70 @@
+package quote
+
+// Adjusted - split and dividend adjusted copy of q derived from its adjusted
+// columns. Open, High and Low are taken from AdjOpen, AdjHigh and AdjLow
+// where q carries them, else scaled by the AdjClose/Close ratio of their bar,
+// and Close is replaced by AdjClose; Volume is left as is. The copy keeps
+// AdjClose but not the other adjusted columns and is marked adjusted in its
+// metadata. A quote without AdjClose is returned unchanged.
+func (q Quote) Adjusted() Quote {
+	adj := q.copy()
+	if q.AdjClose == nil {
+		return adj
+	}
+	provided := q.AdjOpen != nil && q.AdjHigh != nil && q.AdjLow != nil
+	for bar := range adj.Close {
+		switch {
+		case provided:
+			adj.Open[bar] = q.AdjOpen[bar]
+			adj.High[bar] = q.AdjHigh[bar]
+			adj.Low[bar] = q.AdjLow[bar]
+		case q.Close[bar] != 0:
+			ratio := q.AdjClose[bar] / q.Close[bar]
+			adj.Open[bar] = q.Open[bar] * ratio
+			adj.High[bar] = q.High[bar] * ratio
+			adj.Low[bar] = q.Low[bar] * ratio
+		default:
+			continue
+		}
+		adj.Close[bar] = q.AdjClose[bar]
+	}
+	adj.AdjOpen, adj.AdjHigh, adj.AdjLow = nil, nil, nil
+	if adj.Meta != nil {
+		adj.Meta.Adjusted = true
+	}
+	return adj
+}
+
+// Unadjusted - copy of q with the prices as traded only, without its
+// adjusted columns. A quote marked adjusted in its metadata has no prices as
+// traded left and is returned unchanged.
+func (q Quote) Unadjusted() Quote {
+	raw := q.copy()
+	if q.Meta != nil && q.Meta.Adjusted {
+		return raw
+	}
+	raw.AdjOpen, raw.AdjHigh, raw.AdjLow, raw.AdjClose = nil, nil, nil, nil
+	return raw
+}
+
+// withAdjustedPrices - q with AdjOpen, AdjHigh and AdjLow scaled from its
+// open, high and low by the AdjClose/Close ratio of each bar, for sources
+// reporting only the adjusted close. Unchanged without AdjClose.
+func (q Quote) withAdjustedPrices() Quote {
+	if q.AdjClose == nil {
+		return q
+	}
+	q.AdjOpen = make([]float64, len(q.Date))
+	q.AdjHigh = make([]float64, len(q.Date))
+	q.AdjLow = make([]float64, len(q.Date))
+	for bar := range q.Date {
+		ratio := 1.0
+		if q.Close[bar] != 0 {
+			ratio = q.AdjClose[bar] / q.Close[bar]
+		}
+		q.AdjOpen[bar] = q.Open[bar] * ratio
+		q.AdjHigh[bar] = q.High[bar] * ratio
+		q.AdjLow[bar] = q.Low[bar] * ratio
+	}
+	return q
+}
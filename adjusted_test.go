@@ -0,0 +1,136 @@
+package quote
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// tiingoDividendDaily - Tiingo daily prices with a 0.5 dividend on the fourth bar,
+// adjusted fields as the provider rounds them
+const tiingoDividendDaily = `[
+{"date":"2023-03-13T00:00:00.000Z","open":98.1,"high":99.9,"low":97.3,"close":99.2,"volume":1000,"adjOpen":97.6095,"adjHigh":99.4005,"adjLow":96.8135,"adjClose":98.704,"adjVolume":1000,"divCash":0.0,"splitFactor":1.0},
+{"date":"2023-03-14T00:00:00.000Z","open":99.2,"high":100.8,"low":98.6,"close":100.4,"volume":1100,"adjOpen":98.704,"adjHigh":100.296,"adjLow":98.107,"adjClose":99.898,"adjVolume":1100,"divCash":0.0,"splitFactor":1.0},
+{"date":"2023-03-15T00:00:00.000Z","open":100.4,"high":101.1,"low":99.5,"close":100.0,"volume":1200,"adjOpen":99.898,"adjHigh":100.5945,"adjLow":99.0025,"adjClose":99.5,"adjVolume":1200,"divCash":0.0,"splitFactor":1.0},
+{"date":"2023-03-16T00:00:00.000Z","open":99.4,"high":100.2,"low":98.8,"close":99.9,"volume":1300,"adjOpen":99.4,"adjHigh":100.2,"adjLow":98.8,"adjClose":99.9,"adjVolume":1300,"divCash":0.5,"splitFactor":1.0},
+{"date":"2023-03-17T00:00:00.000Z","open":99.9,"high":101.0,"low":99.1,"close":100.6,"volume":1400,"adjOpen":99.9,"adjHigh":101.0,"adjLow":99.1,"adjClose":100.6,"adjVolume":1400,"divCash":0.0,"splitFactor":1.0}
+]`
+
+func TestAdjusted(t *testing.T) {
+	q := cleanDaily("spy", 3)
+	equals(t, q, q.Adjusted())
+
+	q.AdjClose = []float64{q.Close[0] / 2, q.Close[1] / 2, q.Close[2]}
+	adj := q.Adjusted()
+	equals(t, q.AdjClose, adj.Close)
+	equals(t, q.Open[0]/2, adj.Open[0])
+	equals(t, q.High[1]/2, adj.High[1])
+	equals(t, q.Low[2], adj.Low[2])
+	equals(t, q.Volume, adj.Volume)
+	// q is not modified
+	equals(t, 2*q.AdjClose[0], q.Close[0])
+
+	// provided adjusted prices are taken as they are
+	q.AdjOpen = []float64{1, 2, 3}
+	q.AdjHigh = []float64{4, 5, 6}
+	q.AdjLow = []float64{0.5, 1.5, 2.5}
+	q.Meta = &Metadata{Symbol: "spy"}
+	adj = q.Adjusted()
+	equals(t, q.AdjOpen, adj.Open)
+	equals(t, q.AdjHigh, adj.High)
+	equals(t, q.AdjLow, adj.Low)
+	equals(t, q.AdjClose, adj.Close)
+	equals(t, q.AdjClose, adj.AdjClose)
+	assert(t, adj.AdjOpen == nil && adj.AdjHigh == nil && adj.AdjLow == nil, "adjusted quote carries adjusted prices")
+	equals(t, true, adj.Meta.Adjusted)
+	equals(t, false, q.Meta.Adjusted)
+}
+
+func TestUnadjusted(t *testing.T) {
+	q := cleanDaily("spy", 3).withAdjustedPrices()
+	equals(t, q, q.Unadjusted())
+
+	q.AdjClose = []float64{q.Close[0] / 2, q.Close[1] / 2, q.Close[2]}
+	q = q.withAdjustedPrices()
+	equals(t, q.Open[0]/2, q.AdjOpen[0])
+	equals(t, q.Low[2], q.AdjLow[2])
+	q.Meta = &Metadata{Symbol: "spy"}
+	raw := q.Unadjusted()
+	equals(t, q.Bars(), raw.Bars())
+	assert(t, raw.AdjOpen == nil && raw.AdjHigh == nil && raw.AdjLow == nil && raw.AdjClose == nil, "unadjusted quote carries adjusted prices")
+	assert(t, q.AdjClose != nil, "q modified")
+
+	// an adjusted quote has no raw prices to return
+	adj := q.Adjusted()
+	equals(t, adj, adj.Unadjusted())
+}
+
+func TestAdjustedColumnsRoundTrip(t *testing.T) {
+	q := cleanDaily("spy", 3)
+	q.AdjClose = []float64{q.Close[0] / 2, q.Close[1] / 2, q.Close[2]}
+	q = q.withAdjustedPrices()
+
+	csv, err := NewQuoteFromCSV("spy", q.CSV())
+	ok(t, err)
+	equals(t, q, csv)
+	header := strings.SplitN(q.CSV(), "\n", 2)[0]
+	assert(t, strings.HasSuffix(header, ",adjclose,adjopen,adjhigh,adjlow"), "csv header %s", header)
+	raw, err := NewQuoteFromCSV("spy", q.Unadjusted().CSV())
+	ok(t, err)
+	equals(t, q.Unadjusted(), raw)
+
+	js, err := NewQuoteFromJSON(q.JSON(false))
+	ok(t, err)
+	equals(t, q, js)
+	assert(t, !strings.Contains(q.Unadjusted().JSON(false), "adjopen"), "json of unadjusted quote has adjusted prices")
+
+	nd, err := NewQuoteFromNDJSON(strings.NewReader(q.NDJSON()))
+	ok(t, err)
+	equals(t, q, nd)
+
+	data, err := q.Marshal()
+	ok(t, err)
+	pb, err := UnmarshalQuote(data)
+	ok(t, err)
+	equals(t, q, pb)
+}
+
+func TestQuotePairFromTiingo(t *testing.T) {
+	fakeServer(t, &tiingoURL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, tiingoDividendDaily)
+	})
+
+	raw, adj, err := NewQuotePairFromTiingo("spy", "2023-03-13", "2023-03-17", Daily, "token")
+	ok(t, err)
+	equals(t, 5, len(raw.Close))
+	equals(t, 99.2, raw.Close[0])
+	equals(t, 98.704, adj.Close[0])
+	equals(t, raw.AdjClose, adj.AdjClose)
+	equals(t, 97.6095, raw.AdjOpen[0])
+	equals(t, raw.Adjusted().Bars(), adj.Bars())
+	assert(t, adj.AdjOpen == nil, "adjusted quote carries adjusted opens")
+
+	// adjusted series derived from raw prices agrees with the provider's own
+	fromClose := raw
+	fromClose.AdjOpen, fromClose.AdjHigh, fromClose.AdjLow = nil, nil, nil
+	derived := fromClose.Adjusted()
+	for bar := range adj.Close {
+		for _, f := range [][2]float64{
+			{derived.Open[bar], adj.Open[bar]},
+			{derived.High[bar], adj.High[bar]},
+			{derived.Low[bar], adj.Low[bar]},
+			{derived.Close[bar], adj.Close[bar]},
+		} {
+			assert(t, math.Abs(f[0]-f[1]) < 1e-4*f[1], "bar %d derived %v provider %v", bar, f[0], f[1])
+		}
+	}
+
+	q, err := NewQuoteFromTiingo("spy", "2023-03-13", "2023-03-17", Daily, "token")
+	ok(t, err)
+	equals(t, adj.Open, q.Open)
+	equals(t, adj.Close, q.Close)
+	equals(t, true, q.Meta.Adjusted)
+	equals(t, false, raw.Meta.Adjusted)
+}
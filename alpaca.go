@@ -0,0 +1,183 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// alpacaURL - base url of the Alpaca market data api
+var alpacaURL = "https://data.alpaca.markets"
+
+// alpacaPeriods - bar timeframes of the Alpaca market data api
+var alpacaPeriods = []Period{Min1, Min5, Min15, Min60, Daily}
+
+// alpacaTimeframes - timeframe of each supported period
+var alpacaTimeframes = map[Period]string{
+	Min1:  "1Min",
+	Min5:  "5Min",
+	Min15: "15Min",
+	Min60: "1Hour",
+	Daily: "1Day",
+}
+
+// alpacaRecent - the most recent bars a free subscription may not query
+const alpacaRecent = 15 * time.Minute
+
+// alpacaBars - one page of a bars response
+type alpacaBars struct {
+	Bars []struct {
+		T time.Time `json:"t"` // bar start, RFC3339 in UTC
+		O float64   `json:"o"`
+		H float64   `json:"h"`
+		L float64   `json:"l"`
+		C float64   `json:"c"`
+		V float64   `json:"v"`
+	} `json:"bars"`
+	NextPageToken string `json:"next_page_token"`
+	Message       string `json:"message"`
+}
+
+// alpacaKeys - key id and secret of an Alpaca token written keyID:secret
+func alpacaKeys(token string) (string, string) {
+	if i := strings.Index(token, ":"); i >= 0 {
+		return token[:i], token[i+1:]
+	}
+	return token, ""
+}
+
+// NewQuoteFromAlpaca - Alpaca unadjusted historical bars for a symbol.
+// Intraday bars are timestamped in UTC, daily bars by date. Pages are followed
+// with Delay between requests. A subscription that does not permit the most
+// recent 15 minutes, as on the free tier, gets the range cut short of them.
+func NewQuoteFromAlpaca(symbol, startDate, endDate string, period Period, keyID, secret string) (Quote, error) {
+	return NewQuoteFromAlpacaCtx(context.Background(), symbol, startDate, endDate, period, keyID, secret)
+}
+
+// NewQuoteFromAlpacaCtx - NewQuoteFromAlpaca, canceled with ctx. A download
+// canceled between its pages returns the bars so far with the wrapped error of
+// ctx.
+func NewQuoteFromAlpacaCtx(ctx context.Context, symbol, startDate, endDate string, period Period, keyID, secret string) (Quote, error) {
+
+	if err := ValidatePeriod("alpaca", period); err != nil {
+		Log.Printf("alpaca error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	from := ParseDateString(startDate)
+	to := parseEndDate(endDate)
+
+	quote := NewQuote(symbol, 0)
+	pageToken := ""
+	cut := false
+	for page := 0; ; page++ {
+		if page > 0 && delayContext(ctx, "alpaca") != nil {
+			return quote.withMeta("alpaca", period, false), interrupted(ctx, "alpaca %s after %d pages", symbol, page)
+		}
+		params := url.Values{}
+		params.Set("timeframe", alpacaTimeframes[period])
+		params.Set("start", from.Format(time.RFC3339))
+		params.Set("end", to.Format(time.RFC3339))
+		params.Set("limit", "10000")
+		if pageToken != "" {
+			params.Set("page_token", pageToken)
+		}
+		bars, status, err := alpacaPage(ctx, fmt.Sprintf("%s/v2/stocks/%s/bars?%s", alpacaURL, url.PathEscape(symbol), params.Encode()), keyID, secret)
+		if err != nil && ctx.Err() != nil {
+			return quote.withMeta("alpaca", period, false), interrupted(ctx, "alpaca %s after %d pages", symbol, page)
+		}
+		if err != nil {
+			Log.Printf("alpaca %s error: %v\n", symbol, err)
+			return NewQuote("", 0), err
+		}
+		if status == http.StatusForbidden && strings.Contains(bars.Message, "subscription does not permit") {
+			recent := time.Now().UTC().Add(-alpacaRecent).Truncate(time.Minute)
+			if cut || !to.After(recent) {
+				err := fmt.Errorf("alpaca %s: %s", symbol, bars.Message)
+				Log.Printf("%v\n", err)
+				return NewQuote("", 0), err
+			}
+			Log.Printf("alpaca %s: subscription does not permit the last %v, bars end at %s\n", symbol, alpacaRecent, recent.Format(time.RFC3339))
+			to, cut = recent, true
+			continue
+		}
+		if status != http.StatusOK {
+			err := fmt.Errorf("alpaca %s: %d %s", symbol, status, bars.Message)
+			Log.Printf("%v\n", err)
+			return NewQuote("", 0), err
+		}
+		for _, bar := range bars.Bars {
+			date := bar.T.UTC()
+			if period == Daily {
+				// midnight New York, the same date in UTC
+				date = date.Truncate(24 * time.Hour)
+			}
+			quote.PushBar(Bar{Date: date, Open: bar.O, High: bar.H, Low: bar.L, Close: bar.C, Volume: bar.V})
+		}
+		if pageToken = bars.NextPageToken; pageToken == "" {
+			break
+		}
+	}
+	return quote.withMeta("alpaca", period, false), nil
+}
+
+// alpacaPage - a bars page and its http status, the keys sent as headers,
+// canceled with ctx
+func alpacaPage(ctx context.Context, url, keyID, secret string) (alpacaBars, int, error) {
+
+	var bars alpacaBars
+	req, err := newRequest(ctx, url)
+	if err != nil {
+		return bars, 0, err
+	}
+	req.Header.Set("APCA-API-KEY-ID", keyID)
+	req.Header.Set("APCA-API-SECRET-KEY", secret)
+	if err := waitRate(ctx, "alpaca"); err != nil {
+		return bars, 0, err
+	}
+	client := httpClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return bars, 0, err
+	}
+	defer resp.Body.Close()
+	contents, _ := ioutil.ReadAll(resp.Body)
+
+	err = json.Unmarshal(contents, &bars)
+	if resp.StatusCode != http.StatusOK {
+		if bars.Message == "" {
+			bars.Message = strings.TrimSpace(string(contents))
+		}
+		return bars, resp.StatusCode, nil
+	}
+	return bars, resp.StatusCode, err
+}
+
+// NewQuotesFromAlpacaSyms - create a list of prices from symbols in string array
+func NewQuotesFromAlpacaSyms(symbols []string, startDate, endDate string, period Period, keyID, secret string) (Quotes, error) {
+	return NewQuotesFromAlpacaSymsCtx(context.Background(), symbols, startDate, endDate, period, keyID, secret)
+}
+
+// NewQuotesFromAlpacaSymsCtx - NewQuotesFromAlpacaSyms, canceled with ctx.
+// The quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromAlpacaSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period, keyID, secret string) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromAlpacaCtx(ctx, symbol, startDate, endDate, period, keyID, secret)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "alpaca after %d of %d symbols", i, len(symbols))
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + symbol)
+		}
+		delayContext(ctx, "alpaca")
+	}
+	return quotes, nil
+}
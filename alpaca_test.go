@@ -0,0 +1,56 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fakeAlpaca - bars endpoint serving two pages of daily bars linked by
+// page_token, refusing ranges ending within the last 15 minutes
+func fakeAlpaca(ends *[]time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		end, _ := time.Parse(time.RFC3339, r.URL.Query().Get("end"))
+		*ends = append(*ends, end)
+		switch {
+		case r.Header.Get("APCA-API-KEY-ID") != "id" || r.Header.Get("APCA-API-SECRET-KEY") != "secret":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"forbidden."}`)
+		case end.After(time.Now().Add(-alpacaRecent)):
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"subscription does not permit querying recent SIP data"}`)
+		case r.URL.Query().Get("page_token") == "":
+			fmt.Fprint(w, `{"bars":[
+{"t":"2023-03-01T05:00:00Z","o":146.83,"h":147.23,"l":145.01,"c":145.31,"v":55479000},
+{"t":"2023-03-02T05:00:00Z","o":144.38,"h":146.71,"l":143.9,"c":145.91,"v":52238100}],
+"symbol":"AAPL","next_page_token":"QUFQTHxEfDIwMjMtMDMtMDJUMDU6MDA6MDAuMDAwMDAwMDAwWg=="}`)
+		default:
+			fmt.Fprint(w, `{"bars":[
+{"t":"2023-03-03T05:00:00Z","o":148.04,"h":151.11,"l":147.33,"c":151.03,"v":70732300}],
+"symbol":"AAPL","next_page_token":null}`)
+		}
+	}
+}
+
+func TestAlpaca(t *testing.T) {
+	var ends []time.Time
+	fakeServer(t, &alpacaURL, fakeAlpaca(&ends))
+
+	q, err := NewQuoteFromAlpaca("AAPL", "2023-03-01", "2023-03-03", Daily, "id", "secret")
+	ok(t, err)
+	equals(t, 2, len(ends))
+	equals(t, []time.Time{day(2023, 3, 1), day(2023, 3, 2), day(2023, 3, 3)}, q.Date)
+	equals(t, []float64{145.31, 145.91, 151.03}, q.Close)
+
+	_, err = NewQuoteFromAlpaca("AAPL", "2023-03-01", "2023-03-03", Daily, "id", "wrong")
+	assert(t, err != nil, "wrong secret accepted")
+
+	// a range up to now is cut short of the last 15 minutes
+	ends = nil
+	q, err = NewQuoteFromAlpaca("AAPL", "2023-03-01", time.Now().UTC().Format("2006-01-02 15:04"), Min5, "id", "secret")
+	ok(t, err)
+	equals(t, 3, len(ends))
+	assert(t, !ends[1].After(time.Now().Add(-alpacaRecent)), "range not cut, ends %v", ends[1])
+	equals(t, 3, len(q.Date))
+}
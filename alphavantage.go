@@ -0,0 +1,195 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// alphaVantageURL - base url of the Alpha Vantage api
+var alphaVantageURL = "https://www.alphavantage.co"
+
+// alphaVantagePeriods - periods of the Alpha Vantage daily and intraday series
+var alphaVantagePeriods = []Period{Min1, Min5, Min15, Min30, Min60, Daily}
+
+// alphaVantageIntervals - intraday interval of each supported period, daily
+// bars come from their own function
+var alphaVantageIntervals = map[Period]string{
+	Min1:  "1min",
+	Min5:  "5min",
+	Min15: "15min",
+	Min30: "30min",
+	Min60: "60min",
+	Daily: "",
+}
+
+// AlphaVantageError - a message Alpha Vantage sent in place of data
+type AlphaVantageError struct {
+	Message   string
+	Throttled bool // the call frequency limit was hit, e.g. 5 calls a minute on free keys
+}
+
+func (e *AlphaVantageError) Error() string {
+	return "alphavantage: " + e.Message
+}
+
+// Temporary - true if the request may succeed when retried, after the
+// throttling window has passed
+func (e *AlphaVantageError) Temporary() bool {
+	return e.Throttled
+}
+
+// NewQuoteFromAlphaVantage - Alpha Vantage historical prices for a symbol,
+// daily bars with the provider's adjusted close in AdjClose or intraday bars
+// timestamped in US/Eastern wall clock time. The full history is requested
+// and cut to the date range. Throttled requests return an *AlphaVantageError
+// that is Temporary.
+func NewQuoteFromAlphaVantage(symbol, startDate, endDate string, period Period, apiKey string) (Quote, error) {
+	return NewQuoteFromAlphaVantageCtx(context.Background(), symbol, startDate, endDate, period, apiKey)
+}
+
+// NewQuoteFromAlphaVantageCtx - NewQuoteFromAlphaVantage, canceled with ctx
+func NewQuoteFromAlphaVantageCtx(ctx context.Context, symbol, startDate, endDate string, period Period, apiKey string) (Quote, error) {
+
+	if err := ValidatePeriod("alphavantage", period); err != nil {
+		Log.Printf("alphavantage error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("outputsize", "full")
+	params.Set("apikey", apiKey)
+	if period == Daily {
+		params.Set("function", "TIME_SERIES_DAILY_ADJUSTED")
+	} else {
+		params.Set("function", "TIME_SERIES_INTRADAY")
+		params.Set("interval", alphaVantageIntervals[period])
+	}
+
+	if err := waitRate(ctx, "alphavantage"); err != nil {
+		return NewQuote("", 0), err
+	}
+	client := httpClient()
+	resp, err := getContext(ctx, client, alphaVantageURL+"/query?"+params.Encode())
+	if err != nil {
+		Log.Printf("alphavantage error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return NewQuote("", 0), fmt.Errorf("alphavantage %s: %s", symbol, resp.Status)
+	}
+	contents, _ := ioutil.ReadAll(resp.Body)
+
+	quote, err := alphaVantageSeries(symbol, contents)
+	if err != nil {
+		Log.Printf("alphavantage %s error: %v\n", symbol, err)
+		return NewQuote("", 0), err
+	}
+	from := ParseDateString(startDate)
+	return quote.between(from, parseEndDate(endDate)).withMeta("alphavantage", period, false), nil
+}
+
+// alphaVantageSeries - the bars of an Alpha Vantage time series response in
+// date order. The series is an object keyed by timestamp, its values keyed
+// "1. open", "2. high" etc.
+func alphaVantageSeries(symbol string, contents []byte) (Quote, error) {
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(contents, &body); err != nil {
+		return NewQuote("", 0), err
+	}
+	for _, key := range []string{"Note", "Information", "Error Message"} {
+		var msg string
+		if json.Unmarshal(body[key], &msg) == nil && msg != "" {
+			// "Note" and "Information" report the call frequency limit
+			return NewQuote("", 0), &AlphaVantageError{Message: msg, Throttled: key != "Error Message"}
+		}
+	}
+
+	var series map[string]map[string]string
+	for key, raw := range body {
+		if strings.HasPrefix(key, "Time Series") {
+			if err := json.Unmarshal(raw, &series); err != nil {
+				return NewQuote("", 0), err
+			}
+		}
+	}
+	if series == nil {
+		return NewQuote("", 0), &AlphaVantageError{Message: "no time series in response"}
+	}
+
+	// timestamps sort chronologically as strings
+	stamps := make([]string, 0, len(series))
+	for stamp := range series {
+		stamps = append(stamps, stamp)
+	}
+	sort.Strings(stamps)
+
+	quote := NewQuote(symbol, len(stamps))
+	for bar, stamp := range stamps {
+		layout := "2006-01-02 15:04:05"
+		if len(stamp) == len("2006-01-02") {
+			layout = "2006-01-02"
+		}
+		date, err := time.Parse(layout, stamp)
+		if err != nil {
+			return NewQuote("", 0), err
+		}
+		quote.Date[bar] = date
+		for key, value := range series[stamp] {
+			// "1. open" is open
+			name := key[strings.Index(key, " ")+1:]
+			v, _ := strconv.ParseFloat(value, 64)
+			switch name {
+			case "open":
+				quote.Open[bar] = v
+			case "high":
+				quote.High[bar] = v
+			case "low":
+				quote.Low[bar] = v
+			case "close":
+				quote.Close[bar] = v
+			case "volume":
+				quote.Volume[bar] = v
+			case "adjusted close":
+				quote.setExtra(&quote.AdjClose, bar, v)
+			}
+		}
+	}
+	return quote, nil
+}
+
+// NewQuotesFromAlphaVantageSyms - create a list of prices from symbols in string array
+func NewQuotesFromAlphaVantageSyms(symbols []string, startDate, endDate string, period Period, apiKey string) (Quotes, error) {
+	return NewQuotesFromAlphaVantageSymsCtx(context.Background(), symbols, startDate, endDate, period, apiKey)
+}
+
+// NewQuotesFromAlphaVantageSymsCtx - NewQuotesFromAlphaVantageSyms,
+// canceled with ctx. The quotes downloaded before are returned with the
+// wrapped error of ctx.
+func NewQuotesFromAlphaVantageSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period, apiKey string) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromAlphaVantageCtx(ctx, symbol, startDate, endDate, period, apiKey)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "alphavantage after %d of %d symbols", i, len(symbols))
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + symbol)
+		}
+		delayContext(ctx, "alphavantage")
+	}
+	return quotes, nil
+}
@@ -0,0 +1,72 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// alphaVantageDaily - TIME_SERIES_DAILY_ADJUSTED response, keys out of order
+// as json objects give no order
+const alphaVantageDaily = `{
+"Meta Data": {"1. Information": "Daily Time Series with Splits and Dividend Events", "2. Symbol": "IBM", "5. Time Zone": "US/Eastern"},
+"Time Series (Daily)": {
+"2023-03-03": {"1. open": "128.8", "2. high": "129.0", "3. low": "127.8", "4. close": "129.64", "5. adjusted close": "125.1", "6. volume": "3500000", "7. dividend amount": "0.0000", "8. split coefficient": "1.0"},
+"2023-03-01": {"1. open": "128.9", "2. high": "129.5", "3. low": "127.2", "4. close": "128.19", "5. adjusted close": "123.7", "6. volume": "3700000", "7. dividend amount": "0.0000", "8. split coefficient": "1.0"},
+"2023-02-28": {"1. open": "130.6", "2. high": "130.9", "3. low": "128.1", "4. close": "129.3", "5. adjusted close": "124.8", "6. volume": "5000000", "7. dividend amount": "0.0000", "8. split coefficient": "1.0"},
+"2023-03-02": {"1. open": "127.9", "2. high": "128.5", "3. low": "127.3", "4. close": "128.93", "5. adjusted close": "124.4", "6. volume": "3300000", "7. dividend amount": "0.0000", "8. split coefficient": "1.0"}
+}}`
+
+// alphaVantageIntraday - TIME_SERIES_INTRADAY 5min response
+const alphaVantageIntraday = `{
+"Meta Data": {"1. Information": "Intraday (5min) open, high, low, close prices and volume", "4. Interval": "5min", "6. Time Zone": "US/Eastern"},
+"Time Series (5min)": {
+"2023-03-01 09:40:00": {"1. open": "128.5", "2. high": "128.7", "3. low": "128.4", "4. close": "128.6", "5. volume": "9000"},
+"2023-03-01 09:35:00": {"1. open": "128.9", "2. high": "129.0", "3. low": "128.4", "4. close": "128.5", "5. volume": "12000"}
+}}`
+
+func TestAlphaVantage(t *testing.T) {
+	var function, interval string
+	fakeServer(t, &alphaVantageURL, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		function, interval = q.Get("function"), q.Get("interval")
+		switch {
+		case q.Get("apikey") == "throttled":
+			fmt.Fprint(w, `{"Note": "Thank you for using Alpha Vantage! Our standard API call frequency is 5 calls per minute and 500 calls per day."}`)
+		case q.Get("symbol") == "NOPE":
+			fmt.Fprint(w, `{"Error Message": "Invalid API call. Please retry or visit the documentation for TIME_SERIES_DAILY_ADJUSTED."}`)
+		case function == "TIME_SERIES_INTRADAY":
+			fmt.Fprint(w, alphaVantageIntraday)
+		default:
+			fmt.Fprint(w, alphaVantageDaily)
+		}
+	})
+
+	q, err := NewQuoteFromAlphaVantage("IBM", "2023-03-01", "2023-03-02", Daily, "key")
+	ok(t, err)
+	equals(t, "TIME_SERIES_DAILY_ADJUSTED", function)
+	equals(t, []time.Time{day(2023, 3, 1), day(2023, 3, 2)}, q.Date)
+	equals(t, []float64{128.19, 128.93}, q.Close)
+	equals(t, []float64{123.7, 124.4}, q.AdjClose)
+	equals(t, []float64{3700000, 3300000}, q.Volume)
+
+	q, err = NewQuoteFromAlphaVantage("IBM", "2023-03-01", "2023-03-01", Min5, "key")
+	ok(t, err)
+	equals(t, "TIME_SERIES_INTRADAY", function)
+	equals(t, "5min", interval)
+	equals(t, []time.Time{time.Date(2023, 3, 1, 9, 35, 0, 0, time.UTC), time.Date(2023, 3, 1, 9, 40, 0, 0, time.UTC)}, q.Date)
+	equals(t, []float64{128.9, 128.5}, q.Open)
+	equals(t, 0, len(q.AdjClose))
+
+	_, err = NewQuoteFromAlphaVantage("IBM", "2023-03-01", "2023-03-02", Daily, "throttled")
+	avErr, isAV := err.(*AlphaVantageError)
+	assert(t, isAV && avErr.Temporary(), "expected a temporary error, got %v", err)
+
+	_, err = NewQuoteFromAlphaVantage("NOPE", "2023-03-01", "2023-03-02", Daily, "key")
+	avErr, isAV = err.(*AlphaVantageError)
+	assert(t, isAV && !avErr.Temporary(), "expected a permanent error, got %v", err)
+
+	_, err = NewQuoteFromAlphaVantage("IBM", "2023-03-01", "2023-03-02", Weekly, "key")
+	assert(t, err != nil, "expected invalid period error")
+}
@@ -0,0 +1,133 @@
+package quote
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// amibrokerHeader - format line of the Amibroker ASCII importer for the
+// columns written by Amibroker
+const amibrokerHeader = "$FORMAT Ticker,Date_YMD,Time,Open,High,Low,Close,Volume"
+
+// AmibrokerOptions - layout of Amibroker ASCII import files
+type AmibrokerOptions struct {
+	Header    bool // start with the $FORMAT line describing the columns
+	Precision int  // decimals of prices and volume, FullPrecision, or as Quote.CSV when 0
+}
+
+// DefaultAmibrokerOptions - layout written by Amibroker, without a header
+var DefaultAmibrokerOptions = AmibrokerOptions{}
+
+// Amibroker - convert Quote structure to Amibroker ASCII import format,
+// Ticker,YYYYMMDD,HHMMSS,Open,High,Low,Close,Volume. The time of daily bars
+// is 000000.
+func (q Quote) Amibroker() string {
+	return q.AmibrokerWithOptions(DefaultAmibrokerOptions)
+}
+
+// AmibrokerWithOptions - convert Quote structure to Amibroker ASCII import
+// format laid out as opts
+func (q Quote) AmibrokerWithOptions(opts AmibrokerOptions) string {
+	return Quotes{q}.AmibrokerWithOptions(opts)
+}
+
+// WriteAmibroker - write Quote struct to Amibroker ASCII import file
+func (q Quote) WriteAmibroker(filename string) error {
+	if filename == "" {
+		if q.Symbol != "" {
+			filename = q.Symbol + ".csv"
+		} else {
+			filename = "quote.csv"
+		}
+	}
+	csv := q.Amibroker()
+	return writeFile(filename, []byte(csv))
+}
+
+// Amibroker - convert Quotes structure to Amibroker ASCII import format, the
+// ticker of each row is the symbol of its quote
+func (q Quotes) Amibroker() string {
+	return q.AmibrokerWithOptions(DefaultAmibrokerOptions)
+}
+
+// AmibrokerWithOptions - convert Quotes structure to Amibroker ASCII import
+// format laid out as opts
+func (q Quotes) AmibrokerWithOptions(opts AmibrokerOptions) string {
+
+	var buffer bytes.Buffer
+	if opts.Header {
+		buffer.WriteString(amibrokerHeader + "\n")
+	}
+
+	dates := newDateCache("20060102")
+	for sym := 0; sym < len(q); sym++ {
+		quote := q[sym]
+		precision := quote.precision(opts.Precision)
+		intraday := quote.intraday()
+		for bar := range quote.Close {
+			tod := "000000"
+			if intraday {
+				tod = quote.Date[bar].Format("150405")
+			}
+			buffer.WriteString(quote.Symbol + "," + dates.format(quote.Date[bar]) + "," + tod)
+			for _, v := range []float64{quote.Open[bar], quote.High[bar], quote.Low[bar], quote.Close[bar], quote.Volume[bar]} {
+				buffer.WriteString("," + strconv.FormatFloat(v, 'f', precision, 64))
+			}
+			buffer.WriteString("\n")
+		}
+	}
+
+	return buffer.String()
+}
+
+// WriteAmibroker - write Quotes structure to Amibroker ASCII import file
+func (q Quotes) WriteAmibroker(filename string) error {
+	if filename == "" {
+		filename = "quotes.csv"
+	}
+	csv := q.Amibroker()
+	ba := []byte(csv)
+	return writeFile(filename, ba)
+}
+
+// NewQuotesFromAmibroker - parse Amibroker ASCII import data, with or without
+// the $FORMAT header, into Quotes array in order of first appearance. Dates
+// are UTC.
+func NewQuotesFromAmibroker(ami string) (Quotes, error) {
+
+	quotes := Quotes{}
+	index := map[string]int{}
+	for n, line := range strings.Split(ami, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "$") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != 8 {
+			return quotes, fmt.Errorf("line %d: %d fields, want 8", n+1, len(fields))
+		}
+		date, err := time.Parse("20060102150405", fields[1]+fields[2])
+		if err != nil {
+			return quotes, fmt.Errorf("line %d: %v", n+1, err)
+		}
+		var ohlcv [5]float64
+		for i := range ohlcv {
+			if ohlcv[i], err = strconv.ParseFloat(fields[3+i], 64); err != nil {
+				return quotes, fmt.Errorf("line %d: %v", n+1, err)
+			}
+		}
+
+		sym := fields[0]
+		i, ok := index[sym]
+		if !ok {
+			i = len(quotes)
+			index[sym] = i
+			quotes = append(quotes, NewQuote(sym, 0))
+		}
+		quotes[i].PushBar(Bar{Date: date, Open: ohlcv[0], High: ohlcv[1], Low: ohlcv[2], Close: ohlcv[3], Volume: ohlcv[4]})
+	}
+	return quotes, nil
+}
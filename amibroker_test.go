@@ -0,0 +1,58 @@
+package quote
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// amibrokerIntraday - five minute bars of btcusd across midnight
+func amibrokerIntraday() Quote {
+	q := NewQuote("btcusd", 0)
+	for i := 0; i < 4; i++ {
+		d := time.Date(2023, 3, 1, 23, 50, 30, 0, time.UTC).Add(time.Duration(i) * 5 * time.Minute)
+		q.PushBar(Bar{Date: d, Open: 100.5 + float64(i), High: 102.25 + float64(i), Low: 99.125, Close: 101 + float64(i), Volume: 12.5 * float64(i+1)})
+	}
+	return q
+}
+
+func TestAmibroker(t *testing.T) {
+	q := amibrokerIntraday()
+	equals(t, "btcusd,20230301,235030,100.50000000,102.25000000,99.12500000,101.00000000,12.50000000\n"+
+		"btcusd,20230301,235530,101.50000000,103.25000000,99.12500000,102.00000000,25.00000000\n"+
+		"btcusd,20230302,000030,102.50000000,104.25000000,99.12500000,103.00000000,37.50000000\n"+
+		"btcusd,20230302,000530,103.50000000,105.25000000,99.12500000,104.00000000,50.00000000\n", q.Amibroker())
+
+	lines := strings.Split(q.AmibrokerWithOptions(AmibrokerOptions{Header: true}), "\n")
+	equals(t, "$FORMAT Ticker,Date_YMD,Time,Open,High,Low,Close,Volume", lines[0])
+	equals(t, 6, len(lines))
+
+	daily := NewQuote("spy", 0)
+	daily.PushBar(Bar{Date: day(2023, 3, 1), Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 100})
+	equals(t, "spy,20230301,000000,1.00,2.00,0.50,1.50,100.00\n", daily.Amibroker())
+	equals(t, "spy,20230301,000000,1,2,0.5,1.5,100\n", daily.AmibrokerWithOptions(AmibrokerOptions{Precision: FullPrecision}))
+}
+
+func TestAmibrokerRoundTrip(t *testing.T) {
+	intraday := amibrokerIntraday()
+	daily := NewQuote("spy", 0)
+	for i := 0; i < 3; i++ {
+		daily.PushBar(Bar{Date: day(2023, 3, 1+i), Open: 1, High: 2, Low: 0.5, Close: 1.5 + float64(i), Volume: 100})
+	}
+
+	for _, opts := range []AmibrokerOptions{DefaultAmibrokerOptions, {Header: true}} {
+		quotes, err := NewQuotesFromAmibroker(intraday.AmibrokerWithOptions(opts))
+		ok(t, err)
+		equals(t, 1, len(quotes))
+		equals(t, intraday, quotes[0])
+
+		quotes, err = NewQuotesFromAmibroker(Quotes{intraday, daily}.AmibrokerWithOptions(opts))
+		ok(t, err)
+		equals(t, Quotes{intraday, daily}, quotes)
+	}
+
+	_, err := NewQuotesFromAmibroker("spy,20230301,000000,1,2,0.5,1.5\n")
+	assert(t, err != nil, "expected field count error")
+	_, err = NewQuotesFromAmibroker("spy,2023-03-01,000000,1,2,0.5,1.5,100\n")
+	assert(t, err != nil, "expected date error")
+}
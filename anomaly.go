@@ -0,0 +1,147 @@
+package quote
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Gap - a run of missing bars between two consecutive bars of a quote
+type Gap struct {
+	After    int       // index of the bar before the gap
+	From, To time.Time // first and last missing timestamp
+	Missing  int       // bars missing
+}
+
+// Jump - a bar whose close moved from the previous close by more than the
+// threshold of AnomalyOptions
+type Jump struct {
+	Bar    int
+	Date   time.Time
+	Return float64 // close to close return, e.g. 99 for a 100x tick
+}
+
+// Duplicate - a bar repeating the timestamp of an earlier bar
+type Duplicate struct {
+	Bar  int
+	Date time.Time
+}
+
+// BarRun - consecutive bars [Start,End) of a quote
+type BarRun struct {
+	Start, End int
+}
+
+// Anomalies - bars of a quote to inspect before trusting it, by index
+type Anomalies struct {
+	Symbol     string
+	Gaps       []Gap
+	Jumps      []Jump
+	ZeroVolume []BarRun // runs of at least MinZeroVolume bars without volume
+	Duplicates []Duplicate
+}
+
+// AnomalyOptions - options of AnomaliesWithOptions
+type AnomalyOptions struct {
+	JumpThreshold float64   // absolute close to close return reported as a jump
+	MinZeroVolume int       // shortest run of zero volume bars reported
+	Calendar      *Calendar // bars expected, nil for the calendar of the source in q's metadata
+}
+
+// DefaultAnomalyOptions - options of Anomalies, reporting moves of more than
+// FatFingerReturn and zero volume for three bars or more
+var DefaultAnomalyOptions = AnomalyOptions{JumpThreshold: FatFingerReturn, MinZeroVolume: 3}
+
+// Empty - true if a has no findings
+func (a Anomalies) Empty() bool {
+	return len(a.Gaps) == 0 && len(a.Jumps) == 0 && len(a.ZeroVolume) == 0 && len(a.Duplicates) == 0
+}
+
+// Anomalies - gaps of period, jumps, zero volume runs and duplicate
+// timestamps of q, see AnomaliesWithOptions for the defaults. Dates are
+// assumed to be ascending. An empty period is taken from q's metadata or
+// inferred from its bars.
+func (q Quote) Anomalies(period Period) Anomalies {
+	return q.AnomaliesWithOptions(period, DefaultAnomalyOptions)
+}
+
+// AnomaliesWithOptions - Anomalies with the threshold, run length and
+// calendar of opts. Missing bars are counted as by MissingBars. Jumps are
+// not reported from or to a zero or negative close.
+func (q Quote) AnomaliesWithOptions(period Period, opts AnomalyOptions) Anomalies {
+	a := Anomalies{Symbol: q.Symbol}
+	for _, t := range q.MissingBars(period, q.calendarOrSource(opts.Calendar)) {
+		after := sort.Search(len(q.Date), func(i int) bool { return q.Date[i].After(t) }) - 1
+		if n := len(a.Gaps); n > 0 && a.Gaps[n-1].After == after {
+			a.Gaps[n-1].To = t
+			a.Gaps[n-1].Missing++
+			continue
+		}
+		a.Gaps = append(a.Gaps, Gap{After: after, From: t, To: t, Missing: 1})
+	}
+
+	seen := make(map[time.Time]bool, len(q.Date))
+	zeros := 0
+	for bar := range q.Date {
+		if bar > 0 && q.Close[bar-1] > 0 && q.Close[bar] > 0 {
+			if r := q.Close[bar]/q.Close[bar-1] - 1; math.Abs(r) > opts.JumpThreshold {
+				a.Jumps = append(a.Jumps, Jump{Bar: bar, Date: q.Date[bar], Return: r})
+			}
+		}
+		if d := q.Date[bar].UTC(); seen[d] {
+			a.Duplicates = append(a.Duplicates, Duplicate{Bar: bar, Date: q.Date[bar]})
+		} else {
+			seen[d] = true
+		}
+		if q.Volume[bar] == 0 {
+			zeros++
+		}
+		if zeros > 0 && (q.Volume[bar] != 0 || bar == len(q.Date)-1) {
+			end := bar
+			if q.Volume[bar] == 0 {
+				end++
+			}
+			if zeros >= opts.MinZeroVolume {
+				a.ZeroVolume = append(a.ZeroVolume, BarRun{Start: end - zeros, End: end})
+			}
+			zeros = 0
+		}
+	}
+	return a
+}
+
+// Anomalies - the Anomalies of each member of q with findings, in order
+func (q Quotes) Anomalies(period Period) []Anomalies {
+	return q.AnomaliesWithOptions(period, DefaultAnomalyOptions)
+}
+
+// AnomaliesWithOptions - Anomalies with the options of opts
+func (q Quotes) AnomaliesWithOptions(period Period, opts AnomalyOptions) []Anomalies {
+	var found []Anomalies
+	for _, quote := range q {
+		if a := quote.AnomaliesWithOptions(period, opts); !a.Empty() {
+			found = append(found, a)
+		}
+	}
+	return found
+}
+
+// String - render a as a line per finding
+func (a Anomalies) String() string {
+	var buffer bytes.Buffer
+	for _, g := range a.Gaps {
+		fmt.Fprintf(&buffer, "%s: %d missing bars after bar %d, %s to %s\n", a.Symbol, g.Missing, g.After, formatQualityDate(g.From), formatQualityDate(g.To))
+	}
+	for _, j := range a.Jumps {
+		fmt.Fprintf(&buffer, "%s: bar %d (%s) close moved %+.1f%% from the previous close\n", a.Symbol, j.Bar, formatQualityDate(j.Date), 100*j.Return)
+	}
+	for _, r := range a.ZeroVolume {
+		fmt.Fprintf(&buffer, "%s: bars %d to %d zero volume\n", a.Symbol, r.Start, r.End-1)
+	}
+	for _, d := range a.Duplicates {
+		fmt.Fprintf(&buffer, "%s: bar %d (%s) repeats an earlier timestamp\n", a.Symbol, d.Bar, formatQualityDate(d.Date))
+	}
+	return buffer.String()
+}
@@ -0,0 +1,63 @@
+package quote
+
+import (
+	"strings"
+	"testing"
+)
+
+// anomalyFixture - daily bars from 2023-01-02 without the 6th and 7th, a
+// 100x tick on the 4th, zero volume from the 5th to the 9th and the 10th twice
+func anomalyFixture() Quote {
+	full := closesFixture(10, 11, 1100, 11, 12, 12, 12, 13, 13)
+	q := NewQuote("spy", 0)
+	q.Meta = full.Meta
+	for _, bar := range []int{0, 1, 2, 3, 6, 7, 8, 8} {
+		q.appendBar(full, bar)
+	}
+	q.Volume = []float64{1, 1, 1, 0, 0, 0, 1, 0}
+	return q
+}
+
+func TestAnomalies(t *testing.T) {
+	q := anomalyFixture()
+
+	a := q.Anomalies("")
+	equals(t, "spy", a.Symbol)
+	equals(t, []Gap{{After: 3, From: day(2023, 1, 6), To: day(2023, 1, 7), Missing: 2}}, a.Gaps)
+	equals(t, []Jump{{Bar: 2, Date: day(2023, 1, 4), Return: 99}, {Bar: 3, Date: day(2023, 1, 5), Return: -0.99}}, a.Jumps)
+	equals(t, []BarRun{{Start: 3, End: 6}}, a.ZeroVolume)
+	equals(t, []Duplicate{{Bar: 7, Date: day(2023, 1, 10)}}, a.Duplicates)
+	assert(t, !a.Empty(), "anomalies empty")
+
+	lines := strings.Split(strings.TrimSuffix(a.String(), "\n"), "\n")
+	equals(t, []string{
+		"spy: 2 missing bars after bar 3, 2023-01-06 00:00 to 2023-01-07 00:00",
+		"spy: bar 2 (2023-01-04 00:00) close moved +9900.0% from the previous close",
+		"spy: bar 3 (2023-01-05 00:00) close moved -99.0% from the previous close",
+		"spy: bars 3 to 5 zero volume",
+		"spy: bar 7 (2023-01-10 00:00) repeats an earlier timestamp",
+	}, lines)
+
+	// the weekend is not missing on weekdays, single zero volume bars count
+	a = q.AnomaliesWithOptions(Daily, AnomalyOptions{JumpThreshold: 100, MinZeroVolume: 1, Calendar: &WeekdaysOnly})
+	equals(t, []Gap{{After: 3, From: day(2023, 1, 6), To: day(2023, 1, 6), Missing: 1}}, a.Gaps)
+	equals(t, 0, len(a.Jumps))
+	equals(t, []BarRun{{Start: 3, End: 6}, {Start: 7, End: 8}}, a.ZeroVolume)
+
+	// zero closes are not jumps
+	z := closesFixture(0, 10, 0, 10)
+	equals(t, 0, len(z.Anomalies("").Jumps))
+
+	clean := closesFixture(10, 11, 12)
+	assert(t, clean.Anomalies("").Empty(), "clean quote has anomalies: %s", clean.Anomalies(""))
+	assert(t, NewQuote("spy", 0).Anomalies("").Empty(), "empty quote has anomalies")
+}
+
+func TestQuotesAnomalies(t *testing.T) {
+	clean := closesFixture(10, 11, 12)
+	clean.Symbol = "qqq"
+	found := Quotes{clean, anomalyFixture()}.Anomalies("")
+	equals(t, 1, len(found))
+	equals(t, "spy", found[0].Symbol)
+	equals(t, 0, len(Quotes{clean}.Anomalies("")))
+}
@@ -0,0 +1,133 @@
+package quote
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// csvLastDates - the last date of each symbol of csv rows, "" for single
+// symbol csv
+func csvLastDates(rows *csvRows, cols csvColumns, opts CSVOptions) (map[string]time.Time, error) {
+	last := map[string]time.Time{}
+	for {
+		fields, row, err := rows.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) < cols.width {
+			continue
+		}
+		d, err := opts.parseDate(fields[cols.date])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: bad date '%s'", row, fields[cols.date])
+		}
+		sym := ""
+		if cols.symbol >= 0 {
+			sym = fields[cols.symbol]
+		}
+		if d.After(last[sym]) {
+			last[sym] = d
+		}
+	}
+	return last, nil
+}
+
+// barsAfter - the bars of q after last. Bars overlapping last must include
+// one at last, else q does not continue the bars up to last.
+func (q Quote) barsAfter(last time.Time) (Quote, error) {
+	i := sort.Search(len(q.Date), func(i int) bool { return q.Date[i].After(last) })
+	if i > 0 && !q.Date[i-1].Equal(last) {
+		return q, fmt.Errorf("%s bars from %s do not continue the last bar at %s",
+			q.Symbol, q.Date[0].Format(time.RFC3339), last.Format(time.RFC3339))
+	}
+	return q.Last(len(q.Date) - i), nil
+}
+
+// readLastDates - the header and last date of each symbol of csv read from r
+func readLastDates(r io.Reader, symbol bool, opts CSVOptions) ([]string, map[string]time.Time, error) {
+	rows, err := opts.readCSV(r)
+	if err != nil || rows.header == nil {
+		return nil, nil, err
+	}
+	cols, err := opts.columns(rows.header, symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+	last, err := csvLastDates(rows, cols, opts)
+	return rows.header, last, err
+}
+
+// appendCSV - append quotes to the csv file filename in the columns of its
+// header, creating it if it does not exist or is empty
+func appendCSV(filename string, quotes Quotes, symbol bool) error {
+
+	opts := DefaultCSVOptions
+	var header []string
+	last := map[string]time.Time{}
+	f, err := openFile(filename)
+	if err == nil {
+		header, last, err = readLastDates(f, symbol, opts)
+		f.Close()
+	}
+	// a missing file, or an empty one that is not even a gzip stream
+	if err != nil && !os.IsNotExist(err) && err != io.EOF {
+		return fmt.Errorf("%s %v", filename, err)
+	}
+	if header == nil {
+		return appendStream(filename, func(w io.Writer) error {
+			return writeCSVQuotes(w, quotes, symbol, opts)
+		})
+	}
+
+	columns := make([]string, len(header))
+	for i, name := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+
+	after := make(Quotes, len(quotes))
+	for i, q := range quotes {
+		sym := ""
+		if symbol {
+			sym = q.Symbol
+		}
+		after[i], err = q.barsAfter(last[sym])
+		if err != nil {
+			return err
+		}
+	}
+	return appendStream(filename, func(w io.Writer) error {
+		return writeCSVRows(w, after, columns, opts, false)
+	})
+}
+
+// AppendCSV - append the bars of q after the last bar of the csv file
+// filename, e.g. the latest bars of a nightly download, in the columns of its
+// header. The file is created with a header if it does not exist. Bars
+// overlapping the file must include one at its last date, else nothing is
+// written and an error returned.
+func (q Quote) AppendCSV(filename string) error {
+	if filename == "" {
+		if q.Symbol != "" {
+			filename = q.Symbol + ".csv"
+		} else {
+			filename = "quote.csv"
+		}
+	}
+	return appendCSV(filename, Quotes{q}, false)
+}
+
+// AppendCSV - append the bars of each quote after the last bar of its symbol
+// in the multi-symbol csv file filename, as Quote.AppendCSV
+func (q Quotes) AppendCSV(filename string) error {
+	if filename == "" {
+		filename = "quotes.csv"
+	}
+	return appendCSV(filename, q, true)
+}
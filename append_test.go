@@ -0,0 +1,74 @@
+package quote
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendCSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "append")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	aapl := csvLayoutFixture()
+	name := filepath.Join(dir, "aapl.csv")
+
+	// a missing or empty file is created with a header
+	ok(t, aapl.slice(0, 1).AppendCSV(name))
+	_, err = os.Stat(name)
+	ok(t, err)
+	ok(t, ioutil.WriteFile(name, nil, 0644))
+	ok(t, aapl.slice(0, 2).AppendCSV(name))
+	back, err := NewQuoteFromCSVFile("aapl", name)
+	ok(t, err)
+	equals(t, aapl.slice(0, 2), back)
+
+	// only bars after the last are appended
+	ok(t, aapl.slice(1, 3).AppendCSV(name))
+	ok(t, aapl.AppendCSV(name))
+	csv, err := ioutil.ReadFile(name)
+	ok(t, err)
+	equals(t, aapl.CSV(), string(csv))
+
+	// a gap is appended as is
+	later := aapl.slice(2, 3)
+	later.Date[0] = later.Date[0].AddDate(0, 0, 7)
+	ok(t, later.AppendCSV(name))
+	back, err = NewQuoteFromCSVFile("aapl", name)
+	ok(t, err)
+	equals(t, 4, len(back.Date))
+	equals(t, later.Date[0], back.Date[3])
+
+	// overlapping bars that miss the last bar are refused
+	shifted := aapl.slice(0, 3)
+	for i := range shifted.Date {
+		shifted.Date[i] = shifted.Date[i].AddDate(0, 0, 8).Add(12 * time.Hour)
+	}
+	err = shifted.AppendCSV(name)
+	assert(t, err != nil, "misaligned bars appended")
+	back, err = NewQuoteFromCSVFile("aapl", name)
+	ok(t, err)
+	equals(t, 4, len(back.Date))
+}
+
+func TestQuotesAppendCSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "append")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	quotes := partitionFixture()
+	name := filepath.Join(dir, "quotes.csv.gz")
+	first := Quotes{quotes[0].slice(0, 2)}
+	ok(t, first.AppendCSV(name))
+
+	// appended symbols keep the columns of the file, new symbols start at their first bar
+	quotes[1].Trades = []float64{7}
+	ok(t, quotes.AppendCSV(name))
+	back, err := NewQuotesFromCSVFile(name)
+	ok(t, err)
+	quotes[1].Trades = nil
+	equals(t, quotes.CSV(), back.CSV())
+}
@@ -0,0 +1,120 @@
+package quote
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// gapRange - a run of missing bars between two consecutive bars of a quote
+type gapRange struct {
+	from, to      time.Time // first and last missing timestamp
+	after, before time.Time // the bars on either side
+}
+
+// gapRanges - missing bars of q grouped by the pair of bars they fall between
+func (q Quote) gapRanges(period Period, cal Calendar) []gapRange {
+	var ranges []gapRange
+	for _, t := range q.gaps(period, cal) {
+		next := sort.Search(len(q.Date), func(i int) bool { return q.Date[i].After(t) })
+		n := len(ranges)
+		if n > 0 && ranges[n-1].before.Equal(q.Date[next]) {
+			ranges[n-1].to = t
+			continue
+		}
+		ranges = append(ranges, gapRange{from: t, to: t, after: q.Date[next-1], before: q.Date[next]})
+	}
+	return ranges
+}
+
+// backfillEmpty - gap ranges a source had no data for, remembered for the
+// rest of the run so they are not requested again
+var backfillEmpty = struct {
+	sync.Mutex
+	keys map[string]bool
+}{keys: make(map[string]bool)}
+
+// backfillKey - backfillEmpty key of a gap range
+func backfillKey(source SourceSpec, symbol string, period Period, r gapRange) string {
+	return fmt.Sprintf("%s|%s|%s|%d|%d", source.Name, symbol, period, r.from.Unix(), r.to.Unix())
+}
+
+// mergeBars - q with the bars of fill whose timestamps are absent from q
+// inserted in date order, and the number of bars inserted. Both quotes must
+// be in ascending date order.
+func mergeBars(q, fill Quote) (Quote, int) {
+	out := Quote{Symbol: q.Symbol, Precision: q.Precision, Meta: q.Meta}
+	added := 0
+	i, j := 0, 0
+	for i < len(q.Date) || j < len(fill.Date) {
+		switch {
+		case j == len(fill.Date) || i < len(q.Date) && q.Date[i].Before(fill.Date[j]):
+			out.appendBar(q, i)
+			i++
+		case i < len(q.Date) && q.Date[i].Equal(fill.Date[j]):
+			j++
+		default:
+			if n := len(out.Date); n == 0 || out.Date[n-1].Before(fill.Date[j]) {
+				out.appendBar(fill, j)
+				added++
+			}
+			j++
+		}
+	}
+	return out, added
+}
+
+// BackfillGaps - fill the bars missing from q, as found by gap detection,
+// from source. Only the missing sub-ranges are requested, one request per run
+// of consecutive missing bars with Delay between requests. Returns the merged
+// quote and the number of bars added.
+//
+// Missing bars are counted against period on a weekday calendar for yahoo and
+// tiingo and every day for crypto sources. Ranges the source has no data for,
+// e.g. exchange holidays, are remembered and not requested again by later
+// calls in the same process. On error the bars merged so far are returned.
+// An empty period is taken from q's metadata or inferred from its bars.
+func BackfillGaps(ctx context.Context, q Quote, source SourceSpec, period Period) (Quote, int, error) {
+
+	period = q.periodOrInfer(period)
+	if err := ValidatePeriod(source.Name, period); err != nil {
+		return q, 0, err
+	}
+
+	fill := Quote{Symbol: q.Symbol}
+	requested := false
+	var err error
+	for _, r := range q.gapRanges(period, source.Calendar()) {
+		key := backfillKey(source, q.Symbol, period, r)
+		backfillEmpty.Lock()
+		empty := backfillEmpty.keys[key]
+		backfillEmpty.Unlock()
+		if empty {
+			continue
+		}
+
+		if requested {
+			delay(source.Name)
+		}
+		requested = true
+		var got Quote
+		got, err = NewQuoteFromSource(ctx, source, q.Symbol, r.from, r.to, period)
+		if err != nil {
+			Log.Printf("%s backfill %s to %s: %v\n", q.Symbol, r.from.Format("2006-01-02 15:04"), r.to.Format("2006-01-02 15:04"), err)
+			break
+		}
+		got = got.between(r.after.Add(time.Nanosecond), r.before)
+		if len(got.Date) == 0 {
+			backfillEmpty.Lock()
+			backfillEmpty.keys[key] = true
+			backfillEmpty.Unlock()
+			continue
+		}
+		fill.appendQuote(got)
+	}
+
+	merged, added := mergeBars(q, fill)
+	return merged, added, err
+}
@@ -0,0 +1,89 @@
+package quote
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fakeTiingoCryptoHoles - fakeTiingoCrypto that has no data for requests
+// starting on one of the dead days
+func fakeTiingoCryptoHoles(first, last time.Time, dead []string, requests *int) http.HandlerFunc {
+	inner := fakeTiingoCrypto(first, last, 100000, requests)
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, d := range dead {
+			if r.URL.Query().Get("startDate") == d {
+				*requests++
+				w.Write([]byte(`[{"ticker":"btcusd","priceData":[]}]`))
+				return
+			}
+		}
+		inner(w, r)
+	}
+}
+
+// withoutBars - q without the bars dated from <= date < to
+func withoutBars(q Quote, from, to time.Time) Quote {
+	out := Quote{Symbol: q.Symbol, Precision: q.Precision}
+	for bar, d := range q.Date {
+		if d.Before(from) || !d.Before(to) {
+			out.appendBar(q, bar)
+		}
+	}
+	return out
+}
+
+func TestBackfillGaps(t *testing.T) {
+	first := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(2023, 1, 10, 23, 55, 0, 0, time.UTC)
+	requests := 0
+	fakeServer(t, &tiingoURL, fakeTiingoCryptoHoles(first, last, []string{"2023-1-7"}, &requests))
+
+	full, err := NewQuoteFromTiingoCrypto("btcusd", "2023-01-01", "2023-01-10", Min5, "token")
+	ok(t, err)
+
+	// three two-day holes, the source has no data for the third
+	q := full
+	holes := []time.Time{day(2023, 1, 2), day(2023, 1, 4).Add(6 * time.Hour), day(2023, 1, 7).Add(30 * time.Minute)}
+	for _, h := range holes {
+		q = withoutBars(q, h, h.Add(48*time.Hour))
+	}
+	missing := len(full.Date) - len(q.Date)
+	equals(t, 3*2*24*12, missing)
+	equals(t, 3, len(q.gapRanges(Min5, AllDays)))
+
+	requests = 0
+	spec := SourceSpec{Name: "tiingo-crypto", Token: "token"}
+	repaired, added, err := BackfillGaps(context.Background(), q, spec, Min5)
+	ok(t, err)
+	equals(t, 2*2*24*12, added)
+	equals(t, len(q.Date)+added, len(repaired.Date))
+	equals(t, 3, requests)
+	equals(t, 1, len(repaired.gapRanges(Min5, AllDays)))
+
+	// repaired bars are the source's, in order
+	equals(t, full.between(first, holes[2]).Close, repaired.between(first, holes[2]).Close)
+	for bar := 1; bar < len(repaired.Date); bar++ {
+		assert(t, repaired.Date[bar].After(repaired.Date[bar-1]), "bar %d out of order", bar)
+	}
+
+	// the empty range is not requested again
+	again, added, err := BackfillGaps(context.Background(), repaired, spec, Min5)
+	ok(t, err)
+	equals(t, 0, added)
+	equals(t, len(repaired.Date), len(again.Date))
+	equals(t, 3, requests)
+
+	_, _, err = BackfillGaps(context.Background(), q, SourceSpec{Name: "yahoo"}, Min5)
+	assert(t, err != nil, "yahoo accepted 5m backfill")
+}
+
+func TestMergeBars(t *testing.T) {
+	q := cleanDaily("spy", 5)
+	gappy := withoutBars(q, q.Date[1], q.Date[3])
+	merged, added := mergeBars(gappy, q)
+	equals(t, 2, added)
+	equals(t, q.Close, merged.Close)
+	equals(t, q.Date, merged.Date)
+}
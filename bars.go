@@ -0,0 +1,323 @@
+package quote
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// aggregation - how the values of an optional column over the bars
+// Resample joins become one
+type aggregation int
+
+const (
+	aggLast aggregation = iota
+	aggSum
+	aggFirst
+	aggMax
+	aggMin
+)
+
+// join - value of a joined bar with value v of the next bar added to it
+func (a aggregation) join(joined, v float64) float64 {
+	switch a {
+	case aggSum:
+		return joined + v
+	case aggFirst:
+		return joined
+	case aggMax:
+		return math.Max(joined, v)
+	case aggMin:
+		return math.Min(joined, v)
+	}
+	return v
+}
+
+// extraColumn - an optional per-bar column of Quote
+type extraColumn struct {
+	name   string                    // csv header
+	digits int                       // decimals written to csv, -1 for the symbol precision
+	agg    aggregation               // how the bars Resample joins are aggregated
+	col    func(q *Quote) *[]float64 // column of q
+}
+
+// extraColumns - optional columns, in csv column order
+var extraColumns = []extraColumn{
+	{"trades", 0, aggSum, func(q *Quote) *[]float64 { return &q.Trades }},
+	{"openinterest", -1, aggLast, func(q *Quote) *[]float64 { return &q.OpenInterest }},
+	{"adjclose", -1, aggLast, func(q *Quote) *[]float64 { return &q.AdjClose }},
+	{"session", 0, aggLast, func(q *Quote) *[]float64 { return &q.Session }},
+	{"adjopen", -1, aggFirst, func(q *Quote) *[]float64 { return &q.AdjOpen }},
+	{"adjhigh", -1, aggMax, func(q *Quote) *[]float64 { return &q.AdjHigh }},
+	{"adjlow", -1, aggMin, func(q *Quote) *[]float64 { return &q.AdjLow }},
+}
+
+// extrasPresent - for each of extraColumns, true if q carries it
+func (q Quote) extrasPresent() []bool {
+	present := make([]bool, len(extraColumns))
+	for i, x := range extraColumns {
+		present[i] = *x.col(&q) != nil
+	}
+	return present
+}
+
+// hasExtras - true if q carries any of the optional per-bar columns
+func (q Quote) hasExtras() bool {
+	for _, p := range q.extrasPresent() {
+		if p {
+			return true
+		}
+	}
+	return false
+}
+
+// columns - the open, high, low, close and volume columns of q followed by
+// the extraColumns
+func (q *Quote) columns() []*[]float64 {
+	cols := []*[]float64{&q.Open, &q.High, &q.Low, &q.Close, &q.Volume}
+	for _, x := range extraColumns {
+		cols = append(cols, x.col(q))
+	}
+	return cols
+}
+
+// columnName - json name of column i of columns
+func columnName(i int) string {
+	if i < 5 {
+		return []string{"open", "high", "low", "close", "volume"}[i]
+	}
+	return extraColumns[i-5].name
+}
+
+// checkColumns - error unless every column of q has a value per date, the
+// optional ones when present
+func (q *Quote) checkColumns() error {
+	for i, col := range q.columns() {
+		if len(*col) != len(q.Date) && (i < 5 || len(*col) > 0) {
+			return fmt.Errorf("%s: %d dates but %d %s values", q.Symbol, len(q.Date), len(*col), columnName(i))
+		}
+	}
+	return nil
+}
+
+// Len - number of bars of q
+func (q Quote) Len() int {
+	return len(q.Date)
+}
+
+// Bar - bar i of q, panicking like a slice index if out of range
+func (q Quote) Bar(i int) Bar {
+	return Bar{Date: q.Date[i], Open: q.Open[i], High: q.High[i], Low: q.Low[i], Close: q.Close[i], Volume: q.Volume[i]}
+}
+
+// Bars - copy of the bars of q, e.g. to range over
+func (q Quote) Bars() []Bar {
+	bars := make([]Bar, len(q.Date))
+	for i := range bars {
+		bars[i] = q.Bar(i)
+	}
+	return bars
+}
+
+// SetBar - overwrite bar i of q, zeroing the optional columns it carries as
+// PushBar does, panicking like a slice index if out of range
+func (q *Quote) SetBar(i int, bar Bar) {
+	q.Date[i] = bar.Date
+	q.Open[i] = bar.Open
+	q.High[i] = bar.High
+	q.Low[i] = bar.Low
+	q.Close[i] = bar.Close
+	q.Volume[i] = bar.Volume
+	for _, x := range extraColumns {
+		if col := *x.col(q); col != nil {
+			col[i] = 0
+		}
+	}
+}
+
+// PushBar - append bar to q, zero filling the optional columns it carries
+func (q *Quote) PushBar(bar Bar) {
+	q.Date = append(q.Date, bar.Date)
+	q.Open = append(q.Open, bar.Open)
+	q.High = append(q.High, bar.High)
+	q.Low = append(q.Low, bar.Low)
+	q.Close = append(q.Close, bar.Close)
+	q.Volume = append(q.Volume, bar.Volume)
+	for _, x := range extraColumns {
+		if col := x.col(q); *col != nil {
+			*col = append(*col, 0)
+		}
+	}
+}
+
+// AppendBar - append bar to q as PushBar does, in amortized constant time,
+// or replace the last bar if bar is dated like it, e.g. to update the still
+// forming candle. A bar older than the last is ErrOutOfOrder.
+func (q *Quote) AppendBar(bar Bar) error {
+	if n := len(q.Date); n > 0 {
+		last := q.Date[n-1]
+		if bar.Date.Equal(last) {
+			q.SetBar(n-1, bar)
+			return nil
+		}
+		if !bar.Date.After(last) {
+			return ErrOutOfOrder
+		}
+	}
+	q.PushBar(bar)
+	return nil
+}
+
+// Truncate - drop the oldest bars of q in place to keep at most maxBars,
+// keeping the capacity of its columns so that q can serve as a rolling
+// window appended to by AppendBar without reallocating. The kept bars are
+// moved, in time linear in maxBars, and slices of q taken before see them
+// moved.
+func (q *Quote) Truncate(maxBars int) {
+	if maxBars < 0 {
+		maxBars = 0
+	}
+	drop := len(q.Date) - maxBars
+	if drop <= 0 {
+		return
+	}
+	q.Date = q.Date[:copy(q.Date, q.Date[drop:])]
+	shift := func(col *[]float64) {
+		if len(*col) > 0 {
+			*col = (*col)[:copy(*col, (*col)[drop:])]
+		}
+	}
+	shift(&q.Open)
+	shift(&q.High)
+	shift(&q.Low)
+	shift(&q.Close)
+	shift(&q.Volume)
+	for _, x := range extraColumns {
+		shift(x.col(q))
+	}
+}
+
+// setExtra - set bar of the optional column col of q, creating the column
+// zero filled when q did not carry it yet
+func (q *Quote) setExtra(col *[]float64, bar int, v float64) {
+	if *col == nil {
+		*col = make([]float64, len(q.Date))
+	}
+	(*col)[bar] = v
+}
+
+// appendExtra - append src[bar] to the optional column dst of a quote that
+// had n bars, zero filling dst when only src carries the column
+func appendExtra(dst []float64, n int, src []float64, bar int) []float64 {
+	if src == nil {
+		if dst == nil {
+			return nil
+		}
+		return append(dst, 0)
+	}
+	if dst == nil {
+		dst = make([]float64, n, n+1)
+	}
+	return append(dst, src[bar])
+}
+
+// appendBar - append bar of src, including optional columns, to q
+func (q *Quote) appendBar(src Quote, bar int) {
+	n := len(q.Date)
+	q.Date = append(q.Date, src.Date[bar])
+	q.Open = append(q.Open, src.Open[bar])
+	q.High = append(q.High, src.High[bar])
+	q.Low = append(q.Low, src.Low[bar])
+	q.Close = append(q.Close, src.Close[bar])
+	q.Volume = append(q.Volume, src.Volume[bar])
+	for _, x := range extraColumns {
+		col := x.col(q)
+		*col = appendExtra(*col, n, *x.col(&src), bar)
+	}
+}
+
+// joinBar - aggregate bar of src into the last bar of q: the highest high,
+// the lowest low, the close and the summed volume, optional columns joined
+// by their aggregation
+func (q *Quote) joinBar(src Quote, bar int) {
+	n := len(q.Date) - 1
+	q.High[n] = math.Max(q.High[n], src.High[bar])
+	q.Low[n] = math.Min(q.Low[n], src.Low[bar])
+	q.Close[n] = src.Close[bar]
+	q.Volume[n] += src.Volume[bar]
+	for _, x := range extraColumns {
+		if s := *x.col(&src); s != nil {
+			dst := *x.col(q)
+			dst[n] = x.agg.join(dst[n], s[bar])
+		}
+	}
+}
+
+// appendQuote - append all bars of src to q
+func (q *Quote) appendQuote(src Quote) {
+	for bar := range src.Date {
+		q.appendBar(src, bar)
+	}
+}
+
+// sliceExtra - optional column [start,end), nil stays nil
+func sliceExtra(col []float64, start, end int) []float64 {
+	if col == nil {
+		return nil
+	}
+	return col[start:end]
+}
+
+// slice - bars [start,end) of q, sharing the underlying arrays
+func (q Quote) slice(start, end int) Quote {
+	q.Date = q.Date[start:end]
+	q.Open = q.Open[start:end]
+	q.High = q.High[start:end]
+	q.Low = q.Low[start:end]
+	q.Close = q.Close[start:end]
+	q.Volume = q.Volume[start:end]
+	for _, x := range extraColumns {
+		col := x.col(&q)
+		*col = sliceExtra(*col, start, end)
+	}
+	return q
+}
+
+// between - bars of q dated from <= date < to, sharing the underlying arrays
+func (q Quote) between(from, to time.Time) Quote {
+	start := sort.Search(len(q.Date), func(i int) bool { return !q.Date[i].Before(from) })
+	end := sort.Search(len(q.Date), func(i int) bool { return !q.Date[i].Before(to) })
+	if end < start {
+		end = start
+	}
+	return q.slice(start, end)
+}
+
+// copyExtra - copy of an optional column, nil stays nil
+func copyExtra(col []float64) []float64 {
+	if col == nil {
+		return nil
+	}
+	return append([]float64{}, col...)
+}
+
+// copy - deep copy of q
+func (q Quote) copy() Quote {
+	c := NewQuote(q.Symbol, len(q.Date))
+	c.Precision = q.Precision
+	if q.Meta != nil {
+		meta := *q.Meta
+		c.Meta = &meta
+	}
+	copy(c.Date, q.Date)
+	copy(c.Open, q.Open)
+	copy(c.High, q.High)
+	copy(c.Low, q.Low)
+	copy(c.Close, q.Close)
+	copy(c.Volume, q.Volume)
+	for _, x := range extraColumns {
+		*x.col(&c) = copyExtra(*x.col(&q))
+	}
+	return c
+}
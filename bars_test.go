@@ -0,0 +1,193 @@
+package quote
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const extrasCSV = `datetime,open,high,low,close,volume,trades,openinterest
+2023-03-01 00:00,1.00,2.00,0.50,1.50,100.00,12,500.00
+2023-03-02 00:00,1.50,2.50,1.00,2.00,200.00,7,510.00
+`
+
+func TestCSVExtrasAbsent(t *testing.T) {
+	q := cleanDaily("spy", 2)
+	equals(t, true, strings.HasPrefix(q.CSV(), "datetime,open,high,low,close,volume\n"))
+
+	r, err := NewQuoteFromCSV("spy", q.CSV())
+	ok(t, err)
+	assert(t, r.Trades == nil, "unexpected trades %v", r.Trades)
+	assert(t, r.OpenInterest == nil, "unexpected open interest %v", r.OpenInterest)
+	assert(t, !strings.Contains(q.JSON(false), "trades"), "trades in json without trades")
+}
+
+func TestCSVExtrasRoundTrip(t *testing.T) {
+	q, err := NewQuoteFromCSV("spy", extrasCSV)
+	ok(t, err)
+	equals(t, []float64{12, 7}, q.Trades)
+	equals(t, []float64{500, 510}, q.OpenInterest)
+	equals(t, extrasCSV, q.CSV())
+
+	var r Quote
+	ok(t, json.Unmarshal([]byte(q.JSON(false)), &r))
+	equals(t, q.Trades, r.Trades)
+	equals(t, q.OpenInterest, r.OpenInterest)
+
+	// multi-symbol output writes empty fields for quotes without a column
+	plain := cleanDaily("qqq", 1)
+	csv := Quotes{q, plain}.CSV()
+	assert(t, strings.HasPrefix(csv, "symbol,datetime,open,high,low,close,volume,trades,openinterest\n"), "bad header %q", csv)
+	assert(t, strings.Contains(csv, ",,\n"), "missing empty extras %q", csv)
+	qs, err := NewQuotesFromCSV(csv)
+	ok(t, err)
+	spy, _ := qs.Symbol("spy")
+	equals(t, q.Trades, spy.Trades)
+	qqq, _ := qs.Symbol("qqq")
+	assert(t, qqq.Trades == nil, "unexpected trades %v", qqq.Trades)
+}
+
+func TestBarExtrasPropagate(t *testing.T) {
+	q, err := NewQuoteFromCSV("spy", extrasCSV)
+	ok(t, err)
+
+	equals(t, []float64{7}, q.slice(1, 2).Trades)
+	c := q.copy()
+	c.Trades[0] = 99
+	equals(t, 12.0, q.Trades[0])
+
+	// appending a bar with extras to a quote without them zero-fills earlier bars
+	plain := cleanDaily("spy", 2)
+	plain.appendBar(q, 1)
+	equals(t, []float64{0, 0, 7}, plain.Trades)
+	equals(t, []float64{0, 0, 510}, plain.OpenInterest)
+
+	// and pushing a plain bar keeps the columns aligned
+	plain.PushBar(Bar{Date: q.Date[1]})
+	equals(t, 4, len(plain.Trades))
+
+	dir, err := ioutil.TempDir("", "extras")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+	ok(t, Quotes{q}.WritePartitioned(dir, "csv", []string{"symbol", "date"}))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "symbol=spy", "date=2023-03-02", "data.csv"))
+	ok(t, err)
+	assert(t, strings.HasSuffix(string(data), ",7,510.00\n"), "extras missing from partition %q", data)
+}
+
+func TestBarAccessors(t *testing.T) {
+	q := NewQuote("spy", 0)
+	equals(t, 0, q.Len())
+	equals(t, []Bar{}, q.Bars())
+
+	bars := []Bar{
+		{Date: day(2023, 1, 3), Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 100},
+		{Date: day(2023, 1, 4), Open: 1.5, High: 3, Low: 1, Close: 2.5, Volume: 200},
+	}
+	for _, bar := range bars {
+		q.PushBar(bar)
+	}
+	equals(t, 2, q.Len())
+	equals(t, bars[1], q.Bar(1))
+	equals(t, bars, q.Bars())
+
+	// the optional columns a quote carries are zero filled, others stay absent
+	q.Trades = []float64{5, 6}
+	q.PushBar(Bar{Date: day(2023, 1, 5), Close: 3})
+	equals(t, []float64{5, 6, 0}, q.Trades)
+	equals(t, []float64(nil), q.AdjClose)
+
+	q.SetBar(0, bars[1])
+	equals(t, bars[1], q.Bar(0))
+	equals(t, []float64{0, 6, 0}, q.Trades)
+
+	// Bars is a copy
+	q.Bars()[0].Close = 99
+	equals(t, 2.5, q.Close[0])
+
+	defer func() {
+		assert(t, recover() != nil, "out of range bar did not panic")
+	}()
+	q.Bar(3)
+}
+
+func TestAppendBar(t *testing.T) {
+	q := NewQuote("btcusd", 0)
+	ok(t, q.AppendBar(Bar{Date: day(2023, 1, 2), Open: 1, High: 2, Low: 1, Close: 2, Volume: 5}))
+	ok(t, q.AppendBar(Bar{Date: day(2023, 1, 3), Open: 2, High: 2, Low: 2, Close: 2, Volume: 1}))
+
+	// the forming candle is updated in place
+	forming := Bar{Date: day(2023, 1, 3), Open: 2, High: 3, Low: 1.5, Close: 2.5, Volume: 4}
+	ok(t, q.AppendBar(forming))
+	equals(t, 2, q.Len())
+	equals(t, forming, q.Bar(1))
+
+	equals(t, ErrOutOfOrder, q.AppendBar(Bar{Date: day(2023, 1, 2)}))
+	equals(t, 2, q.Len())
+
+	// optional columns stay aligned
+	q.Trades = []float64{3, 4}
+	ok(t, q.AppendBar(Bar{Date: day(2023, 1, 4)}))
+	equals(t, []float64{3, 4, 0}, q.Trades)
+	ok(t, q.checkColumns())
+
+	// a replaced bar does not keep the optional values of the one before
+	q.AdjClose = []float64{1, 2, 3}
+	q.Trades[2] = 7
+	ok(t, q.AppendBar(Bar{Date: day(2023, 1, 4), Close: 3}))
+	equals(t, []float64{3, 4, 0}, q.Trades)
+	equals(t, []float64{1, 2, 0}, q.AdjClose)
+}
+
+func TestTruncate(t *testing.T) {
+	q := closesFixture(1, 2, 3, 4, 5)
+	q.Trades = []float64{1, 2, 3, 4, 5}
+	capacity := cap(q.Close)
+
+	q.Truncate(3)
+	equals(t, []float64{3, 4, 5}, q.Close)
+	equals(t, []float64{3, 4, 5}, q.Trades)
+	equals(t, day(2023, 1, 4), q.Date[0])
+	equals(t, capacity, cap(q.Close))
+	ok(t, q.checkColumns())
+
+	q.Truncate(10)
+	equals(t, 3, q.Len())
+	q.Truncate(-1)
+	equals(t, 0, q.Len())
+	assert(t, q.Trades != nil, "truncate dropped the trades column")
+
+	// a full rolling window appends without allocating
+	w := closesFixture(1, 2, 3, 4, 5)
+	next := w.Date[4]
+	allocs := testing.AllocsPerRun(100, func() {
+		next = next.AddDate(0, 0, 1)
+		w.Truncate(4)
+		w.AppendBar(Bar{Date: next, Close: 6})
+	})
+	equals(t, 0.0, allocs)
+	equals(t, 5, w.Len())
+}
+
+func BenchmarkAppendBar(b *testing.B) {
+	q := NewQuote("btcusd", 0)
+	start := day(2023, 1, 2)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q.AppendBar(Bar{Date: start.Add(time.Duration(i) * time.Minute), Close: 1})
+	}
+}
+
+func BenchmarkRollingWindow(b *testing.B) {
+	q := NewQuote("btcusd", 0)
+	start := day(2023, 1, 2)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		q.Truncate(999)
+		q.AppendBar(Bar{Date: start.Add(time.Duration(i) * time.Minute), Close: 1})
+	}
+}
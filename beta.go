@@ -0,0 +1,67 @@
+package quote
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Beta - rolling beta of asset against benchmark, the covariance of their
+// close to close returns over the variance of the benchmark's, after
+// inner-aligning their dates. Dates missing from either quote are skipped, the
+// return on the next common date then spans the gap.
+//
+// With window > 1 the dates returned are those of the returns and values are
+// NaN for the first window-1 returns. With window <= 0 a single full-sample
+// beta is returned, dated on the last common date. Windows over which the
+// benchmark is constant have a NaN beta.
+func Beta(asset, benchmark Quote, window int) ([]time.Time, []float64, error) {
+	if window == 1 {
+		return nil, nil, fmt.Errorf("invalid beta window 1, must be at least 2 or <= 0 for the full sample")
+	}
+	dates, ra, rb, err := alignedReturns(asset, benchmark)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if window <= 0 {
+		if len(dates) == 0 {
+			return nil, nil, fmt.Errorf("no common dates for %s and %s", asset.Symbol, benchmark.Symbol)
+		}
+		var m comoments
+		for i := range dates {
+			m.add(rb[i], ra[i])
+		}
+		return dates[len(dates)-1:], []float64{m.beta()}, nil
+	}
+
+	beta := make([]float64, len(dates))
+	var m comoments
+	for i := range dates {
+		m.add(rb[i], ra[i])
+		if i >= window {
+			m.remove(rb[i-window], ra[i-window])
+		}
+		if i < window-1 {
+			beta[i] = math.NaN()
+			continue
+		}
+		beta[i] = m.beta()
+	}
+	return dates, beta, nil
+}
+
+// Betas - Beta of each quote against benchmark, keyed by symbol. Quotes that
+// cannot be aligned with the benchmark are logged and left out.
+func (q Quotes) Betas(benchmark Quote, window int) map[string][]float64 {
+	betas := make(map[string][]float64, len(q))
+	for _, quote := range q {
+		_, beta, err := Beta(quote, benchmark, window)
+		if err != nil {
+			Log.Printf("beta of %s: %v\n", quote.Symbol, err)
+			continue
+		}
+		betas[quote.Symbol] = beta
+	}
+	return betas
+}
@@ -0,0 +1,76 @@
+package quote
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// withReturns - copy of benchmark whose returns are scale*benchmark returns
+func withReturns(symbol string, benchmark Quote, scale float64) Quote {
+	q := benchmark.copy()
+	q.Symbol = symbol
+	for bar := 1; bar < len(q.Close); bar++ {
+		r := benchmark.Close[bar]/benchmark.Close[bar-1] - 1
+		q.Close[bar] = q.Close[bar-1] * (1 + scale*r)
+	}
+	return q
+}
+
+func TestBeta(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	spy := randomWalk("spy", 100, rng)
+	tqqq := withReturns("tqqq", spy, 3)
+
+	dates, beta, err := Beta(tqqq, spy, 0)
+	ok(t, err)
+	equals(t, 1, len(beta))
+	equals(t, spy.Date[99], dates[0])
+	assert(t, math.Abs(beta[0]-3) < 1e-9, "full sample beta %v", beta[0])
+
+	_, rolling, err := Beta(tqqq, spy, 10)
+	ok(t, err)
+	equals(t, 99, len(rolling))
+	assert(t, math.IsNaN(rolling[8]), "warm-up beta %v", rolling[8])
+	for i := 9; i < len(rolling); i++ {
+		assert(t, math.Abs(rolling[i]-3) < 1e-9, "beta %d = %v", i, rolling[i])
+	}
+
+	_, _, err = Beta(tqqq, spy, 1)
+	assert(t, err != nil, "expected window error")
+}
+
+func TestBetaMissingData(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	spy := randomWalk("spy", 30, rng)
+	asset := removeBar(withReturns("x", spy, 2), 10)
+
+	dates, beta, err := Beta(asset, spy, 0)
+	ok(t, err)
+	equals(t, spy.Date[29], dates[0])
+	// the return over the gap is not exactly 2x, but the estimate stays close
+	assert(t, math.Abs(beta[0]-2) < 0.1, "beta %v", beta[0])
+
+	_, rolling, err := Beta(asset, spy, 5)
+	ok(t, err)
+	equals(t, 28, len(rolling))
+}
+
+func TestBetaZeroVarianceBenchmark(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	asset := randomWalk("x", 20, rng)
+	flat := asset.copy()
+	flat.Symbol = "flat"
+	for bar := range flat.Close {
+		flat.Close[bar] = 10
+	}
+	_, beta, err := Beta(asset, flat, 5)
+	ok(t, err)
+	for i := range beta {
+		assert(t, math.IsNaN(beta[i]), "beta %d = %v", i, beta[i])
+	}
+
+	betas := Quotes{asset, flat}.Betas(asset, 0)
+	assert(t, math.Abs(betas["x"][0]-1) < 1e-9, "self beta %v", betas["x"][0])
+	equals(t, 0.0, betas["flat"][0])
+}
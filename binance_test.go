@@ -0,0 +1,126 @@
+package quote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeBinanceKlines - klines endpoint serving daily bars opening from first
+// up to last, and the still forming bar of today, counting its requests
+func fakeBinanceKlines(first, last time.Time, requests *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.URL.Query().Get("symbol") == "NOPE" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":-1121,"msg":"Invalid symbol."}`)
+			return
+		}
+		start, _ := strconv.ParseInt(r.URL.Query().Get("startTime"), 10, 64)
+		end, _ := strconv.ParseInt(r.URL.Query().Get("endTime"), 10, 64)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		days := []time.Time{}
+		for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+			days = append(days, d)
+		}
+		days = append(days, time.Now().UTC().Truncate(24*time.Hour))
+		var bars []string
+		for _, d := range days {
+			open := d.UnixNano() / int64(time.Millisecond)
+			if open < start || open > end || len(bars) == limit {
+				continue
+			}
+			closeTime := open + 24*3600*1000 - 1
+			bars = append(bars, fmt.Sprintf(`[%d,"1.0","2.0","0.5","1.5","%d",%d,"0",7,"0","0","0"]`, open, d.YearDay(), closeTime))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(bars, ","))
+	}
+}
+
+func TestBinancePaging(t *testing.T) {
+	first := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 0, 2499)
+	requests := 0
+	fakeServer(t, &binanceURL, fakeBinanceKlines(first, last, &requests))
+
+	q, err := NewQuoteFromBinance("btcusdt", "2015-01-01", last.Format("2006-01-02"), Daily)
+	ok(t, err)
+	equals(t, 3, requests)
+	equals(t, 2500, len(q.Date))
+	equals(t, first, q.Date[0])
+	equals(t, last, q.Date[len(q.Date)-1])
+	for bar := 1; bar < len(q.Date); bar++ {
+		assert(t, q.Date[bar].After(q.Date[bar-1]), "bar %d at %v not after %v", bar, q.Date[bar], q.Date[bar-1])
+	}
+	equals(t, 2500, len(q.Trades))
+	equals(t, 7.0, q.Trades[2499])
+	equals(t, 1.5, q.Close[1000])
+}
+
+func TestBinanceFormingCandle(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	requests := 0
+	fakeServer(t, &binanceURL, fakeBinanceKlines(today.AddDate(0, 0, -5), today.AddDate(0, 0, -1), &requests))
+
+	q, err := NewQuoteFromBinance("btcusdt", today.AddDate(0, 0, -5).Format("2006-01-02"), today.Format("2006-01-02"), Daily)
+	ok(t, err)
+	equals(t, 5, len(q.Date))
+	equals(t, today.AddDate(0, 0, -1), q.Date[4])
+}
+
+func TestBinanceSymsRejected(t *testing.T) {
+	first := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	requests := 0
+	fakeServer(t, &binanceURL, fakeBinanceKlines(first, first.AddDate(0, 0, 9), &requests))
+
+	quotes, err := NewQuotesFromBinanceSyms([]string{"btcusdt", "nope", "ethusdt"}, "2023-03-01", "2023-03-10", Daily)
+	equals(t, 2, len(quotes))
+	equals(t, "ethusdt", quotes[1].Symbol)
+	equals(t, 10, len(quotes[1].Date))
+	rejected, isRejected := err.(*BinanceSymbolsError)
+	assert(t, isRejected, "unexpected error %v", err)
+	equals(t, []string{"nope"}, rejected.Symbols)
+}
+
+// cancelingBinance - fake klines endpoint of fakeBinanceKlines calling cancel
+// on request n and then waiting for the client to go away
+func cancelingBinance(first, last time.Time, n int, cancel context.CancelFunc) http.HandlerFunc {
+	requests := 0
+	klines := fakeBinanceKlines(first, last, &requests)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if requests == n-1 {
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+		klines(w, r)
+	}
+}
+
+func TestBinanceCanceled(t *testing.T) {
+	// canceled during the second of three pages
+	first := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 0, 2499)
+	ctx, cancel := context.WithCancel(context.Background())
+	fakeServer(t, &binanceURL, cancelingBinance(first, last, 2, cancel))
+	q, err := NewQuoteFromBinanceCtx(ctx, "btcusdt", "2015-01-01", last.Format("2006-01-02"), Daily)
+	assert(t, errors.Is(err, context.Canceled), "not canceled: %v", err)
+	equals(t, "binance btcusdt after 1 requests: context canceled", err.Error())
+	equals(t, binanceLimit, len(q.Date))
+	equals(t, first, q.Date[0])
+	equals(t, "binance", q.Meta.Source)
+
+	// canceled during the second symbol
+	ctx, cancel = context.WithCancel(context.Background())
+	fakeServer(t, &binanceURL, cancelingBinance(first, last, 2, cancel))
+	quotes, err := NewQuotesFromBinanceSymsCtx(ctx, []string{"btcusdt", "ethusdt", "nope"}, "2015-01-01", "2015-01-10", Daily)
+	assert(t, errors.Is(err, context.Canceled), "not canceled: %v", err)
+	equals(t, "binance after 1 of 3 symbols: context canceled", err.Error())
+	equals(t, []string{"btcusdt"}, quotes.Symbols())
+}
@@ -0,0 +1,99 @@
+package quote
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// binanceFuturesURL - base url of the Binance USDⓈ-M futures api
+var binanceFuturesURL = "https://fapi.binance.com"
+
+// binanceFuturesPeriods - kline intervals of the Binance futures api, the
+// same as spot
+var binanceFuturesPeriods = binancePeriods
+
+// BinanceFuturesOptions - options of Binance futures downloads
+type BinanceFuturesOptions struct {
+	Continuous bool // continuous perpetual contract klines of the pair instead of the symbol's klines
+}
+
+// DefaultBinanceFuturesOptions - options of NewQuoteFromBinanceFutures
+var DefaultBinanceFuturesOptions = BinanceFuturesOptions{}
+
+// NewQuoteFromBinanceFutures - Binance USDⓈ-M futures historical prices for a
+// symbol such as BTCUSDT
+func NewQuoteFromBinanceFutures(symbol, startDate, endDate string, period Period) (Quote, error) {
+	return NewQuoteFromBinanceFuturesWithOptions(symbol, startDate, endDate, period, DefaultBinanceFuturesOptions)
+}
+
+// NewQuoteFromBinanceFuturesCtx - NewQuoteFromBinanceFutures, canceled with
+// ctx
+func NewQuoteFromBinanceFuturesCtx(ctx context.Context, symbol, startDate, endDate string, period Period) (Quote, error) {
+	return NewQuoteFromBinanceFuturesWithOptionsCtx(ctx, symbol, startDate, endDate, period, DefaultBinanceFuturesOptions)
+}
+
+// NewQuoteFromBinanceFuturesWithOptions - Binance USDⓈ-M futures historical
+// prices for a symbol, paged like spot klines. With opts.Continuous the symbol
+// is a pair and the klines those of its perpetual contract.
+func NewQuoteFromBinanceFuturesWithOptions(symbol, startDate, endDate string, period Period, opts BinanceFuturesOptions) (Quote, error) {
+	return NewQuoteFromBinanceFuturesWithOptionsCtx(context.Background(), symbol, startDate, endDate, period, opts)
+}
+
+// NewQuoteFromBinanceFuturesWithOptionsCtx -
+// NewQuoteFromBinanceFuturesWithOptions, canceled with ctx. A download
+// canceled between its requests returns the bars so far with the wrapped error
+// of ctx.
+func NewQuoteFromBinanceFuturesWithOptionsCtx(ctx context.Context, symbol, startDate, endDate string, period Period, opts BinanceFuturesOptions) (Quote, error) {
+	pageURL := func(interval string, start, end int64) string {
+		if opts.Continuous {
+			return fmt.Sprintf(
+				"%s/fapi/v1/continuousKlines?pair=%s&contractType=PERPETUAL&interval=%s&startTime=%d&endTime=%d&limit=%d",
+				binanceFuturesURL,
+				strings.ToUpper(symbol),
+				interval,
+				start,
+				end,
+				binanceLimit)
+		}
+		return fmt.Sprintf(
+			"%s/fapi/v1/klines?symbol=%s&interval=%s&startTime=%d&endTime=%d&limit=%d",
+			binanceFuturesURL,
+			strings.ToUpper(symbol),
+			interval,
+			start,
+			end,
+			binanceLimit)
+	}
+	quote, err := binanceKlines(ctx, "binance-futures", symbol, startDate, endDate, period, pageURL)
+	if err != nil && ctx.Err() == nil {
+		return NewQuote("", 0), err
+	}
+	return quote.withMeta("binance-futures", period, false), err
+}
+
+// NewQuotesFromBinanceFuturesSyms - create a list of prices from symbols in string array
+func NewQuotesFromBinanceFuturesSyms(symbols []string, startDate, endDate string, period Period) (Quotes, error) {
+	return NewQuotesFromBinanceFuturesSymsCtx(context.Background(), symbols, startDate, endDate, period)
+}
+
+// NewQuotesFromBinanceFuturesSymsCtx - NewQuotesFromBinanceFuturesSyms,
+// canceled with ctx. The quotes downloaded before are returned with the
+// wrapped error of ctx.
+func NewQuotesFromBinanceFuturesSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromBinanceFuturesCtx(ctx, symbol, startDate, endDate, period)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "binance-futures after %d of %d symbols", i, len(symbols))
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + symbol)
+		}
+		delayContext(ctx, "binance-futures")
+	}
+	return quotes, nil
+}
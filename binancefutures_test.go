@@ -0,0 +1,40 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBinanceFutures(t *testing.T) {
+	first := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	requests := 0
+	klines := fakeBinanceKlines(first, first.AddDate(0, 0, 1499), &requests)
+	var urls []string
+	fakeServer(t, &binanceFuturesURL, func(w http.ResponseWriter, r *http.Request) {
+		urls = append(urls, r.URL.Path+" "+r.URL.Query().Get("symbol")+r.URL.Query().Get("pair")+" "+r.URL.Query().Get("contractType"))
+		if r.URL.Query().Get("symbol") == "NOPE" {
+			// futures answer some errors with a 200 status
+			fmt.Fprint(w, `{"code":-1121,"msg":"Invalid symbol."}`)
+			return
+		}
+		klines(w, r)
+	})
+
+	q, err := NewQuoteFromBinanceFutures("btcusdt", "2019-03-01", first.AddDate(0, 0, 1499).Format("2006-01-02"), Daily)
+	ok(t, err)
+	equals(t, []string{"/fapi/v1/klines BTCUSDT ", "/fapi/v1/klines BTCUSDT "}, urls)
+	equals(t, 1500, len(q.Date))
+	equals(t, "binance-futures", q.Meta.Source)
+	equals(t, "USDT", q.Meta.Currency)
+
+	urls = nil
+	q, err = NewQuoteFromBinanceFuturesWithOptions("btcusdt", "2019-03-01", "2019-03-10", Daily, BinanceFuturesOptions{Continuous: true})
+	ok(t, err)
+	equals(t, []string{"/fapi/v1/continuousKlines BTCUSDT PERPETUAL"}, urls)
+	equals(t, 10, len(q.Date))
+
+	_, err = NewQuoteFromBinanceFutures("nope", "2023-03-01", "2023-03-10", Daily)
+	equals(t, "binance 200: Invalid symbol.", fmt.Sprint(err))
+}
@@ -0,0 +1,115 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeBittrexCandles - v3 candles endpoint of the ETH-BTC market recording
+// the paths requested. Historical paths serve their whole day, month or
+// year, recent serves the last day, 31 days or 366 days up to now.
+func fakeBittrexCandles(paths *[]string) http.HandlerFunc {
+	steps := map[string]time.Duration{"MINUTE_1": time.Minute, "MINUTE_5": 5 * time.Minute, "HOUR_1": time.Hour, "DAY_1": 24 * time.Hour}
+	windows := map[string]time.Duration{"MINUTE_1": 24 * time.Hour, "MINUTE_5": 24 * time.Hour, "HOUR_1": 31 * 24 * time.Hour, "DAY_1": 366 * 24 * time.Hour}
+	return func(w http.ResponseWriter, r *http.Request) {
+		// /v3/markets/{market}/candles/{interval}/recent or /historical/y[/m[/d]]
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v3/markets/"), "/")
+		*paths = append(*paths, strings.Join(parts[3:], "/"))
+		if parts[0] != "ETH-BTC" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code":"MARKET_DOES_NOT_EXIST"}`)
+			return
+		}
+		step := steps[parts[2]]
+		now := time.Now().UTC()
+		from, to := now.Add(-windows[parts[2]]).Truncate(step), now
+		if parts[3] == "historical" {
+			ymd := []int{0, 1, 1}
+			for i, p := range parts[4:] {
+				fmt.Sscan(p, &ymd[i])
+			}
+			from = time.Date(ymd[0], time.Month(ymd[1]), ymd[2], 0, 0, 0, 0, time.UTC)
+			switch len(parts[4:]) {
+			case 1:
+				to = from.AddDate(1, 0, 0)
+			case 2:
+				to = from.AddDate(0, 1, 0)
+			default:
+				to = from.AddDate(0, 0, 1)
+			}
+		}
+		var candles []string
+		for t := from; t.Before(to); t = t.Add(step) {
+			candles = append(candles, fmt.Sprintf(`{"startsAt":"%s","open":"1","high":"2","low":"0.5","close":"1.5","volume":"%d","quoteVolume":"1"}`, t.Format(time.RFC3339), t.Day()))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(candles, ","))
+	}
+}
+
+func TestBittrexDateRange(t *testing.T) {
+	var paths []string
+	fakeServer(t, &bittrexURL, fakeBittrexCandles(&paths))
+
+	q, err := NewQuoteFromBittrex("eth-btc", "2023-03-03", "2023-03-05", Daily)
+	ok(t, err)
+	equals(t, []string{"historical/2023"}, paths)
+	equals(t, 3, len(q.Date))
+	equals(t, time.Date(2023, 3, 3, 0, 0, 0, 0, time.UTC), q.Date[0])
+	equals(t, []float64{3, 4, 5}, q.Volume)
+
+	paths = nil
+	q, err = NewQuoteFromBittrex("ETH-BTC", "2022-12-30", "2023-01-02", Daily)
+	ok(t, err)
+	equals(t, []string{"historical/2022", "historical/2023"}, paths)
+	equals(t, 4, len(q.Date))
+
+	paths = nil
+	q, err = NewQuoteFromBittrex("ETH-BTC", "2023-02-28 22:00", "2023-03-01 01:00", Min60)
+	ok(t, err)
+	equals(t, []string{"historical/2023/2", "historical/2023/3"}, paths)
+	equals(t, 4, len(q.Date))
+
+	paths = nil
+	q, err = NewQuoteFromBittrex("ETH-BTC", "2023-03-05 23:58", "2023-03-06 00:01", Min1)
+	ok(t, err)
+	equals(t, []string{"historical/2023/3/5", "historical/2023/3/6"}, paths)
+	equals(t, 4, len(q.Date))
+
+	_, err = NewQuoteFromBittrex("ETH-BTC", "2023-03-03", "2023-03-05", Min30)
+	assert(t, err != nil, "expected invalid period error")
+}
+
+func TestBittrexRecent(t *testing.T) {
+	var paths []string
+	fakeServer(t, &bittrexURL, fakeBittrexCandles(&paths))
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	q, err := NewQuoteFromBittrex("ETH-BTC", today.AddDate(0, 0, -2).Format("2006-01-02"), today.Format("2006-01-02"), Daily)
+	ok(t, err)
+	equals(t, "recent", paths[len(paths)-1])
+	equals(t, today, q.Date[len(q.Date)-1])
+	equals(t, 3, len(q.Date))
+
+	q, err = NewQuoteFromBittrexRecent("ETH-BTC", Daily)
+	ok(t, err)
+	equals(t, 367, len(q.Date))
+}
+
+func TestBittrexUnknownMarket(t *testing.T) {
+	var paths []string
+	fakeServer(t, &bittrexURL, fakeBittrexCandles(&paths))
+
+	_, err := NewQuoteFromBittrex("BTC-ETH", "2023-03-03", "2023-03-05", Daily)
+	unknown, isUnknown := err.(*UnknownMarketError)
+	assert(t, isUnknown, "unexpected error %v", err)
+	equals(t, "BTC-ETH", unknown.Market)
+	equals(t, "unknown market BTC-ETH", err.Error())
+}
+
+func TestParseEndDate(t *testing.T) {
+	equals(t, time.Date(2023, 3, 6, 0, 0, 0, 0, time.UTC), parseEndDate("2023-03-05"))
+	equals(t, time.Date(2023, 3, 5, 12, 30, 0, 1, time.UTC), parseEndDate("2023-03-05 12:30"))
+}
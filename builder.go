@@ -0,0 +1,87 @@
+package quote
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// AppendPolicy - how QuoteBuilder.Append treats a bar that is not newer than the last one
+type AppendPolicy int
+
+const (
+	// RejectOutOfOrder - bars must have strictly increasing dates (default)
+	RejectOutOfOrder AppendPolicy = iota
+	// ReplaceLast - a bar dated like the last bar replaces it, e.g. to update
+	// the still forming candle; older bars are rejected
+	ReplaceLast
+	// AllowOutOfOrder - append every bar as is
+	AllowOutOfOrder
+)
+
+// ErrOutOfOrder - returned by QuoteBuilder.Append for a bar that is not newer than the last one
+var ErrOutOfOrder = errors.New("bar is not newer than the last bar")
+
+// QuoteBuilder - grows a Quote one bar at a time. Safe for one or more
+// goroutines appending while others take snapshots.
+type QuoteBuilder struct {
+	mu     sync.RWMutex
+	q      Quote
+	policy AppendPolicy
+}
+
+// NewQuoteBuilder - new empty QuoteBuilder with room for capacityHint bars
+func NewQuoteBuilder(symbol string, capacityHint int) *QuoteBuilder {
+	if capacityHint < 0 {
+		capacityHint = 0
+	}
+	q := NewQuote(symbol, 0)
+	q.Date = make([]time.Time, 0, capacityHint)
+	q.Open = make([]float64, 0, capacityHint)
+	q.High = make([]float64, 0, capacityHint)
+	q.Low = make([]float64, 0, capacityHint)
+	q.Close = make([]float64, 0, capacityHint)
+	q.Volume = make([]float64, 0, capacityHint)
+	return &QuoteBuilder{q: q}
+}
+
+// SetPolicy - set how out of order bars are handled
+func (b *QuoteBuilder) SetPolicy(policy AppendPolicy) {
+	b.mu.Lock()
+	b.policy = policy
+	b.mu.Unlock()
+}
+
+// Append - add bar, subject to the builder's AppendPolicy
+func (b *QuoteBuilder) Append(bar Bar) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := len(b.q.Date)
+	if n > 0 && b.policy != AllowOutOfOrder {
+		last := b.q.Date[n-1]
+		if b.policy == ReplaceLast && bar.Date.Equal(last) {
+			b.q.SetBar(n-1, bar)
+			return nil
+		}
+		if !bar.Date.After(last) {
+			return ErrOutOfOrder
+		}
+	}
+	b.q.PushBar(bar)
+	return nil
+}
+
+// Len - number of bars appended so far
+func (b *QuoteBuilder) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.q.Date)
+}
+
+// Snapshot - copy of the bars appended so far, unaffected by later appends
+func (b *QuoteBuilder) Snapshot() Quote {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.q.copy()
+}
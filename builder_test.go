@@ -0,0 +1,93 @@
+package quote
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func minuteBar(m int, c float64) Bar {
+	return Bar{Date: time.Date(2024, 1, 2, 9, m, 0, 0, time.UTC), Open: c, High: c, Low: c, Close: c, Volume: 1}
+}
+
+func TestQuoteBuilderReject(t *testing.T) {
+	b := NewQuoteBuilder("btcusdt", 4)
+	ok(t, b.Append(minuteBar(0, 1)))
+	ok(t, b.Append(minuteBar(1, 2)))
+	equals(t, ErrOutOfOrder, b.Append(minuteBar(1, 3)))
+	equals(t, ErrOutOfOrder, b.Append(minuteBar(0, 3)))
+	q := b.Snapshot()
+	equals(t, "btcusdt", q.Symbol)
+	equals(t, []float64{1, 2}, q.Close)
+}
+
+func TestQuoteBuilderReplaceLast(t *testing.T) {
+	b := NewQuoteBuilder("btcusdt", 0)
+	b.SetPolicy(ReplaceLast)
+	ok(t, b.Append(minuteBar(0, 1)))
+	ok(t, b.Append(minuteBar(1, 2)))
+	// the forming 09:01 candle updates twice
+	ok(t, b.Append(minuteBar(1, 2.5)))
+	ok(t, b.Append(minuteBar(1, 2.75)))
+	equals(t, ErrOutOfOrder, b.Append(minuteBar(0, 9)))
+	ok(t, b.Append(minuteBar(2, 3)))
+	q := b.Snapshot()
+	equals(t, 3, b.Len())
+	equals(t, []float64{1, 2.75, 3}, q.Close)
+}
+
+func TestQuoteBuilderAllow(t *testing.T) {
+	b := NewQuoteBuilder("x", 0)
+	b.SetPolicy(AllowOutOfOrder)
+	ok(t, b.Append(minuteBar(1, 1)))
+	ok(t, b.Append(minuteBar(0, 2)))
+	ok(t, b.Append(minuteBar(0, 3)))
+	equals(t, 3, b.Len())
+}
+
+func TestQuoteBuilderSnapshotIsCopy(t *testing.T) {
+	b := NewQuoteBuilder("x", 0)
+	b.SetPolicy(ReplaceLast)
+	ok(t, b.Append(minuteBar(0, 1)))
+	snap := b.Snapshot()
+	ok(t, b.Append(minuteBar(0, 5)))
+	equals(t, 1.0, snap.Close[0])
+	snap.Close[0] = 42
+	equals(t, 5.0, b.Snapshot().Close[0])
+}
+
+// run with -race
+func TestQuoteBuilderConcurrent(t *testing.T) {
+	const bars = 2000
+	b := NewQuoteBuilder("x", 16)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < bars; i++ {
+			c := float64(i)
+			if err := b.Append(Bar{Date: start.Add(time.Duration(i) * time.Minute), Close: c}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				q := b.Snapshot()
+				for bar := 1; bar < len(q.Close); bar++ {
+					if q.Close[bar] != q.Close[bar-1]+1 || !q.Date[bar].After(q.Date[bar-1]) {
+						t.Errorf("inconsistent snapshot at bar %d", bar)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	equals(t, bars, b.Len())
+}
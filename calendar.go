@@ -0,0 +1,120 @@
+package quote
+
+import (
+	"time"
+)
+
+// Calendar - trading calendar, decides which missing bars count as gaps
+//
+// The zero value trades every day around the clock, like crypto markets.
+type Calendar struct {
+	Weekdays bool        // only monday to friday are trading days
+	Holidays []time.Time // additional non-trading days (time of day ignored)
+	// intraday session as offsets from midnight in the bar's location,
+	// ignored when SessionEnd is zero
+	SessionStart time.Duration
+	SessionEnd   time.Duration
+}
+
+// AllDays - every day is a trading day
+var AllDays = Calendar{}
+
+// WeekdaysOnly - monday to friday are trading days
+var WeekdaysOnly = Calendar{Weekdays: true}
+
+// calendarOrDefault - dereference cal, defaulting to AllDays
+func calendarOrDefault(cal *Calendar) Calendar {
+	if cal == nil {
+		return AllDays
+	}
+	return *cal
+}
+
+// calendarOrSource - cal if given, else the calendar of the source in q's
+// metadata, AllDays without metadata
+func (q Quote) calendarOrSource(cal *Calendar) Calendar {
+	if cal != nil {
+		return *cal
+	}
+	if q.Meta != nil {
+		return SourceSpec{Name: q.Meta.Source}.Calendar()
+	}
+	return AllDays
+}
+
+// IsTradingDay - true if t falls on a trading day
+func (c Calendar) IsTradingDay(t time.Time) bool {
+	if c.Weekdays && (t.Weekday() == time.Saturday || t.Weekday() == time.Sunday) {
+		return false
+	}
+	y, m, d := t.Date()
+	for _, h := range c.Holidays {
+		hy, hm, hd := h.Date()
+		if y == hy && m == hm && d == hd {
+			return false
+		}
+	}
+	return true
+}
+
+// IsTradingTime - true if t falls on a trading day and within the session
+func (c Calendar) IsTradingTime(t time.Time) bool {
+	if !c.IsTradingDay(t) {
+		return false
+	}
+	if c.SessionEnd == 0 {
+		return true
+	}
+	y, m, d := t.Date()
+	offset := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+	return offset >= c.SessionStart && offset < c.SessionEnd
+}
+
+// gaps - timestamps of bars that are expected by period and calendar between
+// consecutive bars of q but are absent. Dates are assumed to be ascending.
+func (q Quote) gaps(period Period, cal Calendar) []time.Time {
+
+	var missing []time.Time
+	step := period.Duration()
+	if step == 0 || len(q.Date) < 2 {
+		return missing
+	}
+
+	for bar := 1; bar < len(q.Date); bar++ {
+		prev, next := q.Date[bar-1], q.Date[bar]
+		if !next.After(prev) {
+			continue
+		}
+		switch period {
+		case Monthly:
+			// months vary in length, compare calendar months instead
+			months := (next.Year()-prev.Year())*12 + int(next.Month()-prev.Month())
+			for k := 1; k < months; k++ {
+				missing = append(missing, time.Date(prev.Year(), prev.Month()+time.Month(k), 1, 0, 0, 0, 0, prev.Location()))
+			}
+		case Yearly:
+			for k := 1; k < next.Year()-prev.Year(); k++ {
+				missing = append(missing, time.Date(prev.Year()+k, 1, 1, 0, 0, 0, 0, prev.Location()))
+			}
+		case Weekly:
+			// weekly bars may shift a day or two around holidays
+			for t := prev.AddDate(0, 0, 7); t.Before(next.Add(-step / 2)); t = t.AddDate(0, 0, 7) {
+				missing = append(missing, t)
+			}
+		case Daily, Day3:
+			days := int(step / (24 * time.Hour))
+			for t := prev.AddDate(0, 0, days); t.Before(next.Add(-step / 2)); t = t.AddDate(0, 0, days) {
+				if cal.IsTradingDay(t) {
+					missing = append(missing, t)
+				}
+			}
+		default:
+			for t := prev.Add(step); t.Before(next.Add(-step / 2)); t = t.Add(step) {
+				if cal.IsTradingTime(t) {
+					missing = append(missing, t)
+				}
+			}
+		}
+	}
+	return missing
+}
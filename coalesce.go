@@ -0,0 +1,57 @@
+package quote
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// flight - a source request in progress, shared by identical requests
+type flight struct {
+	done  chan struct{}
+	quote Quote
+	err   error
+}
+
+// inflight - source requests in progress by flightKey
+var inflight = struct {
+	sync.Mutex
+	calls map[string]*flight
+}{calls: make(map[string]*flight)}
+
+// flightKey - key of identical source requests
+func flightKey(spec SourceSpec, symbol string, from, to time.Time, period Period) string {
+	return fmt.Sprintf("%s|%t|%t|%s|%s|%d|%d|%s", spec.Name, spec.Adjust, spec.PrePost, spec.Token, symbol, from.UnixNano(), to.UnixNano(), period)
+}
+
+// coalesce - result of fetch, shared with identical requests already in
+// progress instead of fetching again. Every caller gets its own copy of the
+// quote. Callers that join a request stop waiting when ctx is done; the
+// request itself is canceled only with the ctx of the caller that started
+// it, see fetchSource.
+func coalesce(ctx context.Context, key string, fetch func() (Quote, error)) (Quote, error) {
+
+	inflight.Lock()
+	f, shared := inflight.calls[key]
+	if !shared {
+		f = &flight{done: make(chan struct{})}
+		inflight.calls[key] = f
+	}
+	inflight.Unlock()
+
+	if shared {
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return NewQuote("", 0), ctx.Err()
+		}
+	} else {
+		f.quote, f.err = fetch()
+		inflight.Lock()
+		delete(inflight.calls, key)
+		inflight.Unlock()
+		close(f.done)
+	}
+	return f.quote.copy(), f.err
+}
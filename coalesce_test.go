@@ -0,0 +1,97 @@
+package quote
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewQuoteFromSourceCoalesced(t *testing.T) {
+	var mu sync.Mutex
+	hits := 0
+	saved := fetchSource
+	defer func() { fetchSource = saved }()
+	fetchSource = func(_ context.Context, spec SourceSpec, symbol string, from, to time.Time, period Period) (Quote, error) {
+		mu.Lock()
+		hits++
+		mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		q := cleanDaily(symbol, 10)
+		q.Meta = &Metadata{Symbol: symbol, Source: spec.Name}
+		return q, nil
+	}
+
+	const callers = 50
+	spec := SourceSpec{Name: "binance"}
+	results := make([]Quote, callers)
+	errs := make([]error, callers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			results[i], errs[i] = NewQuoteFromSource(context.Background(), spec, "btcusdt", day(2024, 1, 1), day(2024, 1, 15), Daily)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	equals(t, 1, hits)
+	for i, q := range results {
+		ok(t, errs[i])
+		equals(t, 10, len(q.Close))
+		q.Close[0] = float64(i)
+	}
+	results[0].Meta.Symbol = "mine"
+	// each caller owns its quote
+	for i, q := range results {
+		equals(t, float64(i), q.Close[0])
+		equals(t, 101.0, q.Close[1])
+	}
+	equals(t, "btcusdt", results[1].Meta.Symbol)
+
+	// a different range is fetched again, as is the same range once done
+	_, err := NewQuoteFromSource(context.Background(), spec, "btcusdt", day(2024, 1, 1), day(2024, 1, 16), Daily)
+	ok(t, err)
+	_, err = NewQuoteFromSource(context.Background(), spec, "btcusdt", day(2024, 1, 1), day(2024, 1, 15), Daily)
+	ok(t, err)
+	equals(t, 3, hits)
+	equals(t, 0, len(inflight.calls))
+}
+
+func TestNewQuoteFromSourceCoalescedCancel(t *testing.T) {
+	release := make(chan struct{})
+	saved := fetchSource
+	defer func() { fetchSource = saved }()
+	fetchSource = func(_ context.Context, spec SourceSpec, symbol string, from, to time.Time, period Period) (Quote, error) {
+		<-release
+		return cleanDaily(symbol, 3), nil
+	}
+
+	spec := SourceSpec{Name: "binance"}
+	first := make(chan Quote)
+	go func() {
+		q, _ := NewQuoteFromSource(context.Background(), spec, "ethusdt", day(2024, 1, 1), day(2024, 1, 5), Daily)
+		first <- q
+	}()
+	for {
+		inflight.Lock()
+		n := len(inflight.calls)
+		inflight.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// a joined caller gives up on cancel without affecting the download
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := coalesce(ctx, flightKey(spec, "ethusdt", day(2024, 1, 1), day(2024, 1, 5), Daily), nil)
+	equals(t, context.Canceled, err)
+	close(release)
+	equals(t, 3, len((<-first).Close))
+}
@@ -0,0 +1,143 @@
+package quote
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// coinbaseURL - base url of the Coinbase Exchange api
+var coinbaseURL = "https://api.exchange.coinbase.com"
+
+// ValidateCoinbaseProducts - check symbols against the Coinbase products list
+// before downloading (default=true). Disable for sandbox environments with
+// nonstandard products.
+var ValidateCoinbaseProducts = true
+
+// UnknownProductError - a symbol that is not a Coinbase product
+type UnknownProductError struct {
+	Product     string
+	Suggestions []string // closest valid product ids, best first
+}
+
+func (e *UnknownProductError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("unknown product %s", e.Product)
+	}
+	return fmt.Sprintf("unknown product %s; did you mean %s?", e.Product, strings.Join(e.Suggestions, " or "))
+}
+
+// coinbaseProducts - cached product ids, per api url
+var coinbaseProducts = struct {
+	sync.Mutex
+	url string
+	ids []string
+}{}
+
+// coinbaseProductIDs - Coinbase product ids, downloaded once and cached
+func coinbaseProductIDs(ctx context.Context) ([]string, error) {
+	coinbaseProducts.Lock()
+	defer coinbaseProducts.Unlock()
+	if coinbaseProducts.ids != nil && coinbaseProducts.url == coinbaseURL {
+		return coinbaseProducts.ids, nil
+	}
+
+	if err := waitRate(ctx, "coinbase"); err != nil {
+		return nil, err
+	}
+	client := httpClient()
+	resp, err := getContext(ctx, client, coinbaseURL+"/products")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("coinbase products: %s", resp.Status)
+	}
+	contents, _ := ioutil.ReadAll(resp.Body)
+	ids, err := getCoinbaseMarket("coinbase", string(contents))
+	if err != nil {
+		return nil, err
+	}
+	coinbaseProducts.url = coinbaseURL
+	coinbaseProducts.ids = ids
+	return ids, nil
+}
+
+// editDistance - levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// suggestProducts - up to max ids closest to product by case-insensitive edit
+// distance, ignoring ids that differ in more than half of product's characters
+func suggestProducts(product string, ids []string, max int) []string {
+	type candidate struct {
+		id   string
+		dist int
+	}
+	p := strings.ToLower(product)
+	var candidates []candidate
+	for _, id := range ids {
+		d := editDistance(p, strings.ToLower(id))
+		if d <= (len(p)+1)/2 {
+			candidates = append(candidates, candidate{id, d})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].dist != candidates[j].dist {
+			return candidates[i].dist < candidates[j].dist
+		}
+		return candidates[i].id < candidates[j].id
+	})
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < max; i++ {
+		suggestions = append(suggestions, candidates[i].id)
+	}
+	return suggestions
+}
+
+// validateCoinbaseProduct - UnknownProductError if symbol is not a Coinbase
+// product. Validation is skipped when the products list is unavailable.
+func validateCoinbaseProduct(ctx context.Context, symbol string) error {
+	if !ValidateCoinbaseProducts {
+		return nil
+	}
+	ids, err := coinbaseProductIDs(ctx)
+	if err != nil {
+		Log.Printf("coinbase products unavailable, not validating '%s': %v\n", symbol, err)
+		return nil
+	}
+	for _, id := range ids {
+		if strings.EqualFold(id, symbol) {
+			return nil
+		}
+	}
+	return &UnknownProductError{Product: symbol, Suggestions: suggestProducts(symbol, ids, 3)}
+}
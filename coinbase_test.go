@@ -0,0 +1,217 @@
+package quote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testCoinbaseProducts = []string{"BTC-USD", "BTC-EUR", "ETH-USD", "ETH-BTC", "LTC-USD", "BCH-USD"}
+
+// fakeCoinbaseProducts - coinbase products endpoint counting its requests
+func fakeCoinbaseProducts(requests *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		fmt.Fprint(w, "[")
+		for i, id := range testCoinbaseProducts {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"id":"%s","status":"online"}`, id)
+		}
+		fmt.Fprint(w, "]")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	equals(t, 0, editDistance("btc-usd", "btc-usd"))
+	equals(t, 1, editDistance("btcusd", "btc-usd"))
+	equals(t, 3, editDistance("kitten", "sitting"))
+	equals(t, 3, editDistance("", "abc"))
+}
+
+func TestSuggestProducts(t *testing.T) {
+	equals(t, []string{"BTC-USD", "LTC-USD", "BCH-USD"}, suggestProducts("btcusd", testCoinbaseProducts, 3))
+	equals(t, []string{"ETH-USD", "BCH-USD"}, suggestProducts("eth-usdd", testCoinbaseProducts, 2))
+	equals(t, 0, len(suggestProducts("dogecoin", testCoinbaseProducts, 3)))
+}
+
+func TestValidateCoinbaseProduct(t *testing.T) {
+	requests := 0
+	fakeServer(t, &coinbaseURL, fakeCoinbaseProducts(&requests))
+
+	ok(t, validateCoinbaseProduct(context.Background(), "btc-usd"))
+	ok(t, validateCoinbaseProduct(context.Background(), "ETH-BTC"))
+
+	err := validateCoinbaseProduct(context.Background(), "btcusd")
+	unknown, isUnknown := err.(*UnknownProductError)
+	assert(t, isUnknown, "unexpected error %v", err)
+	equals(t, "BTC-USD", unknown.Suggestions[0])
+	equals(t, "unknown product btcusd; did you mean BTC-USD or LTC-USD or BCH-USD?", err.Error())
+
+	_, err = NewQuoteFromCoinbase("btcusd", "2023-01-01", "2023-01-02", Daily)
+	_, isUnknown = err.(*UnknownProductError)
+	assert(t, isUnknown, "unexpected error %v", err)
+
+	// the products list is downloaded once
+	equals(t, 1, requests)
+
+	// and validation can be disabled
+	ValidateCoinbaseProducts = false
+	defer func() { ValidateCoinbaseProducts = true }()
+	ok(t, validateCoinbaseProduct(context.Background(), "btcusd"))
+	equals(t, 1, requests)
+}
+
+func TestValidateCoinbaseProductUnavailable(t *testing.T) {
+	fakeServer(t, &coinbaseURL, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	})
+
+	// an unavailable products list does not block downloads
+	ok(t, validateCoinbaseProduct(context.Background(), "btcusd"))
+}
+
+func TestCoinbaseGranularity(t *testing.T) {
+	accepted := map[int]bool{60: true, 300: true, 900: true, 3600: true, 21600: true, 86400: true}
+	for _, p := range coinbasePeriods {
+		assert(t, accepted[coinbaseGranularity(p)], "%s: granularity %d", p.Name(), coinbaseGranularity(p))
+	}
+	equals(t, len(accepted), len(coinbasePeriods))
+	for _, p := range []Period{Min3, Min30, Hour2, Hour4, Day3, Weekly} {
+		equals(t, 0, coinbaseGranularity(p))
+	}
+}
+
+func TestCoinbaseWindows(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	ok(t, err)
+	tests := []struct {
+		start, end time.Time
+		step       time.Duration
+		bars       int
+	}{
+		// spring forward, 23 hours in the day
+		{time.Date(2023, 3, 12, 0, 0, 0, 0, ny), time.Date(2023, 3, 13, 0, 0, 0, 0, ny), 5 * time.Minute, 23*12 + 1},
+		// fall back, 25 hours in the day
+		{time.Date(2023, 11, 5, 0, 0, 0, 0, ny), time.Date(2023, 11, 6, 0, 0, 0, 0, ny), time.Minute, 25*60 + 1},
+		// 719 hours across the change, and the last bar
+		{time.Date(2023, 3, 1, 0, 0, 0, 0, ny), time.Date(2023, 3, 31, 0, 0, 0, 0, ny), time.Hour, 30*24 - 1 + 1},
+		{day(2023, 1, 1), day(2023, 1, 1), 24 * time.Hour, 1},
+	}
+	for _, tt := range tests {
+		windows := coinbaseWindows(tt.start, tt.end, tt.step)
+		equals(t, (tt.bars+coinbaseMaxBars-1)/coinbaseMaxBars, len(windows))
+		equals(t, tt.start, windows[0][0])
+		equals(t, tt.end, windows[len(windows)-1][1])
+		bars := 0
+		for i, w := range windows {
+			n := int(w[1].Sub(w[0])/tt.step) + 1
+			assert(t, n <= coinbaseMaxBars, "window %d has %d bars", i, n)
+			if i > 0 {
+				equals(t, windows[i-1][1].Add(tt.step), w[0])
+			}
+			bars += n
+		}
+		equals(t, tt.bars, bars)
+	}
+}
+
+func TestCoinbaseCandles(t *testing.T) {
+	var windows []string
+	overlap := time.Duration(0) // candles repeated from the previous window
+	fakeServer(t, &coinbaseURL, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/products/BTC-USD/candles" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"NotFound"}`)
+			return
+		}
+		start, _ := time.Parse(time.RFC3339, r.URL.Query().Get("start"))
+		end, _ := time.Parse(time.RFC3339, r.URL.Query().Get("end"))
+		windows = append(windows, r.URL.Query().Get("start")+" "+r.URL.Query().Get("end"))
+		var bars []string
+		for d := end; !d.Before(start.Add(-overlap)); d = d.Add(-time.Hour) {
+			bars = append(bars, fmt.Sprintf("[%d,0.5,2,1,1.5,%d]", d.Unix(), d.Hour()))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(bars, ","))
+	})
+	ValidateCoinbaseProducts = false
+	defer func() { ValidateCoinbaseProducts = true }()
+
+	q, err := NewQuoteFromGdax("BTC-USD", "2023-03-01", "2023-03-20", Min60)
+	ok(t, err)
+	equals(t, []string{
+		"2023-03-01T00:00:00Z 2023-03-09T07:00:00Z",
+		"2023-03-09T08:00:00Z 2023-03-17T15:00:00Z",
+		"2023-03-17T16:00:00Z 2023-03-20T00:00:00Z",
+	}, windows)
+	equals(t, 19*24+1, len(q.Date))
+	for bar := 1; bar < len(q.Date); bar++ {
+		assert(t, q.Date[bar].After(q.Date[bar-1]), "bar %d out of order", bar)
+	}
+	equals(t, day(2023, 3, 20), q.Date[len(q.Date)-1])
+
+	// pages repeating the boundary candle read the same bars
+	overlap = time.Hour
+	repeated, err := NewQuoteFromCoinbase("BTC-USD", "2023-03-01", "2023-03-20", Min60)
+	ok(t, err)
+	equals(t, q.Date[0].Add(-time.Hour), repeated.Date[0])
+	equals(t, q.Date, repeated.Date[1:])
+
+	_, err = NewQuoteFromCoinbase("BTC-USDX", "2023-03-01", "2023-03-02", Daily)
+	assert(t, err != nil, "expected error")
+	equals(t, "coinbase BTC-USDX: NotFound", err.Error())
+}
+
+// fakeCoinbaseCandles - coinbase candles endpoint of hourly bars calling
+// cancel on request n and then waiting for the client to go away
+func fakeCoinbaseCandles(n int, cancel context.CancelFunc) http.HandlerFunc {
+	requests := 0
+	return func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == n {
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+		start, _ := time.Parse(time.RFC3339, r.URL.Query().Get("start"))
+		end, _ := time.Parse(time.RFC3339, r.URL.Query().Get("end"))
+		var bars []string
+		for d := end; !d.Before(start); d = d.Add(-time.Hour) {
+			bars = append(bars, fmt.Sprintf("[%d,0.5,2,1,1.5,1]", d.Unix()))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(bars, ","))
+	}
+}
+
+func TestCoinbaseCanceled(t *testing.T) {
+	ValidateCoinbaseProducts = false
+	defer func() { ValidateCoinbaseProducts = true }()
+
+	// canceled during the second of three pages
+	ctx, cancel := context.WithCancel(context.Background())
+	fakeServer(t, &coinbaseURL, fakeCoinbaseCandles(2, cancel))
+	q, err := NewQuoteFromGdaxCtx(ctx, "BTC-USD", "2023-03-01", "2023-03-20", Min60)
+	assert(t, errors.Is(err, context.Canceled), "not canceled: %v", err)
+	equals(t, "coinbase BTC-USD after 1 of 3 requests: context canceled", err.Error())
+	equals(t, coinbaseMaxBars, len(q.Date))
+	equals(t, day(2023, 3, 1), q.Date[0])
+	equals(t, "coinbase", q.Meta.Source)
+
+	// canceled during the second symbol
+	ctx, cancel = context.WithCancel(context.Background())
+	fakeServer(t, &coinbaseURL, fakeCoinbaseCandles(2, cancel))
+	quotes, err := NewQuotesFromGdaxSymsCtx(ctx, []string{"BTC-USD", "ETH-USD", "LTC-USD"}, "2023-03-01", "2023-03-02", Min60)
+	assert(t, errors.Is(err, context.Canceled), "not canceled: %v", err)
+	equals(t, "coinbase after 1 of 3 symbols: context canceled", err.Error())
+	equals(t, []string{"BTC-USD"}, quotes.Symbols())
+
+	// a context done before the first request
+	quotes, err = NewQuotesFromCoinbaseSymsCtx(ctx, []string{"BTC-USD"}, "2023-03-01", "2023-03-02", Min60)
+	assert(t, errors.Is(err, context.Canceled), "not canceled: %v", err)
+	equals(t, 0, len(quotes))
+}
@@ -0,0 +1,192 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// coinGeckoURL - base url of the CoinGecko api
+var coinGeckoURL = "https://api.coingecko.com"
+
+// coinGeckoPeriods - candle sizes of the CoinGecko ohlc endpoint that have a
+// Period, it buckets ranges over 30 days into 4 day candles
+var coinGeckoPeriods = []Period{Min30, Hour4}
+
+// coinGeckoDays - the days values the ohlc endpoint accepts, shortest first,
+// max beyond them
+var coinGeckoDays = []int{1, 7, 14, 30, 90, 180, 365}
+
+// coinGeckoCandle - candle size CoinGecko picks for days of history
+func coinGeckoCandle(days int) time.Duration {
+	switch {
+	case days <= 2:
+		return 30 * time.Minute
+	case days <= 30:
+		return 4 * time.Hour
+	}
+	return 4 * 24 * time.Hour
+}
+
+// coinGeckoPair - coin id and vs currency of a symbol such as bitcoin:usd
+func coinGeckoPair(symbol string) (string, string, error) {
+	parts := strings.Split(symbol, ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("coingecko: invalid symbol '%s', must be like bitcoin:usd", symbol)
+	}
+	return parts[0], parts[1], nil
+}
+
+// NewQuoteFromCoinGecko - CoinGecko historical prices of a coin such as
+// bitcoin in vsCurrency, no api key needed, with the symbol coinID:vsCurrency.
+// CoinGecko picks the candle size from how far back startDate is: 30 minutes
+// for the last day, 4 hours up to 30 days and 4 days beyond. Bars are
+// timestamped by their start in UTC and cut to the date range, Meta.Period is
+// empty for 4 day candles. Volume is the 24 hour volume in vsCurrency nearest
+// each candle close, zero when there is none.
+func NewQuoteFromCoinGecko(coinID, vsCurrency, startDate, endDate string) (Quote, error) {
+	return NewQuoteFromCoinGeckoCtx(context.Background(), coinID, vsCurrency, startDate, endDate)
+}
+
+// NewQuoteFromCoinGeckoCtx - NewQuoteFromCoinGecko, canceled with ctx. The
+// candles come without volume until the second request, a download canceled
+// between them returns no bars.
+func NewQuoteFromCoinGeckoCtx(ctx context.Context, coinID, vsCurrency, startDate, endDate string) (Quote, error) {
+
+	symbol := coinID + ":" + vsCurrency
+	from := ParseDateString(startDate)
+	to := parseEndDate(endDate)
+
+	need := int(math.Ceil(time.Since(from).Hours() / 24))
+	days := "max"
+	candle := coinGeckoCandle(math.MaxInt32)
+	for _, d := range coinGeckoDays {
+		if d >= need {
+			days, candle = strconv.Itoa(d), coinGeckoCandle(d)
+			break
+		}
+	}
+
+	params := url.Values{}
+	params.Set("vs_currency", vsCurrency)
+	params.Set("days", days)
+	var ohlc [][5]float64
+	if err := coinGeckoGet(ctx, fmt.Sprintf("%s/api/v3/coins/%s/ohlc?%s", coinGeckoURL, url.PathEscape(coinID), params.Encode()), &ohlc); err != nil {
+		Log.Printf("coingecko %s error: %v\n", symbol, err)
+		return NewQuote("", 0), err
+	}
+	if delayContext(ctx, "coingecko") != nil {
+		return NewQuote("", 0), interrupted(ctx, "coingecko %s", symbol)
+	}
+
+	params = url.Values{}
+	params.Set("vs_currency", vsCurrency)
+	params.Set("from", strconv.FormatInt(from.Add(-candle).Unix(), 10))
+	params.Set("to", strconv.FormatInt(to.Add(candle).Unix(), 10))
+	var chart struct {
+		TotalVolumes [][2]float64 `json:"total_volumes"`
+	}
+	if err := coinGeckoGet(ctx, fmt.Sprintf("%s/api/v3/coins/%s/market_chart/range?%s", coinGeckoURL, url.PathEscape(coinID), params.Encode()), &chart); err != nil {
+		Log.Printf("coingecko %s error: %v\n", symbol, err)
+		return NewQuote("", 0), err
+	}
+
+	quote := coinGeckoMerge(symbol, ohlc, chart.TotalVolumes, candle)
+	period := Period("")
+	for _, p := range coinGeckoPeriods {
+		if p.Duration() == candle {
+			period = p
+		}
+	}
+	return quote.between(from, to).withMeta("coingecko", period, false), nil
+}
+
+// coinGeckoMerge - bars of ohlc candles stamped by their close, with the
+// volume nearest each close within half a candle
+func coinGeckoMerge(symbol string, ohlc [][5]float64, volumes [][2]float64, candle time.Duration) Quote {
+
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i][0] < volumes[j][0] })
+	half := float64(candle/time.Millisecond) / 2
+
+	quote := NewQuote(symbol, 0)
+	for _, c := range ohlc {
+		ms := c[0]
+		volume := 0.0
+		i := sort.Search(len(volumes), func(i int) bool { return volumes[i][0] >= ms })
+		best := half
+		for _, j := range []int{i - 1, i} {
+			if j >= 0 && j < len(volumes) && math.Abs(volumes[j][0]-ms) <= best {
+				volume, best = volumes[j][1], math.Abs(volumes[j][0]-ms)
+			}
+		}
+		closed := time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC()
+		quote.PushBar(Bar{Date: closed.Add(-candle), Open: c[1], High: c[2], Low: c[3], Close: c[4], Volume: volume})
+	}
+	return quote
+}
+
+// coinGeckoGet - decode a CoinGecko response into v, an error for an error
+// reply such as {"error":"coin not found"}. Canceled with ctx.
+func coinGeckoGet(ctx context.Context, url string, v interface{}) error {
+
+	if err := waitRate(ctx, "coingecko"); err != nil {
+		return err
+	}
+	client := httpClient()
+	resp, err := getContext(ctx, client, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	contents, _ := ioutil.ReadAll(resp.Body)
+
+	var reply struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(contents, &reply) == nil && reply.Error != "" {
+		return fmt.Errorf("coingecko: %s", reply.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("coingecko: %s", resp.Status)
+	}
+	return json.Unmarshal(contents, v)
+}
+
+// NewQuotesFromCoinGeckoSyms - create a list of prices from symbols such as
+// bitcoin:usd in string array
+func NewQuotesFromCoinGeckoSyms(symbols []string, startDate, endDate string) (Quotes, error) {
+	return NewQuotesFromCoinGeckoSymsCtx(context.Background(), symbols, startDate, endDate)
+}
+
+// NewQuotesFromCoinGeckoSymsCtx - NewQuotesFromCoinGeckoSyms,
+// canceled with ctx. The quotes downloaded before are returned with the
+// wrapped error of ctx.
+func NewQuotesFromCoinGeckoSymsCtx(ctx context.Context, symbols []string, startDate, endDate string) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, symbol := range symbols {
+		coinID, vsCurrency, err := coinGeckoPair(symbol)
+		var quote Quote
+		if err == nil {
+			quote, err = NewQuoteFromCoinGeckoCtx(ctx, coinID, vsCurrency, startDate, endDate)
+			if err != nil && ctx.Err() != nil {
+				return quotes, interrupted(ctx, "coingecko after %d of %d symbols", i, len(symbols))
+			}
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + symbol)
+		}
+		delayContext(ctx, "coingecko")
+	}
+	return quotes, nil
+}
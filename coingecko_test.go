@@ -0,0 +1,66 @@
+package quote
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoinGeckoMerge(t *testing.T) {
+	// candles close at 04:00, 08:00 and 12:00, volumes are sampled off the hour
+	ohlc := [][5]float64{
+		{1677643200000, 23150, 23300, 23100, 23250},
+		{1677657600000, 23250, 23400, 23200, 23350},
+		{1677672000000, 23350, 23500, 23300, 23450},
+	}
+	volumes := [][2]float64{
+		{1677658200000, 2.2e10}, // 08:10
+		{1677643500000, 2.1e10}, // 04:05
+		{1677650400000, 9.9e10}, // 06:00, nearer other samples
+	}
+	q := coinGeckoMerge("bitcoin:usd", ohlc, volumes, 4*time.Hour)
+	equals(t, []time.Time{
+		time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 3, 1, 4, 0, 0, 0, time.UTC),
+		time.Date(2023, 3, 1, 8, 0, 0, 0, time.UTC),
+	}, q.Date)
+	equals(t, []float64{23250, 23350, 23450}, q.Close)
+	equals(t, []float64{2.1e10, 2.2e10, 0}, q.Volume)
+}
+
+func TestCoinGecko(t *testing.T) {
+	var days string
+	fakeServer(t, &coinGeckoURL, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/v3/coins/nocoin/"):
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"coin not found"}`)
+		case strings.HasSuffix(r.URL.Path, "/ohlc"):
+			days = r.URL.Query().Get("days")
+			// two candles closing at the last 4 hour boundaries
+			last := time.Now().UTC().Truncate(4 * time.Hour)
+			fmt.Fprintf(w, "[[%d,1,2,0.5,1.5],[%d,1.5,2.5,1,2]]",
+				last.Add(-4*time.Hour).UnixNano()/1e6, last.UnixNano()/1e6)
+		default:
+			fmt.Fprint(w, `{"prices":[],"market_caps":[],"total_volumes":[]}`)
+		}
+	})
+
+	start := time.Now().UTC().AddDate(0, 0, -10).Format("2006-01-02")
+	q, err := NewQuoteFromCoinGecko("bitcoin", "usd", start, "")
+	ok(t, err)
+	equals(t, "14", days)
+	equals(t, 2, len(q.Date))
+	equals(t, []float64{0, 0}, q.Volume)
+	equals(t, Hour4, q.Meta.Period)
+	equals(t, "USD", q.Meta.Currency)
+
+	_, err = NewQuoteFromCoinGecko("nocoin", "usd", start, "")
+	equals(t, "coingecko: coin not found", fmt.Sprint(err))
+
+	_, err = fetchSource(context.Background(), SourceSpec{Name: "coingecko"}, "bitcoin:usd", time.Now().AddDate(0, 0, -10), time.Now(), Min30)
+	assert(t, err != nil, "30m bars accepted for 10 days")
+}
@@ -0,0 +1,194 @@
+package quote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// alignKey - key used to match bars of two quotes. Daily and coarser bars
+// match on calendar date since sources disagree on the time of day.
+func alignKey(t time.Time, period Period) string {
+	switch period {
+	case Daily, Day3, Weekly, Monthly, Yearly:
+		return t.Format("2006-01-02")
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// alignDates - inner-align the dates of a and b, returning the indexes of
+// matching bars and the dates present in only one of the quotes
+func alignDates(a, b Quote, period Period) (ia, ib []int, onlyA, onlyB []time.Time) {
+	index := make(map[string]int, len(b.Date))
+	for bar, d := range b.Date {
+		index[alignKey(d, period)] = bar
+	}
+	matched := make(map[int]bool, len(b.Date))
+	for bar, d := range a.Date {
+		if j, ok := index[alignKey(d, period)]; ok && !matched[j] {
+			ia = append(ia, bar)
+			ib = append(ib, j)
+			matched[j] = true
+		} else {
+			onlyA = append(onlyA, d)
+		}
+	}
+	for bar, d := range b.Date {
+		if !matched[bar] {
+			onlyB = append(onlyB, d)
+		}
+	}
+	return ia, ib, onlyA, onlyB
+}
+
+// barSpacing - smallest time between consecutive bars of q, which unlike
+// the average is not inflated by missing bars. Zero with fewer than two bars.
+func (q Quote) barSpacing() time.Duration {
+	var spacing time.Duration
+	for bar := 1; bar < len(q.Date); bar++ {
+		gap := q.Date[bar].Sub(q.Date[bar-1])
+		if gap > 0 && (spacing == 0 || gap < spacing) {
+			spacing = gap
+		}
+	}
+	return spacing
+}
+
+// alignPeriod - period to align a and b on, an error if their bars are of
+// different periods (e.g. daily and intraday)
+func alignPeriod(a, b Quote) (Period, error) {
+	if a.intraday() != b.intraday() {
+		return "", fmt.Errorf("cannot align %s and %s: daily and intraday bars", a.Symbol, b.Symbol)
+	}
+	sa, sb := a.barSpacing(), b.barSpacing()
+	if sa > 0 && sb > 0 && (float64(sa) > 1.5*float64(sb) || float64(sb) > 1.5*float64(sa)) {
+		return "", fmt.Errorf("cannot align %s and %s: bars are %v and %v apart", a.Symbol, b.Symbol, sa, sb)
+	}
+	if a.intraday() {
+		return Min1, nil
+	}
+	return Daily, nil
+}
+
+// FieldDeviation - relative deviation of one field between two sources
+type FieldDeviation struct {
+	Field   string
+	Mean    float64 // mean of |a-b|/|a| over aligned bars
+	Max     float64 // largest |a-b|/|a|
+	MaxDate time.Time
+}
+
+// DiffReport - comparison of the same symbol and range from two sources
+type DiffReport struct {
+	Symbol      string
+	A           string // name of the first source
+	B           string // name of the second source
+	AdjustmentA string // price adjustment applied by the first source
+	AdjustmentB string // price adjustment applied by the second source
+	Aligned     int    // bars present in both
+	OnlyA       []time.Time
+	OnlyB       []time.Time
+	Fields      []FieldDeviation // open, high, low, close, volume
+}
+
+// relDiff - relative difference of b from a
+func relDiff(a, b float64) float64 {
+	if a == b {
+		return 0
+	}
+	if a == 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(a-b) / math.Abs(a)
+}
+
+// newDiffReport - compare two quotes of the same symbol bar by bar
+func newDiffReport(a, b Quote, period Period) DiffReport {
+	ia, ib, onlyA, onlyB := alignDates(a, b, period)
+	r := DiffReport{Symbol: a.Symbol, Aligned: len(ia), OnlyA: onlyA, OnlyB: onlyB}
+	fields := []struct {
+		name string
+		a, b []float64
+	}{
+		{"open", a.Open, b.Open},
+		{"high", a.High, b.High},
+		{"low", a.Low, b.Low},
+		{"close", a.Close, b.Close},
+		{"volume", a.Volume, b.Volume},
+	}
+	for _, f := range fields {
+		fd := FieldDeviation{Field: f.name}
+		for k := range ia {
+			d := relDiff(f.a[ia[k]], f.b[ib[k]])
+			fd.Mean += d
+			if d > fd.Max {
+				fd.Max = d
+				fd.MaxDate = a.Date[ia[k]]
+			}
+		}
+		if len(ia) > 0 {
+			fd.Mean /= float64(len(ia))
+		}
+		r.Fields = append(r.Fields, fd)
+	}
+	return r
+}
+
+// MaxDeviation - largest relative deviation over the given fields, or over
+// all price fields (open, high, low, close) when none are given
+func (r DiffReport) MaxDeviation(fields ...string) float64 {
+	if len(fields) == 0 {
+		fields = []string{"open", "high", "low", "close"}
+	}
+	max := 0.0
+	for _, fd := range r.Fields {
+		for _, f := range fields {
+			if fd.Field == f && fd.Max > max {
+				max = fd.Max
+			}
+		}
+	}
+	return max
+}
+
+// String - render report as human readable text
+func (r DiffReport) String() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("%s: %s (%s) vs %s (%s)\n", r.Symbol, r.A, r.AdjustmentA, r.B, r.AdjustmentB))
+	buffer.WriteString(fmt.Sprintf("aligned bars: %d, only in %s: %d, only in %s: %d\n", r.Aligned, r.A, len(r.OnlyA), r.B, len(r.OnlyB)))
+	buffer.WriteString(fmt.Sprintf("%-8s %12s %12s  %s\n", "field", "mean dev", "max dev", "max date"))
+	for _, fd := range r.Fields {
+		date := ""
+		if !fd.MaxDate.IsZero() {
+			date = fd.MaxDate.Format("2006-01-02 15:04")
+		}
+		buffer.WriteString(fmt.Sprintf("%-8s %11.4f%% %11.4f%%  %s\n", fd.Field, 100*fd.Mean, 100*fd.Max, date))
+	}
+	if r.AdjustmentA != r.AdjustmentB {
+		buffer.WriteString("warning: sources use different price adjustments, consider comparing raw closes only\n")
+	}
+	return buffer.String()
+}
+
+// CompareSources - download the same symbol and range from two sources and
+// report how far they deviate on the dates both provide
+func CompareSources(ctx context.Context, symbol string, from, to time.Time, period Period, a, b SourceSpec) (DiffReport, error) {
+
+	qa, err := NewQuoteFromSource(ctx, a, symbol, from, to, period)
+	if err != nil {
+		return DiffReport{}, fmt.Errorf("%s: %v", a.Name, err)
+	}
+	delay(b.Name)
+	qb, err := NewQuoteFromSource(ctx, b, symbol, from, to, period)
+	if err != nil {
+		return DiffReport{}, fmt.Errorf("%s: %v", b.Name, err)
+	}
+
+	r := newDiffReport(qa, qb, period)
+	r.Symbol = symbol
+	r.A, r.B = a.Name, b.Name
+	r.AdjustmentA, r.AdjustmentB = a.Adjustment(), b.Adjustment()
+	return r, nil
+}
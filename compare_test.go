@@ -0,0 +1,67 @@
+package quote
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlignDates(t *testing.T) {
+	a := cleanDaily("spy", 5)
+	b := removeBar(cleanDaily("spy", 6), 1)
+	// same calendar day at a different time of day still aligns for daily data
+	b.Date[0] = b.Date[0].Add(16 * time.Hour)
+
+	ia, ib, onlyA, onlyB := alignDates(a, b, Daily)
+	equals(t, []int{0, 2, 3, 4}, ia)
+	equals(t, []int{0, 1, 2, 3}, ib)
+	equals(t, []time.Time{a.Date[1]}, onlyA)
+	equals(t, []time.Time{b.Date[4]}, onlyB)
+
+	// intraday data aligns on the exact instant
+	_, _, onlyA, _ = alignDates(a, b, Min60)
+	equals(t, 2, len(onlyA))
+}
+
+func TestDiffReport(t *testing.T) {
+	a := cleanDaily("spy", 4)
+	b := cleanDaily("spy", 4)
+	r := newDiffReport(a, b, Daily)
+	equals(t, 4, r.Aligned)
+	equals(t, 0.0, r.MaxDeviation())
+
+	b.Close[2] = 111.1 // 10% off
+	b.Volume[1] = 500
+	r = newDiffReport(a, b, Daily)
+	equals(t, "close", r.Fields[3].Field)
+	assert(t, r.Fields[3].Max > 0.0999 && r.Fields[3].Max < 0.1001, "unexpected max %v", r.Fields[3].Max)
+	assert(t, r.Fields[3].Mean > 0.0249 && r.Fields[3].Mean < 0.0251, "unexpected mean %v", r.Fields[3].Mean)
+	equals(t, a.Date[2], r.Fields[3].MaxDate)
+	equals(t, 0.5, r.Fields[4].Max)
+	equals(t, r.Fields[3].Max, r.MaxDeviation())
+	equals(t, 0.5, r.MaxDeviation("volume"))
+	equals(t, 0.0, r.MaxDeviation("open"))
+
+	r.A, r.B, r.AdjustmentA, r.AdjustmentB = "yahoo", "tiingo", "raw", "adjusted"
+	assert(t, strings.Contains(r.String(), "different price adjustments"), "missing adjustment warning")
+}
+
+func TestCompareSourcesErrors(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	_, err := CompareSources(context.Background(), "spy", from, to, Daily, SourceSpec{Name: "nope"}, SourceSpec{Name: "yahoo"})
+	assert(t, err != nil, "expected invalid source error")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = CompareSources(ctx, "spy", from, to, Daily, SourceSpec{Name: "yahoo"}, SourceSpec{Name: "tiingo"})
+	assert(t, err != nil, "expected context error")
+}
+
+func TestSourceSpecAdjustment(t *testing.T) {
+	equals(t, "adjusted", SourceSpec{Name: "yahoo", Adjust: true}.Adjustment())
+	equals(t, "raw", SourceSpec{Name: "yahoo"}.Adjustment())
+	equals(t, "adjusted", SourceSpec{Name: "tiingo"}.Adjustment())
+	equals(t, "none", SourceSpec{Name: "binance"}.Adjustment())
+}
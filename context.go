@@ -0,0 +1,51 @@
+package quote
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// newRequest - GET request of url, canceled with ctx
+func newRequest(ctx context.Context, url string) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, "GET", url, nil)
+}
+
+// getContext - GET url with client, canceled with ctx
+func getContext(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := newRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+// sleepContext - sleep for d, returning early with the error of ctx if it is
+// done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// interrupted - the error of ctx wrapped with what was stopped, nil if ctx is
+// not done. errors.Is matches context.Canceled or DeadlineExceeded.
+func interrupted(ctx context.Context, format string, args ...interface{}) error {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), err)
+}
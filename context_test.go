@@ -0,0 +1,33 @@
+package quote
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSleepContext(t *testing.T) {
+	ok(t, sleepContext(context.Background(), 0))
+	ok(t, sleepContext(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	equals(t, context.Canceled, sleepContext(ctx, time.Hour))
+	assert(t, time.Since(start) < time.Second, "slept after cancel")
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	equals(t, context.DeadlineExceeded, sleepContext(ctx, time.Hour))
+}
+
+func TestInterrupted(t *testing.T) {
+	ok(t, interrupted(context.Background(), "yahoo spy"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := interrupted(ctx, "yahoo %s", "spy")
+	equals(t, "yahoo spy: context canceled", err.Error())
+	assert(t, errors.Is(err, context.Canceled), "not canceled: %v", err)
+}
@@ -0,0 +1,122 @@
+package quote
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// comoments - streaming means and co-moments of paired samples, updated in a
+// single pass with Welford's method. Samples can be removed again, which makes
+// rolling windows O(1) per bar.
+type comoments struct {
+	n             float64
+	meanX, meanY  float64
+	sxx, syy, sxy float64 // sums of squared deviations and cross deviations
+}
+
+// add - add sample x, y
+func (m *comoments) add(x, y float64) {
+	m.n++
+	dx := x - m.meanX
+	dy := y - m.meanY
+	m.meanX += dx / m.n
+	m.meanY += dy / m.n
+	m.sxx += dx * (x - m.meanX)
+	m.syy += dy * (y - m.meanY)
+	m.sxy += dx * (y - m.meanY)
+}
+
+// remove - remove sample x, y previously added
+func (m *comoments) remove(x, y float64) {
+	if m.n <= 1 {
+		*m = comoments{}
+		return
+	}
+	m.n--
+	dx := x - m.meanX
+	dy := y - m.meanY
+	m.meanX -= dx / m.n
+	m.meanY -= dy / m.n
+	m.sxx -= dx * (x - m.meanX)
+	m.syy -= dy * (y - m.meanY)
+	m.sxy -= dx * (y - m.meanY)
+}
+
+// zeroVariance - true if s, a sum of squared deviations, is zero up to rounding
+func (m *comoments) zeroVariance(s, mean float64) bool {
+	return s <= 1e-12*m.n*(mean*mean+1e-300)
+}
+
+// correlation - Pearson correlation, NaN if either variance is zero
+func (m *comoments) correlation() float64 {
+	if m.n < 2 || m.zeroVariance(m.sxx, m.meanX) || m.zeroVariance(m.syy, m.meanY) {
+		return math.NaN()
+	}
+	return m.sxy / math.Sqrt(m.sxx*m.syy)
+}
+
+// beta - regression slope of y on x, cov(x,y)/var(x), NaN if x is constant
+func (m *comoments) beta() float64 {
+	if m.n < 2 || m.zeroVariance(m.sxx, m.meanX) {
+		return math.NaN()
+	}
+	return m.sxy / m.sxx
+}
+
+// alignedReturns - close to close returns of a and b over the dates both
+// quotes have. Each return spans consecutive aligned bars, so a bar missing
+// from one quote makes the return on the next common date cover both days.
+func alignedReturns(a, b Quote) ([]time.Time, []float64, []float64, error) {
+	period, err := alignPeriod(a, b)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ia, ib, _, _ := alignDates(a, b, period)
+	if len(ia) < 2 {
+		return nil, nil, nil, nil
+	}
+	dates := make([]time.Time, len(ia)-1)
+	ra := make([]float64, len(ia)-1)
+	rb := make([]float64, len(ia)-1)
+	for k := 1; k < len(ia); k++ {
+		pa, pb := a.Close[ia[k-1]], b.Close[ib[k-1]]
+		if pa == 0 || pb == 0 {
+			return nil, nil, nil, fmt.Errorf("zero close on %s", a.Date[ia[k-1]].Format("2006-01-02 15:04"))
+		}
+		dates[k-1] = a.Date[ia[k]]
+		ra[k-1] = a.Close[ia[k]]/pa - 1
+		rb[k-1] = b.Close[ib[k]]/pb - 1
+	}
+	return dates, ra, rb, nil
+}
+
+// RollingCorrelation - Pearson correlation of the close to close returns of a
+// and b over a rolling window of returns, after inner-aligning their dates.
+// The dates returned are those of the returns (all aligned dates but the
+// first); values are NaN for the first window-1 returns and for windows in
+// which either return series is constant.
+func RollingCorrelation(a, b Quote, window int) ([]time.Time, []float64, error) {
+	if window < 2 {
+		return nil, nil, fmt.Errorf("invalid correlation window %d, must be at least 2", window)
+	}
+	dates, ra, rb, err := alignedReturns(a, b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	corr := make([]float64, len(dates))
+	var m comoments
+	for i := range dates {
+		m.add(ra[i], rb[i])
+		if i >= window {
+			m.remove(ra[i-window], rb[i-window])
+		}
+		if i < window-1 {
+			corr[i] = math.NaN()
+			continue
+		}
+		corr[i] = m.correlation()
+	}
+	return dates, corr, nil
+}
@@ -0,0 +1,105 @@
+package quote
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// randomWalk - n daily bars of a random walk starting on 2023-01-02
+func randomWalk(symbol string, n int, rng *rand.Rand) Quote {
+	q := NewQuote(symbol, n)
+	c := 100.0
+	for bar := 0; bar < n; bar++ {
+		c *= 1 + 0.02*rng.NormFloat64()
+		q.Date[bar] = day(2023, 1, 2).AddDate(0, 0, bar)
+		q.Open[bar], q.High[bar], q.Low[bar], q.Close[bar] = c, c, c, c
+	}
+	return q
+}
+
+// bruteCorrelation - Pearson correlation computed directly
+func bruteCorrelation(x, y []float64) float64 {
+	var mx, my float64
+	for i := range x {
+		mx += x[i]
+		my += y[i]
+	}
+	mx /= float64(len(x))
+	my /= float64(len(y))
+	var sxx, syy, sxy float64
+	for i := range x {
+		sxx += (x[i] - mx) * (x[i] - mx)
+		syy += (y[i] - my) * (y[i] - my)
+		sxy += (x[i] - mx) * (y[i] - my)
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+func TestRollingCorrelation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	a := randomWalk("a", 300, rng)
+	b := randomWalk("b", 300, rng)
+	// make b partly follow a
+	for bar := 1; bar < len(b.Close); bar++ {
+		b.Close[bar] = b.Close[bar]*0.5 + a.Close[bar]*0.5
+	}
+
+	window := 20
+	dates, corr, err := RollingCorrelation(a, b, window)
+	ok(t, err)
+	equals(t, 299, len(corr))
+	equals(t, a.Date[1], dates[0])
+	_, ra, rb, _ := alignedReturns(a, b)
+	for i := range corr {
+		if i < window-1 {
+			assert(t, math.IsNaN(corr[i]), "warm-up %d not NaN: %v", i, corr[i])
+			continue
+		}
+		want := bruteCorrelation(ra[i-window+1:i+1], rb[i-window+1:i+1])
+		assert(t, math.Abs(corr[i]-want) < 1e-9, "window %d: %v want %v", i, corr[i], want)
+	}
+}
+
+func TestRollingCorrelationMisaligned(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	a := randomWalk("a", 10, rng)
+	b := removeBar(a.copy(), 4)
+	b.Symbol = "b"
+
+	dates, corr, err := RollingCorrelation(a, b, 3)
+	ok(t, err)
+	// the return spanning the missing bar is taken over both days
+	equals(t, 8, len(dates))
+	equals(t, a.Date[5], dates[3])
+	// identical closes on the common dates correlate perfectly
+	for i := 2; i < len(corr); i++ {
+		assert(t, math.Abs(corr[i]-1) < 1e-9, "corr %d = %v", i, corr[i])
+	}
+}
+
+func TestRollingCorrelationZeroVariance(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	a := randomWalk("a", 10, rng)
+	flat := cleanDaily("flat", 10)
+	for bar := range flat.Close {
+		flat.Date[bar] = a.Date[bar]
+		flat.Close[bar] = 50
+	}
+	_, corr, err := RollingCorrelation(a, flat, 3)
+	ok(t, err)
+	for i := range corr {
+		assert(t, math.IsNaN(corr[i]), "corr %d = %v", i, corr[i])
+	}
+
+	_, _, err = RollingCorrelation(a, flat, 1)
+	assert(t, err != nil, "expected window error")
+
+	intraday := a.copy()
+	for bar := range intraday.Date {
+		intraday.Date[bar] = intraday.Date[bar].Add(time.Duration(bar) * time.Minute)
+	}
+	_, _, err = RollingCorrelation(a, intraday, 3)
+	assert(t, err != nil, "expected alignment error")
+}
@@ -0,0 +1,204 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cryptoCompareURL - base url of the CryptoCompare api
+var cryptoCompareURL = "https://min-api.cryptocompare.com"
+
+// cryptoCompareLimit - most bars a histo request returns, one more than the
+// limit parameter
+const cryptoCompareLimit = 2000
+
+// cryptoComparePeriods - periods of the CryptoCompare histo endpoints
+var cryptoComparePeriods = []Period{Min1, Min3, Min5, Min15, Min30, Min60, Hour2, Hour4, Hour6, Hour8, Hour12, Daily, Day3}
+
+// cryptoCompareHisto - endpoint and aggregate of a supported period
+type cryptoCompareHisto struct {
+	endpoint  string
+	aggregate int
+}
+
+// cryptoCompareHistos - histo endpoint and aggregate of each supported period
+var cryptoCompareHistos = map[Period]cryptoCompareHisto{
+	Min1:   {"histominute", 1},
+	Min3:   {"histominute", 3},
+	Min5:   {"histominute", 5},
+	Min15:  {"histominute", 15},
+	Min30:  {"histominute", 30},
+	Min60:  {"histohour", 1},
+	Hour2:  {"histohour", 2},
+	Hour4:  {"histohour", 4},
+	Hour6:  {"histohour", 6},
+	Hour8:  {"histohour", 8},
+	Hour12: {"histohour", 12},
+	Daily:  {"histoday", 1},
+	Day3:   {"histoday", 3},
+}
+
+// cryptoCompareResponse - a histo response, Response is Success or Error
+type cryptoCompareResponse struct {
+	Response string `json:"Response"`
+	Message  string `json:"Message"`
+	Data     struct {
+		TimeFrom int64 `json:"TimeFrom"`
+		TimeTo   int64 `json:"TimeTo"`
+		Data     []struct {
+			Time       int64   `json:"time"` // bar start, seconds since the epoch
+			Open       float64 `json:"open"`
+			High       float64 `json:"high"`
+			Low        float64 `json:"low"`
+			Close      float64 `json:"close"`
+			VolumeFrom float64 `json:"volumefrom"`
+		} `json:"Data"`
+	} `json:"Data"`
+}
+
+// cryptoComparePair - from and to symbols of a pair such as BTC/USD
+func cryptoComparePair(symbol string) (string, string, error) {
+	parts := strings.Split(symbol, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("cryptocompare: invalid pair '%s', must be like BTC/USD", symbol)
+	}
+	return parts[0], parts[1], nil
+}
+
+// NewQuoteFromCryptoCompare - CryptoCompare aggregate historical prices of fsym
+// in tsym across exchanges, e.g. BTC in USD, with the symbol fsym/tsym. Bars
+// are timestamped in UTC and requested 2000 at a time back from endDate, with
+// Delay between requests. Volume is in fsym. Bars before the pair traded,
+// which CryptoCompare fills with zeros, are dropped.
+func NewQuoteFromCryptoCompare(fsym, tsym, startDate, endDate string, period Period, apiKey string) (Quote, error) {
+	return NewQuoteFromCryptoCompareCtx(context.Background(), fsym, tsym, startDate, endDate, period, apiKey)
+}
+
+// NewQuoteFromCryptoCompareCtx - NewQuoteFromCryptoCompare, canceled with ctx.
+// A download canceled between its requests returns the bars so far with the
+// wrapped error of ctx.
+func NewQuoteFromCryptoCompareCtx(ctx context.Context, fsym, tsym, startDate, endDate string, period Period, apiKey string) (Quote, error) {
+
+	histo, ok := cryptoCompareHistos[period]
+	if !ok {
+		return NewQuote("", 0), ValidatePeriod("cryptocompare", period)
+	}
+	symbol := fsym + "/" + tsym
+	from := ParseDateString(startDate)
+	to := parseEndDate(endDate)
+
+	// pages newest first
+	var pages []Quote
+	bars := func() Quote {
+		quote := NewQuote(symbol, 0)
+		for i := len(pages) - 1; i >= 0; i-- {
+			quote.appendQuote(pages[i])
+		}
+		return quote.between(from, to).withMeta("cryptocompare", period, false)
+	}
+	toTs := to.Unix()
+	for {
+		if len(pages) > 0 && delayContext(ctx, "cryptocompare") != nil {
+			return bars(), interrupted(ctx, "cryptocompare %s after %d requests", symbol, len(pages))
+		}
+		params := url.Values{}
+		params.Set("fsym", fsym)
+		params.Set("tsym", tsym)
+		params.Set("limit", strconv.Itoa(cryptoCompareLimit))
+		params.Set("aggregate", strconv.Itoa(histo.aggregate))
+		params.Set("toTs", strconv.FormatInt(toTs, 10))
+		if apiKey != "" {
+			params.Set("api_key", apiKey)
+		}
+		res, err := cryptoComparePage(ctx, fmt.Sprintf("%s/data/v2/%s?%s", cryptoCompareURL, histo.endpoint, params.Encode()))
+		if err != nil && ctx.Err() != nil {
+			return bars(), interrupted(ctx, "cryptocompare %s after %d requests", symbol, len(pages))
+		}
+		if err != nil {
+			Log.Printf("cryptocompare %s error: %v\n", symbol, err)
+			return NewQuote("", 0), err
+		}
+
+		// zero bars precede the first trade, the history starts in this page
+		page := NewQuote(symbol, 0)
+		started := false
+		for _, bar := range res.Data.Data {
+			if bar.Open == 0 && bar.High == 0 && bar.Low == 0 && bar.Close == 0 {
+				started = true
+				continue
+			}
+			page.PushBar(Bar{Date: time.Unix(bar.Time, 0).UTC(), Open: bar.Open, High: bar.High, Low: bar.Low, Close: bar.Close, Volume: bar.VolumeFrom})
+		}
+		pages = append(pages, page)
+		if started || len(res.Data.Data) == 0 || res.Data.TimeFrom <= from.Unix() {
+			break
+		}
+		toTs = res.Data.TimeFrom - 1
+	}
+	return bars(), nil
+}
+
+// cryptoComparePage - a histo response, an error for an Error response,
+// canceled with ctx
+func cryptoComparePage(ctx context.Context, url string) (cryptoCompareResponse, error) {
+
+	var res cryptoCompareResponse
+	if err := waitRate(ctx, "cryptocompare"); err != nil {
+		return res, err
+	}
+	client := httpClient()
+	resp, err := getContext(ctx, client, url)
+	if err != nil {
+		return res, err
+	}
+	defer resp.Body.Close()
+	contents, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(contents, &res); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return res, fmt.Errorf("cryptocompare: %s", resp.Status)
+		}
+		return res, err
+	}
+	if res.Response == "Error" {
+		return res, fmt.Errorf("cryptocompare: %s", res.Message)
+	}
+	return res, nil
+}
+
+// NewQuotesFromCryptoCompareSyms - create a list of prices from pairs such as
+// BTC/USD in string array
+func NewQuotesFromCryptoCompareSyms(symbols []string, startDate, endDate string, period Period, apiKey string) (Quotes, error) {
+	return NewQuotesFromCryptoCompareSymsCtx(context.Background(), symbols, startDate, endDate, period, apiKey)
+}
+
+// NewQuotesFromCryptoCompareSymsCtx - NewQuotesFromCryptoCompareSyms,
+// canceled with ctx. The quotes downloaded before are returned with the
+// wrapped error of ctx.
+func NewQuotesFromCryptoCompareSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period, apiKey string) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, symbol := range symbols {
+		fsym, tsym, err := cryptoComparePair(symbol)
+		var quote Quote
+		if err == nil {
+			quote, err = NewQuoteFromCryptoCompareCtx(ctx, fsym, tsym, startDate, endDate, period, apiKey)
+			if err != nil && ctx.Err() != nil {
+				return quotes, interrupted(ctx, "cryptocompare after %d of %d symbols", i, len(symbols))
+			}
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + symbol)
+		}
+		delayContext(ctx, "cryptocompare")
+	}
+	return quotes, nil
+}
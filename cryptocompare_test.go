@@ -0,0 +1,69 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeCryptoCompare - histohour endpoint serving limit+1 bars up to toTs,
+// zeros before listed, counting its requests
+func fakeCryptoCompare(listed time.Time, requests *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.URL.Query().Get("fsym") == "NOPE" {
+			fmt.Fprint(w, `{"Response":"Error","Message":"There is no data for the symbol NOPE .","HasWarning":false,"Type":2,"Data":{}}`)
+			return
+		}
+		toTs, _ := strconv.ParseInt(r.URL.Query().Get("toTs"), 10, 64)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		last := toTs - toTs%3600
+		first := last - int64(limit)*3600
+		var bars []string
+		for ts := first; ts <= last; ts += 3600 {
+			price := 0.0
+			if ts >= listed.Unix() {
+				price = float64(ts-listed.Unix())/3600 + 1
+			}
+			bars = append(bars, fmt.Sprintf(`{"time":%d,"high":%g,"low":%g,"open":%g,"volumefrom":2.5,"volumeto":%g,"close":%g}`,
+				ts, price, price, price, price*2.5, price))
+		}
+		fmt.Fprintf(w, `{"Response":"Success","Message":"","Data":{"Aggregated":false,"TimeFrom":%d,"TimeTo":%d,"Data":[%s]}}`,
+			first, last, strings.Join(bars, ","))
+	}
+}
+
+func TestCryptoCompare(t *testing.T) {
+	listed := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	requests := 0
+	fakeServer(t, &cryptoCompareURL, fakeCryptoCompare(listed, &requests))
+
+	// 2000 bars a page, the range needs three pages
+	q, err := NewQuoteFromCryptoCompare("BTC", "USD", "2023-01-15", "2023-06-30", Min60, "key")
+	ok(t, err)
+	equals(t, 3, requests)
+	equals(t, "BTC/USD", q.Symbol)
+	equals(t, time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC), q.Date[0])
+	equals(t, time.Date(2023, 6, 30, 23, 0, 0, 0, time.UTC), q.Date[len(q.Date)-1])
+	equals(t, 167*24, len(q.Date))
+	equals(t, 2.5, q.Volume[0])
+	equals(t, "USD", q.Meta.Currency)
+
+	// stops at the first bar traded
+	requests = 0
+	q, err = NewQuoteFromCryptoCompare("BTC", "USD", "2020-01-01", "2023-01-10", Min60, "key")
+	ok(t, err)
+	equals(t, 1, requests)
+	equals(t, listed, q.Date[0])
+	equals(t, 1.0, q.Close[0])
+
+	_, err = NewQuoteFromCryptoCompare("NOPE", "USD", "2023-01-01", "2023-01-10", Min60, "key")
+	equals(t, "cryptocompare: There is no data for the symbol NOPE .", fmt.Sprint(err))
+
+	quotes, _ := NewQuotesFromCryptoCompareSyms([]string{"BTC/USD", "BTCUSD", "ETH/USD"}, "2023-01-01", "2023-01-02", Daily, "key")
+	equals(t, 2, len(quotes))
+	equals(t, "ETH/USD", quotes[1].Symbol)
+}
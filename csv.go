@@ -0,0 +1,414 @@
+package quote
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVOptions - layout of csv quote data
+type CSVOptions struct {
+	Delimiter    rune      // field separator, default ','
+	DecimalComma bool      // ',' is the decimal separator and '.' groups thousands, e.g. 1.234,56
+	DateLayout   string    // time layout of the date column, default "2006-01-02 15:04"
+	Epoch        bool      // dates are unix seconds instead of DateLayout
+	Offset       bool      // dates end in their utc offset, e.g. 2023-03-12 03:00-04:00
+	NoHeader     bool      // no header line, the first line is a bar
+	Precision    int       // decimals of written prices, FullPrecision, or Quote.precision when 0
+	Layout       CSVLayout // column order and per quote date layouts, the columns of Quote.CSV when zero
+	Strict       bool      // fail on numbers that do not parse, e.g. blank or null, instead of reading them as 0
+}
+
+// offsetLayout - utc offset appended to date layouts with CSVOptions.Offset
+const offsetLayout = "-07:00"
+
+// DefaultCSVOptions - layout written by Quote.CSV
+var DefaultCSVOptions = CSVOptions{Delimiter: ',', DateLayout: "2006-01-02 15:04"}
+
+var (
+	dottedDate  = regexp.MustCompile(`^\d{2}\.\d{2}\.\d{4}`)
+	slashedDate = regexp.MustCompile(`^\d{2}/\d{2}/\d{4}`)
+	timeOfDay   = regexp.MustCompile(`[ T]\d{2}:\d{2}(:\d{2})?$`)
+)
+
+// DetectCSVOptions - guess the layout of csv from its first data line.
+// Recognizes ';' separated lines with decimal commas and dd.mm.yyyy or
+// dd/mm/yyyy dates as exported by many european brokers.
+func DetectCSVOptions(csv string) CSVOptions {
+
+	opts := DefaultCSVOptions
+	lines := strings.SplitN(csv, "\n", 3)
+	if len(lines) < 2 {
+		return opts
+	}
+	line := strings.TrimSpace(lines[1])
+
+	if strings.Count(line, ";") >= 5 {
+		opts.Delimiter = ';'
+		opts.DecimalComma = strings.Contains(line, ",")
+	}
+
+	fields := strings.Split(line, string(opts.Delimiter))
+	date := strings.Trim(fields[0], `" `)
+	if len(fields) > 1 && !dottedDate.MatchString(date) && !slashedDate.MatchString(date) {
+		// multi-symbol layout, date is the second column
+		date = strings.Trim(fields[1], `" `)
+	}
+	layout := ""
+	switch {
+	case dottedDate.MatchString(date):
+		layout = "02.01.2006"
+	case slashedDate.MatchString(date):
+		layout = "02/01/2006"
+	}
+	if layout != "" {
+		if m := timeOfDay.FindString(date); m != "" {
+			layout += m[:1] + "15:04:05"[:len(m)-1]
+		}
+		opts.DateLayout = layout
+	}
+	return opts
+}
+
+// withDefaults - fill in unset options
+func (opts CSVOptions) withDefaults() CSVOptions {
+	if opts.Delimiter == 0 {
+		opts.Delimiter = DefaultCSVOptions.Delimiter
+	}
+	if strings.TrimSpace(opts.DateLayout) == "" {
+		opts.DateLayout = DefaultCSVOptions.DateLayout
+	}
+	return opts
+}
+
+// parseFloat - parse a number in the locale described by opts
+func (opts CSVOptions) parseFloat(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if opts.DecimalComma {
+		s = strings.Replace(s, ".", "", -1)
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	s = strings.Replace(s, " ", "", -1)
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseDate - parse a date with the layout in opts, the longer of the
+// Layout's if set, accepting values that omit trailing parts of the layout
+// (e.g. a date without time of day) before any Offset, or as unix seconds
+// with Epoch
+func (opts CSVOptions) parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if opts.Epoch {
+		secs, err := strconv.ParseInt(s, 10, 64)
+		return time.Unix(secs, 0).UTC(), err
+	}
+	var zone *time.Location
+	if opts.Offset && len(s) > len(offsetLayout) {
+		offset, err := time.Parse(offsetLayout, s[len(s)-len(offsetLayout):])
+		if err != nil {
+			return time.Time{}, err
+		}
+		zone = time.UTC
+		if _, secs := offset.Zone(); secs != 0 {
+			zone = time.FixedZone("", secs)
+		}
+		s = s[:len(s)-len(offsetLayout)]
+	}
+	layout := opts.DateLayout
+	if opts.Layout.DateTimeLayout != "" {
+		layout = opts.Layout.DateTimeLayout
+	} else if opts.Layout.DateLayout != "" {
+		layout = opts.Layout.DateLayout
+	}
+	d, err := time.Parse(layout, s)
+	if err != nil && len(s) < len(layout) {
+		d, err = time.Parse(layout[:len(s)], s)
+	}
+	if err == nil && zone != nil {
+		d = time.Date(d.Year(), d.Month(), d.Day(), d.Hour(), d.Minute(), d.Second(), d.Nanosecond(), zone)
+	}
+	return d, err
+}
+
+// csvRows - the rows of csv streamed from a reader
+type csvRows struct {
+	reader *csv.Reader
+	header []string // nil with NoHeader or for empty csv
+	row    int      // 1 based number of the last row read, counting the header
+}
+
+// readCSV - start reading csv from r, reading its header unless NoHeader
+func (opts CSVOptions) readCSV(r io.Reader) (*csvRows, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = opts.Delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+	rows := &csvRows{reader: reader}
+	if opts.NoHeader {
+		return rows, nil
+	}
+	header, err := reader.Read()
+	if err == io.EOF {
+		return rows, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows.header = append([]string(nil), header...)
+	rows.row = 1
+	return rows, nil
+}
+
+// next - the fields of the next row, valid until the following call, and its
+// row number. io.EOF after the last row.
+func (rows *csvRows) next() ([]string, int, error) {
+	fields, err := rows.reader.Read()
+	rows.row++
+	return fields, rows.row, err
+}
+
+// csvColumns - field indexes of the columns of csv rows
+type csvColumns struct {
+	symbol int    // -1 for single symbol csv
+	date   int    // date column
+	prices [5]int // open, high, low, close and volume columns
+	extras []int  // for each of extraColumns, -1 if absent
+	width  int    // fields of a complete row
+}
+
+// columns - the columns of csv rows with header, by Layout.Columns if set and
+// else date, open, high, low, close, volume and named optional columns,
+// preceded by the symbol for multi-symbol csv
+func (opts CSVOptions) columns(header []string, symbol bool) (csvColumns, error) {
+	c := csvColumns{symbol: -1, date: -1, prices: [5]int{-1, -1, -1, -1, -1}}
+	names := opts.Layout.Columns
+	if names == nil {
+		first := 0
+		if symbol {
+			c.symbol, first = 0, 1
+		}
+		c.date = first
+		for i := range c.prices {
+			c.prices[i] = first + 1 + i
+		}
+		c.extras = csvExtras(header)
+		c.width = first + 6
+		return c, nil
+	}
+
+	c.extras = csvExtras(nil)
+	for i, name := range names {
+		c.width = i + 1
+		switch name {
+		case "symbol":
+			c.symbol = i
+		case "date", "datetime":
+			c.date = i
+		default:
+			known := false
+			for col := 0; col < 5+len(extraColumns); col++ {
+				if columnName(col) != name {
+					continue
+				}
+				if col < 5 {
+					c.prices[col] = i
+				} else {
+					c.extras[col-5] = i
+				}
+				known = true
+			}
+			if !known {
+				return c, fmt.Errorf("unknown csv column '%s'", name)
+			}
+		}
+	}
+	if symbol && c.symbol < 0 {
+		return c, fmt.Errorf("csv layout lacks the symbol column")
+	}
+	if c.date < 0 {
+		return c, fmt.Errorf("csv layout lacks the date column")
+	}
+	for i, col := range c.prices {
+		if col < 0 {
+			return c, fmt.Errorf("csv layout lacks the %s column", columnName(i))
+		}
+	}
+	return c, nil
+}
+
+// parseBar - parse the date, open, high, low, close and volume fields of a row
+func (opts CSVOptions) parseBar(fields []string, cols csvColumns, row int) (Bar, error) {
+	var bar Bar
+	var err error
+	bar.Date, err = opts.parseDate(fields[cols.date])
+	if err != nil {
+		return bar, fmt.Errorf("row %d: bad date '%s'", row, fields[cols.date])
+	}
+	prices := []*float64{&bar.Open, &bar.High, &bar.Low, &bar.Close, &bar.Volume}
+	for i, price := range prices {
+		field := fields[cols.prices[i]]
+		*price, err = opts.parseFloat(field)
+		if err != nil && !opts.Strict {
+			*price, err = 0, nil
+		}
+		if err != nil {
+			return bar, fmt.Errorf("row %d: bad number '%s'", row, field)
+		}
+	}
+	return bar, nil
+}
+
+// csvExtras - for each of extraColumns, its index in header or -1 if absent
+func csvExtras(header []string) []int {
+	cols := make([]int, len(extraColumns))
+	for i, x := range extraColumns {
+		cols[i] = -1
+		for j, name := range header {
+			if strings.ToLower(strings.TrimSpace(name)) == x.name {
+				cols[i] = j
+			}
+		}
+	}
+	return cols
+}
+
+// parseExtra - parse optional column col of fields into the last bar of q
+func (opts CSVOptions) parseExtra(q *Quote, dst *[]float64, fields []string, col, row int) error {
+	if col < 0 || col >= len(fields) || strings.TrimSpace(fields[col]) == "" {
+		return nil
+	}
+	v, err := opts.parseFloat(fields[col])
+	if err != nil && !opts.Strict {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("row %d: bad number '%s'", row, fields[col])
+	}
+	q.setExtra(dst, len(q.Date)-1, v)
+	return nil
+}
+
+// parseRow - parse a row of fields and append it to q
+func (opts CSVOptions) parseRow(q *Quote, fields []string, cols csvColumns, row int) error {
+	bar, err := opts.parseBar(fields, cols, row)
+	if err != nil {
+		return err
+	}
+	q.PushBar(bar)
+	for i, x := range extraColumns {
+		err = opts.parseExtra(q, x.col(q), fields, cols.extras[i], row)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewQuoteFromCSVWithOptions - parse csv quote string in the layout described by opts
+func NewQuoteFromCSVWithOptions(symbol, csv string, opts CSVOptions) (Quote, error) {
+	return NewQuoteFromCSVReaderWithOptions(symbol, strings.NewReader(csv), opts)
+}
+
+// NewQuoteFromCSVReaderWithOptions - parse csv quote data streamed from r in
+// the layout described by opts, a row at a time
+func NewQuoteFromCSVReaderWithOptions(symbol string, r io.Reader, opts CSVOptions) (Quote, error) {
+
+	opts = opts.withDefaults()
+	rows, err := opts.readCSV(r)
+	if err != nil {
+		return NewQuote("", 0), err
+	}
+	cols, err := opts.columns(rows.header, false)
+	if err != nil {
+		return NewQuote("", 0), err
+	}
+
+	q := NewQuote(symbol, 0)
+	for {
+		fields, row, err := rows.next()
+		if err == io.EOF || (err == nil && len(fields) < cols.width) {
+			break
+		}
+		if err == nil {
+			err = opts.parseRow(&q, fields, cols, row)
+		}
+		if err != nil {
+			return NewQuote("", 0), err
+		}
+	}
+	return q, nil
+}
+
+// NewQuotesFromCSVWithOptions - parse multi-symbol csv quote string in the
+// layout described by opts. Symbols are returned in order of first appearance.
+func NewQuotesFromCSVWithOptions(csv string, opts CSVOptions) (Quotes, error) {
+	return NewQuotesFromCSVReaderWithOptions(strings.NewReader(csv), opts)
+}
+
+// NewQuotesFromCSVReaderWithOptions - parse multi-symbol csv quote data
+// streamed from r in the layout described by opts, a row at a time
+func NewQuotesFromCSVReaderWithOptions(r io.Reader, opts CSVOptions) (Quotes, error) {
+
+	opts = opts.withDefaults()
+	rows, err := opts.readCSV(r)
+	if err != nil {
+		return Quotes{}, err
+	}
+	cols, err := opts.columns(rows.header, true)
+	if err != nil {
+		return Quotes{}, err
+	}
+
+	quotes := Quotes{}
+	index := make(map[string]int)
+	for {
+		fields, row, err := rows.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Quotes{}, err
+		}
+		if len(fields) < cols.width {
+			continue
+		}
+		sym := fields[cols.symbol]
+		idx, ok := index[sym]
+		if !ok {
+			idx = len(quotes)
+			index[sym] = idx
+			quotes = append(quotes, NewQuote(sym, 0))
+		}
+		err = opts.parseRow(&quotes[idx], fields, cols, row)
+		if err != nil {
+			return Quotes{}, err
+		}
+	}
+	return quotes, nil
+}
+
+// NewQuoteFromCSVFileWithOptions - parse csv quote file in the layout described by opts
+func NewQuoteFromCSVFileWithOptions(symbol, filename string, opts CSVOptions) (Quote, error) {
+	f, err := openFile(filename)
+	if err != nil {
+		return NewQuote("", 0), err
+	}
+	defer f.Close()
+	return NewQuoteFromCSVReaderWithOptions(symbol, f, opts)
+}
+
+// NewQuotesFromCSVFileWithOptions - parse multi-symbol csv quote file in the layout described by opts
+func NewQuotesFromCSVFileWithOptions(filename string, opts CSVOptions) (Quotes, error) {
+	f, err := openFile(filename)
+	if err != nil {
+		return Quotes{}, err
+	}
+	defer f.Close()
+	return NewQuotesFromCSVReaderWithOptions(f, opts)
+}
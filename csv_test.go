@@ -0,0 +1,200 @@
+package quote
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// German style export: ';' separator, decimal comma, dotted thousands, dd.mm.yyyy dates
+const germanCSV = `Datum;Eröffnung;Hoch;Tief;Schluss;Volumen
+28.12.2023;1.234,56;1.240,00;1.230,10;1.238,75;12.345
+29.12.2023;1.238,75;1.250,50;1.236,00;1.249,99;9.876,5
+`
+
+// French style export: ';' separator, decimal comma, dd/mm/yyyy dates with time
+const frenchCSV = "date;ouverture;haut;bas;clôture;volume\r\n" +
+	"29/12/2023 09:00;74,12;74,80;73,95;74,50;1520\r\n" +
+	"29/12/2023 10:00;74,50;75,02;74,40;74,98;980\r\n"
+
+func TestDetectCSVOptions(t *testing.T) {
+	equals(t, CSVOptions{Delimiter: ';', DecimalComma: true, DateLayout: "02.01.2006"}, DetectCSVOptions(germanCSV))
+	equals(t, CSVOptions{Delimiter: ';', DecimalComma: true, DateLayout: "02/01/2006 15:04"}, DetectCSVOptions(frenchCSV))
+	equals(t, DefaultCSVOptions, DetectCSVOptions(NewQuote("spy", 0).CSV()+"2024-01-02 00:00,1.00,2.00,0.50,1.50,100.00\n"))
+	equals(t, DefaultCSVOptions, DetectCSVOptions(""))
+}
+
+func TestNewQuoteFromCSVWithOptionsGerman(t *testing.T) {
+	q, err := NewQuoteFromCSVWithOptions("sap", germanCSV, DetectCSVOptions(germanCSV))
+	ok(t, err)
+	equals(t, 2, len(q.Close))
+	equals(t, time.Date(2023, 12, 28, 0, 0, 0, 0, time.UTC), q.Date[0])
+	equals(t, 1234.56, q.Open[0])
+	equals(t, 1240.0, q.High[0])
+	equals(t, 1238.75, q.Close[0])
+	equals(t, 12345.0, q.Volume[0])
+	equals(t, 1249.99, q.Close[1])
+	equals(t, 9876.5, q.Volume[1])
+}
+
+func TestNewQuoteFromCSVWithOptionsFrench(t *testing.T) {
+	opts := CSVOptions{Delimiter: ';', DecimalComma: true, DateLayout: "02/01/2006 15:04"}
+	q, err := NewQuoteFromCSVWithOptions("bnp", frenchCSV, opts)
+	ok(t, err)
+	equals(t, []time.Time{
+		time.Date(2023, 12, 29, 9, 0, 0, 0, time.UTC),
+		time.Date(2023, 12, 29, 10, 0, 0, 0, time.UTC),
+	}, q.Date)
+	equals(t, []float64{74.12, 74.5}, q.Open)
+	equals(t, []float64{74.5, 74.98}, q.Close)
+
+	// round trip through the default layout
+	back, err := NewQuoteFromCSV("bnp", q.CSV())
+	ok(t, err)
+	equals(t, q.Close, back.Close)
+	equals(t, q.Date, back.Date)
+}
+
+func TestNewQuoteFromCSVWithOptionsErrors(t *testing.T) {
+	_, err := NewQuoteFromCSVWithOptions("x", "h\n2023-01-02 00:00,1,2,x,4,5\n", CSVOptions{Strict: true})
+	assert(t, err != nil, "expected bad number error")
+	_, err = NewQuoteFromCSVWithOptions("x", "h\n31.12.2023,1,2,3,4,5\n", CSVOptions{})
+	assert(t, err != nil, "expected bad date error")
+}
+
+func TestNewQuoteFromCSVLenient(t *testing.T) {
+	// blank and null numbers read as 0 unless Strict, as they always have
+	csv := "datetime,open,high,low,close,volume,adjclose\n" +
+		"2023-01-02 00:00,1,2,0.5,1.5,,1.4\n" +
+		"2023-01-03 00:00,1.5,2.5,1,2,null,x\n"
+	q, err := NewQuoteFromCSV("x", csv)
+	ok(t, err)
+	equals(t, []float64{1.5, 2}, q.Close)
+	equals(t, []float64{0, 0}, q.Volume)
+	equals(t, []float64{1.4, 0}, q.AdjClose)
+
+	opts := DefaultCSVOptions
+	opts.Strict = true
+	_, err = NewQuoteFromCSVWithOptions("x", csv, opts)
+	equals(t, "row 2: bad number ''", err.Error())
+}
+
+func TestNewQuotesFromCSVWithOptions(t *testing.T) {
+	csv := "symbol;date;open;high;low;close;volume\n" +
+		"sap;28.12.2023;1,5;2;1;1,75;10\n" +
+		"bmw;28.12.2023;100,25;101;99;100,5;20\n" +
+		"sap;29.12.2023;1,75;2;1,5;1,8;30\n"
+	opts := DetectCSVOptions(csv)
+	equals(t, "02.01.2006", opts.DateLayout)
+	quotes, err := NewQuotesFromCSVWithOptions(csv, opts)
+	ok(t, err)
+	equals(t, 2, len(quotes))
+	equals(t, "sap", quotes[0].Symbol)
+	equals(t, []float64{1.75, 1.8}, quotes[0].Close)
+	equals(t, "bmw", quotes[1].Symbol)
+	equals(t, []float64{100.5}, quotes[1].Close)
+}
+
+func TestNewQuoteFromCSVFileWithOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csv")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "sap.csv")
+	ok(t, ioutil.WriteFile(filename, []byte(germanCSV), 0644))
+
+	q, err := NewQuoteFromCSVFileWithOptions("sap", filename, DetectCSVOptions(germanCSV))
+	ok(t, err)
+	equals(t, 2, len(q.Close))
+}
+
+// csvRowReader - n generated csv rows of symbol, or of 3 symbols if symbol
+// is empty, produced as read
+type csvRowReader struct {
+	symbol string
+	n, row int
+	line   []byte // the row being read
+	off    int    // bytes of line read
+}
+
+func (r *csvRowReader) Read(p []byte) (int, error) {
+	if r.off == len(r.line) {
+		if r.row > r.n {
+			return 0, io.EOF
+		}
+		r.line, r.off = r.line[:0], 0
+		switch {
+		case r.row == 0 && r.symbol == "":
+			r.line = append(r.line, "symbol,datetime,open,high,low,close,volume\n"...)
+		case r.row == 0:
+			r.line = append(r.line, "datetime,open,high,low,close,volume\n"...)
+		default:
+			if r.symbol == "" {
+				r.line = append(r.line, []string{"aapl,", "msft,", "spy,"}[r.row%3]...)
+			}
+			d := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(r.row) * time.Minute)
+			r.line = d.AppendFormat(r.line, "2006-01-02 15:04")
+			r.line = append(r.line, ",100.25,101.50,99.75,100.50,12345.00\n"...)
+		}
+		r.row++
+	}
+	n := copy(p, r.line[r.off:])
+	r.off += n
+	return n, nil
+}
+
+func TestNewQuoteFromCSVReader(t *testing.T) {
+	q, err := NewQuoteFromCSVReader("spy", &csvRowReader{symbol: "spy", n: 1000})
+	ok(t, err)
+	equals(t, 1000, len(q.Close))
+	equals(t, time.Date(2020, 1, 1, 16, 40, 0, 0, time.UTC), q.Date[999])
+	equals(t, 12345.0, q.Volume[999])
+
+	quotes, err := NewQuotesFromCSVReader(&csvRowReader{n: 1000})
+	ok(t, err)
+	equals(t, []string{"msft", "spy", "aapl"}, []string{quotes[0].Symbol, quotes[1].Symbol, quotes[2].Symbol})
+	equals(t, 333, len(quotes[2].Close))
+
+	// errors name the row of the stream, blank lines aside
+	strict := DefaultCSVOptions
+	strict.Strict = true
+	_, err = NewQuoteFromCSVReaderWithOptions("x", strings.NewReader("h\n2023-01-02 00:00,1,2,3,4,5\n\n2023-01-03 00:00,1,2,x,4,5\n"), strict)
+	equals(t, "row 3: bad number 'x'", err.Error())
+	_, err = NewQuotesFromCSVReader(strings.NewReader("h\nx,2023-01-02 00:00,1,2,3,4,5\nx,2023-01-03,1,2,3,4,5,6\ny,bad,1,2,3,4,5\n"))
+	equals(t, "row 4: bad date 'bad'", err.Error())
+	q, err = NewQuoteFromCSVReader("x", strings.NewReader(""))
+	ok(t, err)
+	equals(t, 0, len(q.Close))
+}
+
+// benchmarkCSVRead - parse rows of 3 symbols with parse, reporting allocations
+func benchmarkCSVRead(b *testing.B, rows int, parse func(r io.Reader) (Quotes, error)) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		quotes, err := parse(&csvRowReader{n: rows})
+		if err != nil || len(quotes) != 3 {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCSVReader1M - streams a million rows, allocating little beyond
+// the resulting bars
+func BenchmarkCSVReader1M(b *testing.B) {
+	benchmarkCSVRead(b, 1000000, NewQuotesFromCSVReader)
+}
+
+// BenchmarkCSVString1M - reads a million rows into a string first, as the
+// file constructors used to, adding the size of the csv to the peak memory
+func BenchmarkCSVString1M(b *testing.B) {
+	benchmarkCSVRead(b, 1000000, func(r io.Reader) (Quotes, error) {
+		csv, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return NewQuotesFromCSV(string(csv))
+	})
+}
@@ -0,0 +1,220 @@
+package quote
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVLayout - columns and date format of csv written by CSVWithOptions
+type CSVLayout struct {
+	Columns        []string // symbol, date, open, high, low, close, volume or optional column names in order, nil for the columns of CSV. Optional columns a quote lacks are 0.
+	Header         []string // header names of Columns, nil for the column names
+	DateLayout     string   // time layout of dates, CSVOptions.DateLayout if empty
+	DateTimeLayout string   // time layout of dates of intraday quotes, DateLayout if empty
+}
+
+// CSVLayoutBacktrader - the column order backtrader's GenericCSVData reads by
+// default, openinterest being 0 unless the quote carries it, with ISO dates
+// and no time of day for daily bars
+var CSVLayoutBacktrader = CSVLayout{
+	Columns:        []string{"date", "open", "high", "low", "close", "volume", "openinterest"},
+	Header:         []string{"datetime", "open", "high", "low", "close", "volume", "openinterest"},
+	DateLayout:     "2006-01-02",
+	DateTimeLayout: "2006-01-02 15:04:05",
+}
+
+// CSVLayoutZipline - the columns of a zipline csvdir bundle file, ISO dates
+// without time of day for daily bars
+var CSVLayoutZipline = CSVLayout{
+	Columns:        []string{"date", "open", "high", "low", "close", "volume"},
+	DateLayout:     "2006-01-02",
+	DateTimeLayout: "2006-01-02 15:04:05",
+}
+
+// dateLayout - time layout of the dates of q
+func (opts CSVOptions) dateLayout(q Quote) string {
+	layout := opts.Layout.DateLayout
+	if layout == "" {
+		layout = opts.DateLayout
+	}
+	if opts.Layout.DateTimeLayout != "" && q.intraday() {
+		layout = opts.Layout.DateTimeLayout
+	}
+	if opts.Offset {
+		layout += offsetLayout
+	}
+	return layout
+}
+
+// csvField - the text of a column of bar
+type csvField func(bar int) string
+
+// csvFields - a csvField per column of q. Optional columns q lacks are
+// empty, or 0 if zero is set.
+func (q Quote) csvFields(columns []string, opts CSVOptions, dates *dateCache, zero bool) ([]csvField, error) {
+	precision := q.precision(opts.Precision)
+	number := func(col []float64, digits int) csvField {
+		return func(bar int) string {
+			if col == nil && !zero {
+				return ""
+			}
+			v := 0.0
+			if col != nil {
+				v = col[bar]
+			}
+			s := strconv.FormatFloat(v, 'f', digits, 64)
+			if opts.DecimalComma {
+				s = strings.Replace(s, ".", ",", 1)
+			}
+			return s
+		}
+	}
+
+	fields := make([]csvField, len(columns))
+	for i, name := range columns {
+		switch name {
+		case "symbol":
+			fields[i] = func(bar int) string { return q.Symbol }
+		case "date", "datetime":
+			fields[i] = func(bar int) string { return dates.format(q.Date[bar]) }
+			if opts.Epoch {
+				fields[i] = func(bar int) string { return strconv.FormatInt(q.Date[bar].Unix(), 10) }
+			}
+		default:
+			for c, col := range q.columns() {
+				if columnName(c) != name {
+					continue
+				}
+				digits := precision
+				if c >= 5 && extraColumns[c-5].digits >= 0 {
+					digits = extraColumns[c-5].digits
+				}
+				fields[i] = number(*col, digits)
+			}
+		}
+		if fields[i] == nil {
+			return nil, fmt.Errorf("unknown csv column '%s'", name)
+		}
+	}
+	return fields, nil
+}
+
+// writeCSVQuotes - stream quotes as csv laid out as opts to w, by default
+// with a symbol column first if symbol is set and the optional columns any
+// of the quotes carry
+func writeCSVQuotes(w io.Writer, quotes Quotes, symbol bool, opts CSVOptions) error {
+
+	opts = opts.withDefaults()
+	columns := opts.Layout.Columns
+	explicit := columns != nil
+	if !explicit {
+		columns = []string{"datetime", "open", "high", "low", "close", "volume"}
+		if symbol {
+			columns = append([]string{"symbol"}, columns...)
+		}
+		present := make([]bool, len(extraColumns))
+		for _, q := range quotes {
+			for i, p := range q.extrasPresent() {
+				present[i] = present[i] || p
+			}
+		}
+		for i, x := range extraColumns {
+			if present[i] {
+				columns = append(columns, x.name)
+			}
+		}
+	}
+	header := opts.Layout.Header
+	if header == nil {
+		header = columns
+	}
+	if len(header) != len(columns) {
+		return fmt.Errorf("%d csv header names for %d columns", len(header), len(columns))
+	}
+
+	if !opts.NoHeader {
+		if _, err := io.WriteString(w, strings.Join(header, string(opts.Delimiter))+"\n"); err != nil {
+			return err
+		}
+	}
+	return writeCSVRows(w, quotes, columns, opts, explicit)
+}
+
+// writeCSVRows - stream the bars of quotes as csv rows of columns to w.
+// Optional columns a quote lacks are empty, or 0 if zero is set.
+func writeCSVRows(w io.Writer, quotes Quotes, columns []string, opts CSVOptions, zero bool) error {
+	sep := string(opts.Delimiter)
+	caches := map[string]*dateCache{}
+	line := make([]string, len(columns))
+	for _, q := range quotes {
+		layout := opts.dateLayout(q)
+		if caches[layout] == nil {
+			caches[layout] = newDateCache(layout)
+		}
+		fields, err := q.csvFields(columns, opts, caches[layout], zero)
+		if err != nil {
+			return err
+		}
+		for bar := range q.Close {
+			for i, field := range fields {
+				line[i] = field(bar)
+			}
+			if _, err := io.WriteString(w, strings.Join(line, sep)+"\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeCSVWithOptions - stream Quote structure as csv laid out as opts to w
+func (q Quote) writeCSVWithOptions(w io.Writer, opts CSVOptions) error {
+	return writeCSVQuotes(w, Quotes{q}, false, opts)
+}
+
+// CSVWithOptions - convert Quote structure to csv with the delimiter, decimal
+// separator, dates, header, precision and Layout of opts
+func (q Quote) CSVWithOptions(opts CSVOptions) (string, error) {
+	var buffer bytes.Buffer
+	err := q.writeCSVWithOptions(&buffer, opts)
+	return buffer.String(), err
+}
+
+// WriteCSVWithOptions - write Quote structure to csv file laid out as opts,
+// gzip compressed if filename ends in .gz
+func (q Quote) WriteCSVWithOptions(filename string, opts CSVOptions) error {
+	if filename == "" {
+		filename = q.Symbol + ".csv"
+	}
+	return writeStream(filename, func(w io.Writer) error {
+		return q.writeCSVWithOptions(w, opts)
+	})
+}
+
+// writeCSVWithOptions - stream Quotes structure as csv laid out as opts to
+// w, in output order
+func (q Quotes) writeCSVWithOptions(w io.Writer, opts CSVOptions) error {
+	return writeCSVQuotes(w, q.inOutputOrder(), true, opts)
+}
+
+// CSVWithOptions - convert Quotes structure to multi-symbol csv laid out as
+// opts. Layout.Columns should include symbol for the csv to be read back.
+func (q Quotes) CSVWithOptions(opts CSVOptions) (string, error) {
+	var buffer bytes.Buffer
+	err := q.writeCSVWithOptions(&buffer, opts)
+	return buffer.String(), err
+}
+
+// WriteCSVWithOptions - write Quotes structure to multi-symbol csv file laid
+// out as opts, gzip compressed if filename ends in .gz
+func (q Quotes) WriteCSVWithOptions(filename string, opts CSVOptions) error {
+	if filename == "" {
+		filename = "quotes.csv"
+	}
+	return writeStream(filename, func(w io.Writer) error {
+		return q.writeCSVWithOptions(w, opts)
+	})
+}
@@ -0,0 +1,122 @@
+package quote
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// csvLayoutFixture - 3 daily aapl bars
+func csvLayoutFixture() Quote {
+	q := NewQuote("aapl", 3)
+	q.Date = []time.Time{day(2023, 1, 30), day(2023, 1, 31), day(2023, 2, 1)}
+	q.Open = []float64{143.97, 142.70, 143.97}
+	q.High = []float64{144.34, 144.34, 146.61}
+	q.Low = []float64{142.28, 142.28, 141.32}
+	q.Close = []float64{143.00, 144.29, 145.43}
+	q.Volume = []float64{64015300, 65874500, 77663600}
+	return q
+}
+
+func golden(t *testing.T, name string) string {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	ok(t, err)
+	return string(data)
+}
+
+func TestCSVLayouts(t *testing.T) {
+	aapl := csvLayoutFixture()
+	opts := DefaultCSVOptions
+	opts.Layout = CSVLayoutBacktrader
+	csv, err := aapl.CSVWithOptions(opts)
+	ok(t, err)
+	equals(t, golden(t, "backtrader.csv"), csv)
+
+	opts.Layout = CSVLayoutZipline
+	csv, err = aapl.CSVWithOptions(opts)
+	ok(t, err)
+	equals(t, golden(t, "zipline.csv"), csv)
+
+	// intraday bars keep their time, a present openinterest is written
+	btc := NewQuote("BTCUSD", 2)
+	btc.Date = []time.Time{time.Date(2023, 2, 14, 9, 30, 0, 0, time.UTC), time.Date(2023, 2, 14, 9, 31, 0, 0, time.UTC)}
+	btc.Open = []float64{0.01234567, 0.01234599}
+	btc.High = []float64{0.012346, 0.012347}
+	btc.Low = []float64{0.012345, 0.01234599}
+	btc.Close = []float64{0.01234599, 0.0123465}
+	btc.Volume = []float64{1500.5, 980}
+	btc.OpenInterest = []float64{42, 43}
+	opts.Layout = CSVLayoutBacktrader
+	csv, err = btc.CSVWithOptions(opts)
+	ok(t, err)
+	equals(t, golden(t, "backtrader_intraday.csv"), csv)
+}
+
+func TestCSVWithOptions(t *testing.T) {
+	spy := partitionFixture()[0]
+	spy.Trades = []float64{1, 2, 3, 4}
+
+	// the default options write what CSV does
+	csv, err := spy.CSVWithOptions(DefaultCSVOptions)
+	ok(t, err)
+	equals(t, spy.CSV(), csv)
+
+	aapl := csvLayoutFixture()
+	opts := CSVOptions{Delimiter: ';', DecimalComma: true, DateLayout: "02.01.2006", NoHeader: true, Layout: CSVLayout{
+		Columns: []string{"close", "date"},
+	}}
+	csv, err = aapl.CSVWithOptions(opts)
+	ok(t, err)
+	equals(t, "143,00;30.01.2023\n144,29;31.01.2023\n145,43;01.02.2023\n", csv)
+
+	opts.Layout.Columns = []string{"date", "bid"}
+	_, err = aapl.CSVWithOptions(opts)
+	assert(t, err != nil, "unknown column written")
+	opts.Layout = CSVLayout{Columns: []string{"date"}, Header: []string{"a", "b"}}
+	_, err = aapl.CSVWithOptions(opts)
+	assert(t, err != nil, "header of the wrong length written")
+}
+
+func TestCSVWithOptionsRoundTrip(t *testing.T) {
+	aapl := csvLayoutFixture()
+	opts := CSVOptions{Delimiter: ';', Epoch: true, NoHeader: true}
+	csv, err := aapl.CSVWithOptions(opts)
+	ok(t, err)
+	equals(t, "1675036800;143.97;144.34;142.28;143.00;64015300.00\n", csv[:strings.Index(csv, "\n")+1])
+	q, err := NewQuoteFromCSVWithOptions("aapl", csv, opts)
+	ok(t, err)
+	equals(t, aapl, q)
+
+	// reordered columns, including the symbol, are read back by name
+	quotes := partitionFixture()[:2]
+	quotes.SortBySymbol()
+	opts = CSVOptions{DecimalComma: true, Delimiter: ';', Layout: CSVLayout{
+		Columns:        []string{"close", "volume", "low", "high", "open", "date", "symbol"},
+		DateLayout:     "02.01.2006",
+		DateTimeLayout: "02.01.2006 15:04",
+	}}
+	csv, err = quotes.CSVWithOptions(opts)
+	ok(t, err)
+	read, err := NewQuotesFromCSVWithOptions(csv, opts)
+	ok(t, err)
+	equals(t, quotes, read)
+
+	// backtrader csv reads back with its openinterest
+	opts = CSVOptions{Layout: CSVLayoutBacktrader}
+	csv, err = aapl.CSVWithOptions(opts)
+	ok(t, err)
+	q, err = NewQuoteFromCSVWithOptions("aapl", csv, opts)
+	ok(t, err)
+	equals(t, []float64{0, 0, 0}, q.OpenInterest)
+	q.OpenInterest = nil
+	equals(t, aapl, q)
+
+	opts.Layout = CSVLayout{Columns: []string{"date", "open", "high", "low", "close"}}
+	_, err = NewQuoteFromCSVWithOptions("aapl", csv, opts)
+	assert(t, err != nil, "layout without volume read")
+	opts.Layout = CSVLayoutZipline
+	_, err = NewQuotesFromCSVWithOptions(csv, opts)
+	assert(t, err != nil, "multi-symbol layout without symbol read")
+}
@@ -0,0 +1,124 @@
+package quote
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConvertMode - how ConvertCurrency applies the fx rate to prices
+type ConvertMode int
+
+const (
+	// Multiply - prices times the rate, for fx priced in the target currency
+	// per unit of the currency of q, e.g. EURUSD to convert euro prices to USD
+	Multiply ConvertMode = iota
+	// Divide - prices divided by the rate, for fx priced in the currency of q
+	// per unit of the target currency, e.g. EURUSD to convert USD prices to euro
+	Divide
+)
+
+// ConvertOptions - options of ConvertCurrencyWithOptions
+type ConvertOptions struct {
+	Mode ConvertMode
+	// MaxStaleDays - days the last fx close is carried forward over weekends
+	// and holidays; a bar further from the last fx close has no rate
+	MaxStaleDays int
+	// DropMissing - leave out bars without a rate instead of failing
+	DropMissing bool
+}
+
+// DefaultConvertOptions - options of ConvertCurrency, carrying an fx close
+// over a four day weekend such as Easter
+var DefaultConvertOptions = ConvertOptions{Mode: Multiply, MaxStaleDays: 4}
+
+// calendarDay - the day of t in its own location, at midnight UTC to compare
+// days of quotes in different locations
+func calendarDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// fxCurrencies - currency fx is priced in per unit of its base currency,
+// empty where its metadata and symbol do not tell
+func fxCurrencies(fx Quote) (base, quoted string) {
+	if fx.Meta == nil {
+		return "", ""
+	}
+	quoted = fx.Meta.Currency
+	sym := strings.ToUpper(fx.Symbol)
+	switch {
+	case quoted == "":
+	case len(sym) == 6 && sym[3:] == quoted:
+		base = sym[:3]
+	case fx.Meta.Source == "ecb" && sym == quoted:
+		base = "EUR"
+	}
+	return base, quoted
+}
+
+// ConvertCurrency - copy of q in another currency, open, high, low, close and
+// the adjusted prices converted by the fx close of the day of each bar as
+// mode says, volume and the other optional columns unchanged. The fx close of the last
+// earlier day is used on days without one, e.g. weekends and holidays; a bar
+// before the first fx close or further than DefaultConvertOptions allows
+// from the last is an error. Both quotes must be in ascending date order.
+func (q Quote) ConvertCurrency(fx Quote, mode ConvertMode) (Quote, error) {
+	opts := DefaultConvertOptions
+	opts.Mode = mode
+	return q.ConvertCurrencyWithOptions(fx, opts)
+}
+
+// ConvertCurrencyWithOptions - ConvertCurrency with the options of opts. The
+// result records the currency of the conversion in its metadata where fx
+// tells it.
+func (q Quote) ConvertCurrencyWithOptions(fx Quote, opts ConvertOptions) (Quote, error) {
+	days := make([]time.Time, len(fx.Date))
+	for bar, d := range fx.Date {
+		days[bar] = calendarDay(d)
+	}
+	stale := time.Duration(opts.MaxStaleDays) * 24 * time.Hour
+
+	out := NewQuote(q.Symbol, 0)
+	out.Precision = q.Precision
+	if q.Meta != nil {
+		meta := *q.Meta
+		out.Meta = &meta
+	}
+	var rates []float64
+	for bar, d := range q.Date {
+		day := calendarDay(d)
+		// last fx day on or before day
+		i := sort.Search(len(days), func(i int) bool { return days[i].After(day) }) - 1
+		if i < 0 || day.Sub(days[i]) > stale {
+			if opts.DropMissing {
+				continue
+			}
+			return Quote{}, fmt.Errorf("%s: no %s rate for %s", q.Symbol, fx.Symbol, day.Format("2006-01-02"))
+		}
+		rate := fx.Close[i]
+		if opts.Mode == Divide {
+			if rate == 0 {
+				return Quote{}, fmt.Errorf("%s: zero %s rate on %s", q.Symbol, fx.Symbol, days[i].Format("2006-01-02"))
+			}
+			rate = 1 / rate
+		}
+		out.appendBar(q, bar)
+		rates = append(rates, rate)
+	}
+	for _, col := range [][]float64{out.Open, out.High, out.Low, out.Close, out.AdjClose, out.AdjOpen, out.AdjHigh, out.AdjLow} {
+		for bar := range col {
+			col[bar] *= rates[bar]
+		}
+	}
+
+	if out.Meta != nil {
+		base, quoted := fxCurrencies(fx)
+		if opts.Mode == Divide {
+			quoted = base
+		}
+		out.Meta.Currency = quoted
+	}
+	return out, nil
+}
@@ -0,0 +1,99 @@
+package quote
+
+import (
+	"testing"
+	"time"
+)
+
+// easterFX - ecb usd per euro without rates from Good Friday 2023-04-07 to
+// Easter Monday 2023-04-10
+func easterFX() Quote {
+	fx := NewQuote("USD", 0)
+	for _, b := range []Bar{
+		{Date: day(2023, 4, 6), Close: 1.0915},
+		{Date: day(2023, 4, 11), Close: 1.0922},
+		{Date: day(2023, 4, 12), Close: 1.0978},
+	} {
+		b.Open, b.High, b.Low = b.Close, b.Close, b.Close
+		fx.PushBar(b)
+	}
+	fx.Meta = &Metadata{Symbol: "USD", Source: "ecb", Period: Daily, Currency: "USD"}
+	return fx
+}
+
+// easterPrices - daily euro closes of 100 every day from 2023-04-05 to
+// 2023-04-12, volume 1
+func easterPrices() Quote {
+	q := NewQuote("btc", 0)
+	for d := day(2023, 4, 5); !d.After(day(2023, 4, 12)); d = d.AddDate(0, 0, 1) {
+		q.PushBar(Bar{Date: d, Open: 100, High: 110, Low: 90, Close: 100, Volume: 1})
+	}
+	q.Meta = &Metadata{Symbol: "btc", Period: Daily, Currency: "EUR"}
+	return q
+}
+
+func TestConvertCurrency(t *testing.T) {
+	q := easterPrices()
+	fx := easterFX()
+
+	// the 5th is before the first rate
+	_, err := q.ConvertCurrency(fx, Multiply)
+	assert(t, err != nil, "bar before the first rate accepted")
+
+	q = q.slice(1, q.Len())
+	q.AdjClose = make([]float64, q.Len())
+	for bar := range q.AdjClose {
+		q.AdjClose[bar] = 50
+	}
+	q.Trades = []float64{1, 2, 3, 4, 5, 6, 7}
+	c, err := q.ConvertCurrency(fx, Multiply)
+	ok(t, err)
+	equals(t, q.Date, c.Date)
+	// thursday's rate carried over the long weekend
+	want := []float64{1.0915, 1.0915, 1.0915, 1.0915, 1.0915, 1.0922, 1.0978}
+	for bar, rate := range want {
+		near(t, 100*rate, c.Close[bar], "close")
+		near(t, 100*rate, c.Open[bar], "open")
+		near(t, 110*rate, c.High[bar], "high")
+		near(t, 90*rate, c.Low[bar], "low")
+		near(t, 50*rate, c.AdjClose[bar], "adjclose")
+	}
+	equals(t, q.Volume, c.Volume)
+	equals(t, q.Trades, c.Trades)
+	equals(t, "USD", c.Meta.Currency)
+	equals(t, 100.0, q.Close[0])
+
+	c, err = q.ConvertCurrency(fx, Divide)
+	ok(t, err)
+	near(t, 100/1.0915, c.Close[4], "divided close")
+	equals(t, "EUR", c.Meta.Currency)
+}
+
+func TestConvertCurrencyWithOptions(t *testing.T) {
+	q := easterPrices()
+	fx := easterFX()
+
+	// rates carried two days at most, leaving out the 5th, 9th and 10th
+	opts := ConvertOptions{Mode: Multiply, MaxStaleDays: 2, DropMissing: true}
+	c, err := q.ConvertCurrencyWithOptions(fx, opts)
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 4, 6), day(2023, 4, 7), day(2023, 4, 8), day(2023, 4, 11), day(2023, 4, 12)}, c.Date)
+	near(t, 109.15, c.Close[2], "carried close")
+	near(t, 109.22, c.Close[3], "close")
+
+	opts.DropMissing = false
+	_, err = q.slice(1, q.Len()).ConvertCurrencyWithOptions(fx, opts)
+	assert(t, err != nil, "bar beyond the stale rate accepted")
+
+	// after the last rate
+	opts = DefaultConvertOptions
+	opts.DropMissing = true
+	fx = fx.slice(0, 1)
+	c, err = q.ConvertCurrencyWithOptions(fx, opts)
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 4, 6), day(2023, 4, 7), day(2023, 4, 8), day(2023, 4, 9), day(2023, 4, 10)}, c.Date)
+
+	fx.Close[0] = 0
+	_, err = q.slice(1, 2).ConvertCurrency(fx, Divide)
+	assert(t, err != nil, "zero rate divided by")
+}
@@ -0,0 +1,37 @@
+package quote
+
+import (
+	"time"
+)
+
+// dateCacheSize - most dates held by a dateCache, about 40 years of days
+const dateCacheSize = 1 << 14
+
+// dateCache - formatted midnight timestamps keyed by calendar day, for
+// multi-symbol builders that format the same daily dates once per symbol.
+// Timestamps with a time of day are formatted directly.
+type dateCache struct {
+	layout string
+	dates  map[int64]string
+}
+
+// newDateCache - empty cache for layout
+func newDateCache(layout string) *dateCache {
+	return &dateCache{layout: layout, dates: make(map[int64]string)}
+}
+
+// format - t formatted with the cache layout
+func (c *dateCache) format(t time.Time) string {
+	if h, m, s := t.Clock(); h != 0 || m != 0 || s != 0 || t.Nanosecond() != 0 {
+		return t.Format(c.layout)
+	}
+	key := dayKey(t)
+	if str, ok := c.dates[key]; ok {
+		return str
+	}
+	str := t.Format(c.layout)
+	if len(c.dates) < dateCacheSize {
+		c.dates[key] = str
+	}
+	return str
+}
@@ -0,0 +1,109 @@
+package quote
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dailyQuotes - symbols quotes of years of weekday bars
+func dailyQuotes(symbols, years int) Quotes {
+	quotes := make(Quotes, symbols)
+	for sym := range quotes {
+		q := NewQuote(fmt.Sprintf("s%02d", sym), 0)
+		for d := day(2014, 1, 1); d.Before(day(2014+years, 1, 1)); d = d.AddDate(0, 0, 1) {
+			if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+				continue
+			}
+			c := 100 + float64(sym) + float64(d.YearDay())/7
+			q.PushBar(Bar{Date: d, Open: c - 1, High: c + 1, Low: c - 2, Close: c, Volume: 1e6})
+		}
+		quotes[sym] = q
+	}
+	return quotes
+}
+
+func TestDateCache(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	ok(t, err)
+	c := newDateCache("2006-01-02 15:04")
+	dates := []time.Time{
+		day(2023, 3, 1),
+		time.Date(2023, 3, 1, 0, 0, 0, 0, ny),
+		time.Date(2023, 3, 1, 9, 30, 0, 0, ny),
+		time.Date(2023, 3, 1, 0, 0, 0, 1, time.UTC),
+		day(2023, 3, 2),
+		day(2023, 3, 1),
+	}
+	for _, d := range dates {
+		equals(t, d.Format("2006-01-02 15:04"), c.format(d))
+	}
+	equals(t, 2, len(c.dates))
+
+	// bounded
+	for d := day(1900, 1, 1); len(c.dates) < dateCacheSize; d = d.AddDate(0, 0, 1) {
+		c.format(d)
+	}
+	far := day(2200, 1, 1)
+	equals(t, "2200-01-01 00:00", c.format(far))
+	equals(t, dateCacheSize, len(c.dates))
+}
+
+func TestQuotesCSVDates(t *testing.T) {
+	quotes := dailyQuotes(3, 1)
+	intraday := NewQuote("btcusd", 0)
+	intraday.PushBar(Bar{Date: time.Date(2014, 1, 2, 0, 0, 0, 0, time.UTC), Close: 1})
+	intraday.PushBar(Bar{Date: time.Date(2014, 1, 2, 0, 5, 0, 0, time.UTC), Close: 2})
+	quotes = append(quotes, intraday)
+
+	// output is unchanged by the cache
+	var want strings.Builder
+	want.WriteString("symbol,datetime,open,high,low,close,volume\n")
+	for _, q := range quotes.inOutputOrder() {
+		p := getPrecision(q.Symbol)
+		for bar := range q.Date {
+			fmt.Fprintf(&want, "%s,%s,%.*f,%.*f,%.*f,%.*f,%.*f\n", q.Symbol, q.Date[bar].Format("2006-01-02 15:04"),
+				p, q.Open[bar], p, q.High[bar], p, q.Low[bar], p, q.Close[bar], p, q.Volume[bar])
+		}
+	}
+	equals(t, want.String(), quotes.CSV())
+
+	lines := strings.Split(quotes.Amibroker(), "\n")
+	equals(t, "s00,20140101,000000,99.14,101.14,98.14,100.14,1000000.00", lines[0])
+	equals(t, "btcusd,20140102,000500,0.00000000,0.00000000,0.00000000,2.00000000,0.00000000", lines[len(lines)-2])
+}
+
+// 50 symbols of 10 years of daily bars
+func BenchmarkQuotesCSV(b *testing.B) {
+	quotes := dailyQuotes(50, 10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		quotes.CSV()
+	}
+}
+
+func BenchmarkDateFormat(b *testing.B) {
+	quotes := dailyQuotes(50, 10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, q := range quotes {
+			for _, d := range q.Date {
+				_ = d.Format("2006-01-02 15:04")
+			}
+		}
+	}
+}
+
+func BenchmarkDateCache(b *testing.B) {
+	quotes := dailyQuotes(50, 10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := newDateCache("2006-01-02 15:04")
+		for _, q := range quotes {
+			for _, d := range q.Date {
+				_ = c.format(d)
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// deribitURL - base url of the Deribit api
+var deribitURL = "https://www.deribit.com"
+
+// deribitMaxBars - most bars requested at a time, longer ranges are chunked
+const deribitMaxBars = 5000
+
+// deribitPeriods - chart resolutions of the Deribit api
+var deribitPeriods = []Period{Min1, Min3, Min5, Min15, Min30, Min60, Hour2, Hour6, Hour12, Daily}
+
+// deribitResolutions - resolution of each supported period
+var deribitResolutions = map[Period]string{
+	Min1:   "1",
+	Min3:   "3",
+	Min5:   "5",
+	Min15:  "15",
+	Min30:  "30",
+	Min60:  "60",
+	Hour2:  "120",
+	Hour6:  "360",
+	Hour12: "720",
+	Daily:  "1D",
+}
+
+// deribitChart - a chart data response, one array per column
+type deribitChart struct {
+	Result struct {
+		Status string    `json:"status"` // ok or no_data
+		Ticks  []int64   `json:"ticks"`  // bar start, milliseconds since the epoch
+		Open   []float64 `json:"open"`
+		High   []float64 `json:"high"`
+		Low    []float64 `json:"low"`
+		Close  []float64 `json:"close"`
+		Volume []float64 `json:"volume"`
+	} `json:"result"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewQuoteFromDeribit - Deribit historical candles for an instrument such as
+// BTC-PERPETUAL or BTC-28JUN24-60000-C, timestamped in UTC. Ranges over 5000
+// bars are requested in chunks with Delay between them. Returns a
+// *NoDataError when Deribit has no candles in the range.
+func NewQuoteFromDeribit(instrument, startDate, endDate string, period Period) (Quote, error) {
+	return NewQuoteFromDeribitCtx(context.Background(), instrument, startDate, endDate, period)
+}
+
+// NewQuoteFromDeribitCtx - NewQuoteFromDeribit, canceled with ctx. A download
+// canceled between its chunks returns the bars so far with the wrapped error of
+// ctx.
+func NewQuoteFromDeribitCtx(ctx context.Context, instrument, startDate, endDate string, period Period) (Quote, error) {
+
+	resolution, ok := deribitResolutions[period]
+	if !ok {
+		return NewQuote("", 0), ValidatePeriod("deribit", period)
+	}
+	from := ParseDateString(startDate)
+	to := parseEndDate(endDate)
+
+	quote := NewQuote(instrument, 0)
+	for i, start := 0, from; start.Before(to); i++ {
+		end := start.Add(deribitMaxBars * period.Duration())
+		if end.After(to) {
+			end = to
+		}
+		if i > 0 && delayContext(ctx, "deribit") != nil {
+			return quote.withMeta("deribit", period, false), interrupted(ctx, "deribit %s after %d requests", instrument, i)
+		}
+
+		params := url.Values{}
+		params.Set("instrument_name", instrument)
+		params.Set("resolution", resolution)
+		params.Set("start_timestamp", strconv.FormatInt(start.UnixNano()/int64(time.Millisecond), 10))
+		params.Set("end_timestamp", strconv.FormatInt(end.UnixNano()/int64(time.Millisecond), 10))
+
+		if err := waitRate(ctx, "deribit"); err != nil {
+			return quote.withMeta("deribit", period, false), interrupted(ctx, "deribit %s after %d requests", instrument, i)
+		}
+		client := httpClient()
+		resp, err := getContext(ctx, client, deribitURL+"/api/v2/public/get_tradingview_chart_data?"+params.Encode())
+		if err != nil && ctx.Err() != nil {
+			return quote.withMeta("deribit", period, false), interrupted(ctx, "deribit %s after %d requests", instrument, i)
+		}
+		if err != nil {
+			Log.Printf("deribit error: %v\n", err)
+			return NewQuote("", 0), err
+		}
+		contents, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		chunk, err := parseDeribit(instrument, contents)
+		if err == nil && resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("deribit %s: %s", instrument, resp.Status)
+		}
+		if _, noData := err.(*NoDataError); noData {
+			// an empty chunk, e.g. before the instrument listed
+			err = nil
+		}
+		if err != nil {
+			Log.Printf("deribit %s error: %v\n", instrument, err)
+			return NewQuote("", 0), err
+		}
+		quote.appendQuote(chunk.between(start, end))
+		start = end
+	}
+	if len(quote.Date) == 0 {
+		return NewQuote("", 0), &NoDataError{Source: "deribit", Symbol: instrument}
+	}
+	return quote.withMeta("deribit", period, false), nil
+}
+
+// parseDeribit - bars of a chart data response, a *NoDataError for a no_data
+// status
+func parseDeribit(instrument string, contents []byte) (Quote, error) {
+
+	var chart deribitChart
+	if err := json.Unmarshal(contents, &chart); err != nil {
+		return NewQuote("", 0), err
+	}
+	if chart.Error != nil {
+		return NewQuote("", 0), fmt.Errorf("deribit %s: %s (%d)", instrument, chart.Error.Message, chart.Error.Code)
+	}
+	res := chart.Result
+	if res.Status == "no_data" {
+		return NewQuote("", 0), &NoDataError{Source: "deribit", Symbol: instrument}
+	}
+	n := len(res.Ticks)
+	if len(res.Open) != n || len(res.High) != n || len(res.Low) != n || len(res.Close) != n || len(res.Volume) != n {
+		return NewQuote("", 0), fmt.Errorf("deribit %s: columns of different lengths", instrument)
+	}
+
+	quote := NewQuote(instrument, n)
+	for bar := 0; bar < n; bar++ {
+		quote.Date[bar] = time.Unix(0, res.Ticks[bar]*int64(time.Millisecond)).UTC()
+		quote.Open[bar] = res.Open[bar]
+		quote.High[bar] = res.High[bar]
+		quote.Low[bar] = res.Low[bar]
+		quote.Close[bar] = res.Close[bar]
+		quote.Volume[bar] = res.Volume[bar]
+	}
+	return quote, nil
+}
+
+// NewQuotesFromDeribitSyms - create a list of prices from instruments in string array
+func NewQuotesFromDeribitSyms(instruments []string, startDate, endDate string, period Period) (Quotes, error) {
+	return NewQuotesFromDeribitSymsCtx(context.Background(), instruments, startDate, endDate, period)
+}
+
+// NewQuotesFromDeribitSymsCtx - NewQuotesFromDeribitSyms, canceled with ctx.
+// The quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromDeribitSymsCtx(ctx context.Context, instruments []string, startDate, endDate string, period Period) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, instrument := range instruments {
+		quote, err := NewQuoteFromDeribitCtx(ctx, instrument, startDate, endDate, period)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "deribit after %d of %d symbols", i, len(instruments))
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + instrument)
+		}
+		delayContext(ctx, "deribit")
+	}
+	return quotes, nil
+}
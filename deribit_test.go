@@ -0,0 +1,80 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const deribitDaily = `{"jsonrpc":"2.0","result":{"volume":[1521.5,2210.25,980.0],"ticks":[1677628800000,1677715200000,1677801600000],
+"status":"ok","open":[23130.5,23640.0,23470.5],"low":[23020.0,23180.0,22000.0],"high":[23900.0,23800.5,23500.0],
+"cost":[35190000,52140000,21960000],"close":[23640.0,23470.5,22350.0]},"usIn":1,"usOut":2,"usDiff":1,"testnet":false}`
+
+func TestParseDeribit(t *testing.T) {
+	q, err := parseDeribit("BTC-PERPETUAL", []byte(deribitDaily))
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 3, 1), day(2023, 3, 2), day(2023, 3, 3)}, q.Date)
+	equals(t, []float64{23130.5, 23640.0, 23470.5}, q.Open)
+	equals(t, []float64{23900.0, 23800.5, 23500.0}, q.High)
+	equals(t, []float64{23020.0, 23180.0, 22000.0}, q.Low)
+	equals(t, []float64{23640.0, 23470.5, 22350.0}, q.Close)
+	equals(t, []float64{1521.5, 2210.25, 980.0}, q.Volume)
+
+	_, err = parseDeribit("BTC-PERPETUAL", []byte(`{"result":{"status":"no_data","ticks":[]}}`))
+	assert(t, noDataErr(err), "unexpected error %v", err)
+
+	_, err = parseDeribit("NOPE", []byte(`{"error":{"message":"instrument_not_found","code":13020}}`))
+	assert(t, err != nil && !noDataErr(err), "unexpected error %v", err)
+
+	_, err = parseDeribit("BTC-PERPETUAL", []byte(`{"result":{"status":"ok","ticks":[1677628800000],"open":[1,2],"high":[1],"low":[1],"close":[1],"volume":[1]}}`))
+	assert(t, err != nil, "columns of different lengths accepted")
+}
+
+// noDataErr - whether err is a *NoDataError
+func noDataErr(err error) bool {
+	_, ok := err.(*NoDataError)
+	return ok
+}
+
+func TestDeribitChunks(t *testing.T) {
+	type window struct{ from, to time.Time }
+	var windows []window
+	var instruments []string
+	fakeServer(t, &deribitURL, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		from, _ := strconv.ParseInt(query.Get("start_timestamp"), 10, 64)
+		to, _ := strconv.ParseInt(query.Get("end_timestamp"), 10, 64)
+		windows = append(windows, window{time.Unix(0, from*int64(time.Millisecond)).UTC(), time.Unix(0, to*int64(time.Millisecond)).UTC()})
+		instruments = append(instruments, query.Get("instrument_name"))
+		if len(windows) == 1 {
+			fmt.Fprint(w, `{"result":{"status":"no_data","ticks":[]}}`)
+			return
+		}
+		// one bar at the start of each window
+		fmt.Fprintf(w, `{"result":{"status":"ok","ticks":[%d],"open":[1],"high":[1],"low":[1],"close":[1],"volume":[1]}}`, from)
+	})
+
+	// 5000 minutes is 3 days 11h20m
+	q, err := NewQuoteFromDeribit("BTC-28JUN24-60000-C", "2023-03-01", "2023-03-08", Min1)
+	ok(t, err)
+	chunk := 5000 * time.Minute
+	start := day(2023, 3, 1)
+	equals(t, []window{
+		{start, start.Add(chunk)},
+		{start.Add(chunk), start.Add(2 * chunk)},
+		{start.Add(2 * chunk), day(2023, 3, 9)},
+	}, windows)
+	equals(t, []string{"BTC-28JUN24-60000-C", "BTC-28JUN24-60000-C", "BTC-28JUN24-60000-C"}, instruments)
+	equals(t, []time.Time{start.Add(chunk), start.Add(2 * chunk)}, q.Date)
+	equals(t, "deribit", q.Meta.Source)
+
+	// no_data for every chunk
+	windows = nil
+	_, err = NewQuoteFromDeribit("BTC-PERPETUAL", "2023-03-01", "2023-03-01", Daily)
+	assert(t, noDataErr(err), "unexpected error %v", err)
+
+	_, err = NewQuoteFromDeribit("BTC-PERPETUAL", "2023-03-01", "2023-03-01", Hour4)
+	assert(t, err != nil, "unsupported period accepted")
+}
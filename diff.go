@@ -0,0 +1,62 @@
+package quote
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// BarDiff - a value differing between two quotes at a date, or a bar only
+// one of them has
+type BarDiff struct {
+	Date  time.Time `json:"date"`
+	Field string    `json:"field,omitempty"` // open, high, low, close or volume, empty for a bar of one quote
+	A     float64   `json:"a"`
+	B     float64   `json:"b"`
+	Only  string    `json:"only,omitempty"` // a or b for a bar of one quote
+}
+
+// String - render d as a line of text
+func (d BarDiff) String() string {
+	date := d.Date.Format("2006-01-02 15:04")
+	if d.Only != "" {
+		return fmt.Sprintf("%s only in %s", date, d.Only)
+	}
+	return fmt.Sprintf("%s %s %g -> %g", date, d.Field, d.A, d.B)
+}
+
+// DiffQuotes - the open, high, low, close and volume of a and b deviating
+// by more than tol relative to a at the dates both have, and the bars of
+// dates only one has, in date order and then field order. Daily bars are
+// matched by day, intraday bars by minute.
+func DiffQuotes(a, b Quote, tol float64) []BarDiff {
+	period := Daily
+	if a.intraday() || b.intraday() {
+		period = Min1
+	}
+	ia, ib, onlyA, onlyB := alignDates(a, b, period)
+
+	var diffs []BarDiff
+	for k := range ia {
+		i, j := ia[k], ib[k]
+		for f, values := range [][2]float64{
+			{a.Open[i], b.Open[j]},
+			{a.High[i], b.High[j]},
+			{a.Low[i], b.Low[j]},
+			{a.Close[i], b.Close[j]},
+			{a.Volume[i], b.Volume[j]},
+		} {
+			if relDiff(values[0], values[1]) > tol {
+				diffs = append(diffs, BarDiff{Date: a.Date[i], Field: columnName(f), A: values[0], B: values[1]})
+			}
+		}
+	}
+	for _, d := range onlyA {
+		diffs = append(diffs, BarDiff{Date: d, Only: "a"})
+	}
+	for _, d := range onlyB {
+		diffs = append(diffs, BarDiff{Date: d, Only: "b"})
+	}
+	sort.SliceStable(diffs, func(i, j int) bool { return diffs[i].Date.Before(diffs[j].Date) })
+	return diffs
+}
@@ -0,0 +1,41 @@
+package quote
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDiffQuotes(t *testing.T) {
+	raw := cleanDaily("spy", 4)
+	equals(t, 0, len(DiffQuotes(raw, raw.copy(), 0)))
+
+	// a 0.5 dividend before the third bar
+	raw.AdjClose = []float64{100.5, 100.5, 101, 101}
+	adj := raw.Adjusted()
+	diffs := DiffQuotes(raw, adj, 0.001)
+	equals(t, 8, len(diffs))
+	equals(t, BarDiff{Date: day(2024, 1, 1), Field: "open", A: 100, B: 100 * 100.5 / 101}, diffs[0])
+	equals(t, BarDiff{Date: day(2024, 1, 1), Field: "close", A: 101, B: 100.5}, diffs[3])
+	equals(t, BarDiff{Date: day(2024, 1, 2), Field: "open", A: 100, B: 100 * 100.5 / 101}, diffs[4])
+	equals(t, "2024-01-01 00:00 close 101 -> 100.5", diffs[3].String())
+	// within tolerance
+	equals(t, 0, len(DiffQuotes(raw, adj, 0.01)))
+
+	// bars of one side, in date order among the values
+	b := adj.slice(1, 4).copy()
+	b.PushBar(Bar{Date: day(2024, 1, 8), Open: 1, High: 1, Low: 1, Close: 1})
+	diffs = DiffQuotes(raw, b, 0.01)
+	equals(t, []BarDiff{{Date: day(2024, 1, 1), Only: "a"}, {Date: day(2024, 1, 8), Only: "b"}}, diffs)
+	equals(t, "2024-01-08 00:00 only in b", diffs[1].String())
+
+	data, err := json.Marshal(diffs[:1])
+	ok(t, err)
+	equals(t, `[{"date":"2024-01-01T00:00:00Z","a":0,"b":0,"only":"a"}]`, string(data))
+
+	// intraday bars are matched by minute
+	m1 := resampleFixture(time.Date(2023, 5, 2, 9, 30, 0, 0, time.UTC), Min1, 3)
+	m2 := m1.copy()
+	m2.Volume[2] = 2
+	equals(t, []BarDiff{{Date: m1.Date[2], Field: "volume", A: 1, B: 2}}, DiffQuotes(m1, m2, 0))
+}
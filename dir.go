@@ -0,0 +1,93 @@
+package quote
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileError - a file that could not be read as a quote
+type FileError struct {
+	Filename string
+	Err      error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Filename, e.Err)
+}
+
+// DirError - files of a directory that failed to load, the others were loaded
+type DirError []*FileError
+
+func (e DirError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d files failed to load: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// NewQuotesFromDir - read the quote files of dir matching pattern, e.g.
+// "*.csv", in filename order. An empty pattern matches every .csv and .json
+// file, gzip compressed or not. Json files are read with NewQuoteFromJSONFile, with or without a
+// metadata envelope, other files with NewQuoteFromCSVFile. The symbol is the
+// filename without its extension unless a json file names one. Files that
+// fail to parse are skipped and returned in a DirError along with the
+// quotes of the others.
+func NewQuotesFromDir(dir string, pattern string) (Quotes, error) {
+
+	patterns := []string{pattern}
+	if pattern == "" {
+		patterns = []string{"*.csv", "*.json", "*.csv.gz", "*.json.gz"}
+	}
+	var filenames []string
+	for _, p := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, p))
+		if err != nil {
+			return Quotes{}, err
+		}
+		filenames = append(filenames, matches...)
+	}
+	if len(patterns) > 1 {
+		sort.Strings(filenames)
+	}
+
+	quotes := Quotes{}
+	var failed DirError
+	for _, filename := range filenames {
+		if info, err := os.Stat(filename); err != nil || info.IsDir() {
+			continue
+		}
+		q, err := NewQuoteFromFile(filename)
+		if err != nil {
+			failed = append(failed, &FileError{Filename: filename, Err: err})
+			continue
+		}
+		quotes = append(quotes, q)
+	}
+	if len(failed) > 0 {
+		return quotes, failed
+	}
+	return quotes, nil
+}
+
+// NewQuoteFromFile - read a csv or json quote file as NewQuotesFromDir does,
+// by its extension, gzip compressed or not
+func NewQuoteFromFile(filename string) (Quote, error) {
+	base := filepath.Base(filename)
+	if gzipped(base) {
+		base = base[:len(base)-len(".gz")]
+	}
+	ext := filepath.Ext(base)
+	symbol := strings.TrimSuffix(base, ext)
+	if strings.EqualFold(ext, ".json") {
+		q, err := NewQuoteFromJSONFile(filename)
+		if err == nil && q.Symbol == "" {
+			q.Symbol = symbol
+		}
+		return q, err
+	}
+	return NewQuoteFromCSVFile(symbol, filename)
+}
@@ -0,0 +1,46 @@
+package quote
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewQuotesFromDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dir")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	spy := partitionFixture()[0]
+	ok(t, spy.WriteCSV(filepath.Join(dir, "spy.csv")))
+	ok(t, spy.WriteMetaJSON(filepath.Join(dir, "aapl.json"), false))
+	btc := partitionFixture()[1]
+	btc.Symbol = ""
+	ok(t, btc.WriteJSON(filepath.Join(dir, "btc-usd.json"), false))
+	ok(t, ioutil.WriteFile(filepath.Join(dir, "bad.csv"), []byte("date,open,high,low,close,volume\nnot a date,1,2,3,4,5\n"), 0644))
+	ok(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a quote"), 0644))
+	ok(t, os.Mkdir(filepath.Join(dir, "sub.csv"), 0755))
+
+	quotes, err := NewQuotesFromDir(dir, "")
+	failed, isDirError := err.(DirError)
+	assert(t, isDirError, "unexpected error %v", err)
+	equals(t, 1, len(failed))
+	equals(t, filepath.Join(dir, "bad.csv"), failed[0].Filename)
+
+	// json symbols are kept, the others come from the filename
+	equals(t, 3, len(quotes))
+	equals(t, "spy", quotes[0].Symbol)
+	equals(t, "btc-usd", quotes[1].Symbol)
+	equals(t, "spy", quotes[2].Symbol)
+	equals(t, spy.Date, quotes[2].Date)
+	equals(t, spy.Close, quotes[2].Close)
+	equals(t, btc.Close, quotes[1].Close)
+
+	quotes, err = NewQuotesFromDir(dir, "*.json")
+	ok(t, err)
+	equals(t, 2, len(quotes))
+
+	_, err = NewQuotesFromDir(dir, "[")
+	assert(t, err != nil, "bad pattern accepted")
+}
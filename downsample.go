@@ -0,0 +1,107 @@
+package quote
+
+import "math"
+
+// DownsampleMethod - how Downsample reduces the bars of a quote
+type DownsampleMethod int
+
+const (
+	// Bucket - aggregate the bars of each bucket into one as Resample does,
+	// for candlestick charts
+	Bucket DownsampleMethod = iota
+	// LTTB - keep the bar of each bucket whose close spans the largest
+	// triangle with its neighbours (largest-triangle-three-buckets), for
+	// line charts
+	LTTB
+)
+
+// Downsample - q reduced to at most maxPoints bars for charting, q itself if
+// it has no more bars or maxPoints is zero or less. The first and last bars
+// are always kept as they are, the bars between them are split into
+// maxPoints-2 buckets of consecutive bars reduced to one bar each by method;
+// a bucket of LTTB keeps the OHLC of the picked bar, one of Bucket is dated
+// on its first bar. Dates are assumed to be ascending. A maxPoints of 1 is
+// taken as 2.
+func (q Quote) Downsample(maxPoints int, method DownsampleMethod) Quote {
+	n := len(q.Date)
+	if maxPoints <= 0 || n <= maxPoints {
+		return q
+	}
+	if maxPoints < 2 {
+		maxPoints = 2
+	}
+	buckets := maxPoints - 2
+	// bound - first bar of bucket i, n-1 past the last
+	bound := func(i int) int {
+		if i >= buckets {
+			return n - 1
+		}
+		return 1 + i*(n-2)/buckets
+	}
+
+	out := Quote{Symbol: q.Symbol, Precision: q.Precision, Meta: q.Meta}
+	out.appendBar(q, 0)
+	prev := 0
+	for i := 0; i < buckets; i++ {
+		start, end := bound(i), bound(i+1)
+		if method == Bucket {
+			out.appendBar(q, start)
+			for bar := start + 1; bar < end; bar++ {
+				out.joinBar(q, bar)
+			}
+			continue
+		}
+
+		// the average of the next bucket, the last bar after the last bucket
+		next, nextEnd := end, bound(i+2)
+		if nextEnd == end {
+			nextEnd = n
+		}
+		var avgX, avgY float64
+		for bar := next; bar < nextEnd; bar++ {
+			avgX += q.lttbX(bar)
+			avgY += q.Close[bar]
+		}
+		avgX /= float64(nextEnd - next)
+		avgY /= float64(nextEnd - next)
+
+		picked, maxArea := start, -1.0
+		ax, ay := q.lttbX(prev), q.Close[prev]
+		for bar := start; bar < end; bar++ {
+			area := math.Abs((ax-avgX)*(q.Close[bar]-ay) - (ax-q.lttbX(bar))*(avgY-ay))
+			if area > maxArea {
+				picked, maxArea = bar, area
+			}
+		}
+		out.appendBar(q, picked)
+		prev = picked
+	}
+	out.appendBar(q, n-1)
+	return out
+}
+
+// lttbX - the time of bar of q as the x coordinate of LTTB, in seconds
+func (q Quote) lttbX(bar int) float64 {
+	return float64(q.Date[bar].Unix())
+}
+
+// highstockPoints - q downsampled by Bucket to the optional maxPoints of the
+// Highstock writers
+func (q Quote) highstockPoints(maxPoints []int) Quote {
+	if len(maxPoints) == 0 {
+		return q
+	}
+	return q.Downsample(maxPoints[0], Bucket)
+}
+
+// highstockPoints - each member of q downsampled as Quote.highstockPoints
+func (q Quotes) highstockPoints(maxPoints []int) Quotes {
+	if len(maxPoints) == 0 {
+		return q
+	}
+	out := make(Quotes, len(q))
+	for i, quote := range q {
+		out[i] = quote.highstockPoints(maxPoints)
+	}
+	return out
+}
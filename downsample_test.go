@@ -0,0 +1,84 @@
+package quote
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownsampleBucket(t *testing.T) {
+	q := resampleFixture(time.Date(2023, 5, 2, 9, 30, 0, 0, time.UTC), Min1, 12)
+	q.Trades = []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+
+	d := q.Downsample(5, Bucket)
+	equals(t, []time.Time{q.Date[0], q.Date[1], q.Date[4], q.Date[7], q.Date[11]}, d.Date)
+	equals(t, []float64{0, 1, 4, 7, 11}, d.Open)
+	equals(t, []float64{0.5, 3.5, 6.5, 10.5, 11.5}, d.High)
+	equals(t, []float64{-0.5, 0.5, 3.5, 6.5, 10.5}, d.Low)
+	equals(t, []float64{0.25, 3.25, 6.25, 10.25, 11.25}, d.Close)
+	equals(t, []float64{1, 3, 3, 4, 1}, d.Volume)
+	equals(t, []float64{1, 3, 3, 4, 1}, d.Trades)
+	equals(t, q.Meta, d.Meta)
+	// q is not modified
+	equals(t, 12, len(q.Date))
+	equals(t, 1.0, q.Trades[1])
+}
+
+func TestDownsampleLTTB(t *testing.T) {
+	q := closesFixture(10, 11, 12, 50, 13, 14, 15, 16, 17, 18)
+	d := q.Downsample(4, LTTB)
+	equals(t, 4, len(d.Date))
+	// the spike is kept, with the OHLC of its bar
+	equals(t, q.Bar(0), d.Bar(0))
+	equals(t, q.Bar(3), d.Bar(1))
+	equals(t, q.Bar(9), d.Bar(3))
+
+	// a straight line keeps its ends
+	line := closesFixture(1, 2, 3, 4, 5, 6, 7)
+	d = line.Downsample(2, LTTB)
+	equals(t, []float64{1, 7}, d.Close)
+}
+
+func TestDownsampleBounds(t *testing.T) {
+	for _, method := range []DownsampleMethod{Bucket, LTTB} {
+		for _, n := range []int{0, 1, 2, 3, 10, 101, 1000} {
+			q := resampleFixture(time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC), Min5, n)
+			for i := range q.Close {
+				q.Close[i] = math.Sin(float64(i))
+			}
+			for _, maxPoints := range []int{-1, 0, 1, 2, 3, 7, 100, 2000} {
+				d := q.Downsample(maxPoints, method)
+				switch {
+				case maxPoints <= 0 || n <= maxPoints:
+					equals(t, q, d)
+					continue
+				case maxPoints == 1:
+					equals(t, 2, len(d.Date))
+				default:
+					assert(t, len(d.Date) <= maxPoints, "method %d: %d bars of %d downsampled to %d", method, n, maxPoints, len(d.Date))
+				}
+				equals(t, q.Date[0], d.Date[0])
+				equals(t, q.Date[n-1], d.Date[len(d.Date)-1])
+				equals(t, q.Close[n-1], d.Close[len(d.Close)-1])
+				for bar := 1; bar < len(d.Date); bar++ {
+					assert(t, d.Date[bar].After(d.Date[bar-1]), "method %d: %d of %d bars out of order at %d", method, maxPoints, n, bar)
+				}
+			}
+		}
+	}
+}
+
+func TestHighstockMaxPoints(t *testing.T) {
+	q := resampleFixture(time.Date(2023, 5, 2, 9, 30, 0, 0, time.UTC), Min1, 12)
+	equals(t, q.Highstock(), q.Highstock(0))
+	equals(t, q.Downsample(5, Bucket).Highstock(), q.Highstock(5))
+	equals(t, 5, strings.Count(q.Highstock(5), "\n")-2)
+
+	spy := cleanDaily("spy", 3)
+	quotes := Quotes{q, spy}
+	hs := quotes.Highstock(2)
+	equals(t, Quotes{q.Downsample(2, Bucket), spy.Downsample(2, Bucket)}.Highstock(), hs)
+	assert(t, hs != quotes.Highstock(), "quotes not downsampled")
+	equals(t, 12, len(q.Date))
+}
@@ -0,0 +1,190 @@
+package quote
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ecbURL - base url of the European Central Bank
+var ecbURL = "https://www.ecb.europa.eu"
+
+// ecbPeriods - periods of the ECB reference rates, published each TARGET
+// business day
+var ecbPeriods = []Period{Daily}
+
+// ecbRates - the ECB reference rates history, ascending dates and for each
+// currency its rate per euro on those dates, zero where not published
+type ecbRates struct {
+	dates []time.Time
+	rates map[string][]float64
+}
+
+// NewQuoteFromECB - ECB euro foreign exchange reference rates for a currency
+// such as usd, the rate per euro, or for a pair such as gbpjpy, the cross rate
+// of two euro rates. No api key needed. The rate is in Open, High, Low and
+// Close and Volume is zero. There are no bars on weekends and TARGET holidays.
+func NewQuoteFromECB(pair, startDate, endDate string) (Quote, error) {
+	return NewQuoteFromECBCtx(context.Background(), pair, startDate, endDate)
+}
+
+// NewQuoteFromECBCtx - NewQuoteFromECB, canceled with ctx
+func NewQuoteFromECBCtx(ctx context.Context, pair, startDate, endDate string) (Quote, error) {
+
+	rates, err := ecbHistory(ctx)
+	if err != nil {
+		Log.Printf("ecb error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	return rates.quote(pair, startDate, endDate)
+}
+
+// ecbHistory - download and parse the reference rates history, canceled with
+// ctx
+func ecbHistory(ctx context.Context) (*ecbRates, error) {
+
+	if err := waitRate(ctx, "ecb"); err != nil {
+		return nil, err
+	}
+	client := httpClient()
+	resp, err := getContext(ctx, client, ecbURL+"/stats/eurofxref/eurofxref-hist.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ecb: %s", resp.Status)
+	}
+	contents, _ := ioutil.ReadAll(resp.Body)
+	return parseECBZip(contents)
+}
+
+// parseECBZip - the reference rates of eurofxref-hist.zip, a zip holding
+// a single csv
+func parseECBZip(contents []byte) (*ecbRates, error) {
+
+	archive, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+	if err != nil {
+		return nil, err
+	}
+	if len(archive.File) != 1 {
+		return nil, fmt.Errorf("ecb: %d files in the history archive, expected one", len(archive.File))
+	}
+	f, err := archive.File[0].Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseECB(f)
+}
+
+// parseECB - the reference rates of the history csv, newest first, with a
+// Date column and a column per currency. Rates not published are N/A.
+func parseECB(r io.Reader) (*ecbRates, error) {
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || rows[0][0] != "Date" {
+		return nil, fmt.Errorf("ecb: no Date column in the history")
+	}
+	header := rows[0]
+	rows = rows[1:]
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+
+	rates := &ecbRates{dates: make([]time.Time, len(rows)), rates: map[string][]float64{}}
+	for _, currency := range header[1:] {
+		if currency = strings.TrimSpace(currency); currency != "" {
+			rates.rates[strings.ToLower(currency)] = make([]float64, len(rows))
+		}
+	}
+	for i, row := range rows {
+		if rates.dates[i], err = time.Parse("2006-01-02", row[0]); err != nil {
+			return nil, err
+		}
+		for col := 1; col < len(row) && col < len(header); col++ {
+			currency := strings.ToLower(strings.TrimSpace(header[col]))
+			if v, err := strconv.ParseFloat(strings.TrimSpace(row[col]), 64); err == nil && currency != "" {
+				rates.rates[currency][i] = v
+			}
+		}
+	}
+	return rates, nil
+}
+
+// euroRate - rates per euro of a currency, all 1 for the euro
+func (e *ecbRates) euroRate(currency string) ([]float64, bool) {
+	if currency == "eur" {
+		ones := make([]float64, len(e.dates))
+		for i := range ones {
+			ones[i] = 1
+		}
+		return ones, true
+	}
+	r, ok := e.rates[currency]
+	return r, ok
+}
+
+// quote - daily rates of a currency or pair between startDate and endDate
+// (inclusive), skipping days either side of a cross rate is missing
+func (e *ecbRates) quote(pair, startDate, endDate string) (Quote, error) {
+
+	p := strings.ToLower(pair)
+	base, counter := "eur", p
+	if len(p) == 6 {
+		base, counter = p[:3], p[3:]
+	}
+	baseRates, okBase := e.euroRate(base)
+	counterRates, okCounter := e.euroRate(counter)
+	if len(counter) != 3 || !okBase || !okCounter {
+		return NewQuote("", 0), fmt.Errorf("ecb: unknown currency or pair '%s'", pair)
+	}
+
+	quote := NewQuote(pair, 0)
+	for i, date := range e.dates {
+		if baseRates[i] == 0 || counterRates[i] == 0 {
+			continue
+		}
+		rate := counterRates[i] / baseRates[i]
+		quote.PushBar(Bar{Date: date, Open: rate, High: rate, Low: rate, Close: rate})
+	}
+	return quote.between(ParseDateString(startDate), parseEndDate(endDate)).withMeta("ecb", Daily, false), nil
+}
+
+// NewQuotesFromECBSyms - create a list of rates from currencies and pairs in
+// string array, from a single download
+func NewQuotesFromECBSyms(pairs []string, startDate, endDate string) (Quotes, error) {
+	return NewQuotesFromECBSymsCtx(context.Background(), pairs, startDate, endDate)
+}
+
+// NewQuotesFromECBSymsCtx - NewQuotesFromECBSyms, canceled with ctx
+func NewQuotesFromECBSymsCtx(ctx context.Context, pairs []string, startDate, endDate string) (Quotes, error) {
+
+	quotes := Quotes{}
+	rates, err := ecbHistory(ctx)
+	if err != nil {
+		Log.Printf("ecb error: %v\n", err)
+		return quotes, err
+	}
+	for _, pair := range pairs {
+		quote, err := rates.quote(pair, startDate, endDate)
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + pair)
+		}
+	}
+	return quotes, nil
+}
@@ -0,0 +1,82 @@
+package quote
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// ecbHistoryCSV - the history csv layout, newest first with a trailing comma,
+// Friday 2023-03-03 to Tuesday 2023-03-07 across a weekend
+const ecbHistoryCSV = `Date,USD,JPY,GBP,CYP,
+2023-03-07,1.0683,145.20,0.88750,N/A,
+2023-03-06,1.0670,145.26,0.88660,N/A,
+2023-03-03,1.0615,144.41,0.88705,N/A,
+`
+
+// ecbZip - csv zipped as the ECB serves it
+func ecbZip(t *testing.T, csv string) []byte {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	f, err := archive.Create("eurofxref-hist.csv")
+	ok(t, err)
+	_, err = f.Write([]byte(csv))
+	ok(t, err)
+	ok(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestParseECBZip(t *testing.T) {
+	rates, err := parseECBZip(ecbZip(t, ecbHistoryCSV))
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 3, 3), day(2023, 3, 6), day(2023, 3, 7)}, rates.dates)
+	equals(t, []float64{1.0615, 1.0670, 1.0683}, rates.rates["usd"])
+	equals(t, []float64{0, 0, 0}, rates.rates["cyp"])
+
+	_, err = parseECBZip([]byte("not a zip"))
+	assert(t, err != nil, "invalid zip accepted")
+}
+
+func TestECB(t *testing.T) {
+	requests := 0
+	fakeServer(t, &ecbURL, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(ecbZip(t, ecbHistoryCSV))
+	})
+
+	// no bars over the weekend
+	q, err := NewQuoteFromECB("usd", "2023-03-03", "2023-03-07")
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 3, 3), day(2023, 3, 6), day(2023, 3, 7)}, q.Date)
+	equals(t, []float64{1.0615, 1.0670, 1.0683}, q.Close)
+	equals(t, q.Close, q.Open)
+	equals(t, []float64{0, 0, 0}, q.Volume)
+	equals(t, "USD", q.Meta.Currency)
+
+	// gbpjpy is eurjpy / eurgbp
+	q, err = NewQuoteFromECB("gbpjpy", "2023-03-06", "2023-03-06")
+	ok(t, err)
+	jpy, gbp := 145.26, 0.88660
+	equals(t, []float64{jpy / gbp}, q.Close)
+	equals(t, "JPY", q.Meta.Currency)
+
+	q, err = NewQuoteFromECB("eurusd", "2023-03-06", "2023-03-07")
+	ok(t, err)
+	equals(t, []float64{1.0670, 1.0683}, q.Close)
+
+	// not published since the euro replaced it
+	q, err = NewQuoteFromECB("cyp", "2023-03-03", "2023-03-07")
+	ok(t, err)
+	equals(t, 0, len(q.Date))
+
+	_, err = NewQuoteFromECB("xyz", "2023-03-03", "2023-03-07")
+	assert(t, err != nil, "unknown currency accepted")
+
+	requests = 0
+	quotes, err := NewQuotesFromECBSyms([]string{"usd", "gbpusd", "nope"}, "2023-03-03", "2023-03-07")
+	ok(t, err)
+	equals(t, 1, requests)
+	equals(t, 2, len(quotes))
+}
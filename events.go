@@ -0,0 +1,94 @@
+package quote
+
+import (
+	"sort"
+	"time"
+)
+
+// Split - stock split on its ex-date, Numerator new shares for every
+// Denominator old shares, e.g. 4 for 1
+type Split struct {
+	Date        time.Time
+	Numerator   int
+	Denominator int
+}
+
+// Splits - list of splits
+type Splits []Split
+
+// Events - corporate actions of a symbol
+type Events struct {
+	Dividends Dividends
+	Splits    Splits
+}
+
+// sort - order events by date
+func (e Events) sort() {
+	sort.SliceStable(e.Dividends, func(i, j int) bool { return e.Dividends[i].Date.Before(e.Dividends[j].Date) })
+	sort.SliceStable(e.Splits, func(i, j int) bool { return e.Splits[i].Date.Before(e.Splits[j].Date) })
+}
+
+// eventBar - first bar of q on or after the day of d, len(q.Date) if none
+func (q Quote) eventBar(d time.Time) int {
+	return sort.Search(len(q.Date), func(i int) bool { return sameOrAfterDay(q.Date[i], d) })
+}
+
+// ApplyEvents - back-adjusted copy of the raw prices in q.
+//
+// Bars before each split's ex-date have Open, High, Low and Close divided by
+// the split ratio and Volume multiplied by it. With dividends set, bars before
+// each ex-dividend date are also scaled by 1-amount/close, close being the
+// split adjusted close of the previous bar; amounts are taken as split
+// adjusted, as Yahoo reports them. This reproduces the provider adjusted close.
+// Events on a non-trading day apply from the next bar, events on or before the
+// first bar or after the last bar are ignored.
+func (q Quote) ApplyEvents(events Events, dividends bool) Quote {
+
+	adj := q.copy()
+	numrows := len(q.Close)
+
+	// price and volume factors of each bar, splits first
+	price := make([]float64, numrows)
+	volume := make([]float64, numrows)
+	for bar := range price {
+		price[bar], volume[bar] = 1, 1
+	}
+	for _, split := range events.Splits {
+		bar := q.eventBar(split.Date)
+		if bar == 0 || bar == numrows || split.Numerator <= 0 || split.Denominator <= 0 {
+			continue
+		}
+		ratio := float64(split.Numerator) / float64(split.Denominator)
+		for b := 0; b < bar; b++ {
+			price[b] /= ratio
+			volume[b] *= ratio
+		}
+	}
+
+	if dividends {
+		splitOnly := append([]float64(nil), price...)
+		for _, div := range events.Dividends {
+			bar := q.eventBar(div.Date)
+			if bar == 0 || bar == numrows {
+				continue
+			}
+			prev := q.Close[bar-1] * splitOnly[bar-1]
+			if prev == 0 {
+				continue
+			}
+			f := 1 - div.Amount/prev
+			for b := 0; b < bar; b++ {
+				price[b] *= f
+			}
+		}
+	}
+
+	for bar := 0; bar < numrows; bar++ {
+		adj.Open[bar] = q.Open[bar] * price[bar]
+		adj.High[bar] = q.High[bar] * price[bar]
+		adj.Low[bar] = q.Low[bar] * price[bar]
+		adj.Close[bar] = q.Close[bar] * price[bar]
+		adj.Volume[bar] = q.Volume[bar] * volume[bar]
+	}
+	return adj
+}
@@ -0,0 +1,71 @@
+package quote
+
+import (
+	"math"
+	"net/http"
+	"testing"
+)
+
+// yahooAAPLEvents2020 - chart api response with AAPL's 2020 dividends and 4:1 split
+const yahooAAPLEvents2020 = `{"chart":{"result":[{"meta":{"symbol":"AAPL","gmtoffset":-18000},
+"timestamp":[1581085800],
+"events":{
+ "dividends":{
+  "1604673000":{"amount":0.205,"date":1604673000},
+  "1581085800":{"amount":0.1925,"date":1581085800},
+  "1596810600":{"amount":0.205,"date":1596810600},
+  "1588948200":{"amount":0.205,"date":1588948200}},
+ "splits":{
+  "1598884200":{"date":1598884200,"numerator":4,"denominator":1,"splitRatio":"4:1"}}},
+"indicators":{"quote":[{"open":[80],"high":[81],"low":[79],"close":[80],"volume":[1]}]}}],"error":null}}`
+
+func TestNewEventsFromYahoo(t *testing.T) {
+	var query string
+	useYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("events")
+		w.Write([]byte(yahooAAPLEvents2020))
+	})
+
+	events, err := NewEventsFromYahoo("aapl", "2020-01-01", "2020-12-31")
+	ok(t, err)
+	equals(t, "div|split", query)
+	equals(t, Dividends{
+		{Date: day(2020, 2, 7), Amount: 0.1925},
+		{Date: day(2020, 5, 8), Amount: 0.205},
+		{Date: day(2020, 8, 7), Amount: 0.205},
+		{Date: day(2020, 11, 6), Amount: 0.205},
+	}, events.Dividends)
+	equals(t, Splits{{Date: day(2020, 8, 31), Numerator: 4, Denominator: 1}}, events.Splits)
+}
+
+func TestApplyEvents(t *testing.T) {
+	// raw prices around a 4:1 split on the 4th bar, dividend on the 2nd
+	q := NewQuote("aapl", 5)
+	closes := []float64{400, 404, 408, 102, 103}
+	for bar, c := range closes {
+		q.Date[bar] = day(2020, 8, 26+bar)
+		q.Open[bar], q.High[bar], q.Low[bar], q.Close[bar] = c, c+4, c-4, c
+		q.Volume[bar] = 1000
+	}
+	q.Date[3] = day(2020, 8, 31) // the split lands on monday
+	q.Date[4] = day(2020, 9, 1)
+	events := Events{
+		Dividends: Dividends{{Date: day(2020, 8, 27), Amount: 0.5}},
+		Splits:    Splits{{Date: day(2020, 8, 29), Numerator: 4, Denominator: 1}},
+	}
+
+	splits := q.ApplyEvents(events, false)
+	equals(t, []float64{100, 101, 102, 102, 103}, splits.Close)
+	equals(t, []float64{101, 102, 103, 106, 107}, splits.High)
+	equals(t, []float64{4000, 4000, 4000, 1000, 1000}, splits.Volume)
+	// raw prices untouched
+	equals(t, 400.0, q.Close[0])
+
+	// the split adjusted dividend of 0.5 on a close of 100 scales earlier bars by 0.995
+	all := q.ApplyEvents(events, true)
+	assert(t, math.Abs(all.Close[0]-99.5) < 1e-9, "close %v", all.Close[0])
+	equals(t, splits.Close[1:], all.Close[1:])
+
+	// events outside the quote are ignored
+	equals(t, q.Close, q.ApplyEvents(Events{Splits: Splits{{Date: day(2020, 8, 1), Numerator: 2, Denominator: 1}}}, true).Close)
+}
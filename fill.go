@@ -0,0 +1,92 @@
+package quote
+
+import "time"
+
+// FillMethod - prices of the bars Fill inserts
+type FillMethod int
+
+const (
+	// ForwardFill - open, high, low and close at the previous close, zero volume
+	ForwardFill FillMethod = iota
+	// Zero - all prices and volume zero
+	Zero
+	// Interpolate - open, high, low and close on a straight line from the
+	// previous close to the next, zero volume
+	Interpolate
+)
+
+// FillOptions - options of FillWithOptions
+type FillOptions struct {
+	Method   FillMethod
+	Calendar *Calendar // which missing bars are filled, nil for the calendar of the source in q's metadata
+}
+
+// MissingBars - timestamps of bars expected by period and cal between the
+// first and last bar of q but absent, e.g. only weekdays for equities with
+// WeekdaysOnly. Dates are assumed to be ascending, see Sort. An empty period
+// is taken from q's metadata or inferred from its bars.
+func (q Quote) MissingBars(period Period, cal Calendar) []time.Time {
+	return q.gaps(q.periodOrInfer(period), cal)
+}
+
+// Fill - copy of q with a bar inserted at each of its MissingBars, priced by
+// method. Missing bars are counted on the calendar of the source in q's
+// metadata, WeekdaysOnly for equity sources such as yahoo and AllDays
+// otherwise; use FillWithOptions for another calendar.
+func (q Quote) Fill(period Period, method FillMethod) Quote {
+	return q.FillWithOptions(period, FillOptions{Method: method})
+}
+
+// FillWithOptions - Fill with the method and calendar of opts. Inserted bars
+// have zero trades and repeat the previous value of other optional columns,
+// the adjusted open, high and low at the repeated adjusted close, except
+// with Zero.
+func (q Quote) FillWithOptions(period Period, opts FillOptions) Quote {
+	missing := q.MissingBars(period, q.calendarOrSource(opts.Calendar))
+	if len(missing) == 0 {
+		return q.copy()
+	}
+
+	out := NewQuote(q.Symbol, 0)
+	out.Precision = q.Precision
+	if q.Meta != nil {
+		meta := *q.Meta
+		out.Meta = &meta
+	}
+	for bar := range q.Date {
+		out.appendBar(q, bar)
+		var fill []time.Time
+		for len(missing) > 0 && bar+1 < len(q.Date) && missing[0].Before(q.Date[bar+1]) {
+			fill = append(fill, missing[0])
+			missing = missing[1:]
+		}
+
+		for k, date := range fill {
+			price := q.Close[bar]
+			switch opts.Method {
+			case Zero:
+				price = 0
+			case Interpolate:
+				price += (q.Close[bar+1] - q.Close[bar]) * float64(k+1) / float64(len(fill)+1)
+			}
+			out.PushBar(Bar{Date: date, Open: price, High: price, Low: price, Close: price})
+			if opts.Method == Zero {
+				continue
+			}
+			for _, x := range extraColumns {
+				if col := *x.col(&out); col != nil && x.agg != aggSum {
+					col[len(col)-1] = col[len(col)-2]
+				}
+			}
+			// a flat bar at the adjusted close, as at the close
+			if n := len(out.Date) - 1; out.AdjClose != nil {
+				for _, col := range [][]float64{out.AdjOpen, out.AdjHigh, out.AdjLow} {
+					if col != nil {
+						col[n] = out.AdjClose[n]
+					}
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package quote
+
+import (
+	"testing"
+	"time"
+)
+
+// fillFixture - daily spy bars from monday 2023-01-02 to friday 2023-01-13
+// without wednesday the 4th and tuesday the 10th, closing at 100 plus the day
+func fillFixture() Quote {
+	q := NewQuote("spy", 0)
+	for d := 2; d <= 13; d++ {
+		date := day(2023, 1, d)
+		if d == 4 || d == 10 || date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			continue
+		}
+		c := 100 + float64(d)
+		q.PushBar(Bar{Date: date, Open: c - 1, High: c + 1, Low: c - 2, Close: c, Volume: 1000})
+	}
+	q.Meta = &Metadata{Symbol: "spy", Source: "yahoo", Period: Daily}
+	return q
+}
+
+func TestMissingBars(t *testing.T) {
+	spy := fillFixture()
+	equals(t, []time.Time{day(2023, 1, 4), day(2023, 1, 10)}, spy.MissingBars(Daily, WeekdaysOnly))
+	equals(t, []time.Time{day(2023, 1, 4), day(2023, 1, 7), day(2023, 1, 8), day(2023, 1, 10)}, spy.MissingBars(Daily, AllDays))
+	equals(t, spy.MissingBars(Daily, WeekdaysOnly), spy.MissingBars("", WeekdaysOnly))
+}
+
+func TestFillDaily(t *testing.T) {
+	spy := fillFixture()
+
+	filled := spy.Fill(Daily, ForwardFill)
+	equals(t, 10, filled.Len())
+	equals(t, Bar{Date: day(2023, 1, 4), Open: 103, High: 103, Low: 103, Close: 103}, filled.Bar(2))
+	equals(t, Bar{Date: day(2023, 1, 10), Open: 109, High: 109, Low: 109, Close: 109}, filled.Bar(6))
+	equals(t, spy.Bar(2), filled.Bar(3))
+	equals(t, spy.Bar(7), filled.Bar(9))
+	equals(t, 8, spy.Len())
+
+	filled = spy.Fill(Daily, Zero)
+	equals(t, Bar{Date: day(2023, 1, 4)}, filled.Bar(2))
+
+	filled = spy.Fill(Daily, Interpolate)
+	equals(t, 104.0, filled.Close[2])
+	equals(t, 104.0, filled.Open[2])
+
+	// a flat bar at the previous adjusted close
+	adj := spy.copy()
+	adj.AdjClose = make([]float64, adj.Len())
+	for bar, c := range adj.Close {
+		adj.AdjClose[bar] = c / 2
+	}
+	filled = adj.withAdjustedPrices().Fill(Daily, ForwardFill)
+	equals(t, []float64{51.5, 51.5, 51.5, 51.5}, []float64{filled.AdjOpen[2], filled.AdjHigh[2], filled.AdjLow[2], filled.AdjClose[2]})
+
+	// weekends are filled on every day calendars
+	filled = spy.FillWithOptions(Daily, FillOptions{Method: ForwardFill, Calendar: &AllDays})
+	equals(t, 12, filled.Len())
+	equals(t, day(2023, 1, 7), filled.Date[5])
+	spy.Meta = nil
+	equals(t, 12, spy.Fill(Daily, ForwardFill).Len())
+
+	// complete quotes are copied
+	filled = spy.Fill(Daily, ForwardFill)
+	copied := filled.Fill(Daily, ForwardFill)
+	equals(t, filled, copied)
+	copied.Close[0] = 0
+	assert(t, filled.Close[0] != 0, "fill shares arrays")
+}
+
+func TestFillIntraday(t *testing.T) {
+	// three hours of minute bars without the middle hour
+	start := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	btc := NewQuote("BTC-USD", 0)
+	for m := 0; m < 180; m++ {
+		if m >= 60 && m < 120 {
+			continue
+		}
+		btc.PushBar(Bar{Date: start.Add(time.Duration(m) * time.Minute), Open: float64(m), High: float64(m), Low: float64(m), Close: float64(m), Volume: 1})
+	}
+	btc.Trades = make([]float64, btc.Len())
+	btc.OpenInterest = make([]float64, btc.Len())
+	for i := range btc.Trades {
+		btc.Trades[i], btc.OpenInterest[i] = 3, float64(i)
+	}
+	btc.Meta = &Metadata{Symbol: "BTC-USD", Source: "coinbase", Period: Min1}
+
+	missing := btc.MissingBars(Min1, AllDays)
+	equals(t, 60, len(missing))
+	equals(t, start.Add(time.Hour), missing[0])
+	equals(t, start.Add(2*time.Hour-time.Minute), missing[59])
+
+	filled := btc.Fill("", Interpolate)
+	equals(t, 180, filled.Len())
+	for m := 0; m < 180; m++ {
+		equals(t, start.Add(time.Duration(m)*time.Minute), filled.Date[m])
+		equals(t, float64(m), filled.Close[m])
+	}
+	equals(t, 0.0, filled.Volume[90])
+	equals(t, 0.0, filled.Trades[90])
+	equals(t, 59.0, filled.OpenInterest[90])
+	equals(t, 60.0, filled.OpenInterest[120])
+
+	filled = btc.Fill(Min1, Zero)
+	equals(t, 0.0, filled.OpenInterest[90])
+	equals(t, 0.0, filled.Close[90])
+}
@@ -0,0 +1,188 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// finnhubURL - base url of the Finnhub api
+var finnhubURL = "https://finnhub.io"
+
+// finnhubPeriods - candle resolutions of the Finnhub api
+var finnhubPeriods = []Period{Min1, Min5, Min15, Min30, Min60, Daily, Weekly, Monthly}
+
+// finnhubResolutions - resolution of each supported period
+var finnhubResolutions = map[Period]string{
+	Min1:    "1",
+	Min5:    "5",
+	Min15:   "15",
+	Min30:   "30",
+	Min60:   "60",
+	Daily:   "D",
+	Weekly:  "W",
+	Monthly: "M",
+}
+
+// finnhubCandles - a candle response, one array per column
+type finnhubCandles struct {
+	S string    `json:"s"` // ok or no_data
+	T []int64   `json:"t"` // bar start, seconds since the epoch
+	O []float64 `json:"o"`
+	H []float64 `json:"h"`
+	L []float64 `json:"l"`
+	C []float64 `json:"c"`
+	V []float64 `json:"v"`
+}
+
+// NewQuoteFromFinnhub - Finnhub historical candles for a symbol. Symbols with
+// an exchange prefix such as BINANCE:BTCUSDT are crypto pairs, others stocks.
+// Intraday bars are timestamped in UTC, daily and longer bars by date. Minute
+// resolutions are requested a year at a time, with Delay between requests.
+// Returns a *NoDataError when Finnhub has no candles in the range.
+func NewQuoteFromFinnhub(symbol, startDate, endDate string, period Period, token string) (Quote, error) {
+	return NewQuoteFromFinnhubCtx(context.Background(), symbol, startDate, endDate, period, token)
+}
+
+// NewQuoteFromFinnhubCtx - NewQuoteFromFinnhub, canceled with ctx. A download
+// canceled between its requests returns the bars so far with the wrapped error
+// of ctx.
+func NewQuoteFromFinnhubCtx(ctx context.Context, symbol, startDate, endDate string, period Period, token string) (Quote, error) {
+
+	if err := ValidatePeriod("finnhub", period); err != nil {
+		Log.Printf("finnhub error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	from := ParseDateString(startDate)
+	to := parseEndDate(endDate)
+
+	endpoint := "stock"
+	if strings.Contains(symbol, ":") {
+		endpoint = "crypto"
+	}
+
+	quote := NewQuote(symbol, 0)
+	candles := func() Quote {
+		return quote.between(from, to).withMeta("finnhub", period, true)
+	}
+	for i, start := 0, from; start.Before(to); i++ {
+		end := to
+		if period.Duration() < 24*time.Hour && start.AddDate(1, 0, 0).Before(to) {
+			end = start.AddDate(1, 0, 0)
+		}
+		if i > 0 && delayContext(ctx, "finnhub") != nil {
+			return candles(), interrupted(ctx, "finnhub %s after %d requests", symbol, i)
+		}
+
+		params := url.Values{}
+		params.Set("symbol", symbol)
+		params.Set("resolution", finnhubResolutions[period])
+		params.Set("from", strconv.FormatInt(start.Unix(), 10))
+		params.Set("to", strconv.FormatInt(end.Unix(), 10))
+		params.Set("token", token)
+
+		if err := waitRate(ctx, "finnhub"); err != nil {
+			return candles(), interrupted(ctx, "finnhub %s after %d requests", symbol, i)
+		}
+		client := httpClient()
+		resp, err := getContext(ctx, client, fmt.Sprintf("%s/api/v1/%s/candle?%s", finnhubURL, endpoint, params.Encode()))
+		if err != nil && ctx.Err() != nil {
+			return candles(), interrupted(ctx, "finnhub %s after %d requests", symbol, i)
+		}
+		if err != nil {
+			Log.Printf("finnhub error: %v\n", err)
+			return NewQuote("", 0), err
+		}
+		contents, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			// errors come as {"error":"..."}
+			var body struct {
+				Error string `json:"error"`
+			}
+			json.Unmarshal(contents, &body)
+			err := fmt.Errorf("finnhub %s: %s %s", symbol, resp.Status, body.Error)
+			Log.Printf("%v\n", err)
+			return NewQuote("", 0), err
+		}
+
+		chunk, err := parseFinnhub(symbol, contents, period)
+		if _, noData := err.(*NoDataError); err != nil && !noData {
+			Log.Printf("finnhub %s error: %v\n", symbol, err)
+			return NewQuote("", 0), err
+		}
+		quote.appendQuote(chunk)
+		start = end
+	}
+	quote = quote.between(from, to)
+	if len(quote.Date) == 0 {
+		return NewQuote("", 0), &NoDataError{Source: "finnhub", Symbol: symbol}
+	}
+	return quote.withMeta("finnhub", period, true), nil
+}
+
+// parseFinnhub - bars of a Finnhub candle response, a *NoDataError for a
+// no_data status
+func parseFinnhub(symbol string, contents []byte, period Period) (Quote, error) {
+
+	var candles finnhubCandles
+	if err := json.Unmarshal(contents, &candles); err != nil {
+		return NewQuote("", 0), err
+	}
+	if candles.S == "no_data" {
+		return NewQuote("", 0), &NoDataError{Source: "finnhub", Symbol: symbol}
+	}
+	if candles.S != "ok" {
+		return NewQuote("", 0), fmt.Errorf("finnhub %s: status '%s'", symbol, candles.S)
+	}
+	n := len(candles.T)
+	if len(candles.O) != n || len(candles.H) != n || len(candles.L) != n || len(candles.C) != n || len(candles.V) != n {
+		return NewQuote("", 0), fmt.Errorf("finnhub %s: columns of different lengths", symbol)
+	}
+
+	quote := NewQuote(symbol, n)
+	for bar := 0; bar < n; bar++ {
+		date := time.Unix(candles.T[bar], 0).UTC()
+		if period.Duration() >= 24*time.Hour {
+			date = date.Truncate(24 * time.Hour)
+		}
+		quote.Date[bar] = date
+		quote.Open[bar] = candles.O[bar]
+		quote.High[bar] = candles.H[bar]
+		quote.Low[bar] = candles.L[bar]
+		quote.Close[bar] = candles.C[bar]
+		quote.Volume[bar] = candles.V[bar]
+	}
+	return quote, nil
+}
+
+// NewQuotesFromFinnhubSyms - create a list of prices from symbols in string array
+func NewQuotesFromFinnhubSyms(symbols []string, startDate, endDate string, period Period, token string) (Quotes, error) {
+	return NewQuotesFromFinnhubSymsCtx(context.Background(), symbols, startDate, endDate, period, token)
+}
+
+// NewQuotesFromFinnhubSymsCtx - NewQuotesFromFinnhubSyms, canceled with ctx.
+// The quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromFinnhubSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period, token string) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromFinnhubCtx(ctx, symbol, startDate, endDate, period, token)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "finnhub after %d of %d symbols", i, len(symbols))
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + symbol)
+		}
+		delayContext(ctx, "finnhub")
+	}
+	return quotes, nil
+}
@@ -0,0 +1,66 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const finnhubDaily = `{"c":[145.31,145.91,151.03],"h":[147.23,146.71,151.11],"l":[145.01,143.9,147.33],
+"o":[146.83,144.38,148.04],"s":"ok","t":[1677628800,1677715200,1677801600],"v":[55479000,52238100,70732300]}`
+
+func TestParseFinnhub(t *testing.T) {
+	q, err := parseFinnhub("AAPL", []byte(finnhubDaily), Daily)
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 3, 1), day(2023, 3, 2), day(2023, 3, 3)}, q.Date)
+	equals(t, []float64{146.83, 144.38, 148.04}, q.Open)
+	equals(t, []float64{147.23, 146.71, 151.11}, q.High)
+	equals(t, []float64{145.01, 143.9, 147.33}, q.Low)
+	equals(t, []float64{145.31, 145.91, 151.03}, q.Close)
+	equals(t, []float64{55479000, 52238100, 70732300}, q.Volume)
+
+	_, err = parseFinnhub("NOPE", []byte(`{"s":"no_data"}`), Daily)
+	_, noData := err.(*NoDataError)
+	assert(t, noData, "unexpected error %v", err)
+
+	_, err = parseFinnhub("AAPL", []byte(`{"c":[1,2],"h":[1],"l":[1],"o":[1],"s":"ok","t":[1677628800],"v":[1]}`), Daily)
+	assert(t, err != nil, "columns of different lengths accepted")
+}
+
+func TestFinnhubChunks(t *testing.T) {
+	type window struct{ from, to time.Time }
+	var windows []window
+	fakeServer(t, &finnhubURL, func(w http.ResponseWriter, r *http.Request) {
+		from, _ := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+		to, _ := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+		windows = append(windows, window{time.Unix(from, 0).UTC(), time.Unix(to, 0).UTC()})
+		if r.URL.Query().Get("symbol") == "NEW" && len(windows) == 1 {
+			fmt.Fprint(w, `{"s":"no_data"}`)
+			return
+		}
+		// one bar at the start of each window
+		fmt.Fprintf(w, `{"c":[1],"h":[1],"l":[1],"o":[1],"s":"ok","t":[%d],"v":[1]}`, from)
+	})
+
+	q, err := NewQuoteFromFinnhub("AAPL", "2020-03-01", "2022-06-30", Min5, "tok")
+	ok(t, err)
+	equals(t, []window{
+		{day(2020, 3, 1), day(2021, 3, 1)},
+		{day(2021, 3, 1), day(2022, 3, 1)},
+		{day(2022, 3, 1), day(2022, 7, 1)},
+	}, windows)
+	equals(t, []time.Time{day(2020, 3, 1), day(2021, 3, 1), day(2022, 3, 1)}, q.Date)
+
+	// a year without candles before the symbol listed
+	windows = nil
+	q, err = NewQuoteFromFinnhub("NEW", "2020-03-01", "2021-06-30", Min1, "tok")
+	ok(t, err)
+	equals(t, []time.Time{day(2021, 3, 1)}, q.Date)
+
+	windows = nil
+	_, err = NewQuoteFromFinnhub("AAPL", "2020-03-01", "2022-06-30", Daily, "tok")
+	ok(t, err)
+	equals(t, 1, len(windows))
+}
@@ -0,0 +1,136 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// fredURL - base url of the FRED api
+var fredURL = "https://api.stlouisfed.org"
+
+// fredPeriods - periods accepted for FRED series, which come at their native
+// frequency whatever the period
+var fredPeriods = []Period{Daily, Weekly, Monthly, Yearly}
+
+// FREDOptions - options of FRED downloads
+type FREDOptions struct {
+	FillMissing bool // repeat the last value for missing observations instead of skipping them
+}
+
+// DefaultFREDOptions - options of NewQuoteFromFRED
+var DefaultFREDOptions = FREDOptions{}
+
+// NewQuoteFromFRED - FRED observations of an economic series such as DGS10 or
+// CPIAUCSL, the value in Open, High, Low and Close and Volume zero so that
+// the series writes like prices. Observations keep their native dates, e.g.
+// the first of the month for monthly series. Missing observations are
+// skipped.
+func NewQuoteFromFRED(seriesID, startDate, endDate string, apiKey string) (Quote, error) {
+	return NewQuoteFromFREDWithOptions(seriesID, startDate, endDate, apiKey, DefaultFREDOptions)
+}
+
+// NewQuoteFromFREDCtx - NewQuoteFromFRED, canceled with ctx
+func NewQuoteFromFREDCtx(ctx context.Context, seriesID, startDate, endDate string, apiKey string) (Quote, error) {
+	return NewQuoteFromFREDWithOptionsCtx(ctx, seriesID, startDate, endDate, apiKey, DefaultFREDOptions)
+}
+
+// NewQuoteFromFREDWithOptions - FRED observations of an economic series, with
+// missing observations skipped or filled according to opts
+func NewQuoteFromFREDWithOptions(seriesID, startDate, endDate string, apiKey string, opts FREDOptions) (Quote, error) {
+	return NewQuoteFromFREDWithOptionsCtx(context.Background(), seriesID, startDate, endDate, apiKey, opts)
+}
+
+// NewQuoteFromFREDWithOptionsCtx - NewQuoteFromFREDWithOptions, canceled with
+// ctx
+func NewQuoteFromFREDWithOptionsCtx(ctx context.Context, seriesID, startDate, endDate string, apiKey string, opts FREDOptions) (Quote, error) {
+
+	from := ParseDateString(startDate)
+	to := ParseDateString(endDate)
+
+	params := url.Values{}
+	params.Set("series_id", seriesID)
+	params.Set("api_key", apiKey)
+	params.Set("file_type", "json")
+	params.Set("observation_start", from.Format("2006-01-02"))
+	params.Set("observation_end", to.Format("2006-01-02"))
+
+	if err := waitRate(ctx, "fred"); err != nil {
+		return NewQuote("", 0), err
+	}
+	client := httpClient()
+	resp, err := getContext(ctx, client, fredURL+"/fred/series/observations?"+params.Encode())
+	if err != nil {
+		Log.Printf("fred error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	defer resp.Body.Close()
+	contents, _ := ioutil.ReadAll(resp.Body)
+
+	var res struct {
+		ErrorMessage string `json:"error_message"`
+		Observations []struct {
+			Date  string `json:"date"`
+			Value string `json:"value"` // "." when missing
+		} `json:"observations"`
+	}
+	err = json.Unmarshal(contents, &res)
+	if res.ErrorMessage != "" {
+		err = fmt.Errorf("fred %s: %s", seriesID, res.ErrorMessage)
+	} else if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("fred %s: %s", seriesID, resp.Status)
+	}
+	if err != nil {
+		Log.Printf("fred error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+
+	quote := NewQuote(seriesID, 0)
+	for _, obs := range res.Observations {
+		date, err := time.Parse("2006-01-02", obs.Date)
+		if err != nil {
+			return NewQuote("", 0), err
+		}
+		value, err := strconv.ParseFloat(obs.Value, 64)
+		if err != nil {
+			// "." is a missing observation
+			if !opts.FillMissing || len(quote.Close) == 0 {
+				continue
+			}
+			value = quote.Close[len(quote.Close)-1]
+		}
+		quote.PushBar(Bar{Date: date, Open: value, High: value, Low: value, Close: value})
+	}
+	period, _ := quote.InferPeriod()
+	return quote.withMeta("fred", period, false), nil
+}
+
+// NewQuotesFromFREDSyms - create a list of series from series ids in string array
+func NewQuotesFromFREDSyms(seriesIDs []string, startDate, endDate string, apiKey string) (Quotes, error) {
+	return NewQuotesFromFREDSymsCtx(context.Background(), seriesIDs, startDate, endDate, apiKey)
+}
+
+// NewQuotesFromFREDSymsCtx - NewQuotesFromFREDSyms, canceled with ctx.
+// The quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromFREDSymsCtx(ctx context.Context, seriesIDs []string, startDate, endDate string, apiKey string) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, seriesID := range seriesIDs {
+		quote, err := NewQuoteFromFREDCtx(ctx, seriesID, startDate, endDate, apiKey)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "fred after %d of %d symbols", i, len(seriesIDs))
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + seriesID)
+		}
+		delayContext(ctx, "fred")
+	}
+	return quotes, nil
+}
@@ -0,0 +1,55 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFRED(t *testing.T) {
+	var query string
+	fakeServer(t, &fredURL, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		switch r.URL.Query().Get("series_id") {
+		case "NOPE":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error_code":400,"error_message":"Bad Request.  The series does not exist."}`)
+		case "CPIAUCSL":
+			fmt.Fprint(w, `{"frequency":"Monthly","observations":[
+{"realtime_start":"2023-06-01","realtime_end":"2023-06-01","date":"2023-01-01","value":"300.536"},
+{"realtime_start":"2023-06-01","realtime_end":"2023-06-01","date":"2023-02-01","value":"301.648"},
+{"realtime_start":"2023-06-01","realtime_end":"2023-06-01","date":"2023-03-01","value":"301.808"}]}`)
+		default:
+			fmt.Fprint(w, `{"observations":[
+{"date":"2023-01-02","value":"."},
+{"date":"2023-01-03","value":"3.79"},
+{"date":"2023-01-04","value":"3.69"},
+{"date":"2023-01-05","value":"."},
+{"date":"2023-01-06","value":"3.55"}]}`)
+		}
+	})
+
+	q, err := NewQuoteFromFRED("DGS10", "2023-01-01", "2023-01-06", "key")
+	ok(t, err)
+	equals(t, "api_key=key&file_type=json&observation_end=2023-01-06&observation_start=2023-01-01&series_id=DGS10", query)
+	equals(t, []time.Time{day(2023, 1, 3), day(2023, 1, 4), day(2023, 1, 6)}, q.Date)
+	equals(t, []float64{3.79, 3.69, 3.55}, q.Close)
+	equals(t, q.Close, q.Open)
+	equals(t, q.Close, q.High)
+	equals(t, q.Close, q.Low)
+	equals(t, []float64{0, 0, 0}, q.Volume)
+
+	q, err = NewQuoteFromFREDWithOptions("DGS10", "2023-01-01", "2023-01-06", "key", FREDOptions{FillMissing: true})
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 1, 3), day(2023, 1, 4), day(2023, 1, 5), day(2023, 1, 6)}, q.Date)
+	equals(t, []float64{3.79, 3.69, 3.69, 3.55}, q.Close)
+
+	q, err = NewQuoteFromFRED("CPIAUCSL", "2023-01-01", "2023-03-31", "key")
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 1, 1), day(2023, 2, 1), day(2023, 3, 1)}, q.Date)
+	equals(t, Monthly, q.Meta.Period)
+
+	_, err = NewQuoteFromFRED("NOPE", "2023-01-01", "2023-03-31", "key")
+	equals(t, "fred NOPE: Bad Request.  The series does not exist.", fmt.Sprint(err))
+}
@@ -0,0 +1,152 @@
+package quote
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math"
+	"strconv"
+)
+
+// goFloat - exact go literal for v
+func goFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "math.NaN()"
+	case math.IsInf(v, 1):
+		return "math.Inf(1)"
+	case math.IsInf(v, -1):
+		return "math.Inf(-1)"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// goFloats - write a []float64 field, eight values per line
+func goFloats(b *bytes.Buffer, name string, col []float64) {
+	fmt.Fprintf(b, "%s: []float64{", name)
+	for i, v := range col {
+		if i%8 == 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(goFloat(v) + ", ")
+	}
+	b.WriteString("\n},\n")
+}
+
+// goQuote - write the fields of q as a composite literal body
+func (q Quote) goQuote(b *bytes.Buffer) {
+	fmt.Fprintf(b, "Symbol: %q,\n", q.Symbol)
+	if q.Precision != 0 {
+		fmt.Fprintf(b, "Precision: %d,\n", q.Precision)
+	}
+	b.WriteString("Date: []time.Time{\n")
+	for _, d := range q.Date {
+		d = d.UTC()
+		fmt.Fprintf(b, "time.Date(%d, %d, %d, %d, %d, %d, %d, time.UTC),\n",
+			d.Year(), d.Month(), d.Day(), d.Hour(), d.Minute(), d.Second(), d.Nanosecond())
+	}
+	b.WriteString("},\n")
+	goFloats(b, "Open", q.Open)
+	goFloats(b, "High", q.High)
+	goFloats(b, "Low", q.Low)
+	goFloats(b, "Close", q.Close)
+	goFloats(b, "Volume", q.Volume)
+	if q.Trades != nil {
+		goFloats(b, "Trades", q.Trades)
+	}
+	if q.OpenInterest != nil {
+		goFloats(b, "OpenInterest", q.OpenInterest)
+	}
+	if q.AdjClose != nil {
+		goFloats(b, "AdjClose", q.AdjClose)
+	}
+	if q.Session != nil {
+		goFloats(b, "Session", q.Session)
+	}
+	if q.AdjOpen != nil {
+		goFloats(b, "AdjOpen", q.AdjOpen)
+	}
+	if q.AdjHigh != nil {
+		goFloats(b, "AdjHigh", q.AdjHigh)
+	}
+	if q.AdjLow != nil {
+		goFloats(b, "AdjLow", q.AdjLow)
+	}
+}
+
+// nonFinite - true if any price or volume of q is NaN or infinite
+func (q Quote) nonFinite() bool {
+	cols := [][]float64{q.Open, q.High, q.Low, q.Close, q.Volume, q.Trades, q.OpenInterest, q.AdjClose, q.Session, q.AdjOpen, q.AdjHigh, q.AdjLow}
+	for _, col := range cols {
+		for _, v := range col {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// goImports - standard library imports needed by the literal of quotes
+func goImports(quotes ...Quote) []string {
+	var imports []string
+	for _, q := range quotes {
+		if q.nonFinite() {
+			imports = append(imports, "math")
+			break
+		}
+	}
+	if len(quotes) > 0 {
+		imports = append(imports, "time")
+	}
+	return imports
+}
+
+// goFile - gofmt'ed go file declaring varName of type typ with the given literal body
+func goFile(pkg, varName, typ string, imports []string, body []byte) string {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by go-quote; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString("import (\n")
+	for _, imp := range imports {
+		fmt.Fprintf(&b, "%q\n", imp)
+	}
+	b.WriteString("\n\"github.com/markcheno/go-quote\"\n)\n\n")
+	fmt.Fprintf(&b, "var %s = %s{\n", varName, typ)
+	b.Write(body)
+	b.WriteString("}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		Log.Printf("gofmt generated code: %v\n", err)
+		return b.String()
+	}
+	return string(src)
+}
+
+// GoCode - go source file in package pkg declaring the Quote as variable
+// varName, for use as a test fixture. Dates are written in UTC and prices
+// exactly. The file imports this package, so pkg must not be quote itself.
+func (q Quote) GoCode(pkg, varName string) string {
+	var body bytes.Buffer
+	q.goQuote(&body)
+	return goFile(pkg, varName, "quote.Quote", goImports(q), body.Bytes())
+}
+
+// GoCode - go source file in package pkg declaring the Quotes as variable varName
+func (q Quotes) GoCode(pkg, varName string) string {
+	var body bytes.Buffer
+	for _, quote := range q {
+		body.WriteString("{\n")
+		quote.goQuote(&body)
+		body.WriteString("},\n")
+	}
+	return goFile(pkg, varName, "quote.Quotes", goImports(q...), body.Bytes())
+}
+
+// Last - the last n bars of q, all of q if it has fewer
+func (q Quote) Last(n int) Quote {
+	if n < 0 || n >= len(q.Date) {
+		return q
+	}
+	return q.slice(len(q.Date)-n, len(q.Date))
+}
@@ -0,0 +1,37 @@
+// Code generated by go-quote; DO NOT EDIT.
+
+package quote_test
+
+import (
+	"time"
+
+	"github.com/markcheno/go-quote"
+)
+
+var fixtureQuote = quote.Quote{
+	Symbol:    "btc-usd",
+	Precision: 8,
+	Date: []time.Time{
+		time.Date(2023, 3, 1, 9, 30, 0, 123456789, time.UTC),
+		time.Date(2023, 3, 1, 9, 31, 0, 123456789, time.UTC),
+		time.Date(2023, 3, 1, 9, 32, 0, 123456789, time.UTC),
+	},
+	Open: []float64{
+		0.3333333333333333, 0.16666666666666666, 0.1111111111111111,
+	},
+	High: []float64{
+		31415.926535897932, 62831.853071795864, 94247.7796076938,
+	},
+	Low: []float64{
+		1e-12, 5e-13, 3.3333333333333334e-13,
+	},
+	Close: []float64{
+		0.30000000000000004, 0.5, 0.7000000000000001,
+	},
+	Volume: []float64{
+		1.2345678901e+07, 2.4691357802e+07, 3.7037036703e+07,
+	},
+	Trades: []float64{
+		1, 0, 7,
+	},
+}
@@ -0,0 +1,71 @@
+package quote_test
+
+import (
+	"io/ioutil"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markcheno/go-quote"
+)
+
+// fixtureSource - the quote gocode_fixture_test.go was generated from,
+// with prices that have no short decimal representation
+func fixtureSource() quote.Quote {
+	q := quote.NewQuote("btc-usd", 3)
+	q.Precision = 8
+	for bar := range q.Date {
+		x := float64(bar + 1)
+		q.Date[bar] = time.Date(2023, 3, 1, 9, 30+bar, 0, 123456789, time.UTC)
+		q.Open[bar] = 1 / (3 * x)
+		q.High[bar] = math.Pi * 1e4 * x
+		q.Low[bar] = 1e-12 / x
+		q.Close[bar] = 0.1 + 0.2*x
+		q.Volume[bar] = 12345678.901 * x
+	}
+	q.Trades = []float64{1, 0, 7}
+	return q
+}
+
+func TestGoCodeRoundTrip(t *testing.T) {
+	src := fixtureSource()
+
+	// the compiled fixture equals the quote it was generated from
+	if !reflect.DeepEqual(src, fixtureQuote) {
+		t.Errorf("fixture differs from source:\n%s\n%s", src.JSON(false), fixtureQuote.JSON(false))
+	}
+
+	// and is exactly what the generator writes today
+	data, err := ioutil.ReadFile("gocode_fixture_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := src.GoCode("quote_test", "fixtureQuote"); code != string(data) {
+		t.Errorf("generated code changed, regenerate gocode_fixture_test.go:\n%s", code)
+	}
+}
+
+func TestGoCode(t *testing.T) {
+	q := fixtureSource()
+	q.Close[1] = math.NaN()
+	code := quote.Quotes{q}.GoCode("fixtures", "quotes")
+	for _, want := range []string{"package fixtures\n", "\t\"math\"\n", "var quotes = quote.Quotes{\n", "math.NaN()", "time.Date(2023, 3, 1, 9, 31, 0, 123456789, time.UTC)"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("missing %q in:\n%s", want, code)
+		}
+	}
+
+	if code := (quote.Quotes{}).GoCode("fixtures", "quotes"); strings.Contains(code, "time") {
+		t.Errorf("unused time import:\n%s", code)
+	}
+
+	last := q.Last(2)
+	if len(last.Close) != 2 || !last.Date[0].Equal(q.Date[1]) || len(last.Trades) != 2 {
+		t.Errorf("unexpected last bars %v", last.Date)
+	}
+	if len(q.Last(10).Close) != 3 {
+		t.Error("last truncated a short quote")
+	}
+}
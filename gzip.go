@@ -0,0 +1,145 @@
+package quote
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// gzipped - true if filename is gzip compressed, by its .gz extension
+func gzipped(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), ".gz")
+}
+
+// outputFile - a buffered file being written, gzip compressed for .gz files
+type outputFile struct {
+	*bufio.Writer
+	file *os.File
+	gz   *gzip.Writer
+}
+
+// createFile - create filename for streaming output, gzip compressed when it
+// ends in .gz. Close flushes the compressor and closes the file.
+func createFile(filename string) (*outputFile, error) {
+	return openOutput(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+}
+
+// appendFile - open filename for streaming output at its end, creating it if
+// it does not exist. Output to .gz files is a further gzip member, which gzip
+// readers concatenate.
+func appendFile(filename string) (*outputFile, error) {
+	return openOutput(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+}
+
+// openOutput - open filename with flag for streaming output
+func openOutput(filename string, flag int) (*outputFile, error) {
+	f, err := os.OpenFile(filename, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	out := &outputFile{file: f}
+	var w io.Writer = f
+	if gzipped(filename) {
+		out.gz = gzip.NewWriter(f)
+		w = out.gz
+	}
+	out.Writer = bufio.NewWriter(w)
+	return out, nil
+}
+
+// Close - flush all output and close the file, returning the first error
+func (f *outputFile) Close() error {
+	err := f.Flush()
+	if f.gz != nil {
+		if e := f.gz.Close(); err == nil {
+			err = e
+		}
+	}
+	if e := f.file.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// writeStream - write filename with write, gzip compressed for .gz files
+func writeStream(filename string, write func(w io.Writer) error) error {
+	f, err := createFile(filename)
+	if err != nil {
+		return err
+	}
+	return f.writeAndClose(write)
+}
+
+// appendStream - append to filename with write, gzip compressed for .gz files
+func appendStream(filename string, write func(w io.Writer) error) error {
+	f, err := appendFile(filename)
+	if err != nil {
+		return err
+	}
+	return f.writeAndClose(write)
+}
+
+// writeAndClose - write f with write, then close it
+func (f *outputFile) writeAndClose(write func(w io.Writer) error) error {
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeFile - ioutil.WriteFile, gzip compressed for .gz files
+func writeFile(filename string, data []byte) error {
+	if !gzipped(filename) {
+		return ioutil.WriteFile(filename, data, 0644)
+	}
+	return writeStream(filename, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// inputFile - a file being read, decompressed for .gz files
+type inputFile struct {
+	io.Reader
+	file *os.File
+}
+
+// openFile - open filename for streaming input, decompressing .gz files
+func openFile(filename string) (*inputFile, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	in := &inputFile{Reader: f, file: f}
+	if gzipped(filename) {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		in.Reader = gz
+	}
+	return in, nil
+}
+
+// Close - close the file
+func (f *inputFile) Close() error {
+	return f.file.Close()
+}
+
+// readFile - ioutil.ReadFile, decompressing .gz files
+func readFile(filename string) ([]byte, error) {
+	if !gzipped(filename) {
+		return ioutil.ReadFile(filename)
+	}
+	f, err := openFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
@@ -0,0 +1,81 @@
+package quote
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// gunzip - decompressed contents of a gzip file
+func gunzip(t *testing.T, filename string) []byte {
+	f, err := os.Open(filename)
+	ok(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	ok(t, err)
+	data, err := ioutil.ReadAll(gz)
+	ok(t, err)
+	return data
+}
+
+func TestGzipRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gzip")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	quotes := partitionFixture()[:2]
+	spy := quotes[0]
+
+	name := filepath.Join(dir, "spy.csv.gz")
+	ok(t, spy.WriteCSV(name))
+	equals(t, spy.CSV(), string(gunzip(t, name)))
+	back, err := NewQuoteFromCSVFile("spy", name)
+	ok(t, err)
+	equals(t, spy.CSV(), back.CSV())
+
+	name = filepath.Join(dir, "quotes.csv.gz")
+	ok(t, quotes.WriteCSV(name))
+	equals(t, quotes.CSV(), string(gunzip(t, name)))
+	all, err := NewQuotesFromCSVFile(name)
+	ok(t, err)
+	equals(t, quotes.CSV(), all.CSV())
+
+	name = filepath.Join(dir, "spy.json.GZ")
+	ok(t, spy.WriteJSON(name, false))
+	equals(t, spy.JSON(false), string(gunzip(t, name)))
+	back, err = NewQuoteFromJSONFile(name)
+	ok(t, err)
+	equals(t, spy.Date, back.Date)
+	equals(t, spy.Close, back.Close)
+
+	name = filepath.Join(dir, "quotes.json.gz")
+	ok(t, quotes.WriteJSON(name, false))
+	all, err = NewQuotesFromJSONFile(name)
+	ok(t, err)
+	equals(t, quotes.JSON(false), all.JSON(false))
+
+	ok(t, spy.WriteHighstock(filepath.Join(dir, "spy.hs.gz")))
+	equals(t, spy.Highstock(), string(gunzip(t, filepath.Join(dir, "spy.hs.gz"))))
+	ok(t, quotes.WriteHighstock(filepath.Join(dir, "quotes.hs.gz")))
+	equals(t, quotes.Highstock(), string(gunzip(t, filepath.Join(dir, "quotes.hs.gz"))))
+
+	// without .gz files are written as before
+	name = filepath.Join(dir, "spy.csv")
+	ok(t, spy.WriteCSV(name))
+	plain, err := ioutil.ReadFile(name)
+	ok(t, err)
+	equals(t, spy.CSV(), string(plain))
+	assert(t, !bytes.HasPrefix(plain, []byte{0x1f, 0x8b}), "plain file compressed")
+
+	// both are read back by directory
+	loaded, err := NewQuotesFromDir(dir, "spy.csv*")
+	ok(t, err)
+	symbols := []string{}
+	for _, q := range loaded {
+		symbols = append(symbols, q.Symbol)
+	}
+	equals(t, []string{"spy", "spy"}, symbols)
+}
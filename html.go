@@ -0,0 +1,133 @@
+package quote
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// highstockURL - Highcharts Stock script loaded by html output
+var highstockURL = "https://code.highcharts.com/stock/highstock.js"
+
+// htmlSeries - chart data of a quote, [ms, open, high, low, close] and
+// [ms, volume] points as Highstock takes them
+type htmlSeries struct {
+	Symbol string       `json:"symbol"`
+	OHLC   [][5]float64 `json:"ohlc"`
+	Volume [][2]float64 `json:"volume"`
+}
+
+// htmlSeries - q as Highstock candlestick and volume series
+func (q Quote) htmlSeries() htmlSeries {
+	s := htmlSeries{
+		Symbol: q.Symbol,
+		OHLC:   make([][5]float64, len(q.Close)),
+		Volume: make([][2]float64, len(q.Close)),
+	}
+	for bar := range q.Close {
+		ms := float64(q.Date[bar].UnixNano() / 1000000)
+		s.OHLC[bar] = [5]float64{ms, q.Open[bar], q.High[bar], q.Low[bar], q.Close[bar]}
+		s.Volume[bar] = [2]float64{ms, q.Volume[bar]}
+	}
+	return s
+}
+
+// htmlScript - draws quotes[i] in #chart, a price pane above a volume pane,
+// redrawn on choosing another symbol from #symbol
+const htmlScript = `var quotes = JSON.parse(document.getElementById("quotes").textContent);
+function chart(q) {
+  Highcharts.stockChart("chart", {
+    title: {text: q.symbol},
+    rangeSelector: {selected: 4},
+    yAxis: [
+      {labels: {align: "right", x: -3}, title: {text: "price"}, height: "70%", lineWidth: 2, resize: {enabled: true}},
+      {labels: {align: "right", x: -3}, title: {text: "volume"}, top: "72%", height: "28%", offset: 0, lineWidth: 2}
+    ],
+    series: [
+      {type: "candlestick", name: q.symbol, data: q.ohlc},
+      {type: "column", name: "volume", data: q.volume, yAxis: 1}
+    ]
+  });
+}
+var select = document.getElementById("symbol");
+if (select) {
+  select.onchange = function() { chart(quotes[select.value]); };
+}
+if (quotes.length > 0) {
+  chart(quotes[0]);
+}
+`
+
+// writeHTML - stream Quotes structure as a html page charting one symbol at
+// a time to w
+func (q Quotes) writeHTML(w io.Writer) error {
+	series := make([]htmlSeries, len(q))
+	symbols := make([]string, len(q))
+	for i, quote := range q {
+		series[i] = quote.htmlSeries()
+		symbols[i] = quote.Symbol
+	}
+	// json.Marshal escapes <, > and &, so the data can not end its script tag
+	data, err := json.Marshal(series)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(strings.Join(symbols, ", ")))
+	fmt.Fprintf(&b, "<script src=\"%s\"></script>\n", html.EscapeString(highstockURL))
+	b.WriteString("<style>body { font-family: sans-serif; margin: 8px; } #chart { height: 90vh; }</style>\n")
+	b.WriteString("</head>\n<body>\n")
+	if len(q) > 1 {
+		b.WriteString("<select id=\"symbol\">\n")
+		for i, symbol := range symbols {
+			fmt.Fprintf(&b, "<option value=\"%d\">%s</option>\n", i, html.EscapeString(symbol))
+		}
+		b.WriteString("</select>\n")
+	}
+	b.WriteString("<div id=\"chart\"></div>\n<script type=\"application/json\" id=\"quotes\">")
+	b.Write(data)
+	b.WriteString("</script>\n<script>\n" + htmlScript + "</script>\n</body>\n</html>\n")
+	_, err = b.WriteTo(w)
+	return err
+}
+
+// HTML - convert Quote structure to a self-contained html page with a
+// Highstock candlestick chart and volume pane
+func (q Quote) HTML() string {
+	return Quotes{q}.HTML()
+}
+
+// WriteHTML - write Quote structure to a html chart page
+func (q Quote) WriteHTML(filename string) error {
+	if filename == "" {
+		if q.Symbol != "" {
+			filename = q.Symbol + ".html"
+		} else {
+			filename = "quote.html"
+		}
+	}
+	return Quotes{q}.WriteHTML(filename)
+}
+
+// HTML - convert Quotes structure to a self-contained html page charting a
+// symbol chosen from a dropdown. The data is inline, only Highstock is
+// loaded from its CDN.
+func (q Quotes) HTML() string {
+	var buffer bytes.Buffer
+	q.writeHTML(&buffer)
+	return buffer.String()
+}
+
+// WriteHTML - write Quotes structure to a html chart page, gzip compressed
+// if filename ends in .gz
+func (q Quotes) WriteHTML(filename string) error {
+	if filename == "" {
+		filename = "quotes.html"
+	}
+	return writeStream(filename, q.writeHTML)
+}
@@ -0,0 +1,65 @@
+package quote
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// htmlData - the chart data embedded in a html page
+func htmlData(t *testing.T, page string) []htmlSeries {
+	const start = `<script type="application/json" id="quotes">`
+	i := strings.Index(page, start)
+	assert(t, i >= 0, "no data in page")
+	data := page[i+len(start):]
+	data = data[:strings.Index(data, "</script>")]
+	var series []htmlSeries
+	ok(t, json.Unmarshal([]byte(data), &series))
+	return series
+}
+
+func TestHTML(t *testing.T) {
+	spy := partitionFixture()[0]
+	page := spy.HTML()
+	assert(t, strings.Contains(page, "<title>spy</title>"), "no title")
+	assert(t, strings.Contains(page, `<script src="https://code.highcharts.com/stock/highstock.js">`), "no highstock")
+	assert(t, !strings.Contains(page, "<select"), "symbol dropdown for one symbol")
+	series := htmlData(t, page)
+	equals(t, 1, len(series))
+	equals(t, "spy", series[0].Symbol)
+	equals(t, 4, len(series[0].OHLC))
+	equals(t, 4, len(series[0].Volume))
+	equals(t, [5]float64{1675036800000, 100, 101, 99, 100}, series[0].OHLC[0])
+	equals(t, [2]float64{1675036800000, 1000}, series[0].Volume[0])
+}
+
+func TestHTMLQuotes(t *testing.T) {
+	quotes := partitionFixture()
+	quotes[2].Symbol = "</script><b>"
+	page := quotes.HTML()
+	assert(t, strings.Contains(page, `<option value="1">BTC/USD</option>`), "no dropdown")
+	assert(t, strings.Contains(page, `<option value="2">&lt;/script&gt;&lt;b&gt;</option>`), "symbol not escaped")
+	series := htmlData(t, page)
+	equals(t, 3, len(series))
+	equals(t, 1, len(series[1].OHLC))
+	equals(t, "</script><b>", series[2].Symbol)
+	equals(t, 0, len(series[2].OHLC))
+
+	htmlData(t, Quotes{}.HTML())
+}
+
+func TestWriteHTML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "html")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	spy := partitionFixture()[0]
+	name := filepath.Join(dir, "spy.html")
+	ok(t, spy.WriteHTML(name))
+	page, err := ioutil.ReadFile(name)
+	ok(t, err)
+	equals(t, spy.HTML(), string(page))
+}
@@ -0,0 +1,77 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingTransport - http.DefaultTransport counting its requests
+type countingTransport struct {
+	requests int32
+}
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.requests, 1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestHTTPClient(t *testing.T) {
+	saved := HTTPClient
+	ValidateCoinbaseProducts = false
+	defer func() { HTTPClient, ValidateCoinbaseProducts = saved, true }()
+
+	fakeServer(t, &coinbaseURL, fakeCoinbaseCandles(0, nil))
+	counter := &countingTransport{}
+	HTTPClient = &http.Client{Transport: counter}
+
+	// three pages of hourly bars
+	q, err := NewQuoteFromCoinbase("BTC-USD", "2023-03-01", "2023-03-20", Min60)
+	ok(t, err)
+	assert(t, len(q.Date) > coinbaseMaxBars, "%d bars", len(q.Date))
+	equals(t, int32(3), atomic.LoadInt32(&counter.requests))
+
+	// a nil client falls back to one with ClientTimeout
+	HTTPClient = nil
+	_, err = NewQuoteFromCoinbase("BTC-USD", "2023-03-01", "2023-03-02", Min60)
+	ok(t, err)
+	equals(t, int32(3), atomic.LoadInt32(&counter.requests))
+}
+
+func TestYahooSessionWithHTTPClient(t *testing.T) {
+	var ranges [][2]time.Time
+	chart := fakeYahooChart(day(2024, 1, 2), &ranges)
+	useYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cookie":
+			http.SetCookie(w, &http.Cookie{Name: "A3", Value: "session", Path: "/"})
+			http.NotFound(w, r)
+		case "/v1/test/getcrumb":
+			if _, err := r.Cookie("A3"); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, "crumb")
+		default:
+			r.URL.Path = "/v8/finance/chart/x"
+			chart(w, r)
+		}
+	})
+
+	// a client without jar gets the session cookies
+	counter := &countingTransport{}
+	client := &http.Client{Transport: counter}
+	s := NewYahooSession(WithHTTPClient(client))
+	q, err := s.Quote("spy", "2024-01-02", "2024-01-05", Daily, true)
+	ok(t, err)
+	equals(t, 4, len(q.Close))
+	equals(t, int32(3), atomic.LoadInt32(&counter.requests))
+	assert(t, client.Jar == nil, "client modified")
+
+	// the session cookie and crumb are reused
+	_, err = s.Quote("aapl", "2024-01-02", "2024-01-05", Daily, true)
+	ok(t, err)
+	equals(t, int32(4), atomic.LoadInt32(&counter.requests))
+}
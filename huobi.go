@@ -0,0 +1,174 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// huobiURL - base url of the Huobi (HTX) api
+var huobiURL = "https://api.huobi.pro"
+
+// huobiMaxSize - most candles a kline request returns
+const huobiMaxSize = 2000
+
+// huobiPeriods - kline periods of the Huobi api
+var huobiPeriods = []Period{Min1, Min5, Min15, Min30, Min60, Hour4, Daily, Weekly, Monthly}
+
+// huobiIntervals - kline period of each supported period
+var huobiIntervals = map[Period]string{
+	Min1:    "1min",
+	Min5:    "5min",
+	Min15:   "15min",
+	Min30:   "30min",
+	Min60:   "60min",
+	Hour4:   "4hour",
+	Daily:   "1day",
+	Weekly:  "1week",
+	Monthly: "1mon",
+}
+
+// huobiDay - Huobi days start at midnight Beijing time
+var huobiDay = time.FixedZone("UTC+8", 8*3600)
+
+// NewQuoteFromHuobi - Huobi historical prices for a symbol such as btcusdt,
+// the last size bars up to now, at most 2000. The api takes no date range,
+// callers trim the bars they need. Intraday bars are timestamped in UTC,
+// daily and longer bars by their date in Beijing, where Huobi days start.
+func NewQuoteFromHuobi(symbol string, period Period, size int) (Quote, error) {
+	return NewQuoteFromHuobiCtx(context.Background(), symbol, period, size)
+}
+
+// NewQuoteFromHuobiCtx - NewQuoteFromHuobi, canceled with ctx
+func NewQuoteFromHuobiCtx(ctx context.Context, symbol string, period Period, size int) (Quote, error) {
+
+	interval, ok := huobiIntervals[period]
+	if !ok {
+		return NewQuote("", 0), ValidatePeriod("huobi", period)
+	}
+	if size > huobiMaxSize {
+		size = huobiMaxSize
+	}
+
+	params := url.Values{}
+	params.Set("symbol", strings.ToLower(symbol))
+	params.Set("period", interval)
+	params.Set("size", strconv.Itoa(size))
+
+	if err := waitRate(ctx, "huobi"); err != nil {
+		return NewQuote("", 0), err
+	}
+	client := httpClient()
+	resp, err := getContext(ctx, client, huobiURL+"/market/history/kline?"+params.Encode())
+	if err != nil {
+		Log.Printf("huobi error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return NewQuote("", 0), fmt.Errorf("huobi %s: %s", symbol, resp.Status)
+	}
+	contents, _ := ioutil.ReadAll(resp.Body)
+
+	quote, err := parseHuobi(symbol, contents, period)
+	if err != nil {
+		Log.Printf("huobi %s error: %v\n", symbol, err)
+		return NewQuote("", 0), err
+	}
+	return quote.withMeta("huobi", period, false), nil
+}
+
+// NewQuoteFromHuobiRange - Huobi historical prices for a symbol between
+// startDate and endDate (inclusive), cut from the last 2000 bars. A range
+// reaching back further is logged and returns the bars available.
+func NewQuoteFromHuobiRange(symbol, startDate, endDate string, period Period) (Quote, error) {
+	return NewQuoteFromHuobiRangeCtx(context.Background(), symbol, startDate, endDate, period)
+}
+
+// NewQuoteFromHuobiRangeCtx - NewQuoteFromHuobiRange, canceled with ctx
+func NewQuoteFromHuobiRangeCtx(ctx context.Context, symbol, startDate, endDate string, period Period) (Quote, error) {
+
+	quote, err := NewQuoteFromHuobiCtx(ctx, symbol, period, huobiMaxSize)
+	if err != nil {
+		return quote, err
+	}
+	from := ParseDateString(startDate)
+	if len(quote.Date) > 0 && from.Before(quote.Date[0]) {
+		Log.Printf("huobi %s: bars start at %s\n", symbol, quote.Date[0].Format("2006-01-02 15:04"))
+	}
+	return quote.between(from, parseEndDate(endDate)), nil
+}
+
+// parseHuobi - bars of a kline response in date order. Candles come newest
+// first, with a status of ok or error and an err-msg.
+func parseHuobi(symbol string, contents []byte, period Period) (Quote, error) {
+
+	var klines struct {
+		Status string `json:"status"`
+		ErrMsg string `json:"err-msg"`
+		Data   []struct {
+			ID     int64   `json:"id"` // bar start, seconds since the epoch
+			Open   float64 `json:"open"`
+			Close  float64 `json:"close"`
+			Low    float64 `json:"low"`
+			High   float64 `json:"high"`
+			Vol    float64 `json:"vol"`    // quote currency volume
+			Amount float64 `json:"amount"` // base currency volume
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(contents, &klines); err != nil {
+		return NewQuote("", 0), err
+	}
+	if klines.Status != "ok" {
+		return NewQuote("", 0), fmt.Errorf("huobi %s: %s", symbol, klines.ErrMsg)
+	}
+
+	n := len(klines.Data)
+	quote := NewQuote(symbol, n)
+	for i, k := range klines.Data {
+		bar := n - 1 - i
+		date := time.Unix(k.ID, 0).UTC()
+		if period.Duration() >= 24*time.Hour {
+			y, m, d := date.In(huobiDay).Date()
+			date = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		}
+		quote.Date[bar] = date
+		quote.Open[bar] = k.Open
+		quote.High[bar] = k.High
+		quote.Low[bar] = k.Low
+		quote.Close[bar] = k.Close
+		quote.Volume[bar] = k.Amount
+	}
+	return quote, nil
+}
+
+// NewQuotesFromHuobiSyms - create a list of prices from symbols in string array
+func NewQuotesFromHuobiSyms(symbols []string, startDate, endDate string, period Period) (Quotes, error) {
+	return NewQuotesFromHuobiSymsCtx(context.Background(), symbols, startDate, endDate, period)
+}
+
+// NewQuotesFromHuobiSymsCtx - NewQuotesFromHuobiSyms, canceled with ctx.
+// The quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromHuobiSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromHuobiRangeCtx(ctx, symbol, startDate, endDate, period)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "huobi after %d of %d symbols", i, len(symbols))
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + symbol)
+		}
+		delayContext(ctx, "huobi")
+	}
+	return quotes, nil
+}
@@ -0,0 +1,50 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// huobiDaily - daily klines newest first, each starting at midnight Beijing
+const huobiDaily = `{"ch":"market.btcusdt.kline.1day","status":"ok","ts":1677888000000,"data":[
+{"id":1677772800,"open":23465.0,"close":22360.0,"low":22150.0,"high":23480.0,"amount":25000.5,"vol":565000000.0,"count":400000},
+{"id":1677686400,"open":23640.0,"close":23465.0,"low":23200.0,"high":23780.0,"amount":18000.25,"vol":423000000.0,"count":300000},
+{"id":1677600000,"open":23150.0,"close":23640.0,"low":23000.0,"high":24000.0,"amount":20000.0,"vol":470000000.0,"count":350000}]}`
+
+func TestParseHuobi(t *testing.T) {
+	q, err := parseHuobi("btcusdt", []byte(huobiDaily), Daily)
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 3, 1), day(2023, 3, 2), day(2023, 3, 3)}, q.Date)
+	equals(t, []float64{23150, 23640, 23465}, q.Open)
+	equals(t, []float64{24000, 23780, 23480}, q.High)
+	equals(t, []float64{23000, 23200, 22150}, q.Low)
+	equals(t, []float64{23640, 23465, 22360}, q.Close)
+	equals(t, []float64{20000, 18000.25, 25000.5}, q.Volume)
+
+	q, err = parseHuobi("btcusdt", []byte(huobiDaily), Min60)
+	ok(t, err)
+	equals(t, time.Date(2023, 2, 28, 16, 0, 0, 0, time.UTC), q.Date[0])
+
+	_, err = parseHuobi("nope", []byte(`{"status":"error","err-code":"invalid-parameter","err-msg":"invalid symbol","data":null}`), Daily)
+	equals(t, "huobi nope: invalid symbol", fmt.Sprint(err))
+}
+
+func TestHuobiRange(t *testing.T) {
+	var size string
+	fakeServer(t, &huobiURL, func(w http.ResponseWriter, r *http.Request) {
+		size = r.URL.Query().Get("size")
+		fmt.Fprint(w, huobiDaily)
+	})
+
+	q, err := NewQuoteFromHuobiRange("BTCUSDT", "2023-03-02", "2023-03-02", Daily)
+	ok(t, err)
+	equals(t, "2000", size)
+	equals(t, []time.Time{day(2023, 3, 2)}, q.Date)
+	equals(t, "USDT", q.Meta.Currency)
+
+	_, err = NewQuoteFromHuobi("btcusdt", Daily, 5000)
+	ok(t, err)
+	equals(t, "2000", size)
+}
@@ -0,0 +1,215 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// iexURL - base url of the IEX Cloud api
+var iexURL = "https://cloud.iexapis.com"
+
+// iexPeriods - periods of the IEX Cloud chart api, minute bars come one day
+// per request
+var iexPeriods = []Period{Min1, Daily}
+
+// iexRanges - the fixed chart ranges of IEX Cloud, shortest first, with the
+// start of the window each covers counting back from now. 5d is five trading
+// days, a calendar week.
+var iexRanges = []struct {
+	name  string
+	start func(now time.Time) time.Time
+}{
+	{"5d", func(now time.Time) time.Time { return now.AddDate(0, 0, -7) }},
+	{"1m", func(now time.Time) time.Time { return now.AddDate(0, -1, 0) }},
+	{"3m", func(now time.Time) time.Time { return now.AddDate(0, -3, 0) }},
+	{"1y", func(now time.Time) time.Time { return now.AddDate(-1, 0, 0) }},
+	{"2y", func(now time.Time) time.Time { return now.AddDate(-2, 0, 0) }},
+	{"5y", func(now time.Time) time.Time { return now.AddDate(-5, 0, 0) }},
+}
+
+// iexRange - the smallest chart range reaching back to from, max if none does
+func iexRange(from, now time.Time) string {
+	for _, r := range iexRanges {
+		if !from.Before(r.start(now)) {
+			return r.name
+		}
+	}
+	return "max"
+}
+
+// iexBar - one bar of a chart response. Daily bars carry split adjusted
+// prices and the unadjusted u-prefixed ones, minute bars have a minute and
+// null prices for minutes without trades.
+type iexBar struct {
+	Date    string   `json:"date"`
+	Minute  string   `json:"minute"`
+	Open    *float64 `json:"open"`
+	High    *float64 `json:"high"`
+	Low     *float64 `json:"low"`
+	Close   *float64 `json:"close"`
+	Volume  float64  `json:"volume"`
+	UOpen   float64  `json:"uOpen"`
+	UHigh   float64  `json:"uHigh"`
+	ULow    float64  `json:"uLow"`
+	UClose  float64  `json:"uClose"`
+	UVolume float64  `json:"uVolume"`
+}
+
+// NewQuoteFromIEX - IEX Cloud split adjusted historical prices for a symbol
+func NewQuoteFromIEX(symbol, startDate, endDate string, period Period, token string) (Quote, error) {
+	return NewQuoteFromIEXCtx(context.Background(), symbol, startDate, endDate, period, token)
+}
+
+// NewQuoteFromIEXCtx - NewQuoteFromIEX, canceled with ctx
+func NewQuoteFromIEXCtx(ctx context.Context, symbol, startDate, endDate string, period Period, token string) (Quote, error) {
+	_, adjusted, err := NewQuotePairFromIEXCtx(ctx, symbol, startDate, endDate, period, token)
+	return adjusted, err
+}
+
+// NewQuotePairFromIEX - IEX Cloud unadjusted and split adjusted historical
+// prices for a symbol from a single download, both with the adjusted close in
+// AdjClose. Daily bars come from the smallest fixed chart range covering
+// startDate, cut to the date range. Minute bars are requested one weekday at a
+// time with Delay between requests, timestamped in US/Eastern wall clock time
+// and the same in both quotes.
+func NewQuotePairFromIEX(symbol, startDate, endDate string, period Period, token string) (Quote, Quote, error) {
+	return NewQuotePairFromIEXCtx(context.Background(), symbol, startDate, endDate, period, token)
+}
+
+// NewQuotePairFromIEXCtx - NewQuotePairFromIEX, canceled with ctx. A minute
+// download canceled between its requests returns the bars so far with the
+// wrapped error of ctx.
+func NewQuotePairFromIEXCtx(ctx context.Context, symbol, startDate, endDate string, period Period, token string) (Quote, Quote, error) {
+
+	if err := ValidatePeriod("iex", period); err != nil {
+		Log.Printf("iex error: %v\n", err)
+		return NewQuote("", 0), NewQuote("", 0), err
+	}
+	from := ParseDateString(startDate)
+	to := parseEndDate(endDate)
+
+	raw := NewQuote(symbol, 0)
+	adjusted := NewQuote(symbol, 0)
+	pair := func() (Quote, Quote) {
+		raw = raw.between(from, to)
+		adjusted = adjusted.between(from, to)
+		raw.AdjClose = copyExtra(adjusted.Close)
+		adjusted.AdjClose = copyExtra(adjusted.Close)
+		return raw.withMeta("iex", period, false), adjusted.withMeta("iex", period, true)
+	}
+	if period == Daily {
+		bars, err := iexChart(ctx, symbol, "chart/"+iexRange(from, time.Now()), url.Values{"chartByDay": {"true"}}, token)
+		if err != nil {
+			Log.Printf("iex %s error: %v\n", symbol, err)
+			return NewQuote("", 0), NewQuote("", 0), err
+		}
+		for _, b := range bars {
+			if b.Close == nil {
+				continue
+			}
+			date, err := time.Parse("2006-01-02", b.Date)
+			if err != nil {
+				return NewQuote("", 0), NewQuote("", 0), err
+			}
+			raw.PushBar(Bar{Date: date, Open: b.UOpen, High: b.UHigh, Low: b.ULow, Close: b.UClose, Volume: b.UVolume})
+			adjusted.PushBar(Bar{Date: date, Open: *b.Open, High: *b.High, Low: *b.Low, Close: *b.Close, Volume: b.Volume})
+		}
+	} else {
+		requests := 0
+		for day := from.Truncate(24 * time.Hour); day.Before(to); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+				continue
+			}
+			if requests > 0 && delayContext(ctx, "iex") != nil {
+				raw, adjusted := pair()
+				return raw, adjusted, interrupted(ctx, "iex %s after %d requests", symbol, requests)
+			}
+			bars, err := iexChart(ctx, symbol, "chart/date/"+day.Format("20060102"), nil, token)
+			if err != nil && ctx.Err() != nil {
+				raw, adjusted := pair()
+				return raw, adjusted, interrupted(ctx, "iex %s after %d requests", symbol, requests)
+			}
+			requests++
+			if err != nil {
+				Log.Printf("iex %s error: %v\n", symbol, err)
+				return NewQuote("", 0), NewQuote("", 0), err
+			}
+			for _, b := range bars {
+				if b.Open == nil || b.High == nil || b.Low == nil || b.Close == nil {
+					continue
+				}
+				date, err := time.Parse("20060102 15:04", strings.Replace(b.Date, "-", "", -1)+" "+b.Minute)
+				if err != nil {
+					return NewQuote("", 0), NewQuote("", 0), err
+				}
+				bar := Bar{Date: date, Open: *b.Open, High: *b.High, Low: *b.Low, Close: *b.Close, Volume: b.Volume}
+				raw.PushBar(bar)
+				adjusted.PushBar(bar)
+			}
+		}
+	}
+	raw, adjusted = pair()
+	return raw, adjusted, nil
+}
+
+// iexChart - the bars of a chart request for symbol, path relative to the
+// symbol's stock endpoint, canceled with ctx
+func iexChart(ctx context.Context, symbol, path string, params url.Values, token string) ([]iexBar, error) {
+
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("token", token)
+	u := fmt.Sprintf("%s/stable/stock/%s/%s?%s", iexURL, url.PathEscape(symbol), path, params.Encode())
+
+	if err := waitRate(ctx, "iex"); err != nil {
+		return nil, err
+	}
+	client := httpClient()
+	resp, err := getContext(ctx, client, u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	contents, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		// errors come as plain text, e.g. "Unknown symbol"
+		return nil, fmt.Errorf("iex %s: %s %s", symbol, resp.Status, strings.TrimSpace(string(contents)))
+	}
+	var bars []iexBar
+	if err := json.Unmarshal(contents, &bars); err != nil {
+		return nil, err
+	}
+	return bars, nil
+}
+
+// NewQuotesFromIEXSyms - create a list of prices from symbols in string array
+func NewQuotesFromIEXSyms(symbols []string, startDate, endDate string, period Period, token string) (Quotes, error) {
+	return NewQuotesFromIEXSymsCtx(context.Background(), symbols, startDate, endDate, period, token)
+}
+
+// NewQuotesFromIEXSymsCtx - NewQuotesFromIEXSyms, canceled with ctx.
+// The quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromIEXSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period, token string) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromIEXCtx(ctx, symbol, startDate, endDate, period, token)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "iex after %d of %d symbols", i, len(symbols))
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + symbol)
+		}
+		delayContext(ctx, "iex")
+	}
+	return quotes, nil
+}
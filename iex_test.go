@@ -0,0 +1,92 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIEX - chart endpoint serving daily bars of any range and minute bars
+// of any date, recording the requested paths
+func fakeIEX(paths *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.Path)
+		switch {
+		case r.URL.Query().Get("token") != "tok":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "The API key provided is not valid.")
+		case strings.Contains(r.URL.Path, "/NOPE/"):
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "Unknown symbol")
+		case strings.Contains(r.URL.Path, "/chart/date/"):
+			date := r.URL.Path[len(r.URL.Path)-8:]
+			fmt.Fprintf(w, `[
+{"date":"%[1]s","minute":"09:30","open":10,"high":11,"low":9,"close":10.5,"volume":300},
+{"date":"%[1]s","minute":"09:31","open":null,"high":null,"low":null,"close":null,"volume":0},
+{"date":"%[1]s","minute":"09:32","open":10.5,"high":10.6,"low":10.4,"close":10.6,"volume":200}]`, date)
+		default:
+			fmt.Fprint(w, `[
+{"date":"2023-02-28","open":49,"high":51,"low":48,"close":50,"volume":2000,"uOpen":98,"uHigh":102,"uLow":96,"uClose":100,"uVolume":1000},
+{"date":"2023-03-01","open":50,"high":52,"low":49,"close":51,"volume":2200,"uOpen":100,"uHigh":104,"uLow":98,"uClose":102,"uVolume":1100},
+{"date":"2023-03-02","open":51,"high":53,"low":50,"close":52,"volume":2400,"uOpen":102,"uHigh":106,"uLow":100,"uClose":104,"uVolume":1200},
+{"date":"2023-03-03","open":52,"high":54,"low":51,"close":53,"volume":2600,"uOpen":104,"uHigh":108,"uLow":102,"uClose":106,"uVolume":1300}]`)
+		}
+	}
+}
+
+func TestIEXRange(t *testing.T) {
+	now := time.Date(2023, 6, 15, 14, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		from string
+		want string
+	}{
+		{"2023-06-12", "5d"},
+		{"2023-06-01", "1m"},
+		{"2023-05-16", "1m"},
+		{"2023-05-14", "3m"},
+		{"2022-12-01", "1y"},
+		{"2021-07-01", "2y"},
+		{"2019-01-01", "5y"},
+		{"2000-01-01", "max"},
+	} {
+		equals(t, tt.want, iexRange(ParseDateString(tt.from), now))
+	}
+}
+
+func TestIEXDaily(t *testing.T) {
+	var paths []string
+	fakeServer(t, &iexURL, fakeIEX(&paths))
+
+	raw, adjusted, err := NewQuotePairFromIEX("AAPL", "2023-03-01", "2023-03-02", Daily, "tok")
+	ok(t, err)
+	equals(t, 1, len(paths))
+	assert(t, strings.HasPrefix(paths[0], "/stable/stock/AAPL/chart/"), "unexpected path %s", paths[0])
+	equals(t, []time.Time{day(2023, 3, 1), day(2023, 3, 2)}, adjusted.Date)
+	equals(t, []float64{51, 52}, adjusted.Close)
+	equals(t, []float64{2200, 2400}, adjusted.Volume)
+	equals(t, []float64{102, 104}, raw.Close)
+	equals(t, []float64{100, 102}, raw.Open)
+	equals(t, []float64{1100, 1200}, raw.Volume)
+	equals(t, []float64{51, 52}, raw.AdjClose)
+	equals(t, false, raw.Meta.Adjusted)
+	equals(t, true, adjusted.Meta.Adjusted)
+
+	_, err = NewQuoteFromIEX("NOPE", "2023-03-01", "2023-03-02", Daily, "tok")
+	assert(t, err != nil && strings.Contains(err.Error(), "Unknown symbol"), "unexpected error %v", err)
+}
+
+func TestIEXMinutes(t *testing.T) {
+	var paths []string
+	fakeServer(t, &iexURL, fakeIEX(&paths))
+
+	// Friday to Monday, the weekend is not requested
+	q, err := NewQuoteFromIEX("AAPL", "2023-03-03", "2023-03-06", Min1, "tok")
+	ok(t, err)
+	equals(t, []string{"/stable/stock/AAPL/chart/date/20230303", "/stable/stock/AAPL/chart/date/20230306"}, paths)
+	equals(t, 4, len(q.Date))
+	equals(t, time.Date(2023, 3, 3, 9, 30, 0, 0, time.UTC), q.Date[0])
+	equals(t, time.Date(2023, 3, 6, 9, 32, 0, 0, time.UTC), q.Date[3])
+	equals(t, 10.6, q.Close[1])
+}
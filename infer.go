@@ -0,0 +1,112 @@
+package quote
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// inferPeriods - candidate periods of InferPeriod, shortest first
+var inferPeriods = []Period{Min1, Min3, Min5, Min15, Min30, Min60, Hour2, Hour4, Hour6, Hour8, Hour12, Daily, Day3, Weekly, Monthly, Yearly}
+
+// InferPeriod - the Period best matching the spacing of q's bars and the
+// confidence of the match, from 0 to 1. Empty with zero confidence for quotes
+// with fewer than two bars.
+//
+// The period is the one closest to the median gap between bars. Confidence is
+// the fraction of gaps it explains: a gap of exactly one period, or of several
+// periods when every skipped bar falls on a day without any bars (weekends,
+// holidays) or outside the time of day range the bars cover (overnight
+// session breaks). Monthly and yearly gaps are explained by consecutive
+// calendar months and years.
+func (q Quote) InferPeriod() (Period, float64) {
+
+	var deltas []time.Duration
+	for bar := 1; bar < len(q.Date); bar++ {
+		if d := q.Date[bar].Sub(q.Date[bar-1]); d > 0 {
+			deltas = append(deltas, d)
+		}
+	}
+	if len(deltas) == 0 {
+		return "", 0
+	}
+	sorted := append([]time.Duration(nil), deltas...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	median := sorted[len(sorted)/2]
+
+	period := Min1
+	best := math.Inf(1)
+	for _, p := range inferPeriods {
+		if dist := math.Abs(math.Log(float64(median) / float64(p.Duration()))); dist < best {
+			period, best = p, dist
+		}
+	}
+
+	explained := 0
+	if period == Monthly || period == Yearly {
+		for bar := 1; bar < len(q.Date); bar++ {
+			prev, next := q.Date[bar-1], q.Date[bar]
+			months := (next.Year()-prev.Year())*12 + int(next.Month()-prev.Month())
+			if period == Monthly && months == 1 || period == Yearly && next.Year()-prev.Year() == 1 {
+				explained++
+			}
+		}
+		return period, float64(explained) / float64(len(q.Date)-1)
+	}
+
+	// days with bars and the time of day range they cover
+	days := make(map[int64]bool, len(q.Date))
+	first, last := 24*time.Hour, time.Duration(0)
+	for _, d := range q.Date {
+		days[dayKey(d)] = true
+		tod := clockOffset(d)
+		if tod < first {
+			first = tod
+		}
+		if tod > last {
+			last = tod
+		}
+	}
+	step := period.Duration()
+	for bar := 1; bar < len(q.Date); bar++ {
+		prev, gap := q.Date[bar-1], q.Date[bar].Sub(q.Date[bar-1])
+		if gap <= 0 || gap%step != 0 {
+			continue
+		}
+		ok := true
+		for t := prev.Add(step); t.Before(q.Date[bar]) && ok; t = t.Add(step) {
+			tod := clockOffset(t)
+			ok = !days[dayKey(t)] || tod < first || tod > last
+		}
+		if ok {
+			explained++
+		}
+	}
+	return period, float64(explained) / float64(len(q.Date)-1)
+}
+
+// dayKey - calendar day of t in its location
+func dayKey(t time.Time) int64 {
+	y, m, d := t.Date()
+	return int64(y)<<9 | int64(m)<<5 | int64(d)
+}
+
+// clockOffset - offset of t from midnight in its location
+func clockOffset(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// periodOrInfer - period if set, else the period recorded in q's metadata,
+// else the inferred period of q with a logged notice
+func (q Quote) periodOrInfer(period Period) Period {
+	if period != "" {
+		return period
+	}
+	if q.Meta != nil && q.Meta.Period != "" {
+		return q.Meta.Period
+	}
+	inferred, confidence := q.InferPeriod()
+	Log.Printf("%s: no period given, inferred %s with confidence %.2f\n", q.Symbol, inferred.Name(), confidence)
+	return inferred
+}
@@ -0,0 +1,108 @@
+package quote
+
+import (
+	"testing"
+	"time"
+)
+
+// synthQuote - bars of period from start while keep accepts their timestamp
+func synthQuote(period Period, start time.Time, bars int, keep func(time.Time) bool) Quote {
+	q := NewQuote("synth", 0)
+	for d := start; len(q.Date) < bars; {
+		if keep(d) {
+			q.PushBar(Bar{Date: d, Open: 1, High: 1, Low: 1, Close: 1})
+		}
+		if period == Monthly {
+			d = d.AddDate(0, 1, 0)
+		} else if period == Yearly {
+			d = d.AddDate(1, 0, 0)
+		} else {
+			d = d.Add(period.Duration())
+		}
+	}
+	return q
+}
+
+func weekday(t time.Time) bool {
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// session - weekday bars between 09:30 and 16:00
+func session(t time.Time) bool {
+	tod := clockOffset(t)
+	return weekday(t) && tod >= 9*time.Hour+30*time.Minute && tod < 16*time.Hour
+}
+
+func TestInferPeriod(t *testing.T) {
+	start := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	holiday := day(2023, 1, 16)
+	tests := []struct {
+		period Period
+		keep   func(time.Time) bool
+	}{
+		{Min1, session},
+		{Min3, session},
+		{Min5, session},
+		{Min15, session},
+		{Min30, session},
+		{Min60, session},
+		{Hour2, func(time.Time) bool { return true }},
+		{Hour4, weekday},
+		{Hour6, func(time.Time) bool { return true }},
+		{Hour8, func(time.Time) bool { return true }},
+		{Hour12, weekday},
+		{Daily, func(d time.Time) bool { return weekday(d) && !d.Equal(holiday) }},
+		{Day3, func(time.Time) bool { return true }},
+		{Weekly, func(time.Time) bool { return true }},
+		{Monthly, func(time.Time) bool { return true }},
+		{Yearly, func(time.Time) bool { return true }},
+	}
+	for _, tt := range tests {
+		first := start
+		if tt.period.Duration() < time.Hour {
+			first = start.Add(9*time.Hour + 30*time.Minute)
+		}
+		q := synthQuote(tt.period, first, 300, tt.keep)
+		if tt.period == Monthly {
+			// dated on the first weekday of the month
+			for bar, d := range q.Date {
+				for !weekday(d) {
+					d = d.AddDate(0, 0, 1)
+				}
+				q.Date[bar] = d
+			}
+		}
+		// and a bar missing in the middle
+		q = withoutBars(q, q.Date[150], q.Date[151])
+
+		p, confidence := q.InferPeriod()
+		equals(t, tt.period, p)
+		assert(t, confidence > 0.99, "%s: confidence %.3f", tt.period.Name(), confidence)
+	}
+
+	p, confidence := NewQuote("x", 1).InferPeriod()
+	equals(t, Period(""), p)
+	equals(t, 0.0, confidence)
+}
+
+func TestInferPeriodMixed(t *testing.T) {
+	// a day of hourly bars followed by months of daily bars
+	q := synthQuote(Min60, day(2023, 1, 1), 24, func(time.Time) bool { return true })
+	daily := synthQuote(Daily, day(2023, 1, 2), 30, func(time.Time) bool { return true })
+	q.appendQuote(daily)
+
+	_, confidence := q.InferPeriod()
+	assert(t, confidence < 0.7, "mixed frequency confidence %.3f", confidence)
+}
+
+func TestPeriodOrInfer(t *testing.T) {
+	q := cleanDaily("spy", 10)
+	equals(t, Weekly, q.periodOrInfer(Weekly))
+	equals(t, Daily, q.periodOrInfer(""))
+	q.Meta = &Metadata{Period: Min5}
+	equals(t, Min5, q.periodOrInfer(""))
+
+	// quality report without a period counts gaps against the inferred one
+	gappy := withoutBars(cleanDaily("spy", 10), day(2024, 1, 4), day(2024, 1, 6))
+	equals(t, 2, Quotes{gappy}.QualityReport("", &WeekdaysOnly).Symbols[0].Missing)
+}
@@ -0,0 +1,172 @@
+package quote
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Journal entry statuses
+const (
+	JournalOK     = "ok"     // bars received
+	JournalEmpty  = "empty"  // no error but no bars
+	JournalFailed = "failed" // download error
+)
+
+// JournalEntry - outcome of downloading one symbol
+type JournalEntry struct {
+	Symbol   string        `json:"symbol"`
+	Source   string        `json:"source"`
+	Period   Period        `json:"period,omitempty"`
+	From     time.Time     `json:"from"` // requested range
+	To       time.Time     `json:"to"`
+	Bars     int           `json:"bars"`
+	Duration time.Duration `json:"duration"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Recorded time.Time     `json:"recorded"`
+}
+
+// Journal - append-only JSON lines log of completed downloads, one entry
+// per line. Safe for concurrent use; each entry is written and synced with a
+// single write, so a crash loses at most the entry being written.
+type Journal struct {
+	mu     sync.Mutex
+	file   *os.File
+	latest map[string]JournalEntry // last entry of each symbol
+	order  []string                // symbols in order of first entry
+}
+
+// OpenJournal - open or create the journal at path, loading its entries.
+// A truncated last line, as left by a crash, is ignored.
+func OpenJournal(path string) (*Journal, error) {
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j := &Journal{file: file, latest: make(map[string]JournalEntry)}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e JournalEntry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			Log.Printf("journal %s: skipping bad entry %.60q\n", path, scanner.Text())
+			continue
+		}
+		j.add(e)
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	// terminate a truncated last line so the next entry starts on its own
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			file.Write([]byte("\n"))
+		}
+	}
+	return j, nil
+}
+
+// add - index e, the caller holds mu or owns j
+func (j *Journal) add(e JournalEntry) {
+	if _, ok := j.latest[e.Symbol]; !ok {
+		j.order = append(j.order, e.Symbol)
+	}
+	j.latest[e.Symbol] = e
+}
+
+// Record - append e to the journal. Status defaults from Bars and Error and
+// Recorded to now.
+func (j *Journal) Record(e JournalEntry) error {
+	if e.Status == "" {
+		switch {
+		case e.Error != "":
+			e.Status = JournalFailed
+		case e.Bars == 0:
+			e.Status = JournalEmpty
+		default:
+			e.Status = JournalOK
+		}
+	}
+	if e.Recorded.IsZero() {
+		e.Recorded = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err = j.file.Write(line); err != nil {
+		return err
+	}
+	if err = j.file.Sync(); err != nil {
+		return err
+	}
+	j.add(e)
+	return nil
+}
+
+// Latest - the last entry recorded for symbol
+func (j *Journal) Latest(symbol string) (JournalEntry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e, ok := j.latest[symbol]
+	return e, ok
+}
+
+// Completed - true if the last entry for symbol from source received bars,
+// for resuming a run without re-reading its output files
+func (j *Journal) Completed(symbol, source string) bool {
+	e, ok := j.Latest(symbol)
+	return ok && e.Source == source && e.Status == JournalOK
+}
+
+// JournalSummary - totals over the last entry of each symbol in a journal
+type JournalSummary struct {
+	Symbols  int
+	OK       int
+	Empty    int
+	Failed   []string // symbols whose last download failed, sorted
+	Bars     int
+	Duration time.Duration
+}
+
+// Summary - totals over the last entry of each symbol
+func (j *Journal) Summary() JournalSummary {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	s := JournalSummary{Symbols: len(j.order)}
+	for _, sym := range j.order {
+		e := j.latest[sym]
+		switch e.Status {
+		case JournalOK:
+			s.OK++
+		case JournalEmpty:
+			s.Empty++
+		default:
+			s.Failed = append(s.Failed, sym)
+		}
+		s.Bars += e.Bars
+		s.Duration += e.Duration
+	}
+	sort.Strings(s.Failed)
+	return s
+}
+
+// Close - close the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
@@ -0,0 +1,89 @@
+package quote
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "run.jsonl")
+
+	j, err := OpenJournal(path)
+	ok(t, err)
+	ok(t, j.Record(JournalEntry{Symbol: "spy", Source: "yahoo", Bars: 0, Error: "timeout"}))
+	ok(t, j.Record(JournalEntry{Symbol: "qqq", Source: "yahoo", Bars: 250, Duration: time.Second}))
+	ok(t, j.Record(JournalEntry{Symbol: "spy", Source: "yahoo", Bars: 252, Duration: 2 * time.Second}))
+	ok(t, j.Record(JournalEntry{Symbol: "xyz", Source: "yahoo"}))
+	ok(t, j.Close())
+
+	// reopened with a crash-truncated last line
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	ok(t, err)
+	f.WriteString(`{"symbol":"iwm","sou`)
+	f.Close()
+	j, err = OpenJournal(path)
+	ok(t, err)
+	defer j.Close()
+
+	e, found := j.Latest("spy")
+	equals(t, true, found)
+	equals(t, JournalOK, e.Status)
+	equals(t, 252, e.Bars)
+	equals(t, true, j.Completed("spy", "yahoo"))
+	equals(t, false, j.Completed("spy", "tiingo"))
+	equals(t, false, j.Completed("xyz", "yahoo"))
+	equals(t, false, j.Completed("iwm", "yahoo"))
+
+	ok(t, j.Record(JournalEntry{Symbol: "iwm", Source: "yahoo", Error: "not found"}))
+	equals(t, JournalSummary{Symbols: 4, OK: 2, Empty: 1, Failed: []string{"iwm"}, Bars: 502, Duration: 3 * time.Second}, j.Summary())
+
+	data, err := ioutil.ReadFile(path)
+	ok(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	equals(t, 6, len(lines))
+	assert(t, strings.HasPrefix(lines[5], `{"symbol":"iwm","source":"yahoo"`), "entry after truncated line %q", lines[5])
+}
+
+func TestJournalConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "run.jsonl")
+
+	j, err := OpenJournal(path)
+	ok(t, err)
+	var wg sync.WaitGroup
+	for w := 0; w < 32; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				sym := fmt.Sprintf("s%02d-%02d", w, i)
+				if err := j.Record(JournalEntry{Symbol: sym, Source: "binance", Bars: i + 1}); err != nil {
+					t.Error(err)
+				}
+				j.Completed(sym, "binance")
+				j.Summary()
+			}
+		}(w)
+	}
+	wg.Wait()
+	ok(t, j.Close())
+
+	j, err = OpenJournal(path)
+	ok(t, err)
+	defer j.Close()
+	s := j.Summary()
+	equals(t, 32*20, s.Symbols)
+	equals(t, 32*20, s.OK)
+	equals(t, 32*210, s.Bars)
+}
@@ -0,0 +1,93 @@
+package quote
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// bySymbol - quotes keyed by symbol. Quotes sharing a symbol are merged into
+// one in date order; where both have a bar at the same date the bar of the
+// later quote is kept.
+func (q Quotes) bySymbol() map[string]Quote {
+	type ref struct{ quote, bar int }
+	refs := make(map[string][]ref)
+	for i, quote := range q {
+		for bar := range quote.Date {
+			refs[quote.Symbol] = append(refs[quote.Symbol], ref{i, bar})
+		}
+		if _, ok := refs[quote.Symbol]; !ok {
+			refs[quote.Symbol] = nil
+		}
+	}
+
+	m := make(map[string]Quote, len(refs))
+	for sym, rs := range refs {
+		sort.SliceStable(rs, func(i, j int) bool {
+			return q[rs[i].quote].Date[rs[i].bar].Before(q[rs[j].quote].Date[rs[j].bar])
+		})
+		merged := NewQuote(sym, 0)
+		for i, r := range rs {
+			if i+1 < len(rs) && q[rs[i+1].quote].Date[rs[i+1].bar].Equal(q[r.quote].Date[r.bar]) {
+				continue
+			}
+			merged.appendBar(q[r.quote], r.bar)
+		}
+		m[sym] = merged
+	}
+	return m
+}
+
+// JSONMap - convert Quotes to a json object keyed by symbol, each value in the
+// Quote json format. Quotes with duplicate symbols are merged, keeping the
+// later quote's bar where both have one at the same date.
+func (q Quotes) JSONMap(indent bool) string {
+	var j []byte
+	if indent {
+		j, _ = json.MarshalIndent(q.bySymbol(), "", "  ")
+	} else {
+		j, _ = json.Marshal(q.bySymbol())
+	}
+	return string(j)
+}
+
+// WriteJSONMap - write Quotes to a json file keyed by symbol
+func (q Quotes) WriteJSONMap(filename string, indent bool) error {
+	if filename == "" {
+		filename = "quotes.json"
+	}
+	jsn := q.JSONMap(indent)
+	return writeFile(filename, []byte(jsn))
+}
+
+// NewQuotesFromJSONMap - parse json object keyed by symbol into Quotes, in symbol order
+func NewQuotesFromJSONMap(jsn string) (Quotes, error) {
+	m := map[string]Quote{}
+	err := json.Unmarshal([]byte(jsn), &m)
+	if err != nil {
+		return Quotes{}, err
+	}
+	symbols := make([]string, 0, len(m))
+	for sym := range m {
+		symbols = append(symbols, sym)
+	}
+	sort.Strings(symbols)
+
+	quotes := make(Quotes, 0, len(m))
+	for _, sym := range symbols {
+		quote := m[sym]
+		if quote.Symbol == "" {
+			quote.Symbol = sym
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, nil
+}
+
+// NewQuotesFromJSONMapFile - parse json file keyed by symbol into Quotes
+func NewQuotesFromJSONMapFile(filename string) (Quotes, error) {
+	jsn, err := readFile(filename)
+	if err != nil {
+		return Quotes{}, err
+	}
+	return NewQuotesFromJSONMap(string(jsn))
+}
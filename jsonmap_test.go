@@ -0,0 +1,38 @@
+package quote
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONMap(t *testing.T) {
+	quotes := Quotes{cleanDaily("spy", 3), cleanDaily("aapl", 2)}
+
+	var raw map[string]json.RawMessage
+	ok(t, json.Unmarshal([]byte(quotes.JSONMap(false)), &raw))
+	equals(t, 2, len(raw))
+	equals(t, quotes[0].JSON(false), string(raw["spy"]))
+
+	back, err := NewQuotesFromJSONMap(quotes.JSONMap(true))
+	ok(t, err)
+	equals(t, 2, len(back))
+	equals(t, "aapl", back[0].Symbol)
+	equals(t, quotes[1].Close, back[0].Close)
+	equals(t, quotes[0].Date, back[1].Date)
+}
+
+func TestJSONMapDuplicateSymbols(t *testing.T) {
+	a := cleanDaily("spy", 3)
+	b := cleanDaily("spy", 5).slice(2, 5).copy()
+	b.Close[0] = 999 // same date as a's last bar
+
+	back, err := NewQuotesFromJSONMap(Quotes{a, b}.JSONMap(false))
+	ok(t, err)
+	equals(t, 1, len(back))
+	spy := back[0]
+	equals(t, 5, len(spy.Date))
+	equals(t, a.Date[0], spy.Date[0].UTC())
+	equals(t, b.Date[2], spy.Date[4].UTC())
+	// the later quote wins on a shared date
+	equals(t, 999.0, spy.Close[2])
+}
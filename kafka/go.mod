@@ -0,0 +1,8 @@
+module github.com/markcheno/go-quote/kafka
+
+go 1.16
+
+require (
+	github.com/markcheno/go-quote v0.0.0-20261015063413-e39b8b02ada4
+	github.com/segmentio/kafka-go v0.4.47
+)
@@ -0,0 +1,294 @@
+/*
+Package kafka publishes go-quote bars to a Kafka topic
+
+It lives in its own module so that the core quote package stays free of
+external dependencies.
+
+Licensed under terms of MIT license (see LICENSE)
+*/
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markcheno/go-quote"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Message - a single keyed Kafka message
+type Message struct {
+	Key   []byte
+	Value []byte
+	Time  time.Time
+}
+
+// Producer - minimal producer interface, satisfied by the kafka-go adapter
+// returned from NewProducer and easy to mock in tests
+type Producer interface {
+	// WriteMessages delivers msgs, returning a DeliveryErrors value when only
+	// some of the messages failed
+	WriteMessages(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// DeliveryErrors - per-message delivery errors, indexed like the messages passed
+// to WriteMessages (nil entries were delivered)
+type DeliveryErrors []error
+
+func (e DeliveryErrors) Error() string {
+	n := 0
+	for _, err := range e {
+		if err != nil {
+			n++
+		}
+	}
+	return fmt.Sprintf("kafka: %d of %d messages failed", n, len(e))
+}
+
+// Encoder - encode one bar of a quote as a message value
+type Encoder func(q quote.Quote, bar int) ([]byte, error)
+
+// JSONEncoder - encode a bar as a flat json object (default), with the
+// optional columns the quote carries named as in its json
+func JSONEncoder(q quote.Quote, bar int) ([]byte, error) {
+	at := func(col []float64) *float64 {
+		if col == nil {
+			return nil
+		}
+		return &col[bar]
+	}
+	return json.Marshal(struct {
+		Symbol       string    `json:"symbol"`
+		Date         time.Time `json:"date"`
+		Open         float64   `json:"open"`
+		High         float64   `json:"high"`
+		Low          float64   `json:"low"`
+		Close        float64   `json:"close"`
+		Volume       float64   `json:"volume"`
+		Trades       *float64  `json:"trades,omitempty"`
+		OpenInterest *float64  `json:"openinterest,omitempty"`
+		AdjClose     *float64  `json:"adjclose,omitempty"`
+		Session      *float64  `json:"session,omitempty"`
+		AdjOpen      *float64  `json:"adjopen,omitempty"`
+		AdjHigh      *float64  `json:"adjhigh,omitempty"`
+		AdjLow       *float64  `json:"adjlow,omitempty"`
+	}{q.Symbol, q.Date[bar], q.Open[bar], q.High[bar], q.Low[bar], q.Close[bar], q.Volume[bar],
+		at(q.Trades), at(q.OpenInterest), at(q.AdjClose), at(q.Session), at(q.AdjOpen), at(q.AdjHigh), at(q.AdjLow)})
+}
+
+// ProtoEncoder - encode a bar as a quote.proto Quote message of that one bar
+// and the optional columns the quote carries, read back with
+// quote.UnmarshalQuote
+func ProtoEncoder(q quote.Quote, bar int) ([]byte, error) {
+	one := func(col []float64) []float64 {
+		if col == nil {
+			return nil
+		}
+		return col[bar : bar+1]
+	}
+	return quote.Quote{
+		Symbol:       q.Symbol,
+		Date:         q.Date[bar : bar+1],
+		Open:         one(q.Open),
+		High:         one(q.High),
+		Low:          one(q.Low),
+		Close:        one(q.Close),
+		Volume:       one(q.Volume),
+		Trades:       one(q.Trades),
+		OpenInterest: one(q.OpenInterest),
+		AdjClose:     one(q.AdjClose),
+		Session:      one(q.Session),
+		AdjOpen:      one(q.AdjOpen),
+		AdjHigh:      one(q.AdjHigh),
+		AdjLow:       one(q.AdjLow),
+	}.Marshal()
+}
+
+// Option - Sink configuration option
+type Option func(*Sink)
+
+// WithEncoder - use enc for message values instead of JSONEncoder, e.g.
+// ProtoEncoder
+func WithEncoder(enc Encoder) Option {
+	return func(s *Sink) { s.encoder = enc }
+}
+
+// WithProducer - publish through p instead of a kafka-go writer
+func WithProducer(p Producer) Option {
+	return func(s *Sink) { s.producer = p }
+}
+
+// Summary - outcome of one or more Publish calls
+type Summary struct {
+	Published int     // bars delivered
+	Skipped   int     // bars at or before the symbol's high-water mark
+	Errors    []error // delivery and encoding errors
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("kafka: published=%d skipped=%d errors=%d", s.Published, s.Skipped, len(s.Errors))
+}
+
+// Sink - publishes bars as Kafka messages keyed by symbol
+//
+// The sink remembers the newest delivered timestamp per symbol, so repeatedly
+// publishing a growing Quote (as in watch mode) only sends new bars. The mark
+// stops before the first bar that failed to encode or deliver, so that bar
+// and the ones after it are sent again by the next Publish.
+type Sink struct {
+	mu       sync.Mutex
+	producer Producer
+	encoder  Encoder
+	hwm      map[string]time.Time
+	summary  Summary
+}
+
+// NewSink - new Sink publishing to topic on brokers
+func NewSink(brokers []string, topic string, opts ...Option) *Sink {
+	s := &Sink{
+		encoder: JSONEncoder,
+		hwm:     make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.producer == nil {
+		s.producer = NewProducer(brokers, topic)
+	}
+	return s
+}
+
+// Publish - publish every bar of q newer than the symbol's high-water mark,
+// returning the number of bars delivered
+func (s *Sink) Publish(ctx context.Context, q quote.Quote) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hwm, seen := s.hwm[q.Symbol]
+	var msgs []Message
+	var bars []int
+	unencoded := -1 // first bar the encoder failed on
+	for bar := range q.Close {
+		if seen && !q.Date[bar].After(hwm) {
+			s.summary.Skipped++
+			continue
+		}
+		value, err := s.encoder(q, bar)
+		if err != nil {
+			s.summary.Errors = append(s.summary.Errors, fmt.Errorf("%s %v: %v", q.Symbol, q.Date[bar], err))
+			if unencoded < 0 {
+				unencoded = bar
+			}
+			continue
+		}
+		msgs = append(msgs, Message{Key: []byte(q.Symbol), Value: value, Time: q.Date[bar]})
+		bars = append(bars, bar)
+	}
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+
+	err := s.producer.WriteMessages(ctx, msgs...)
+	var failed DeliveryErrors
+	if err != nil && !errors.As(err, &failed) {
+		s.summary.Errors = append(s.summary.Errors, err)
+		return 0, err
+	}
+
+	published := 0
+	advance := true
+	for i, bar := range bars {
+		if unencoded >= 0 && bar > unencoded {
+			advance = false
+		}
+		if i < len(failed) && failed[i] != nil {
+			s.summary.Errors = append(s.summary.Errors, fmt.Errorf("%s %v: %v", q.Symbol, q.Date[bar], failed[i]))
+			advance = false
+			continue
+		}
+		published++
+		if d := q.Date[bar]; advance && (!seen || d.After(hwm)) {
+			hwm, seen = d, true
+		}
+	}
+	if seen {
+		s.hwm[q.Symbol] = hwm
+	}
+	s.summary.Published += published
+	if len(failed) > 0 {
+		return published, failed
+	}
+	return published, nil
+}
+
+// Summary - totals for all Publish calls so far
+func (s *Sink) Summary() Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sum := s.summary
+	sum.Errors = append([]error(nil), s.summary.Errors...)
+	return sum
+}
+
+// Close - flush and close the underlying producer
+func (s *Sink) Close() error {
+	return s.producer.Close()
+}
+
+// WriteKafka - publish every bar of quotes to topic, one message per bar
+func WriteKafka(brokers []string, topic string, quotes quote.Quotes, opts ...Option) (Summary, error) {
+	sink := NewSink(brokers, topic, opts...)
+	for _, q := range quotes {
+		_, err := sink.Publish(context.Background(), q)
+		if err != nil {
+			quote.Log.Printf("kafka error: %v\n", err)
+		}
+	}
+	err := sink.Close()
+	sum := sink.Summary()
+	if err == nil && len(sum.Errors) > 0 {
+		err = fmt.Errorf("kafka: %d delivery errors, first: %v", len(sum.Errors), sum.Errors[0])
+	}
+	return sum, err
+}
+
+// producer - kafka-go backed Producer, batching via the writer's own batching
+type producer struct {
+	w *kafkago.Writer
+}
+
+// NewProducer - Producer writing to topic on a comma separated or sliced list of brokers
+func NewProducer(brokers []string, topic string) Producer {
+	var addrs []string
+	for _, b := range brokers {
+		addrs = append(addrs, strings.Split(b, ",")...)
+	}
+	return &producer{w: &kafkago.Writer{
+		Addr:     kafkago.TCP(addrs...),
+		Topic:    topic,
+		Balancer: &kafkago.Hash{},
+	}}
+}
+
+func (p *producer) WriteMessages(ctx context.Context, msgs ...Message) error {
+	kmsgs := make([]kafkago.Message, len(msgs))
+	for i, m := range msgs {
+		kmsgs[i] = kafkago.Message{Key: m.Key, Value: m.Value, Time: m.Time}
+	}
+	err := p.w.WriteMessages(ctx, kmsgs...)
+	var werrs kafkago.WriteErrors
+	if errors.As(err, &werrs) {
+		return DeliveryErrors(werrs)
+	}
+	return err
+}
+
+func (p *producer) Close() error {
+	return p.w.Close()
+}
@@ -0,0 +1,205 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/markcheno/go-quote"
+)
+
+type mockProducer struct {
+	msgs   []Message
+	fail   map[int]bool // fail the n-th message ever written
+	n      int
+	closed bool
+}
+
+func (m *mockProducer) WriteMessages(ctx context.Context, msgs ...Message) error {
+	var errs DeliveryErrors
+	failed := false
+	for _, msg := range msgs {
+		if m.fail[m.n] {
+			errs = append(errs, errors.New("broker unavailable"))
+			failed = true
+		} else {
+			errs = append(errs, nil)
+			m.msgs = append(m.msgs, msg)
+		}
+		m.n++
+	}
+	if failed {
+		return errs
+	}
+	return nil
+}
+
+func (m *mockProducer) Close() error {
+	m.closed = true
+	return nil
+}
+
+func testQuote(symbol string, bars int) quote.Quote {
+	q := quote.NewQuote(symbol, bars)
+	for bar := 0; bar < bars; bar++ {
+		q.Date[bar] = time.Date(2024, 1, 2+bar, 0, 0, 0, 0, time.UTC)
+		q.Close[bar] = float64(100 + bar)
+	}
+	return q
+}
+
+func TestWriteKafka(t *testing.T) {
+	p := &mockProducer{}
+	sum, err := WriteKafka(nil, "bars", quote.Quotes{testQuote("aapl", 3), testQuote("spy", 2)}, WithProducer(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Published != 5 || len(p.msgs) != 5 || !p.closed {
+		t.Fatalf("unexpected summary %v, %d messages, closed=%v", sum, len(p.msgs), p.closed)
+	}
+	if string(p.msgs[3].Key) != "spy" {
+		t.Errorf("expected key spy, got %s", p.msgs[3].Key)
+	}
+	var bar struct {
+		Symbol string  `json:"symbol"`
+		Close  float64 `json:"close"`
+	}
+	if err := json.Unmarshal(p.msgs[2].Value, &bar); err != nil {
+		t.Fatal(err)
+	}
+	if bar.Symbol != "aapl" || bar.Close != 102 {
+		t.Errorf("unexpected value %s", p.msgs[2].Value)
+	}
+}
+
+func TestSinkPublishesOnlyNewBars(t *testing.T) {
+	p := &mockProducer{}
+	sink := NewSink(nil, "bars", WithProducer(p))
+	ctx := context.Background()
+
+	n, err := sink.Publish(ctx, testQuote("btcusdt", 3))
+	if err != nil || n != 3 {
+		t.Fatalf("first publish: n=%d err=%v", n, err)
+	}
+	// watch-mode refresh returns the same bars plus two new ones
+	n, err = sink.Publish(ctx, testQuote("btcusdt", 5))
+	if err != nil || n != 2 {
+		t.Fatalf("second publish: n=%d err=%v", n, err)
+	}
+	sum := sink.Summary()
+	if sum.Published != 5 || sum.Skipped != 3 {
+		t.Errorf("unexpected summary %v", sum)
+	}
+}
+
+func TestSinkDeliveryErrors(t *testing.T) {
+	p := &mockProducer{fail: map[int]bool{1: true}}
+	sink := NewSink(nil, "bars", WithProducer(p))
+	ctx := context.Background()
+
+	n, err := sink.Publish(ctx, testQuote("eth", 3))
+	var failed DeliveryErrors
+	if !errors.As(err, &failed) || n != 2 {
+		t.Fatalf("expected partial delivery, n=%d err=%v", n, err)
+	}
+	sum := sink.Summary()
+	if sum.Published != 2 || len(sum.Errors) != 1 {
+		t.Errorf("unexpected summary %v", sum)
+	}
+}
+
+func TestSinkRetriesFailedBars(t *testing.T) {
+	// the second bar fails, the third is delivered
+	p := &mockProducer{fail: map[int]bool{1: true}}
+	sink := NewSink(nil, "bars", WithProducer(p))
+	ctx := context.Background()
+
+	if n, _ := sink.Publish(ctx, testQuote("eth", 3)); n != 2 {
+		t.Fatalf("first publish: n=%d", n)
+	}
+	// the failed bar and the one after it are sent again
+	n, err := sink.Publish(ctx, testQuote("eth", 3))
+	if err != nil || n != 2 {
+		t.Fatalf("retry: n=%d err=%v", n, err)
+	}
+	if len(p.msgs) != 4 || !p.msgs[2].Time.Equal(testQuote("eth", 3).Date[1]) {
+		t.Fatalf("unexpected messages %v", p.msgs)
+	}
+	if n, _ := sink.Publish(ctx, testQuote("eth", 3)); n != 0 {
+		t.Errorf("published %d bars after the retry", n)
+	}
+
+	// a bar the encoder drops is retried too
+	fail := true
+	enc := func(q quote.Quote, bar int) ([]byte, error) {
+		if bar == 0 && fail {
+			return nil, errors.New("cannot encode")
+		}
+		return JSONEncoder(q, bar)
+	}
+	p = &mockProducer{}
+	sink = NewSink(nil, "bars", WithProducer(p), WithEncoder(enc))
+	if n, _ := sink.Publish(ctx, testQuote("btc", 2)); n != 1 {
+		t.Fatalf("first publish: n=%d", n)
+	}
+	fail = false
+	if n, _ := sink.Publish(ctx, testQuote("btc", 2)); n != 2 {
+		t.Errorf("retry: n=%d", n)
+	}
+}
+
+func TestWithEncoder(t *testing.T) {
+	p := &mockProducer{}
+	enc := func(q quote.Quote, bar int) ([]byte, error) {
+		if bar == 1 {
+			return nil, errors.New("cannot encode")
+		}
+		return []byte(q.Date[bar].Format("2006-01-02")), nil
+	}
+	sum, err := WriteKafka(nil, "bars", quote.Quotes{testQuote("aapl", 2)}, WithProducer(p), WithEncoder(enc))
+	if err == nil || sum.Published != 1 || len(sum.Errors) != 1 {
+		t.Fatalf("unexpected summary %v err=%v", sum, err)
+	}
+	if string(p.msgs[0].Value) != "2024-01-02" {
+		t.Errorf("unexpected value %s", p.msgs[0].Value)
+	}
+}
+
+func TestEncoders(t *testing.T) {
+	q := testQuote("aapl", 2)
+	q.Volume[1] = 1500
+	q.Trades = []float64{10, 12}
+	q.AdjClose = []float64{99, 100.5}
+
+	value, err := JSONEncoder(q, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bar map[string]interface{}
+	if err := json.Unmarshal(value, &bar); err != nil {
+		t.Fatal(err)
+	}
+	if bar["trades"] != 12.0 || bar["adjclose"] != 100.5 || bar["close"] != 101.0 {
+		t.Errorf("unexpected json %s", value)
+	}
+	if _, ok := bar["openinterest"]; ok {
+		t.Errorf("absent column in json %s", value)
+	}
+
+	value, err = ProtoEncoder(q, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := quote.UnmarshalQuote(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Symbol != "aapl" || len(got.Date) != 1 || !got.Date[0].Equal(q.Date[1]) {
+		t.Fatalf("unexpected bar %+v", got)
+	}
+	if got.Close[0] != 101 || got.Volume[0] != 1500 || got.Trades[0] != 12 || got.AdjClose[0] != 100.5 || got.OpenInterest != nil {
+		t.Errorf("unexpected columns %+v", got)
+	}
+}
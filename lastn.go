@@ -0,0 +1,87 @@
+package quote
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// lastNAttempts - most requests NewQuoteLastN makes for one symbol
+const lastNAttempts = 4
+
+// lastNSpan - date range expected to hold n bars of period on the calendar of
+// spec. Weekday sources lose weekends and about ten holidays a year, and
+// their intraday bars cover only the regular session. A few days of slack
+// cover a range ending on a weekend or before today's bars.
+func lastNSpan(spec SourceSpec, n int, period Period) time.Duration {
+
+	const day = 24 * time.Hour
+	step := period.Duration()
+	switch period {
+	case Monthly:
+		step = 31 * day
+	case Yearly:
+		step = 366 * day
+	}
+	weekdays := spec.Calendar().Weekdays
+
+	var days float64
+	switch {
+	case step >= day:
+		days = float64(n) * float64(step) / float64(day)
+		if weekdays && step == day {
+			days *= 7.0 / 5 * 1.05
+		}
+	case weekdays:
+		perDay := math.Max(1, math.Floor(float64(equitySession)/float64(step)))
+		days = float64(n) / perDay * 7 / 5 * 1.05
+	default:
+		days = float64(n) * float64(step) / float64(day)
+	}
+	return time.Duration((math.Ceil(days) + 4) * float64(day))
+}
+
+// NewQuoteLastN - the last n bars of symbol from the source described by spec,
+// up to now. The date range is estimated from period and the source calendar;
+// when it comes back short, e.g. across a long holiday or a trading halt, it
+// is widened in proportion to the shortfall and requested again. A symbol
+// with less history returns all of its bars.
+func NewQuoteLastN(ctx context.Context, spec SourceSpec, symbol string, n int, period Period) (Quote, error) {
+
+	if err := ValidatePeriod(spec.Name, period); err != nil {
+		return NewQuote("", 0), err
+	}
+	if n <= 0 {
+		return NewQuote(symbol, 0), nil
+	}
+
+	to := time.Now().UTC()
+	span := lastNSpan(spec, n, period)
+	var q Quote
+	for attempt := 0; attempt < lastNAttempts; attempt++ {
+		if attempt > 0 {
+			delay(spec.Name)
+		}
+		got, err := NewQuoteFromSource(ctx, spec, symbol, to.Add(-span), to, period)
+		if err != nil {
+			return NewQuote("", 0), err
+		}
+		if len(got.Date) >= n {
+			return got.Last(n), nil
+		}
+		if attempt > 0 && len(got.Date) == len(q.Date) {
+			// the wider range added nothing, the history starts here
+			break
+		}
+		q = got
+
+		// widen by the shortfall, at least doubling
+		factor := 2.0
+		if len(q.Date) > 0 {
+			factor = math.Max(factor, 1.25*float64(n)/float64(len(q.Date)))
+		}
+		span = time.Duration(float64(span) * factor)
+	}
+	Log.Printf("%s: %d of %d bars available\n", symbol, len(q.Date), n)
+	return q, nil
+}
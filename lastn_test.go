@@ -0,0 +1,95 @@
+package quote
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeCalendarSource - fetchSource serving bars of the requested period on
+// the source calendar from listed on, intraday weekday bars in the 09:30 to
+// 16:00 new york session, except on the days skip rejects. Records the
+// requested ranges and returns a restore func.
+func fakeCalendarSource(listed time.Time, skip func(time.Time) bool, ranges *[][2]time.Time) func() {
+	saved := fetchSource
+	fetchSource = func(_ context.Context, spec SourceSpec, symbol string, from, to time.Time, period Period) (Quote, error) {
+		*ranges = append(*ranges, [2]time.Time{from, to})
+		cal := spec.Calendar()
+		step := period.Duration()
+		q := NewQuote(symbol, 0)
+		d := from.Truncate(24 * time.Hour)
+		if step >= 24*time.Hour && period != Daily {
+			d = listed // keep weekly bars on the listing weekday
+		}
+		for ; !d.After(to); d = d.Add(step) {
+			if d.Before(from) || d.Before(listed) || !cal.IsTradingDay(d) || skip != nil && skip(d) {
+				continue
+			}
+			if cal.Weekdays && step < 24*time.Hour {
+				tod := clockOffset(d)
+				if tod < 14*time.Hour+30*time.Minute || tod >= 21*time.Hour {
+					continue
+				}
+			}
+			q.PushBar(Bar{Date: d, Open: 1, High: 1, Low: 1, Close: 1})
+		}
+		return q, nil
+	}
+	return func() { fetchSource = saved }
+}
+
+func TestNewQuoteLastN(t *testing.T) {
+	tests := []struct {
+		source string
+		period Period
+		n      int
+	}{
+		{"yahoo", Daily, 500},
+		{"yahoo", Daily, 1},
+		{"yahoo", Weekly, 52},
+		{"yahoo", Min5, 1000},
+		{"yahoo", Min60, 30},
+		{"binance", Min15, 2000},
+		{"binance", Hour4, 100},
+		{"binance", Daily, 365},
+	}
+	for _, tt := range tests {
+		var ranges [][2]time.Time
+		restore := fakeCalendarSource(day(2000, 1, 3), nil, &ranges)
+		q, err := NewQuoteLastN(context.Background(), SourceSpec{Name: tt.source}, "x", tt.n, tt.period)
+		restore()
+		ok(t, err)
+		assert(t, len(q.Date) == tt.n, "%s %s: %d bars, want %d", tt.source, tt.period.Name(), len(q.Date), tt.n)
+		assert(t, len(ranges) == 1, "%s %s: %d requests", tt.source, tt.period.Name(), len(ranges))
+		assert(t, time.Since(ranges[0][1]) < time.Minute, "%s %s: range ends %v", tt.source, tt.period.Name(), ranges[0][1])
+	}
+}
+
+func TestNewQuoteLastNShort(t *testing.T) {
+	// a month long halt leaves the first range short
+	halted := func(d time.Time) bool { return time.Since(d) < 60*24*time.Hour && time.Since(d) > 20*24*time.Hour }
+	var ranges [][2]time.Time
+	defer fakeCalendarSource(day(2000, 1, 3), halted, &ranges)()
+
+	q, err := NewQuoteLastN(context.Background(), SourceSpec{Name: "yahoo"}, "x", 100, Daily)
+	ok(t, err)
+	equals(t, 100, len(q.Date))
+	equals(t, 2, len(ranges))
+	assert(t, ranges[1][0].Before(ranges[0][0]), "second range %v not wider", ranges[1])
+}
+
+func TestNewQuoteLastNListed(t *testing.T) {
+	// less history than requested returns all of it
+	listed := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -30)
+	var ranges [][2]time.Time
+	defer fakeCalendarSource(listed, nil, &ranges)()
+
+	q, err := NewQuoteLastN(context.Background(), SourceSpec{Name: "binance"}, "x", 100, Daily)
+	ok(t, err)
+	assert(t, len(q.Date) >= 30 && len(q.Date) <= 31, "%d bars", len(q.Date))
+	equals(t, listed, q.Date[0])
+	equals(t, 2, len(ranges))
+
+	_, err = NewQuoteLastN(context.Background(), SourceSpec{Name: "tiingo"}, "x", 10, Min5)
+	assert(t, err != nil, "unsupported period accepted")
+}
@@ -0,0 +1,80 @@
+package quote
+
+import (
+	"sort"
+	"strings"
+)
+
+// SortOutput - write the members of Quotes in symbol order, see SortBySymbol,
+// with the CSV, JSON and Highstock writers; false keeps the order of the
+// Quotes
+var SortOutput = true
+
+// Symbol - the first member of q with symbol sym, ignoring case, and true,
+// or an empty quote and false if there is none
+func (q Quotes) Symbol(sym string) (Quote, bool) {
+	for _, quote := range q {
+		if strings.EqualFold(quote.Symbol, sym) {
+			return quote, true
+		}
+	}
+	return NewQuote("", 0), false
+}
+
+// Symbols - the symbol of each member of q, in order
+func (q Quotes) Symbols() []string {
+	symbols := make([]string, len(q))
+	for i, quote := range q {
+		symbols[i] = quote.Symbol
+	}
+	return symbols
+}
+
+// Remove - q without its members of symbol sym, ignoring case. q is not
+// modified.
+func (q Quotes) Remove(sym string) Quotes {
+	out := Quotes{}
+	for _, quote := range q {
+		if !strings.EqualFold(quote.Symbol, sym) {
+			out = append(out, quote)
+		}
+	}
+	return out
+}
+
+// Index - index in q of the first member of each symbol, keyed by the lower
+// case symbol to look up as Symbol does, e.g. q[index[strings.ToLower(sym)]]
+func (q Quotes) Index() map[string]int {
+	index := make(map[string]int, len(q))
+	for i, quote := range q {
+		key := strings.ToLower(quote.Symbol)
+		if _, ok := index[key]; !ok {
+			index[key] = i
+		}
+	}
+	return index
+}
+
+// SortBySymbol - sort the members of q in place by symbol ignoring case,
+// then by symbol, keeping the order of members of the same symbol
+func (q Quotes) SortBySymbol() {
+	sort.SliceStable(q, func(i, j int) bool {
+		a, b := strings.ToLower(q[i].Symbol), strings.ToLower(q[j].Symbol)
+		if a != b {
+			return a < b
+		}
+		return q[i].Symbol < q[j].Symbol
+	})
+}
+
+// inOutputOrder - q in the order the writers emit it, a sorted copy if
+// SortOutput is set
+func (q Quotes) inOutputOrder() Quotes {
+	if !SortOutput || q == nil {
+		return q
+	}
+	sorted := make(Quotes, len(q))
+	copy(sorted, q)
+	sorted.SortBySymbol()
+	return sorted
+}
@@ -0,0 +1,56 @@
+package quote
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuotesSymbol(t *testing.T) {
+	quotes := Quotes{cleanDaily("SPY", 2), cleanDaily("qqq", 3), cleanDaily("spy", 1)}
+
+	q, found := quotes.Symbol("spy")
+	assert(t, found, "spy not found")
+	equals(t, "SPY", q.Symbol)
+	q, found = quotes.Symbol("QQQ")
+	assert(t, found, "QQQ not found")
+	equals(t, 3, len(q.Date))
+	q, found = quotes.Symbol("iwm")
+	assert(t, !found, "iwm found")
+	equals(t, 0, len(q.Date))
+
+	equals(t, []string{"SPY", "qqq", "spy"}, quotes.Symbols())
+	equals(t, map[string]int{"spy": 0, "qqq": 1}, quotes.Index())
+
+	removed := quotes.Remove("Spy")
+	equals(t, []string{"qqq"}, removed.Symbols())
+	equals(t, 3, len(quotes))
+	equals(t, 0, len(quotes.Remove("qqq").Remove("spy")))
+}
+
+func TestSortBySymbol(t *testing.T) {
+	quotes := Quotes{cleanDaily("spy", 1), cleanDaily("AAPL", 1), cleanDaily("SPY", 1), cleanDaily("msft", 2), cleanDaily("msft", 1)}
+	quotes.SortBySymbol()
+	equals(t, []string{"AAPL", "msft", "msft", "SPY", "spy"}, quotes.Symbols())
+	// members of the same symbol keep their order
+	equals(t, 2, len(quotes[1].Date))
+}
+
+func TestSortOutput(t *testing.T) {
+	spy, aapl, msft := cleanDaily("spy", 1), cleanDaily("aapl", 1), cleanDaily("MSFT", 1)
+	a, b := Quotes{spy, aapl, msft}, Quotes{msft, spy, aapl}
+
+	// output is the same whatever the order of the quotes
+	equals(t, a.CSV(), b.CSV())
+	equals(t, a.JSON(false), b.JSON(false))
+	equals(t, a.Highstock(), b.Highstock())
+	csv := a.CSV()
+	assert(t, strings.Index(csv, "\naapl,") < strings.Index(csv, "\nMSFT,") && strings.Index(csv, "\nMSFT,") < strings.Index(csv, "\nspy,"), "unsorted csv %q", csv)
+	equals(t, []string{"spy", "aapl", "MSFT"}, a.Symbols())
+
+	SortOutput = false
+	defer func() { SortOutput = true }()
+	read, err := NewQuotesFromCSV(b.CSV())
+	ok(t, err)
+	equals(t, []string{"MSFT", "spy", "aapl"}, read.Symbols())
+	assert(t, a.JSON(false) != b.JSON(false), "json sorted")
+}
@@ -0,0 +1,108 @@
+package quote
+
+import (
+	"encoding/json"
+)
+
+// lightweight-charts default up and down candle colors
+const (
+	lwcUpColor   = "#26a69a"
+	lwcDownColor = "#ef5350"
+)
+
+// lwcCandle - lightweight-charts CandlestickData
+type lwcCandle struct {
+	Time  interface{} `json:"time"`
+	Open  float64     `json:"open"`
+	High  float64     `json:"high"`
+	Low   float64     `json:"low"`
+	Close float64     `json:"close"`
+}
+
+// lwcVolume - lightweight-charts HistogramData
+type lwcVolume struct {
+	Time  interface{} `json:"time"`
+	Value float64     `json:"value"`
+	Color string      `json:"color,omitempty"`
+}
+
+// lwcSeries - candlestick and volume series of a quote
+type lwcSeries struct {
+	Candles []lwcCandle `json:"candles"`
+	Volume  []lwcVolume `json:"volume"`
+}
+
+// intraday - true if any bar of q has a time of day
+func (q Quote) intraday() bool {
+	for _, d := range q.Date {
+		if h, m, s := d.Clock(); h != 0 || m != 0 || s != 0 || d.Nanosecond() != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lwcSeries - q as lightweight-charts series. Following the library's
+// convention times are "yyyy-mm-dd" business day strings for daily and longer
+// bars, and UTC unix seconds for intraday bars.
+func (q Quote) lwcSeries() lwcSeries {
+	intraday := q.intraday()
+	s := lwcSeries{
+		Candles: make([]lwcCandle, len(q.Close)),
+		Volume:  make([]lwcVolume, len(q.Close)),
+	}
+	for bar := range q.Close {
+		var t interface{} = q.Date[bar].Format("2006-01-02")
+		if intraday {
+			t = q.Date[bar].Unix()
+		}
+		s.Candles[bar] = lwcCandle{t, q.Open[bar], q.High[bar], q.Low[bar], q.Close[bar]}
+		color := lwcUpColor
+		if q.Close[bar] < q.Open[bar] {
+			color = lwcDownColor
+		}
+		s.Volume[bar] = lwcVolume{t, q.Volume[bar], color}
+	}
+	return s
+}
+
+// LightweightCharts - convert Quote to TradingView lightweight-charts json,
+// an object with "candles" (candlestick series data) and "volume" (histogram
+// series data) arrays
+func (q Quote) LightweightCharts() string {
+	j, _ := json.Marshal(q.lwcSeries())
+	return string(j)
+}
+
+// WriteLightweightCharts - write Quote to a TradingView lightweight-charts json file
+func (q Quote) WriteLightweightCharts(filename string) error {
+	if filename == "" {
+		if q.Symbol != "" {
+			filename = q.Symbol + ".json"
+		} else {
+			filename = "quote.json"
+		}
+	}
+	lwc := q.LightweightCharts()
+	return writeFile(filename, []byte(lwc))
+}
+
+// LightweightCharts - convert Quotes to an object keyed by symbol of
+// TradingView lightweight-charts json, see Quote.LightweightCharts
+func (q Quotes) LightweightCharts() string {
+	m := make(map[string]lwcSeries, len(q))
+	for sym, quote := range q.bySymbol() {
+		m[sym] = quote.lwcSeries()
+	}
+	j, _ := json.Marshal(m)
+	return string(j)
+}
+
+// WriteLightweightCharts - write Quotes to a TradingView lightweight-charts json file
+func (q Quotes) WriteLightweightCharts(filename string) error {
+	if filename == "" {
+		filename = "quotes.json"
+	}
+	lwc := q.LightweightCharts()
+	return writeFile(filename, []byte(lwc))
+}
@@ -0,0 +1,57 @@
+package quote
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLightweightChartsDaily(t *testing.T) {
+	q := NewQuote("aapl", 2)
+	q.Date[0] = time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)
+	q.Date[1] = time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC)
+	q.Open = []float64{130.28, 126.89}
+	q.High = []float64{130.9, 128.6557}
+	q.Low = []float64{124.17, 125.08}
+	q.Close = []float64{125.07, 127.36}
+	q.Volume = []float64{112117471, 89113633}
+
+	equals(t, `{"candles":[`+
+		`{"time":"2023-01-03","open":130.28,"high":130.9,"low":124.17,"close":125.07},`+
+		`{"time":"2023-01-04","open":126.89,"high":128.6557,"low":125.08,"close":127.36}],`+
+		`"volume":[`+
+		`{"time":"2023-01-03","value":112117471,"color":"#ef5350"},`+
+		`{"time":"2023-01-04","value":89113633,"color":"#26a69a"}]}`, q.LightweightCharts())
+}
+
+func TestLightweightChartsIntraday(t *testing.T) {
+	q := NewQuote("btc-usd", 2)
+	q.Date[0] = time.Date(2023, 1, 3, 14, 30, 0, 0, time.UTC)
+	q.Date[1] = time.Date(2023, 1, 3, 14, 35, 0, 0, time.UTC)
+	q.Open = []float64{16700.123456, 16710}
+	q.High = []float64{16720, 16715}
+	q.Low = []float64{16690, 16705}
+	q.Close = []float64{16710, 16712.5}
+	q.Volume = []float64{1.25, 0.5}
+
+	var s struct {
+		Candles []struct {
+			Time int64   `json:"time"`
+			Open float64 `json:"open"`
+		} `json:"candles"`
+		Volume []struct {
+			Time  int64   `json:"time"`
+			Value float64 `json:"value"`
+		} `json:"volume"`
+	}
+	ok(t, json.Unmarshal([]byte(q.LightweightCharts()), &s))
+	equals(t, int64(1672756200), s.Candles[0].Time)
+	equals(t, int64(1672756500), s.Volume[1].Time)
+	// full precision, not truncated to the symbol precision
+	equals(t, 16700.123456, s.Candles[0].Open)
+	equals(t, 1.25, s.Volume[0].Value)
+
+	all := Quotes{q}.LightweightCharts()
+	assert(t, strings.HasPrefix(all, `{"btc-usd":{"candles":[`), "unexpected %s", all)
+}
@@ -0,0 +1,130 @@
+package quote
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MergePolicy - which bar Merge keeps where both quotes have a bar at the same date
+type MergePolicy int
+
+const (
+	// PreferExisting - keep the bar of the quote merged into
+	PreferExisting MergePolicy = iota
+	// PreferNew - keep the bar of the quote merged in, e.g. to update a still
+	// forming candle
+	PreferNew
+	// ErrorOnOverlap - fail the merge if the quotes share a date
+	ErrorOnOverlap
+)
+
+// MergeOptions - options of MergeWithOptions
+type MergeOptions struct {
+	Policy MergePolicy // bars of both quotes at the same date
+	Force  bool        // merge quotes of different symbols, keeping the symbol merged into
+}
+
+// DefaultMergeOptions - options of Merge, keeping existing bars
+var DefaultMergeOptions = MergeOptions{Policy: PreferExisting}
+
+// Merge - q and the bars of other in one quote in ascending date order, one
+// bar per date chosen by policy. Either quote may be in any order. The
+// symbols must match.
+func (q Quote) Merge(other Quote, policy MergePolicy) (Quote, error) {
+	opts := DefaultMergeOptions
+	opts.Policy = policy
+	return q.MergeWithOptions(other, opts)
+}
+
+// MergeWithOptions - Merge with the options of opts. Of several bars at a
+// date within one quote the last is kept. The result has the metadata and
+// precision of q, or of other where q has none, and does not share arrays
+// with either quote.
+func (q Quote) MergeWithOptions(other Quote, opts MergeOptions) (Quote, error) {
+	if q.Symbol != other.Symbol && !opts.Force {
+		return Quote{}, fmt.Errorf("merge of %s into %s, symbols differ", other.Symbol, q.Symbol)
+	}
+
+	type ref struct {
+		quote *Quote
+		bar   int
+	}
+	refs := make([]ref, 0, len(q.Date)+len(other.Date))
+	for bar := range q.Date {
+		refs = append(refs, ref{&q, bar})
+	}
+	for bar := range other.Date {
+		refs = append(refs, ref{&other, bar})
+	}
+	date := func(r ref) time.Time { return r.quote.Date[r.bar] }
+	sort.SliceStable(refs, func(i, j int) bool { return date(refs[i]).Before(date(refs[j])) })
+
+	out := NewQuote(q.Symbol, 0)
+	out.Precision, out.Meta = q.Precision, q.Meta
+	if out.Precision == 0 {
+		out.Precision = other.Precision
+	}
+	if out.Meta == nil {
+		out.Meta = other.Meta
+	}
+	if out.Meta != nil {
+		meta := *out.Meta
+		meta.Symbol = q.Symbol
+		out.Meta = &meta
+	}
+
+	for start := 0; start < len(refs); {
+		// the bars at one date, those of q first
+		end := start + 1
+		for end < len(refs) && date(refs[end]).Equal(date(refs[start])) {
+			end++
+		}
+		keep := refs[end-1]
+		if keep.quote != refs[start].quote {
+			switch opts.Policy {
+			case PreferExisting:
+				keep = refs[start]
+				for _, r := range refs[start:end] {
+					if r.quote == &q {
+						keep = r
+					}
+				}
+			case ErrorOnOverlap:
+				return Quote{}, fmt.Errorf("%s bars overlap at %s", q.Symbol, date(keep).Format(time.RFC3339))
+			}
+		}
+		out.appendBar(*keep.quote, keep.bar)
+		start = end
+	}
+	return out, nil
+}
+
+// MergeBySymbol - each member of q merged with the member of other of the
+// same symbol as Merge does, followed by the members of other whose symbol is
+// not in q, in the order of other. Members without a counterpart are kept as
+// they are.
+func (q Quotes) MergeBySymbol(other Quotes, policy MergePolicy) (Quotes, error) {
+	out := make(Quotes, len(q))
+	index := make(map[string]int, len(q))
+	for i, quote := range q {
+		out[i] = quote
+		if _, ok := index[quote.Symbol]; !ok {
+			index[quote.Symbol] = i
+		}
+	}
+	for _, quote := range other {
+		i, ok := index[quote.Symbol]
+		if !ok {
+			index[quote.Symbol] = len(out)
+			out = append(out, quote)
+			continue
+		}
+		merged, err := out[i].Merge(quote, policy)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = merged
+	}
+	return out, nil
+}
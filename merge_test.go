@@ -0,0 +1,144 @@
+package quote
+
+import "testing"
+
+// mergeFixture - daily spy bars on the given days of january 2023, closing
+// at close plus the day
+func mergeFixture(close float64, days ...int) Quote {
+	q := NewQuote("spy", 0)
+	for _, d := range days {
+		c := close + float64(d)
+		q.PushBar(Bar{Date: day(2023, 1, d), Open: c, High: c, Low: c, Close: c, Volume: 1})
+	}
+	return q
+}
+
+// mergeDays - days of the month of the bars of q
+func mergeDays(q Quote) []int {
+	days := make([]int, len(q.Date))
+	for i, d := range q.Date {
+		days[i] = d.Day()
+	}
+	return days
+}
+
+func TestMerge(t *testing.T) {
+	for _, c := range []struct {
+		name              string
+		existing, new     []int
+		days              []int
+		existingWins      []float64 // closes with PreferExisting
+		newWins           []float64 // closes with PreferNew
+		overlapErrorFails bool
+	}{
+		{"disjoint", []int{2, 3}, []int{5, 6}, []int{2, 3, 5, 6},
+			[]float64{102, 103, 205, 206}, []float64{102, 103, 205, 206}, false},
+		{"disjoint before", []int{5, 6}, []int{2, 3}, []int{2, 3, 5, 6},
+			[]float64{202, 203, 105, 106}, []float64{202, 203, 105, 106}, false},
+		{"overlapping", []int{2, 3, 4}, []int{4, 5}, []int{2, 3, 4, 5},
+			[]float64{102, 103, 104, 205}, []float64{102, 103, 204, 205}, true},
+		{"identical", []int{2, 3}, []int{2, 3}, []int{2, 3},
+			[]float64{102, 103}, []float64{202, 203}, true},
+		{"contained", []int{2, 3, 4, 5}, []int{3, 4}, []int{2, 3, 4, 5},
+			[]float64{102, 103, 104, 105}, []float64{102, 203, 204, 105}, true},
+		{"interleaved", []int{2, 4, 6}, []int{3, 5, 7}, []int{2, 3, 4, 5, 6, 7},
+			[]float64{102, 203, 104, 205, 106, 207}, []float64{102, 203, 104, 205, 106, 207}, false},
+		{"interleaved overlapping", []int{2, 4, 6}, []int{3, 4, 5}, []int{2, 3, 4, 5, 6},
+			[]float64{102, 203, 104, 205, 106}, []float64{102, 203, 204, 205, 106}, true},
+		{"unsorted", []int{6, 2, 4}, []int{5, 3}, []int{2, 3, 4, 5, 6},
+			[]float64{102, 203, 104, 205, 106}, []float64{102, 203, 104, 205, 106}, false},
+		{"empty existing", nil, []int{2, 3}, []int{2, 3},
+			[]float64{202, 203}, []float64{202, 203}, false},
+		{"empty new", []int{2, 3}, nil, []int{2, 3},
+			[]float64{102, 103}, []float64{102, 103}, false},
+		{"both empty", nil, nil, []int{}, []float64{}, []float64{}, false},
+	} {
+		existing, other := mergeFixture(100, c.existing...), mergeFixture(200, c.new...)
+		for _, p := range []struct {
+			policy MergePolicy
+			closes []float64
+		}{{PreferExisting, c.existingWins}, {PreferNew, c.newWins}} {
+			q, err := existing.Merge(other, p.policy)
+			ok(t, err)
+			equals(t, c.days, mergeDays(q))
+			equals(t, p.closes, q.Close)
+			equals(t, len(q.Date), len(q.Volume))
+		}
+		q, err := existing.Merge(other, ErrorOnOverlap)
+		if c.overlapErrorFails {
+			assert(t, err != nil, "%s: overlap merged", c.name)
+			continue
+		}
+		ok(t, err)
+		equals(t, c.existingWins, q.Close)
+	}
+}
+
+func TestMergeWithOptions(t *testing.T) {
+	existing, other := mergeFixture(100, 2, 3), mergeFixture(200, 3, 4)
+
+	// bars repeated within a quote keep the last
+	dup := mergeFixture(300, 4, 4)
+	dup.Close[1] = 999
+	q, err := existing.Merge(dup, ErrorOnOverlap)
+	ok(t, err)
+	equals(t, []float64{102, 103, 999}, q.Close)
+
+	// the result does not share arrays
+	q, err = existing.Merge(other, PreferNew)
+	ok(t, err)
+	q.Close[0] = 0
+	equals(t, 102.0, existing.Close[0])
+
+	// optional columns only one quote carries are zero filled
+	other.Trades = []float64{7, 8}
+	q, err = existing.Merge(other, PreferExisting)
+	ok(t, err)
+	equals(t, []float64{0, 0, 8}, q.Trades)
+	equals(t, []float64(nil), q.OpenInterest)
+
+	// metadata and precision of the existing quote, else of the new one
+	other.Meta, other.Precision = &Metadata{Symbol: "spy", Period: Daily}, 4
+	q, err = existing.Merge(other, PreferExisting)
+	ok(t, err)
+	equals(t, Daily, q.Meta.Period)
+	equals(t, int64(4), q.Precision)
+	existing.Meta = &Metadata{Symbol: "spy", Source: "yahoo"}
+	q, err = existing.Merge(other, PreferExisting)
+	ok(t, err)
+	equals(t, "yahoo", q.Meta.Source)
+
+	// symbols must match unless forced
+	other.Symbol = "SPY"
+	_, err = existing.Merge(other, PreferExisting)
+	assert(t, err != nil, "different symbols merged")
+	q, err = existing.MergeWithOptions(other, MergeOptions{Policy: PreferNew, Force: true})
+	ok(t, err)
+	equals(t, "spy", q.Symbol)
+	equals(t, "spy", q.Meta.Symbol)
+	equals(t, []float64{102, 203, 204}, q.Close)
+}
+
+func TestMergeBySymbol(t *testing.T) {
+	spy := mergeFixture(100, 2, 3)
+	qqq := mergeFixture(100, 2)
+	qqq.Symbol = "qqq"
+	newSpy := mergeFixture(200, 3, 4)
+	btc := mergeFixture(200, 5)
+	btc.Symbol = "btc"
+
+	merged, err := Quotes{spy, qqq}.MergeBySymbol(Quotes{btc, newSpy}, PreferNew)
+	ok(t, err)
+	equals(t, 3, len(merged))
+	equals(t, "spy", merged[0].Symbol)
+	equals(t, []float64{102, 203, 204}, merged[0].Close)
+	equals(t, qqq, merged[1])
+	equals(t, btc, merged[2])
+
+	_, err = Quotes{spy}.MergeBySymbol(Quotes{newSpy}, ErrorOnOverlap)
+	assert(t, err != nil, "overlapping members merged")
+
+	merged, err = Quotes{}.MergeBySymbol(Quotes{spy, spy}, PreferExisting)
+	ok(t, err)
+	equals(t, Quotes{spy}, merged)
+}
@@ -0,0 +1,172 @@
+package quote
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Version - go-quote version, recorded in metadata
+const Version = "0.3"
+
+// Metadata - provenance of a Quote
+type Metadata struct {
+	Symbol       string    `json:"symbol"`
+	Source       string    `json:"source,omitempty"`
+	Period       Period    `json:"period,omitempty"`
+	Adjusted     bool      `json:"adjusted"`
+	Currency     string    `json:"currency,omitempty"` // quote currency, when the symbol tells
+	Timezone     string    `json:"timezone,omitempty"` // location of the bar timestamps
+	DownloadedAt time.Time `json:"downloaded_at"`
+	Generator    string    `json:"generator"`
+}
+
+// metaEnvelope - json layout of a Quote with metadata
+type metaEnvelope struct {
+	Meta *Metadata       `json:"meta"`
+	Data json.RawMessage `json:"data"`
+}
+
+// quoteCurrencies - quote currencies recognized as crypto symbol suffixes, longest first
+var quoteCurrencies = []string{"USDT", "USDC", "BUSD", "USD", "EUR", "GBP", "BTC", "ETH", "BNB"}
+
+// symbolCurrency - quote currency of a symbol of source, empty if unknown
+func symbolCurrency(source, symbol string) string {
+	sym := strings.ToUpper(symbol)
+	switch source {
+	case "moex":
+		return "RUB"
+	case "cryptocompare", "coingecko":
+		// BTC/USD and BITCOIN:USD are priced in USD
+		if i := strings.LastIndexAny(sym, "/:"); i >= 0 {
+			return sym[i+1:]
+		}
+	case "coinbase", "bittrex":
+		// BTC-USD, ETH-BTC is priced in BTC
+		if i := strings.LastIndex(sym, "-"); i >= 0 {
+			return sym[i+1:]
+		}
+	case "tiingo-fx", "ecb":
+		// EURUSD is priced in USD
+		if len(sym) == 6 {
+			return sym[3:]
+		}
+		if source == "ecb" && len(sym) == 3 {
+			// USD per euro
+			return sym
+		}
+	case "binance", "binance-futures", "tiingo-crypto", "huobi":
+		for _, c := range quoteCurrencies {
+			if strings.HasSuffix(sym, c) && len(sym) > len(c) {
+				return c
+			}
+		}
+	}
+	return ""
+}
+
+// withMeta - q with metadata for a download from source, in
+// IntradayLocation if set
+func (q Quote) withMeta(source string, period Period, adjusted bool) Quote {
+	q = q.inIntradayLocation(period)
+	tz := "UTC"
+	if len(q.Date) > 0 {
+		tz = q.Date[0].Location().String()
+	}
+	q.Meta = &Metadata{
+		Symbol:       q.Symbol,
+		Source:       source,
+		Period:       period,
+		Adjusted:     adjusted,
+		Currency:     symbolCurrency(source, q.Symbol),
+		Timezone:     tz,
+		DownloadedAt: time.Now().UTC(),
+		Generator:    "go-quote/" + Version,
+	}
+	return q
+}
+
+// metadata - metadata of q, a minimal block when q has none
+func (q Quote) metadata() *Metadata {
+	if q.Meta != nil {
+		return q.Meta
+	}
+	return &Metadata{Symbol: q.Symbol, Generator: "go-quote/" + Version}
+}
+
+// MetaJSON - convert Quote to json with a metadata envelope,
+// {"meta": {...}, "data": {...quote...}}
+func (q Quote) MetaJSON(indent bool) string {
+	env := metaEnvelope{Meta: q.metadata(), Data: json.RawMessage(q.JSON(false))}
+	var j []byte
+	if indent {
+		j, _ = json.MarshalIndent(env, "", "  ")
+	} else {
+		j, _ = json.Marshal(env)
+	}
+	return string(j)
+}
+
+// WriteMetaJSON - write Quote to json file with a metadata envelope
+func (q Quote) WriteMetaJSON(filename string, indent bool) error {
+	if filename == "" {
+		if q.Symbol != "" {
+			filename = q.Symbol + ".json"
+		} else {
+			filename = "quote.json"
+		}
+	}
+	jsn := q.MetaJSON(indent)
+	return writeFile(filename, []byte(jsn))
+}
+
+// MetaJSON - convert Quotes to a json array of metadata envelopes
+func (q Quotes) MetaJSON(indent bool) string {
+	envs := make([]metaEnvelope, len(q))
+	for i, quote := range q {
+		envs[i] = metaEnvelope{Meta: quote.metadata(), Data: json.RawMessage(quote.JSON(false))}
+	}
+	var j []byte
+	if indent {
+		j, _ = json.MarshalIndent(envs, "", "  ")
+	} else {
+		j, _ = json.Marshal(envs)
+	}
+	return string(j)
+}
+
+// WriteMetaJSON - write Quotes to json file as an array of metadata envelopes
+func (q Quotes) WriteMetaJSON(filename string, indent bool) error {
+	if filename == "" {
+		filename = "quotes.json"
+	}
+	jsn := q.MetaJSON(indent)
+	return writeFile(filename, []byte(jsn))
+}
+
+// quoteJSON - a json quote, either bare or in a metadata envelope
+type quoteJSON struct {
+	Quote
+	Meta *Metadata `json:"meta"`
+	Data *Quote    `json:"data"`
+}
+
+// quote - the decoded quote, with the metadata of its envelope
+func (j quoteJSON) quote() Quote {
+	if j.Data == nil {
+		return j.Quote
+	}
+	q := *j.Data
+	q.Meta = j.Meta
+	return q
+}
+
+// jsonEnd - an error unless only white space follows the value decoded by dec
+func jsonEnd(dec *json.Decoder) error {
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("unexpected data after json value")
+	}
+	return nil
+}
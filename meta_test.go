@@ -0,0 +1,55 @@
+package quote
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetaJSON(t *testing.T) {
+	q := cleanDaily("btc-usd", 3).withMeta("coinbase", Daily, false)
+	equals(t, "USD", q.Meta.Currency)
+	equals(t, "UTC", q.Meta.Timezone)
+	equals(t, "go-quote/"+Version, q.Meta.Generator)
+
+	jsn := q.MetaJSON(true)
+	var env map[string]json.RawMessage
+	ok(t, json.Unmarshal([]byte(jsn), &env))
+	assert(t, env["meta"] != nil && env["data"] != nil, "not an envelope: %s", jsn)
+	assert(t, strings.Contains(string(env["meta"]), `"downloaded_at"`), "missing download time: %s", env["meta"])
+
+	// enveloped read path
+	back, err := NewQuoteFromJSON(jsn)
+	ok(t, err)
+	equals(t, q.Close, back.Close)
+	equals(t, "coinbase", back.Meta.Source)
+	equals(t, Daily, back.Meta.Period)
+	assert(t, back.Meta.DownloadedAt.Sub(q.Meta.DownloadedAt) < time.Second, "download time %v", back.Meta.DownloadedAt)
+
+	// bare read path
+	back, err = NewQuoteFromJSON(q.JSON(false))
+	ok(t, err)
+	equals(t, q.Close, back.Close)
+	assert(t, back.Meta == nil, "unexpected metadata %v", back.Meta)
+
+	// quotes, mixing both forms
+	quotes, err := NewQuotesFromJSON("[" + q.MetaJSON(false) + "," + cleanDaily("spy", 2).JSON(false) + "]")
+	ok(t, err)
+	equals(t, 2, len(quotes))
+	equals(t, "coinbase", quotes[0].Meta.Source)
+	equals(t, 2, len(quotes[1].Close))
+
+	quotes, err = NewQuotesFromJSON(Quotes{q, cleanDaily("spy", 2)}.MetaJSON(false))
+	ok(t, err)
+	equals(t, "spy", quotes[1].Meta.Symbol)
+}
+
+func TestSymbolCurrency(t *testing.T) {
+	equals(t, "USD", symbolCurrency("coinbase", "btc-usd"))
+	equals(t, "BTC", symbolCurrency("bittrex", "ETH-BTC"))
+	equals(t, "USDT", symbolCurrency("binance", "BTCUSDT"))
+	equals(t, "EUR", symbolCurrency("cryptocompare", "ETH/EUR"))
+	equals(t, "USD", symbolCurrency("tiingo-crypto", "btcusd"))
+	equals(t, "", symbolCurrency("yahoo", "spy"))
+}
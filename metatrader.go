@@ -0,0 +1,138 @@
+package quote
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetaTraderOptions - layout of MetaTrader history csv files
+type MetaTraderOptions struct {
+	Separator rune           // field separator, ',' or '\t'
+	Location  *time.Location // time zone of intraday bar times, UTC if nil
+	Precision int            // decimals of prices, FullPrecision, or as Quote.CSV when 0
+}
+
+// DefaultMetaTraderOptions - comma separated UTC times, as imported by the
+// MT4 history center
+var DefaultMetaTraderOptions = MetaTraderOptions{Separator: ','}
+
+// location - time zone of intraday bar times
+func (opts MetaTraderOptions) location() *time.Location {
+	if opts.Location == nil {
+		return time.UTC
+	}
+	return opts.Location
+}
+
+// MetaTrader - convert Quote structure to MetaTrader history csv,
+// YYYY.MM.DD,HH:MM,open,high,low,close,volume. The time of daily bars is
+// 00:00.
+func (q Quote) MetaTrader() string {
+	return q.MetaTraderWithOptions(DefaultMetaTraderOptions)
+}
+
+// MetaTraderWithOptions - convert Quote structure to MetaTrader history csv
+// laid out as opts, intraday bars at their time in opts.Location
+func (q Quote) MetaTraderWithOptions(opts MetaTraderOptions) string {
+
+	sep := string(opts.Separator)
+	if opts.Separator == 0 {
+		sep = ","
+	}
+	loc := opts.location()
+	precision := q.precision(opts.Precision)
+	intraday := q.intraday()
+
+	var buffer bytes.Buffer
+	for bar := range q.Close {
+		date := q.Date[bar]
+		tod := "00:00"
+		if intraday {
+			date = date.In(loc)
+			tod = date.Format("15:04")
+		}
+		buffer.WriteString(strings.Join([]string{
+			date.Format("2006.01.02"),
+			tod,
+			strconv.FormatFloat(q.Open[bar], 'f', precision, 64),
+			strconv.FormatFloat(q.High[bar], 'f', precision, 64),
+			strconv.FormatFloat(q.Low[bar], 'f', precision, 64),
+			strconv.FormatFloat(q.Close[bar], 'f', precision, 64),
+			strconv.FormatFloat(q.Volume[bar], 'f', -1, 64),
+		}, sep))
+		buffer.WriteString("\n")
+	}
+	return buffer.String()
+}
+
+// WriteMetaTrader - write Quote struct to MetaTrader history csv file
+func (q Quote) WriteMetaTrader(filename string) error {
+	return q.WriteMetaTraderWithOptions(filename, DefaultMetaTraderOptions)
+}
+
+// WriteMetaTraderWithOptions - write Quote struct to MetaTrader history csv
+// file laid out as opts
+func (q Quote) WriteMetaTraderWithOptions(filename string, opts MetaTraderOptions) error {
+	if filename == "" {
+		if q.Symbol != "" {
+			filename = q.Symbol + ".csv"
+		} else {
+			filename = "quote.csv"
+		}
+	}
+	csv := q.MetaTraderWithOptions(opts)
+	return writeFile(filename, []byte(csv))
+}
+
+// NewQuoteFromMetaTraderCSV - parse MetaTrader history csv, comma or tab
+// separated, into Quote structure. Exports of MT5 with a <DATE> header and
+// tick volume, volume and spread columns are read too, taking the tick
+// volume as volume. Times are UTC.
+func NewQuoteFromMetaTraderCSV(symbol, csv string) (Quote, error) {
+	return NewQuoteFromMetaTraderCSVWithOptions(symbol, csv, DefaultMetaTraderOptions)
+}
+
+// NewQuoteFromMetaTraderCSVWithOptions - parse MetaTrader history csv into
+// Quote structure, intraday times taken in opts.Location. Files of only
+// 00:00 times are daily bars, dated at midnight UTC. The separator is
+// detected.
+func NewQuoteFromMetaTraderCSVWithOptions(symbol, csv string, opts MetaTraderOptions) (Quote, error) {
+
+	q := NewQuote(symbol, 0)
+	for n, line := range strings.Split(csv, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "<") {
+			continue
+		}
+		sep := ","
+		if strings.Contains(line, "\t") {
+			sep = "\t"
+		}
+		fields := strings.Split(line, sep)
+		if len(fields) < 7 {
+			return NewQuote("", 0), fmt.Errorf("line %d: %d fields, want at least 7", n+1, len(fields))
+		}
+		date, err := time.Parse("2006.01.02 15:04", fields[0]+" "+fields[1])
+		if err != nil {
+			return NewQuote("", 0), fmt.Errorf("line %d: %v", n+1, err)
+		}
+		var ohlcv [5]float64
+		for i := range ohlcv {
+			if ohlcv[i], err = strconv.ParseFloat(strings.TrimSpace(fields[2+i]), 64); err != nil {
+				return NewQuote("", 0), fmt.Errorf("line %d: %v", n+1, err)
+			}
+		}
+		q.PushBar(Bar{Date: date, Open: ohlcv[0], High: ohlcv[1], Low: ohlcv[2], Close: ohlcv[3], Volume: ohlcv[4]})
+	}
+
+	// wall clock times so far
+	if loc := opts.location(); q.intraday() && loc != time.UTC {
+		for bar, d := range q.Date {
+			q.Date[bar] = time.Date(d.Year(), d.Month(), d.Day(), d.Hour(), d.Minute(), 0, 0, loc).UTC()
+		}
+	}
+	return q, nil
+}
@@ -0,0 +1,65 @@
+package quote
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// metaTraderIntraday - hourly bars of eurusd across midnight UTC
+func metaTraderIntraday() Quote {
+	q := NewQuote("eurusd", 0)
+	for i := 0; i < 3; i++ {
+		d := time.Date(2023, 3, 1, 23, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Hour)
+		q.PushBar(Bar{Date: d, Open: 1.0605 + float64(i)/1e4, High: 1.061, Low: 1.06, Close: 1.0607, Volume: 1520 + float64(i)})
+	}
+	return q
+}
+
+func TestMetaTrader(t *testing.T) {
+	q := metaTraderIntraday()
+	equals(t, "2023.03.01,23:00,1.06050000,1.06100000,1.06000000,1.06070000,1520\n"+
+		"2023.03.02,00:00,1.06060000,1.06100000,1.06000000,1.06070000,1521\n"+
+		"2023.03.02,01:00,1.06070000,1.06100000,1.06000000,1.06070000,1522\n", q.MetaTrader())
+
+	// athens is two hours ahead of UTC in march
+	athens, err := time.LoadLocation("Europe/Athens")
+	ok(t, err)
+	lines := strings.Split(q.MetaTraderWithOptions(MetaTraderOptions{Separator: '\t', Location: athens}), "\n")
+	equals(t, "2023.03.02\t01:00\t1.06050000\t1.06100000\t1.06000000\t1.06070000\t1520", lines[0])
+
+	daily := NewQuote("spy", 0)
+	daily.PushBar(Bar{Date: day(2023, 3, 1), Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 100})
+	equals(t, "2023.03.01,00:00,1.00,2.00,0.50,1.50,100\n", daily.MetaTraderWithOptions(MetaTraderOptions{Location: athens}))
+}
+
+func TestMetaTraderRoundTrip(t *testing.T) {
+	intraday := metaTraderIntraday()
+	daily := NewQuote("spy", 0)
+	for i := 0; i < 3; i++ {
+		daily.PushBar(Bar{Date: day(2023, 3, 1+i), Open: 1, High: 2, Low: 0.5, Close: 1.5 + float64(i), Volume: 100.5})
+	}
+	athens, err := time.LoadLocation("Europe/Athens")
+	ok(t, err)
+
+	for _, opts := range []MetaTraderOptions{DefaultMetaTraderOptions, {Separator: '\t'}, {Separator: ',', Location: athens}} {
+		for _, q := range []Quote{intraday, daily} {
+			back, err := NewQuoteFromMetaTraderCSVWithOptions(q.Symbol, q.MetaTraderWithOptions(opts), opts)
+			ok(t, err)
+			equals(t, q, back)
+		}
+	}
+
+	// an MT5 export with header, tick volume, volume and spread
+	mt5 := "<DATE>\t<TIME>\t<OPEN>\t<HIGH>\t<LOW>\t<CLOSE>\t<TICKVOL>\t<VOL>\t<SPREAD>\n" +
+		"2023.03.01\t23:00\t1.0605\t1.061\t1.06\t1.0607\t1520\t0\t2\n"
+	q, err := NewQuoteFromMetaTraderCSV("eurusd", mt5)
+	ok(t, err)
+	equals(t, intraday.Date[:1], q.Date)
+	equals(t, []float64{1520}, q.Volume)
+
+	_, err = NewQuoteFromMetaTraderCSV("spy", "2023.03.01,00:00,1,2,0.5,1.5\n")
+	assert(t, err != nil, "expected field count error")
+	_, err = NewQuoteFromMetaTraderCSV("spy", "2023-03-01,00:00,1,2,0.5,1.5,100\n")
+	assert(t, err != nil, "expected date error")
+}
@@ -0,0 +1,192 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// moexURL - base url of the Moscow Exchange ISS api
+var moexURL = "https://iss.moex.com"
+
+// moexBoard - board of symbols without an @BOARD suffix
+const moexBoard = "TQBR"
+
+// moexPeriods - candle intervals of the ISS api
+var moexPeriods = []Period{Min1, Min60, Daily, Weekly, Monthly}
+
+// moexIntervals - interval of each supported period
+var moexIntervals = map[Period]int{
+	Min1:    1,
+	Min60:   60,
+	Daily:   24,
+	Weekly:  7,
+	Monthly: 31,
+}
+
+// moexMarkets - engine and market path of boards outside the stock shares
+// market
+var moexMarkets = map[string]string{
+	"RFUD": "futures/markets/forts",
+	"TQOB": "stock/markets/bonds",
+	"CETS": "currency/markets/selt",
+}
+
+// moexSecurity - security id, board and engine/market path of a symbol such
+// as GAZP or SiH3@RFUD
+func moexSecurity(symbol string) (string, string, string) {
+	secid, board := symbol, moexBoard
+	if i := strings.Index(symbol, "@"); i >= 0 {
+		secid, board = symbol[:i], strings.ToUpper(symbol[i+1:])
+	}
+	market, ok := moexMarkets[board]
+	if !ok {
+		market = "stock/markets/shares"
+	}
+	return secid, board, market
+}
+
+// moexTable - an ISS table, rows of values in the order of columns
+type moexTable struct {
+	Columns []string        `json:"columns"`
+	Data    [][]interface{} `json:"data"`
+}
+
+// NewQuoteFromMOEX - Moscow Exchange historical candles for a symbol, on the
+// TQBR board or the board after an @, e.g. SiH3@RFUD for a futures contract.
+// Bars are timestamped in Moscow wall clock time. Pages are requested with
+// Delay between them.
+func NewQuoteFromMOEX(symbol, startDate, endDate string, period Period) (Quote, error) {
+	return NewQuoteFromMOEXCtx(context.Background(), symbol, startDate, endDate, period)
+}
+
+// NewQuoteFromMOEXCtx - NewQuoteFromMOEX, canceled with ctx. A download
+// canceled between its pages returns the bars so far with the wrapped error of
+// ctx.
+func NewQuoteFromMOEXCtx(ctx context.Context, symbol, startDate, endDate string, period Period) (Quote, error) {
+
+	interval, ok := moexIntervals[period]
+	if !ok {
+		return NewQuote("", 0), ValidatePeriod("moex", period)
+	}
+	secid, board, market := moexSecurity(symbol)
+	from := ParseDateString(startDate)
+	to := parseEndDate(endDate)
+
+	quote := NewQuote(symbol, 0)
+	candles := func() Quote {
+		return quote.between(from, to).withMeta("moex", period, false)
+	}
+	for page, start := 0, 0; ; page++ {
+		if page > 0 && delayContext(ctx, "moex") != nil {
+			return candles(), interrupted(ctx, "moex %s after %d pages", symbol, page)
+		}
+		params := url.Values{}
+		params.Set("from", from.Format("2006-01-02"))
+		params.Set("till", to.Format("2006-01-02"))
+		params.Set("interval", strconv.Itoa(interval))
+		params.Set("start", strconv.Itoa(start))
+		u := fmt.Sprintf("%s/iss/engines/%s/boards/%s/securities/%s/candles.json?%s",
+			moexURL, market, board, url.PathEscape(secid), params.Encode())
+
+		if err := waitRate(ctx, "moex"); err != nil {
+			return candles(), interrupted(ctx, "moex %s after %d pages", symbol, page)
+		}
+		client := httpClient()
+		resp, err := getContext(ctx, client, u)
+		if err != nil && ctx.Err() != nil {
+			return candles(), interrupted(ctx, "moex %s after %d pages", symbol, page)
+		}
+		if err != nil {
+			Log.Printf("moex error: %v\n", err)
+			return NewQuote("", 0), err
+		}
+		contents, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return NewQuote("", 0), fmt.Errorf("moex %s: %s", symbol, resp.Status)
+		}
+
+		bars, err := parseMOEX(symbol, contents)
+		if err != nil {
+			Log.Printf("moex %s error: %v\n", symbol, err)
+			return NewQuote("", 0), err
+		}
+		if len(bars.Date) == 0 {
+			break
+		}
+		quote.appendQuote(bars)
+		start += len(bars.Date)
+	}
+	return candles(), nil
+}
+
+// parseMOEX - bars of a candles response, whose columns are found by name
+func parseMOEX(symbol string, contents []byte) (Quote, error) {
+
+	var res struct {
+		Candles moexTable `json:"candles"`
+	}
+	if err := json.Unmarshal(contents, &res); err != nil {
+		return NewQuote("", 0), err
+	}
+	col := map[string]int{}
+	for i, name := range res.Candles.Columns {
+		col[name] = i
+	}
+	for _, name := range []string{"begin", "open", "high", "low", "close", "volume"} {
+		if _, ok := col[name]; !ok {
+			return NewQuote("", 0), fmt.Errorf("moex %s: no %s column", symbol, name)
+		}
+	}
+
+	quote := NewQuote(symbol, len(res.Candles.Data))
+	for bar, row := range res.Candles.Data {
+		if len(row) != len(res.Candles.Columns) {
+			return NewQuote("", 0), fmt.Errorf("moex %s: row %d has %d values for %d columns", symbol, bar, len(row), len(res.Candles.Columns))
+		}
+		begin, _ := row[col["begin"]].(string)
+		date, err := time.Parse("2006-01-02 15:04:05", begin)
+		if err != nil {
+			return NewQuote("", 0), err
+		}
+		quote.Date[bar] = date
+		quote.Open[bar], _ = row[col["open"]].(float64)
+		quote.High[bar], _ = row[col["high"]].(float64)
+		quote.Low[bar], _ = row[col["low"]].(float64)
+		quote.Close[bar], _ = row[col["close"]].(float64)
+		quote.Volume[bar], _ = row[col["volume"]].(float64)
+	}
+	return quote, nil
+}
+
+// NewQuotesFromMOEXSyms - create a list of prices from symbols in string array
+func NewQuotesFromMOEXSyms(symbols []string, startDate, endDate string, period Period) (Quotes, error) {
+	return NewQuotesFromMOEXSymsCtx(context.Background(), symbols, startDate, endDate, period)
+}
+
+// NewQuotesFromMOEXSymsCtx - NewQuotesFromMOEXSyms, canceled with ctx.
+// The quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromMOEXSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromMOEXCtx(ctx, symbol, startDate, endDate, period)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "moex after %d of %d symbols", i, len(symbols))
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + symbol)
+		}
+		delayContext(ctx, "moex")
+	}
+	return quotes, nil
+}
@@ -0,0 +1,62 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// moexCandles - a candles response as ISS sends it, columns in its own order
+const moexCandles = `{
+"candles": {
+	"metadata": {"open": {"type": "double"}, "close": {"type": "double"}, "high": {"type": "double"}, "low": {"type": "double"},
+		"value": {"type": "double"}, "volume": {"type": "double"}, "begin": {"type": "datetime", "bytes": 19, "max_size": 0},
+		"end": {"type": "datetime", "bytes": 19, "max_size": 0}},
+	"columns": ["open", "close", "high", "low", "value", "volume", "begin", "end"],
+	"data": [
+		[167.5, 168.96, 169.38, 166.5, 5811470561.2, 34576640, "2023-03-01 00:00:00", "2023-03-01 23:59:59"],
+		[168.9, 167.11, 169.5, 166.54, 4179349187.7, 24901650, "2023-03-02 00:00:00", "2023-03-02 23:59:59"]
+	]
+}}`
+
+func TestParseMOEX(t *testing.T) {
+	q, err := parseMOEX("GAZP", []byte(moexCandles))
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 3, 1), day(2023, 3, 2)}, q.Date)
+	equals(t, []float64{167.5, 168.9}, q.Open)
+	equals(t, []float64{169.38, 169.5}, q.High)
+	equals(t, []float64{166.5, 166.54}, q.Low)
+	equals(t, []float64{168.96, 167.11}, q.Close)
+	equals(t, []float64{34576640, 24901650}, q.Volume)
+
+	_, err = parseMOEX("GAZP", []byte(`{"candles": {"columns": ["open", "close"], "data": []}}`))
+	assert(t, err != nil, "missing columns accepted")
+}
+
+func TestMOEX(t *testing.T) {
+	var paths []string
+	fakeServer(t, &moexURL, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path+"?start="+r.URL.Query().Get("start"))
+		if r.URL.Query().Get("start") != "0" {
+			fmt.Fprint(w, `{"candles": {"columns": ["open", "close", "high", "low", "value", "volume", "begin", "end"], "data": []}}`)
+			return
+		}
+		fmt.Fprint(w, moexCandles)
+	})
+
+	q, err := NewQuoteFromMOEX("GAZP", "2023-03-01", "2023-03-02", Daily)
+	ok(t, err)
+	equals(t, []string{
+		"/iss/engines/stock/markets/shares/boards/TQBR/securities/GAZP/candles.json?start=0",
+		"/iss/engines/stock/markets/shares/boards/TQBR/securities/GAZP/candles.json?start=2",
+	}, paths)
+	equals(t, 2, len(q.Date))
+	equals(t, "RUB", q.Meta.Currency)
+
+	paths = nil
+	_, err = NewQuoteFromMOEX("SiH3@rfud", "2023-03-01", "2023-03-02", Min60)
+	ok(t, err)
+	assert(t, strings.HasPrefix(paths[0], "/iss/engines/futures/markets/forts/boards/RFUD/securities/SiH3/"), "unexpected path %s", paths[0])
+}
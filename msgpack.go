@@ -0,0 +1,473 @@
+package quote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// msgpackWriter - MessagePack encoder of the few types quotes need
+type msgpackWriter struct {
+	bytes.Buffer
+}
+
+// header - write a length for the fixed, 16 or 32 bit type codes
+func (w *msgpackWriter) header(n int, fix, max byte, c16, c32 byte) {
+	var buf [4]byte
+	switch {
+	case n <= int(max):
+		w.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		w.WriteByte(c16)
+		binary.BigEndian.PutUint16(buf[:], uint16(n))
+		w.Write(buf[:2])
+	default:
+		w.WriteByte(c32)
+		binary.BigEndian.PutUint32(buf[:], uint32(n))
+		w.Write(buf[:])
+	}
+}
+
+// mapHeader - write the header of a map of n pairs
+func (w *msgpackWriter) mapHeader(n int) {
+	w.header(n, 0x80, 15, 0xde, 0xdf)
+}
+
+// arrayHeader - write the header of an array of n values
+func (w *msgpackWriter) arrayHeader(n int) {
+	w.header(n, 0x90, 15, 0xdc, 0xdd)
+}
+
+// str - write s, str8 for the lengths fixstr can not hold
+func (w *msgpackWriter) str(s string) {
+	if len(s) > 31 && len(s) <= math.MaxUint8 {
+		w.WriteByte(0xd9)
+		w.WriteByte(byte(len(s)))
+	} else {
+		w.header(len(s), 0xa0, 31, 0xda, 0xdb)
+	}
+	w.WriteString(s)
+}
+
+// int - write v as a fixint or int64
+func (w *msgpackWriter) int(v int64) {
+	if v >= -32 && v <= math.MaxInt8 {
+		w.WriteByte(byte(v))
+		return
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(v))
+	w.WriteByte(0xd3)
+	w.Write(buf[:])
+}
+
+// float - write v as float64
+func (w *msgpackWriter) float(v float64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], math.Float64bits(v))
+	w.WriteByte(0xcb)
+	w.Write(buf[:])
+}
+
+// quote - write q as a map of its symbol, dates in unix milliseconds and
+// columns, the optional ones only when present
+func (w *msgpackWriter) quote(q Quote) error {
+	if err := q.checkColumns(); err != nil {
+		return err
+	}
+	cols := q.columns()
+	n := 2
+	for _, col := range cols {
+		if *col != nil {
+			n++
+		}
+	}
+	w.mapHeader(n)
+	w.str("symbol")
+	w.str(q.Symbol)
+	w.str("date")
+	w.arrayHeader(len(q.Date))
+	for _, d := range q.Date {
+		w.int(d.UnixNano() / int64(time.Millisecond))
+	}
+	for i, col := range cols {
+		if *col == nil {
+			continue
+		}
+		w.str(columnName(i))
+		w.arrayHeader(len(*col))
+		for _, v := range *col {
+			w.float(v)
+		}
+	}
+	return nil
+}
+
+// MsgPack - encode Quote struct as a MessagePack map of symbol, date (unix
+// milliseconds) and a float array per column, keyed like its json
+func (q Quote) MsgPack() ([]byte, error) {
+	var w msgpackWriter
+	if err := w.quote(q); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+// MsgPack - encode Quotes structure as a MessagePack map of the quotes
+// keyed by symbol. Symbols must be unique.
+func (q Quotes) MsgPack() ([]byte, error) {
+	var w msgpackWriter
+	w.mapHeader(len(q))
+	seen := map[string]bool{}
+	for _, quote := range q {
+		if seen[quote.Symbol] {
+			return nil, fmt.Errorf("duplicate symbol %s", quote.Symbol)
+		}
+		seen[quote.Symbol] = true
+		w.str(quote.Symbol)
+		if err := w.quote(quote); err != nil {
+			return nil, err
+		}
+	}
+	return w.Bytes(), nil
+}
+
+// msgpackReader - MessagePack decoder of maps, arrays, strings and numbers
+// as written by other msgpack libraries, any other value skipped
+type msgpackReader struct {
+	b []byte
+}
+
+// next - consume n bytes
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || len(r.b) < n {
+		return nil, fmt.Errorf("msgpack: unexpected end of data")
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b, nil
+}
+
+// unsigned - consume an n byte big endian unsigned integer
+func (r *msgpackReader) unsigned(n int) (uint64, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// length - consume the length of a map, array or string of type code c,
+// with fixed lengths below fix+16 or fix+32 and 8, 16 and 32 bit codes
+func (r *msgpackReader) length(c byte, fix, size byte, codes [3]byte, kind string) (int, error) {
+	if c >= fix && c < fix+size {
+		return int(c - fix), nil
+	}
+	for i, code := range codes {
+		if code != 0 && c == code {
+			n, err := r.unsigned(1 << uint(i))
+			return int(n), err
+		}
+	}
+	return 0, fmt.Errorf("msgpack: expected %s, got type 0x%02x", kind, c)
+}
+
+// mapLen - consume a map header, the number of pairs
+func (r *msgpackReader) mapLen() (int, error) {
+	c, err := r.unsigned(1)
+	if err != nil {
+		return 0, err
+	}
+	return r.length(byte(c), 0x80, 16, [3]byte{0, 0xde, 0xdf}, "map")
+}
+
+// arrayLen - consume an array header, the number of values
+func (r *msgpackReader) arrayLen() (int, error) {
+	c, err := r.unsigned(1)
+	if err != nil {
+		return 0, err
+	}
+	return r.length(byte(c), 0x90, 16, [3]byte{0, 0xdc, 0xdd}, "array")
+}
+
+// str - consume a string, or bin as written by old msgpack versions
+func (r *msgpackReader) str() (string, error) {
+	c, err := r.unsigned(1)
+	if err != nil {
+		return "", err
+	}
+	codes := [3]byte{0xd9, 0xda, 0xdb}
+	if c >= 0xc4 && c <= 0xc6 {
+		codes = [3]byte{0xc4, 0xc5, 0xc6}
+	}
+	n, err := r.length(byte(c), 0xa0, 32, codes, "string")
+	if err != nil {
+		return "", err
+	}
+	b, err := r.next(n)
+	return string(b), err
+}
+
+// number - consume an integer or float, as float64 and truncated int64
+func (r *msgpackReader) number() (float64, int64, error) {
+	c, err := r.unsigned(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch {
+	case c <= 0x7f:
+		return float64(c), int64(c), nil
+	case c >= 0xe0:
+		v := int64(int8(c))
+		return float64(v), v, nil
+	case c == 0xca:
+		v, err := r.unsigned(4)
+		f := float64(math.Float32frombits(uint32(v)))
+		return f, int64(f), err
+	case c == 0xcb:
+		v, err := r.unsigned(8)
+		f := math.Float64frombits(v)
+		return f, int64(f), err
+	case c >= 0xcc && c <= 0xcf: // uint8 to uint64
+		v, err := r.unsigned(1 << (c - 0xcc))
+		return float64(v), int64(v), err
+	case c >= 0xd0 && c <= 0xd3: // int8 to int64
+		size := 1 << (c - 0xd0)
+		v, err := r.unsigned(size)
+		shift := uint(64 - 8*size)
+		i := int64(v<<shift) >> shift
+		return float64(i), i, err
+	}
+	return 0, 0, fmt.Errorf("msgpack: expected number, got type 0x%02x", c)
+}
+
+// skipNil - consume a nil if it is next
+func (r *msgpackReader) skipNil() bool {
+	if len(r.b) > 0 && r.b[0] == 0xc0 {
+		r.b = r.b[1:]
+		return true
+	}
+	return false
+}
+
+// skip - consume the next value of any type
+func (r *msgpackReader) skip() error {
+	c, err := r.unsigned(1)
+	if err != nil {
+		return err
+	}
+	var n int
+	switch {
+	case c <= 0x7f || c >= 0xe0 || c == 0xc0 || c == 0xc2 || c == 0xc3:
+		return nil
+	case c >= 0x80 && c <= 0x8f:
+		return r.skipValues(2 * int(c-0x80))
+	case c >= 0x90 && c <= 0x9f:
+		return r.skipValues(int(c - 0x90))
+	case c >= 0xa0 && c <= 0xbf:
+		n = int(c - 0xa0)
+	case c == 0xde || c == 0xdf || c == 0xdc || c == 0xdd:
+		size := 2
+		if c == 0xdf || c == 0xdd {
+			size = 4
+		}
+		count, err := r.unsigned(size)
+		if err != nil {
+			return err
+		}
+		if c == 0xde || c == 0xdf {
+			count *= 2
+		}
+		return r.skipValues(int(count))
+	case c >= 0xc4 && c <= 0xc6, c >= 0xd9 && c <= 0xdb: // bin and str 8 to 32
+		code := c - 0xc4
+		if c >= 0xd9 {
+			code = c - 0xd9
+		}
+		v, err := r.unsigned(1 << code)
+		if err != nil {
+			return err
+		}
+		n = int(v)
+	case c >= 0xc7 && c <= 0xc9: // ext8 to ext32
+		v, err := r.unsigned(1 << (c - 0xc7))
+		if err != nil {
+			return err
+		}
+		n = int(v) + 1
+	case c == 0xca:
+		n = 4
+	case c == 0xcb:
+		n = 8
+	case c >= 0xcc && c <= 0xd3:
+		n = 1 << ((c - 0xcc) % 4)
+	case c >= 0xd4 && c <= 0xd8: // fixext
+		n = 1<<(c-0xd4) + 1
+	default:
+		return fmt.Errorf("msgpack: unknown type 0x%02x", c)
+	}
+	_, err = r.next(n)
+	return err
+}
+
+// skipValues - consume n values
+func (r *msgpackReader) skipValues(n int) error {
+	for i := 0; i < n; i++ {
+		if err := r.skip(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quote - consume a quote map as written by msgpackWriter.quote. Unknown
+// keys and nil values are skipped.
+func (r *msgpackReader) quote() (Quote, error) {
+	q := NewQuote("", 0)
+	names := map[string]*[]float64{}
+	for i, col := range q.columns() {
+		names[columnName(i)] = col
+	}
+	n, err := r.mapLen()
+	if err != nil {
+		return q, err
+	}
+	for ; n > 0; n-- {
+		key, err := r.str()
+		if err != nil {
+			return q, err
+		}
+		col, isColumn := names[key]
+		switch {
+		case r.skipNil():
+		case key == "symbol":
+			q.Symbol, err = r.str()
+		case key == "date" || isColumn:
+			var size int
+			if size, err = r.arrayLen(); err != nil {
+				break
+			}
+			if size > len(r.b) {
+				err = fmt.Errorf("unexpected end of data")
+				break
+			}
+			if isColumn {
+				*col = make([]float64, size)
+			} else {
+				q.Date = make([]time.Time, 0, size)
+			}
+			for i := 0; i < size && err == nil; i++ {
+				var f float64
+				var ms int64
+				f, ms, err = r.number()
+				if isColumn {
+					(*col)[i] = f
+				} else {
+					q.Date = append(q.Date, time.Unix(0, ms*int64(time.Millisecond)).UTC())
+				}
+			}
+		default:
+			err = r.skip()
+		}
+		if err != nil {
+			return q, fmt.Errorf("msgpack: %s: %v", key, err)
+		}
+	}
+	if err = q.checkColumns(); err != nil {
+		return q, fmt.Errorf("msgpack: %v", err)
+	}
+	return q, nil
+}
+
+// NewQuoteFromMsgPack - decode a MessagePack quote written by MsgPack into
+// Quote structure
+func NewQuoteFromMsgPack(data []byte) (Quote, error) {
+	r := msgpackReader{data}
+	q, err := r.quote()
+	if err != nil {
+		return NewQuote("", 0), err
+	}
+	return q, nil
+}
+
+// NewQuotesFromMsgPack - decode a MessagePack map of quotes keyed by symbol
+// into Quotes array, in the order of the map
+func NewQuotesFromMsgPack(data []byte) (Quotes, error) {
+	r := msgpackReader{data}
+	n, err := r.mapLen()
+	if err != nil {
+		return Quotes{}, err
+	}
+	quotes := Quotes{}
+	for ; n > 0; n-- {
+		symbol, err := r.str()
+		if err != nil {
+			return Quotes{}, err
+		}
+		q, err := r.quote()
+		if err != nil {
+			return Quotes{}, err
+		}
+		if q.Symbol == "" {
+			q.Symbol = symbol
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes, nil
+}
+
+// WriteMsgPack - write Quote struct to a MessagePack file, gzip compressed
+// if filename ends in .gz
+func (q Quote) WriteMsgPack(filename string) error {
+	if filename == "" {
+		if q.Symbol != "" {
+			filename = q.Symbol + ".msgpack"
+		} else {
+			filename = "quote.msgpack"
+		}
+	}
+	data, err := q.MsgPack()
+	if err != nil {
+		return err
+	}
+	return writeFile(filename, data)
+}
+
+// WriteMsgPack - write Quotes structure to a MessagePack file, gzip
+// compressed if filename ends in .gz
+func (q Quotes) WriteMsgPack(filename string) error {
+	if filename == "" {
+		filename = "quotes.msgpack"
+	}
+	data, err := q.MsgPack()
+	if err != nil {
+		return err
+	}
+	return writeFile(filename, data)
+}
+
+// NewQuoteFromMsgPackFile - read a MessagePack Quote file written by
+// WriteMsgPack
+func NewQuoteFromMsgPackFile(filename string) (Quote, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return NewQuote("", 0), err
+	}
+	return NewQuoteFromMsgPack(data)
+}
+
+// NewQuotesFromMsgPackFile - read a MessagePack Quotes file written by
+// WriteMsgPack
+func NewQuotesFromMsgPackFile(filename string) (Quotes, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return Quotes{}, err
+	}
+	return NewQuotesFromMsgPack(data)
+}
@@ -0,0 +1,136 @@
+package quote
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMsgPack(t *testing.T) {
+	quotes := partitionFixture()
+	spy := quotes[0]
+	spy.Date[3] = time.Date(1969, 7, 20, 20, 17, 40, 5*int(time.Millisecond), time.UTC)
+	spy.Trades = []float64{10, 0, 12, 13}
+	zero := quotes[1] // 0 volume
+
+	for _, q := range []Quote{spy, zero, NewQuote("", 0)} {
+		data, err := q.MsgPack()
+		ok(t, err)
+		back, err := NewQuoteFromMsgPack(data)
+		ok(t, err)
+		equals(t, q, back)
+	}
+
+	// {"symbol": "", "date": []}
+	data, err := NewQuote("", 0).MsgPack()
+	ok(t, err)
+	equals(t, []byte("\x87\xa6symbol\xa0\xa4date\x90\xa4open\x90\xa4high\x90\xa3low\x90\xa5close\x90\xa6volume\x90"), data)
+
+	bad := NewQuote("bad", 2)
+	bad.Close = bad.Close[:1]
+	_, err = bad.MsgPack()
+	assert(t, err != nil, "mismatched columns encoded")
+	_, err = NewQuoteFromMsgPack(data[:len(data)-1])
+	assert(t, err != nil, "truncated data decoded")
+}
+
+func TestMsgPackQuotes(t *testing.T) {
+	quotes := partitionFixture()
+	data, err := quotes.MsgPack()
+	ok(t, err)
+	equals(t, byte(0x83), data[0])
+	equals(t, "\xa3spy", string(data[1:5]))
+	back, err := NewQuotesFromMsgPack(data)
+	ok(t, err)
+	equals(t, quotes, back)
+
+	data, err = Quotes{}.MsgPack()
+	ok(t, err)
+	back, err = NewQuotesFromMsgPack(data)
+	ok(t, err)
+	equals(t, Quotes{}, back)
+
+	_, err = Quotes{quotes[0], quotes[0]}.MsgPack()
+	assert(t, err != nil, "duplicate symbols encoded")
+}
+
+// as packed by python msgpack: compact ints, a nil column and extra keys
+func TestMsgPackForeign(t *testing.T) {
+	var w msgpackWriter
+	w.mapHeader(8)
+	w.str("date")
+	w.arrayHeader(1)
+	w.Write([]byte{0xcf, 0, 0, 0x01, 0x85, 0xff, 0xf9, 0x34, 0x00}) // uint64 1675036800000
+	w.str("source")
+	w.mapHeader(1)
+	w.str("name")
+	w.Write([]byte{0xc4, 2, 'h', 'i'})
+	for _, col := range []string{"open", "high", "low", "close"} {
+		w.str(col)
+		w.arrayHeader(1)
+		w.Write([]byte{0xca, 0x42, 0xc8, 0, 0}) // float32 100
+	}
+	w.str("volume")
+	w.arrayHeader(1)
+	w.Write([]byte{0xd1, 0xfc, 0x18}) // int16 -1000
+	w.str("adjclose")
+	w.WriteByte(0xc0)
+
+	q, err := NewQuoteFromMsgPack(w.Bytes())
+	ok(t, err)
+	equals(t, "", q.Symbol)
+	equals(t, []time.Time{day(2023, 1, 30)}, q.Date)
+	equals(t, []float64{100}, q.Close)
+	equals(t, []float64{-1000}, q.Volume)
+	assert(t, q.AdjClose == nil, "nil adjclose decoded")
+}
+
+func TestWriteMsgPack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "msgpack")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	quotes := partitionFixture()
+	name := filepath.Join(dir, "quotes.msgpack.gz")
+	ok(t, quotes.WriteMsgPack(name))
+	all, err := NewQuotesFromMsgPackFile(name)
+	ok(t, err)
+	equals(t, quotes, all)
+
+	name = filepath.Join(dir, "spy.msgpack")
+	ok(t, quotes[0].WriteMsgPack(name))
+	q, err := NewQuoteFromMsgPackFile(name)
+	ok(t, err)
+	equals(t, quotes[0], q)
+}
+
+func BenchmarkMsgPack(b *testing.B) {
+	q := pbBenchQuote()
+	b.ResetTimer()
+	var data []byte
+	for i := 0; i < b.N; i++ {
+		data, _ = q.MsgPack()
+	}
+	b.ReportMetric(float64(len(data)), "bytes")
+}
+
+func BenchmarkMsgPackDecode(b *testing.B) {
+	data, _ := pbBenchQuote().MsgPack()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewQuoteFromMsgPack(data)
+	}
+}
+
+func BenchmarkMsgPackJSON(b *testing.B) {
+	q := pbBenchQuote()
+	b.ResetTimer()
+	var data []byte
+	for i := 0; i < b.N; i++ {
+		data, _ = json.Marshal(q)
+	}
+	b.ReportMetric(float64(len(data)), "bytes")
+}
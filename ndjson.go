@@ -0,0 +1,192 @@
+package quote
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ndjsonMaxLine - longest ndjson line read, far more than any bar needs
+const ndjsonMaxLine = 1024 * 1024
+
+// ndjsonBar - a bar as a line of ndjson, optional columns omitted when the
+// quote does not carry them
+type ndjsonBar struct {
+	Symbol       string    `json:"symbol"`
+	TS           time.Time `json:"ts"`
+	Open         float64   `json:"open"`
+	High         float64   `json:"high"`
+	Low          float64   `json:"low"`
+	Close        float64   `json:"close"`
+	Volume       float64   `json:"volume"`
+	Trades       *float64  `json:"trades,omitempty"`
+	OpenInterest *float64  `json:"openinterest,omitempty"`
+	AdjClose     *float64  `json:"adjclose,omitempty"`
+	Session      *float64  `json:"session,omitempty"`
+	AdjOpen      *float64  `json:"adjopen,omitempty"`
+	AdjHigh      *float64  `json:"adjhigh,omitempty"`
+	AdjLow       *float64  `json:"adjlow,omitempty"`
+}
+
+// extras - the optional columns of b, in extraColumns order
+func (b *ndjsonBar) extras() []**float64 {
+	return []**float64{&b.Trades, &b.OpenInterest, &b.AdjClose, &b.Session, &b.AdjOpen, &b.AdjHigh, &b.AdjLow}
+}
+
+// ndjsonBar - bar of q as a line of ndjson
+func (q Quote) ndjsonBar(bar int) ndjsonBar {
+	b := ndjsonBar{
+		Symbol: q.Symbol,
+		TS:     q.Date[bar].UTC(),
+		Open:   q.Open[bar],
+		High:   q.High[bar],
+		Low:    q.Low[bar],
+		Close:  q.Close[bar],
+		Volume: q.Volume[bar],
+	}
+	for i, dst := range b.extras() {
+		if col := *extraColumns[i].col(&q); col != nil {
+			v := col[bar]
+			*dst = &v
+		}
+	}
+	return b
+}
+
+// NDJSON - convert Quote struct to newline delimited json, an object per bar
+func (q Quote) NDJSON() string {
+	var buffer bytes.Buffer
+	q.writeNDJSON(&buffer)
+	return buffer.String()
+}
+
+// writeNDJSON - stream Quote struct as ndjson to w
+func (q Quote) writeNDJSON(w io.Writer) error {
+	return Quotes{q}.writeNDJSON(w)
+}
+
+// WriteNDJSON - write Quote struct to ndjson file, gzip compressed if
+// filename ends in .gz
+func (q Quote) WriteNDJSON(filename string) error {
+	if filename == "" {
+		if q.Symbol != "" {
+			filename = q.Symbol + ".ndjson"
+		} else {
+			filename = "quote.ndjson"
+		}
+	}
+	return writeStream(filename, q.writeNDJSON)
+}
+
+// NDJSON - convert Quotes structure to newline delimited json, the bars of
+// all symbols interleaved in date order
+func (q Quotes) NDJSON() string {
+	var buffer bytes.Buffer
+	q.writeNDJSON(&buffer)
+	return buffer.String()
+}
+
+// writeNDJSON - stream Quotes structure as ndjson to w, merging the bars of
+// all quotes by date. Bars of the same date keep the order of the quotes.
+func (q Quotes) writeNDJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	next := make([]int, len(q))
+	for {
+		sym := -1
+		for i, quote := range q {
+			if next[i] < len(quote.Close) && (sym < 0 || quote.Date[next[i]].Before(q[sym].Date[next[sym]])) {
+				sym = i
+			}
+		}
+		if sym < 0 {
+			return nil
+		}
+		if err := enc.Encode(q[sym].ndjsonBar(next[sym])); err != nil {
+			return err
+		}
+		next[sym]++
+	}
+}
+
+// WriteNDJSON - write Quotes structure to ndjson file, gzip compressed if
+// filename ends in .gz
+func (q Quotes) WriteNDJSON(filename string) error {
+	if filename == "" {
+		filename = "quotes.ndjson"
+	}
+	return writeStream(filename, q.writeNDJSON)
+}
+
+// readNDJSON - parse ndjson from r line by line, calling bar for each bar.
+// Blank lines are skipped.
+func readNDJSON(r io.Reader, bar func(line int, b ndjsonBar) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), ndjsonMaxLine)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var b ndjsonBar
+		if err := json.Unmarshal(text, &b); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		if err := bar(line, b); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// pushNDJSON - append b to q with its optional columns
+func (q *Quote) pushNDJSON(b ndjsonBar) {
+	q.PushBar(Bar{Date: b.TS.UTC(), Open: b.Open, High: b.High, Low: b.Low, Close: b.Close, Volume: b.Volume})
+	for i, v := range b.extras() {
+		if *v != nil {
+			q.setExtra(extraColumns[i].col(q), len(q.Date)-1, **v)
+		}
+	}
+}
+
+// NewQuoteFromNDJSON - parse ndjson of a single symbol from r into Quote
+// structure
+func NewQuoteFromNDJSON(r io.Reader) (Quote, error) {
+	q := NewQuote("", 0)
+	err := readNDJSON(r, func(line int, b ndjsonBar) error {
+		if len(q.Date) == 0 {
+			q.Symbol = b.Symbol
+		} else if b.Symbol != q.Symbol {
+			return fmt.Errorf("line %d: symbol %s, expected %s", line, b.Symbol, q.Symbol)
+		}
+		q.pushNDJSON(b)
+		return nil
+	})
+	if err != nil {
+		return NewQuote("", 0), err
+	}
+	return q, nil
+}
+
+// NewQuotesFromNDJSON - parse ndjson from r into Quotes array, a quote per
+// symbol in order of first appearance
+func NewQuotesFromNDJSON(r io.Reader) (Quotes, error) {
+	quotes := Quotes{}
+	index := map[string]int{}
+	err := readNDJSON(r, func(line int, b ndjsonBar) error {
+		i, ok := index[b.Symbol]
+		if !ok {
+			i = len(quotes)
+			index[b.Symbol] = i
+			quotes = append(quotes, NewQuote(b.Symbol, 0))
+		}
+		quotes[i].pushNDJSON(b)
+		return nil
+	})
+	if err != nil {
+		return Quotes{}, err
+	}
+	return quotes, nil
+}
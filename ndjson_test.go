@@ -0,0 +1,78 @@
+package quote
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNDJSON(t *testing.T) {
+	spy := partitionFixture()[0]
+	spy.AdjClose = []float64{99.5, 100.5, 101.5, 0}
+
+	lines := strings.Split(strings.TrimSuffix(spy.NDJSON(), "\n"), "\n")
+	equals(t, 4, len(lines))
+	equals(t, `{"symbol":"spy","ts":"2023-01-30T00:00:00Z","open":100,"high":101,"low":99,"close":100,"volume":1000,"adjclose":99.5}`, lines[0])
+	equals(t, `{"symbol":"spy","ts":"2023-01-31T23:59:00Z","open":101,"high":102,"low":100,"close":101,"volume":1000,"adjclose":100.5}`, lines[1])
+
+	// blank lines are skipped
+	back, err := NewQuoteFromNDJSON(strings.NewReader("\n" + strings.Join(lines, "\n\n") + "\n\n"))
+	ok(t, err)
+	equals(t, spy, back)
+
+	_, err = NewQuoteFromNDJSON(strings.NewReader(lines[0] + "\n" + `{"symbol":"qqq","ts":"2023-02-01T00:00:00Z"}`))
+	assert(t, err != nil && strings.Contains(err.Error(), "line 2"), "mixed symbols accepted: %v", err)
+	_, err = NewQuoteFromNDJSON(strings.NewReader(lines[0] + "\n{\"symbol\":"))
+	assert(t, err != nil && strings.Contains(err.Error(), "line 2"), "bad json accepted: %v", err)
+}
+
+func TestNDJSONQuotes(t *testing.T) {
+	quotes := partitionFixture()
+	quotes[1].Trades = []float64{42}
+
+	// symbols interleave by date
+	jsn := quotes.NDJSON()
+	symbols := []string{}
+	for _, line := range strings.Split(strings.TrimSuffix(jsn, "\n"), "\n") {
+		symbols = append(symbols, line[len(`{"symbol":"`):strings.Index(line, `","ts"`)])
+	}
+	equals(t, []string{"spy", "spy", "spy", "BTC/USD", "spy"}, symbols)
+	assert(t, strings.Contains(jsn, `"volume":0,"trades":42}`), "missing trades: %s", jsn)
+
+	back, err := NewQuotesFromNDJSON(strings.NewReader(jsn))
+	ok(t, err)
+	equals(t, quotes[:2], back)
+
+	empty, err := NewQuotesFromNDJSON(strings.NewReader("\n"))
+	ok(t, err)
+	equals(t, 0, len(empty))
+}
+
+func TestNDJSONLongLines(t *testing.T) {
+	long := NewQuote(strings.Repeat("x", 100*1024), 1)
+	back, err := NewQuotesFromNDJSON(strings.NewReader(long.NDJSON()))
+	ok(t, err)
+	equals(t, Quotes{long}, back)
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ndjson")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	quotes := partitionFixture()[:2]
+	name := filepath.Join(dir, "quotes.ndjson.gz")
+	ok(t, quotes.WriteNDJSON(name))
+	equals(t, quotes.NDJSON(), string(gunzip(t, name)))
+
+	name = filepath.Join(dir, "spy.ndjson")
+	ok(t, quotes[0].WriteNDJSON(name))
+	f, err := os.Open(name)
+	ok(t, err)
+	defer f.Close()
+	back, err := NewQuoteFromNDJSON(f)
+	ok(t, err)
+	equals(t, quotes[0], back)
+}
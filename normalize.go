@@ -0,0 +1,50 @@
+package quote
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// scaled - copy of q with open, high, low, close and the adjusted prices
+// multiplied by factor, volume and the other optional columns unchanged
+func (q Quote) scaled(factor float64) Quote {
+	c := q.copy()
+	for _, col := range [][]float64{c.Open, c.High, c.Low, c.Close, c.AdjClose, c.AdjOpen, c.AdjHigh, c.AdjLow} {
+		for bar := range col {
+			col[bar] *= factor
+		}
+	}
+	return c
+}
+
+// Normalize - copy of q rebased so its first non-zero close is base, e.g. 100
+// for comparison charts. Open, high, low, close and the adjusted prices are
+// scaled alike, volume is unchanged. Leading zero closes are skipped and stay
+// zero; a quote without a non-zero close is copied unchanged.
+func (q Quote) Normalize(base float64) Quote {
+	for _, c := range q.Close {
+		if c != 0 {
+			return q.scaled(base / c)
+		}
+	}
+	return q.copy()
+}
+
+// NormalizeAt - copy of q with each member rebased as Normalize does so that
+// its close on date, or on its first bar after date, is base. A member
+// without bars from date on, or whose close there is zero, is an error.
+func (q Quotes) NormalizeAt(date time.Time, base float64) (Quotes, error) {
+	out := make(Quotes, len(q))
+	for i, quote := range q {
+		bar := sort.Search(len(quote.Date), func(bar int) bool { return !quote.Date[bar].Before(date) })
+		if bar == len(quote.Date) {
+			return nil, fmt.Errorf("%s: no bars on or after %s", quote.Symbol, date.Format("2006-01-02 15:04"))
+		}
+		if quote.Close[bar] == 0 {
+			return nil, fmt.Errorf("%s: zero close on %s", quote.Symbol, quote.Date[bar].Format("2006-01-02 15:04"))
+		}
+		out[i] = quote.scaled(base / quote.Close[bar])
+	}
+	return out, nil
+}
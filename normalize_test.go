@@ -0,0 +1,57 @@
+package quote
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	q := closesFixture(50, 55, 45)
+	q.High[1] = 60
+	q.AdjClose = []float64{25, 27.5, 22.5}
+	q.Trades = []float64{3, 4, 5}
+
+	n := q.Normalize(100)
+	equals(t, []float64{100, 110, 90}, n.Close)
+	equals(t, []float64{100, 110, 90}, n.Open)
+	equals(t, []float64{100, 120, 90}, n.High)
+	equals(t, []float64{50, 55, 45}, n.AdjClose)
+	equals(t, q.Volume, n.Volume)
+	equals(t, q.Trades, n.Trades)
+	equals(t, []float64{50, 55, 45}, q.Close)
+
+	// leading zero closes are skipped
+	z := closesFixture(0, 0, 20, 30)
+	n = z.Normalize(1)
+	equals(t, []float64{0, 0, 1, 1.5}, n.Close)
+	n = closesFixture(0, 0).Normalize(100)
+	equals(t, []float64{0, 0}, n.Close)
+	equals(t, 0, NewQuote("spy", 0).Normalize(100).Len())
+}
+
+func TestNormalizeAt(t *testing.T) {
+	// daily bars from monday the 2nd, btc without a close on the 2nd
+	spy := closesFixture(200, 210, 220, 230)
+	btc := closesFixture(0, 20000, 22000, 18000)
+	btc.Symbol = "btc"
+
+	quotes, err := Quotes{spy, btc}.NormalizeAt(day(2023, 1, 3), 100)
+	ok(t, err)
+	for i, want := range []float64{95.238095, 100, 104.761905, 109.523810} {
+		near(t, want, quotes[0].Close[i], "spy")
+	}
+	equals(t, []float64{0, 100, 110, 90}, quotes[1].Close)
+
+	// rebased at the first bar after a date between bars
+	quotes, err = Quotes{spy}.NormalizeAt(time.Date(2023, 1, 3, 12, 0, 0, 0, time.UTC), 1)
+	ok(t, err)
+	equals(t, 1.0, quotes[0].Close[2])
+
+	_, err = Quotes{spy, btc}.NormalizeAt(day(2023, 1, 2), 100)
+	assert(t, err != nil, "rebased at a zero close")
+	_, err = Quotes{spy, btc}.NormalizeAt(day(2023, 1, 6), 100)
+	assert(t, err != nil, "rebased after the last bar")
+	quotes, err = Quotes{}.NormalizeAt(day(2023, 1, 6), 100)
+	ok(t, err)
+	equals(t, 0, len(quotes))
+}
@@ -0,0 +1,34 @@
+package quote
+
+import "fmt"
+
+// ParquetWriter - writer of Quote.WriteParquet and Quotes.WriteParquet, set
+// by importing github.com/markcheno/go-quote/parquet. The Parquet library is
+// kept in that module so that this package has no external dependencies.
+var ParquetWriter func(quotes Quotes, filename string) error
+
+// WriteParquet - write Quote struct to a Parquet file in the long format of
+// Quotes.WriteParquet
+func (q Quote) WriteParquet(filename string) error {
+	if filename == "" {
+		if q.Symbol != "" {
+			filename = q.Symbol + ".parquet"
+		} else {
+			filename = "quote.parquet"
+		}
+	}
+	return Quotes{q}.WriteParquet(filename)
+}
+
+// WriteParquet - write Quotes structure to a single Parquet file in the long
+// format, a row per symbol and bar with the columns symbol, ts (timestamp in
+// milliseconds), open, high, low, close and volume. Needs ParquetWriter.
+func (q Quotes) WriteParquet(filename string) error {
+	if ParquetWriter == nil {
+		return fmt.Errorf("parquet output requires an import of github.com/markcheno/go-quote/parquet")
+	}
+	if filename == "" {
+		filename = "quotes.parquet"
+	}
+	return ParquetWriter(q.inOutputOrder(), filename)
+}
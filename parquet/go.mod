@@ -0,0 +1,9 @@
+module github.com/markcheno/go-quote/parquet
+
+go 1.16
+
+require (
+	github.com/markcheno/go-quote v0.0.0-20261015063413-e39b8b02ada4
+	github.com/xitongsys/parquet-go v1.6.2
+	github.com/xitongsys/parquet-go-source v0.0.0-20220315005136-aec0fe3e777c
+)
@@ -0,0 +1,133 @@
+/*
+Package parquet writes go-quote bars as Parquet files, for pandas, duckdb and
+other columnar tools
+
+It lives in its own module so that the core quote package stays free of
+external dependencies. The pure-Go xitongsys/parquet-go library is used.
+Importing the package sets quote.ParquetWriter to WriteQuotes, so that
+Quote.WriteParquet and Quotes.WriteParquet write with DefaultOptions.
+
+Licensed under terms of MIT license (see LICENSE)
+*/
+package parquet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/markcheno/go-quote"
+	"github.com/xitongsys/parquet-go-source/local"
+	pq "github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Compression - compression codec of the column chunks
+type Compression string
+
+// Compression codecs
+const (
+	Snappy       Compression = "snappy"
+	Zstd         Compression = "zstd"
+	Uncompressed Compression = "none"
+)
+
+// codecs - parquet codec of each compression
+var codecs = map[Compression]pq.CompressionCodec{
+	Snappy:       pq.CompressionCodec_SNAPPY,
+	Zstd:         pq.CompressionCodec_ZSTD,
+	Uncompressed: pq.CompressionCodec_UNCOMPRESSED,
+}
+
+// Options - options of Parquet output
+type Options struct {
+	Compression Compression // snappy, zstd or none
+}
+
+// DefaultOptions - options of WriteQuote and WriteQuotes
+var DefaultOptions = Options{Compression: Snappy}
+
+func init() {
+	quote.ParquetWriter = WriteQuotes
+}
+
+// row - a bar in the long format, one row per symbol and bar
+type row struct {
+	Symbol string  `parquet:"name=symbol, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	TS     int64   `parquet:"name=ts, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Open   float64 `parquet:"name=open, type=DOUBLE"`
+	High   float64 `parquet:"name=high, type=DOUBLE"`
+	Low    float64 `parquet:"name=low, type=DOUBLE"`
+	Close  float64 `parquet:"name=close, type=DOUBLE"`
+	Volume float64 `parquet:"name=volume, type=DOUBLE"`
+}
+
+// WriteQuote - write the bars of q to a Parquet file, symbol.parquet if
+// filename is empty
+func WriteQuote(q quote.Quote, filename string) error {
+	return WriteQuoteWithOptions(q, filename, DefaultOptions)
+}
+
+// WriteQuoteWithOptions - write the bars of q to a Parquet file compressed
+// according to opts
+func WriteQuoteWithOptions(q quote.Quote, filename string, opts Options) error {
+	if filename == "" {
+		if q.Symbol != "" {
+			filename = q.Symbol + ".parquet"
+		} else {
+			filename = "quote.parquet"
+		}
+	}
+	return WriteQuotesWithOptions(quote.Quotes{q}, filename, opts)
+}
+
+// WriteQuotes - write the bars of all quotes to a single Parquet file in the
+// long format, columns symbol, ts (timestamp in milliseconds), open, high,
+// low, close and volume, quotes.parquet if filename is empty
+func WriteQuotes(quotes quote.Quotes, filename string) error {
+	return WriteQuotesWithOptions(quotes, filename, DefaultOptions)
+}
+
+// WriteQuotesWithOptions - write the bars of all quotes to a single Parquet
+// file compressed according to opts
+func WriteQuotesWithOptions(quotes quote.Quotes, filename string, opts Options) error {
+	codec, ok := codecs[opts.Compression]
+	if !ok {
+		return fmt.Errorf("parquet: invalid compression '%s', must be snappy, zstd or none", opts.Compression)
+	}
+	if filename == "" {
+		filename = "quotes.parquet"
+	}
+
+	f, err := local.NewLocalFileWriter(filename)
+	if err != nil {
+		return err
+	}
+	w, err := writer.NewParquetWriter(f, new(row), 1)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.CompressionType = codec
+	for _, q := range quotes {
+		for bar := range q.Close {
+			err = w.Write(row{
+				Symbol: q.Symbol,
+				TS:     q.Date[bar].UnixNano() / int64(time.Millisecond),
+				Open:   q.Open[bar],
+				High:   q.High[bar],
+				Low:    q.Low[bar],
+				Close:  q.Close[bar],
+				Volume: q.Volume[bar],
+			})
+			if err != nil {
+				f.Close()
+				return err
+			}
+		}
+	}
+	if err = w.WriteStop(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
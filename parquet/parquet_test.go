@@ -0,0 +1,144 @@
+package parquet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markcheno/go-quote"
+	"github.com/xitongsys/parquet-go-source/local"
+	pq "github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func testQuotes() quote.Quotes {
+	spy := quote.NewQuote("spy", 3)
+	for bar := range spy.Date {
+		spy.Date[bar] = time.Date(2023, 1, 3+bar, 0, 0, 0, 0, time.UTC)
+		spy.Open[bar] = float64(380 + bar)
+		spy.High[bar] = float64(385 + bar)
+		spy.Low[bar] = float64(375 + bar)
+		spy.Close[bar] = float64(382 + bar)
+		spy.Volume[bar] = 1e6
+	}
+	btc := quote.NewQuote("BTC/USD", 1)
+	btc.Date[0] = time.Date(2023, 1, 3, 14, 30, 0, 0, time.UTC)
+	btc.Close[0] = 16700.5
+	return quote.Quotes{spy, btc}
+}
+
+// readRows - rows and column names of a Parquet file
+func readRows(t *testing.T, filename string) ([]row, []string, pq.CompressionCodec) {
+	f, err := local.NewLocalFileReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// the column names as written, NewParquetReader renames the footer
+	// schema to the field names of row
+	footer := &reader.ParquetReader{PFile: f}
+	if err := footer.ReadFooter(); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, el := range footer.Footer.Schema[1:] {
+		names = append(names, el.Name)
+	}
+
+	r, err := reader.NewParquetReader(f, new(row), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.ReadStop()
+
+	rows := make([]row, r.GetNumRows())
+	if err := r.Read(&rows); err != nil {
+		t.Fatal(err)
+	}
+	codec := r.Footer.RowGroups[0].Columns[0].MetaData.Codec
+	return rows, names, codec
+}
+
+func TestWriteQuotes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, compression := range []Compression{Snappy, Zstd, Uncompressed} {
+		filename := filepath.Join(dir, string(compression)+".parquet")
+		if err := WriteQuotesWithOptions(testQuotes(), filename, Options{Compression: compression}); err != nil {
+			t.Fatal(err)
+		}
+		rows, names, codec := readRows(t, filename)
+		if len(rows) != 4 {
+			t.Fatalf("%s: %d rows", compression, len(rows))
+		}
+		want := []string{"symbol", "ts", "open", "high", "low", "close", "volume"}
+		for i := range want {
+			if i >= len(names) || names[i] != want[i] {
+				t.Errorf("%s: columns %v, want %v", compression, names, want)
+				break
+			}
+		}
+		if codec != codecs[compression] {
+			t.Errorf("%s: codec %v", compression, codec)
+		}
+		if rows[0].Symbol != "spy" || rows[0].TS != 1672704000000 || rows[2].Close != 384 || rows[0].Volume != 1e6 {
+			t.Errorf("%s: unexpected rows %+v", compression, rows[:3])
+		}
+		if rows[3].Symbol != "BTC/USD" || rows[3].TS != 1672756200000 || rows[3].Close != 16700.5 {
+			t.Errorf("%s: unexpected row %+v", compression, rows[3])
+		}
+	}
+
+	if err := WriteQuotesWithOptions(testQuotes(), filepath.Join(dir, "lz4.parquet"), Options{Compression: "lz4"}); err == nil {
+		t.Error("unknown compression accepted")
+	}
+}
+
+func TestQuotesWriteParquet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "quotes.parquet")
+	if err := testQuotes().WriteParquet(filename); err != nil {
+		t.Fatal(err)
+	}
+	rows, _, codec := readRows(t, filename)
+	if len(rows) != 4 || codec != pq.CompressionCodec_SNAPPY {
+		t.Errorf("%d rows, codec %v", len(rows), codec)
+	}
+
+	filename = filepath.Join(dir, "spy.parquet")
+	if err := testQuotes()[0].WriteParquet(filename); err != nil {
+		t.Fatal(err)
+	}
+	if rows, _, _ := readRows(t, filename); len(rows) != 3 {
+		t.Errorf("%d spy rows", len(rows))
+	}
+}
+
+func TestWriteQuote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "spy.parquet")
+	if err := WriteQuote(testQuotes()[0], filename); err != nil {
+		t.Fatal(err)
+	}
+	rows, _, codec := readRows(t, filename)
+	if len(rows) != 3 || codec != pq.CompressionCodec_SNAPPY {
+		t.Errorf("%d rows, codec %v", len(rows), codec)
+	}
+}
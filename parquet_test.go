@@ -0,0 +1,26 @@
+package quote
+
+import "testing"
+
+func TestWriteParquet(t *testing.T) {
+	saved := ParquetWriter
+	defer func() { ParquetWriter = saved }()
+
+	ParquetWriter = nil
+	assert(t, cleanDaily("spy", 3).WriteParquet("spy.parquet") != nil, "parquet written without a writer")
+
+	var written []string
+	var symbols [][]string
+	ParquetWriter = func(quotes Quotes, filename string) error {
+		written = append(written, filename)
+		symbols = append(symbols, quotes.Symbols())
+		return nil
+	}
+	ok(t, cleanDaily("spy", 3).WriteParquet(""))
+	ok(t, NewQuote("", 0).WriteParquet(""))
+	ok(t, Quotes{cleanDaily("spy", 3), cleanDaily("aapl", 3)}.WriteParquet(""))
+	ok(t, Quotes{}.WriteParquet("bars.parquet"))
+	equals(t, []string{"spy.parquet", "quote.parquet", "quotes.parquet", "bars.parquet"}, written)
+	// sorted by symbol as the other writers
+	equals(t, []string{"aapl", "spy"}, symbols[2])
+}
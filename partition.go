@@ -0,0 +1,148 @@
+package quote
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// partitionKeys - partition keys accepted by WritePartitioned
+var partitionKeys = map[string]bool{
+	"symbol": true,
+	"year":   true,
+	"month":  true,
+	"date":   true,
+}
+
+// partitionFile - leaf file name, fixed so that re-runs overwrite rather than duplicate
+const partitionFile = "data"
+
+// escapePartitionValue - escape characters that are not allowed in a hive
+// partition value or in a Windows/Unix path component, using %XX encoding
+func escapePartitionValue(v string) string {
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if c < 0x20 || c == 0x7f || strings.IndexByte(`"#%'*/:=?\{[]^<>|`, c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	if b.Len() == 0 {
+		return "__HIVE_DEFAULT_PARTITION__"
+	}
+	return b.String()
+}
+
+// partitionPath - relative directory for bar of quote q
+func partitionPath(q Quote, bar int, partitionBy []string) string {
+	parts := make([]string, len(partitionBy))
+	d := q.Date[bar]
+	for i, key := range partitionBy {
+		var val string
+		switch key {
+		case "symbol":
+			val = q.Symbol
+		case "year":
+			val = fmt.Sprintf("%04d", d.Year())
+		case "month":
+			val = fmt.Sprintf("%02d", int(d.Month()))
+		case "date":
+			val = d.Format("2006-01-02")
+		}
+		parts[i] = key + "=" + escapePartitionValue(val)
+	}
+	return filepath.Join(parts...)
+}
+
+// partitionExt - file extension for a partitioned output format
+func partitionExt(format string) (string, error) {
+	switch format {
+	case "csv":
+		return ".csv", nil
+	case "json":
+		return ".json", nil
+	}
+	return "", fmt.Errorf("invalid partition format '%s', must be 'csv' or 'json'", format)
+}
+
+// WritePartitioned - write Quotes to a hive-style partitioned directory tree
+// under root, e.g. root/symbol=aapl/year=2023/month=03/data.csv
+//
+// Valid partition keys are symbol, year, month and date. Each bar is assigned
+// to a partition by its own (start) timestamp, so a quote spanning several
+// months is split across several leaf directories. Partitions without bars are
+// never created. When symbol is a partition key each leaf file holds a single
+// Quote, otherwise leaf files use the multi-symbol Quotes layout.
+func (q Quotes) WritePartitioned(root string, format string, partitionBy []string) error {
+
+	ext, err := partitionExt(format)
+	if err != nil {
+		return err
+	}
+	if len(partitionBy) == 0 {
+		return fmt.Errorf("no partition keys specified")
+	}
+	bySymbol := false
+	seen := make(map[string]bool)
+	for _, key := range partitionBy {
+		if !partitionKeys[key] {
+			return fmt.Errorf("invalid partition key '%s', must be one of symbol, year, month or date", key)
+		}
+		if seen[key] {
+			return fmt.Errorf("duplicate partition key '%s'", key)
+		}
+		seen[key] = true
+		if key == "symbol" {
+			bySymbol = true
+		}
+	}
+
+	// group bars by leaf directory, keeping first-seen order for determinism
+	var order []string
+	leaves := make(map[string]Quotes)
+	for _, quote := range q {
+		for bar := range quote.Close {
+			dir := partitionPath(quote, bar, partitionBy)
+			leaf, ok := leaves[dir]
+			if !ok {
+				order = append(order, dir)
+			}
+			n := len(leaf)
+			if n == 0 || leaf[n-1].Symbol != quote.Symbol {
+				leaf = append(leaf, Quote{Symbol: quote.Symbol, Precision: quote.Precision})
+				n++
+			}
+			leaf[n-1].appendBar(quote, bar)
+			leaves[dir] = leaf
+		}
+	}
+
+	for _, dir := range order {
+		leaf := leaves[dir]
+		path := filepath.Join(root, dir)
+		err := os.MkdirAll(path, 0755)
+		if err != nil {
+			return err
+		}
+		var data string
+		switch {
+		case format == "csv" && bySymbol:
+			data = leaf[0].CSV()
+		case format == "csv":
+			data = leaf.CSV()
+		case bySymbol:
+			data = leaf[0].JSON(false)
+		default:
+			data = leaf.JSON(false)
+		}
+		err = ioutil.WriteFile(filepath.Join(path, partitionFile+ext), []byte(data), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
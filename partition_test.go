@@ -0,0 +1,112 @@
+package quote
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func partitionFixture() Quotes {
+	spy := NewQuote("spy", 4)
+	dates := []time.Time{
+		time.Date(2023, 1, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 1, 31, 23, 59, 0, 0, time.UTC), // last bar of january
+		time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 4, 3, 0, 0, 0, 0, time.UTC), // no bars in march
+	}
+	for bar, d := range dates {
+		spy.Date[bar] = d
+		spy.Open[bar] = float64(100 + bar)
+		spy.High[bar] = float64(101 + bar)
+		spy.Low[bar] = float64(99 + bar)
+		spy.Close[bar] = float64(100 + bar)
+		spy.Volume[bar] = 1000
+	}
+	btc := NewQuote("BTC/USD", 1)
+	btc.Date[0] = time.Date(2023, 2, 14, 0, 0, 0, 0, time.UTC)
+	btc.Close[0] = 22000
+	return Quotes{spy, btc, NewQuote("empty", 0)}
+}
+
+func listFiles(t *testing.T, root string) []string {
+	var files []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	ok(t, err)
+	sort.Strings(files)
+	return files
+}
+
+func TestWritePartitioned(t *testing.T) {
+	root, err := ioutil.TempDir("", "partition")
+	ok(t, err)
+	defer os.RemoveAll(root)
+
+	quotes := partitionFixture()
+	partitionBy := []string{"symbol", "year", "month"}
+	ok(t, quotes.WritePartitioned(root, "csv", partitionBy))
+	// re-running must overwrite rather than duplicate
+	ok(t, quotes.WritePartitioned(root, "csv", partitionBy))
+
+	equals(t, []string{
+		"symbol=BTC%2FUSD/year=2023/month=02/data.csv",
+		"symbol=spy/year=2023/month=01/data.csv",
+		"symbol=spy/year=2023/month=02/data.csv",
+		"symbol=spy/year=2023/month=04/data.csv",
+	}, listFiles(t, root))
+
+	_, err = os.Stat(filepath.Join(root, "symbol=spy", "year=2023", "month=03"))
+	assert(t, os.IsNotExist(err), "empty partition was created")
+	_, err = os.Stat(filepath.Join(root, "symbol=empty"))
+	assert(t, os.IsNotExist(err), "empty quote partition was created")
+
+	jan, err := ioutil.ReadFile(filepath.Join(root, "symbol=spy", "year=2023", "month=01", "data.csv"))
+	ok(t, err)
+	equals(t, "datetime,open,high,low,close,volume\n"+
+		"2023-01-30 00:00,100.00,101.00,99.00,100.00,1000.00\n"+
+		"2023-01-31 23:59,101.00,102.00,100.00,101.00,1000.00\n", string(jan))
+}
+
+func TestWritePartitionedWithoutSymbol(t *testing.T) {
+	root, err := ioutil.TempDir("", "partition")
+	ok(t, err)
+	defer os.RemoveAll(root)
+
+	ok(t, partitionFixture().WritePartitioned(root, "csv", []string{"date"}))
+	files := listFiles(t, root)
+	equals(t, 5, len(files))
+
+	feb, err := ioutil.ReadFile(filepath.Join(root, "date=2023-02-01", "data.csv"))
+	ok(t, err)
+	equals(t, "symbol,datetime,open,high,low,close,volume\nspy,2023-02-01 00:00,102.00,103.00,101.00,102.00,1000.00\n", string(feb))
+}
+
+func TestWritePartitionedErrors(t *testing.T) {
+	quotes := partitionFixture()
+	assert(t, quotes.WritePartitioned("", "csv", []string{"week"}) != nil, "expected invalid key error")
+	assert(t, quotes.WritePartitioned("", "csv", []string{"year", "year"}) != nil, "expected duplicate key error")
+	assert(t, quotes.WritePartitioned("", "xml", []string{"year"}) != nil, "expected invalid format error")
+	assert(t, quotes.WritePartitioned("", "csv", nil) != nil, "expected missing key error")
+}
+
+func TestEscapePartitionValue(t *testing.T) {
+	// characters that are invalid in Windows file names must never reach the path
+	equals(t, "a%3Cb%3Ec%3Ad%22e%2Ff%5Cg%7Ch%3Fi%2A", escapePartitionValue(`a<b>c:d"e/f\g|h?i*`))
+	equals(t, "x%3Dy", escapePartitionValue("x=y"))
+	equals(t, "__HIVE_DEFAULT_PARTITION__", escapePartitionValue(""))
+
+	q := NewQuote(`c:\tmp`, 1)
+	q.Date[0] = time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	equals(t, filepath.Join("symbol=c%3A%5Ctmp", "year=2023"), partitionPath(q, 0, []string{"symbol", "year"}))
+}
@@ -0,0 +1,285 @@
+package quote
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// protobuf wire types
+const (
+	pbVarint  = 0
+	pbFixed64 = 1
+	pbBytes   = 2
+	pbFixed32 = 5
+)
+
+// field numbers of quote.proto
+const (
+	pbQuotesQuote    = 1 // Quotes.quotes
+	pbQuoteSymbol    = 1 // Quote.symbol
+	pbQuoteMillis    = 2 // Quote.unix_millis
+	pbQuoteOpen      = 3 // Quote.open, followed by high, low, close and volume
+	pbQuoteExtras    = 8 // Quote.trades, followed by the other extraColumns
+	pbQuoteMaxColumn = pbQuoteExtras + 6
+)
+
+// pbAppendVarint - append v to b as a varint
+func pbAppendVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// pbAppendTag - append the key of field with wire type to b
+func pbAppendTag(b []byte, field, wire int) []byte {
+	return pbAppendVarint(b, uint64(field)<<3|uint64(wire))
+}
+
+// pbAppendBytes - append a length delimited field to b
+func pbAppendBytes(b []byte, field int, v []byte) []byte {
+	b = pbAppendTag(b, field, pbBytes)
+	b = pbAppendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// pbAppendDoubles - append a packed repeated double field to b, nothing
+// when vals is empty
+func pbAppendDoubles(b []byte, field int, vals []float64) []byte {
+	if len(vals) == 0 {
+		return b
+	}
+	b = pbAppendTag(b, field, pbBytes)
+	b = pbAppendVarint(b, uint64(8*len(vals)))
+	var buf [8]byte
+	for _, v := range vals {
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+		b = append(b, buf[:]...)
+	}
+	return b
+}
+
+// appendPB - append q as a quote.proto Quote message to b
+func (q Quote) appendPB(b []byte) ([]byte, error) {
+	if err := q.checkColumns(); err != nil {
+		return nil, err
+	}
+
+	if q.Symbol != "" {
+		b = pbAppendBytes(b, pbQuoteSymbol, []byte(q.Symbol))
+	}
+	if len(q.Date) > 0 {
+		var millis []byte
+		for _, d := range q.Date {
+			millis = pbAppendVarint(millis, uint64(d.UnixNano()/int64(time.Millisecond)))
+		}
+		b = pbAppendBytes(b, pbQuoteMillis, millis)
+	}
+	for i, col := range q.columns() {
+		b = pbAppendDoubles(b, pbQuoteOpen+i, *col)
+	}
+	return b, nil
+}
+
+// Marshal - encode Quote struct as a quote.proto Quote message. Times are
+// kept to the millisecond.
+func (q Quote) Marshal() ([]byte, error) {
+	return q.appendPB(nil)
+}
+
+// Marshal - encode Quotes structure as a quote.proto Quotes message
+func (q Quotes) Marshal() ([]byte, error) {
+	var b []byte
+	for _, quote := range q {
+		msg, err := quote.appendPB(nil)
+		if err != nil {
+			return nil, err
+		}
+		b = pbAppendBytes(b, pbQuotesQuote, msg)
+	}
+	return b, nil
+}
+
+// pbField - a field of a protobuf message
+type pbField struct {
+	num  int
+	wire int
+	v    uint64 // varint and fixed values
+	data []byte // length delimited values
+}
+
+// pbFields - call f for each field of the protobuf message b
+func pbFields(b []byte, f func(field pbField) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return fmt.Errorf("pb: bad field key")
+		}
+		b = b[n:]
+		field := pbField{num: int(key >> 3), wire: int(key & 7)}
+		switch field.wire {
+		case pbVarint:
+			if field.v, n = binary.Uvarint(b); n <= 0 {
+				return fmt.Errorf("pb: bad varint in field %d", field.num)
+			}
+		case pbFixed64:
+			if n = 8; len(b) < n {
+				return fmt.Errorf("pb: truncated field %d", field.num)
+			}
+			field.v = binary.LittleEndian.Uint64(b)
+		case pbFixed32:
+			if n = 4; len(b) < n {
+				return fmt.Errorf("pb: truncated field %d", field.num)
+			}
+			field.v = uint64(binary.LittleEndian.Uint32(b))
+		case pbBytes:
+			size, m := binary.Uvarint(b)
+			if m <= 0 || uint64(len(b)-m) < size {
+				return fmt.Errorf("pb: truncated field %d", field.num)
+			}
+			field.data = b[m : m+int(size)]
+			n = m + int(size)
+		default:
+			return fmt.Errorf("pb: unsupported wire type %d of field %d", field.wire, field.num)
+		}
+		b = b[n:]
+		if err := f(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pbMillis - append the packed or single int64 values of field to millis
+func pbMillis(millis []int64, field pbField) ([]int64, error) {
+	if field.wire == pbVarint {
+		return append(millis, int64(field.v)), nil
+	}
+	if field.wire != pbBytes {
+		return nil, fmt.Errorf("pb: unix_millis has wire type %d", field.wire)
+	}
+	for b := field.data; len(b) > 0; {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("pb: bad varint in unix_millis")
+		}
+		millis = append(millis, int64(v))
+		b = b[n:]
+	}
+	return millis, nil
+}
+
+// pbDoubles - append the packed or single double values of field to vals
+func pbDoubles(vals []float64, field pbField) ([]float64, error) {
+	if field.wire == pbFixed64 {
+		return append(vals, math.Float64frombits(field.v)), nil
+	}
+	if field.wire != pbBytes || len(field.data)%8 != 0 {
+		return nil, fmt.Errorf("pb: bad doubles in field %d", field.num)
+	}
+	for b := field.data; len(b) > 0; b = b[8:] {
+		vals = append(vals, math.Float64frombits(binary.LittleEndian.Uint64(b)))
+	}
+	return vals, nil
+}
+
+// UnmarshalQuote - decode a quote.proto Quote message into Quote structure.
+// Unknown fields are skipped.
+func UnmarshalQuote(data []byte) (Quote, error) {
+	q := NewQuote("", 0)
+	cols := q.columns()
+	var millis []int64
+	err := pbFields(data, func(field pbField) error {
+		var err error
+		switch {
+		case field.num == pbQuoteSymbol && field.wire == pbBytes:
+			q.Symbol = string(field.data)
+		case field.num == pbQuoteMillis:
+			millis, err = pbMillis(millis, field)
+		case field.num >= pbQuoteOpen && field.num <= pbQuoteMaxColumn:
+			col := cols[field.num-pbQuoteOpen]
+			*col, err = pbDoubles(*col, field)
+		}
+		return err
+	})
+	if err != nil {
+		return NewQuote("", 0), err
+	}
+
+	q.Date = make([]time.Time, len(millis))
+	for i, ms := range millis {
+		q.Date[i] = time.Unix(0, ms*int64(time.Millisecond)).UTC()
+	}
+	if err := q.checkColumns(); err != nil {
+		return NewQuote("", 0), fmt.Errorf("pb: %v", err)
+	}
+	return q, nil
+}
+
+// UnmarshalQuotes - decode a quote.proto Quotes message into Quotes array
+func UnmarshalQuotes(data []byte) (Quotes, error) {
+	quotes := Quotes{}
+	err := pbFields(data, func(field pbField) error {
+		if field.num != pbQuotesQuote || field.wire != pbBytes {
+			return nil
+		}
+		q, err := UnmarshalQuote(field.data)
+		if err != nil {
+			return err
+		}
+		quotes = append(quotes, q)
+		return nil
+	})
+	if err != nil {
+		return Quotes{}, err
+	}
+	return quotes, nil
+}
+
+// WritePB - write Quote struct to a protobuf file, gzip compressed if
+// filename ends in .gz
+func (q Quote) WritePB(filename string) error {
+	if filename == "" {
+		if q.Symbol != "" {
+			filename = q.Symbol + ".pb"
+		} else {
+			filename = "quote.pb"
+		}
+	}
+	data, err := q.Marshal()
+	if err != nil {
+		return err
+	}
+	return writeFile(filename, data)
+}
+
+// WritePB - write Quotes structure to a protobuf file, gzip compressed if
+// filename ends in .gz
+func (q Quotes) WritePB(filename string) error {
+	if filename == "" {
+		filename = "quotes.pb"
+	}
+	data, err := q.Marshal()
+	if err != nil {
+		return err
+	}
+	return writeFile(filename, data)
+}
+
+// NewQuoteFromPBFile - read a protobuf Quote file written by WritePB
+func NewQuoteFromPBFile(filename string) (Quote, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return NewQuote("", 0), err
+	}
+	return UnmarshalQuote(data)
+}
+
+// NewQuotesFromPBFile - read a protobuf Quotes file written by WritePB
+func NewQuotesFromPBFile(filename string) (Quotes, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return Quotes{}, err
+	}
+	return UnmarshalQuotes(data)
+}
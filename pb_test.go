@@ -0,0 +1,143 @@
+package quote
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMarshalPB(t *testing.T) {
+	q := NewQuote("a", 1)
+	q.Date[0] = time.Unix(0, int64(time.Millisecond)).UTC()
+	q.Open[0], q.High[0], q.Low[0], q.Close[0] = 1, 1, 1, 1
+	data, err := q.Marshal()
+	ok(t, err)
+	one := []byte{0, 0, 0, 0, 0, 0, 0xf0, 0x3f}
+	want := []byte{0x0a, 1, 'a', 0x12, 1, 1}
+	for _, tag := range []byte{0x1a, 0x22, 0x2a, 0x32} {
+		want = append(append(want, tag, 8), one...)
+	}
+	want = append(want, 0x3a, 8, 0, 0, 0, 0, 0, 0, 0, 0)
+	equals(t, want, data)
+
+	back, err := UnmarshalQuote(data)
+	ok(t, err)
+	equals(t, q, back)
+
+	// fields unpacked, out of order and unknown
+	unpacked := []byte{0x10, 1, 0x78, 5}
+	for _, tag := range []byte{0x19, 0x21, 0x29, 0x31} {
+		unpacked = append(append(unpacked, tag), one...)
+	}
+	unpacked = append(unpacked, 0x39, 0, 0, 0, 0, 0, 0, 0, 0, 0x0a, 1, 'a')
+	back, err = UnmarshalQuote(unpacked)
+	ok(t, err)
+	equals(t, q, back)
+
+	_, err = UnmarshalQuote(data[:len(data)-1])
+	assert(t, err != nil, "truncated message accepted")
+	_, err = UnmarshalQuote(data[:len(data)-10])
+	assert(t, err != nil, "quote without volume accepted")
+}
+
+func TestPBRoundTrip(t *testing.T) {
+	quotes := partitionFixture()
+	quotes[0].Date[2] = time.Date(1969, 7, 20, 20, 17, 40, 0, time.UTC) // before the epoch
+	quotes[0].Date[3] = time.Date(2023, 4, 3, 9, 30, 0, 123*int(time.Millisecond), time.UTC)
+	quotes[0].AdjClose = []float64{99.5, 100.5, 101.5, 102.5}
+	quotes[1].Session = []float64{SessionPost}
+
+	data, err := quotes.Marshal()
+	ok(t, err)
+	back, err := UnmarshalQuotes(data)
+	ok(t, err)
+	equals(t, quotes, back)
+
+	// empty quotes
+	data, err = NewQuote("", 0).Marshal()
+	ok(t, err)
+	equals(t, 0, len(data))
+	q, err := UnmarshalQuote(data)
+	ok(t, err)
+	equals(t, NewQuote("", 0), q)
+	data, err = Quotes{}.Marshal()
+	ok(t, err)
+	all, err := UnmarshalQuotes(data)
+	ok(t, err)
+	equals(t, Quotes{}, all)
+
+	bad := NewQuote("bad", 2)
+	bad.Volume = bad.Volume[:1]
+	_, err = bad.Marshal()
+	assert(t, err != nil, "mismatched columns marshalled")
+}
+
+func TestWritePB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pb")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	quotes := partitionFixture()
+	name := filepath.Join(dir, "quotes.pb.gz")
+	ok(t, quotes.WritePB(name))
+	all, err := NewQuotesFromPBFile(name)
+	ok(t, err)
+	equals(t, quotes, all)
+
+	name = filepath.Join(dir, "spy.pb")
+	ok(t, quotes[0].WritePB(name))
+	q, err := NewQuoteFromPBFile(name)
+	ok(t, err)
+	equals(t, quotes[0], q)
+}
+
+// pbBenchQuote - 100k one minute bars
+func pbBenchQuote() Quote {
+	q := NewQuote("btcusd", 0)
+	d := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for bar := 0; bar < 100000; bar++ {
+		c := 20000 + float64(bar%1000)/3
+		q.PushBar(Bar{Date: d.Add(time.Duration(bar) * time.Minute), Open: c - 1, High: c + 2, Low: c - 2, Close: c, Volume: float64(bar % 97)})
+	}
+	return q
+}
+
+func BenchmarkMarshalPB(b *testing.B) {
+	q := pbBenchQuote()
+	b.ResetTimer()
+	var data []byte
+	for i := 0; i < b.N; i++ {
+		data, _ = q.Marshal()
+	}
+	b.ReportMetric(float64(len(data)), "bytes")
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	q := pbBenchQuote()
+	b.ResetTimer()
+	var data []byte
+	for i := 0; i < b.N; i++ {
+		data, _ = json.Marshal(q)
+	}
+	b.ReportMetric(float64(len(data)), "bytes")
+}
+
+func BenchmarkUnmarshalPB(b *testing.B) {
+	data, _ := pbBenchQuote().Marshal()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		UnmarshalQuote(data)
+	}
+}
+
+func BenchmarkUnmarshalJSON(b *testing.B) {
+	data, _ := json.Marshal(pbBenchQuote())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var q Quote
+		json.Unmarshal(data, &q)
+	}
+}
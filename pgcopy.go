@@ -0,0 +1,104 @@
+package quote
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pgPlainIdent - identifiers postgres takes as is, without quotes
+var pgPlainIdent = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+// pgTable - table, optionally schema qualified, as a postgres identifier,
+// quoting the parts that need it
+func pgTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		if !pgPlainIdent.MatchString(part) {
+			parts[i] = `"` + strings.Replace(part, `"`, `""`, -1) + `"`
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// pgCopyEscaper - escapes of the COPY text format
+var pgCopyEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// pgFloat - v as a COPY text value, NaN and infinities as postgres spells them
+func pgFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// PgCopy - convert Quotes structure to a psql script loading it into table,
+// see WritePgCopy
+func (q Quotes) PgCopy(table string) string {
+	var buffer bytes.Buffer
+	q.writePgCopy(&buffer, table)
+	return buffer.String()
+}
+
+// writePgCopy - stream Quotes structure as a psql COPY script to w
+func (q Quotes) writePgCopy(w io.Writer, table string) error {
+	if table == "" {
+		table = "quotes"
+	}
+	table = pgTable(table)
+	_, err := fmt.Fprintf(w, "CREATE TABLE IF NOT EXISTS %s (\n"+
+		"    symbol text NOT NULL,\n"+
+		"    ts timestamptz NOT NULL,\n"+
+		"    open double precision,\n"+
+		"    high double precision,\n"+
+		"    low double precision,\n"+
+		"    close double precision,\n"+
+		"    volume double precision,\n"+
+		"    PRIMARY KEY (symbol, ts)\n"+
+		");\n"+
+		"COPY %s (symbol, ts, open, high, low, close, volume) FROM stdin;\n", table, table)
+	if err != nil {
+		return err
+	}
+
+	for _, quote := range q {
+		symbol := pgCopyEscaper.Replace(quote.Symbol)
+		for bar := range quote.Close {
+			_, err := io.WriteString(w, strings.Join([]string{
+				symbol,
+				quote.Date[bar].UTC().Format(time.RFC3339Nano),
+				pgFloat(quote.Open[bar]),
+				pgFloat(quote.High[bar]),
+				pgFloat(quote.Low[bar]),
+				pgFloat(quote.Close[bar]),
+				pgFloat(quote.Volume[bar]),
+			}, "\t")+"\n")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	_, err = io.WriteString(w, "\\.\n")
+	return err
+}
+
+// WritePgCopy - write Quotes structure as a psql script, run with
+// psql -f filename, that creates table if it does not exist and bulk loads
+// the bars with COPY. Times are UTC. Bars already in the table fail the COPY
+// on its primary key. Gzip compressed if filename ends in .gz.
+func (q Quotes) WritePgCopy(filename, table string) error {
+	if filename == "" {
+		filename = "quotes.sql"
+	}
+	return writeStream(filename, func(w io.Writer) error {
+		return q.writePgCopy(w, table)
+	})
+}
@@ -0,0 +1,48 @@
+package quote
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPgCopy(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	ok(t, err)
+	odd := NewQuote("a\\b\tc\nd", 2)
+	odd.Date[0] = time.Date(2023, 3, 1, 9, 30, 0, 0, ny)
+	odd.Date[1] = time.Date(2023, 3, 1, 9, 31, 0, 500, ny)
+	odd.Open[0], odd.Close[0], odd.Volume[0] = 1.25, 0.1, 1e9
+	odd.High[1] = math.Inf(1)
+
+	lines := strings.Split(Quotes{odd}.PgCopy("public.Quotes"), "\n")
+	equals(t, `CREATE TABLE IF NOT EXISTS public."Quotes" (`, lines[0])
+	equals(t, `COPY public."Quotes" (symbol, ts, open, high, low, close, volume) FROM stdin;`, lines[10])
+	equals(t, "a\\\\b\\tc\\nd\t2023-03-01T14:30:00Z\t1.25\t0\t0\t0.1\t1000000000", lines[11])
+	equals(t, "a\\\\b\\tc\\nd\t2023-03-01T14:31:00.0000005Z\t0\tInfinity\t0\t0\t0", lines[12])
+	equals(t, []string{`\.`, ""}, lines[13:])
+
+	// no bars still terminates the COPY
+	lines = strings.Split(Quotes{}.PgCopy(""), "\n")
+	equals(t, "CREATE TABLE IF NOT EXISTS quotes (", lines[0])
+	equals(t, "COPY quotes (symbol, ts, open, high, low, close, volume) FROM stdin;", lines[10])
+	equals(t, []string{`\.`, ""}, lines[11:])
+}
+
+func TestWritePgCopy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgcopy")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	quotes := partitionFixture()
+	name := filepath.Join(dir, "quotes.sql")
+	ok(t, quotes.WritePgCopy(name, "bars"))
+	sql, err := ioutil.ReadFile(name)
+	ok(t, err)
+	equals(t, quotes.PgCopy("bars"), string(sql))
+	assert(t, strings.Contains(string(sql), "\nBTC/USD\t2023-02-14T00:00:00Z\t0\t0\t0\t22000\t0\n"), "missing btc row: %s", sql)
+}
@@ -0,0 +1,201 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// polygonURL - base url of the Polygon.io api
+var polygonURL = "https://api.polygon.io"
+
+// polygonPeriods - aggregate bar sizes of the Polygon.io api
+var polygonPeriods = []Period{Min1, Min3, Min5, Min15, Min30, Min60, Hour2, Hour4, Hour6, Hour8, Hour12, Daily, Day3, Weekly, Monthly, Yearly}
+
+// polygonRange - multiplier and timespan of an aggregates request
+type polygonRange struct {
+	multiplier int
+	timespan   string
+}
+
+// polygonRanges - aggregate range of each supported period
+var polygonRanges = map[Period]polygonRange{
+	Min1:    {1, "minute"},
+	Min3:    {3, "minute"},
+	Min5:    {5, "minute"},
+	Min15:   {15, "minute"},
+	Min30:   {30, "minute"},
+	Min60:   {1, "hour"},
+	Hour2:   {2, "hour"},
+	Hour4:   {4, "hour"},
+	Hour6:   {6, "hour"},
+	Hour8:   {8, "hour"},
+	Hour12:  {12, "hour"},
+	Daily:   {1, "day"},
+	Day3:    {3, "day"},
+	Weekly:  {1, "week"},
+	Monthly: {1, "month"},
+	Yearly:  {1, "year"},
+}
+
+// PolygonError - a Polygon.io request that failed, by http status or an
+// ERROR status in the response
+type PolygonError struct {
+	Status  int // http status code
+	Message string
+}
+
+func (e *PolygonError) Error() string {
+	return fmt.Sprintf("polygon %d: %s", e.Status, e.Message)
+}
+
+// Temporary - true if the request may succeed when retried, after the rate
+// limit window has passed
+func (e *PolygonError) Temporary() bool {
+	return e.Status == http.StatusTooManyRequests
+}
+
+// polygonAggs - one page of an aggregates response
+type polygonAggs struct {
+	Status  string `json:"status"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Results []struct {
+		T int64   `json:"t"` // bar start, milliseconds since the epoch
+		O float64 `json:"o"`
+		H float64 `json:"h"`
+		L float64 `json:"l"`
+		C float64 `json:"c"`
+		V float64 `json:"v"`
+	} `json:"results"`
+	NextURL string `json:"next_url"`
+}
+
+// NewQuoteFromPolygon - Polygon.io split adjusted aggregate bars for a symbol.
+// Intraday bars are timestamped in UTC, daily and longer bars by date. Pages
+// of a long range are followed with Delay between requests. A rate limited
+// request returns a *PolygonError that is Temporary.
+func NewQuoteFromPolygon(symbol, startDate, endDate string, period Period, apiKey string) (Quote, error) {
+	return NewQuoteFromPolygonCtx(context.Background(), symbol, startDate, endDate, period, apiKey)
+}
+
+// NewQuoteFromPolygonCtx - NewQuoteFromPolygon, canceled with ctx. A download
+// canceled between its pages returns the bars so far with the wrapped error of
+// ctx.
+func NewQuoteFromPolygonCtx(ctx context.Context, symbol, startDate, endDate string, period Period, apiKey string) (Quote, error) {
+
+	if err := ValidatePeriod("polygon", period); err != nil {
+		Log.Printf("polygon error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	r := polygonRanges[period]
+
+	from := ParseDateString(startDate)
+	to := ParseDateString(endDate)
+
+	url := fmt.Sprintf(
+		"%s/v2/aggs/ticker/%s/range/%d/%s/%s/%s?adjusted=true&sort=asc&limit=50000",
+		polygonURL,
+		symbol,
+		r.multiplier,
+		r.timespan,
+		from.Format("2006-01-02"),
+		to.Format("2006-01-02"))
+
+	quote := NewQuote(symbol, 0)
+	for page := 0; url != ""; page++ {
+		if page > 0 && delayContext(ctx, "polygon") != nil {
+			return quote.withMeta("polygon", period, true), interrupted(ctx, "polygon %s after %d pages", symbol, page)
+		}
+		aggs, err := polygonPage(ctx, url, apiKey)
+		if err != nil && ctx.Err() != nil {
+			return quote.withMeta("polygon", period, true), interrupted(ctx, "polygon %s after %d pages", symbol, page)
+		}
+		if err != nil {
+			Log.Printf("polygon %s error: %v\n", symbol, err)
+			return NewQuote("", 0), err
+		}
+		if aggs.Status == "DELAYED" && page == 0 {
+			Log.Printf("polygon %s: delayed data\n", symbol)
+		}
+		for _, bar := range aggs.Results {
+			date := time.Unix(0, bar.T*int64(time.Millisecond)).UTC()
+			if period.Duration() >= 24*time.Hour {
+				// midnight New York, the same date in UTC
+				date = date.Truncate(24 * time.Hour)
+			}
+			quote.PushBar(Bar{Date: date, Open: bar.O, High: bar.H, Low: bar.L, Close: bar.C, Volume: bar.V})
+		}
+		url = aggs.NextURL
+	}
+	return quote.withMeta("polygon", period, true), nil
+}
+
+// polygonPage - an aggregates page, the api key sent as a bearer token,
+// canceled with ctx
+func polygonPage(ctx context.Context, url, apiKey string) (polygonAggs, error) {
+
+	var aggs polygonAggs
+	req, err := newRequest(ctx, url)
+	if err != nil {
+		return aggs, err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	if err := waitRate(ctx, "polygon"); err != nil {
+		return aggs, err
+	}
+	client := httpClient()
+	resp, err := client.Do(req)
+	if err != nil {
+		return aggs, err
+	}
+	defer resp.Body.Close()
+	contents, _ := ioutil.ReadAll(resp.Body)
+
+	jsonErr := json.Unmarshal(contents, &aggs)
+	msg := aggs.Error
+	if msg == "" {
+		msg = aggs.Message
+	}
+	if resp.StatusCode != http.StatusOK {
+		if msg == "" {
+			msg = http.StatusText(resp.StatusCode)
+		}
+		return aggs, &PolygonError{Status: resp.StatusCode, Message: msg}
+	}
+	if jsonErr != nil {
+		return aggs, jsonErr
+	}
+	if aggs.Status == "ERROR" {
+		return aggs, &PolygonError{Status: resp.StatusCode, Message: msg}
+	}
+	return aggs, nil
+}
+
+// NewQuotesFromPolygonSyms - create a list of prices from symbols in string array
+func NewQuotesFromPolygonSyms(symbols []string, startDate, endDate string, period Period, apiKey string) (Quotes, error) {
+	return NewQuotesFromPolygonSymsCtx(context.Background(), symbols, startDate, endDate, period, apiKey)
+}
+
+// NewQuotesFromPolygonSymsCtx - NewQuotesFromPolygonSyms, canceled with ctx.
+// The quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromPolygonSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period, apiKey string) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromPolygonCtx(ctx, symbol, startDate, endDate, period, apiKey)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "polygon after %d of %d symbols", i, len(symbols))
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + symbol)
+		}
+		delayContext(ctx, "polygon")
+	}
+	return quotes, nil
+}
@@ -0,0 +1,71 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakePolygon - aggregates endpoint serving two pages of daily bars, the
+// first linking to the second by next_url
+func fakePolygon(paths *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.Path)
+		switch {
+		case r.Header.Get("Authorization") != "Bearer key":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"status":"ERROR","request_id":"1","error":"Unknown API Key"}`)
+		case strings.Contains(r.URL.Path, "/BUSY/"):
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"status":"ERROR","request_id":"2","error":"You've exceeded the maximum requests per minute."}`)
+		case strings.Contains(r.URL.Path, "/BAD/"):
+			fmt.Fprint(w, `{"status":"ERROR","request_id":"3","error":"Could not parse the time parameter: 'from'."}`)
+		case r.URL.Path == "/page2":
+			fmt.Fprint(w, `{"ticker":"AAPL","status":"DELAYED","results":[
+{"v":5.4e7,"vw":150.1,"o":150.2,"c":151.0,"h":152.3,"l":149.9,"t":1677819600000,"n":450000}]}`)
+		default:
+			// 2023-03-01 and 2023-03-02 at midnight New York
+			fmt.Fprintf(w, `{"ticker":"AAPL","status":"DELAYED","results":[
+{"v":5.5e7,"vw":146.3,"o":146.8,"c":145.3,"h":147.2,"l":145.0,"t":1677646800000,"n":460000},
+{"v":5.2e7,"vw":145.9,"o":144.4,"c":145.9,"h":146.7,"l":143.9,"t":1677733200000,"n":440000}],
+"next_url":"http://%s/page2"}`, r.Host)
+		}
+	}
+}
+
+func TestPolygon(t *testing.T) {
+	var paths []string
+	fakeServer(t, &polygonURL, fakePolygon(&paths))
+
+	q, err := NewQuoteFromPolygon("AAPL", "2023-03-01", "2023-03-03", Daily, "key")
+	ok(t, err)
+	equals(t, []string{"/v2/aggs/ticker/AAPL/range/1/day/2023-03-01/2023-03-03", "/page2"}, paths)
+	equals(t, []time.Time{day(2023, 3, 1), day(2023, 3, 2), day(2023, 3, 3)}, q.Date)
+	equals(t, []float64{145.3, 145.9, 151.0}, q.Close)
+	equals(t, 5.4e7, q.Volume[2])
+
+	paths = nil
+	_, err = NewQuoteFromPolygon("AAPL", "2023-03-01", "2023-03-03", Min5, "key")
+	ok(t, err)
+	equals(t, "/v2/aggs/ticker/AAPL/range/5/minute/2023-03-01/2023-03-03", paths[0])
+
+	for _, tt := range []struct {
+		symbol, key string
+		status      int
+		temporary   bool
+		message     string
+	}{
+		{"AAPL", "nokey", 401, false, "Unknown API Key"},
+		{"BUSY", "key", 429, true, "You've exceeded the maximum requests per minute."},
+		{"BAD", "key", 200, false, "Could not parse the time parameter: 'from'."},
+	} {
+		_, err = NewQuoteFromPolygon(tt.symbol, "2023-03-01", "2023-03-03", Daily, tt.key)
+		pe, isPolygon := err.(*PolygonError)
+		assert(t, isPolygon, "%s: unexpected error %v", tt.symbol, err)
+		equals(t, tt.status, pe.Status)
+		equals(t, tt.temporary, pe.Temporary())
+		equals(t, tt.message, pe.Message)
+	}
+}
@@ -0,0 +1,147 @@
+package quote
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"time"
+)
+
+// FatFingerReturn - absolute single bar return treated as a suspicious tick
+const FatFingerReturn = 0.5
+
+// SymbolQuality - data quality summary for one symbol
+type SymbolQuality struct {
+	Symbol            string
+	Bars              int
+	First             time.Time
+	Last              time.Time
+	Missing           int     // bars expected by period/calendar but absent
+	OHLCViolations    int     // bars with inconsistent or negative prices
+	ZeroVolumeRatio   float64 // fraction of bars with zero volume
+	Duplicates        int     // bars repeating an earlier timestamp
+	LargestReturn     float64 // largest absolute close-to-close return
+	LargestReturnDate time.Time
+	Score             float64 // 0 (unusable) to 100 (clean)
+}
+
+// QualityReport - data quality summary for a set of symbols
+type QualityReport struct {
+	Symbols    []SymbolQuality
+	TotalScore float64 // mean of the symbol scores
+}
+
+// ohlcViolation - true if bar has inconsistent or negative prices
+func (q Quote) ohlcViolation(bar int) bool {
+	return len(q.ohlcProblems(bar)) > 0
+}
+
+// quality - data quality summary for a single quote
+func (q Quote) quality(period Period, cal Calendar) SymbolQuality {
+
+	sq := SymbolQuality{Symbol: q.Symbol, Bars: len(q.Close)}
+	if sq.Bars == 0 {
+		return sq
+	}
+	sq.First = q.Date[0]
+	sq.Last = q.Date[sq.Bars-1]
+	sq.Missing = len(q.gaps(period, cal))
+
+	zero := 0
+	seen := make(map[time.Time]bool, sq.Bars)
+	for bar := 0; bar < sq.Bars; bar++ {
+		if q.ohlcViolation(bar) {
+			sq.OHLCViolations++
+		}
+		if q.Volume[bar] == 0 {
+			zero++
+		}
+		d := q.Date[bar].UTC()
+		if seen[d] {
+			sq.Duplicates++
+		}
+		seen[d] = true
+		if bar > 0 && q.Close[bar-1] != 0 {
+			r := math.Abs(q.Close[bar]/q.Close[bar-1] - 1)
+			if r > sq.LargestReturn {
+				sq.LargestReturn = r
+				sq.LargestReturnDate = q.Date[bar]
+			}
+		}
+	}
+	sq.ZeroVolumeRatio = float64(zero) / float64(sq.Bars)
+
+	// each defect class costs up to its share of the score
+	n := float64(sq.Bars)
+	penalty := float64(sq.Missing)/(n+float64(sq.Missing)) +
+		float64(sq.OHLCViolations)/n +
+		float64(sq.Duplicates)/n +
+		sq.ZeroVolumeRatio/2
+	if sq.LargestReturn > FatFingerReturn {
+		penalty += 0.1
+	}
+	sq.Score = 100 * math.Max(0, 1-penalty)
+	return sq
+}
+
+// QualityReport - data quality report for every symbol. Missing bars are
+// counted against period and cal, a nil cal means AllDays. An empty period
+// is taken from each quote's metadata or inferred from its bars.
+func (q Quotes) QualityReport(period Period, cal *Calendar) QualityReport {
+	c := calendarOrDefault(cal)
+	report := QualityReport{}
+	for _, quote := range q {
+		sq := quote.quality(quote.periodOrInfer(period), c)
+		report.Symbols = append(report.Symbols, sq)
+		report.TotalScore += sq.Score
+	}
+	if len(report.Symbols) > 0 {
+		report.TotalScore /= float64(len(report.Symbols))
+	}
+	return report
+}
+
+func formatQualityDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// String - render report as an aligned text table
+func (r QualityReport) String() string {
+	var buffer bytes.Buffer
+	format := "%-12s %8s %-16s %-16s %8s %8s %8s %8s %10s %6s\n"
+	buffer.WriteString(fmt.Sprintf(format, "symbol", "bars", "first", "last", "missing", "ohlc", "zerovol", "dups", "maxret", "score"))
+	for _, s := range r.Symbols {
+		buffer.WriteString(fmt.Sprintf(format, s.Symbol, fmt.Sprint(s.Bars), formatQualityDate(s.First), formatQualityDate(s.Last),
+			fmt.Sprint(s.Missing), fmt.Sprint(s.OHLCViolations), fmt.Sprintf("%.1f%%", 100*s.ZeroVolumeRatio),
+			fmt.Sprint(s.Duplicates), fmt.Sprintf("%.1f%%", 100*s.LargestReturn), fmt.Sprintf("%.1f", s.Score)))
+	}
+	buffer.WriteString(fmt.Sprintf("total score: %.1f\n", r.TotalScore))
+	return buffer.String()
+}
+
+// CSV - render report as csv
+func (r QualityReport) CSV() string {
+	var buffer bytes.Buffer
+	buffer.WriteString("symbol,bars,first,last,missing,ohlc_violations,zero_volume_ratio,duplicates,largest_return,largest_return_date,score\n")
+	for _, s := range r.Symbols {
+		buffer.WriteString(fmt.Sprintf("%s,%d,%s,%s,%d,%d,%.4f,%d,%.4f,%s,%.2f\n",
+			s.Symbol, s.Bars, formatQualityDate(s.First), formatQualityDate(s.Last), s.Missing, s.OHLCViolations,
+			s.ZeroVolumeRatio, s.Duplicates, s.LargestReturn, formatQualityDate(s.LargestReturnDate), s.Score))
+	}
+	return buffer.String()
+}
+
+// Append - combine two reports, recomputing the total score
+func (r QualityReport) Append(other QualityReport) QualityReport {
+	combined := QualityReport{Symbols: append(append([]SymbolQuality(nil), r.Symbols...), other.Symbols...)}
+	for _, s := range combined.Symbols {
+		combined.TotalScore += s.Score
+	}
+	if len(combined.Symbols) > 0 {
+		combined.TotalScore /= float64(len(combined.Symbols))
+	}
+	return combined
+}
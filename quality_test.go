@@ -0,0 +1,134 @@
+package quote
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// cleanDaily - n weekday bars starting monday 2024-01-01 with sane prices
+func cleanDaily(symbol string, n int) Quote {
+	q := NewQuote(symbol, n)
+	d := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for bar := 0; bar < n; bar++ {
+		for d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			d = d.AddDate(0, 0, 1)
+		}
+		q.Date[bar] = d
+		q.Open[bar] = 100
+		q.High[bar] = 102
+		q.Low[bar] = 99
+		q.Close[bar] = 101
+		q.Volume[bar] = 1000
+		d = d.AddDate(0, 0, 1)
+	}
+	return q
+}
+
+func removeBar(q Quote, bar int) Quote {
+	r := NewQuote(q.Symbol, 0)
+	for i := range q.Close {
+		if i == bar {
+			continue
+		}
+		r.Date = append(r.Date, q.Date[i])
+		r.Open = append(r.Open, q.Open[i])
+		r.High = append(r.High, q.High[i])
+		r.Low = append(r.Low, q.Low[i])
+		r.Close = append(r.Close, q.Close[i])
+		r.Volume = append(r.Volume, q.Volume[i])
+	}
+	return r
+}
+
+func TestGaps(t *testing.T) {
+	q := removeBar(removeBar(cleanDaily("spy", 10), 2), 2) // drop wed and thu
+	equals(t, []time.Time{
+		time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+	}, q.gaps(Daily, WeekdaysOnly))
+	// on an all days calendar the weekend is missing too
+	equals(t, 4, len(q.gaps(Daily, AllDays)))
+
+	holiday := Calendar{Weekdays: true, Holidays: []time.Time{time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)}}
+	equals(t, 1, len(q.gaps(Daily, holiday)))
+}
+
+func TestGapsIntradayAndMonthly(t *testing.T) {
+	q := NewQuote("btcusd", 3)
+	q.Date[0] = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	q.Date[1] = time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC)
+	q.Date[2] = time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	equals(t, 4, len(q.gaps(Min5, AllDays)))
+
+	// session 10:00-10:15 only expects the 10:10 bar
+	session := Calendar{SessionStart: 10 * time.Hour, SessionEnd: 10*time.Hour + 15*time.Minute}
+	equals(t, []time.Time{time.Date(2024, 1, 1, 10, 10, 0, 0, time.UTC)}, q.gaps(Min5, session))
+
+	m := NewQuote("spy", 3)
+	m.Date[0] = time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	m.Date[1] = time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	m.Date[2] = time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+	equals(t, []time.Time{
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+	}, m.gaps(Monthly, AllDays))
+}
+
+func TestQualityReport(t *testing.T) {
+	clean := cleanDaily("clean", 20)
+
+	missing := removeBar(removeBar(cleanDaily("missing", 20), 5), 5)
+
+	ohlc := cleanDaily("ohlc", 20)
+	ohlc.High[3] = 98  // high below open/close
+	ohlc.Low[7] = 103  // low above high
+	ohlc.Close[9] = -1 // negative price
+
+	zerovol := cleanDaily("zerovol", 20)
+	for bar := 0; bar < 5; bar++ {
+		zerovol.Volume[bar] = 0
+	}
+
+	dups := cleanDaily("dups", 20)
+	dups.Date[11] = dups.Date[10]
+
+	fat := cleanDaily("fat", 20)
+	fat.Close[12] = 1010 // 100x bad tick
+	fat.High[12] = 1010
+
+	report := Quotes{clean, missing, ohlc, zerovol, dups, fat}.QualityReport(Daily, &WeekdaysOnly)
+	equals(t, 6, len(report.Symbols))
+
+	c := report.Symbols[0]
+	equals(t, 20, c.Bars)
+	equals(t, clean.Date[0], c.First)
+	equals(t, clean.Date[19], c.Last)
+	equals(t, 0, c.Missing)
+	equals(t, 0, c.OHLCViolations)
+	equals(t, 0, c.Duplicates)
+	equals(t, 100.0, c.Score)
+
+	equals(t, 2, report.Symbols[1].Missing)
+	equals(t, 3, report.Symbols[2].OHLCViolations)
+	equals(t, 0.25, report.Symbols[3].ZeroVolumeRatio)
+	equals(t, 1, report.Symbols[4].Duplicates)
+	assert(t, report.Symbols[5].LargestReturn > 8.9, "expected fat finger return, got %v", report.Symbols[5].LargestReturn)
+	equals(t, fat.Date[12], report.Symbols[5].LargestReturnDate)
+
+	for _, s := range report.Symbols[1:] {
+		assert(t, s.Score < 100, "expected penalty for %s", s.Symbol)
+	}
+	assert(t, report.TotalScore < 100 && report.TotalScore > 0, "unexpected total score %v", report.TotalScore)
+
+	csv := report.CSV()
+	equals(t, 7, strings.Count(csv, "\n"))
+	assert(t, strings.HasPrefix(csv, "symbol,bars,"), "missing csv header")
+	assert(t, strings.Contains(report.String(), "total score:"), "missing total score")
+}
+
+func TestQualityReportEmpty(t *testing.T) {
+	report := Quotes{NewQuote("empty", 0)}.QualityReport(Daily, nil)
+	equals(t, 0, report.Symbols[0].Bars)
+	equals(t, 0.0, report.TotalScore)
+}
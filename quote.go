@@ -12,10 +12,11 @@ package quote
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net"
@@ -27,19 +28,47 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Quote - stucture for historical price data
 type Quote struct {
 	Symbol    string      `json:"symbol"`
-	Precision int64       `json:"-"`
+	Precision int64       `json:"-"` // decimals of written prices, DefaultPrecision when 0
 	Date      []time.Time `json:"date"`
 	Open      []float64   `json:"open"`
 	High      []float64   `json:"high"`
 	Low       []float64   `json:"low"`
 	Close     []float64   `json:"close"`
 	Volume    []float64   `json:"volume"`
+	// optional columns, nil when the source does not provide them
+	Trades       []float64 `json:"trades,omitempty"`
+	OpenInterest []float64 `json:"openinterest,omitempty"`
+	AdjClose     []float64 `json:"adjclose,omitempty"` // provider's split and dividend adjusted close
+	Session      []float64 `json:"session,omitempty"`  // trading session of extended hours intraday bars
+	AdjOpen      []float64 `json:"adjopen,omitempty"`  // provider's adjusted open, high and low, alongside AdjClose
+	AdjHigh      []float64 `json:"adjhigh,omitempty"`
+	AdjLow       []float64 `json:"adjlow,omitempty"`
+	// provenance, set by the fetchers, nil when unknown
+	Meta *Metadata `json:"-"`
+}
+
+// Values of the Session column
+const (
+	SessionPre     = -1.0 // pre-market, before the regular session
+	SessionRegular = 0.0  // regular trading hours
+	SessionPost    = 1.0  // after hours, after the regular session
+)
+
+// Bar - a single bar of historical price data
+type Bar struct {
+	Date   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
 }
 
 // Quotes - an array of historical price data
@@ -51,6 +80,19 @@ type Period string
 // ClientTimeout - connect/read timeout for client requests
 const ClientTimeout = 10 * time.Second
 
+// HTTPClient - client of all HTTP requests of the package, replace it to add
+// a proxy, a recording transport or metrics. Yahoo requests use a copy with
+// the cookie jar of their YahooSession when it has none.
+var HTTPClient = &http.Client{Timeout: ClientTimeout}
+
+// httpClient - HTTPClient, a client with ClientTimeout if it is nil
+func httpClient() *http.Client {
+	if HTTPClient == nil {
+		return &http.Client{Timeout: ClientTimeout}
+	}
+	return HTTPClient
+}
+
 const (
 	// Min1 - 1 Minute time period
 	Min1 Period = "60"
@@ -82,13 +124,94 @@ const (
 	Weekly Period = "w"
 	// Monthly time period
 	Monthly Period = "m"
+	// Yearly time period
+	Yearly Period = "y"
 )
 
+// Duration - nominal length of one bar of period p, zero if unknown.
+// Monthly bars are nominally 30 days long, yearly bars 365 days.
+func (p Period) Duration() time.Duration {
+	switch p {
+	case Min1:
+		return time.Minute
+	case Min3:
+		return 3 * time.Minute
+	case Min5:
+		return 5 * time.Minute
+	case Min15:
+		return 15 * time.Minute
+	case Min30:
+		return 30 * time.Minute
+	case Min60:
+		return time.Hour
+	case Hour2:
+		return 2 * time.Hour
+	case Hour4:
+		return 4 * time.Hour
+	case Hour6:
+		return 6 * time.Hour
+	case Hour8:
+		return 8 * time.Hour
+	case Hour12:
+		return 12 * time.Hour
+	case Daily:
+		return 24 * time.Hour
+	case Day3:
+		return 3 * 24 * time.Hour
+	case Weekly:
+		return 7 * 24 * time.Hour
+	case Monthly:
+		return 30 * 24 * time.Hour
+	case Yearly:
+		return 365 * 24 * time.Hour
+	}
+	return 0
+}
+
+// periods - all periods, shortest first
+var periods = []Period{Min1, Min3, Min5, Min15, Min30, Min60, Hour2, Hour4, Hour6, Hour8, Hour12, Daily, Day3, Weekly, Monthly, Yearly}
+
+// periodAliases - other accepted names of periods
+var periodAliases = map[string]Period{"1d": Daily, "1w": Weekly, "1M": Monthly}
+
+// ParsePeriod - the period named s, by its Name, e.g. 5m, 1h or d, its
+// value, e.g. 300, or one of the aliases 1d, 1w and 1M
+func ParsePeriod(s string) (Period, error) {
+	names := make([]string, len(periods))
+	for i, p := range periods {
+		if s == p.Name() || s == string(p) {
+			return p, nil
+		}
+		names[i] = p.Name()
+	}
+	if p, ok := periodAliases[s]; ok {
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid period '%s', must be one of %s", s, strings.Join(names, ", "))
+}
+
+// Name - short name of period p as used on the command line, e.g. 5m, 1h or d
+func (p Period) Name() string {
+	switch p {
+	case Min1:
+		return "1m"
+	case Min5:
+		return "5m"
+	case Min15:
+		return "15m"
+	case Min30:
+		return "30m"
+	case Min60:
+		return "1h"
+	}
+	return string(p)
+}
+
 // Log - standard logger, disabled by default
 var Log *log.Logger
 
 // Delay - time delay in milliseconds between quote requests (default=100)
-// Be nice, don't get blocked
+// to sources without a limiter of SetRateLimit. Be nice, don't get blocked
 var Delay time.Duration
 
 func init() {
@@ -118,6 +241,39 @@ func ParseDateString(dt string) time.Time {
 	return t
 }
 
+// parseEndDate - exclusive upper bound for an inclusive end date string, the
+// following midnight for a plain date or just after a given time of day
+func parseEndDate(dt string) time.Time {
+	t := ParseDateString(dt)
+	if t.Equal(t.Truncate(24 * time.Hour)) {
+		return t.AddDate(0, 0, 1)
+	}
+	return t.Add(time.Nanosecond)
+}
+
+// FullPrecision - precision writing prices with the fewest digits that read
+// back as the same float64
+const FullPrecision = -1
+
+// DefaultPrecision - decimals of prices written by CSV, Highstock, Amibroker,
+// MetaTrader and xlsx, FullPrecision or by symbol (2, or 8 for crypto) when 0
+var DefaultPrecision = 0
+
+// precision - decimals of written prices of q, the first non-zero of
+// precision, q.Precision, DefaultPrecision and that of its symbol
+func (q Quote) precision(precision int) int {
+	if precision == 0 {
+		precision = int(q.Precision)
+	}
+	if precision == 0 {
+		precision = DefaultPrecision
+	}
+	if precision == 0 {
+		precision = getPrecision(q.Symbol)
+	}
+	return precision
+}
+
 func getPrecision(symbol string) int {
 	var precision int
 	precision = 2
@@ -131,70 +287,72 @@ func getPrecision(symbol string) int {
 
 // CSV - convert Quote structure to csv string
 func (q Quote) CSV() string {
+	var buffer bytes.Buffer
+	q.writeCSVWithOptions(&buffer, DefaultCSVOptions)
+	return buffer.String()
+}
 
-	precision := getPrecision(q.Symbol)
+// WriteCSVTo - stream Quote structure as csv to w
+func (q Quote) WriteCSVTo(w io.Writer) error {
+	return buffered(w, func(w io.Writer) error {
+		return q.writeCSVWithOptions(w, DefaultCSVOptions)
+	})
+}
 
+// Highstock - convert Quote structure to Highstock json format, downsampled
+// by Bucket to at most the optional maxPoints bars
+func (q Quote) Highstock(maxPoints ...int) string {
 	var buffer bytes.Buffer
-	buffer.WriteString("datetime,open,high,low,close,volume\n")
-	for bar := range q.Close {
-		str := fmt.Sprintf("%s,%.*f,%.*f,%.*f,%.*f,%.*f\n", q.Date[bar].Format("2006-01-02 15:04"),
-			precision, q.Open[bar], precision, q.High[bar], precision, q.Low[bar], precision, q.Close[bar], precision, q.Volume[bar])
-		buffer.WriteString(str)
-	}
+	q.highstockPoints(maxPoints).writeHighstock(&buffer)
 	return buffer.String()
 }
 
-// Highstock - convert Quote structure to Highstock json format
-func (q Quote) Highstock() string {
-
-	precision := getPrecision(q.Symbol)
+// writeHighstockBars - stream the bars of q as Highstock json arrays to w
+func (q Quote) writeHighstockBars(w io.Writer) error {
 
-	var buffer bytes.Buffer
-	buffer.WriteString("[\n")
+	precision := q.precision(0)
+	var line []byte
 	for bar := range q.Close {
-		comma := ","
-		if bar == len(q.Close)-1 {
-			comma = ""
+		line = append(line[:0], '[')
+		line = strconv.AppendInt(line, q.Date[bar].UnixNano()/1000000, 10)
+		for _, v := range []float64{q.Open[bar], q.High[bar], q.Low[bar], q.Close[bar], q.Volume[bar]} {
+			line = append(line, ',')
+			line = strconv.AppendFloat(line, v, 'f', precision, 64)
+		}
+		line = append(line, ']')
+		if bar < len(q.Close)-1 {
+			line = append(line, ',')
+		}
+		line = append(line, '\n')
+		if _, err := w.Write(line); err != nil {
+			return err
 		}
-		str := fmt.Sprintf("[%d,%.*f,%.*f,%.*f,%.*f,%.*f]%s\n",
-			q.Date[bar].UnixNano()/1000000, precision, q.Open[bar], precision, q.High[bar], precision, q.Low[bar], precision, q.Close[bar], precision, q.Volume[bar], comma)
-		buffer.WriteString(str)
-
 	}
-	buffer.WriteString("]\n")
-	return buffer.String()
+	return nil
 }
 
-// Amibroker - convert Quote structure to csv string
-func (q Quote) Amibroker() string {
+// WriteHighstockTo - stream Quote structure as Highstock json to w, with
+// the optional maxPoints of Highstock
+func (q Quote) WriteHighstockTo(w io.Writer, maxPoints ...int) error {
+	return buffered(w, q.highstockPoints(maxPoints).writeHighstock)
+}
 
-	precision := getPrecision(q.Symbol)
+// writeHighstock - Highstock json of q to w
+func (q Quote) writeHighstock(w io.Writer) error {
 
-	var buffer bytes.Buffer
-	buffer.WriteString("date,time,open,high,low,close,volume\n")
-	for bar := range q.Close {
-		str := fmt.Sprintf("%s,%s,%.*f,%.*f,%.*f,%.*f,%.*f\n", q.Date[bar].Format("2006-01-02"), q.Date[bar].Format("15:04"),
-			precision, q.Open[bar], precision, q.High[bar], precision, q.Low[bar], precision, q.Close[bar], precision, q.Volume[bar])
-		buffer.WriteString(str)
+	if _, err := io.WriteString(w, "[\n"); err != nil {
+		return err
 	}
-	return buffer.String()
-}
-
-// WriteCSV - write Quote struct to csv file
-func (q Quote) WriteCSV(filename string) error {
-	if filename == "" {
-		if q.Symbol != "" {
-			filename = q.Symbol + ".csv"
-		} else {
-			filename = "quote.csv"
-		}
+	if err := q.writeHighstockBars(w); err != nil {
+		return err
 	}
-	csv := q.CSV()
-	return ioutil.WriteFile(filename, []byte(csv), 0644)
+	_, err := io.WriteString(w, "]\n")
+	return err
 }
 
-// WriteAmibroker - write Quote struct to csv file
-func (q Quote) WriteAmibroker(filename string) error {
+// WriteCSV - write Quote struct to csv file, gzip compressed if filename
+// ends in .gz
+func (q Quote) WriteCSV(filename string) error {
 	if filename == "" {
 		if q.Symbol != "" {
 			filename = q.Symbol + ".csv"
@@ -202,12 +360,14 @@ func (q Quote) WriteAmibroker(filename string) error {
 			filename = "quote.csv"
 		}
 	}
-	csv := q.Amibroker()
-	return ioutil.WriteFile(filename, []byte(csv), 0644)
+	return writeStream(filename, func(w io.Writer) error {
+		return q.writeCSVWithOptions(w, DefaultCSVOptions)
+	})
 }
 
-// WriteHighstock - write Quote struct to Highstock json format
-func (q Quote) WriteHighstock(filename string) error {
+// WriteHighstock - write Quote struct to Highstock json format, with the
+// optional maxPoints of Highstock
+func (q Quote) WriteHighstock(filename string, maxPoints ...int) error {
 	if filename == "" {
 		if q.Symbol != "" {
 			filename = q.Symbol + ".json"
@@ -215,437 +375,663 @@ func (q Quote) WriteHighstock(filename string) error {
 			filename = "quote.json"
 		}
 	}
-	csv := q.Highstock()
-	return ioutil.WriteFile(filename, []byte(csv), 0644)
+	return writeStream(filename, q.highstockPoints(maxPoints).writeHighstock)
 }
 
 // NewQuoteFromCSV - parse csv quote string into Quote structure
 func NewQuoteFromCSV(symbol, csv string) (Quote, error) {
-
-	tmp := strings.Split(csv, "\n")
-	numrows := len(tmp)
-	q := NewQuote(symbol, numrows-1)
-
-	for row, bar := 1, 0; row < numrows; row, bar = row+1, bar+1 {
-		line := strings.Split(tmp[row], ",")
-		if len(line) != 6 {
-			break
-		}
-		q.Date[bar], _ = time.Parse("2006-01-02 15:04", line[0])
-		q.Open[bar], _ = strconv.ParseFloat(line[1], 64)
-		q.High[bar], _ = strconv.ParseFloat(line[2], 64)
-		q.Low[bar], _ = strconv.ParseFloat(line[3], 64)
-		q.Close[bar], _ = strconv.ParseFloat(line[4], 64)
-		q.Volume[bar], _ = strconv.ParseFloat(line[5], 64)
-	}
-	return q, nil
+	return NewQuoteFromCSVWithOptions(symbol, csv, DefaultCSVOptions)
 }
 
 // NewQuoteFromCSVDateFormat - parse csv quote string into Quote structure
 // with specified DateTime format
 func NewQuoteFromCSVDateFormat(symbol, csv string, format string) (Quote, error) {
+	opts := DefaultCSVOptions
+	opts.DateLayout = format
+	return NewQuoteFromCSVWithOptions(symbol, csv, opts)
+}
 
-	tmp := strings.Split(csv, "\n")
-	numrows := len(tmp)
-	q := NewQuote("", numrows-1)
-
-	if len(strings.TrimSpace(format)) == 0 {
-		format = "2006-01-02 15:04"
-	}
-
-	for row, bar := 1, 0; row < numrows; row, bar = row+1, bar+1 {
-		line := strings.Split(tmp[row], ",")
-		q.Date[bar], _ = time.Parse(format, line[0])
-		q.Open[bar], _ = strconv.ParseFloat(line[1], 64)
-		q.High[bar], _ = strconv.ParseFloat(line[2], 64)
-		q.Low[bar], _ = strconv.ParseFloat(line[3], 64)
-		q.Close[bar], _ = strconv.ParseFloat(line[4], 64)
-		q.Volume[bar], _ = strconv.ParseFloat(line[5], 64)
-	}
-	return q, nil
+// NewQuoteFromCSVReader - parse csv quote data streamed from r into Quote
+// structure, without holding more than a row of the csv in memory
+func NewQuoteFromCSVReader(symbol string, r io.Reader) (Quote, error) {
+	return NewQuoteFromCSVReaderWithOptions(symbol, r, DefaultCSVOptions)
 }
 
-// NewQuoteFromCSVFile - parse csv quote file into Quote structure
+// NewQuoteFromCSVFile - parse csv quote file into Quote structure,
+// decompressing .gz files
 func NewQuoteFromCSVFile(symbol, filename string) (Quote, error) {
-	csv, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return NewQuote("", 0), err
-	}
-	return NewQuoteFromCSV(symbol, string(csv))
+	return NewQuoteFromCSVFileWithOptions(symbol, filename, DefaultCSVOptions)
 }
 
 // NewQuoteFromCSVFileDateFormat - parse csv quote file into Quote structure
 // with specified DateTime format
 func NewQuoteFromCSVFileDateFormat(symbol, filename string, format string) (Quote, error) {
-	csv, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return NewQuote("", 0), err
-	}
-	return NewQuoteFromCSVDateFormat(symbol, string(csv), format)
+	opts := DefaultCSVOptions
+	opts.DateLayout = format
+	return NewQuoteFromCSVFileWithOptions(symbol, filename, opts)
 }
 
 // JSON - convert Quote struct to json string
 func (q Quote) JSON(indent bool) string {
-	var j []byte
-	if indent {
-		j, _ = json.MarshalIndent(q, "", "  ")
-	} else {
-		j, _ = json.Marshal(q)
+	var buffer bytes.Buffer
+	if err := q.writeJSON(&buffer, indent, ""); err != nil {
+		return ""
 	}
-	return string(j)
+	return buffer.String()
 }
 
-// WriteJSON - write Quote struct to json file
+// WriteJSONTo - stream Quote struct as json to w, a column at a time
+func (q Quote) WriteJSONTo(w io.Writer, indent bool) error {
+	return buffered(w, func(w io.Writer) error {
+		return q.writeJSON(w, indent, "")
+	})
+}
+
+// WriteJSON - write Quote struct to json file, gzip compressed if filename
+// ends in .gz
 func (q Quote) WriteJSON(filename string, indent bool) error {
 	if filename == "" {
 		filename = q.Symbol + ".json"
 	}
-	json := q.JSON(indent)
-	return ioutil.WriteFile(filename, []byte(json), 0644)
-
+	return writeStream(filename, func(w io.Writer) error {
+		return q.writeJSON(w, indent, "")
+	})
 }
 
-// NewQuoteFromJSON - parse json quote string into Quote structure
+// NewQuoteFromJSON - parse json quote string into Quote structure, with or
+// without a metadata envelope
 func NewQuoteFromJSON(jsn string) (Quote, error) {
-	q := Quote{}
-	err := json.Unmarshal([]byte(jsn), &q)
-	if err != nil {
-		return q, err
+	return NewQuoteFromJSONReader(strings.NewReader(jsn))
+}
+
+// NewQuoteFromJSONReader - decode a json quote streamed from r into Quote
+// structure, with or without a metadata envelope
+func NewQuoteFromJSONReader(r io.Reader) (Quote, error) {
+	dec := json.NewDecoder(r)
+	var j quoteJSON
+	if err := dec.Decode(&j); err != nil {
+		return NewQuote("", 0), err
 	}
-	return q, nil
+	if err := jsonEnd(dec); err != nil {
+		return NewQuote("", 0), err
+	}
+	return j.quote(), nil
 }
 
-// NewQuoteFromJSONFile - parse json quote string into Quote structure
+// NewQuoteFromJSONFile - parse json quote file into Quote structure,
+// decompressing .gz files
 func NewQuoteFromJSONFile(filename string) (Quote, error) {
-	jsn, err := ioutil.ReadFile(filename)
+	f, err := openFile(filename)
 	if err != nil {
 		return NewQuote("", 0), err
 	}
-	return NewQuoteFromJSON(string(jsn))
+	defer f.Close()
+	return NewQuoteFromJSONReader(f)
 }
 
 // CSV - convert Quotes structure to csv string
 func (q Quotes) CSV() string {
-
 	var buffer bytes.Buffer
+	q.writeCSVWithOptions(&buffer, DefaultCSVOptions)
+	return buffer.String()
+}
 
-	buffer.WriteString("symbol,datetime,open,high,low,close,volume\n")
-
-	for sym := 0; sym < len(q); sym++ {
-		quote := q[sym]
-		precision := getPrecision(quote.Symbol)
-		for bar := range quote.Close {
-			str := fmt.Sprintf("%s,%s,%.*f,%.*f,%.*f,%.*f,%.*f\n",
-				quote.Symbol, quote.Date[bar].Format("2006-01-02 15:04"), precision, quote.Open[bar], precision, quote.High[bar], precision, quote.Low[bar], precision, quote.Close[bar], precision, quote.Volume[bar])
-			buffer.WriteString(str)
-		}
-	}
+// WriteCSVTo - stream Quotes structure as csv to w
+func (q Quotes) WriteCSVTo(w io.Writer) error {
+	return buffered(w, func(w io.Writer) error {
+		return q.writeCSVWithOptions(w, DefaultCSVOptions)
+	})
+}
 
+// Highstock - convert Quotes structure to Highstock json format, each quote
+// downsampled by Bucket to at most the optional maxPoints bars
+func (q Quotes) Highstock(maxPoints ...int) string {
+	var buffer bytes.Buffer
+	q.highstockPoints(maxPoints).writeHighstock(&buffer)
 	return buffer.String()
 }
 
-// Highstock - convert Quotes structure to Highstock json format
-func (q Quotes) Highstock() string {
+// WriteHighstockTo - stream Quotes structure as Highstock json to w, with
+// the optional maxPoints of Highstock
+func (q Quotes) WriteHighstockTo(w io.Writer, maxPoints ...int) error {
+	return buffered(w, q.highstockPoints(maxPoints).writeHighstock)
+}
 
-	var buffer bytes.Buffer
+// writeHighstock - Highstock json of q to w, in output order
+func (q Quotes) writeHighstock(w io.Writer) error {
+	q = q.inOutputOrder()
 
-	buffer.WriteString("{")
+	if _, err := io.WriteString(w, "{"); err != nil {
+		return err
+	}
 
 	for sym := 0; sym < len(q); sym++ {
 		quote := q[sym]
-		precision := getPrecision(quote.Symbol)
-		for bar := range quote.Close {
-			comma := ","
-			if bar == len(quote.Close)-1 {
-				comma = ""
+		if len(quote.Close) > 0 {
+			if _, err := fmt.Fprintf(w, "\"%s\":[\n", quote.Symbol); err != nil {
+				return err
 			}
-			if bar == 0 {
-				buffer.WriteString(fmt.Sprintf("\"%s\":[\n", quote.Symbol))
-			}
-			str := fmt.Sprintf("[%d,%.*f,%.*f,%.*f,%.*f,%.*f]%s\n",
-				quote.Date[bar].UnixNano()/1000000, precision, quote.Open[bar], precision, quote.High[bar], precision, quote.Low[bar], precision, quote.Close[bar], precision, quote.Volume[bar], comma)
-			buffer.WriteString(str)
 		}
+		if err := quote.writeHighstockBars(w); err != nil {
+			return err
+		}
+		end := "]\n"
 		if sym < len(q)-1 {
-			buffer.WriteString("],\n")
-		} else {
-			buffer.WriteString("]\n")
+			end = "],\n"
 		}
-	}
-
-	buffer.WriteString("}")
-
-	return buffer.String()
-}
-
-// Amibroker - convert Quotes structure to csv string
-func (q Quotes) Amibroker() string {
-
-	var buffer bytes.Buffer
-
-	buffer.WriteString("symbol,date,time,open,high,low,close,volume\n")
-
-	for sym := 0; sym < len(q); sym++ {
-		quote := q[sym]
-		precision := getPrecision(quote.Symbol)
-		for bar := range quote.Close {
-			str := fmt.Sprintf("%s,%s,%s,%.*f,%.*f,%.*f,%.*f,%.*f\n",
-				quote.Symbol, quote.Date[bar].Format("2006-01-02"), quote.Date[bar].Format("15:04"), precision, quote.Open[bar], precision, quote.High[bar], precision, quote.Low[bar], precision, quote.Close[bar], precision, quote.Volume[bar])
-			buffer.WriteString(str)
+		if _, err := io.WriteString(w, end); err != nil {
+			return err
 		}
 	}
 
-	return buffer.String()
+	_, err := io.WriteString(w, "}")
+	return err
 }
 
-// WriteCSV - write Quotes structure to file
+// WriteCSV - write Quotes structure to file, gzip compressed if filename
+// ends in .gz
 func (q Quotes) WriteCSV(filename string) error {
 	if filename == "" {
 		filename = "quotes.csv"
 	}
-	csv := q.CSV()
-	ba := []byte(csv)
-	return ioutil.WriteFile(filename, ba, 0644)
-}
-
-// WriteAmibroker - write Quotes structure to file
-func (q Quotes) WriteAmibroker(filename string) error {
-	if filename == "" {
-		filename = "quotes.csv"
-	}
-	csv := q.Amibroker()
-	ba := []byte(csv)
-	return ioutil.WriteFile(filename, ba, 0644)
+	return writeStream(filename, func(w io.Writer) error {
+		return q.writeCSVWithOptions(w, DefaultCSVOptions)
+	})
 }
 
 // NewQuotesFromCSV - parse csv quote string into Quotes array
 func NewQuotesFromCSV(csv string) (Quotes, error) {
+	return NewQuotesFromCSVWithOptions(csv, DefaultCSVOptions)
+}
 
-	quotes := Quotes{}
-	tmp := strings.Split(csv, "\n")
-	numrows := len(tmp)
-
-	var index = make(map[string]int)
-	for idx := 1; idx < numrows; idx++ {
-		sym := strings.Split(tmp[idx], ",")[0]
-		index[sym]++
-	}
-
-	row := 1
-	for sym, len := range index {
-		q := NewQuote(sym, len)
-		for bar := 0; bar < len; bar++ {
-			line := strings.Split(tmp[row], ",")
-			q.Date[bar], _ = time.Parse("2006-01-02 15:04", line[1])
-			q.Open[bar], _ = strconv.ParseFloat(line[2], 64)
-			q.High[bar], _ = strconv.ParseFloat(line[3], 64)
-			q.Low[bar], _ = strconv.ParseFloat(line[4], 64)
-			q.Close[bar], _ = strconv.ParseFloat(line[5], 64)
-			q.Volume[bar], _ = strconv.ParseFloat(line[6], 64)
-			row++
-		}
-		quotes = append(quotes, q)
-	}
-	return quotes, nil
+// NewQuotesFromCSVReader - parse multi-symbol csv quote data streamed from r
+// into Quotes array, without holding more than a row of the csv in memory
+func NewQuotesFromCSVReader(r io.Reader) (Quotes, error) {
+	return NewQuotesFromCSVReaderWithOptions(r, DefaultCSVOptions)
 }
 
-// NewQuotesFromCSVFile - parse csv quote file into Quotes array
+// NewQuotesFromCSVFile - parse csv quote file into Quotes array,
+// decompressing .gz files
 func NewQuotesFromCSVFile(filename string) (Quotes, error) {
-	csv, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return Quotes{}, err
-	}
-	return NewQuotesFromCSV(string(csv))
+	return NewQuotesFromCSVFileWithOptions(filename, DefaultCSVOptions)
 }
 
 // JSON - convert Quotes struct to json string
 func (q Quotes) JSON(indent bool) string {
-	var j []byte
-	if indent {
-		j, _ = json.MarshalIndent(q, "", "  ")
-	} else {
-		j, _ = json.Marshal(q)
+	var buffer bytes.Buffer
+	if err := q.writeJSON(&buffer, indent); err != nil {
+		return ""
 	}
-	return string(j)
+	return buffer.String()
+}
+
+// WriteJSONTo - stream Quotes struct as a json array to w, a column at a time
+func (q Quotes) WriteJSONTo(w io.Writer, indent bool) error {
+	return buffered(w, func(w io.Writer) error {
+		return q.writeJSON(w, indent)
+	})
 }
 
-// WriteJSON - write Quote struct to json file
+// WriteJSON - write Quotes struct to json file, gzip compressed if filename
+// ends in .gz
 func (q Quotes) WriteJSON(filename string, indent bool) error {
 	if filename == "" {
 		filename = "quotes.json"
 	}
-	jsn := q.JSON(indent)
-	return ioutil.WriteFile(filename, []byte(jsn), 0644)
+	return writeStream(filename, func(w io.Writer) error {
+		return q.writeJSON(w, indent)
+	})
 }
 
-// WriteHighstock - write Quote struct to json file in Highstock format
-func (q Quotes) WriteHighstock(filename string) error {
+// WriteHighstock - write Quote struct to json file in Highstock format, with
+// the optional maxPoints of Highstock
+func (q Quotes) WriteHighstock(filename string, maxPoints ...int) error {
 	if filename == "" {
 		filename = "quotes.json"
 	}
-	hc := q.Highstock()
-	return ioutil.WriteFile(filename, []byte(hc), 0644)
+	return writeStream(filename, q.highstockPoints(maxPoints).writeHighstock)
 }
 
-// NewQuotesFromJSON - parse json quote string into Quote structure
+// NewQuotesFromJSON - parse json quote string into Quote structure, with or
+// without metadata envelopes
 func NewQuotesFromJSON(jsn string) (Quotes, error) {
+	return NewQuotesFromJSONReader(strings.NewReader(jsn))
+}
+
+// NewQuotesFromJSONReader - decode a json array of quotes streamed from r, a
+// quote at a time, with or without metadata envelopes
+func NewQuotesFromJSONReader(r io.Reader) (Quotes, error) {
 	quotes := Quotes{}
-	err := json.Unmarshal([]byte(jsn), &quotes)
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
 	if err != nil {
 		return quotes, err
 	}
+	if tok == nil {
+		// null
+		return quotes, jsonEnd(dec)
+	}
+	if tok != json.Delim('[') {
+		return quotes, fmt.Errorf("json quotes are not an array")
+	}
+	for dec.More() {
+		var j quoteJSON
+		if err := dec.Decode(&j); err != nil {
+			return Quotes{}, err
+		}
+		quotes = append(quotes, j.quote())
+	}
+	if _, err := dec.Token(); err != nil {
+		return Quotes{}, err
+	}
+	if err := jsonEnd(dec); err != nil {
+		return Quotes{}, err
+	}
 	return quotes, nil
 }
 
 // NewQuotesFromJSONFile - parse json quote string into Quote structure
 func NewQuotesFromJSONFile(filename string) (Quotes, error) {
-	jsn, err := ioutil.ReadFile(filename)
+	f, err := openFile(filename)
 	if err != nil {
 		return Quotes{}, err
 	}
-	return NewQuotesFromJSON(string(jsn))
+	defer f.Close()
+	return NewQuotesFromJSONReader(f)
 }
 
+// yahooURL - base url of the Yahoo download api
+var yahooURL = "https://query1.finance.yahoo.com"
+
+// tiingoURL - base url of the Tiingo api
+var tiingoURL = "https://api.tiingo.com"
+
+// yahooPeriods - periods of the Yahoo chart api
+var yahooPeriods = []Period{Min1, Min5, Min15, Min30, Min60, Daily, Weekly, Monthly, Yearly}
+
+// NullPolicy - handling of Yahoo bars without prices, as returned for
+// holidays and missing data
+type NullPolicy int
+
+const (
+	// SkipNulls - leave out bars without prices
+	SkipNulls NullPolicy = iota
+	// FillForward - repeat the previous close with zero volume, leading
+	// bars without prices are left out
+	FillForward
+	// ErrorOnNulls - fail the download
+	ErrorOnNulls
+)
+
+// YahooOptions - options of Yahoo downloads
+type YahooOptions struct {
+	NullPolicy NullPolicy    // bars missing any of open, high, low or close
+	Session    *YahooSession // nil for the package session
+	PrePost    bool          // include pre-market and after hours intraday bars, marked in the Session column
+}
+
+// session - the session of opts
+func (opts YahooOptions) session() *YahooSession {
+	if opts.Session == nil {
+		return defaultYahooSession()
+	}
+	return opts.Session
+}
+
+// DefaultYahooOptions - options of NewQuoteFromYahoo
+var DefaultYahooOptions = YahooOptions{NullPolicy: SkipNulls}
+
 // NewQuoteFromYahoo - Yahoo historical prices for a symbol
 func NewQuoteFromYahoo(symbol, startDate, endDate string, period Period, adjustQuote bool) (Quote, error) {
+	return NewQuoteFromYahooCtx(context.Background(), symbol, startDate, endDate, period, adjustQuote)
+}
 
-	if period != Daily {
-		Log.Printf("Yahoo intraday data no longer supported\n")
-		return NewQuote("", 0), errors.New("Yahoo intraday data no longer supported")
-	}
+// NewQuoteFromYahooCtx - NewQuoteFromYahoo, canceled with ctx
+func NewQuoteFromYahooCtx(ctx context.Context, symbol, startDate, endDate string, period Period, adjustQuote bool) (Quote, error) {
+	return NewQuoteFromYahooWithOptionsCtx(ctx, symbol, startDate, endDate, period, adjustQuote, DefaultYahooOptions)
+}
 
-	from := ParseDateString(startDate)
-	to := ParseDateString(endDate)
+// NewQuoteFromYahooWithOptions - Yahoo historical prices for a symbol
+func NewQuoteFromYahooWithOptions(symbol, startDate, endDate string, period Period, adjustQuote bool, opts YahooOptions) (Quote, error) {
+	return NewQuoteFromYahooWithOptionsCtx(context.Background(), symbol, startDate, endDate, period, adjustQuote, opts)
+}
 
-	client := &http.Client{
-		Timeout: ClientTimeout,
+// NewQuoteFromYahooWithOptionsCtx - NewQuoteFromYahooWithOptions, canceled
+// with ctx
+func NewQuoteFromYahooWithOptionsCtx(ctx context.Context, symbol, startDate, endDate string, period Period, adjustQuote bool, opts YahooOptions) (Quote, error) {
+	raw, adjusted, err := NewQuotePairFromYahooWithOptionsCtx(ctx, symbol, startDate, endDate, period, opts)
+	if adjustQuote {
+		return adjusted, err
 	}
+	return raw, err
+}
 
-	initReq, err := http.NewRequest("GET", "https://finance.yahoo.com", nil)
-	if err != nil {
-		return NewQuote("", 0), err
-	}
-	initReq.Header.Set("User-Agent", "Mozilla/5.0 (X11; U; Linux i686) Gecko/20071127 Firefox/2.0.0.11")
-	resp, _ := client.Do(initReq)
+// NewQuotePairFromYahoo - Yahoo raw and adjusted historical prices for a
+// symbol from a single download. Both quotes carry the AdjClose column, the
+// raw one the other adjusted prices too, see Adjusted and Unadjusted.
+func NewQuotePairFromYahoo(symbol, startDate, endDate string, period Period) (Quote, Quote, error) {
+	return NewQuotePairFromYahooWithOptions(symbol, startDate, endDate, period, DefaultYahooOptions)
+}
 
-	url := fmt.Sprintf(
-		"https://query1.finance.yahoo.com/v7/finance/download/%s?period1=%d&period2=%d&interval=1d&events=history&corsDomain=finance.yahoo.com",
-		symbol,
-		from.Unix(),
-		to.Unix())
-	resp, err = client.Get(url)
-	if err != nil {
-		Log.Printf("symbol '%s' not found\n", symbol)
-		return NewQuote("", 0), err
+// NewQuotePairFromYahooWithOptions - Yahoo raw and adjusted historical prices
+// for a symbol from a single download
+func NewQuotePairFromYahooWithOptions(symbol, startDate, endDate string, period Period, opts YahooOptions) (Quote, Quote, error) {
+	return NewQuotePairFromYahooWithOptionsCtx(context.Background(), symbol, startDate, endDate, period, opts)
+}
+
+// NewQuotePairFromYahooWithOptionsCtx - NewQuotePairFromYahooWithOptions,
+// canceled with ctx. A 1 minute download canceled between its requests
+// returns the bars so far with the wrapped error of ctx.
+func NewQuotePairFromYahooWithOptionsCtx(ctx context.Context, symbol, startDate, endDate string, period Period, opts YahooOptions) (Quote, Quote, error) {
+
+	if err := ValidatePeriod("yahoo", period); err != nil {
+		Log.Printf("yahoo error: %v\n", err)
+		return NewQuote("", 0), NewQuote("", 0), err
 	}
-	defer resp.Body.Close()
 
-	var csvdata [][]string
-	reader := csv.NewReader(resp.Body)
-	csvdata, err = reader.ReadAll()
-	if err != nil {
-		Log.Printf("bad data for symbol '%s'\n", symbol)
-		return NewQuote("", 0), err
+	from := ParseDateString(startDate)
+	to := parseEndDate(endDate)
+
+	quote, err := yahooChartChunked(ctx, symbol, from, to, period, opts)
+	if err != nil && len(quote.Date) == 0 {
+		return NewQuote("", 0), NewQuote("", 0), err
 	}
 
-	numrows := len(csvdata) - 1
-	quote := NewQuote(symbol, numrows)
+	raw := quote.withMeta("yahoo", period, false)
+	return raw, quote.Adjusted().withMeta("yahoo", period, true), err
+}
 
-	for row := 1; row < len(csvdata); row++ {
+// yahooIntervals - chart api interval of each supported period, yearly bars
+// are built from monthly bars
+var yahooIntervals = map[Period]string{
+	Min1:    "1m",
+	Min5:    "5m",
+	Min15:   "15m",
+	Min30:   "30m",
+	Min60:   "60m",
+	Daily:   "1d",
+	Weekly:  "1wk",
+	Monthly: "1mo",
+	Yearly:  "1mo",
+}
 
-		// Parse row of data
-		d, _ := time.Parse("2006-01-02", csvdata[row][0])
-		o, _ := strconv.ParseFloat(csvdata[row][1], 64)
-		h, _ := strconv.ParseFloat(csvdata[row][2], 64)
-		l, _ := strconv.ParseFloat(csvdata[row][3], 64)
-		c, _ := strconv.ParseFloat(csvdata[row][4], 64)
-		a, _ := strconv.ParseFloat(csvdata[row][5], 64)
-		v, _ := strconv.ParseFloat(csvdata[row][6], 64)
-
-		quote.Date[row-1] = d
-
-		// Adjustment ratio
-		if adjustQuote {
-			quote.Open[row-1] = o
-			quote.High[row-1] = h
-			quote.Low[row-1] = l
-			quote.Close[row-1] = a
-		} else {
-			ratio := c / a
-			quote.Open[row-1] = o * ratio
-			quote.High[row-1] = h * ratio
-			quote.Low[row-1] = l * ratio
-			quote.Close[row-1] = c
-		}
+// yahooMin1Window - longest date range of a single 1 minute chart request
+const yahooMin1Window = 7 * 24 * time.Hour
 
-		quote.Volume[row-1] = v
+// yahooChartChunked - Yahoo chart prices for a date range, 1 minute bars are
+// requested in windows of yahooMin1Window and stitched together, up to the
+// window ctx is canceled before
+func yahooChartChunked(ctx context.Context, symbol string, from, to time.Time, period Period, opts YahooOptions) (Quote, error) {
 
+	if period != Min1 {
+		return yahooChart(ctx, symbol, from, to, period, opts)
 	}
 
+	quote := NewQuote(symbol, 0)
+	for start := from; start.Before(to); start = start.Add(yahooMin1Window) {
+		end := start.Add(yahooMin1Window)
+		if end.After(to) {
+			end = to
+		}
+		if start != from && delayContext(ctx, "yahoo") != nil {
+			return quote, interrupted(ctx, "yahoo %s from %s", symbol, start.Format("2006-01-02"))
+		}
+		q, err := yahooChart(ctx, symbol, start, end, period, opts)
+		if err != nil {
+			return NewQuote("", 0), err
+		}
+		for bar := range q.Date {
+			n := len(quote.Date)
+			if n == 0 || q.Date[bar].After(quote.Date[n-1]) {
+				quote.appendBar(q, bar)
+			}
+		}
+	}
 	return quote, nil
 }
 
-/*
-func NewQuoteFromYahoo(symbol, startDate, endDate string, period Period, adjustQuote bool) (Quote, error) {
+// yahooChartQuote - price columns of a chart api result, null where missing
+type yahooChartQuote struct {
+	Open   []*float64 `json:"open"`
+	High   []*float64 `json:"high"`
+	Low    []*float64 `json:"low"`
+	Close  []*float64 `json:"close"`
+	Volume []*float64 `json:"volume"`
+}
 
-	from := ParseDateString(startDate)
-	to := ParseDateString(endDate)
+// yahooChartResult - chart api result for one symbol
+type yahooChartResult struct {
+	Meta struct {
+		GMTOffset      int             `json:"gmtoffset"`
+		TradingPeriods json.RawMessage `json:"tradingPeriods"`
+	} `json:"meta"`
+	Timestamp  []int64 `json:"timestamp"`
+	Indicators struct {
+		Quote    []yahooChartQuote `json:"quote"`
+		AdjClose []struct {
+			AdjClose []*float64 `json:"adjclose"`
+		} `json:"adjclose"`
+	} `json:"indicators"`
+	Events struct {
+		Dividends map[string]struct {
+			Date   int64   `json:"date"`
+			Amount float64 `json:"amount"`
+		} `json:"dividends"`
+		Splits map[string]struct {
+			Date        int64   `json:"date"`
+			Numerator   float64 `json:"numerator"`
+			Denominator float64 `json:"denominator"`
+		} `json:"splits"`
+	} `json:"events"`
+}
+
+// tradingDay - midnight UTC of the exchange trading day of unix time ts
+func (r yahooChartResult) tradingDay(ts int64) time.Time {
+	local := time.Unix(ts+int64(r.Meta.GMTOffset), 0).UTC()
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// yahooTradingPeriod - start and end unix time of a trading session
+type yahooTradingPeriod struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// sessions - the Session value, SessionPre, SessionRegular or SessionPost,
+// of a bar at unix time ts, from the regular session of each day in the
+// trading periods returned with includePrePost. Bars on days without a
+// regular session are taken as regular.
+func (r yahooChartResult) sessions() func(ts int64) float64 {
+	var periods struct {
+		Regular [][]yahooTradingPeriod `json:"regular"`
+	}
+	json.Unmarshal(r.Meta.TradingPeriods, &periods)
+	regular := make(map[time.Time]yahooTradingPeriod)
+	for _, days := range periods.Regular {
+		for _, p := range days {
+			regular[r.tradingDay(p.Start)] = p
+		}
+	}
+	return func(ts int64) float64 {
+		p, ok := regular[r.tradingDay(ts)]
+		switch {
+		case !ok:
+			return SessionRegular
+		case ts < p.Start:
+			return SessionPre
+		case ts >= p.End:
+			return SessionPost
+		}
+		return SessionRegular
+	}
+}
+
+// yahooChartRequest - Yahoo v8 chart api result for a date range through
+// session s, events is empty or the events parameter, e.g. div|split
+func yahooChartRequest(ctx context.Context, s *YahooSession, symbol string, from, to time.Time, interval, events string, prePost bool) (yahooChartResult, error) {
+
+	var chart struct {
+		Chart struct {
+			Result []yahooChartResult `json:"result"`
+			Error  *struct {
+				Code        string `json:"code"`
+				Description string `json:"description"`
+			} `json:"error"`
+		} `json:"chart"`
+	}
 
 	url := fmt.Sprintf(
-		"http://ichart.yahoo.com/table.csv?s=%s&a=%d&b=%d&c=%d&d=%d&e=%d&f=%d&g=%s&ignore=.csv",
-		symbol,
-		from.Month()-1, from.Day(), from.Year(),
-		to.Month()-1, to.Day(), to.Year(),
-		period)
-	resp, err := http.Get(url)
+		"%s/v8/finance/chart/%s?period1=%d&period2=%d&interval=%s&includePrePost=%t",
+		yahooURL,
+		url.PathEscape(symbol),
+		from.Unix(),
+		to.Unix(),
+		interval,
+		prePost)
+	if events != "" {
+		url += "&events=" + events
+	}
+
+	resp, err := s.request(ctx, url)
 	if err != nil {
 		Log.Printf("symbol '%s' not found\n", symbol)
-		return NewQuote("", 0), err
+		return yahooChartResult{}, err
 	}
 	defer resp.Body.Close()
 
-	var csvdata [][]string
-	reader := csv.NewReader(resp.Body)
-	csvdata, err = reader.ReadAll()
+	contents, _ := ioutil.ReadAll(resp.Body)
+	err = json.Unmarshal(contents, &chart)
+	if err != nil {
+		Log.Printf("yahoo symbol '%s' error: %v\n", symbol, err)
+		return yahooChartResult{}, fmt.Errorf("yahoo %s: %s", symbol, resp.Status)
+	}
+	if e := chart.Chart.Error; e != nil {
+		Log.Printf("yahoo symbol '%s' error: %s\n", symbol, e.Description)
+		return yahooChartResult{}, fmt.Errorf("yahoo %s: %s: %s", symbol, e.Code, e.Description)
+	}
+	if len(chart.Chart.Result) < 1 {
+		return yahooChartResult{}, fmt.Errorf("yahoo %s: no data returned", symbol)
+	}
+	return chart.Chart.Result[0], nil
+}
+
+// yahooChart - Yahoo v8 chart api prices for a date range. Daily and longer
+// bars are dated at midnight UTC of their exchange trading day, intraday bars
+// carry their UTC timestamp. Bars missing any price are handled according to
+// opts.NullPolicy.
+func yahooChart(ctx context.Context, symbol string, from, to time.Time, period Period, opts YahooOptions) (Quote, error) {
+
+	// extended hours only exist for intraday bars
+	intraday := period.Duration() < 24*time.Hour
+	prePost := opts.PrePost && intraday
+	result, err := yahooChartRequest(ctx, opts.session(), symbol, from, to, yahooIntervals[period], "", prePost)
 	if err != nil {
-		Log.Printf("bad data for symbol '%s'\n", symbol)
 		return NewQuote("", 0), err
 	}
 
-	numrows := len(csvdata) - 1
-	quote := NewQuote(symbol, numrows)
+	var prices yahooChartQuote
+	if len(result.Indicators.Quote) > 0 {
+		prices = result.Indicators.Quote[0]
+	}
+	var adjclose []*float64
+	if len(result.Indicators.AdjClose) > 0 {
+		adjclose = result.Indicators.AdjClose[0].AdjClose
+	}
+	value := func(col []*float64, bar int) (float64, bool) {
+		if bar >= len(col) || col[bar] == nil {
+			return 0, false
+		}
+		return *col[bar], true
+	}
 
-	for row := 1; row < len(csvdata); row++ {
+	session := result.sessions()
+	quote := NewQuote(symbol, 0)
+	for bar, ts := range result.Timestamp {
+		d := time.Unix(ts, 0).UTC()
+		if !intraday {
+			d = result.tradingDay(ts)
+		}
+		o, okOpen := value(prices.Open, bar)
+		h, okHigh := value(prices.High, bar)
+		l, okLow := value(prices.Low, bar)
+		c, okClose := value(prices.Close, bar)
+		v, _ := value(prices.Volume, bar)
+		a, okAdj := value(adjclose, bar)
+
+		if !okOpen || !okHigh || !okLow || !okClose {
+			n := len(quote.Date)
+			switch {
+			case opts.NullPolicy == ErrorOnNulls:
+				return NewQuote("", 0), fmt.Errorf("yahoo %s: no prices for %s", symbol, d.Format("2006-01-02 15:04"))
+			case opts.NullPolicy != FillForward || n == 0:
+				continue
+			}
+			prev := quote.Close[n-1]
+			o, h, l, c, v = prev, prev, prev, prev, 0
+			okAdj = quote.AdjClose != nil
+			if okAdj {
+				a = quote.AdjClose[n-1]
+			}
+		}
 
-		// Parse row of data
-		d, _ := time.Parse("2006-01-02", csvdata[row][0])
-		o, _ := strconv.ParseFloat(csvdata[row][1], 64)
-		h, _ := strconv.ParseFloat(csvdata[row][2], 64)
-		l, _ := strconv.ParseFloat(csvdata[row][3], 64)
-		c, _ := strconv.ParseFloat(csvdata[row][4], 64)
-		v, _ := strconv.ParseFloat(csvdata[row][5], 64)
-		a, _ := strconv.ParseFloat(csvdata[row][6], 64)
+		quote.PushBar(Bar{Date: d, Open: o, High: h, Low: l, Close: c, Volume: v})
+		if okAdj {
+			quote.setExtra(&quote.AdjClose, len(quote.Date)-1, a)
+		}
+		if prePost {
+			quote.setExtra(&quote.Session, len(quote.Date)-1, session(ts))
+		}
+	}
+	quote = quote.withAdjustedPrices()
+	if period == Yearly {
+		return quote.years(), nil
+	}
+	return quote, nil
+}
 
-		// Adjustment factor
-		factor := 1.0
-		if adjustQuote {
-			factor = a / c
+// years - one bar per calendar year of q, dated on the first bar of the
+// year: the first open, highest high, lowest low, last close and total
+// volume, optional columns such as the adjusted prices joined as by Resample
+func (q Quote) years() Quote {
+	y := Quote{Symbol: q.Symbol, Precision: q.Precision, Meta: q.Meta}
+	for bar := range q.Date {
+		n := len(y.Date)
+		if n == 0 || q.Date[bar].Year() != y.Date[n-1].Year() {
+			y.appendBar(q, bar)
+			continue
 		}
+		y.joinBar(q, bar)
+	}
+	return y
+}
 
-		// Append to quote
-		bar := numrows - row // reverse the order
-		quote.Date[bar] = d
-		quote.Open[bar] = o * factor
-		quote.High[bar] = h * factor
-		quote.Low[bar] = l * factor
-		quote.Close[bar] = c * factor
-		quote.Volume[bar] = v
+// NewEventsFromYahoo - Yahoo dividends and splits of a symbol between
+// startDate and endDate (inclusive), in date order. Events are dated on their
+// ex-date at midnight UTC, dividend amounts are split adjusted to today's shares.
+func NewEventsFromYahoo(symbol, startDate, endDate string) (Events, error) {
+
+	from := ParseDateString(startDate)
+	to := parseEndDate(endDate)
 
+	result, err := yahooChartRequest(context.Background(), defaultYahooSession(), symbol, from, to, "1d", "div|split", false)
+	if err != nil {
+		return Events{}, err
 	}
 
-	return quote, nil
+	var events Events
+	for _, div := range result.Events.Dividends {
+		events.Dividends = append(events.Dividends, Dividend{Date: result.tradingDay(div.Date), Amount: div.Amount})
+	}
+	for _, split := range result.Events.Splits {
+		events.Splits = append(events.Splits, Split{
+			Date:        result.tradingDay(split.Date),
+			Numerator:   int(split.Numerator),
+			Denominator: int(split.Denominator),
+		})
+	}
+	events.sort()
+	return events, nil
 }
-*/
 
 // NewQuotesFromYahoo - create a list of prices from symbols in file
 func NewQuotesFromYahoo(filename, startDate, endDate string, period Period, adjustQuote bool) (Quotes, error) {
@@ -665,26 +1051,56 @@ func NewQuotesFromYahoo(filename, startDate, endDate string, period Period, adju
 		if err == nil {
 			quotes = append(quotes, quote)
 		}
-		time.Sleep(Delay * time.Millisecond)
+		delay("yahoo")
 	}
 	return quotes, nil
 }
 
 // NewQuotesFromYahooSyms - create a list of prices from symbols in string array
 func NewQuotesFromYahooSyms(symbols []string, startDate, endDate string, period Period, adjustQuote bool) (Quotes, error) {
+	return NewQuotesFromYahooSymsCtx(context.Background(), symbols, startDate, endDate, period, adjustQuote)
+}
+
+// NewQuotesFromYahooSymsCtx - NewQuotesFromYahooSyms, canceled with ctx. The
+// quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromYahooSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period, adjustQuote bool) (Quotes, error) {
 
 	quotes := Quotes{}
-	for _, symbol := range symbols {
-		quote, err := NewQuoteFromYahoo(symbol, startDate, endDate, period, adjustQuote)
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromYahooCtx(ctx, symbol, startDate, endDate, period, adjustQuote)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "yahoo after %d of %d symbols", i, len(symbols))
+		}
 		if err == nil {
 			quotes = append(quotes, quote)
 		}
-		time.Sleep(Delay * time.Millisecond)
+		delayContext(ctx, "yahoo")
 	}
 	return quotes, nil
 }
 
-func tiingoDaily(symbol string, from, to time.Time, token string) (Quote, error) {
+// tiingoPeriods - periods of the Tiingo daily prices api
+var tiingoPeriods = []Period{Daily, Weekly, Monthly, Yearly}
+
+// tiingoFreqs - resampleFreq of each supported period
+var tiingoFreqs = map[Period]string{
+	Daily:   "daily",
+	Weekly:  "weekly",
+	Monthly: "monthly",
+	Yearly:  "annually",
+}
+
+func tiingoDaily(ctx context.Context, symbol string, from, to time.Time, period Period, token string) (Quote, error) {
+	_, adjusted, err := tiingoDailyPair(ctx, symbol, from, to, period, token)
+	return adjusted, err
+}
+
+func tiingoDailyPair(ctx context.Context, symbol string, from, to time.Time, period Period, token string) (Quote, Quote, error) {
+
+	if err := ValidatePeriod("tiingo", period); err != nil {
+		Log.Printf("tiingo error: %v\n", err)
+		return NewQuote("", 0), NewQuote("", 0), err
+	}
 
 	type tquote struct {
 		AdjClose    float64 `json:"adjClose"`
@@ -705,76 +1121,87 @@ func tiingoDaily(symbol string, from, to time.Time, token string) (Quote, error)
 	var tiingo []tquote
 
 	url := fmt.Sprintf(
-		"https://api.tiingo.com/tiingo/daily/%s/prices?startDate=%s&endDate=%s",
+		"%s/tiingo/daily/%s/prices?startDate=%s&endDate=%s",
+		tiingoURL,
 		symbol,
 		url.QueryEscape(from.Format("2006-1-2")),
 		url.QueryEscape(to.Format("2006-1-2")))
+	if period != Daily {
+		url += "&resampleFreq=" + tiingoFreqs[period]
+	}
 
-	client := &http.Client{Timeout: ClientTimeout}
-	req, _ := http.NewRequest("GET", url, nil)
+	if err := waitRate(ctx, "tiingo"); err != nil {
+		return NewQuote("", 0), NewQuote("", 0), err
+	}
+	client := httpClient()
+	req, _ := newRequest(ctx, url)
 	req.Header.Set("Authorization", fmt.Sprintf("Token %s", token))
 	resp, err := client.Do(req)
 
 	if err != nil {
 		Log.Printf("tiingo error: %v\n", err)
-		return NewQuote("", 0), err
+		return NewQuote("", 0), NewQuote("", 0), err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusOK {
-		contents, _ := ioutil.ReadAll(resp.Body)
-		err = json.Unmarshal(contents, &tiingo)
-		if err != nil {
-			Log.Printf("tiingo error: %v\n", err)
-			return NewQuote("", 0), err
-		}
-	} else if resp.StatusCode == http.StatusNotFound {
-		Log.Printf("symbol '%s' not found\n", symbol)
-		return NewQuote("", 0), err
+	contents, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		err := tiingoError(resp.StatusCode, contents)
+		Log.Printf("tiingo %s error: %v\n", symbol, err)
+		return NewQuote("", 0), NewQuote("", 0), err
+	}
+	err = json.Unmarshal(contents, &tiingo)
+	if err != nil {
+		Log.Printf("tiingo error: %v\n", err)
+		return NewQuote("", 0), NewQuote("", 0), err
 	}
 
 	numrows := len(tiingo)
-	quote := NewQuote(symbol, numrows)
+	raw := NewQuote(symbol, numrows)
+	raw.AdjOpen = make([]float64, numrows)
+	raw.AdjHigh = make([]float64, numrows)
+	raw.AdjLow = make([]float64, numrows)
+	raw.AdjClose = make([]float64, numrows)
 
 	for bar := 0; bar < numrows; bar++ {
-		quote.Date[bar], _ = time.Parse("2006-01-02", tiingo[bar].Date[0:10])
-		quote.Open[bar] = tiingo[bar].AdjOpen
-		quote.High[bar] = tiingo[bar].AdjHigh
-		quote.Low[bar] = tiingo[bar].AdjLow
-		quote.Close[bar] = tiingo[bar].AdjClose
-		quote.Volume[bar] = float64(tiingo[bar].Volume)
-	}
+		raw.Date[bar], _ = time.Parse("2006-01-02", tiingo[bar].Date[0:10])
+		raw.Open[bar] = tiingo[bar].Open
+		raw.High[bar] = tiingo[bar].High
+		raw.Low[bar] = tiingo[bar].Low
+		raw.Close[bar] = tiingo[bar].Close
+		raw.Volume[bar] = float64(tiingo[bar].Volume)
+		raw.AdjOpen[bar] = tiingo[bar].AdjOpen
+		raw.AdjHigh[bar] = tiingo[bar].AdjHigh
+		raw.AdjLow[bar] = tiingo[bar].AdjLow
+		raw.AdjClose[bar] = tiingo[bar].AdjClose
+	}
+
+	return raw.withMeta("tiingo", period, false), raw.Adjusted().withMeta("tiingo", period, true), nil
+}
 
-	return quote, nil
+// tiingoCryptoPeriods - resample frequencies of the Tiingo crypto api
+var tiingoCryptoPeriods = []Period{Min1, Min3, Min5, Min15, Min30, Min60, Hour2, Hour4, Hour6, Hour8, Hour12, Daily}
+
+// tiingoCryptoFreqs - resampleFreq of each supported period
+var tiingoCryptoFreqs = map[Period]string{
+	Min1:   "1min",
+	Min3:   "3min",
+	Min5:   "5min",
+	Min15:  "15min",
+	Min30:  "30min",
+	Min60:  "1hour",
+	Hour2:  "2hour",
+	Hour4:  "4hour",
+	Hour6:  "6hour",
+	Hour8:  "8hour",
+	Hour12: "12hour",
+	Daily:  "1day",
 }
 
-func tiingoCrypto(symbol string, from, to time.Time, period Period, token string) (Quote, error) {
+func tiingoCrypto(ctx context.Context, symbol string, from, to time.Time, period Period, token string) (Quote, error) {
 
-	resampleFreq := "1day"
-	switch period {
-	case Min1:
-		resampleFreq = "1min"
-	case Min3:
-		resampleFreq = "3min"
-	case Min5:
-		resampleFreq = "5min"
-	case Min15:
-		resampleFreq = "15min"
-	case Min30:
-		resampleFreq = "30min"
-	case Min60:
-		resampleFreq = "1hour"
-	case Hour2:
-		resampleFreq = "2hour"
-	case Hour4:
-		resampleFreq = "4hour"
-	case Hour6:
-		resampleFreq = "6hour"
-	case Hour8:
-		resampleFreq = "8hour"
-	case Hour12:
-		resampleFreq = "12hour"
-	case Daily:
+	resampleFreq, ok := tiingoCryptoFreqs[period]
+	if !ok {
 		resampleFreq = "1day"
 	}
 
@@ -799,14 +1226,18 @@ func tiingoCrypto(symbol string, from, to time.Time, period Period, token string
 	var crypto []cryptoData
 
 	url := fmt.Sprintf(
-		"https://api.tiingo.com/tiingo/crypto/prices?tickers=%s&startDate=%s&endDate=%s&resampleFreq=%s",
+		"%s/tiingo/crypto/prices?tickers=%s&startDate=%s&endDate=%s&resampleFreq=%s",
+		tiingoURL,
 		symbol,
 		url.QueryEscape(from.Format("2006-1-2")),
 		url.QueryEscape(to.Format("2006-1-2")),
 		resampleFreq)
 
-	client := &http.Client{Timeout: ClientTimeout}
-	req, _ := http.NewRequest("GET", url, nil)
+	if err := waitRate(ctx, "tiingo-crypto"); err != nil {
+		return NewQuote("", 0), err
+	}
+	client := httpClient()
+	req, _ := newRequest(ctx, url)
 	req.Header.Set("Authorization", fmt.Sprintf("Token %s", token))
 	resp, err := client.Do(req)
 
@@ -817,6 +1248,11 @@ func tiingoCrypto(symbol string, from, to time.Time, period Period, token string
 	defer resp.Body.Close()
 
 	contents, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		err := tiingoError(resp.StatusCode, contents)
+		Log.Printf("tiingo crypto symbol '%s' error: %v\n", symbol, err)
+		return NewQuote("", 0), err
+	}
 	err = json.Unmarshal(contents, &crypto)
 	if err != nil {
 		Log.Printf("tiingo crypto symbol '%s' error: %v\n", symbol, err)
@@ -837,160 +1273,350 @@ func tiingoCrypto(symbol string, from, to time.Time, period Period, token string
 		quote.Low[bar] = crypto[0].PriceData[bar].Low
 		quote.Close[bar] = crypto[0].PriceData[bar].Close
 		quote.Volume[bar] = float64(crypto[0].PriceData[bar].Volume)
+		quote.setExtra(&quote.Trades, bar, crypto[0].PriceData[bar].TradesDone)
+	}
+
+	return quote, nil
+}
+
+// tiingoCryptoBars - bars per tiingo crypto request, the endpoint silently
+// truncates longer responses (30 days of 5 minute bars)
+const tiingoCryptoBars = 8640
+
+// tiingoCryptoChunk - date range covering bars of period, in whole days
+func tiingoCryptoChunk(period Period, bars int) time.Duration {
+	day := 24 * time.Hour
+	days := time.Duration(bars) * period.Duration() / day
+	if days < 1 {
+		days = 1
+	}
+	return days * day
+}
+
+// tiingoCryptoChunked - tiingo crypto prices for a long date range, fetched in
+// chunks sized to period and stitched together. A chunk whose last bar falls
+// well before the chunk end is taken as truncated by the provider, the
+// download then resumes from that bar with smaller chunks. Canceling ctx
+// between chunks returns the bars so far with its wrapped error.
+func tiingoCryptoChunked(ctx context.Context, symbol string, from, to time.Time, period Period, token string) (Quote, error) {
+
+	quote := NewQuote(symbol, 0)
+	step := period.Duration()
+	chunk := tiingoCryptoChunk(period, tiingoCryptoBars)
+
+	start := from
+	for {
+		end := start.Add(chunk)
+		if end.After(to) {
+			end = to
+		}
+		q, err := tiingoCrypto(ctx, symbol, start, end, period, token)
+		if err != nil {
+			return NewQuote("", 0), err
+		}
+
+		// chunks overlap by a day at their boundaries, skip bars already seen
+		added := 0
+		for bar := range q.Date {
+			n := len(quote.Date)
+			if n == 0 || q.Date[bar].After(quote.Date[n-1]) {
+				quote.appendBar(q, bar)
+				added++
+			}
+		}
+		Log.Printf("tiingo crypto %s %s to %s: %d bars\n", symbol, start.Format("2006-01-02"), end.Format("2006-01-02"), added)
+
+		next := end
+		if n := len(q.Date); n > 0 && q.Date[n-1].Before(end.Add(-step)) {
+			resume := q.Date[n-1].Truncate(24 * time.Hour)
+			if resume.After(start) {
+				chunk = tiingoCryptoChunk(period, int(q.Date[n-1].Sub(q.Date[0])/step))
+				Log.Printf("tiingo crypto %s truncated at %d bars, resuming from %s in %d day chunks\n",
+					symbol, n, resume.Format("2006-01-02"), int(chunk.Hours()/24))
+				next = resume
+			} else {
+				// no progress from the same day, the data just has a gap
+				chunk = tiingoCryptoChunk(period, tiingoCryptoBars)
+			}
+		}
+		if !next.Before(to) {
+			break
+		}
+		start = next
+		if delayContext(ctx, "tiingo-crypto") != nil {
+			return quote, interrupted(ctx, "tiingo crypto %s from %s", symbol, start.Format("2006-01-02"))
+		}
 	}
 
 	return quote, nil
 }
 
-// NewQuoteFromTiingo - Tiingo daily historical prices for a symbol
-func NewQuoteFromTiingo(symbol, startDate, endDate string, token string) (Quote, error) {
+// NewQuoteFromTiingo - Tiingo adjusted historical prices for a symbol, daily
+// or resampled by Tiingo to weekly, monthly or yearly bars. The last bar of a
+// range ending mid week, month or year covers only part of it.
+func NewQuoteFromTiingo(symbol, startDate, endDate string, period Period, token string) (Quote, error) {
+	return NewQuoteFromTiingoCtx(context.Background(), symbol, startDate, endDate, period, token)
+}
+
+// NewQuoteFromTiingoCtx - NewQuoteFromTiingo, canceled with ctx
+func NewQuoteFromTiingoCtx(ctx context.Context, symbol, startDate, endDate string, period Period, token string) (Quote, error) {
+
+	from := ParseDateString(startDate)
+	to := ParseDateString(endDate)
+
+	return tiingoDaily(ctx, symbol, from, to, period, token)
+}
+
+// NewQuotePairFromTiingo - Tiingo raw and provider adjusted historical
+// prices for a symbol from a single download. Both quotes carry the AdjClose
+// column, the raw one the provider's adjusted open, high and low too.
+func NewQuotePairFromTiingo(symbol, startDate, endDate string, period Period, token string) (Quote, Quote, error) {
+	return NewQuotePairFromTiingoCtx(context.Background(), symbol, startDate, endDate, period, token)
+}
+
+// NewQuotePairFromTiingoCtx - NewQuotePairFromTiingo, canceled with ctx
+func NewQuotePairFromTiingoCtx(ctx context.Context, symbol, startDate, endDate string, period Period, token string) (Quote, Quote, error) {
 
 	from := ParseDateString(startDate)
 	to := ParseDateString(endDate)
 
-	return tiingoDaily(symbol, from, to, token)
+	return tiingoDailyPair(ctx, symbol, from, to, period, token)
 }
 
-// NewQuoteFromTiingoCrypto - Tiingo crypto historical prices for a symbol
+// NewQuoteFromTiingoCrypto - Tiingo crypto historical prices for a symbol.
+// Long date ranges are downloaded in several requests.
 func NewQuoteFromTiingoCrypto(symbol, startDate, endDate string, period Period, token string) (Quote, error) {
+	return NewQuoteFromTiingoCryptoCtx(context.Background(), symbol, startDate, endDate, period, token)
+}
+
+// NewQuoteFromTiingoCryptoCtx - NewQuoteFromTiingoCrypto, canceled with ctx.
+// A download canceled between its requests returns the bars so far with the
+// wrapped error of ctx.
+func NewQuoteFromTiingoCryptoCtx(ctx context.Context, symbol, startDate, endDate string, period Period, token string) (Quote, error) {
+
+	if err := ValidatePeriod("tiingo-crypto", period); err != nil {
+		Log.Printf("tiingo error: %v\n", err)
+		return NewQuote("", 0), err
+	}
 
 	from := ParseDateString(startDate)
 	to := ParseDateString(endDate)
 
-	return tiingoCrypto(symbol, from, to, period, token)
+	quote, err := tiingoCryptoChunked(ctx, symbol, from, to, period, token)
+	return quote.withMeta("tiingo-crypto", period, false), err
+}
+
+// NewQuotesFromTiingoSyms - create a list of prices from symbols in string
+// array. Symbols that fail, e.g. not found, are skipped; a fatal TiingoError,
+// a rejected token or the rate limit, stops the download and is returned with
+// the quotes so far.
+func NewQuotesFromTiingoSyms(symbols []string, startDate, endDate string, period Period, token string) (Quotes, error) {
+	return NewQuotesFromTiingoSymsCtx(context.Background(), symbols, startDate, endDate, period, token)
 }
 
-// NewQuotesFromTiingoSyms - create a list of prices from symbols in string array
-func NewQuotesFromTiingoSyms(symbols []string, startDate, endDate string, token string) (Quotes, error) {
+// NewQuotesFromTiingoSymsCtx - NewQuotesFromTiingoSyms, canceled with ctx.
+// The quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromTiingoSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period, token string) (Quotes, error) {
 
 	quotes := Quotes{}
-	for _, symbol := range symbols {
-		quote, err := NewQuoteFromTiingo(symbol, startDate, endDate, token)
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromTiingoCtx(ctx, symbol, startDate, endDate, period, token)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "tiingo after %d of %d symbols", i, len(symbols))
+		}
 		if err == nil {
 			quotes = append(quotes, quote)
+		} else if tiingoFatal(err) {
+			return quotes, err
 		} else {
 			Log.Println("error downloading " + symbol)
 		}
-		time.Sleep(Delay * time.Millisecond)
+		delayContext(ctx, "tiingo")
 	}
 	return quotes, nil
 }
 
-// NewQuotesFromTiingoCryptoSyms - create a list of prices from symbols in string array
+// NewQuotesFromTiingoCryptoSyms - create a list of prices from symbols in
+// string array, stopping at the first fatal TiingoError
 func NewQuotesFromTiingoCryptoSyms(symbols []string, startDate, endDate string, period Period, token string) (Quotes, error) {
+	return NewQuotesFromTiingoCryptoSymsCtx(context.Background(), symbols, startDate, endDate, period, token)
+}
+
+// NewQuotesFromTiingoCryptoSymsCtx - NewQuotesFromTiingoCryptoSyms, canceled
+// with ctx. The quotes downloaded before are returned with the wrapped error
+// of ctx.
+func NewQuotesFromTiingoCryptoSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period, token string) (Quotes, error) {
 
 	quotes := Quotes{}
-	for _, symbol := range symbols {
-		quote, err := NewQuoteFromTiingoCrypto(symbol, startDate, endDate, period, token)
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromTiingoCryptoCtx(ctx, symbol, startDate, endDate, period, token)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "tiingo crypto after %d of %d symbols", i, len(symbols))
+		}
 		if err == nil {
 			quotes = append(quotes, quote)
+		} else if tiingoFatal(err) {
+			return quotes, err
 		} else {
 			Log.Println("error downloading " + symbol)
 		}
-		time.Sleep(Delay * time.Millisecond)
+		delayContext(ctx, "tiingo-crypto")
 	}
 	return quotes, nil
 }
 
-// NewQuoteFromCoinbase - Coinbase Pro historical prices for a symbol
-func NewQuoteFromCoinbase(symbol, startDate, endDate string, period Period) (Quote, error) {
+// coinbaseGranularities - candle granularities in seconds accepted by Coinbase
+var coinbaseGranularities = map[Period]int{
+	Min1:  60,
+	Min5:  300,
+	Min15: 900,
+	Min60: 3600,
+	Hour6: 21600,
+	Daily: 86400,
+}
 
-	start := ParseDateString(startDate) //.In(time.Now().Location())
-	end := ParseDateString(endDate)     //.In(time.Now().Location())
+// coinbaseGranularity - candle granularity in seconds of a supported period,
+// 0 if Coinbase does not accept it
+func coinbaseGranularity(period Period) int {
+	return coinbaseGranularities[period]
+}
 
-	var granularity int // seconds
+// coinbasePeriods - candle granularities accepted by Coinbase
+var coinbasePeriods = []Period{Min1, Min5, Min15, Min60, Hour6, Daily}
 
-	switch period {
-	case Min1:
-		granularity = 60
-	case Min5:
-		granularity = 5 * 60
-	case Min15:
-		granularity = 15 * 60
-	case Min30:
-		granularity = 30 * 60
-	case Min60:
-		granularity = 60 * 60
-	case Daily:
-		granularity = 24 * 60 * 60
-	case Weekly:
-		granularity = 7 * 24 * 60 * 60
-	default:
-		granularity = 24 * 60 * 60
-	}
+// coinbaseMaxBars - most candles Coinbase returns per request
+const coinbaseMaxBars = 200
+
+// NewQuoteFromCoinbase - Coinbase historical prices for a symbol.
+// Returns an *UnknownProductError for symbols that are not Coinbase products,
+// see ValidateCoinbaseProducts.
+func NewQuoteFromCoinbase(symbol, startDate, endDate string, period Period) (Quote, error) {
+	return NewQuoteFromCoinbaseCtx(context.Background(), symbol, startDate, endDate, period)
+}
 
-	var quote Quote
-	quote.Symbol = symbol
+// NewQuoteFromCoinbaseCtx - NewQuoteFromCoinbase, canceled with ctx. A
+// download canceled between its requests returns the bars so far with the
+// wrapped error of ctx.
+func NewQuoteFromCoinbaseCtx(ctx context.Context, symbol, startDate, endDate string, period Period) (Quote, error) {
+	quote, err := coinbaseCandles(ctx, symbol, startDate, endDate, period)
+	return quote.withMeta("coinbase", period, false), err
+}
 
-	maxBars := 200
-	var step time.Duration
-	step = time.Second * time.Duration(granularity)
+// NewQuoteFromGdax - Coinbase historical prices for a symbol.
+//
+// Deprecated: GDAX is now Coinbase, use NewQuoteFromCoinbase.
+func NewQuoteFromGdax(symbol, startDate, endDate string, period Period) (Quote, error) {
+	return NewQuoteFromCoinbase(symbol, startDate, endDate, period)
+}
 
-	startBar := start
-	endBar := startBar.Add(time.Duration(maxBars) * step)
+// NewQuoteFromGdaxCtx - Coinbase historical prices for a symbol, canceled
+// with ctx.
+//
+// Deprecated: GDAX is now Coinbase, use NewQuoteFromCoinbaseCtx.
+func NewQuoteFromGdaxCtx(ctx context.Context, symbol, startDate, endDate string, period Period) (Quote, error) {
+	return NewQuoteFromCoinbaseCtx(ctx, symbol, startDate, endDate, period)
+}
 
-	if endBar.After(end) {
-		endBar = end
+// coinbaseWindows - inclusive [from, to] request windows of at most
+// coinbaseMaxBars bars of step covering start through end
+func coinbaseWindows(start, end time.Time, step time.Duration) [][2]time.Time {
+	var windows [][2]time.Time
+	for from := start; !from.After(end); {
+		to := from.Add((coinbaseMaxBars - 1) * step)
+		if to.After(end) {
+			to = end
+		}
+		windows = append(windows, [2]time.Time{from, to})
+		from = to.Add(step)
 	}
+	return windows
+}
+
+func coinbaseCandles(ctx context.Context, symbol, startDate, endDate string, period Period) (Quote, error) {
 
-	//Log.Printf("startBar=%v, endBar=%v\n", startBar, endBar)
+	if err := ValidatePeriod("coinbase", period); err != nil {
+		Log.Printf("coinbase error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	if err := validateCoinbaseProduct(ctx, symbol); err != nil {
+		Log.Printf("coinbase error: %v\n", err)
+		return NewQuote("", 0), err
+	}
 
-	for startBar.Before(end) {
+	start := ParseDateString(startDate)
+	end := ParseDateString(endDate)
+	granularity := coinbaseGranularity(period)
+	step := time.Second * time.Duration(granularity)
+
+	quote := NewQuote(symbol, 0)
+	windows := coinbaseWindows(start, end, step)
+	// a candle can come back in two windows
+	candles := func() Quote {
+		quote.Sort()
+		quote.Dedupe(KeepLast)
+		return quote
+	}
+	for i, window := range windows {
+		if i > 0 && delayContext(ctx, "coinbase") != nil {
+			return candles(), interrupted(ctx, "coinbase %s after %d of %d requests", symbol, i, len(windows))
+		}
 
 		url := fmt.Sprintf(
-			"https://api.pro.coinbase.com/products/%s/candles?start=%s&end=%s&granularity=%d",
+			"%s/products/%s/candles?start=%s&end=%s&granularity=%d",
+			coinbaseURL,
 			symbol,
-			url.QueryEscape(startBar.Format(time.RFC3339)),
-			url.QueryEscape(endBar.Format(time.RFC3339)),
+			url.QueryEscape(window[0].UTC().Format(time.RFC3339)),
+			url.QueryEscape(window[1].UTC().Format(time.RFC3339)),
 			granularity)
 
-		client := &http.Client{Timeout: ClientTimeout}
-		req, _ := http.NewRequest("GET", url, nil)
-		resp, err := client.Do(req)
-
+		if err := waitRate(ctx, "coinbase"); err != nil {
+			return NewQuote("", 0), err
+		}
+		client := httpClient()
+		resp, err := getContext(ctx, client, url)
+		if err != nil && ctx.Err() != nil {
+			return candles(), interrupted(ctx, "coinbase %s after %d of %d requests", symbol, i, len(windows))
+		}
 		if err != nil {
 			Log.Printf("coinbase error: %v\n", err)
 			return NewQuote("", 0), err
 		}
-		defer resp.Body.Close()
-
 		contents, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			var apiErr struct {
+				Message string `json:"message"`
+			}
+			if json.Unmarshal(contents, &apiErr) != nil || apiErr.Message == "" {
+				apiErr.Message = resp.Status
+			}
+			err = fmt.Errorf("coinbase %s: %s", symbol, apiErr.Message)
+			Log.Printf("coinbase error: %v\n", err)
+			return NewQuote("", 0), err
+		}
 
 		type cb [6]float64
 		var bars []cb
-		err = json.Unmarshal(contents, &bars)
-		if err != nil {
+		if err := json.Unmarshal(contents, &bars); err != nil {
 			Log.Printf("coinbase error: %v\n", err)
+			return NewQuote("", 0), err
 		}
 
 		numrows := len(bars)
 		q := NewQuote(symbol, numrows)
-
-		//Log.Printf("numrows=%d, bars=%v\n", numrows, bars)
-
 		for row := 0; row < numrows; row++ {
 			bar := numrows - 1 - row // reverse the order
-			q.Date[bar] = time.Unix(int64(bars[row][0]), 0)
+			q.Date[bar] = time.Unix(int64(bars[row][0]), 0).UTC()
 			q.Open[bar] = bars[row][1]
 			q.High[bar] = bars[row][2]
 			q.Low[bar] = bars[row][3]
 			q.Close[bar] = bars[row][4]
 			q.Volume[bar] = bars[row][5]
 		}
-		quote.Date = append(quote.Date, q.Date...)
-		quote.Open = append(quote.Open, q.Open...)
-		quote.High = append(quote.High, q.High...)
-		quote.Low = append(quote.Low, q.Low...)
-		quote.Close = append(quote.Close, q.Close...)
-		quote.Volume = append(quote.Volume, q.Volume...)
-
-		time.Sleep(time.Second)
-		startBar = endBar.Add(step)
-		endBar = startBar.Add(time.Duration(maxBars) * step)
-
+		quote.appendQuote(q)
 	}
-
-	return quote, nil
+	return candles(), nil
 }
 
 // NewQuotesFromCoinbase - create a list of prices from symbols in file
@@ -1013,112 +1639,222 @@ func NewQuotesFromCoinbase(filename, startDate, endDate string, period Period) (
 		} else {
 			Log.Println("error downloading " + sym)
 		}
-		time.Sleep(Delay * time.Millisecond)
+		delay("coinbase")
 	}
 	return quotes, nil
 }
 
+// NewQuotesFromGdax - create a list of prices from symbols in file
+//
+// Deprecated: GDAX is now Coinbase, use NewQuotesFromCoinbase.
+func NewQuotesFromGdax(filename, startDate, endDate string, period Period) (Quotes, error) {
+	return NewQuotesFromCoinbase(filename, startDate, endDate, period)
+}
+
 // NewQuotesFromCoinbaseSyms - create a list of prices from symbols in string array
 func NewQuotesFromCoinbaseSyms(symbols []string, startDate, endDate string, period Period) (Quotes, error) {
+	return NewQuotesFromCoinbaseSymsCtx(context.Background(), symbols, startDate, endDate, period)
+}
+
+// NewQuotesFromCoinbaseSymsCtx - NewQuotesFromCoinbaseSyms, canceled with
+// ctx. The quotes downloaded before are returned with the wrapped error of
+// ctx.
+func NewQuotesFromCoinbaseSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period) (Quotes, error) {
 
 	quotes := Quotes{}
-	for _, symbol := range symbols {
-		quote, err := NewQuoteFromCoinbase(symbol, startDate, endDate, period)
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromCoinbaseCtx(ctx, symbol, startDate, endDate, period)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "coinbase after %d of %d symbols", i, len(symbols))
+		}
 		if err == nil {
 			quotes = append(quotes, quote)
 		} else {
 			Log.Println("error downloading " + symbol)
 		}
-		time.Sleep(Delay * time.Millisecond)
+		delayContext(ctx, "coinbase")
 	}
 	return quotes, nil
 }
 
-// NewQuoteFromBittrex - Biitrex historical prices for a symbol
-func NewQuoteFromBittrex(symbol string, period Period) (Quote, error) {
+// NewQuotesFromGdaxSyms - create a list of prices from symbols in string array
+//
+// Deprecated: GDAX is now Coinbase, use NewQuotesFromCoinbaseSyms.
+func NewQuotesFromGdaxSyms(symbols []string, startDate, endDate string, period Period) (Quotes, error) {
+	return NewQuotesFromCoinbaseSyms(symbols, startDate, endDate, period)
+}
+
+// NewQuotesFromGdaxSymsCtx - create a list of prices from symbols in string
+// array, canceled with ctx
+//
+// Deprecated: GDAX is now Coinbase, use NewQuotesFromCoinbaseSymsCtx.
+func NewQuotesFromGdaxSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period) (Quotes, error) {
+	return NewQuotesFromCoinbaseSymsCtx(ctx, symbols, startDate, endDate, period)
+}
+
+// bittrexURL - base url of the Bittrex api
+var bittrexURL = "https://api.bittrex.com"
+
+// bittrexPeriods - candle intervals of the Bittrex v3 api
+var bittrexPeriods = []Period{Min1, Min5, Min60, Daily}
+
+// UnknownMarketError - a symbol that is not a Bittrex market
+type UnknownMarketError struct {
+	Market string
+}
+
+func (e *UnknownMarketError) Error() string {
+	return fmt.Sprintf("unknown market %s", e.Market)
+}
+
+// NewQuoteFromBittrex - Biitrex historical prices for a symbol between
+// startDate and endDate (inclusive). Bittrex serves historical candles a day
+// (1m, 5m), a month (1h) or a year (d) per request, so a range takes one
+// request per day, month or year it touches, with Delay between requests.
+// The period still in progress comes from the recent candles endpoint.
+// Returns an *UnknownMarketError for a symbol Bittrex does not list.
+func NewQuoteFromBittrex(symbol, startDate, endDate string, period Period) (Quote, error) {
+	return NewQuoteFromBittrexCtx(context.Background(), symbol, startDate, endDate, period)
+}
+
+// NewQuoteFromBittrexCtx - NewQuoteFromBittrex, canceled with ctx. A
+// download canceled between its requests returns the bars so far with the
+// wrapped error of ctx.
+func NewQuoteFromBittrexCtx(ctx context.Context, symbol, startDate, endDate string, period Period) (Quote, error) {
+
+	interval, ok := bittrexIntervals[period]
+	if !ok {
+		return NewQuote("", 0), ValidatePeriod("bittrex", period)
+	}
+
+	from := ParseDateString(startDate).UTC()
+	to := parseEndDate(endDate).UTC()
+	now := time.Now().UTC()
+
+	quote := NewQuote(symbol, 0)
+	candles := func() Quote {
+		return quote.between(from, to).withMeta("bittrex", period, false)
+	}
+	for i, t := 0, from; t.Before(to) && t.Before(now); i++ {
+		start, next, path := bittrexChunk(period, t)
+		if i > 0 && delayContext(ctx, "bittrex") != nil {
+			return candles(), interrupted(ctx, "bittrex %s after %d requests", symbol, i)
+		}
+		if next.After(now) {
+			path = "recent"
+		} else {
+			path = "historical/" + path
+		}
+		chunk, err := bittrexCandles(ctx, symbol, interval, path)
+		if err != nil && ctx.Err() != nil {
+			return candles(), interrupted(ctx, "bittrex %s after %d requests", symbol, i)
+		}
+		if err != nil {
+			Log.Printf("bittrex error: %v\n", err)
+			return NewQuote("", 0), err
+		}
+		quote.appendQuote(chunk.between(start, next))
+		t = next
+	}
+	return candles(), nil
+}
+
+// NewQuoteFromBittrexRecent - Biitrex historical prices for a symbol, for
+// whatever recent window the endpoint returns.
+//
+// Deprecated: this is the former two-argument NewQuoteFromBittrex, use
+// NewQuoteFromBittrex with a date range instead.
+func NewQuoteFromBittrexRecent(symbol string, period Period) (Quote, error) {
+	interval, ok := bittrexIntervals[period]
+	if !ok {
+		return NewQuote("", 0), ValidatePeriod("bittrex", period)
+	}
+	quote, err := bittrexCandles(context.Background(), symbol, interval, "recent")
+	return quote.withMeta("bittrex", period, false), err
+}
 
-	var bittrexPeriod string
+// bittrexIntervals - candleInterval of each supported period
+var bittrexIntervals = map[Period]string{
+	Min1:  "MINUTE_1",
+	Min5:  "MINUTE_5",
+	Min60: "HOUR_1",
+	Daily: "DAY_1",
+}
 
+// bittrexChunk - the day, month or year of historical candles of period
+// holding t, as its start, the start of the next one and its
+// year[/month[/day]] path
+func bittrexChunk(period Period, t time.Time) (time.Time, time.Time, string) {
+	y, m, d := t.UTC().Date()
 	switch period {
-	case Min1:
-		bittrexPeriod = "oneMin"
-	case Min5:
-		bittrexPeriod = "fiveMin"
-	case Min30:
-		bittrexPeriod = "thirtyMin"
-	case Min60:
-		bittrexPeriod = "hour"
 	case Daily:
-		bittrexPeriod = "day"
+		start := time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, 0), fmt.Sprintf("%d", y)
+	case Min60:
+		start := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0), fmt.Sprintf("%d/%d", y, m)
 	default:
-		bittrexPeriod = "day"
+		start := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1), fmt.Sprintf("%d/%d/%d", y, m, d)
 	}
+}
 
-	var quote Quote
-	quote.Symbol = symbol
+// bittrexCandles - candles of symbol from the recent or historical/... path
+// of the v3 candles endpoint, canceled with ctx
+func bittrexCandles(ctx context.Context, symbol, interval, path string) (Quote, error) {
 
 	url := fmt.Sprintf(
-		"https://bittrex.com/Api/v2.0/pub/market/GetTicks?marketName=%s&tickInterval=%s",
-		symbol,
-		bittrexPeriod)
-
-	client := &http.Client{Timeout: ClientTimeout}
-	req, _ := http.NewRequest("GET", url, nil)
-	resp, err := client.Do(req)
+		"%s/v3/markets/%s/candles/%s/%s",
+		bittrexURL,
+		strings.ToUpper(symbol),
+		interval,
+		path)
 
+	if err := waitRate(ctx, "bittrex"); err != nil {
+		return NewQuote("", 0), err
+	}
+	client := httpClient()
+	resp, err := getContext(ctx, client, url)
 	if err != nil {
-		Log.Printf("bittrex error: %v\n", err)
 		return NewQuote("", 0), err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return NewQuote("", 0), &UnknownMarketError{Market: symbol}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return NewQuote("", 0), fmt.Errorf("bittrex candles: %s", resp.Status)
+	}
 
 	contents, _ := ioutil.ReadAll(resp.Body)
 
-	type OHLC struct {
-		O  float64
-		H  float64
-		L  float64
-		C  float64
-		V  float64
-		T  string
-		BV float64
+	type candle struct {
+		StartsAt time.Time
+		Open     float64 `json:",string"`
+		High     float64 `json:",string"`
+		Low      float64 `json:",string"`
+		Close    float64 `json:",string"`
+		Volume   float64 `json:",string"`
 	}
-	type Result struct {
-		Success bool   `json:"succes"`
-		Message string `json:"message"`
-		OHLC    []OHLC `json:"result"`
+	var candles []candle
+	if err := json.Unmarshal(contents, &candles); err != nil {
+		return NewQuote("", 0), err
 	}
 
-	var result Result
-
-	err = json.Unmarshal(contents, &result)
-	if err != nil {
-		Log.Printf("bittrex error: %v\n", err)
+	q := NewQuote(symbol, len(candles))
+	for bar, c := range candles {
+		q.Date[bar] = c.StartsAt.UTC()
+		q.Open[bar] = c.Open
+		q.High[bar] = c.High
+		q.Low[bar] = c.Low
+		q.Close[bar] = c.Close
+		q.Volume[bar] = c.Volume
 	}
-
-	numrows := len(result.OHLC)
-	q := NewQuote(symbol, numrows)
-
-	for bar := 0; bar < numrows; bar++ {
-		q.Date[bar], _ = time.Parse("2006-01-02T15:04:05", result.OHLC[bar].T) //"2017-11-28T16:50:00"
-		q.Open[bar] = result.OHLC[bar].O
-		q.High[bar] = result.OHLC[bar].H
-		q.Low[bar] = result.OHLC[bar].L
-		q.Close[bar] = result.OHLC[bar].C
-		q.Volume[bar] = result.OHLC[bar].V
-	}
-	quote.Date = append(quote.Date, q.Date...)
-	quote.Open = append(quote.Open, q.Open...)
-	quote.High = append(quote.High, q.High...)
-	quote.Low = append(quote.Low, q.Low...)
-	quote.Close = append(quote.Close, q.Close...)
-	quote.Volume = append(quote.Volume, q.Volume...)
-
-	return quote, nil
+	return q, nil
 }
 
 // NewQuotesFromBittrex - create a list of prices from symbols in file
-func NewQuotesFromBittrex(filename string, period Period) (Quotes, error) {
+func NewQuotesFromBittrex(filename, startDate, endDate string, period Period) (Quotes, error) {
 
 	quotes := Quotes{}
 	inFile, err := os.Open(filename)
@@ -1131,220 +1867,303 @@ func NewQuotesFromBittrex(filename string, period Period) (Quotes, error) {
 
 	for scanner.Scan() {
 		sym := scanner.Text()
-		quote, err := NewQuoteFromBittrex(sym, period)
+		quote, err := NewQuoteFromBittrex(sym, startDate, endDate, period)
 		if err == nil {
 			quotes = append(quotes, quote)
 		} else {
 			Log.Println("error downloading " + sym)
 		}
-		time.Sleep(Delay * time.Millisecond)
+		delay("bittrex")
 	}
 	return quotes, nil
 }
 
 // NewQuotesFromBittrexSyms - create a list of prices from symbols in string array
-func NewQuotesFromBittrexSyms(symbols []string, period Period) (Quotes, error) {
+func NewQuotesFromBittrexSyms(symbols []string, startDate, endDate string, period Period) (Quotes, error) {
+	return NewQuotesFromBittrexSymsCtx(context.Background(), symbols, startDate, endDate, period)
+}
+
+// NewQuotesFromBittrexSymsCtx - NewQuotesFromBittrexSyms, canceled with ctx.
+// The quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromBittrexSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period) (Quotes, error) {
 
 	quotes := Quotes{}
-	for _, symbol := range symbols {
-		quote, err := NewQuoteFromBittrex(symbol, period)
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromBittrexCtx(ctx, symbol, startDate, endDate, period)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "bittrex after %d of %d symbols", i, len(symbols))
+		}
 		if err == nil {
 			quotes = append(quotes, quote)
 		} else {
 			Log.Println("error downloading " + symbol)
 		}
-		time.Sleep(Delay * time.Millisecond)
+		delayContext(ctx, "bittrex")
 	}
 	return quotes, nil
 }
 
+// binancePeriods - kline intervals of the Binance api
+var binancePeriods = []Period{Min1, Min3, Min5, Min15, Min30, Min60, Hour2, Hour4, Hour6, Hour8, Hour12, Daily, Day3, Weekly, Monthly}
+
 // NewQuoteFromBinance - Binance historical prices for a symbol
 func NewQuoteFromBinance(symbol string, startDate, endDate string, period Period) (Quote, error) {
+	return NewQuoteFromBinanceCtx(context.Background(), symbol, startDate, endDate, period)
+}
 
-	start := ParseDateString(startDate)
-	end := ParseDateString(endDate)
+// NewQuoteFromBinanceCtx - NewQuoteFromBinance, canceled with ctx. A
+// download canceled between its requests returns the bars so far with the
+// wrapped error of ctx.
+func NewQuoteFromBinanceCtx(ctx context.Context, symbol string, startDate, endDate string, period Period) (Quote, error) {
+	pageURL := func(interval string, start, end int64) string {
+		return fmt.Sprintf(
+			"%s/api/v3/klines?symbol=%s&interval=%s&startTime=%d&endTime=%d&limit=%d",
+			binanceURL,
+			strings.ToUpper(symbol),
+			interval,
+			start,
+			end,
+			binanceLimit)
+	}
+	quote, err := binanceKlines(ctx, "binance", symbol, startDate, endDate, period, pageURL)
+	return quote.withMeta("binance", period, false), err
+}
 
-	var interval string
-	var granularity int // seconds
+// binanceIntervals - kline interval of each supported period
+var binanceIntervals = map[Period]string{
+	Min1:    "1m",
+	Min3:    "3m",
+	Min5:    "5m",
+	Min15:   "15m",
+	Min30:   "30m",
+	Min60:   "1h",
+	Hour2:   "2h",
+	Hour4:   "4h",
+	Hour6:   "6h",
+	Hour8:   "8h",
+	Hour12:  "12h",
+	Daily:   "1d",
+	Day3:    "3d",
+	Weekly:  "1w",
+	Monthly: "1M",
+}
 
-	switch period {
-	case Min1:
-		interval = "1m"
-		granularity = 60
-	case Min3:
-		interval = "3m"
-		granularity = 3 * 60
-	case Min5:
-		interval = "5m"
-		granularity = 5 * 60
-	case Min15:
-		interval = "15m"
-		granularity = 15 * 60
-	case Min30:
-		interval = "30m"
-		granularity = 30 * 60
-	case Min60:
-		interval = "1h"
-		granularity = 60 * 60
-	case Hour2:
-		interval = "2h"
-		granularity = 2 * 60 * 60
-	case Hour4:
-		interval = "4h"
-		granularity = 4 * 60 * 60
-	case Hour8:
-		interval = "8h"
-		granularity = 8 * 60 * 60
-	case Hour12:
-		interval = "12h"
-		granularity = 12 * 60 * 60
-	case Daily:
-		interval = "1d"
-		granularity = 24 * 60 * 60
-	case Day3:
-		interval = "3d"
-		granularity = 3 * 24 * 60 * 60
-	case Weekly:
-		interval = "1w"
-		granularity = 7 * 24 * 60 * 60
-	case Monthly:
-		interval = "1M"
-		granularity = 30 * 24 * 60 * 60
-	default:
-		interval = "1d"
-		granularity = 24 * 60 * 60
+// binanceURL - base url of the Binance api
+var binanceURL = "https://api.binance.com"
+
+// binanceLimit - most klines Binance returns per request
+const binanceLimit = 1000
+
+// BinanceSymbolsError - symbols Binance rejected as invalid, in request order
+type BinanceSymbolsError struct {
+	Symbols []string
+}
+
+func (e *BinanceSymbolsError) Error() string {
+	return fmt.Sprintf("binance rejected symbols %s", strings.Join(e.Symbols, ", "))
+}
+
+// binanceStatusError - a Binance request answered with an error status
+type binanceStatusError struct {
+	status int
+	msg    string
+}
+
+func (e *binanceStatusError) Error() string {
+	return fmt.Sprintf("binance %d: %s", e.status, e.msg)
+}
+
+// binancePageURL - url of the klines of an interval opening from start to
+// end, in milliseconds since the epoch
+type binancePageURL func(interval string, start, end int64) string
+
+// binancePage - up to binanceLimit klines from url of source, binance or
+// binance-futures. An error payload such as {"code":-1121,"msg":"Invalid
+// symbol."} is an error whatever the status. Canceled with ctx.
+func binancePage(ctx context.Context, source, url string) ([][12]interface{}, error) {
+
+	if err := waitRate(ctx, source); err != nil {
+		return nil, err
+	}
+	client := httpClient()
+	resp, err := getContext(ctx, client, url)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
+	contents, _ := ioutil.ReadAll(resp.Body)
 
-	var quote Quote
-	quote.Symbol = symbol
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Msg string `json:"msg"`
+		}
+		if json.Unmarshal(contents, &apiErr) != nil || apiErr.Msg == "" {
+			apiErr.Msg = resp.Status
+		}
+		return nil, &binanceStatusError{resp.StatusCode, apiErr.Msg}
+	}
 
-	maxBars := 500
-	var step time.Duration
-	step = time.Second * time.Duration(granularity)
+	var bars [][12]interface{}
+	if err = json.Unmarshal(contents, &bars); err != nil {
+		var apiErr struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		if json.Unmarshal(contents, &apiErr) == nil && apiErr.Msg != "" {
+			return nil, &binanceStatusError{resp.StatusCode, apiErr.Msg}
+		}
+	}
+	return bars, err
+}
 
-	startBar := start
-	endBar := startBar.Add(time.Duration(maxBars) * step)
+// binanceKlines - klines of symbol opening from startDate through endDate,
+// requested binanceLimit at a time from pageURL of source with Delay between
+// requests. The candle still forming, whose close time is in the future, is
+// dropped. Canceled with ctx, returning the klines so far with the wrapped
+// error of ctx.
+func binanceKlines(ctx context.Context, source, symbol string, startDate, endDate string, period Period, pageURL binancePageURL) (Quote, error) {
 
-	if endBar.After(end) {
-		endBar = end
+	if err := ValidatePeriod("binance", period); err != nil {
+		Log.Printf("binance error: %v\n", err)
+		return NewQuote("", 0), err
 	}
 
-	for startBar.Before(end) {
+	const ms = int64(time.Millisecond)
+	start := ParseDateString(startDate).UnixNano() / ms
+	end := parseEndDate(endDate).UnixNano()/ms - 1
+	now := time.Now().UnixNano() / ms
 
-		url := fmt.Sprintf(
-			"https://api.binance.com/api/v1/klines?symbol=%s&interval=%s&startTime=%d&endTime=%d",
-			strings.ToUpper(symbol),
-			interval,
-			startBar.UnixNano()/1000000,
-			endBar.UnixNano()/1000000)
-		//log.Println(url)
-		client := &http.Client{Timeout: ClientTimeout}
-		req, _ := http.NewRequest("GET", url, nil)
-		resp, err := client.Do(req)
+	interval, ok := binanceIntervals[period]
+	if !ok {
+		interval = "1d"
+	}
 
+	quote := NewQuote(symbol, 0)
+
+	/*
+		0       OpenTime                 int64
+		1 			Open                     float64
+		2 			High                     float64
+		3		 	Low                      float64
+		4 			Close                    float64
+		5 			Volume                   float64
+		6 			CloseTime                int64
+		7 			QuoteAssetVolume         float64
+		8 			NumTrades                int64
+		9 			TakerBuyBaseAssetVolume  float64
+		10 			TakerBuyQuoteAssetVolume float64
+		11 			Ignore                   float64
+	*/
+
+	klines := func() Quote {
+		quote.Sort()
+		quote.Dedupe(KeepLast)
+		return quote
+	}
+	for page := 0; start <= end; page++ {
+		if page > 0 && delayContext(ctx, source) != nil {
+			return klines(), interrupted(ctx, "%s %s after %d requests", source, symbol, page)
+		}
+		bars, err := binancePage(ctx, source, pageURL(interval, start, end))
+		if err != nil && ctx.Err() != nil {
+			return klines(), interrupted(ctx, "%s %s after %d requests", source, symbol, page)
+		}
 		if err != nil {
 			Log.Printf("binance error: %v\n", err)
 			return NewQuote("", 0), err
 		}
-		defer resp.Body.Close()
-
-		contents, _ := ioutil.ReadAll(resp.Body)
 
-		type binance [12]interface{}
-		var bars []binance
-		err = json.Unmarshal(contents, &bars)
-		if err != nil {
-			Log.Printf("binance error: %v\n", err)
+		for _, bar := range bars {
+			open, _ := bar[0].(float64)
+			closeTime, _ := bar[6].(float64)
+			if int64(open) < start || int64(closeTime) > now {
+				continue
+			}
+			start = int64(open) + 1
+
+			row := len(quote.Date)
+			quote.Date = append(quote.Date, time.Unix(0, int64(open)*ms).UTC())
+			for i, col := range []*[]float64{&quote.Open, &quote.High, &quote.Low, &quote.Close, &quote.Volume} {
+				s, _ := bar[i+1].(string)
+				v, _ := strconv.ParseFloat(s, 64)
+				*col = append(*col, v)
+			}
+			if quote.Trades != nil {
+				quote.Trades = append(quote.Trades, 0)
+			}
+			if trades, ok := bar[8].(float64); ok {
+				quote.setExtra(&quote.Trades, row, trades)
+			}
+		}
+		if len(bars) < binanceLimit {
+			break
 		}
-
-		numrows := len(bars)
-		q := NewQuote(symbol, numrows)
-		//fmt.Printf("numrows=%d, bars=%v\n", numrows, bars)
-
-		/*
-			0       OpenTime                 int64
-			1 			Open                     float64
-			2 			High                     float64
-			3		 	Low                      float64
-			4 			Close                    float64
-			5 			Volume                   float64
-			6 			CloseTime                int64
-			7 			QuoteAssetVolume         float64
-			8 			NumTrades                int64
-			9 			TakerBuyBaseAssetVolume  float64
-			10 			TakerBuyQuoteAssetVolume float64
-			11 			Ignore                   float64
-		*/
-
-		for bar := 0; bar < numrows; bar++ {
-			q.Date[bar] = time.Unix(int64(bars[bar][6].(float64))/1000, 0)
-			q.Open[bar], _ = strconv.ParseFloat(bars[bar][1].(string), 64)
-			q.High[bar], _ = strconv.ParseFloat(bars[bar][2].(string), 64)
-			q.Low[bar], _ = strconv.ParseFloat(bars[bar][3].(string), 64)
-			q.Close[bar], _ = strconv.ParseFloat(bars[bar][4].(string), 64)
-			q.Volume[bar], _ = strconv.ParseFloat(bars[bar][5].(string), 64)
-		}
-		quote.Date = append(quote.Date, q.Date...)
-		quote.Open = append(quote.Open, q.Open...)
-		quote.High = append(quote.High, q.High...)
-		quote.Low = append(quote.Low, q.Low...)
-		quote.Close = append(quote.Close, q.Close...)
-		quote.Volume = append(quote.Volume, q.Volume...)
-
-		time.Sleep(time.Second)
-		startBar = endBar.Add(step)
-		endBar = startBar.Add(time.Duration(maxBars) * step)
-
 	}
-	return quote, nil
+	return klines(), nil
 }
 
 // NewQuotesFromBinance - create a list of prices from symbols in file
 func NewQuotesFromBinance(filename string, startDate, endDate string, period Period) (Quotes, error) {
-	quotes := Quotes{}
 	inFile, err := os.Open(filename)
 	if err != nil {
-		return quotes, err
+		return Quotes{}, err
 	}
 	defer inFile.Close()
 	scanner := bufio.NewScanner(inFile)
 	scanner.Split(bufio.ScanLines)
 
+	var symbols []string
 	for scanner.Scan() {
-		sym := scanner.Text()
-		quote, err := NewQuoteFromBinance(sym, startDate, endDate, period)
-		if err == nil {
-			quotes = append(quotes, quote)
-		} else {
-			Log.Println("error downloading " + sym)
-		}
-		time.Sleep(Delay * time.Millisecond)
+		symbols = append(symbols, scanner.Text())
 	}
-	return quotes, nil
+	return NewQuotesFromBinanceSyms(symbols, startDate, endDate, period)
 }
 
-// NewQuotesFromBinanceSyms - create a list of prices from symbols in string array
+// NewQuotesFromBinanceSyms - create a list of prices from symbols in string
+// array. Symbols that fail to download are skipped; those Binance rejects as
+// invalid are also listed in a *BinanceSymbolsError returned with the quotes.
 func NewQuotesFromBinanceSyms(symbols []string, startDate, endDate string, period Period) (Quotes, error) {
+	return NewQuotesFromBinanceSymsCtx(context.Background(), symbols, startDate, endDate, period)
+}
+
+// NewQuotesFromBinanceSymsCtx - NewQuotesFromBinanceSyms, canceled with ctx.
+// The quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromBinanceSymsCtx(ctx context.Context, symbols []string, startDate, endDate string, period Period) (Quotes, error) {
 
 	quotes := Quotes{}
-	for _, symbol := range symbols {
-		quote, err := NewQuoteFromBinance(symbol, startDate, endDate, period)
+	var rejected []string
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromBinanceCtx(ctx, symbol, startDate, endDate, period)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "binance after %d of %d symbols", i, len(symbols))
+		}
 		if err == nil {
 			quotes = append(quotes, quote)
 		} else {
 			Log.Println("error downloading " + symbol)
+			if se, ok := err.(*binanceStatusError); ok && se.status == http.StatusBadRequest {
+				rejected = append(rejected, symbol)
+			}
 		}
-		time.Sleep(Delay * time.Millisecond)
+		delayContext(ctx, "binance")
+	}
+	if len(rejected) > 0 {
+		return quotes, &BinanceSymbolsError{Symbols: rejected}
 	}
 	return quotes, nil
 }
 
 // NewEtfList - download a list of etf symbols to an array of strings
 func NewEtfList() ([]string, error) {
+	return NewEtfListCtx(context.Background())
+}
+
+// NewEtfListCtx - NewEtfList, canceled with ctx
+func NewEtfListCtx(ctx context.Context) ([]string, error) {
 
 	var symbols []string
 
-	buf, err := getAnonFTP("ftp.nasdaqtrader.com", "21", "symboldirectory", "otherlisted.txt")
+	buf, err := getAnonFTP(ctx, "ftp.nasdaqtrader.com", "21", "symboldirectory", "otherlisted.txt")
 	if err != nil {
 		Log.Println(err)
 		return symbols, err
@@ -1426,8 +2245,19 @@ func ValidMarket(market string) bool {
 	return false
 }
 
+// marketSource - the source of the symbols of market, e.g. binance for
+// binance-btc
+func marketSource(market string) string {
+	return strings.SplitN(market, "-", 2)[0]
+}
+
 // NewMarketList - download a list of market symbols to an array of strings
 func NewMarketList(market string) ([]string, error) {
+	return NewMarketListCtx(context.Background(), market)
+}
+
+// NewMarketListCtx - NewMarketList, canceled with ctx
+func NewMarketListCtx(ctx context.Context, market string) ([]string, error) {
 
 	var symbols []string
 	if !ValidMarket(market) {
@@ -1498,14 +2328,17 @@ func NewMarketList(market string) ([]string, error) {
 	//case "tiingo-usd":
 	//	url = fmt.Sprintf("https://api.tiingo.com/tiingo/crypto?token=%s", os.Getenv("TIINGO_API_TOKEN"))
 	case "coinbase":
-		url = "https://api.pro.coinbase.com/products"
+		url = coinbaseURL + "/products"
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := newRequest(ctx, url)
 	req.Header.Add("User-Agent", "markcheno/go-quote")
 	req.Header.Add("Accept", "application/xml")
 	req.Header.Add("Content-Type", "application/xml; charset=utf-8")
-	client := &http.Client{}
+	if err := waitRate(ctx, marketSource(market)); err != nil {
+		return symbols, err
+	}
+	client := httpClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		return symbols, err
@@ -1781,18 +2614,35 @@ func deleteEmpty(s []string) []string {
 	return r
 }
 
-// Grab a file via anonymous FTP
-func getAnonFTP(addr, port string, dir string, fname string) ([]byte, error) {
+// Grab a file via anonymous FTP, the connections are closed when ctx is done
+func getAnonFTP(ctx context.Context, addr, port string, dir string, fname string) ([]byte, error) {
 
 	var err error
 	var contents []byte
 	const timeout = 5 * time.Second
+	dialer := net.Dialer{Timeout: timeout}
 
-	nconn, err := net.DialTimeout("tcp", addr+":"+port, timeout)
+	nconn, err := dialer.DialContext(ctx, "tcp", addr+":"+port)
 	if err != nil {
 		return contents, err
 	}
 	defer nconn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	var dconn net.Conn
+	var mu sync.Mutex
+	go func() {
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			nconn.Close()
+			if dconn != nil {
+				dconn.Close()
+			}
+			mu.Unlock()
+		case <-done:
+		}
+	}()
 
 	conn := textproto.NewConn(nconn)
 	_, _, _ = conn.ReadResponse(2)
@@ -1819,11 +2669,24 @@ func getAnonFTP(addr, port string, dir string, fname string) ([]byte, error) {
 
 	_ = conn.PrintfLine("RETR %s", fname)
 	_, _, err = conn.ReadResponse(1)
-	dconn, err := net.DialTimeout("tcp", addr+":"+strconv.Itoa(dport), timeout)
+	data, err := dialer.DialContext(ctx, "tcp", addr+":"+strconv.Itoa(dport))
+	if err != nil {
+		return contents, err
+	}
+	mu.Lock()
+	dconn = data
+	if ctx.Err() != nil {
+		// canceled before the watcher could see dconn
+		dconn.Close()
+	}
+	mu.Unlock()
 	defer dconn.Close()
 
 	contents, err = ioutil.ReadAll(dconn)
 	if err != nil {
+		if ctx.Err() != nil {
+			return contents, ctx.Err()
+		}
 		return contents, err
 	}
 
@@ -0,0 +1,514 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/markcheno/go-quote"
+)
+
+// periodValues - accepted -period values without aliases
+const periodValues = "1m|3m|5m|15m|30m|1h|2h|4h|6h|8h|12h|d|3d|w|m|y"
+
+// formats - accepted -format values
+var formats = []string{"csv", "json", "jsonmap", "hs", "lwc", "ami", "go", "xlsx", "ndjson", "pb", "msgpack", "pgcopy", "html", "parquet", "sqlite"}
+
+// stdoutFormats - formats -outfile=- streams to stdout
+var stdoutFormats = []string{"csv", "json", "hs"}
+
+// csvLayouts - accepted -csv-layout values
+var csvLayouts = []string{"default", "backtrader", "zipline"}
+
+// partitionKeys - accepted -partition keys
+var partitionKeys = []string{"symbol", "year", "month", "date"}
+
+// lookupPeriod - period of a -period value, false if unknown
+func lookupPeriod(periodFlag string) (quote.Period, bool) {
+	period, err := quote.ParsePeriod(periodFlag)
+	if err != nil {
+		return quote.Daily, false
+	}
+	return period, true
+}
+
+// getPeriod - period of a -period value, Daily if unknown
+func getPeriod(periodFlag string) quote.Period {
+	period, _ := lookupPeriod(periodFlag)
+	return period
+}
+
+// parsePrecision - quote.DefaultPrecision of a -precision value, full or a
+// number of decimals, 0 (by symbol) if empty
+func parsePrecision(precision string) (int, error) {
+	switch precision {
+	case "":
+		return 0, nil
+	case "full":
+		return quote.FullPrecision, nil
+	}
+	n, err := strconv.Atoi(precision)
+	if err == nil && (n < 1 || n > 17) {
+		err = fmt.Errorf("precision %d out of range", n)
+	}
+	return n, err
+}
+
+// rateLimit - a -rate limit of source in requests per second
+type rateLimit struct {
+	source string
+	rps    float64
+	burst  int
+}
+
+// parseRates - limits of a -rate value, comma separated source:rps[:burst]
+// with a burst of 1 by default, none if empty
+func parseRates(rates string) ([]rateLimit, error) {
+	if rates == "" {
+		return nil, nil
+	}
+	var limits []rateLimit
+	for _, pair := range strings.Split(rates, ",") {
+		parts := strings.Split(pair, ":")
+		if len(parts) < 2 || len(parts) > 3 || quote.SupportedPeriods(parts[0]) == nil {
+			return nil, fmt.Errorf("invalid rate '%s'", pair)
+		}
+		limit := rateLimit{source: parts[0], burst: 1}
+		var err error
+		limit.rps, err = strconv.ParseFloat(parts[1], 64)
+		if err != nil || !(limit.rps > 0) || math.IsInf(limit.rps, 1) {
+			return nil, fmt.Errorf("invalid rate '%s'", pair)
+		}
+		if len(parts) == 3 {
+			if limit.burst, err = strconv.Atoi(parts[2]); err != nil || limit.burst < 1 {
+				return nil, fmt.Errorf("invalid rate burst '%s'", pair)
+			}
+		}
+		limits = append(limits, limit)
+	}
+	return limits, nil
+}
+
+// parseDateFlag - parse a yyyy[-mm[-dd]] date flag, empty is now
+func parseDateFlag(dt string) (time.Time, error) {
+	const layout = "2006-01-02 15:04"
+	if dt == "" {
+		return time.Now(), nil
+	}
+	if len(dt) > len(layout) {
+		return time.Time{}, fmt.Errorf("too long")
+	}
+	return time.Parse(layout, dt+"0000-01-01 00:00"[len(dt):])
+}
+
+// flagError - invalid flag value with the flag, the value and what is accepted
+type flagError struct {
+	flag     string
+	value    interface{}
+	accepted string
+}
+
+func (e flagError) Error() string {
+	return fmt.Sprintf("invalid -%s '%v', %s", e.flag, e.value, e.accepted)
+}
+
+// oneOf - accepted text for a list of values
+func oneOf(values []string) string {
+	return "must be one of " + strings.Join(values, ", ")
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSource - -source, its -period and its -token
+func checkSource(source, periodFlag, token string) error {
+	if quote.SupportedPeriods(source) == nil {
+		return flagError{"source", source, oneOf(quote.Sources())}
+	}
+	period, ok := lookupPeriod(periodFlag)
+	if !ok {
+		return flagError{"period", periodFlag, oneOf(strings.Split(periodValues, "|"))}
+	}
+	if quote.ValidatePeriod(source, period) != nil {
+		names := []string{}
+		for _, p := range quote.SupportedPeriods(source) {
+			names = append(names, p.Name())
+		}
+		return flagError{"period", periodFlag, fmt.Sprintf("not supported by -source=%s, %s", source, oneOf(names))}
+	}
+	if env := tokenEnv(source); env != "" && token == "" {
+		return fmt.Errorf("missing -token for -source=%s, must be passed or %s must be set", source, env)
+	}
+	return nil
+}
+
+// tokenEnv - environment variable holding the default -token of source,
+// empty for sources without a token
+func tokenEnv(source string) string {
+	switch {
+	case strings.HasPrefix(source, "tiingo"):
+		return "TIINGO_API_TOKEN"
+	case source == "alphavantage":
+		return "ALPHAVANTAGE_API_KEY"
+	case source == "polygon":
+		return "POLYGON_API_KEY"
+	case source == "iex":
+		return "IEX_TOKEN"
+	case source == "finnhub":
+		return "FINNHUB_TOKEN"
+	case source == "alpaca":
+		return "APCA_API_KEY_ID and APCA_API_SECRET_KEY"
+	case source == "cryptocompare":
+		return "CRYPTOCOMPARE_API_KEY"
+	case source == "fred":
+		return "FRED_API_KEY"
+	}
+	return ""
+}
+
+// envToken - the -token of source from its environment, token for sources
+// without one. Alpaca's key id and secret are joined as keyid:secret.
+func envToken(source, token string) string {
+	switch env := tokenEnv(source); {
+	case source == "alpaca":
+		keyID, secret := os.Getenv("APCA_API_KEY_ID"), os.Getenv("APCA_API_SECRET_KEY")
+		if keyID == "" || secret == "" {
+			return ""
+		}
+		return keyID + ":" + secret
+	case env != "":
+		return os.Getenv(env)
+	}
+	return token
+}
+
+// flagRules - checks of checkFlags, in order. Domain checks of single flags
+// come first so that cross-flag errors only see valid values.
+var flagRules = []func(flags quoteflags) error{
+
+	// domains
+	func(flags quoteflags) error {
+		if flags.source == "dir" {
+			// files are read whatever their period
+			return nil
+		}
+		return checkSource(flags.source, flags.period, flags.token)
+	},
+	func(flags quoteflags) error {
+		if flags.start == "" && flags.years < 1 {
+			return flagError{"years", flags.years, "must be at least 1"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if _, err := parseDateFlag(flags.start); err != nil {
+			return flagError{"start", flags.start, "must be yyyy[-mm[-dd]]"}
+		}
+		if _, err := parseDateFlag(flags.end); err != nil {
+			return flagError{"end", flags.end, "must be yyyy[-mm[-dd]]"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.delay < 0 {
+			return flagError{"delay", flags.delay, "must not be negative"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if _, err := parseRates(flags.rate); err != nil {
+			return flagError{"rate", flags.rate, "must be source:rps[:burst] pairs of known sources and positive rates, e.g. binance:10,tiingo:0.01"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if !contains(formats, flags.format) {
+			return flagError{"format", flags.format, oneOf(formats)}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		// the writer modules register themselves when built in
+		if flags.format == "parquet" && quote.ParquetWriter == nil {
+			return fmt.Errorf("-format=parquet requires quote built with -tags parquet")
+		}
+		if flags.format == "sqlite" && quote.SQLiteWriter == nil {
+			return fmt.Errorf("-format=sqlite requires quote built with -tags sqlite")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if _, err := parsePrecision(flags.precision); err != nil {
+			return flagError{"precision", flags.precision, "must be full or 1 to 17 decimals"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.log == "" {
+			return flagError{"log", flags.log, "must be a filename, stdout, stderr or discard"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.partition == "" {
+			return nil
+		}
+		seen := map[string]bool{}
+		for _, key := range strings.Split(flags.partition, ",") {
+			if !contains(partitionKeys, key) || seen[key] {
+				return flagError{"partition", flags.partition, "keys must be distinct and " + oneOf(partitionKeys)}
+			}
+			seen[key] = true
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.last < 0 {
+			return flagError{"last", flags.last, "must not be negative"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.bars < 0 {
+			return flagError{"bars", flags.bars, "must not be negative"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.tolerance < 0 {
+			return flagError{"tolerance", flags.tolerance, "must not be negative"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.minScore < 0 || flags.minScore > 100 {
+			return flagError{"min-score", flags.minScore, "must be between 0 and 100"}
+		}
+		return nil
+	},
+
+	// cross-flag constraints
+	func(flags quoteflags) error {
+		from, _ := parseDateFlag(flags.start)
+		to, _ := parseDateFlag(flags.end)
+		if flags.start != "" && from.After(to) {
+			end := flags.end
+			if end == "" {
+				end = "today"
+			}
+			return flagError{"start", flags.start, fmt.Sprintf("must not be after -end %s", end)}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.bars > 0 && (flags.yearsSet || flags.start != "" || flags.end != "") {
+			return fmt.Errorf("-bars counts back from now, not valid with -years, -start or -end")
+		}
+		if flags.bars > 0 && (flags.adjust.both() || flags.compare != "" || flags.repair) {
+			return fmt.Errorf("-bars not valid with -adjust=both, -compare or -repair")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.source != "dir" {
+			return nil
+		}
+		if flags.infile == "" {
+			return fmt.Errorf("-source=dir requires -infile, the directory to read")
+		}
+		if flags.bars > 0 || flags.adjust.both() || flags.compare != "" || flags.repair {
+			return fmt.Errorf("-source=dir not valid with -bars, -adjust=both, -compare or -repair")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.prePost && flags.source != "yahoo" {
+			return flagError{"source", flags.source, "must be yahoo with -prepost"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.outdir != "" && filepath.IsAbs(flags.outfile) {
+			return flagError{"outfile", flags.outfile, "must be relative to -outdir"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.compare == "" {
+			return nil
+		}
+		sources := strings.Split(flags.compare, ",")
+		if len(sources) != 2 {
+			return flagError{"compare", flags.compare, "must be two sources separated by a comma"}
+		}
+		for _, src := range sources {
+			if err := checkSource(src, flags.period, flags.token); err != nil {
+				return fmt.Errorf("-compare: %v", err)
+			}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.closeOnly && flags.compare == "" {
+			return fmt.Errorf("-close-only requires -compare")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.minScore > 0 && !flags.report {
+			return fmt.Errorf("-min-score requires -report")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if !(flags.maxJump > 0) {
+			return flagError{"max-jump", flags.maxJump, "must be positive"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if !flags.adjust.both() {
+			return nil
+		}
+		if flags.source != "yahoo" && flags.source != "tiingo" && flags.source != "iex" {
+			return flagError{"source", flags.source, "must be yahoo, tiingo or iex with -adjust=both"}
+		}
+		if flags.partition != "" {
+			return fmt.Errorf("-adjust=both not valid with -partition")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if !flags.repair {
+			return nil
+		}
+		if flags.format != "csv" && flags.format != "json" {
+			return flagError{"format", flags.format, "must be csv or json with -repair"}
+		}
+		if flags.all || flags.partition != "" || flags.adjust.both() {
+			return fmt.Errorf("-repair works on individual symbol files, not with -all, -partition or -adjust=both")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.validate && (flags.compare != "" || flags.repair || flags.source == "dir") {
+			return fmt.Errorf("-validate checks the files given, not with -compare, -repair or -source=dir")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if _, err := time.LoadLocation(flags.tz); err != nil {
+			return flagError{"tz", flags.tz, "must be a time zone such as UTC or America/New_York"}
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if !contains(csvLayouts, flags.csvLayout) {
+			return flagError{"csv-layout", flags.csvLayout, oneOf(csvLayouts)}
+		}
+		if flags.csvLayout == "default" {
+			return nil
+		}
+		if flags.format != "csv" {
+			return flagError{"format", flags.format, "must be csv with -csv-layout"}
+		}
+		if flags.all || flags.partition != "" || flags.repair {
+			return fmt.Errorf("-csv-layout writes individual symbol files, not with -all, -partition or -repair")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if utf8.RuneCountInString(flags.csvDelim) != 1 && flags.csvDelim != "tab" && flags.csvDelim != `\t` {
+			return flagError{"csv-delim", flags.csvDelim, "must be a single character or tab"}
+		}
+		if strings.ContainsAny(flags.csvDelim, "\"\r\n") {
+			return flagError{"csv-delim", flags.csvDelim, "must not be a quote or newline"}
+		}
+		if !customCSV(flags) || flags.csvLayout != "default" {
+			return nil
+		}
+		if flags.format != "csv" {
+			return flagError{"format", flags.format, "must be csv with -csv-delim, -csv-dateformat, -no-header or -csv-offset"}
+		}
+		if flags.partition != "" || flags.repair {
+			return fmt.Errorf("-csv-delim, -csv-dateformat, -no-header and -csv-offset do not apply with -partition or -repair")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.outfile != "-" {
+			return nil
+		}
+		if !contains(stdoutFormats, flags.format) {
+			return flagError{"format", flags.format, "must be " + strings.Join(stdoutFormats, ", ") + " with -outfile=-"}
+		}
+		if flags.outdir != "" || flags.partition != "" || flags.append || flags.repair || flags.meta || flags.report || flags.adjust.both() {
+			return fmt.Errorf("-outfile=- not valid with -outdir, -partition, -append, -repair, -meta, -report or -adjust=both")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if !flags.append {
+			return nil
+		}
+		if flags.format != "csv" {
+			return flagError{"format", flags.format, "must be csv with -append"}
+		}
+		if flags.partition != "" || flags.repair || customCSV(flags) {
+			return fmt.Errorf("-append extends default csv files, not with -partition, -repair or -csv-* flags")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.meta && flags.format != "json" {
+			return flagError{"format", flags.format, "must be json with -meta"}
+		}
+		if flags.meta && flags.partition != "" {
+			return fmt.Errorf("-meta not valid with -partition")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.format == "jsonmap" && !flags.all {
+			return fmt.Errorf("-format=jsonmap requires -all")
+		}
+		if flags.format == "pgcopy" && !flags.all {
+			return fmt.Errorf("-format=pgcopy requires -all")
+		}
+		return nil
+	},
+	func(flags quoteflags) error {
+		if flags.partition == "" {
+			return nil
+		}
+		if flags.outdir == "" {
+			return fmt.Errorf("-partition requires -outdir")
+		}
+		if flags.format != "csv" && flags.format != "json" {
+			return flagError{"format", flags.format, "must be csv or json with -partition"}
+		}
+		return nil
+	},
+}
+
+// checkFlags - validate the domain of every flag and the constraints between
+// them, returning the first violation
+func checkFlags(flags quoteflags) error {
+	for _, rule := range flagRules {
+		if err := rule(flags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
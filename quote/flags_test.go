@@ -0,0 +1,169 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/markcheno/go-quote"
+)
+
+// validFlags - the flag defaults of run
+func validFlags() quoteflags {
+	return quoteflags{years: 5, delay: 100, period: "d", source: "yahoo", format: "csv", csvLayout: "default", csvDelim: ",", log: "stdout", adjust: "true", tolerance: 0.01, maxJump: 50, benchmark: "spy"}
+}
+
+func TestCheckFlagsInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(f *quoteflags)
+		want   string // in the error
+	}{
+		{"unknown source", func(f *quoteflags) { f.source = "google" }, "-source 'google', must be one of yahoo"},
+		{"unknown period", func(f *quoteflags) { f.period = "2d" }, "-period '2d', must be one of 1m, 3m"},
+		{"unsupported period", func(f *quoteflags) { f.period = "3m" }, "-period '3m', not supported by -source=yahoo"},
+		{"missing token", func(f *quoteflags) { f.source = "tiingo" }, "missing -token for -source=tiingo"},
+		{"alphavantage missing token", func(f *quoteflags) { f.source = "alphavantage" }, "ALPHAVANTAGE_API_KEY must be set"},
+		{"fred missing token", func(f *quoteflags) { f.source = "fred" }, "FRED_API_KEY must be set"},
+		{"cryptocompare missing token", func(f *quoteflags) { f.source = "cryptocompare" }, "CRYPTOCOMPARE_API_KEY must be set"},
+		{"alpaca missing token", func(f *quoteflags) { f.source = "alpaca" }, "APCA_API_KEY_ID and APCA_API_SECRET_KEY must be set"},
+		{"finnhub missing token", func(f *quoteflags) { f.source = "finnhub" }, "FINNHUB_TOKEN must be set"},
+		{"iex missing token", func(f *quoteflags) { f.source = "iex" }, "IEX_TOKEN must be set"},
+		{"polygon missing token", func(f *quoteflags) { f.source = "polygon" }, "POLYGON_API_KEY must be set"},
+		{"fx missing token", func(f *quoteflags) { f.source = "tiingo-fx" }, "missing -token for -source=tiingo-fx"},
+		{"tiingo intraday", func(f *quoteflags) { f.source, f.period, f.token = "tiingo", "1h", "tok" }, "not supported by -source=tiingo, must be one of d, w, m, y"},
+		{"zero years", func(f *quoteflags) { f.years = 0 }, "-years '0', must be at least 1"},
+		{"bad start", func(f *quoteflags) { f.start = "2023-13" }, "-start '2023-13', must be yyyy[-mm[-dd]]"},
+		{"long end", func(f *quoteflags) { f.end = "2023-01-01 00:00:00" }, "-end '2023-01-01 00:00:00'"},
+		{"start after end", func(f *quoteflags) { f.start, f.end = "2024-02", "2024-01-31" }, "-start '2024-02', must not be after -end 2024-01-31"},
+		{"start in the future", func(f *quoteflags) { f.start = "2999" }, "must not be after -end today"},
+		{"negative delay", func(f *quoteflags) { f.delay = -1 }, "-delay '-1'"},
+		{"rate unknown source", func(f *quoteflags) { f.rate = "google:1" }, "-rate 'google:1', must be source:rps[:burst] pairs"},
+		{"rate without rps", func(f *quoteflags) { f.rate = "binance:10,yahoo" }, "-rate 'binance:10,yahoo'"},
+		{"zero rate", func(f *quoteflags) { f.rate = "binance:0" }, "-rate 'binance:0'"},
+		{"infinite rate", func(f *quoteflags) { f.rate = "binance:inf" }, "-rate 'binance:inf'"},
+		{"zero rate burst", func(f *quoteflags) { f.rate = "binance:10:0" }, "-rate 'binance:10:0'"},
+		{"unknown format", func(f *quoteflags) { f.format = "xml" }, "-format 'xml', must be one of csv, json, jsonmap"},
+		{"empty log", func(f *quoteflags) { f.log = "" }, "-log ''"},
+		{"unknown partition key", func(f *quoteflags) { f.outdir, f.partition = "out", "symbol,week" }, "-partition 'symbol,week'"},
+		{"repeated partition key", func(f *quoteflags) { f.outdir, f.partition = "out", "year,year" }, "keys must be distinct"},
+		{"negative last", func(f *quoteflags) { f.last = -5 }, "-last '-5'"},
+		{"negative bars", func(f *quoteflags) { f.bars = -1 }, "-bars '-1'"},
+		{"bars with start", func(f *quoteflags) { f.bars, f.start = 500, "2020" }, "-bars counts back from now"},
+		{"bars with years", func(f *quoteflags) { f.bars, f.yearsSet = 500, true }, "not valid with -years, -start or -end"},
+		{"bars with compare", func(f *quoteflags) { f.bars, f.compare = 500, "yahoo,binance" }, "-bars not valid with -adjust=both, -compare"},
+		{"prepost binance", func(f *quoteflags) { f.source, f.prePost = "binance", true }, "-source 'binance', must be yahoo with -prepost"},
+		{"negative tolerance", func(f *quoteflags) { f.compare, f.tolerance = "yahoo,binance", -0.1 }, "-tolerance '-0.1'"},
+		{"min score above 100", func(f *quoteflags) { f.report, f.minScore = true, 101 }, "-min-score '101', must be between 0 and 100"},
+		{"absolute outfile with outdir", func(f *quoteflags) { f.outdir, f.outfile = "out", "/tmp/spy.csv" }, "-outfile '/tmp/spy.csv', must be relative to -outdir"},
+		{"compare one source", func(f *quoteflags) { f.compare = "yahoo" }, "-compare 'yahoo', must be two sources"},
+		{"compare bad source", func(f *quoteflags) { f.compare = "yahoo,google" }, "-compare: invalid -source 'google'"},
+		{"close-only without compare", func(f *quoteflags) { f.closeOnly = true }, "-close-only requires -compare"},
+		{"min-score without report", func(f *quoteflags) { f.minScore = 50 }, "-min-score requires -report"},
+		{"zero max jump", func(f *quoteflags) { f.report, f.maxJump = true, 0 }, "-max-jump '0', must be positive"},
+		{"adjust both crypto", func(f *quoteflags) { f.source, f.adjust = "binance", "both" }, "must be yahoo, tiingo or iex with -adjust=both"},
+		{"repair hs", func(f *quoteflags) { f.repair, f.format = true, "hs" }, "-format 'hs', must be csv or json with -repair"},
+		{"repair all", func(f *quoteflags) { f.repair, f.all = true, true }, "-repair works on individual symbol files"},
+		{"validate repair", func(f *quoteflags) { f.validate, f.repair = true, true }, "-validate checks the files given"},
+		{"meta csv", func(f *quoteflags) { f.meta = true }, "-format 'csv', must be json with -meta"},
+		{"jsonmap without all", func(f *quoteflags) { f.format = "jsonmap" }, "-format=jsonmap requires -all"},
+		{"unknown csv layout", func(f *quoteflags) { f.csvLayout = "pandas" }, "-csv-layout 'pandas', must be one of default, backtrader, zipline"},
+		{"csv layout json", func(f *quoteflags) { f.csvLayout, f.format = "zipline", "json" }, "-format 'json', must be csv with -csv-layout"},
+		{"csv layout all", func(f *quoteflags) { f.csvLayout, f.all = "backtrader", true }, "-csv-layout writes individual symbol files"},
+		{"zero precision", func(f *quoteflags) { f.precision = "0" }, "-precision '0', must be full or 1 to 17 decimals"},
+		{"stdout xlsx", func(f *quoteflags) { f.outfile, f.format = "-", "xlsx" }, "-format 'xlsx', must be csv, json, hs with -outfile=-"},
+		{"stdout outdir", func(f *quoteflags) { f.outfile, f.outdir = "-", "data" }, "-outfile=- not valid with -outdir"},
+		{"append json", func(f *quoteflags) { f.append, f.format = true, "json" }, "-format 'json', must be csv with -append"},
+		{"append partition", func(f *quoteflags) { f.append, f.partition = true, "symbol" }, "-append extends default csv files"},
+		{"long csv delim", func(f *quoteflags) { f.csvDelim = ";;" }, "-csv-delim ';;', must be a single character or tab"},
+		{"newline csv delim", func(f *quoteflags) { f.csvDelim = "\n" }, "-csv-delim '\n', must not be a quote or newline"},
+		{"no header json", func(f *quoteflags) { f.noHeader, f.format = true, "json" }, "-format 'json', must be csv with -csv-delim, -csv-dateformat, -no-header or -csv-offset"},
+		{"csv dateformat partition", func(f *quoteflags) { f.csvDate, f.partition = "epoch", "symbol" }, "-csv-delim, -csv-dateformat, -no-header and -csv-offset do not apply"},
+		{"csv offset json", func(f *quoteflags) { f.csvOffset, f.format = true, "json" }, "-format 'json', must be csv with -csv-delim"},
+		{"unknown tz", func(f *quoteflags) { f.tz = "Mars/Olympus" }, "-tz 'Mars/Olympus'"},
+		{"pgcopy without all", func(f *quoteflags) { f.format = "pgcopy" }, "-format=pgcopy requires -all"},
+		{"partition without outdir", func(f *quoteflags) { f.partition = "symbol" }, "-partition requires -outdir"},
+		{"dir without infile", func(f *quoteflags) { f.source = "dir" }, "-source=dir requires -infile"},
+		{"dir with bars", func(f *quoteflags) { f.source, f.infile, f.bars = "dir", "data", 500 }, "-source=dir not valid with -bars"},
+		{"partition go", func(f *quoteflags) { f.outdir, f.partition, f.format = "out", "symbol", "go" }, "must be csv or json with -partition"},
+	}
+	for _, tt := range tests {
+		f := validFlags()
+		tt.change(&f)
+		err := checkFlags(f)
+		if err == nil {
+			t.Errorf("%s: accepted", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %q does not contain %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCheckFlagsValid(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(f *quoteflags)
+	}{
+		{"defaults", func(f *quoteflags) {}},
+		{"years ignored with start", func(f *quoteflags) { f.years, f.start = 0, "2020" }},
+		{"single day", func(f *quoteflags) { f.start, f.end = "2024-01-02", "2024-01-02" }},
+		{"start time of day", func(f *quoteflags) { f.start, f.end = "2024-01-02 09:30", "2024-01-02 16:00" }},
+		{"yearly", func(f *quoteflags) { f.period = "y" }},
+		{"period alias", func(f *quoteflags) { f.source, f.period = "binance", "1M" }},
+		{"tiingo fx hourly", func(f *quoteflags) { f.source, f.period, f.token = "tiingo-fx", "1h", "tok" }},
+		{"tiingo weekly", func(f *quoteflags) { f.source, f.period, f.token = "tiingo", "w", "tok" }},
+		{"relative outfile with outdir", func(f *quoteflags) { f.outdir, f.outfile = "out", "sub/spy.csv" }},
+		{"all partition keys", func(f *quoteflags) { f.outdir, f.partition, f.format = "out", "symbol,year,month,date", "json" }},
+		{"compare with close-only", func(f *quoteflags) { f.compare, f.closeOnly, f.token = "yahoo,tiingo", true, "tok" }},
+		{"report with min-score", func(f *quoteflags) { f.report, f.minScore = true, 100 }},
+		{"bars with default years", func(f *quoteflags) { f.bars = 500 }},
+		{"meta json all", func(f *quoteflags) { f.meta, f.format, f.all = true, "json", true }},
+		{"dir any period", func(f *quoteflags) { f.source, f.infile, f.period = "dir", "data", "1m" }},
+		{"rate pairs", func(f *quoteflags) { f.rate = "binance:10,tiingo:0.01:5" }},
+	}
+	for _, tt := range tests {
+		f := validFlags()
+		tt.change(&f)
+		if err := checkFlags(f); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestRunInvalidFlag(t *testing.T) {
+	for _, args := range [][]string{{"-format=xml", "spy"}, {"-nope", "spy"}} {
+		if code := run(args, nil); code != 2 {
+			t.Errorf("%v: exit code %d", args, code)
+		}
+	}
+}
+
+func TestParseRates(t *testing.T) {
+	rates, err := parseRates("binance:10,tiingo:0.01:5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []rateLimit{{"binance", 10, 1}, {"tiingo", 0.01, 5}}
+	if !reflect.DeepEqual(rates, want) {
+		t.Errorf("rates %v, want %v", rates, want)
+	}
+	if rates, err := parseRates(""); rates != nil || err != nil {
+		t.Errorf("empty -rate: %v %v", rates, err)
+	}
+}
+
+func TestCheckFlagsWriters(t *testing.T) {
+	savedParquet, savedSQLite := quote.ParquetWriter, quote.SQLiteWriter
+	quote.ParquetWriter, quote.SQLiteWriter = nil, nil
+	defer func() { quote.ParquetWriter, quote.SQLiteWriter = savedParquet, savedSQLite }()
+
+	for _, format := range []string{"parquet", "sqlite"} {
+		f := validFlags()
+		f.format = format
+		if err := checkFlags(f); err == nil || !strings.Contains(err.Error(), "-tags "+format) {
+			t.Errorf("%s without the writer: %v", format, err)
+		}
+	}
+}
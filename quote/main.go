@@ -12,11 +12,23 @@ Licensed under terms of MIT license
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
 	"io/ioutil"
+	"math"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/markcheno/go-quote"
 )
@@ -25,27 +37,122 @@ var usage = `Usage:
   quote -h | -help
   quote -v | -version
   quote <market> [-output=<outputFile>]
+  quote [-tolerance=<frac>] [-format=json] diff <file> <file>
   quote [-years=<years>|(-start=<datestr> [-end=<datestr>])] [options] [-infile=<filename>|<symbol> ...]
 
 Options:
   -h -help             show help
   -v -version          show version
   -years=<years>       number of years to download [default=5]
+  -bars=<n>            download the last n bars up to now instead of a date
+                       range, not with -years, -start or -end
   -start=<datestr>     yyyy[-[mm-[dd]]]
   -end=<datestr>       yyyy[-[mm-[dd]]] [default=today]
-  -infile=<filename>   list of symbols to download
-  -outfile=<filename>  output filename
-  -period=<period>     1m|3m|5m|15m|30m|1h|2h|4h|6h|8h|12h|d|3d|w|m [default=d]
-  -source=<source>     yahoo|tiingo|tiingo-crypto|coinbase|bittrex|binance [default=yahoo]
-  -token=<tiingo_tok>  tingo api token [default=TIINGO_API_TOKEN]
-  -format=<format>     (csv|json|hs|ami) [default=csv]
-  -adjust=<bool>       adjust yahoo prices [default=true]
+  -infile=<filename>   list of symbols to download, or with -source=dir the
+                       directory of csv and json files to read
+  -outfile=<filename>  output filename, gzip compressed if it ends in .gz, or -
+                       to write csv, json or hs to stdout, logging to stderr
+  -outdir=<dir>        output directory
+  -partition=<keys>    hive-style partitioned output under -outdir, keys from
+                       symbol,year,month,date (e.g. symbol,year,month)
+  -period=<period>     1m|3m|5m|15m|30m|1h|2h|4h|6h|8h|12h|d|3d|w|m|y [default=d]
+  -source=<source>     yahoo|tiingo|tiingo-crypto|tiingo-fx|coinbase|bittrex|binance
+                       |binance-futures|alphavantage|stooq|polygon|iex|finnhub
+                       |alpaca|huobi|cryptocompare|coingecko|fred|ecb|moex
+                       |deribit|dir
+                       [default=yahoo], dir reads previously downloaded
+                       files instead, e.g. to convert them to -format,
+                       fred series come at their own frequency, ecb symbols
+                       are currencies per euro (usd) or pairs (gbpjpy), moex
+                       symbols may name a board (SiH3@RFUD) [default=TQBR],
+                       deribit symbols are instruments (BTC-PERPETUAL)
+  -token=<api_token>   tiingo, alphavantage, polygon, iex, finnhub,
+                       cryptocompare or fred api token, keyid:secret for
+                       alpaca [default=TIINGO_API_TOKEN, ALPHAVANTAGE_API_KEY
+                       for alphavantage, POLYGON_API_KEY for polygon,
+                       IEX_TOKEN for iex, FINNHUB_TOKEN for finnhub,
+                       APCA_API_KEY_ID:APCA_API_SECRET_KEY for alpaca,
+                       CRYPTOCOMPARE_API_KEY for cryptocompare or FRED_API_KEY
+                       for fred]
+  -format=<format>     (csv|json|jsonmap|hs|lwc|ami|go|xlsx|ndjson|pb|msgpack|
+                       pgcopy|html|parquet|sqlite) [default=csv], jsonmap
+                       (json object keyed by symbol) requires -all,
+                       xlsx (a sheet per symbol) requires -all or one symbol,
+                       ndjson is a json object per bar, one per line,
+                       pb is protocol buffers binary as in quote.proto,
+                       msgpack is MessagePack, with -all a map by symbol,
+                       pgcopy (a psql script creating -table and loading it
+                       with COPY) requires -all,
+                       lwc is TradingView lightweight-charts json,
+                       html is a Highstock chart page, with -all a symbol
+                       dropdown,
+                       go is a go source test fixture,
+                       parquet is snappy compressed, a row per symbol and bar,
+                       in a quote built with -tags parquet,
+                       sqlite upserts the bars of all symbols into the bars
+                       table of the -outfile database [default=quotes.db],
+                       in a quote built with -tags sqlite
+  -csv-layout=<layout> csv columns and dates for backtrader's GenericCSVData or a
+                       zipline csvdir bundle (default|backtrader|zipline), one
+                       file per symbol [default=default]
+  -csv-delim=<char>    csv field separator, e.g. ';' or tab [default=,]
+  -csv-dateformat=<layout>
+                       go time layout of csv dates, e.g. 2006-01-02, or epoch
+                       for unix seconds [default=2006-01-02 15:04]
+  -no-header=<bool>    csv without a header line [default=false]
+  -csv-offset=<bool>   end csv dates in their utc offset, e.g.
+                       2023-03-12 03:00-04:00 [default=false]
+  -tz=<zone>           time zone of intraday dates, e.g. UTC or
+                       America/New_York [default=as the source returns them]
+  -precision=<n|full>  decimals of prices in csv, hs, ami and xlsx output, full
+                       for the shortest text reading back as the same number
+                       [default=2, or 8 for crypto]
+  -last=<bars>         keep only the last bars of each symbol, e.g. to keep
+                       -format=go fixtures reviewable [default=all]
+  -prepost=<bool>      include yahoo pre-market and after hours intraday bars,
+                       marked in a session column (-1 pre, 0 regular, 1 post)
+                       [default=false]
+  -adjust=<mode>       adjust yahoo or iex prices (true|false|both), both writes
+                       the raw prices and a second file suffixed _adj
+                       [default=true]
+  -adjclose=<bool>     add the provider's adjusted close as an adjclose column,
+                       for yahoo, tiingo, alphavantage and iex, and to raw
+                       yahoo and tiingo prices the adjopen, adjhigh and adjlow
+                       columns [default=false]
   -all=<bool>          all in one file (true|false) [default=false]
+  -append=<bool>       append the bars after the last of existing csv output
+                       files instead of overwriting them, refusing bars that
+                       overlap without continuing them [default=false]
+  -table=<name>        table of -format=pgcopy output [default=quotes]
+  -meta=<bool>         wrap json output as {"meta": {source, period, adjusted,
+                       downloaded_at, ...}, "data": {...}} [default=false]
   -log=<dest>          filename|stdout|stderr|discard [default=stdout]
   -delay=<ms>          delay in milliseconds between quote requests
+  -rate=<pairs>        limit the requests to sources, each page included, to
+                       source:rps[:burst] pairs instead of -delay, e.g.
+                       binance:10,tiingo:0.01 [default=none]
+  -compare=<a,b>       compare two sources instead of downloading, e.g. yahoo,tiingo
+  -tolerance=<frac>    max relative deviation allowed by -compare and diff
+                       [default=0.01]
+  -close-only=<bool>   -compare raw (unadjusted) closes only [default=false]
+  -report=<bool>       print a data quality report after download, then each gap,
+                       jump, zero volume run and duplicate bar, exiting non-zero
+                       if there are any [default=false]
+  -min-score=<score>   exit non-zero if the report's total score is below score (0-100)
+  -max-jump=<pct>      close to close move reported as a jump by -report [default=50]
+  -stats=<bool>        print per-symbol summary statistics to stderr [default=false]
+  -stats-out=<file>    write the -stats table to a csv file instead
+  -benchmark=<symbol>  -stats beta benchmark, empty for none [default=spy]
+  -repair=<bool>       instead of downloading, backfill the gaps in existing csv
+                       or json output files in place [default=false]
+  -validate=<bool>     instead of downloading, check the csv or json files
+                       given as arguments for inconsistent or negative prices
+                       and unordered dates, exit non-zero on any finding
+                       [default=false]
+  -journal=<file>      append a json line per downloaded symbol (source, range,
+                       bars, duration, status) to file
 
-Note: not all periods work with all sources
-
+` + periodsUsage() + `
 Valid markets:
 etfs:       etf
 crypto:     bittrex-btc,bittrex-eth,bittrex-usdt,
@@ -54,113 +161,140 @@ crypto:     bittrex-btc,bittrex-eth,bittrex-usdt,
 `
 
 const (
-	version    = "0.2"
+	version    = quote.Version
 	dateFormat = "2006-01-02"
 )
 
 type quoteflags struct {
-	years   int
-	delay   int
-	start   string
-	end     string
-	period  string
-	source  string
-	token   string
-	infile  string
-	outfile string
-	format  string
-	log     string
-	all     bool
-	adjust  bool
-	version bool
-}
-
-func check(e error) {
-	if e != nil {
-		fmt.Printf("\nerror: %v\n\n", e)
-		fmt.Println(usage)
-		os.Exit(0)
-		//panic(e)
-	}
+	years     int
+	delay     int
+	rate      string
+	start     string
+	end       string
+	period    string
+	source    string
+	token     string
+	infile    string
+	outfile   string
+	outdir    string
+	partition string
+	format    string
+	csvLayout string
+	csvDelim  string
+	csvDate   string
+	noHeader  bool
+	csvOffset bool
+	tz        string
+	precision string
+	log       string
+	all       bool
+	append    bool
+	meta      bool
+	adjust    adjustFlag
+	version   bool
+	report    bool
+	minScore  float64
+	maxJump   float64
+	compare   string
+	tolerance float64
+	closeOnly bool
+	stats     bool
+	statsOut  string
+	benchmark string
+	repair    bool
+	validate  bool
+	last      int
+	journalTo string
+	bars      int
+	prePost   bool
+	adjClose  bool
+	table     string
+	yearsSet  bool            // -years given explicitly
+	journal   *quote.Journal  // open -journal, nil without
+	dir       quote.Quotes    // quotes read from the -infile directory with -source=dir
+	ctx       context.Context // canceled on interrupt, nil for none
 }
 
-func checkFlags(flags quoteflags) error {
-
-	// validate source
-	if flags.source != "yahoo" &&
-		flags.source != "tiingo" &&
-		flags.source != "tiingo-crypto" &&
-		flags.source != "coinbase" &&
-		flags.source != "bittrex" &&
-		flags.source != "binance" {
-		return fmt.Errorf("invalid source, must be either 'yahoo', 'tiingo', 'coinbase', 'bittrex', or 'binance'")
+// context - the context of downloads, canceled on interrupt
+func (flags quoteflags) context() context.Context {
+	if flags.ctx == nil {
+		return context.Background()
 	}
+	return flags.ctx
+}
 
-	// validate period
-	if flags.source == "yahoo" &&
-		(flags.period == "1m" || flags.period == "5m" || flags.period == "15m" || flags.period == "30m" || flags.period == "1h") {
-		return fmt.Errorf("invalid period for yahoo, must be 'd'")
+// pause - sleep -delay between requests, cut short on interrupt. A source
+// limited by -rate is paced by its limiter instead.
+func pause(flags quoteflags) {
+	if quote.SourceRateLimiter(flags.source) != nil {
+		return
 	}
-	if flags.source == "tiingo" {
-		// check period
-		if flags.period != "d" {
-			return fmt.Errorf("invalid period for tiingo, must be 'd'")
-		}
-		// check token
-		if flags.token == "" {
-			return fmt.Errorf("missing token for tiingo, must be passed or TIINGO_API_TOKEN must be set")
-		}
-	}
-
-	if flags.source == "tiingo-crypto" &&
-		!(flags.period == "1m" ||
-			flags.period == "3m" ||
-			flags.period == "5m" ||
-			flags.period == "15m" ||
-			flags.period == "30m" ||
-			flags.period == "1h" ||
-			flags.period == "2h" ||
-			flags.period == "4h" ||
-			flags.period == "6h" ||
-			flags.period == "8h" ||
-			flags.period == "12h" ||
-			flags.period == "d") {
-		return fmt.Errorf("invalid source for tiingo-crypto, must be '1m', '3m', '5m', '15m', '30m', '1h', '2h', '4h', '6h', '8h', '12h', '1d', '3d', '1w', or '1M'")
-	}
-
-	if flags.source == "tiingo-crypto" && flags.token == "" {
-		return fmt.Errorf("missing token for tiingo-crypto, must be passed or TIINGO_API_TOKEN must be set")
-	}
-
-	if flags.source == "bittrex" && !(flags.period == "1m" || flags.period == "5m" || flags.period == "30m" || flags.period == "1h" || flags.period == "d") {
-		return fmt.Errorf("invalid source for bittrex, must be '1m', '5m', '30m', '1h' or 'd'")
-	}
-
-	if flags.source == "binance" &&
-		!(flags.period == "1m" ||
-			flags.period == "3m" ||
-			flags.period == "5m" ||
-			flags.period == "15m" ||
-			flags.period == "30m" ||
-			flags.period == "1h" ||
-			flags.period == "2h" ||
-			flags.period == "4h" ||
-			flags.period == "6h" ||
-			flags.period == "8h" ||
-			flags.period == "12h" ||
-			flags.period == "d" ||
-			flags.period == "3d" ||
-			flags.period == "w" ||
-			flags.period == "m") {
-		return fmt.Errorf("invalid source for binance, must be '1m', '3m', '5m', '15m', '30m', '1h', '2h', '4h', '6h', '8h', '12h', '1d', '3d', '1w', or '1M'")
+	timer := time.NewTimer(quote.Delay * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-flags.context().Done():
+	case <-timer.C:
 	}
+}
+
+// notifyContext - context canceled on the first interrupt, replaced in tests
+var notifyContext = func() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
 
+// adjustFlag - value of -adjust: true, false or both
+type adjustFlag string
+
+func (a adjustFlag) String() string { return string(a) }
+
+func (a *adjustFlag) Set(s string) error {
+	if s == "both" {
+		*a = "both"
+		return nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("must be true, false or both")
+	}
+	*a = adjustFlag(strconv.FormatBool(b))
 	return nil
 }
 
+func (a *adjustFlag) IsBoolFlag() bool { return true }
+
+// adjusted - true if adjusted prices were requested, alone or with raw prices
+func (a adjustFlag) adjusted() bool { return a != "false" }
+
+// both - true if both raw and adjusted prices were requested
+func (a adjustFlag) both() bool { return a == "both" }
+
+// periodsUsage - the periods supported by each source, for the usage text
+func periodsUsage() string {
+	u := "Periods by source:\n"
+	for _, src := range quote.Sources() {
+		names := []string{}
+		for _, p := range quote.SupportedPeriods(src) {
+			names = append(names, p.Name())
+		}
+		u += fmt.Sprintf("  %-17s%s\n", src, strings.Join(names, ","))
+	}
+	return u
+}
+
+func check(e error) bool {
+	if e != nil {
+		fmt.Printf("error: %v\nrun 'quote -h' for usage\n", e)
+		return true
+	}
+	return false
+}
+
 func setOutput(flags quoteflags) error {
 	var err error
-	if flags.log == "stdout" {
+	if flags.log == "stdout" && flags.outfile == "-" {
+		// keep the log out of the quotes
+		quote.Log.SetOutput(os.Stderr)
+	} else if flags.log == "stdout" {
 		quote.Log.SetOutput(os.Stdout)
 	} else if flags.log == "stderr" {
 		quote.Log.SetOutput(os.Stderr)
@@ -180,7 +314,11 @@ func getSymbols(flags quoteflags, args []string) ([]string, error) {
 	var err error
 	var symbols []string
 
-	if flags.infile != "" {
+	if flags.source == "dir" {
+		for _, q := range flags.dir {
+			symbols = append(symbols, q.Symbol)
+		}
+	} else if flags.infile != "" {
 		symbols, err = quote.NewSymbolsFromFile(flags.infile)
 		if err != nil {
 			return symbols, err
@@ -195,56 +333,16 @@ func getSymbols(flags quoteflags, args []string) ([]string, error) {
 	}
 
 	// validate outfileFlag
-	if len(symbols) > 1 && flags.outfile != "" && !flags.all {
+	if len(symbols) > 1 && flags.outfile != "" && !flags.all && flags.partition == "" && flags.format != "sqlite" {
 		return symbols, fmt.Errorf("outfile not valid with multiple symbols\nuse -all=true")
 	}
+	if len(symbols) > 1 && flags.format == "xlsx" && !flags.all {
+		return symbols, fmt.Errorf("-format=xlsx not valid with multiple symbols\nuse -all=true")
+	}
 
 	return symbols, nil
 }
 
-func getPeriod(periodFlag string) quote.Period {
-	period := quote.Daily
-	switch periodFlag {
-	case "1m":
-		period = quote.Min1
-	case "3m":
-		period = quote.Min3
-	case "5m":
-		period = quote.Min5
-	case "15m":
-		period = quote.Min15
-	case "30m":
-		period = quote.Min30
-	case "1h":
-		period = quote.Min60
-	case "2h":
-		period = quote.Hour2
-	case "4h":
-		period = quote.Hour4
-	case "6h":
-		period = quote.Hour6
-	case "8h":
-		period = quote.Hour8
-	case "12h":
-		period = quote.Hour12
-	case "d":
-		period = quote.Daily
-	case "1d":
-		period = quote.Daily
-	case "3d":
-		period = quote.Day3
-	case "w":
-		period = quote.Weekly
-	case "1w":
-		period = quote.Weekly
-	case "m":
-		period = quote.Monthly
-	case "1M":
-		period = quote.Monthly
-	}
-	return period
-}
-
 func getTimes(flags quoteflags) (time.Time, time.Time) {
 	// determine start/end times
 	to := quote.ParseDateString(flags.end)
@@ -257,80 +355,703 @@ func getTimes(flags quoteflags) (time.Time, time.Time) {
 	return from, to
 }
 
-func outputAll(symbols []string, flags quoteflags) error {
-	// output all in one file
+// outputPath - place filename in the output directory, if any
+func outputPath(flags quoteflags, filename string) string {
+	if flags.outdir == "" {
+		return filename
+	}
+	return filepath.Join(flags.outdir, filename)
+}
+
+// defaultFilename - default output filename for a symbol (or all symbols) and format,
+// the one database of all symbols for sqlite
+func defaultFilename(sym string, flags quoteflags) string {
+	ext := ".csv"
+	if flags.format == "json" || flags.format == "jsonmap" || flags.format == "hs" || flags.format == "lwc" {
+		ext = ".json"
+	} else if flags.format == "go" {
+		ext = ".go"
+	} else if flags.format == "xlsx" {
+		ext = ".xlsx"
+	} else if flags.format == "ndjson" {
+		ext = ".ndjson"
+	} else if flags.format == "pb" {
+		ext = ".pb"
+	} else if flags.format == "msgpack" {
+		ext = ".msgpack"
+	} else if flags.format == "pgcopy" {
+		ext = ".sql"
+	} else if flags.format == "html" {
+		ext = ".html"
+	} else if flags.format == "parquet" {
+		ext = ".parquet"
+	} else if flags.format == "sqlite" {
+		ext = ".db"
+	}
+	if sym == "" || flags.format == "sqlite" {
+		return "quotes" + ext
+	}
+	// pairs such as BTC/USD or bitcoin:usd
+	return strings.NewReplacer("/", "-", ":", "-").Replace(sym) + ext
+}
+
+// adjustedFilename - filename of the adjusted output for -adjust=both, e.g. spy_adj.csv
+func adjustedFilename(filename string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "_adj" + ext
+}
+
+// fetchPair - raw and adjusted prices for a symbol from a single download
+func fetchPair(sym string, flags quoteflags) (quote.Quote, quote.Quote, error) {
 	from, to := getTimes(flags)
-	period := getPeriod(flags.period)
-	quotes := quote.Quotes{}
-	var err error
-	if flags.source == "yahoo" {
-		quotes, err = quote.NewQuotesFromYahooSyms(symbols, from.Format(dateFormat), to.Format(dateFormat), period, flags.adjust)
-	} else if flags.source == "tiingo" {
-		quotes, err = quote.NewQuotesFromTiingoSyms(symbols, from.Format(dateFormat), to.Format(dateFormat), flags.token)
-	} else if flags.source == "tiingo-crypto" {
-		quotes, err = quote.NewQuotesFromTiingoCryptoSyms(symbols, from.Format(dateFormat), to.Format(dateFormat), period, flags.token)
-	} else if flags.source == "coinbase" {
-		quotes, err = quote.NewQuotesFromCoinbaseSyms(symbols, from.Format(dateFormat), to.Format(dateFormat), period)
-	} else if flags.source == "bittrex" {
-		quotes, err = quote.NewQuotesFromBittrexSyms(symbols, period)
-	} else if flags.source == "binance" {
-		quotes, err = quote.NewQuotesFromBinanceSyms(symbols, from.Format(dateFormat), to.Format(dateFormat), period)
+	if flags.source == "iex" {
+		return quote.NewQuotePairFromIEXCtx(flags.context(), sym, from.Format(dateFormat), to.Format(dateFormat), getPeriod(flags.period), flags.token)
+	}
+	if flags.source == "tiingo" {
+		return quote.NewQuotePairFromTiingoCtx(flags.context(), sym, from.Format(dateFormat), to.Format(dateFormat), getPeriod(flags.period), flags.token)
 	}
+	opts := quote.DefaultYahooOptions
+	opts.PrePost = flags.prePost
+	return quote.NewQuotePairFromYahooWithOptionsCtx(flags.context(), sym, from.Format(dateFormat), to.Format(dateFormat), getPeriod(flags.period), opts)
+}
+
+// fetchAllPairs - raw and adjusted prices for all symbols, those so far and
+// the error of the context on interrupt
+func fetchAllPairs(symbols []string, flags quoteflags) (quote.Quotes, quote.Quotes, error) {
+	raw, adjusted := quote.Quotes{}, quote.Quotes{}
+	for _, sym := range symbols {
+		if err := flags.context().Err(); err != nil {
+			return raw, adjusted, err
+		}
+		start := time.Now()
+		r, a, err := fetchPair(sym, flags)
+		journalRecord(sym, flags, len(r.Close), start, err)
+		if err == nil {
+			raw = append(raw, r)
+			adjusted = append(adjusted, a)
+		}
+		pause(flags)
+	}
+	return raw, adjusted, nil
+}
+
+// goIdent - go identifier for a symbol, e.g. brkB for BRK.B
+func goIdent(sym string) string {
+	id := ""
+	upper := false
+	for _, r := range strings.ToLower(sym) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = id != ""
+			continue
+		}
+		if id == "" && unicode.IsDigit(r) {
+			id = "q"
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		id += string(r)
+	}
+	return id
+}
+
+// goPackage - package name for a generated go file, taken from the other go
+// files in its directory or else from the directory name
+func goPackage(filename string) string {
+	abs, err := filepath.Abs(filename)
 	if err != nil {
+		return "main"
+	}
+	dir := filepath.Dir(abs)
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	pkg := ""
+	for _, file := range files {
+		if file == abs {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly)
+		if err != nil {
+			continue
+		}
+		pkg = f.Name.Name
+		if !strings.HasSuffix(pkg, "_test") {
+			return pkg
+		}
+	}
+	if pkg == "" {
+		pkg = strings.ToLower(goIdent(filepath.Base(dir)))
+	}
+	if pkg == "" || token.Lookup(pkg).IsKeyword() {
+		pkg = "main"
+	}
+	return pkg
+}
+
+// writeGoCode - write a go fixture file, defaulting the file name like the other formats
+func writeGoCode(code func(pkg string) string, outfile, sym string, flags quoteflags) error {
+	if outfile == "" {
+		outfile = defaultFilename(sym, flags)
+	}
+	return ioutil.WriteFile(outfile, []byte(code(goPackage(outfile))), 0644)
+}
+
+// outputColumns - q as written, without the adjusted price columns unless
+// -adjclose was given or an existing file is repaired
+func outputColumns(q quote.Quote, flags quoteflags) quote.Quote {
+	if !flags.adjClose && !flags.repair {
+		q.AdjOpen, q.AdjHigh, q.AdjLow, q.AdjClose = nil, nil, nil, nil
+	}
+	return q
+}
+
+// customCSV - whether any of the csv output flags differs from its default
+func customCSV(flags quoteflags) bool {
+	return flags.csvLayout != "default" || flags.csvDelim != "," || flags.csvDate != "" || flags.noHeader || flags.csvOffset
+}
+
+// csvDelimiter - the separator of -csv-delim, which may be tab or \t
+func csvDelimiter(delim string) rune {
+	if delim == "tab" || delim == `\t` {
+		return '\t'
+	}
+	r, _ := utf8.DecodeRuneInString(delim)
+	return r
+}
+
+// csvOptions - csv output options of -csv-layout, -csv-delim,
+// -csv-dateformat, -no-header and -csv-offset
+func csvOptions(flags quoteflags) quote.CSVOptions {
+	opts := quote.DefaultCSVOptions
+	switch flags.csvLayout {
+	case "backtrader":
+		opts.Layout = quote.CSVLayoutBacktrader
+	case "zipline":
+		opts.Layout = quote.CSVLayoutZipline
+	}
+	opts.Delimiter = csvDelimiter(flags.csvDelim)
+	if flags.csvDate == "epoch" {
+		opts.Epoch = true
+	} else if flags.csvDate != "" {
+		opts.DateLayout = flags.csvDate
+		opts.Layout.DateLayout, opts.Layout.DateTimeLayout = "", ""
+	}
+	opts.NoHeader = flags.noHeader
+	opts.Offset = flags.csvOffset
+	return opts
+}
+
+// stdout - destination of -outfile=-, replaceable in tests
+var stdout io.Writer = os.Stdout
+
+// writeQuoteTo - stream q to w as csv, json or hs
+func writeQuoteTo(q quote.Quote, w io.Writer, flags quoteflags) error {
+	if flags.format == "csv" && customCSV(flags) {
+		csv, err := q.CSVWithOptions(csvOptions(flags))
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, csv)
 		return err
+	} else if flags.format == "csv" {
+		return q.WriteCSVTo(w)
+	} else if flags.format == "json" {
+		return q.WriteJSONTo(w, false)
 	}
+	return q.WriteHighstockTo(w)
+}
 
-	if flags.format == "csv" {
-		err = quotes.WriteCSV(flags.outfile)
+// writeQuotesTo - stream quotes to w as csv, json or hs
+func writeQuotesTo(quotes quote.Quotes, w io.Writer, flags quoteflags) error {
+	if flags.format == "csv" && customCSV(flags) {
+		csv, err := quotes.CSVWithOptions(csvOptions(flags))
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, csv)
+		return err
+	} else if flags.format == "csv" {
+		return quotes.WriteCSVTo(w)
 	} else if flags.format == "json" {
-		err = quotes.WriteJSON(flags.outfile, false)
+		return quotes.WriteJSONTo(w, false)
+	}
+	return quotes.WriteHighstockTo(w)
+}
+
+func writeQuote(q quote.Quote, outfile string, flags quoteflags) error {
+	var err error
+	q = outputColumns(q, flags)
+	if flags.last > 0 {
+		q = q.Last(flags.last)
+	}
+	if outfile == "-" {
+		return writeQuoteTo(q, stdout, flags)
+	}
+	if flags.format == "csv" && flags.append {
+		err = q.AppendCSV(outfile)
+	} else if flags.format == "csv" && customCSV(flags) {
+		err = q.WriteCSVWithOptions(outfile, csvOptions(flags))
+	} else if flags.format == "csv" {
+		err = q.WriteCSV(outfile)
+	} else if flags.format == "json" && flags.meta {
+		err = q.WriteMetaJSON(outfile, false)
+	} else if flags.format == "json" {
+		err = q.WriteJSON(outfile, false)
 	} else if flags.format == "hs" {
-		err = quotes.WriteHighstock(flags.outfile)
+		err = q.WriteHighstock(outfile)
+	} else if flags.format == "lwc" {
+		err = q.WriteLightweightCharts(outfile)
 	} else if flags.format == "ami" {
-		err = quotes.WriteAmibroker(flags.outfile)
+		err = q.WriteAmibroker(outfile)
+	} else if flags.format == "go" {
+		err = writeGoCode(func(pkg string) string { return q.GoCode(pkg, goIdent(q.Symbol)+"Quote") }, outfile, q.Symbol, flags)
+	} else if flags.format == "xlsx" {
+		err = q.WriteXLSX(outfile)
+	} else if flags.format == "ndjson" {
+		err = q.WriteNDJSON(outfile)
+	} else if flags.format == "pb" {
+		err = q.WritePB(outfile)
+	} else if flags.format == "msgpack" {
+		err = q.WriteMsgPack(outfile)
+	} else if flags.format == "html" {
+		err = q.WriteHTML(outfile)
+	} else if flags.format == "parquet" {
+		err = q.WriteParquet(outfile)
+	} else if flags.format == "sqlite" {
+		err = q.WriteSQLite(outfile)
 	}
 	return err
 }
 
-func outputIndividual(symbols []string, flags quoteflags) error {
-	// output individual symbol files
+func writeQuotes(quotes quote.Quotes, outfile string, flags quoteflags) error {
+	var err error
+	out := make(quote.Quotes, len(quotes))
+	for i, q := range quotes {
+		out[i] = outputColumns(q, flags)
+		if flags.last > 0 {
+			out[i] = out[i].Last(flags.last)
+		}
+	}
+	quotes = out
+	if outfile == "-" {
+		return writeQuotesTo(quotes, stdout, flags)
+	}
+	if flags.format == "csv" && flags.append {
+		err = quotes.AppendCSV(outfile)
+	} else if flags.format == "csv" && customCSV(flags) {
+		err = quotes.WriteCSVWithOptions(outfile, csvOptions(flags))
+	} else if flags.format == "csv" {
+		err = quotes.WriteCSV(outfile)
+	} else if flags.format == "json" && flags.meta {
+		err = quotes.WriteMetaJSON(outfile, false)
+	} else if flags.format == "json" {
+		err = quotes.WriteJSON(outfile, false)
+	} else if flags.format == "jsonmap" {
+		err = quotes.WriteJSONMap(outfile, false)
+	} else if flags.format == "hs" {
+		err = quotes.WriteHighstock(outfile)
+	} else if flags.format == "lwc" {
+		err = quotes.WriteLightweightCharts(outfile)
+	} else if flags.format == "ami" {
+		err = quotes.WriteAmibroker(outfile)
+	} else if flags.format == "go" {
+		err = writeGoCode(func(pkg string) string { return quotes.GoCode(pkg, "quotes") }, outfile, "", flags)
+	} else if flags.format == "xlsx" {
+		err = quotes.WriteXLSX(outfile)
+	} else if flags.format == "ndjson" {
+		err = quotes.WriteNDJSON(outfile)
+	} else if flags.format == "pb" {
+		err = quotes.WritePB(outfile)
+	} else if flags.format == "msgpack" {
+		err = quotes.WriteMsgPack(outfile)
+	} else if flags.format == "pgcopy" {
+		err = quotes.WritePgCopy(outfile, flags.table)
+	} else if flags.format == "html" {
+		err = quotes.WriteHTML(outfile)
+	} else if flags.format == "parquet" {
+		err = quotes.WriteParquet(outfile)
+	} else if flags.format == "sqlite" {
+		err = quotes.WriteSQLite(outfile)
+	}
+	return err
+}
 
+// fetchSymbol - download a single symbol from the source in flags
+var fetchSymbol = func(sym string, flags quoteflags) (quote.Quote, error) {
+	if flags.source == "dir" {
+		return dirQuote(sym, flags)
+	}
 	from, to := getTimes(flags)
 	period := getPeriod(flags.period)
+	spec := quote.SourceSpec{Name: flags.source, Token: flags.token, Adjust: flags.adjust.adjusted(), PrePost: flags.prePost}
+	if flags.bars > 0 {
+		return quote.NewQuoteLastN(flags.context(), spec, sym, flags.bars, period)
+	}
+	return quote.NewQuoteFromSource(flags.context(), spec, sym, from, to, period)
+}
+
+// loadDir - the quotes of the -infile directory, reporting files that could
+// not be read
+func loadDir(flags quoteflags) (quote.Quotes, error) {
+	quotes, err := quote.NewQuotesFromDir(flags.infile, "")
+	if failed, ok := err.(quote.DirError); ok {
+		for _, f := range failed {
+			fmt.Printf("Error reading file: %v\n", f)
+		}
+		err = nil
+	}
+	return quotes, err
+}
+
+// dirQuote - the quote of sym read with -source=dir
+func dirQuote(sym string, flags quoteflags) (quote.Quote, error) {
+	for _, q := range flags.dir {
+		if q.Symbol == sym {
+			return q, nil
+		}
+	}
+	return quote.NewQuote("", 0), fmt.Errorf("no file for %s in %s", sym, flags.infile)
+}
 
+// journalRecord - record a download in the -journal, if one is open
+func journalRecord(sym string, flags quoteflags, bars int, start time.Time, err error) {
+	if flags.journal == nil {
+		return
+	}
+	from, to := getTimes(flags)
+	e := quote.JournalEntry{
+		Symbol:   sym,
+		Source:   flags.source,
+		Period:   getPeriod(flags.period),
+		From:     from,
+		To:       to,
+		Bars:     bars,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if err := flags.journal.Record(e); err != nil {
+		fmt.Printf("Error writing journal: %v\n", err)
+	}
+}
+
+// fetchJournaled - fetchSymbol, recorded in the -journal
+func fetchJournaled(sym string, flags quoteflags) (quote.Quote, error) {
+	start := time.Now()
+	q, err := fetchSymbol(sym, flags)
+	journalRecord(sym, flags, len(q.Close), start, err)
+	return q, err
+}
+
+// fetchAll - fetchJournaled for all symbols, those so far and the error of
+// the context on interrupt
+func fetchAll(symbols []string, flags quoteflags) (quote.Quotes, error) {
+	quotes := quote.Quotes{}
 	for _, sym := range symbols {
-		var q quote.Quote
-		if flags.source == "yahoo" {
-			q, _ = quote.NewQuoteFromYahoo(sym, from.Format(dateFormat), to.Format(dateFormat), period, flags.adjust)
-		} else if flags.source == "tiingo" {
-			q, _ = quote.NewQuoteFromTiingo(sym, from.Format(dateFormat), to.Format(dateFormat), flags.token)
-		} else if flags.source == "tiingo-crypto" {
-			q, _ = quote.NewQuoteFromTiingoCrypto(sym, from.Format(dateFormat), to.Format(dateFormat), period, flags.token)
-		} else if flags.source == "coinbase" {
-			q, _ = quote.NewQuoteFromCoinbase(sym, from.Format(dateFormat), to.Format(dateFormat), period)
-		} else if flags.source == "bittrex" {
-			q, _ = quote.NewQuoteFromBittrex(sym, period)
-		} else if flags.source == "binance" {
-			q, _ = quote.NewQuoteFromBinance(sym, from.Format(dateFormat), to.Format(dateFormat), period)
+		if err := flags.context().Err(); err != nil {
+			return quotes, err
+		}
+		q, err := fetchJournaled(sym, flags)
+		if err == nil {
+			quotes = append(quotes, q)
+		} else {
+			quote.Log.Println("error downloading " + sym)
+		}
+		pause(flags)
+	}
+	return quotes, nil
+}
+
+// calendarFor - trading calendar used for gap detection for a source
+func calendarFor(source string) quote.Calendar {
+	return quote.SourceSpec{Name: source}.Calendar()
+}
+
+// summary - quality report and statistics accumulated over downloads
+type summary struct {
+	report    quote.QualityReport
+	anomalies []quote.Anomalies
+	stats     quote.StatsTable
+	benchmark *quote.Quote // -stats beta benchmark, nil if none
+}
+
+// add - add quality and statistics of quotes when requested
+func (sum *summary) add(quotes quote.Quotes, flags quoteflags) {
+	if flags.report {
+		cal := calendarFor(flags.source)
+		sum.report = sum.report.Append(quotes.QualityReport(getPeriod(flags.period), &cal))
+		opts := quote.DefaultAnomalyOptions
+		opts.JumpThreshold = flags.maxJump / 100
+		opts.Calendar = &cal
+		sum.anomalies = append(sum.anomalies, quotes.AnomaliesWithOptions(getPeriod(flags.period), opts)...)
+	}
+	if flags.stats {
+		for _, q := range quotes {
+			s := q.Stats(0)
+			if sum.benchmark != nil {
+				s.Benchmark = sum.benchmark.Symbol
+				_, beta, err := quote.Beta(q, *sum.benchmark, 0)
+				s.Beta = math.NaN()
+				if err == nil {
+					s.Beta = beta[0]
+				}
+			}
+			sum.stats = append(sum.stats, s)
+		}
+	}
+}
+
+// writeStats - print the statistics table to stderr, or as csv to -stats-out
+func writeStats(stats quote.StatsTable, flags quoteflags, stderr io.Writer) error {
+	if flags.statsOut != "" {
+		return ioutil.WriteFile(flags.statsOut, []byte(stats.CSV()), 0644)
+	}
+	_, err := fmt.Fprint(stderr, stats.String())
+	return err
+}
+
+func outputAll(symbols []string, flags quoteflags, sum *summary) error {
+	// output all in one file
+	var quotes, adjusted quote.Quotes
+	var err error
+	if flags.adjust.both() {
+		quotes, adjusted, err = fetchAllPairs(symbols, flags)
+	} else {
+		quotes, err = fetchAll(symbols, flags)
+	}
+	if err != nil {
+		return err
+	}
+	sum.add(quotes, flags)
+
+	if flags.outdir != "" {
+		err = os.MkdirAll(flags.outdir, 0755)
+		if err != nil {
+			return err
+		}
+		if flags.outfile == "" {
+			flags.outfile = defaultFilename("", flags)
+		}
+		flags.outfile = outputPath(flags, flags.outfile)
+	}
+
+	if flags.adjust.both() {
+		if flags.outfile == "" {
+			flags.outfile = defaultFilename("", flags)
+		}
+		err = writeQuotes(adjusted, adjustedFilename(flags.outfile), flags)
+		if err != nil {
+			return err
+		}
+	}
+	return writeQuotes(quotes, flags.outfile, flags)
+}
+
+func outputPartitioned(symbols []string, flags quoteflags, sum *summary) error {
+	// output hive-style partitioned directory tree
+	quotes, err := fetchAll(symbols, flags)
+	if err != nil {
+		return err
+	}
+	sum.add(quotes, flags)
+	for i, q := range quotes {
+		quotes[i] = outputColumns(q, flags)
+	}
+	return quotes.WritePartitioned(flags.outdir, flags.format, strings.Split(flags.partition, ","))
+}
+
+func outputIndividual(symbols []string, flags quoteflags, sum *summary, stderr io.Writer) error {
+	// output individual symbol files, skipping the symbols that fail to
+	// download
+
+	if flags.outdir != "" {
+		err := os.MkdirAll(flags.outdir, 0755)
+		if err != nil {
+			return err
 		}
+	}
+	failed := 0
+	for _, sym := range symbols {
+		if err := flags.context().Err(); err != nil {
+			return err
+		}
+		var q, adjusted quote.Quote
 		var err error
-		if flags.format == "csv" {
-			err = q.WriteCSV(flags.outfile)
-		} else if flags.format == "json" {
-			err = q.WriteJSON(flags.outfile, false)
-		} else if flags.format == "hs" {
-			err = q.WriteHighstock(flags.outfile)
-		} else if flags.format == "ami" {
-			err = q.WriteAmibroker(flags.outfile)
+		if flags.adjust.both() {
+			start := time.Now()
+			q, adjusted, err = fetchPair(sym, flags)
+			journalRecord(sym, flags, len(q.Close), start, err)
+		} else {
+			q, err = fetchJournaled(sym, flags)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "Error downloading %s: %v\n", sym, err)
+			failed++
+			pause(flags)
+			continue
+		}
+		sum.add(quote.Quotes{q}, flags)
+		outfile := flags.outfile
+		if flags.outdir != "" {
+			if outfile == "" {
+				outfile = defaultFilename(sym, flags)
+			}
+			outfile = outputPath(flags, outfile)
+		}
+		err = writeQuote(q, outfile, flags)
+		if err == nil && flags.adjust.both() {
+			if outfile == "" {
+				outfile = defaultFilename(sym, flags)
+			}
+			err = writeQuote(adjusted, adjustedFilename(outfile), flags)
 		}
 		if err != nil {
 			fmt.Printf("Error writing file: %v\n", err)
 		}
-		time.Sleep(quote.Delay * time.Millisecond)
+		pause(flags)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d symbols failed to download", failed, len(symbols))
 	}
 	return nil
 }
 
+// outputValidate - print the Validate findings of each csv or json quote
+// file, false if any file has findings or can not be read
+func outputValidate(files []string) bool {
+	pass := true
+	for _, file := range files {
+		q, err := quote.NewQuoteFromFile(file)
+		if err != nil {
+			fmt.Printf("%s: %v\n", file, err)
+			pass = false
+			continue
+		}
+		findings := q.Validate()
+		for _, f := range findings {
+			fmt.Printf("%s: %v\n", file, f)
+		}
+		if len(findings) > 0 {
+			pass = false
+			continue
+		}
+		fmt.Printf("%s: %d bars ok\n", file, len(q.Date))
+	}
+	return pass
+}
+
+// outputDiff - print the values and bars of files a and b that differ by
+// more than -tolerance, as json with -format=json, true if there are none
+func outputDiff(a, b string, flags quoteflags) (bool, error) {
+	qa, err := quote.NewQuoteFromFile(a)
+	if err != nil {
+		return false, err
+	}
+	qb, err := quote.NewQuoteFromFile(b)
+	if err != nil {
+		return false, err
+	}
+	diffs := quote.DiffQuotes(qa, qb, flags.tolerance)
+
+	if flags.format == "json" {
+		if diffs == nil {
+			diffs = []quote.BarDiff{}
+		}
+		data, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		fmt.Println(string(data))
+		return len(diffs) == 0, nil
+	}
+	values, onlyA, onlyB := 0, 0, 0
+	for _, d := range diffs {
+		switch d.Only {
+		case "a":
+			onlyA++
+		case "b":
+			onlyB++
+		default:
+			values++
+		}
+	}
+	fmt.Printf("a=%s b=%s: %d values differ, %d bars only in a, %d bars only in b\n", a, b, values, onlyA, onlyB)
+	for _, d := range diffs {
+		fmt.Println(d)
+	}
+	return len(diffs) == 0, nil
+}
+
+// backfill - fill gaps of a quote from a source, replaceable in tests
+var backfill = quote.BackfillGaps
+
+func outputRepair(symbols []string, flags quoteflags) error {
+	// backfill gaps in existing individual symbol files, in place
+	period := getPeriod(flags.period)
+	spec := quote.SourceSpec{Name: flags.source, Token: flags.token, Adjust: flags.adjust.adjusted(), PrePost: flags.prePost}
+	cal := calendarFor(flags.source)
+	for _, sym := range symbols {
+		infile := flags.outfile
+		if infile == "" {
+			infile = outputPath(flags, defaultFilename(sym, flags))
+		}
+		var q quote.Quote
+		var err error
+		if flags.format == "json" {
+			q, err = quote.NewQuoteFromJSONFile(infile)
+		} else {
+			q, err = quote.NewQuoteFromCSVFile(sym, infile)
+		}
+		if err != nil {
+			fmt.Printf("Error reading file: %v\n", err)
+			continue
+		}
+		repaired, added, err := backfill(flags.context(), q, spec, period)
+		if err != nil {
+			fmt.Printf("Error repairing %s: %v\n", sym, err)
+		}
+		if added > 0 {
+			err = writeQuote(repaired, infile, flags)
+			if err != nil {
+				fmt.Printf("Error writing file: %v\n", err)
+			}
+		}
+		missing := quote.Quotes{repaired}.QualityReport(period, &cal).Symbols[0].Missing
+		fmt.Printf("%s: %d bars added, %d still missing\n", sym, added, missing)
+		pause(flags)
+	}
+	return nil
+}
+
+func outputCompare(symbols []string, flags quoteflags) (bool, error) {
+	// compare two sources, returns false if any symbol exceeds the tolerance
+	from, to := getTimes(flags)
+	period := getPeriod(flags.period)
+	sources := strings.Split(flags.compare, ",")
+	a := quote.SourceSpec{Name: sources[0], Token: flags.token, Adjust: flags.adjust.adjusted() && !flags.closeOnly}
+	b := quote.SourceSpec{Name: sources[1], Token: flags.token, Adjust: flags.adjust.adjusted() && !flags.closeOnly}
+
+	pass := true
+	for _, sym := range symbols {
+		r, err := quote.CompareSources(flags.context(), sym, from, to, period, a, b)
+		if err != nil {
+			return false, err
+		}
+		fmt.Print(r.String())
+		dev := r.MaxDeviation()
+		if flags.closeOnly {
+			dev = r.MaxDeviation("close")
+		}
+		if dev > flags.tolerance {
+			fmt.Printf("%s: deviation %.4f%% exceeds tolerance %.4f%%\n", sym, 100*dev, 100*flags.tolerance)
+			pass = false
+		}
+		fmt.Println()
+	}
+	return pass, nil
+}
+
 func handleCommand(cmd string, flags quoteflags) bool {
 
 	// handle market special commands
@@ -347,53 +1068,233 @@ func handleCommand(cmd string, flags quoteflags) bool {
 }
 
 func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run - run the cli with args, returning the exit code
+func run(args []string, stderr io.Writer) int {
 
 	var err error
 	var symbols []string
 	var flags quoteflags
 
-	flag.IntVar(&flags.years, "years", 5, "number of years to download")
-	flag.IntVar(&flags.delay, "delay", 100, "milliseconds to delay between requests")
-	flag.StringVar(&flags.start, "start", "", "start date (yyyy[-mm[-dd]])")
-	flag.StringVar(&flags.end, "end", "", "end date (yyyy[-mm[-dd]])")
-	flag.StringVar(&flags.period, "period", "d", "1m|5m|15m|30m|1h|d")
-	flag.StringVar(&flags.source, "source", "yahoo", "yahoo|tiingo|coinbase|bittrex|binance")
-	flag.StringVar(&flags.token, "token", os.Getenv("TIINGO_API_TOKEN"), "tiingo api token")
-	flag.StringVar(&flags.infile, "infile", "", "input filename")
-	flag.StringVar(&flags.outfile, "outfile", "", "output filename")
-	flag.StringVar(&flags.format, "format", "csv", "csv|json")
-	flag.StringVar(&flags.log, "log", "stdout", "<filename>|stdout")
-	flag.BoolVar(&flags.all, "all", false, "all output in one file")
-	flag.BoolVar(&flags.adjust, "adjust", true, "adjust Yahoo prices")
-	flag.BoolVar(&flags.version, "v", false, "show version")
-	flag.BoolVar(&flags.version, "version", false, "show version")
-	flag.Parse()
+	fs := flag.NewFlagSet("quote", flag.ContinueOnError)
+	fs.IntVar(&flags.years, "years", 5, "number of years to download")
+	fs.IntVar(&flags.delay, "delay", 100, "milliseconds to delay between requests")
+	fs.StringVar(&flags.rate, "rate", "", "source:rps[:burst] request rate limits")
+	fs.StringVar(&flags.start, "start", "", "start date (yyyy[-mm[-dd]])")
+	fs.StringVar(&flags.end, "end", "", "end date (yyyy[-mm[-dd]])")
+	fs.StringVar(&flags.period, "period", "d", periodValues)
+	fs.StringVar(&flags.source, "source", "yahoo", strings.Join(quote.Sources(), "|"))
+	fs.StringVar(&flags.token, "token", os.Getenv("TIINGO_API_TOKEN"), "api token of tiingo, alphavantage, polygon, iex, finnhub, cryptocompare or fred, keyid:secret for alpaca")
+	fs.StringVar(&flags.infile, "infile", "", "input filename")
+	fs.StringVar(&flags.outfile, "outfile", "", "output filename")
+	fs.StringVar(&flags.outdir, "outdir", "", "output directory")
+	fs.StringVar(&flags.partition, "partition", "", "partition keys (symbol,year,month,date)")
+	fs.StringVar(&flags.format, "format", "csv", strings.Join(formats, "|"))
+	fs.StringVar(&flags.csvLayout, "csv-layout", "default", strings.Join(csvLayouts, "|"))
+	fs.StringVar(&flags.csvDelim, "csv-delim", ",", "csv field separator")
+	fs.StringVar(&flags.csvDate, "csv-dateformat", "", "go time layout of csv dates or epoch")
+	fs.BoolVar(&flags.noHeader, "no-header", false, "csv without a header line")
+	fs.BoolVar(&flags.csvOffset, "csv-offset", false, "end csv dates in their utc offset")
+	fs.StringVar(&flags.tz, "tz", "", "time zone of intraday dates")
+	fs.StringVar(&flags.precision, "precision", "", "decimals of written prices (n|full)")
+	fs.StringVar(&flags.log, "log", "stdout", "<filename>|stdout")
+	fs.BoolVar(&flags.all, "all", false, "all output in one file")
+	fs.BoolVar(&flags.append, "append", false, "append new bars to existing csv files")
+	fs.BoolVar(&flags.meta, "meta", false, "wrap json output in a metadata envelope")
+	fs.StringVar(&flags.table, "table", "quotes", "table of -format=pgcopy output")
+	flags.adjust = "true"
+	fs.Var(&flags.adjust, "adjust", "adjust Yahoo or IEX prices (true|false|both)")
+	fs.StringVar(&flags.compare, "compare", "", "compare two sources (a,b)")
+	fs.Float64Var(&flags.tolerance, "tolerance", 0.01, "max relative deviation for -compare")
+	fs.BoolVar(&flags.closeOnly, "close-only", false, "compare raw closes only")
+	fs.BoolVar(&flags.report, "report", false, "print data quality report")
+	fs.Float64Var(&flags.minScore, "min-score", 0, "minimum data quality score")
+	fs.Float64Var(&flags.maxJump, "max-jump", 50, "percent move reported as a jump")
+	fs.BoolVar(&flags.stats, "stats", false, "print summary statistics")
+	fs.StringVar(&flags.statsOut, "stats-out", "", "write summary statistics csv to file")
+	fs.StringVar(&flags.benchmark, "benchmark", "spy", "beta benchmark symbol for -stats")
+	fs.BoolVar(&flags.repair, "repair", false, "backfill gaps in existing output files")
+	fs.BoolVar(&flags.validate, "validate", false, "check the csv or json files given for bad prices and dates")
+	fs.IntVar(&flags.last, "last", 0, "keep only the last n bars of each symbol")
+	fs.StringVar(&flags.journalTo, "journal", "", "append a jsonl record of each download to file")
+	fs.IntVar(&flags.bars, "bars", 0, "download the last n bars instead of a date range")
+	fs.BoolVar(&flags.prePost, "prepost", false, "include yahoo pre-market and after hours intraday bars")
+	fs.BoolVar(&flags.adjClose, "adjclose", false, "write the provider's adjusted price columns")
+	fs.BoolVar(&flags.version, "v", false, "show version")
+	fs.BoolVar(&flags.version, "version", false, "show version")
+	fs.SetOutput(ioutil.Discard)
+	fs.Usage = func() {}
+	if err = fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			fmt.Print(usage)
+			return 0
+		}
+		check(err)
+		return 2
+	}
+	tokenSet := false
+	fs.Visit(func(f *flag.Flag) {
+		flags.yearsSet = flags.yearsSet || f.Name == "years"
+		tokenSet = tokenSet || f.Name == "token"
+	})
+	if !tokenSet {
+		flags.token = envToken(flags.source, flags.token)
+	}
 
 	if flags.version {
 		fmt.Println(version)
-		os.Exit(0)
+		return 0
 	}
 
 	quote.Delay = time.Duration(flags.delay)
 
+	err = checkFlags(flags)
+	if check(err) {
+		return 2
+	}
+	rates, _ := parseRates(flags.rate)
+	for _, limit := range rates {
+		quote.SetRateLimit(limit.source, limit.rps, limit.burst)
+	}
+	quote.DefaultPrecision, _ = parsePrecision(flags.precision)
+	quote.IntradayLocation = nil
+	if flags.tz != "" {
+		quote.IntradayLocation, _ = time.LoadLocation(flags.tz)
+	}
+
 	err = setOutput(flags)
-	check(err)
+	if check(err) {
+		return 0
+	}
 
-	err = checkFlags(flags)
-	check(err)
+	if flags.validate {
+		if fs.NArg() == 0 {
+			check(fmt.Errorf("-validate requires the files to check"))
+			return 2
+		}
+		if !outputValidate(fs.Args()) {
+			return 1
+		}
+		return 0
+	}
+
+	if fs.NArg() > 0 && fs.Arg(0) == "diff" {
+		if fs.NArg() != 3 {
+			check(fmt.Errorf("diff requires the two files to compare"))
+			return 2
+		}
+		same, err := outputDiff(fs.Arg(1), fs.Arg(2), flags)
+		if check(err) {
+			return 2
+		}
+		if !same {
+			return 1
+		}
+		return 0
+	}
+
+	if flags.journalTo != "" {
+		flags.journal, err = quote.OpenJournal(flags.journalTo)
+		if check(err) {
+			return 0
+		}
+		defer flags.journal.Close()
+	}
 
-	symbols, err = getSymbols(flags, flag.Args())
-	check(err)
+	if flags.source == "dir" {
+		flags.dir, err = loadDir(flags)
+		if check(err) {
+			return 0
+		}
+	}
+
+	symbols, err = getSymbols(flags, fs.Args())
+	if check(err) {
+		return 0
+	}
 
 	// check for and handled special commands
-	if handleCommand(symbols[0], flags) {
-		os.Exit(0)
+	if flags.source != "dir" && handleCommand(symbols[0], flags) {
+		return 0
+	}
+
+	// an interrupt stops the downloads at the next request, a second one
+	// exits at once
+	ctx, stop := notifyContext()
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	flags.ctx = ctx
+
+	if flags.compare != "" {
+		pass, err := outputCompare(symbols, flags)
+		if check(err) {
+			return 0
+		}
+		if !pass {
+			return 1
+		}
+		return 0
+	}
+
+	if flags.repair {
+		err = outputRepair(symbols, flags)
+		check(err)
+		return 0
+	}
+
+	flags.stats = flags.stats || flags.statsOut != ""
+	var sum summary
+	if flags.stats && flags.benchmark != "" {
+		bench, err := fetchSymbol(flags.benchmark, flags)
+		if err == nil && len(bench.Close) > 0 {
+			sum.benchmark = &bench
+		} else {
+			quote.Log.Printf("benchmark %s unavailable, no beta\n", flags.benchmark)
+		}
 	}
 
 	// main output
-	if flags.all {
-		err = outputAll(symbols, flags)
+	if flags.partition != "" {
+		err = outputPartitioned(symbols, flags, &sum)
+	} else if flags.all {
+		err = outputAll(symbols, flags, &sum)
 	} else {
-		err = outputIndividual(symbols, flags)
+		err = outputIndividual(symbols, flags, &sum, stderr)
+	}
+	if flags.context().Err() != nil {
+		fmt.Fprintln(stderr, "interrupted")
+		return 130
+	}
+	code := 0
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		code = 1
+	}
+
+	if flags.stats {
+		err = writeStats(sum.stats, flags, stderr)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error writing stats: %v\n", err)
+		}
+	}
+
+	if flags.report {
+		fmt.Print(sum.report.String())
+		for _, a := range sum.anomalies {
+			fmt.Print(a.String())
+		}
+		if sum.report.TotalScore < flags.minScore {
+			fmt.Printf("quality score %.1f below minimum %.1f\n", sum.report.TotalScore, flags.minScore)
+			return 1
+		}
+		if len(sum.anomalies) > 0 {
+			return 1
+		}
 	}
+	return code
 }
@@ -0,0 +1,878 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markcheno/go-quote"
+)
+
+// fakeSource - replace downloads with synthetic daily quotes, spy rising 1% a
+// day and other symbols twice as fast, returning a restore func
+func fakeSource() func() {
+	saved := fetchSymbol
+	fetchSymbol = func(sym string, flags quoteflags) (quote.Quote, error) {
+		q := quote.NewQuote(sym, 0)
+		c := 100.0
+		rate := 0.01
+		if sym != "spy" {
+			rate = 0.02
+		}
+		for bar := 0; bar < 10; bar++ {
+			if bar%3 == 2 {
+				c *= 1 - rate
+			} else {
+				c *= 1 + rate
+			}
+			q.Date = append(q.Date, time.Date(2023, 1, 2+bar, 0, 0, 0, 0, time.UTC))
+			q.Open = append(q.Open, c)
+			q.High = append(q.High, c)
+			q.Low = append(q.Low, c)
+			q.Close = append(q.Close, c)
+			q.Volume = append(q.Volume, 1000)
+		}
+		return q, nil
+	}
+	return func() { fetchSymbol = saved }
+}
+
+func TestRunStats(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-stats", "-outdir=" + dir, "aapl", "msft"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected table:\n%s", stderr.String())
+	}
+	for _, col := range []string{"symbol", "bars", "first", "last", "close", "cagr", "maxdd", "vol", "avgvolume", "beta"} {
+		if !strings.Contains(lines[0], col) {
+			t.Errorf("missing column %s in %q", col, lines[0])
+		}
+	}
+	fields := strings.Fields(lines[1])
+	if fields[0] != "aapl" || fields[1] != "10" || fields[len(fields)-1] != "2.00" || fields[len(fields)-2] != "1000" {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "msft") {
+		t.Errorf("unexpected row %q", lines[2])
+	}
+	if _, err := os.Stat(filepath.Join(dir, "aapl.csv")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunStatsFailedDownload(t *testing.T) {
+	defer fakeSource()()
+	fake := fetchSymbol
+	fetchSymbol = func(sym string, flags quoteflags) (quote.Quote, error) {
+		if sym == "bad" {
+			return quote.NewQuote("", 0), errors.New("not found")
+		}
+		return fake(sym, flags)
+	}
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-stats", "-benchmark=", "-outdir=" + dir, "aapl", "bad"}, &stderr)
+	if code != 1 {
+		t.Fatalf("exit code %d", code)
+	}
+	out := stderr.String()
+	if !strings.Contains(out, "Error downloading bad: not found") || !strings.Contains(out, "1 of 2 symbols failed") {
+		t.Errorf("unexpected stderr:\n%s", out)
+	}
+	if strings.Contains(out, "\nbad ") {
+		t.Errorf("failed download in the table:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bad.csv")); !os.IsNotExist(err) {
+		t.Errorf("file written for a failed download: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "aapl.csv")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunStatsOut(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	out := filepath.Join(dir, "stats.csv")
+	code := run([]string{"-delay=0", "-log=discard", "-all", "-benchmark=", "-stats-out=" + out, "-outdir=" + dir, "aapl", "msft"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("unexpected stderr %q", stderr.String())
+	}
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "symbol,bars,first,last,last_close") || strings.Contains(lines[0], "beta") {
+		t.Fatalf("unexpected csv:\n%s", data)
+	}
+	if !strings.HasPrefix(lines[1], "aapl,10,2023-01-02 00:00,2023-01-11 00:00,") {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+}
+
+func TestRunMeta(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-format=json", "-meta", "-outdir=" + dir, "aapl"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "aapl.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), `{"meta":{"symbol":"aapl"`) {
+		t.Errorf("missing metadata envelope %.60s", data)
+	}
+	q, err := quote.NewQuoteFromJSON(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Close) != 10 || q.Meta == nil || q.Meta.Generator != "go-quote/"+quote.Version {
+		t.Errorf("unexpected quote %d bars, meta %+v", len(q.Close), q.Meta)
+	}
+
+	f := validFlags()
+	f.meta = true
+	if checkFlags(f) == nil {
+		t.Error("-meta accepted with csv format")
+	}
+}
+
+func TestRunDir(t *testing.T) {
+	restore := fakeSource()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-outdir=" + in, "aapl", "msft"}, &stderr)
+	restore()
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if err := ioutil.WriteFile(filepath.Join(in, "bad.csv"), []byte("date,open,high,low,close,volume\nnot a date,1,2,3,4,5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	code = run([]string{"-delay=0", "-log=discard", "-source=dir", "-infile=" + in, "-format=json", "-outdir=" + out}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	for _, sym := range []string{"aapl", "msft"} {
+		q, err := quote.NewQuoteFromJSONFile(filepath.Join(out, sym+".json"))
+		if err != nil {
+			t.Errorf("%s: %v", sym, err)
+			continue
+		}
+		if q.Symbol != sym || len(q.Close) != 10 {
+			t.Errorf("%s: unexpected quote %s with %d bars", sym, q.Symbol, len(q.Close))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "bad.json")); err == nil {
+		t.Error("unreadable file converted")
+	}
+}
+
+func TestRunXLSX(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-format=xlsx", "-all", "-outdir=" + dir, "aapl", "msft"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "quotes.xlsx")); err != nil {
+		t.Error(err)
+	}
+
+	// one workbook per symbol is refused
+	run([]string{"-delay=0", "-log=discard", "-format=xlsx", "-outdir=" + dir, "spy", "qqq"}, &stderr)
+	if _, err := os.Stat(filepath.Join(dir, "spy.xlsx")); err == nil {
+		t.Error("xlsx written for multiple symbols without -all")
+	}
+}
+
+func TestRunNDJSON(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-format=ndjson", "-all", "-outdir=" + dir, "aapl", "msft"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	f, err := os.Open(filepath.Join(dir, "quotes.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	quotes, err := quote.NewQuotesFromNDJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 2 || quotes[0].Symbol != "aapl" || quotes[1].Symbol != "msft" {
+		t.Errorf("unexpected quotes %v", quotes)
+	}
+}
+
+func TestRunPB(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-format=pb", "-outdir=" + dir, "aapl", "msft"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	for _, sym := range []string{"aapl", "msft"} {
+		q, err := quote.NewQuoteFromPBFile(filepath.Join(dir, sym+".pb"))
+		if err != nil {
+			t.Error(err)
+		} else if q.Symbol != sym || len(q.Close) == 0 {
+			t.Errorf("%s: unexpected quote %s with %d bars", sym, q.Symbol, len(q.Close))
+		}
+	}
+}
+
+func TestRunCSVLayout(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-csv-layout=zipline", "-outdir=" + dir, "aapl"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	csv, err := ioutil.ReadFile(filepath.Join(dir, "aapl.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(csv), "date,open,high,low,close,volume\n2023-01-02,102.00,") {
+		t.Errorf("unexpected csv:\n%s", csv)
+	}
+}
+
+func TestRunCSVOptions(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	outfile := filepath.Join(dir, "quotes.csv")
+	code := run([]string{"-delay=0", "-log=discard", "-all", "-csv-delim=;", "-csv-dateformat=epoch", "-no-header", "-outfile=" + outfile, "aapl", "msft"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	csv, err := ioutil.ReadFile(outfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(csv), "aapl;1672617600;102.00;") {
+		t.Errorf("unexpected csv:\n%s", csv)
+	}
+	opts := quote.CSVOptions{Delimiter: ';', Epoch: true, NoHeader: true}
+	quotes, err := quote.NewQuotesFromCSVWithOptions(string(csv), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 2 || quotes[1].Symbol != "msft" {
+		t.Errorf("unexpected quotes read back: %v", quotes)
+	}
+}
+
+func TestRunTZ(t *testing.T) {
+	defer fakeSource()()
+	defer func() { quote.IntradayLocation = nil }()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	outfile := filepath.Join(dir, "aapl.csv")
+	code := run([]string{"-delay=0", "-log=discard", "-tz=America/New_York", "-csv-offset", "-outfile=" + outfile, "aapl"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if quote.IntradayLocation == nil || quote.IntradayLocation.String() != "America/New_York" {
+		t.Errorf("unexpected intraday location %v", quote.IntradayLocation)
+	}
+	csv, err := ioutil.ReadFile(outfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(csv), "datetime,open,high,low,close,volume\n2023-01-02 00:00+00:00,") {
+		t.Errorf("unexpected csv:\n%s", csv)
+	}
+}
+
+func TestRunPrecision(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-precision=full", "-outdir=" + dir, "aapl"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	csv, err := ioutil.ReadFile(filepath.Join(dir, "aapl.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(csv), "\n2023-01-02 00:00,102,") {
+		t.Errorf("unexpected csv:\n%s", csv)
+	}
+}
+
+func TestRunAppend(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	outfile := filepath.Join(dir, "quotes.csv")
+	var csv []byte
+	for i := 0; i < 2; i++ {
+		var stderr bytes.Buffer
+		code := run([]string{"-delay=0", "-log=discard", "-all", "-append", "-outfile=" + outfile, "aapl", "msft"}, &stderr)
+		if code != 0 {
+			t.Fatalf("exit code %d: %s", code, stderr.String())
+		}
+		appended, err := ioutil.ReadFile(outfile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 && !bytes.Equal(csv, appended) {
+			t.Errorf("bars appended twice:\n%s", appended)
+		}
+		csv = appended
+	}
+	if !strings.HasPrefix(string(csv), "symbol,datetime,open,high,low,close,volume\naapl,") {
+		t.Errorf("unexpected csv:\n%s", csv)
+	}
+}
+
+func TestRunStdout(t *testing.T) {
+	defer fakeSource()()
+	defer func(w io.Writer) { stdout = w }(stdout)
+	var out bytes.Buffer
+	stdout = &out
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-all", "-format=json", "-outfile=-", "aapl", "msft"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	quotes, err := quote.NewQuotesFromJSON(out.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 2 || quotes[0].Symbol != "aapl" || len(quotes[0].Close) == 0 {
+		t.Errorf("unexpected json on stdout:\n%s", out.String())
+	}
+}
+
+func TestRunHTML(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-format=html", "-all", "-outdir=" + dir, "aapl", "msft"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	page, err := ioutil.ReadFile(filepath.Join(dir, "quotes.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), `<option value="1">msft</option>`) {
+		t.Errorf("no msft in page:\n%s", page)
+	}
+}
+
+func TestRunPgCopy(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-format=pgcopy", "-all", "-table=bars", "-outdir=" + dir, "aapl", "msft"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	sql, err := ioutil.ReadFile(filepath.Join(dir, "quotes.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(sql), "COPY bars (symbol, ts, open, high, low, close, volume) FROM stdin;\naapl\t") ||
+		!strings.HasSuffix(string(sql), "\n\\.\n") {
+		t.Errorf("unexpected script:\n%s", sql)
+	}
+}
+
+func TestRunRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	gappy := "datetime,open,high,low,close,volume\n" +
+		"2023-01-02 00:00,1.00,1.00,1.00,1.00,10.00\n" +
+		"2023-01-04 00:00,3.00,3.00,3.00,3.00,10.00\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "btcusd.csv"), []byte(gappy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := backfill
+	defer func() { backfill = saved }()
+	var gotSpec quote.SourceSpec
+	backfill = func(ctx context.Context, q quote.Quote, spec quote.SourceSpec, period quote.Period) (quote.Quote, int, error) {
+		gotSpec = spec
+		r, err := quote.NewQuoteFromCSV(q.Symbol, gappy+"2023-01-03 00:00,2,2,2,2,10\n")
+		if err != nil || len(q.Close) != 2 {
+			t.Fatalf("unexpected quote %v: %v", q.Close, err)
+		}
+		r.Date[1], r.Date[2] = r.Date[2], r.Date[1]
+		r.Close[1], r.Close[2] = r.Close[2], r.Close[1]
+		return r, 1, nil
+	}
+
+	var stderr bytes.Buffer
+	code := run([]string{"-delay=0", "-log=discard", "-repair", "-source=binance", "-outdir=" + dir, "btcusd"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	if gotSpec.Name != "binance" {
+		t.Errorf("unexpected source %v", gotSpec)
+	}
+	q, err := quote.NewQuoteFromCSVFile("btcusd", filepath.Join(dir, "btcusd.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Close) != 3 || q.Close[1] != 2 {
+		t.Errorf("file not repaired: %v", q.Close)
+	}
+
+	f := validFlags()
+	f.source, f.format, f.repair = "binance", "hs", true
+	if checkFlags(f) == nil {
+		t.Error("-repair accepted with hs format")
+	}
+}
+
+func TestRunValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	good := filepath.Join(dir, "spy.csv")
+	bad := filepath.Join(dir, "btcusd.csv")
+	header := "datetime,open,high,low,close,volume\n"
+	if err := ioutil.WriteFile(good, []byte(header+
+		"2023-01-03 00:00,1.00,2.00,0.50,1.50,10.00\n"+
+		"2023-01-04 00:00,1.50,2.00,1.00,1.75,10.00\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(bad, []byte(header+
+		"2023-01-04 00:00,1.00,2.00,0.50,1.50,10.00\n"+
+		"2023-01-03 00:00,1.50,1.00,1.00,1.75,10.00\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{good}, 0},
+		{[]string{good, bad}, 1},
+		{[]string{filepath.Join(dir, "missing.csv")}, 1},
+		{nil, 2},
+	} {
+		var stderr bytes.Buffer
+		code := run(append([]string{"-log=discard", "-validate"}, c.args...), &stderr)
+		if code != c.code {
+			t.Errorf("%v: exit code %d, want %d", c.args, code, c.code)
+		}
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	old := filepath.Join(dir, "old.csv")
+	same := filepath.Join(dir, "same.csv")
+	moved := filepath.Join(dir, "moved.csv")
+	header := "datetime,open,high,low,close,volume\n"
+	bars := "2023-01-03 00:00,1.00,2.00,0.50,1.50,10.00\n"
+	for file, csv := range map[string]string{
+		old:   header + bars + "2023-01-04 00:00,1.50,2.00,1.00,1.75,10.00\n",
+		same:  header + bars + "2023-01-04 00:00,1.50,2.00,1.00,1.75,10.00\n",
+		moved: header + bars + "2023-01-04 00:00,1.50,2.00,1.00,1.80,10.00\n",
+	} {
+		if err := ioutil.WriteFile(file, []byte(csv), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, c := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{"diff", old, same}, 0},
+		{[]string{"diff", old, moved}, 1},
+		{[]string{"-tolerance=0.05", "diff", old, moved}, 0},
+		{[]string{"-format=json", "diff", old, moved}, 1},
+		{[]string{"diff", old, filepath.Join(dir, "missing.csv")}, 2},
+		{[]string{"diff", old}, 2},
+	} {
+		var stderr bytes.Buffer
+		code := run(append([]string{"-log=discard"}, c.args...), &stderr)
+		if code != c.code {
+			t.Errorf("%v: exit code %d, want %d", c.args, code, c.code)
+		}
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// spy moves 1% a bar, aapl 2%
+	for _, c := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{"spy", "aapl"}, 0},
+		{[]string{"-max-jump=1.5", "spy"}, 0},
+		{[]string{"-max-jump=1.5", "spy", "aapl"}, 1},
+	} {
+		var stderr bytes.Buffer
+		code := run(append([]string{"-delay=0", "-log=discard", "-report", "-outdir=" + dir}, c.args...), &stderr)
+		if code != c.code {
+			t.Errorf("%v: exit code %d, want %d", c.args, code, c.code)
+		}
+	}
+}
+
+func TestRunGoFixture(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pkgdir := filepath.Join(dir, "feed")
+	if err := os.Mkdir(pkgdir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(pkgdir, "feed.go"), []byte("package prices\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	out := filepath.Join(pkgdir, "fixture_test.go")
+	code := run([]string{"-delay=0", "-log=discard", "-format=go", "-outfile=" + out, "-last=3", "brk.b"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(data)
+	if !strings.HasPrefix(src, "// Code generated by go-quote; DO NOT EDIT.\n\npackage prices\n") ||
+		!strings.Contains(src, "var brkBQuote = quote.Quote{") ||
+		strings.Count(src, "time.Date(") != 3 {
+		t.Errorf("unexpected fixture:\n%s", src)
+	}
+
+	// the directory name when there are no other go files
+	if pkg := goPackage(filepath.Join(dir, "my-data", "x.go")); pkg != "mydata" {
+		t.Errorf("unexpected package %s", pkg)
+	}
+}
+
+func TestRunJournal(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	path := filepath.Join(dir, "run.jsonl")
+	for _, args := range [][]string{{"aapl", "msft"}, {"-all", "aapl"}} {
+		args = append([]string{"-delay=0", "-log=discard", "-journal=" + path, "-outdir=" + dir}, args...)
+		if code := run(args, &stderr); code != 0 {
+			t.Fatalf("exit code %d", code)
+		}
+	}
+
+	j, err := quote.OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	s := j.Summary()
+	if s.Symbols != 2 || s.OK != 2 || s.Bars != 20 {
+		t.Errorf("unexpected summary %+v", s)
+	}
+	if !j.Completed("aapl", "yahoo") {
+		t.Error("aapl not completed")
+	}
+	data, _ := ioutil.ReadFile(path)
+	if n := strings.Count(string(data), "\n"); n != 3 {
+		t.Errorf("%d entries:\n%s", n, data)
+	}
+}
+
+func TestRunAdjClose(t *testing.T) {
+	saved := fetchSymbol
+	defer func() { fetchSymbol = saved }()
+	fetchSymbol = func(sym string, flags quoteflags) (quote.Quote, error) {
+		q := quote.NewQuote(sym, 1)
+		q.Date[0] = time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+		q.Open[0], q.High[0], q.Low[0], q.Close[0] = 10, 10, 10, 10
+		q.AdjClose = []float64{5}
+		q.AdjOpen, q.AdjHigh, q.AdjLow = []float64{5}, []float64{5}, []float64{5}
+		return q, nil
+	}
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tt := range []struct {
+		flag   string
+		header string
+	}{
+		{"-adjclose=false", "datetime,open,high,low,close,volume\n"},
+		{"-adjclose", "datetime,open,high,low,close,volume,adjclose,adjopen,adjhigh,adjlow\n"},
+	} {
+		var stderr bytes.Buffer
+		if code := run([]string{"-delay=0", "-log=discard", tt.flag, "-outdir=" + dir, "spy"}, &stderr); code != 0 {
+			t.Fatalf("%s: exit code %d", tt.flag, code)
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, "spy.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(data), tt.header) {
+			t.Errorf("%s: unexpected csv\n%s", tt.flag, data)
+		}
+	}
+}
+
+func TestRunTokenEnv(t *testing.T) {
+	saved := fetchSymbol
+	defer func() { fetchSymbol = saved }()
+	var token string
+	fetchSymbol = func(sym string, flags quoteflags) (quote.Quote, error) {
+		token = flags.token
+		return quote.NewQuote(sym, 0), nil
+	}
+	for _, env := range []string{"TIINGO_API_TOKEN", "ALPHAVANTAGE_API_KEY", "POLYGON_API_KEY", "IEX_TOKEN", "FINNHUB_TOKEN", "APCA_API_KEY_ID", "APCA_API_SECRET_KEY"} {
+		savedEnv, set := os.LookupEnv(env)
+		os.Setenv(env, strings.ToLower(env))
+		if set {
+			defer os.Setenv(env, savedEnv)
+		} else {
+			defer os.Unsetenv(env)
+		}
+	}
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tt := range []struct {
+		args  []string
+		token string
+	}{
+		{[]string{"-source=tiingo"}, "tiingo_api_token"},
+		{[]string{"-source=alphavantage"}, "alphavantage_api_key"},
+		{[]string{"-source=alphavantage", "-token=tok"}, "tok"},
+		{[]string{"-source=polygon"}, "polygon_api_key"},
+		{[]string{"-source=iex"}, "iex_token"},
+		{[]string{"-source=finnhub"}, "finnhub_token"},
+		{[]string{"-source=alpaca"}, "apca_api_key_id:apca_api_secret_key"},
+	} {
+		var stderr bytes.Buffer
+		args := append([]string{"-delay=0", "-log=discard", "-outdir=" + dir}, tt.args...)
+		if code := run(append(args, "ibm"), &stderr); code != 0 {
+			t.Fatalf("%v: exit code %d", tt.args, code)
+		}
+		if token != tt.token {
+			t.Errorf("%v: token %q, want %q", tt.args, token, tt.token)
+		}
+	}
+}
+
+func TestRunInterrupted(t *testing.T) {
+	defer fakeSource()()
+	saved, savedNotify := fetchSymbol, notifyContext
+	defer func() { notifyContext = savedNotify }()
+	var cancel context.CancelFunc
+	notifyContext = func() (context.Context, context.CancelFunc) {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		return ctx, cancel
+	}
+	var fetched []string
+	fetchSymbol = func(sym string, flags quoteflags) (quote.Quote, error) {
+		fetched = append(fetched, sym)
+		if sym == "msft" {
+			// ctrl-c during the first download, cutting the delay after it
+			// short
+			cancel()
+		}
+		return saved(sym, flags)
+	}
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, all := range []string{"-all=false", "-all"} {
+		fetched = nil
+		var stderr bytes.Buffer
+		start := time.Now()
+		code := run([]string{"-delay=10000", "-log=discard", all, "-outdir=" + dir, "msft", "ibm"}, &stderr)
+		if time.Since(start) > 5*time.Second {
+			t.Errorf("%s: slow exit after the interrupt", all)
+		}
+		if code != 130 {
+			t.Errorf("%s: exit code %d", all, code)
+		}
+		if stderr.String() != "interrupted\n" {
+			t.Errorf("%s: stderr %q", all, stderr.String())
+		}
+		if strings.Join(fetched, ",") != "msft" {
+			t.Errorf("%s: fetched %v", all, fetched)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ibm.csv")); !os.IsNotExist(err) {
+		t.Errorf("ibm written after the interrupt: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "quotes.csv")); !os.IsNotExist(err) {
+		t.Errorf("-all output written after the interrupt: %v", err)
+	}
+}
+
+func TestRunRate(t *testing.T) {
+	defer fakeSource()()
+	defer quote.SetRateLimit("yahoo", 0, 0)
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the limit of the source replaces -delay between symbols
+	var stderr bytes.Buffer
+	start := time.Now()
+	code := run([]string{"-delay=10000", "-rate=yahoo:1000,binance:10", "-log=discard", "-outdir=" + dir, "aapl", "msft"}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("slept -delay with -rate")
+	}
+	if quote.SourceRateLimiter("yahoo") == nil || quote.SourceRateLimiter("binance") == nil {
+		t.Errorf("-rate limits not set")
+	}
+	quote.SetRateLimit("binance", 0, 0)
+
+	if code := run([]string{"-rate=yahoo", "spy"}, &stderr); code != 2 {
+		t.Errorf("invalid -rate: exit code %d", code)
+	}
+}
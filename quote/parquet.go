@@ -0,0 +1,6 @@
+//go:build parquet
+// +build parquet
+
+package main
+
+import _ "github.com/markcheno/go-quote/parquet" // -format=parquet
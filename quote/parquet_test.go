@@ -0,0 +1,39 @@
+//go:build parquet
+// +build parquet
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunParquet(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, args := range [][]string{{"-all"}, {"-all=false"}} {
+		var stderr bytes.Buffer
+		args = append(args, "-delay=0", "-log=discard", "-format=parquet", "-outdir="+dir, "aapl", "msft")
+		if code := run(args, &stderr); code != 0 {
+			t.Fatalf("%v: exit code %d: %s", args, code, stderr.String())
+		}
+	}
+	for _, name := range []string{"quotes.parquet", "aapl.parquet", "msft.parquet"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+			t.Errorf("%s: not a parquet file", name)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+//go:build sqlite
+// +build sqlite
+
+package main
+
+import _ "github.com/markcheno/go-quote/sqlite" // -format=sqlite
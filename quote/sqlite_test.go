@@ -0,0 +1,45 @@
+//go:build sqlite
+// +build sqlite
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markcheno/go-quote/sqlite"
+)
+
+func TestRunSQLite(t *testing.T) {
+	defer fakeSource()()
+	dir, err := ioutil.TempDir("", "quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// per symbol and -all upsert into the same database
+	for _, args := range [][]string{{"-all=false", "aapl", "msft"}, {"-all", "aapl", "msft", "spy"}} {
+		var stderr bytes.Buffer
+		args = append([]string{"-delay=0", "-log=discard", "-format=sqlite", "-outfile=bars.db", "-outdir=" + dir}, args...)
+		if code := run(args, &stderr); code != 0 {
+			t.Fatalf("%v: exit code %d: %s", args, code, stderr.String())
+		}
+	}
+	quotes, err := sqlite.NewQuotesFromSQLite(filepath.Join(dir, "bars.db"), []string{"aapl", "msft", "spy"}, time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range quotes {
+		if len(q.Close) != 10 {
+			t.Errorf("%s: %d bars", q.Symbol, len(q.Close))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "quotes.db")); err == nil {
+		t.Error("-outfile ignored")
+	}
+}
@@ -2,10 +2,17 @@ package quote
 
 import (
 	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 // assert fails the test if the condition is false.
@@ -35,6 +42,30 @@ func equals(t *testing.T, exp, act interface{}) {
 	}
 }
 
+// near fails the test if got is not within 1e-6 of want.
+func near(t *testing.T, want, got float64, what string) {
+	t.Helper()
+	assert(t, math.Abs(want-got) < 1e-6, "%s: want %v got %v", what, want, got)
+}
+
+// day - midnight utc of a date
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// fakeServer - serve a source's requests with handler for the rest of the
+// test, pointing *url at the server and Delay at 0
+func fakeServer(t *testing.T, url *string, handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(handler)
+	saved, savedDelay := *url, Delay
+	*url, Delay = server.URL, 0
+	t.Cleanup(func() {
+		server.Close()
+		*url, Delay = saved, savedDelay
+	})
+	return server
+}
+
 // TODO - everything
 
 func TestNewQuoteFromCSV(t *testing.T) {
@@ -98,3 +129,91 @@ aapl,2018-07-20 00:00,191.78,192.43,190.17,188.57,20676200.00`
 		t.Error("Invalid last value")
 	}
 }
+
+// shibFixture - 2 daily bars of shibusd priced in fractions of a cent
+func shibFixture() Quote {
+	q := NewQuote("shibusd", 0)
+	q.PushBar(Bar{Date: day(2023, 2, 1), Open: 0.00001234, High: 0.000012345678901234, Low: 1.0 / 3 * 1e-5, Close: 0.0000123, Volume: 1234567890123.25})
+	q.PushBar(Bar{Date: day(2023, 2, 2), Open: 0.0000123, High: 0.0000125, Low: 0.00001199999, Close: 0.00001249, Volume: 9.87654321e12})
+	return q
+}
+
+func TestFullPrecisionCSVRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "precision")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	shib := shibFixture()
+	name := filepath.Join(dir, "shibusd.csv")
+	ok(t, shib.WriteCSV(name))
+	back, err := NewQuoteFromCSVFile("shibusd", name)
+	ok(t, err)
+	assert(t, back.High[0] != shib.High[0], "8 decimals kept full precision")
+
+	defer func(p int) { DefaultPrecision = p }(DefaultPrecision)
+	DefaultPrecision = FullPrecision
+	ok(t, shib.WriteCSV(name))
+	back, err = NewQuoteFromCSVFile("shibusd", name)
+	ok(t, err)
+	equals(t, shib, back)
+	assert(t, strings.Contains(shib.CSV(), ",0.000012345678901234,0.0000033333333333333333,"), "csv: %s", shib.CSV())
+}
+
+func TestPrecision(t *testing.T) {
+	shib := shibFixture()
+	hs := shib.Highstock()
+	assert(t, strings.HasPrefix(hs, "[\n[1675209600000,0.00001234,0.00001235,0.00000333,0.00001230,1234567890123.25000000],\n"), "highstock: %s", hs)
+
+	shib.Precision = FullPrecision
+	hs = shib.Highstock()
+	assert(t, strings.HasPrefix(hs, "[\n[1675209600000,0.00001234,0.000012345678901234,0.0000033333333333333333,0.0000123,1234567890123.25],\n"), "highstock: %s", hs)
+
+	// the per call option overrides the quote's
+	opts := DefaultCSVOptions
+	opts.Precision = 3
+	csv, err := shib.CSVWithOptions(opts)
+	ok(t, err)
+	assert(t, strings.Contains(csv, "\n2023-02-01 00:00,0.000,0.000,0.000,0.000,1234567890123.250\n"), "csv: %s", csv)
+
+	spy := NewQuote("spy", 0)
+	spy.PushBar(Bar{Date: day(2023, 3, 1), Open: 1, High: 2, Low: 0.5, Close: 1.5, Volume: 100})
+	equals(t, "{\"spy\":[\n[1677628800000,1.00,2.00,0.50,1.50,100.00]\n]\n}", Quotes{spy}.Highstock())
+	defer func(p int) { DefaultPrecision = p }(DefaultPrecision)
+	DefaultPrecision = FullPrecision
+	equals(t, "{\"spy\":[\n[1677628800000,1,2,0.5,1.5,100]\n]\n}", Quotes{spy}.Highstock())
+}
+
+func TestNewQuoteFromJSONReader(t *testing.T) {
+	spy := partitionFixture()[0]
+	spy.Trades = []float64{1, 2, 3, 4}
+	q, err := NewQuoteFromJSONReader(strings.NewReader(spy.JSON(true) + "\n"))
+	ok(t, err)
+	equals(t, spy, q)
+
+	meta := &Metadata{Source: "yahoo"}
+	spy.Meta = meta
+	q, err = NewQuoteFromJSONReader(strings.NewReader(spy.MetaJSON(false)))
+	ok(t, err)
+	equals(t, "yahoo", q.Meta.Source)
+	equals(t, spy.Close, q.Close)
+
+	_, err = NewQuoteFromJSONReader(strings.NewReader(spy.JSON(false) + "{}"))
+	assert(t, err != nil, "trailing data read")
+
+	quotes := partitionFixture()
+	quotes.SortBySymbol()
+	all, err := NewQuotesFromJSONReader(strings.NewReader(quotes.MetaJSON(true)))
+	ok(t, err)
+	equals(t, 3, len(all))
+	equals(t, quotes[1].Close, all[1].Close)
+	all, err = NewQuotesFromJSONReader(strings.NewReader(quotes.JSON(false)))
+	ok(t, err)
+	equals(t, quotes, all)
+	all, err = NewQuotesFromJSONReader(strings.NewReader(" null "))
+	ok(t, err)
+	equals(t, 0, len(all))
+	_, err = NewQuotesFromJSONReader(strings.NewReader(spy.JSON(false)))
+	assert(t, err != nil, "quote read as quotes")
+	_, err = NewQuotesFromJSONReader(strings.NewReader("[" + spy.JSON(false)))
+	assert(t, err != nil, "unterminated array read")
+}
@@ -0,0 +1,137 @@
+package quote
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter - paces the requests to a source, see SetRateLimiter
+type RateLimiter interface {
+	// Wait - block until the next request may be made, returning the error
+	// of ctx if it is done first
+	Wait(ctx context.Context) error
+}
+
+// rateNow, rateSleep - clock of TokenBucket, replaced in tests
+var (
+	rateNow   = time.Now
+	rateSleep = sleepContext
+)
+
+// TokenBucket - RateLimiter letting through bursts of up to burst requests,
+// its tokens refilled at rate per second. Waiting requests are served in
+// order. Safe for concurrent use.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64 // negative for the requests waiting
+	last   time.Time
+}
+
+// NewTokenBucket - a full bucket of burst tokens, at least 1, refilled at
+// rate per second. A rate of zero or less does not limit.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{rate: rate, burst: burst, tokens: float64(burst)}
+}
+
+// Wait - take a token, waiting for it to be refilled if there is none left.
+// The token is given back if ctx is done first.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if b.rate <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	now := rateNow()
+	if !b.last.IsZero() {
+		b.tokens = math.Min(float64(b.burst), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if err := rateSleep(ctx, wait); err != nil {
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// rateLimiters - limiters of SetRateLimiter by source name
+var rateLimiters struct {
+	sync.Mutex
+	m map[string]RateLimiter
+}
+
+// SetRateLimiter - pace every request to source, including the pages of a
+// single download, with l instead of sleeping Delay between them. source is
+// a SourceSpec name, e.g. binance. A nil l removes the limit.
+func SetRateLimiter(source string, l RateLimiter) {
+	rateLimiters.Lock()
+	defer rateLimiters.Unlock()
+	if l == nil {
+		delete(rateLimiters.m, source)
+		return
+	}
+	if rateLimiters.m == nil {
+		rateLimiters.m = map[string]RateLimiter{}
+	}
+	rateLimiters.m[source] = l
+}
+
+// SetRateLimit - limit the requests to source to rate per second with bursts
+// of up to burst requests, see SetRateLimiter. A rate of zero or less removes
+// the limit.
+func SetRateLimit(source string, rate float64, burst int) {
+	if rate <= 0 {
+		SetRateLimiter(source, nil)
+		return
+	}
+	SetRateLimiter(source, NewTokenBucket(rate, burst))
+}
+
+// SourceRateLimiter - the limiter of source, nil if it has none and Delay
+// applies
+func SourceRateLimiter(source string) RateLimiter {
+	rateLimiters.Lock()
+	defer rateLimiters.Unlock()
+	return rateLimiters.m[source]
+}
+
+// waitRate - wait for the limiter of source before a request to it, if it
+// has one
+func waitRate(ctx context.Context, source string) error {
+	if l := SourceRateLimiter(source); l != nil {
+		return l.Wait(ctx)
+	}
+	return nil
+}
+
+// delayContext - sleep Delay between two requests to source unless it has a
+// limiter, which paces them instead. Returns the error of ctx if it is done.
+func delayContext(ctx context.Context, source string) error {
+	if SourceRateLimiter(source) != nil {
+		return ctx.Err()
+	}
+	return sleepContext(ctx, Delay*time.Millisecond)
+}
+
+// delay - delayContext without cancellation
+func delay(source string) {
+	delayContext(context.Background(), source)
+}
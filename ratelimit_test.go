@@ -0,0 +1,120 @@
+package quote
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock - clock of TokenBucket advanced only by its sleeps
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// useFakeClock - a fake clock for TokenBucket, returning it and a restore
+// func
+func useFakeClock() (*fakeClock, func()) {
+	c := &fakeClock{now: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)}
+	savedNow, savedSleep := rateNow, rateSleep
+	rateNow = func() time.Time {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.now
+	}
+	rateSleep = func(ctx context.Context, d time.Duration) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c.advance(d)
+		c.mu.Lock()
+		c.sleeps = append(c.sleeps, d)
+		c.mu.Unlock()
+		return nil
+	}
+	return c, func() { rateNow, rateSleep = savedNow, savedSleep }
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock, restore := useFakeClock()
+	defer restore()
+
+	// a burst of 2, then one request every half second
+	b := NewTokenBucket(2, 2)
+	for i := 0; i < 5; i++ {
+		ok(t, b.Wait(context.Background()))
+	}
+	ms := time.Millisecond
+	equals(t, []time.Duration{0, 0, 500 * ms, 500 * ms, 500 * ms}, clock.sleeps)
+
+	// idle time refills up to the burst only
+	clock.advance(time.Minute)
+	clock.sleeps = nil
+	for i := 0; i < 3; i++ {
+		ok(t, b.Wait(context.Background()))
+	}
+	equals(t, []time.Duration{0, 0, 500 * ms}, clock.sleeps)
+
+	// a canceled wait takes no token
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	clock.sleeps = nil
+	assert(t, errors.Is(b.Wait(ctx), context.Canceled), "wait not canceled")
+	ok(t, b.Wait(context.Background()))
+	equals(t, []time.Duration{500 * ms}, clock.sleeps)
+
+	// no limit
+	clock.sleeps = nil
+	for i := 0; i < 3; i++ {
+		ok(t, NewTokenBucket(0, 1).Wait(context.Background()))
+	}
+	equals(t, 0, len(clock.sleeps))
+}
+
+func TestSetRateLimit(t *testing.T) {
+	clock, restore := useFakeClock()
+	defer restore()
+	saved := HTTPClient
+	ValidateCoinbaseProducts = false
+	defer func() {
+		HTTPClient, ValidateCoinbaseProducts = saved, true
+		SetRateLimit("coinbase", 0, 0)
+	}()
+
+	fakeServer(t, &coinbaseURL, fakeCoinbaseCandles(0, nil))
+	counter := &countingTransport{}
+	HTTPClient = &http.Client{Transport: counter}
+
+	// every page is paced by the limiter instead of Delay, which would take
+	// 20 seconds
+	Delay = 10000
+	SetRateLimit("coinbase", 2, 1)
+	assert(t, SourceRateLimiter("coinbase") != nil, "no coinbase limiter")
+	equals(t, nil, SourceRateLimiter("binance"))
+	start := time.Now()
+	_, err := NewQuoteFromCoinbase("BTC-USD", "2023-03-01", "2023-03-20", Min60)
+	ok(t, err)
+	assert(t, time.Since(start) < 5*time.Second, "slept Delay")
+	equals(t, int32(3), atomic.LoadInt32(&counter.requests))
+	equals(t, []time.Duration{0, 500 * time.Millisecond, 500 * time.Millisecond}, clock.sleeps)
+
+	// other sources still sleep Delay
+	Delay = 1
+	start = time.Now()
+	ok(t, delayContext(context.Background(), "binance"))
+	assert(t, time.Since(start) >= time.Millisecond, "binance did not sleep Delay")
+
+	SetRateLimit("coinbase", 0, 0)
+	equals(t, nil, SourceRateLimiter("coinbase"))
+}
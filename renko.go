@@ -0,0 +1,128 @@
+package quote
+
+import (
+	"fmt"
+	"math"
+)
+
+// RenkoOptions - options of RenkoWithOptions
+type RenkoOptions struct {
+	BrickSize float64 // price move of a brick, 0 to take it from ATRPeriod
+	ATRPeriod int     // bars of the average true range used as brick size when BrickSize is 0
+}
+
+// averageTrueRange - mean true range of the last n bars of q
+func (q Quote) averageTrueRange(n int) (float64, error) {
+	if n <= 0 || len(q.Close) < n+1 {
+		return 0, fmt.Errorf("%s: average true range of %d bars needs more than %d bars, has %d", q.Symbol, n, n, len(q.Close))
+	}
+	sum := 0.0
+	for bar := len(q.Close) - n; bar < len(q.Close); bar++ {
+		prev := q.Close[bar-1]
+		sum += math.Max(q.High[bar]-q.Low[bar], math.Max(math.Abs(q.High[bar]-prev), math.Abs(q.Low[bar]-prev)))
+	}
+	return sum / float64(n), nil
+}
+
+// priceBars - empty quote of the bars Renko and RangeBars build from q,
+// metadata without a period as they are not spaced in time
+func (q Quote) priceBars() Quote {
+	out := NewQuote(q.Symbol, 0)
+	out.Precision = q.Precision
+	if q.Meta != nil {
+		meta := *q.Meta
+		meta.Period = ""
+		out.Meta = &meta
+	}
+	return out
+}
+
+// Renko - renko bricks of brickSize built from the closes of q. A brick is
+// added each time the close moves brickSize beyond the last brick, 2
+// brickSize against it to reverse, from the first close. Bricks are dated by
+// the bar completing them, which also carries the volume since the last
+// brick; further bricks of the same bar have zero volume. A brick still
+// forming is left out.
+func (q Quote) Renko(brickSize float64) (Quote, error) {
+	return q.RenkoWithOptions(RenkoOptions{BrickSize: brickSize})
+}
+
+// RenkoWithOptions - Renko with the brick size of opts, or the average true
+// range of the last ATRPeriod bars if BrickSize is 0
+func (q Quote) RenkoWithOptions(opts RenkoOptions) (Quote, error) {
+	size := opts.BrickSize
+	if size == 0 && opts.ATRPeriod > 0 {
+		atr, err := q.averageTrueRange(opts.ATRPeriod)
+		if err != nil {
+			return Quote{}, err
+		}
+		size = atr
+	}
+	if !(size > 0) {
+		return Quote{}, fmt.Errorf("%s: renko brick size %g must be positive", q.Symbol, size)
+	}
+
+	out := q.priceBars()
+	if len(q.Close) == 0 {
+		return out, nil
+	}
+	// bricks lie on levels base + n*size, the last from low to high
+	base := q.Close[0]
+	level := func(n int) float64 { return base + float64(n)*size }
+	low, high := 0, 0
+	volume := 0.0
+	for bar, c := range q.Close {
+		volume += q.Volume[bar]
+		for c >= level(high+1) {
+			out.PushBar(Bar{Date: q.Date[bar], Open: level(high), High: level(high + 1), Low: level(high), Close: level(high + 1), Volume: volume})
+			volume = 0
+			low, high = high, high+1
+		}
+		for c <= level(low-1) {
+			out.PushBar(Bar{Date: q.Date[bar], Open: level(low), High: level(low), Low: level(low - 1), Close: level(low - 1), Volume: volume})
+			volume = 0
+			low, high = low-1, low
+		}
+	}
+	return out, nil
+}
+
+// RangeBars - bars of q rebuilt from its closes to span rangeSize from low
+// to high each. A bar closes when the close moves rangeSize away from its
+// low or high, at that price, and the next opens there; a move of several
+// rangeSize adds a bar for each. Bars are dated by the bar completing them,
+// which also carries the volume since the last; further bars completed by the
+// same bar have zero volume. A bar still forming is left out.
+func (q Quote) RangeBars(rangeSize float64) (Quote, error) {
+	if !(rangeSize > 0) {
+		return Quote{}, fmt.Errorf("%s: range bar size %g must be positive", q.Symbol, rangeSize)
+	}
+
+	out := q.priceBars()
+	if len(q.Close) == 0 {
+		return out, nil
+	}
+	open := q.Close[0]
+	high, low := open, open
+	volume := 0.0
+	for bar, c := range q.Close {
+		volume += q.Volume[bar]
+		for {
+			var last float64
+			if c >= low+rangeSize {
+				last = low + rangeSize
+				high = last
+			} else if c <= high-rangeSize {
+				last = high - rangeSize
+				low = last
+			} else {
+				break
+			}
+			out.PushBar(Bar{Date: q.Date[bar], Open: open, High: high, Low: low, Close: last, Volume: volume})
+			volume = 0
+			open, high, low = last, last, last
+		}
+		high, low = math.Max(high, c), math.Min(low, c)
+	}
+	return out, nil
+}
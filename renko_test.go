@@ -0,0 +1,82 @@
+package quote
+
+import "testing"
+
+// closesFixture - daily bars from 2023-01-02 closing at closes, with high and
+// low at the close and a volume of 1
+func closesFixture(closes ...float64) Quote {
+	q := NewQuote("spy", 0)
+	for i, c := range closes {
+		q.PushBar(Bar{Date: day(2023, 1, 2).AddDate(0, 0, i), Open: c, High: c, Low: c, Close: c, Volume: 1})
+	}
+	q.Meta = &Metadata{Symbol: "spy", Period: Daily}
+	return q
+}
+
+func TestRenko(t *testing.T) {
+	q := closesFixture(10, 10.5, 11.2, 12.1, 11.5, 10.9, 9.8, 8.9, 9.5, 11.3, 13.2)
+	r, err := q.Renko(1)
+	ok(t, err)
+	equals(t, []Bar{
+		{Date: q.Date[2], Open: 10, High: 11, Low: 10, Close: 11, Volume: 3},
+		{Date: q.Date[3], Open: 11, High: 12, Low: 11, Close: 12, Volume: 1},
+		// reversal at 2 bricks from the top, 9.8 <= 10
+		{Date: q.Date[6], Open: 11, High: 11, Low: 10, Close: 10, Volume: 3},
+		{Date: q.Date[7], Open: 10, High: 10, Low: 9, Close: 9, Volume: 1},
+		{Date: q.Date[9], Open: 10, High: 11, Low: 10, Close: 11, Volume: 2},
+		// two bricks completed by one bar
+		{Date: q.Date[10], Open: 11, High: 12, Low: 11, Close: 12, Volume: 1},
+		{Date: q.Date[10], Open: 12, High: 13, Low: 12, Close: 13, Volume: 0},
+	}, r.Bars())
+	equals(t, Period(""), r.Meta.Period)
+	equals(t, Daily, q.Meta.Period)
+
+	// a move short of a brick makes none
+	r, err = closesFixture(10, 10.9, 9.1).Renko(1)
+	ok(t, err)
+	equals(t, 0, r.Len())
+	r, err = NewQuote("spy", 0).Renko(1)
+	ok(t, err)
+	equals(t, 0, r.Len())
+
+	for _, size := range []float64{0, -1} {
+		_, err = q.Renko(size)
+		assert(t, err != nil, "renko of brick size %g", size)
+	}
+}
+
+func TestRenkoATR(t *testing.T) {
+	// true ranges 1, 2, 1: the last 2 average 1.5
+	q := closesFixture(10, 11, 13, 12)
+	r, err := q.RenkoWithOptions(RenkoOptions{ATRPeriod: 2})
+	ok(t, err)
+	want, err := q.Renko(1.5)
+	ok(t, err)
+	equals(t, want, r)
+	equals(t, []float64{11.5, 13}, r.Close)
+
+	_, err = q.RenkoWithOptions(RenkoOptions{ATRPeriod: 4})
+	assert(t, err != nil, "average true range of more bars than the quote has")
+	_, err = q.RenkoWithOptions(RenkoOptions{BrickSize: -1, ATRPeriod: 2})
+	assert(t, err != nil, "renko of a negative brick size")
+}
+
+func TestRangeBars(t *testing.T) {
+	q := closesFixture(10, 10.25, 10.75, 11.25, 11, 10, 9.75, 12.5)
+	r, err := q.RangeBars(1)
+	ok(t, err)
+	equals(t, []Bar{
+		{Date: q.Date[3], Open: 10, High: 11, Low: 10, Close: 11, Volume: 4},
+		{Date: q.Date[5], Open: 11, High: 11.25, Low: 10.25, Close: 10.25, Volume: 2},
+		{Date: q.Date[7], Open: 10.25, High: 10.75, Low: 9.75, Close: 10.75, Volume: 2},
+		{Date: q.Date[7], Open: 10.75, High: 11.75, Low: 10.75, Close: 11.75, Volume: 0},
+	}, r.Bars())
+	for bar := range r.Close {
+		equals(t, 1.0, r.High[bar]-r.Low[bar])
+	}
+
+	for _, size := range []float64{0, -0.5} {
+		_, err = q.RangeBars(size)
+		assert(t, err != nil, "range bars of size %g", size)
+	}
+}
@@ -0,0 +1,178 @@
+package quote
+
+import (
+	"fmt"
+	"time"
+)
+
+// ResampleOptions - bucketing of ResampleWithOptions
+type ResampleOptions struct {
+	// Anchor - start of one of the buckets, natural boundaries when zero:
+	// multiples of the period from midnight for intraday periods, midnight
+	// for days, mondays for weeks, the 1st for months and january 1st for
+	// years. Calendar periods keep the time of day of Anchor, e.g. 17:00 New
+	// York for forex days.
+	Anchor time.Time
+	// Location - time zone of the bucket boundaries, that of the bars if nil
+	Location *time.Location
+	// DropPartial - drop the last bucket if the bars end before it does
+	DropPartial bool
+}
+
+// DefaultResampleOptions - natural boundaries in the time zone of the bars,
+// keeping a partial last bucket
+var DefaultResampleOptions = ResampleOptions{}
+
+// floorDiv - n/d rounded down
+func floorDiv(n, d int) int {
+	if n < 0 {
+		return -((-n + d - 1) / d)
+	}
+	return n / d
+}
+
+// civilDay - days from 1970-01-01 to the calendar date of t
+func civilDay(t time.Time) int {
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+// bucket - start and end of the bucket of target containing t
+func (opts ResampleOptions) bucket(t time.Time, target Period) (time.Time, time.Time) {
+	loc := opts.Location
+	if loc == nil {
+		loc = t.Location()
+	}
+	t = t.In(loc)
+	anchor := opts.Anchor.In(loc)
+
+	switch target {
+	case Daily, Day3, Weekly:
+		days := int(target.Duration() / (24 * time.Hour))
+		if opts.Anchor.IsZero() {
+			anchor = time.Date(1970, 1, 1, 0, 0, 0, 0, loc)
+		}
+		if opts.Anchor.IsZero() && target == Weekly {
+			// the first monday
+			anchor = time.Date(1970, 1, 5, 0, 0, 0, 0, loc)
+		}
+		k := floorDiv(civilDay(t)-civilDay(anchor), days) * days
+		start := anchor.AddDate(0, 0, k)
+		if start.After(t) {
+			k -= days
+			start = anchor.AddDate(0, 0, k)
+		}
+		return start, anchor.AddDate(0, 0, k+days)
+	case Monthly, Yearly:
+		months := 1
+		if target == Yearly {
+			months = 12
+		}
+		if opts.Anchor.IsZero() {
+			anchor = time.Date(1970, 1, 1, 0, 0, 0, 0, loc)
+		}
+		n := (t.Year()-anchor.Year())*12 + int(t.Month()-anchor.Month())
+		k := floorDiv(n, months) * months
+		start := anchor.AddDate(0, k, 0)
+		if start.After(t) {
+			k -= months
+			start = anchor.AddDate(0, k, 0)
+		}
+		return start, anchor.AddDate(0, k+months, 0)
+	}
+
+	step := target.Duration()
+	if !opts.Anchor.IsZero() {
+		start := anchor.Add(time.Duration(floorDiv(int(t.Sub(anchor)), int(step))) * step)
+		return start, start.Add(step)
+	}
+	// elapsed time from midnight, so days with a dst change have an hourly
+	// bucket more or less
+	y, m, d := t.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	start := midnight.Add(t.Sub(midnight) / step * step)
+	end := start.Add(step)
+	if next := time.Date(y, m, d+1, 0, 0, 0, 0, loc); end.After(next) {
+		end = next
+	}
+	return start, end
+}
+
+// periodEnd - end of the bar of period p starting at t, by the calendar for
+// days and longer
+func periodEnd(t time.Time, p Period) time.Time {
+	switch p {
+	case Daily, Day3, Weekly:
+		return t.AddDate(0, 0, int(p.Duration()/(24*time.Hour)))
+	case Monthly:
+		return t.AddDate(0, 1, 0)
+	case Yearly:
+		return t.AddDate(1, 0, 0)
+	}
+	return t.Add(p.Duration())
+}
+
+// Resample - aggregate the bars of q to the coarser period target at natural
+// boundaries in the time zone of the bars, see ResampleWithOptions
+func (q Quote) Resample(target Period) (Quote, error) {
+	return q.ResampleWithOptions(target, DefaultResampleOptions)
+}
+
+// ResampleWithOptions - aggregate the bars of q, in date order, to the
+// coarser period target with buckets laid out as opts: the first open, the
+// highest high, the lowest low, the last close and the summed volume and
+// trades of the bars in a bucket, dated at its start. Adjusted prices are
+// joined alike, other optional columns keep their last value. The period of q is that of its metadata or else
+// inferred, an error if target is finer or intraday and not a multiple of it.
+func (q Quote) ResampleWithOptions(target Period, opts ResampleOptions) (Quote, error) {
+
+	step := target.Duration()
+	if step == 0 {
+		return Quote{}, fmt.Errorf("unknown period '%s'", target)
+	}
+	out := NewQuote(q.Symbol, 0)
+	out.Precision = q.Precision
+	if q.Meta != nil {
+		meta := *q.Meta
+		meta.Period = target
+		out.Meta = &meta
+	}
+	if len(q.Date) == 0 {
+		return out, nil
+	}
+	source := q.periodOrInfer("")
+	if len(q.Date) > 1 || source != "" {
+		switch {
+		case source.Duration() == 0:
+			return Quote{}, fmt.Errorf("%s: unknown period '%s'", q.Symbol, source)
+		case step < source.Duration():
+			return Quote{}, fmt.Errorf("%s: can not resample %s bars to finer %s bars", q.Symbol, source.Name(), target.Name())
+		case step < 24*time.Hour && step%source.Duration() != 0:
+			return Quote{}, fmt.Errorf("%s: %s is not a multiple of %s", q.Symbol, target.Name(), source.Name())
+		}
+	}
+
+	var end time.Time
+	for bar := range q.Date {
+		start, stop := opts.bucket(q.Date[bar], target)
+		n := len(out.Date)
+		if n == 0 || !start.Equal(out.Date[n-1]) {
+			b := q.Bar(bar)
+			b.Date = start
+			out.PushBar(b)
+			for _, x := range extraColumns {
+				if src := *x.col(&q); src != nil {
+					out.setExtra(x.col(&out), n, src[bar])
+				}
+			}
+			end = stop
+			continue
+		}
+		out.joinBar(q, bar)
+	}
+
+	if opts.DropPartial && periodEnd(q.Date[len(q.Date)-1], source).Before(end) {
+		out = out.slice(0, len(out.Date)-1)
+	}
+	return out, nil
+}
@@ -0,0 +1,162 @@
+package quote
+
+import (
+	"testing"
+	"time"
+)
+
+// resampleFixture - n bars of period from start, bar i opening at i with a
+// volume of 1
+func resampleFixture(start time.Time, period Period, n int) Quote {
+	q := NewQuote("btcusd", 0)
+	q.Meta = &Metadata{Symbol: "btcusd", Period: period}
+	for i := 0; i < n; i++ {
+		f := float64(i)
+		q.PushBar(Bar{Date: start.Add(time.Duration(i) * period.Duration()), Open: f, High: f + 0.5, Low: f - 0.5, Close: f + 0.25, Volume: 1})
+	}
+	return q
+}
+
+func TestResample(t *testing.T) {
+	q := resampleFixture(time.Date(2023, 5, 2, 9, 32, 0, 0, time.UTC), Min1, 12)
+	q.Trades = []float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	q.OpenInterest = []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+
+	m5, err := q.Resample(Min5)
+	ok(t, err)
+	equals(t, []time.Time{
+		time.Date(2023, 5, 2, 9, 30, 0, 0, time.UTC),
+		time.Date(2023, 5, 2, 9, 35, 0, 0, time.UTC),
+		time.Date(2023, 5, 2, 9, 40, 0, 0, time.UTC),
+	}, m5.Date)
+	equals(t, []float64{0, 3, 8}, m5.Open)
+	equals(t, []float64{2.5, 7.5, 11.5}, m5.High)
+	equals(t, []float64{-0.5, 2.5, 7.5}, m5.Low)
+	equals(t, []float64{2.25, 7.25, 11.25}, m5.Close)
+	equals(t, []float64{3, 5, 4}, m5.Volume)
+	equals(t, []float64{3, 5, 4}, m5.Trades)
+	equals(t, []float64{2, 7, 11}, m5.OpenInterest)
+	assert(t, m5.AdjOpen == nil, "resampled quote gained adjusted prices")
+	equals(t, Min5, m5.Meta.Period)
+	equals(t, Min1, q.Meta.Period)
+
+	// 09:40 lacks 09:44, the bars up to 09:39 fill theirs
+	m5, err = q.ResampleWithOptions(Min5, ResampleOptions{DropPartial: true})
+	ok(t, err)
+	equals(t, 2, m5.Len())
+	m5, err = q.slice(0, 8).ResampleWithOptions(Min5, ResampleOptions{DropPartial: true})
+	ok(t, err)
+	equals(t, 2, m5.Len())
+
+	h1, err := q.Resample(Min60)
+	ok(t, err)
+	equals(t, []Bar{{Date: time.Date(2023, 5, 2, 9, 0, 0, 0, time.UTC), Open: 0, High: 11.5, Low: -0.5, Close: 11.25, Volume: 12}}, h1.Bars())
+
+	_, err = h1.Resample(Min5)
+	assert(t, err != nil, "resampled to a finer period")
+	m3 := resampleFixture(q.Date[0], Min3, 4)
+	_, err = m3.Resample(Min5)
+	assert(t, err != nil, "resampled to a period that is not a multiple")
+	_, err = q.Resample("7")
+	assert(t, err != nil, "resampled to an unknown period")
+
+	// adjusted prices are joined as the prices are
+	q.AdjClose = q.Close
+	adj := q.withAdjustedPrices()
+	m5, err = adj.Resample(Min5)
+	ok(t, err)
+	equals(t, m5.Open, m5.AdjOpen)
+	equals(t, m5.High, m5.AdjHigh)
+	equals(t, m5.Low, m5.AdjLow)
+	equals(t, m5.Close, m5.AdjClose)
+
+	empty, err := NewQuote("spy", 0).Resample(Daily)
+	ok(t, err)
+	equals(t, 0, empty.Len())
+}
+
+func TestResampleCalendar(t *testing.T) {
+	// wednesday 2023-03-29 to friday 2023-04-14
+	d := resampleFixture(day(2023, 3, 29), Daily, 17)
+
+	w, err := d.Resample(Weekly)
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 3, 27), day(2023, 4, 3), day(2023, 4, 10)}, w.Date)
+	equals(t, []float64{0, 5, 12}, w.Open)
+	equals(t, []float64{5, 7, 5}, w.Volume)
+	w, err = d.ResampleWithOptions(Weekly, ResampleOptions{DropPartial: true})
+	ok(t, err)
+	equals(t, 2, w.Len())
+
+	m, err := d.Resample(Monthly)
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 3, 1), day(2023, 4, 1)}, m.Date)
+	equals(t, []float64{3, 14}, m.Volume)
+
+	// weeks anchored on sundays
+	w, err = d.ResampleWithOptions(Weekly, ResampleOptions{Anchor: day(2023, 1, 1)})
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 3, 26), day(2023, 4, 2), day(2023, 4, 9)}, w.Date)
+
+	// monthly bars to years
+	mo := resampleFixture(day(2022, 1, 1), Monthly, 24)
+	for i := range mo.Date {
+		mo.Date[i] = day(2022, 1, 1).AddDate(0, i, 0)
+	}
+	y, err := mo.ResampleWithOptions(Yearly, ResampleOptions{DropPartial: true})
+	ok(t, err)
+	equals(t, []float64{12, 12}, y.Volume)
+}
+
+func TestResampleDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	ok(t, err)
+
+	// half hours from midnight on the day clocks fall back, 01:00 and 01:30
+	// come twice
+	q := resampleFixture(time.Date(2023, 11, 5, 0, 0, 0, 0, ny), Min30, 8)
+	h, err := q.Resample(Min60)
+	ok(t, err)
+	equals(t, 4, h.Len())
+	equals(t, []float64{2, 2, 2, 2}, h.Volume)
+	equals(t, []string{"00:00 EDT", "01:00 EDT", "01:00 EST", "02:00 EST"}, []string{
+		h.Date[0].Format("15:04 MST"), h.Date[1].Format("15:04 MST"), h.Date[2].Format("15:04 MST"), h.Date[3].Format("15:04 MST"),
+	})
+
+	// hourly bars over the days clocks spring forward and fall back
+	for _, c := range []struct {
+		start time.Time
+		hours []float64
+	}{
+		{time.Date(2023, 3, 11, 0, 0, 0, 0, ny), []float64{24, 23, 24, 1}},
+		{time.Date(2023, 11, 4, 0, 0, 0, 0, ny), []float64{24, 25, 23}},
+	} {
+		q = resampleFixture(c.start, Min60, 24*3)
+		d, err := q.Resample(Daily)
+		ok(t, err)
+		equals(t, c.hours, d.Volume)
+		for i, date := range d.Date {
+			equals(t, c.start.AddDate(0, 0, i), date)
+		}
+
+		// the same bars as utc, bucketed in new york
+		utc := q.copy()
+		for i := range utc.Date {
+			utc.Date[i] = utc.Date[i].UTC()
+		}
+		d, err = utc.ResampleWithOptions(Daily, ResampleOptions{Location: ny})
+		ok(t, err)
+		equals(t, c.hours, d.Volume)
+	}
+
+	// forex days from 17:00 new york, across the spring forward
+	q = resampleFixture(time.Date(2023, 3, 11, 12, 0, 0, 0, ny), Min60, 48)
+	d, err := q.ResampleWithOptions(Daily, ResampleOptions{Anchor: time.Date(2023, 1, 2, 17, 0, 0, 0, ny)})
+	ok(t, err)
+	equals(t, []time.Time{
+		time.Date(2023, 3, 10, 17, 0, 0, 0, ny),
+		time.Date(2023, 3, 11, 17, 0, 0, 0, ny),
+		time.Date(2023, 3, 12, 17, 0, 0, 0, ny),
+	}, d.Date)
+	equals(t, []float64{5, 23, 20}, d.Volume)
+}
@@ -0,0 +1,50 @@
+package quote
+
+import "math"
+
+// returns - f of each pair of consecutive closes of q, NaN where either close
+// is zero or negative, and the number of NaN
+func (q Quote) returns(f func(prev, next float64) float64) ([]float64, int) {
+	if len(q.Close) < 2 {
+		return []float64{}, 0
+	}
+	r := make([]float64, len(q.Close)-1)
+	bad := 0
+	for bar := 1; bar < len(q.Close); bar++ {
+		prev, next := q.Close[bar-1], q.Close[bar]
+		if prev <= 0 || next <= 0 {
+			r[bar-1] = math.NaN()
+			bad++
+			continue
+		}
+		r[bar-1] = f(prev, next)
+	}
+	return r, bad
+}
+
+// Returns - arithmetic close to close returns of q, Len()-1 of them, the
+// return at i from bar i to bar i+1. Returns touching a zero or negative
+// close are NaN rather than left out, and counted by the second value.
+func (q Quote) Returns() ([]float64, int) {
+	return q.returns(func(prev, next float64) float64 { return next/prev - 1 })
+}
+
+// LogReturns - natural log close to close returns of q, laid out as Returns
+// with NaN where a close is zero or negative
+func (q Quote) LogReturns() ([]float64, int) {
+	return q.returns(func(prev, next float64) float64 { return math.Log(next / prev) })
+}
+
+// CumulativeReturn - return from the first close of q to the last, the
+// compounded Returns. NaN if any close is zero or negative, 0 for fewer than
+// two bars.
+func (q Quote) CumulativeReturn() float64 {
+	r, bad := q.Returns()
+	if bad > 0 {
+		return math.NaN()
+	}
+	if len(r) == 0 {
+		return 0
+	}
+	return q.Close[len(q.Close)-1]/q.Close[0] - 1
+}
@@ -0,0 +1,55 @@
+package quote
+
+import (
+	"math"
+	"testing"
+)
+
+func TestReturns(t *testing.T) {
+	q := closesFixture(100, 110, 99, 99, 123.75)
+
+	r, bad := q.Returns()
+	equals(t, 0, bad)
+	equals(t, q.Len()-1, len(r))
+	for i, want := range []float64{0.1, -0.1, 0, 0.25} {
+		near(t, want, r[i], "return")
+	}
+
+	l, bad := q.LogReturns()
+	equals(t, 0, bad)
+	for i, want := range []float64{0.0953101798, -0.1053605157, 0, 0.2231435513} {
+		near(t, want, l[i], "log return")
+	}
+	sum := 0.0
+	for _, x := range l {
+		sum += x
+	}
+	near(t, math.Log(123.75/100), sum, "summed log returns")
+
+	near(t, 0.2375, q.CumulativeReturn(), "cumulative return")
+	compounded := 1.0
+	for _, x := range r {
+		compounded *= 1 + x
+	}
+	near(t, compounded-1, q.CumulativeReturn(), "compounded returns")
+}
+
+func TestReturnsZeroClose(t *testing.T) {
+	q := closesFixture(100, 0, 50, 55, -1)
+	r, bad := q.Returns()
+	equals(t, 4, len(r))
+	equals(t, 3, bad)
+	assert(t, math.IsNaN(r[0]) && math.IsNaN(r[1]) && math.IsNaN(r[3]), "returns touching zero or negative closes not NaN: %v", r)
+	near(t, 0.1, r[2], "return")
+	l, bad := q.LogReturns()
+	equals(t, 3, bad)
+	near(t, math.Log(1.1), l[2], "log return")
+	assert(t, math.IsNaN(q.CumulativeReturn()), "cumulative return over a zero close")
+
+	for _, short := range []Quote{NewQuote("spy", 0), closesFixture(100)} {
+		r, bad = short.Returns()
+		equals(t, []float64{}, r)
+		equals(t, 0, bad)
+		equals(t, 0.0, short.CumulativeReturn())
+	}
+}
@@ -0,0 +1,85 @@
+package quote
+
+import (
+	"sort"
+	"time"
+)
+
+// KeepPolicy - which of the bars sharing a date Dedupe keeps
+type KeepPolicy int
+
+const (
+	// KeepFirst - keep the first of the bars sharing a date
+	KeepFirst KeepPolicy = iota
+	// KeepLast - keep the last of the bars sharing a date, e.g. the later
+	// and complete copy of a candle repeated across pages
+	KeepLast
+)
+
+// Sort - sort the bars of q, including optional columns, by ascending date
+// in place. Bars sharing a date keep their order.
+func (q *Quote) Sort() {
+	if sort.SliceIsSorted(q.Date, func(i, j int) bool { return q.Date[i].Before(q.Date[j]) }) {
+		return
+	}
+	order := make([]int, len(q.Date))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return q.Date[order[i]].Before(q.Date[order[j]]) })
+
+	dates := make([]time.Time, len(order))
+	for i, bar := range order {
+		dates[i] = q.Date[bar]
+	}
+	copy(q.Date, dates)
+	values := make([]float64, len(order))
+	for _, col := range q.columns() {
+		if *col == nil {
+			continue
+		}
+		for i, bar := range order {
+			values[i] = (*col)[bar]
+		}
+		copy(*col, values)
+	}
+}
+
+// Dedupe - collapse runs of bars sharing a date into the bar chosen by keep,
+// in place, and return the number of bars removed. Bars of a date must be
+// adjacent, as they are after Sort.
+func (q *Quote) Dedupe(keep KeepPolicy) int {
+	cols := q.columns()
+	n := 0
+	for bar := range q.Date {
+		if n > 0 && q.Date[bar].Equal(q.Date[n-1]) {
+			if keep == KeepFirst {
+				continue
+			}
+			n--
+		}
+		q.Date[n] = q.Date[bar]
+		for _, col := range cols {
+			if *col != nil {
+				(*col)[n] = (*col)[bar]
+			}
+		}
+		n++
+	}
+
+	removed := len(q.Date) - n
+	q.Date = q.Date[:n]
+	for _, col := range cols {
+		if *col != nil {
+			*col = (*col)[:n]
+		}
+	}
+	return removed
+}
+
+// SortBars - Sort the bars of each member of q
+func (q Quotes) SortBars() {
+	for i := range q {
+		q[i].Sort()
+	}
+}
@@ -0,0 +1,82 @@
+package quote
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// sortFixture - n minute bars from 2023-03-01 with every third bar
+// repeated, bar i closing at i and the repeat at i plus 0.5, shuffled
+func sortFixture(rnd *rand.Rand, n int) Quote {
+	q := NewQuote("btcusd", 0)
+	for i := 0; i < n; i++ {
+		date := time.Date(2023, 3, 1, 0, i, 0, 0, time.UTC)
+		q.PushBar(Bar{Date: date, Close: float64(i)})
+		if i%3 == 0 {
+			q.PushBar(Bar{Date: date, Close: float64(i) + 0.5})
+		}
+	}
+	q.Trades = make([]float64, len(q.Date))
+	copy(q.Trades, q.Close)
+	rnd.Shuffle(len(q.Date), func(i, j int) {
+		q.Date[i], q.Date[j] = q.Date[j], q.Date[i]
+		q.Close[i], q.Close[j] = q.Close[j], q.Close[i]
+		q.Trades[i], q.Trades[j] = q.Trades[j], q.Trades[i]
+	})
+	return q
+}
+
+func TestSortDedupe(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for run := 0; run < 50; run++ {
+		n := rnd.Intn(40)
+		q := sortFixture(rnd, n)
+		bars := len(q.Date)
+
+		q.Sort()
+		equals(t, bars, len(q.Date))
+		for bar := 1; bar < len(q.Date); bar++ {
+			assert(t, !q.Date[bar].Before(q.Date[bar-1]), "bar %d out of order", bar)
+		}
+		// columns move with their dates
+		for bar := range q.Date {
+			equals(t, float64(q.Date[bar].Minute()), float64(int(q.Close[bar])))
+			equals(t, q.Close[bar], q.Trades[bar])
+		}
+
+		first, last := q.copy(), q.copy()
+		equals(t, bars-n, first.Dedupe(KeepFirst))
+		equals(t, bars-n, last.Dedupe(KeepLast))
+		equals(t, n, len(first.Date))
+		equals(t, n, len(last.Trades))
+		for bar := 0; bar < n; bar++ {
+			equals(t, time.Date(2023, 3, 1, 0, bar, 0, 0, time.UTC), first.Date[bar])
+			equals(t, first.Date, last.Date)
+			equals(t, first.Close[bar] == last.Close[bar], bar%3 != 0)
+		}
+		equals(t, 0, last.Dedupe(KeepLast))
+	}
+}
+
+func TestSortStable(t *testing.T) {
+	q := NewQuote("spy", 0)
+	for _, b := range []Bar{
+		{Date: day(2023, 1, 3), Close: 1},
+		{Date: day(2023, 1, 2), Close: 2},
+		{Date: day(2023, 1, 3), Close: 3},
+		{Date: day(2023, 1, 2), Close: 4},
+	} {
+		q.PushBar(b)
+	}
+	q.Sort()
+	equals(t, []float64{2, 4, 1, 3}, q.Close)
+	equals(t, []float64(nil), q.Trades)
+	equals(t, 2, q.Dedupe(KeepFirst))
+	equals(t, []float64{2, 1}, q.Close)
+
+	quotes := Quotes{mergeFixture(100, 3, 2), mergeFixture(100, 5, 4, 6)}
+	quotes.SortBars()
+	equals(t, []float64{102, 103}, quotes[0].Close)
+	equals(t, []float64{104, 105, 106}, quotes[1].Close)
+}
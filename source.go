@@ -0,0 +1,199 @@
+package quote
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SourceSpec - identifies a quote source and its settings
+type SourceSpec struct {
+	Name    string // yahoo, tiingo, tiingo-crypto, tiingo-fx, coinbase, bittrex, binance, binance-futures, alphavantage, stooq, polygon, iex, finnhub, alpaca, huobi, cryptocompare, coingecko, fred, ecb, moex or deribit
+	Token   string // api token, for sources that need one, keyID:secret for alpaca
+	Adjust  bool   // request adjusted prices, for sources that support both
+	PrePost bool   // include pre-market and after hours intraday bars, yahoo only
+}
+
+// Adjustment - describe the price adjustment the source applies
+func (s SourceSpec) Adjustment() string {
+	switch s.Name {
+	case "yahoo", "iex":
+		if s.Adjust {
+			return "adjusted"
+		}
+		return "raw"
+	case "tiingo":
+		return "adjusted"
+	case "alphavantage", "alpaca":
+		return "raw"
+	case "stooq", "polygon", "finnhub":
+		return "adjusted"
+	}
+	return "none"
+}
+
+// Calendar - trading calendar of the source, weekdays for stock and forex
+// sources and every day for crypto sources
+func (s SourceSpec) Calendar() Calendar {
+	switch s.Name {
+	case "yahoo", "tiingo", "tiingo-fx", "alphavantage", "stooq", "polygon", "iex", "finnhub", "alpaca", "fred", "ecb", "moex":
+		return WeekdaysOnly
+	}
+	return AllDays
+}
+
+// String - source name
+func (s SourceSpec) String() string {
+	return s.Name
+}
+
+// sourcePeriods - periods supported by each source, in registration order
+var sourcePeriods = []struct {
+	name    string
+	periods []Period
+}{
+	{"yahoo", yahooPeriods},
+	{"tiingo", tiingoPeriods},
+	{"tiingo-crypto", tiingoCryptoPeriods},
+	{"tiingo-fx", tiingoFXPeriods},
+	{"coinbase", coinbasePeriods},
+	{"bittrex", bittrexPeriods},
+	{"binance", binancePeriods},
+	{"binance-futures", binanceFuturesPeriods},
+	{"alphavantage", alphaVantagePeriods},
+	{"stooq", stooqPeriods},
+	{"polygon", polygonPeriods},
+	{"iex", iexPeriods},
+	{"finnhub", finnhubPeriods},
+	{"alpaca", alpacaPeriods},
+	{"huobi", huobiPeriods},
+	{"cryptocompare", cryptoComparePeriods},
+	{"coingecko", coinGeckoPeriods},
+	{"fred", fredPeriods},
+	{"ecb", ecbPeriods},
+	{"moex", moexPeriods},
+	{"deribit", deribitPeriods},
+}
+
+// Sources - names of the supported quote sources
+func Sources() []string {
+	names := make([]string, len(sourcePeriods))
+	for i, src := range sourcePeriods {
+		names[i] = src.name
+	}
+	return names
+}
+
+// SupportedPeriods - periods supported by source, nil for an unknown source
+func SupportedPeriods(source string) []Period {
+	for _, src := range sourcePeriods {
+		if src.name == source {
+			return append([]Period(nil), src.periods...)
+		}
+	}
+	return nil
+}
+
+// ValidatePeriod - check that source supports period p, the error lists the
+// periods that it does support
+func ValidatePeriod(source string, p Period) error {
+	periods := SupportedPeriods(source)
+	if periods == nil {
+		return fmt.Errorf("invalid source '%s', must be one of %s", source, strings.Join(Sources(), ", "))
+	}
+	names := make([]string, len(periods))
+	for i, period := range periods {
+		if period == p {
+			return nil
+		}
+		names[i] = period.Name()
+	}
+	return fmt.Errorf("invalid period '%s' for %s, must be one of %s", p.Name(), source, strings.Join(names, ", "))
+}
+
+// NewQuoteFromSource - historical prices for a symbol from the source described
+// by spec. Concurrent identical requests share a single download.
+func NewQuoteFromSource(ctx context.Context, spec SourceSpec, symbol string, from, to time.Time, period Period) (Quote, error) {
+
+	if err := ctx.Err(); err != nil {
+		return NewQuote("", 0), err
+	}
+	return coalesce(ctx, flightKey(spec, symbol, from, to, period), func() (Quote, error) {
+		return fetchSource(ctx, spec, symbol, from, to, period)
+	})
+}
+
+// fetchSource - download for NewQuoteFromSource, canceled with ctx, replaced
+// in tests
+var fetchSource = func(ctx context.Context, spec SourceSpec, symbol string, from, to time.Time, period Period) (Quote, error) {
+
+	startDate := from.Format("2006-01-02 15:04")
+	endDate := to.Format("2006-01-02 15:04")
+
+	switch spec.Name {
+	case "yahoo":
+		opts := DefaultYahooOptions
+		opts.PrePost = spec.PrePost
+		return NewQuoteFromYahooWithOptionsCtx(ctx, symbol, startDate, endDate, period, spec.Adjust, opts)
+	case "tiingo":
+		return NewQuoteFromTiingoCtx(ctx, symbol, startDate, endDate, period, spec.Token)
+	case "tiingo-crypto":
+		return NewQuoteFromTiingoCryptoCtx(ctx, symbol, startDate, endDate, period, spec.Token)
+	case "tiingo-fx":
+		return NewQuoteFromTiingoForexCtx(ctx, symbol, startDate, endDate, period, spec.Token)
+	case "coinbase":
+		return NewQuoteFromCoinbaseCtx(ctx, symbol, startDate, endDate, period)
+	case "bittrex":
+		return NewQuoteFromBittrexCtx(ctx, symbol, startDate, endDate, period)
+	case "binance":
+		return NewQuoteFromBinanceCtx(ctx, symbol, startDate, endDate, period)
+	case "binance-futures":
+		return NewQuoteFromBinanceFuturesCtx(ctx, symbol, startDate, endDate, period)
+	case "alphavantage":
+		return NewQuoteFromAlphaVantageCtx(ctx, symbol, startDate, endDate, period, spec.Token)
+	case "stooq":
+		return NewQuoteFromStooqCtx(ctx, symbol, startDate, endDate)
+	case "polygon":
+		return NewQuoteFromPolygonCtx(ctx, symbol, startDate, endDate, period, spec.Token)
+	case "iex":
+		raw, adjusted, err := NewQuotePairFromIEXCtx(ctx, symbol, startDate, endDate, period, spec.Token)
+		if spec.Adjust {
+			return adjusted, err
+		}
+		return raw, err
+	case "finnhub":
+		return NewQuoteFromFinnhubCtx(ctx, symbol, startDate, endDate, period, spec.Token)
+	case "alpaca":
+		keyID, secret := alpacaKeys(spec.Token)
+		return NewQuoteFromAlpacaCtx(ctx, symbol, startDate, endDate, period, keyID, secret)
+	case "huobi":
+		return NewQuoteFromHuobiRangeCtx(ctx, symbol, startDate, endDate, period)
+	case "cryptocompare":
+		fsym, tsym, err := cryptoComparePair(symbol)
+		if err != nil {
+			return NewQuote("", 0), err
+		}
+		return NewQuoteFromCryptoCompareCtx(ctx, fsym, tsym, startDate, endDate, period, spec.Token)
+	case "coingecko":
+		coinID, vsCurrency, err := coinGeckoPair(symbol)
+		if err != nil {
+			return NewQuote("", 0), err
+		}
+		q, err := NewQuoteFromCoinGeckoCtx(ctx, coinID, vsCurrency, startDate, endDate)
+		if err == nil && q.Meta.Period != period {
+			// the candle size follows from the range
+			return NewQuote("", 0), fmt.Errorf("coingecko has no %s bars from %s, 30m covers the last day and 4h the last 30 days", period.Name(), startDate)
+		}
+		return q, err
+	case "fred":
+		return NewQuoteFromFREDCtx(ctx, symbol, startDate, endDate, spec.Token)
+	case "ecb":
+		return NewQuoteFromECBCtx(ctx, symbol, startDate, endDate)
+	case "moex":
+		return NewQuoteFromMOEXCtx(ctx, symbol, startDate, endDate, period)
+	case "deribit":
+		return NewQuoteFromDeribitCtx(ctx, symbol, startDate, endDate, period)
+	}
+	return NewQuote("", 0), fmt.Errorf("invalid source '%s'", spec.Name)
+}
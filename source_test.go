@@ -0,0 +1,226 @@
+package quote
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSupportedPeriods(t *testing.T) {
+	tests := []struct {
+		source  string
+		valid   []Period
+		invalid []Period
+	}{
+		{"yahoo", []Period{Min1, Min5, Min60, Daily, Weekly, Monthly}, []Period{Min3, Hour4, Day3}},
+		{"tiingo", []Period{Daily, Weekly, Monthly, Yearly}, []Period{Min5, Day3}},
+		{"tiingo-crypto", []Period{Min1, Min3, Hour6, Hour12, Daily}, []Period{Day3, Weekly}},
+		{"tiingo-fx", []Period{Min1, Min5, Hour4, Daily}, []Period{Day3, Weekly}},
+		{"coinbase", []Period{Min1, Min15, Hour6, Daily}, []Period{Min30, Weekly}},
+		{"bittrex", []Period{Min1, Min60, Daily}, []Period{Min3, Min30, Weekly}},
+		{"binance", []Period{Min3, Hour6, Day3, Weekly, Monthly}, []Period{Period("7m")}},
+		{"binance-futures", []Period{Min1, Hour8, Monthly}, []Period{Yearly}},
+		{"alphavantage", []Period{Min1, Min60, Daily}, []Period{Min3, Weekly}},
+		{"stooq", []Period{Daily}, []Period{Min60, Weekly}},
+		{"polygon", []Period{Min1, Hour4, Daily, Yearly}, []Period{Period("7m")}},
+		{"iex", []Period{Min1, Daily}, []Period{Min5, Weekly}},
+		{"finnhub", []Period{Min1, Min60, Daily, Monthly}, []Period{Min3, Yearly}},
+		{"alpaca", []Period{Min1, Min60, Daily}, []Period{Min30, Weekly}},
+		{"huobi", []Period{Min1, Hour4, Monthly}, []Period{Hour2, Yearly}},
+		{"cryptocompare", []Period{Min1, Min30, Hour12, Day3}, []Period{Weekly, Monthly}},
+		{"coingecko", []Period{Min30, Hour4}, []Period{Min60, Daily}},
+		{"fred", []Period{Daily, Monthly}, []Period{Min60, Day3}},
+		{"ecb", []Period{Daily}, []Period{Min60, Weekly}},
+		{"moex", []Period{Min1, Min60, Monthly}, []Period{Min5, Yearly}},
+		{"deribit", []Period{Min1, Min3, Hour12, Daily}, []Period{Hour4, Weekly}},
+	}
+	equals(t, len(tests), len(Sources()))
+	for i, tt := range tests {
+		equals(t, tt.source, Sources()[i])
+		assert(t, len(SupportedPeriods(tt.source)) > 0, "%s: no periods", tt.source)
+		for _, p := range tt.valid {
+			ok(t, ValidatePeriod(tt.source, p))
+		}
+		for _, p := range tt.invalid {
+			err := ValidatePeriod(tt.source, p)
+			assert(t, err != nil, "%s: period %s accepted", tt.source, p.Name())
+			assert(t, strings.Contains(err.Error(), "must be one of "+SupportedPeriods(tt.source)[0].Name()), "%s: %v", tt.source, err)
+		}
+	}
+
+	assert(t, SupportedPeriods("nope") == nil, "periods for unknown source")
+	err := ValidatePeriod("nope", Daily)
+	assert(t, err != nil && strings.Contains(err.Error(), "yahoo, tiingo"), "bad error %v", err)
+}
+
+func TestFetcherRejectsPeriod(t *testing.T) {
+	// fails before any request is made
+	_, err := NewQuoteFromYahoo("spy", "2023-01-01", "2023-02-01", Hour4, true)
+	equals(t, "invalid period '4h' for yahoo, must be one of 1m, 5m, 15m, 30m, 1h, d, w, m, y", err.Error())
+	_, err = NewQuoteFromBittrex("ETH-BTC", "2023-01-01", "2023-02-01", Weekly)
+	assert(t, err != nil && strings.Contains(err.Error(), "1m, 5m, 1h, d"), "bad error %v", err)
+	_, err = NewQuoteFromCoinbase("BTC-USD", "2023-01-01", "2023-02-01", Min30)
+	assert(t, err != nil && strings.Contains(err.Error(), "for coinbase"), "bad error %v", err)
+}
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		s      string
+		period Period
+		d      time.Duration
+	}{
+		{"1m", Min1, time.Minute},
+		{"60", Min1, time.Minute},
+		{"3m", Min3, 3 * time.Minute},
+		{"5m", Min5, 5 * time.Minute},
+		{"300", Min5, 5 * time.Minute},
+		{"15m", Min15, 15 * time.Minute},
+		{"30m", Min30, 30 * time.Minute},
+		{"1h", Min60, time.Hour},
+		{"2h", Hour2, 2 * time.Hour},
+		{"4h", Hour4, 4 * time.Hour},
+		{"6h", Hour6, 6 * time.Hour},
+		{"8h", Hour8, 8 * time.Hour},
+		{"12h", Hour12, 12 * time.Hour},
+		{"d", Daily, 24 * time.Hour},
+		{"1d", Daily, 24 * time.Hour},
+		{"3d", Day3, 72 * time.Hour},
+		{"w", Weekly, 7 * 24 * time.Hour},
+		{"1w", Weekly, 7 * 24 * time.Hour},
+		{"m", Monthly, 30 * 24 * time.Hour},
+		{"1M", Monthly, 30 * 24 * time.Hour},
+		{"y", Yearly, 365 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		p, err := ParsePeriod(tt.s)
+		ok(t, err)
+		equals(t, tt.period, p)
+		equals(t, tt.d, p.Duration())
+		// names round trip
+		named, err := ParsePeriod(p.Name())
+		ok(t, err)
+		equals(t, p, named)
+	}
+	for _, s := range []string{"", "2m", "1y", "D", "1H"} {
+		_, err := ParsePeriod(s)
+		assert(t, err != nil && strings.Contains(err.Error(), "must be one of 1m, 3m, 5m"), "%q: bad error %v", s, err)
+	}
+	equals(t, time.Duration(0), Period("7m").Duration())
+}
+
+func TestSourceIntervals(t *testing.T) {
+	// native granularity of every supported period of every source
+	native := map[string]func(Period) (string, bool){
+		"yahoo":         func(p Period) (string, bool) { s, ok := yahooIntervals[p]; return s, ok },
+		"tiingo":        func(p Period) (string, bool) { s, ok := tiingoFreqs[p]; return s, ok },
+		"tiingo-crypto": func(p Period) (string, bool) { s, ok := tiingoCryptoFreqs[p]; return s, ok },
+		"tiingo-fx":     func(p Period) (string, bool) { s, ok := tiingoCryptoFreqs[p]; return s, ok },
+		"coinbase": func(p Period) (string, bool) {
+			g := coinbaseGranularity(p)
+			return fmt.Sprint(g), g > 0
+		},
+		"bittrex":         func(p Period) (string, bool) { s, ok := bittrexIntervals[p]; return s, ok },
+		"binance":         func(p Period) (string, bool) { s, ok := binanceIntervals[p]; return s, ok },
+		"binance-futures": func(p Period) (string, bool) { s, ok := binanceIntervals[p]; return s, ok },
+		"alphavantage": func(p Period) (string, bool) {
+			if p == Daily {
+				return "TIME_SERIES_DAILY_ADJUSTED", true
+			}
+			s, ok := alphaVantageIntervals[p]
+			return s, ok
+		},
+		"stooq":   func(p Period) (string, bool) { return "d", p == Daily },
+		"alpaca":  func(p Period) (string, bool) { tf, ok := alpacaTimeframes[p]; return tf, ok },
+		"deribit": func(p Period) (string, bool) { r, ok := deribitResolutions[p]; return r, ok },
+		"moex":    func(p Period) (string, bool) { i, ok := moexIntervals[p]; return fmt.Sprint(i), ok },
+		"ecb":     func(p Period) (string, bool) { return "d", p == Daily },
+		"fred": func(p Period) (string, bool) {
+			return "native", p == Daily || p == Weekly || p == Monthly || p == Yearly
+		},
+		"coingecko": func(p Period) (string, bool) { return "ohlc", p == Min30 || p == Hour4 },
+		"cryptocompare": func(p Period) (string, bool) {
+			h, ok := cryptoCompareHistos[p]
+			return fmt.Sprintf("%s/%d", h.endpoint, h.aggregate), ok
+		},
+		"huobi":   func(p Period) (string, bool) { i, ok := huobiIntervals[p]; return i, ok },
+		"finnhub": func(p Period) (string, bool) { r, ok := finnhubResolutions[p]; return r, ok },
+		"iex":     func(p Period) (string, bool) { return "chart", p == Min1 || p == Daily },
+		"polygon": func(p Period) (string, bool) {
+			r, ok := polygonRanges[p]
+			return fmt.Sprintf("%d/%s", r.multiplier, r.timespan), ok
+		},
+	}
+	tests := []struct {
+		source string
+		period Period
+		native string
+	}{
+		{"yahoo", Min1, "1m"}, {"yahoo", Min5, "5m"}, {"yahoo", Min15, "15m"}, {"yahoo", Min30, "30m"},
+		{"yahoo", Min60, "60m"}, {"yahoo", Daily, "1d"}, {"yahoo", Weekly, "1wk"}, {"yahoo", Monthly, "1mo"},
+		{"yahoo", Yearly, "1mo"},
+		{"tiingo", Daily, "daily"}, {"tiingo", Weekly, "weekly"}, {"tiingo", Monthly, "monthly"}, {"tiingo", Yearly, "annually"},
+		{"tiingo-crypto", Min1, "1min"}, {"tiingo-crypto", Min3, "3min"}, {"tiingo-crypto", Min5, "5min"},
+		{"tiingo-crypto", Min15, "15min"}, {"tiingo-crypto", Min30, "30min"}, {"tiingo-crypto", Min60, "1hour"},
+		{"tiingo-crypto", Hour2, "2hour"}, {"tiingo-crypto", Hour4, "4hour"}, {"tiingo-crypto", Hour6, "6hour"},
+		{"tiingo-crypto", Hour8, "8hour"}, {"tiingo-crypto", Hour12, "12hour"}, {"tiingo-crypto", Daily, "1day"},
+		{"tiingo-fx", Min1, "1min"}, {"tiingo-fx", Min3, "3min"}, {"tiingo-fx", Min5, "5min"},
+		{"tiingo-fx", Min15, "15min"}, {"tiingo-fx", Min30, "30min"}, {"tiingo-fx", Min60, "1hour"},
+		{"tiingo-fx", Hour2, "2hour"}, {"tiingo-fx", Hour4, "4hour"}, {"tiingo-fx", Hour6, "6hour"},
+		{"tiingo-fx", Hour8, "8hour"}, {"tiingo-fx", Hour12, "12hour"}, {"tiingo-fx", Daily, "1day"},
+		{"coinbase", Min1, "60"}, {"coinbase", Min5, "300"}, {"coinbase", Min15, "900"},
+		{"coinbase", Min60, "3600"}, {"coinbase", Hour6, "21600"}, {"coinbase", Daily, "86400"},
+		{"bittrex", Min1, "MINUTE_1"}, {"bittrex", Min5, "MINUTE_5"}, {"bittrex", Min60, "HOUR_1"},
+		{"bittrex", Daily, "DAY_1"},
+		{"binance", Min1, "1m"}, {"binance", Min3, "3m"}, {"binance", Min5, "5m"}, {"binance", Min15, "15m"},
+		{"binance", Min30, "30m"}, {"binance", Min60, "1h"}, {"binance", Hour2, "2h"}, {"binance", Hour4, "4h"},
+		{"binance", Hour6, "6h"}, {"binance", Hour8, "8h"}, {"binance", Hour12, "12h"}, {"binance", Daily, "1d"},
+		{"binance", Day3, "3d"}, {"binance", Weekly, "1w"}, {"binance", Monthly, "1M"},
+		{"binance-futures", Min1, "1m"}, {"binance-futures", Min3, "3m"}, {"binance-futures", Min5, "5m"},
+		{"binance-futures", Min15, "15m"}, {"binance-futures", Min30, "30m"}, {"binance-futures", Min60, "1h"},
+		{"binance-futures", Hour2, "2h"}, {"binance-futures", Hour4, "4h"}, {"binance-futures", Hour6, "6h"},
+		{"binance-futures", Hour8, "8h"}, {"binance-futures", Hour12, "12h"}, {"binance-futures", Daily, "1d"},
+		{"binance-futures", Day3, "3d"}, {"binance-futures", Weekly, "1w"}, {"binance-futures", Monthly, "1M"},
+		{"alphavantage", Min1, "1min"}, {"alphavantage", Min5, "5min"}, {"alphavantage", Min15, "15min"},
+		{"alphavantage", Min30, "30min"}, {"alphavantage", Min60, "60min"}, {"alphavantage", Daily, "TIME_SERIES_DAILY_ADJUSTED"},
+		{"stooq", Daily, "d"},
+		{"polygon", Min1, "1/minute"}, {"polygon", Min3, "3/minute"}, {"polygon", Min5, "5/minute"},
+		{"polygon", Min15, "15/minute"}, {"polygon", Min30, "30/minute"}, {"polygon", Min60, "1/hour"},
+		{"polygon", Hour2, "2/hour"}, {"polygon", Hour4, "4/hour"}, {"polygon", Hour6, "6/hour"},
+		{"polygon", Hour8, "8/hour"}, {"polygon", Hour12, "12/hour"}, {"polygon", Daily, "1/day"},
+		{"polygon", Day3, "3/day"}, {"polygon", Weekly, "1/week"}, {"polygon", Monthly, "1/month"},
+		{"polygon", Yearly, "1/year"},
+		{"iex", Min1, "chart"}, {"iex", Daily, "chart"},
+		{"finnhub", Min1, "1"}, {"finnhub", Min5, "5"}, {"finnhub", Min15, "15"}, {"finnhub", Min30, "30"},
+		{"alpaca", Min1, "1Min"}, {"alpaca", Min5, "5Min"}, {"alpaca", Min15, "15Min"}, {"alpaca", Min60, "1Hour"},
+		{"alpaca", Daily, "1Day"},
+		{"huobi", Min1, "1min"}, {"huobi", Min5, "5min"}, {"huobi", Min15, "15min"}, {"huobi", Min30, "30min"},
+		{"huobi", Min60, "60min"}, {"huobi", Hour4, "4hour"}, {"huobi", Daily, "1day"}, {"huobi", Weekly, "1week"},
+		{"huobi", Monthly, "1mon"},
+		{"cryptocompare", Min1, "histominute/1"}, {"cryptocompare", Min3, "histominute/3"},
+		{"cryptocompare", Min5, "histominute/5"}, {"cryptocompare", Min15, "histominute/15"},
+		{"cryptocompare", Min30, "histominute/30"}, {"cryptocompare", Min60, "histohour/1"},
+		{"cryptocompare", Hour2, "histohour/2"}, {"cryptocompare", Hour4, "histohour/4"},
+		{"cryptocompare", Hour6, "histohour/6"}, {"cryptocompare", Hour8, "histohour/8"},
+		{"cryptocompare", Hour12, "histohour/12"}, {"cryptocompare", Daily, "histoday/1"},
+		{"cryptocompare", Day3, "histoday/3"},
+		{"coingecko", Min30, "ohlc"}, {"coingecko", Hour4, "ohlc"},
+		{"ecb", Daily, "d"},
+		{"moex", Min1, "1"}, {"moex", Min60, "60"}, {"moex", Daily, "24"}, {"moex", Weekly, "7"}, {"moex", Monthly, "31"},
+		{"deribit", Min1, "1"}, {"deribit", Min3, "3"}, {"deribit", Min5, "5"}, {"deribit", Min15, "15"},
+		{"deribit", Min30, "30"}, {"deribit", Min60, "60"}, {"deribit", Hour2, "120"}, {"deribit", Hour6, "360"},
+		{"deribit", Hour12, "720"}, {"deribit", Daily, "1D"},
+		{"fred", Daily, "native"}, {"fred", Weekly, "native"}, {"fred", Monthly, "native"}, {"fred", Yearly, "native"},
+		{"finnhub", Min60, "60"}, {"finnhub", Daily, "D"}, {"finnhub", Weekly, "W"}, {"finnhub", Monthly, "M"},
+	}
+	mapped := map[string]int{}
+	for _, tt := range tests {
+		s, found := native[tt.source](tt.period)
+		assert(t, found, "%s %s: no native period", tt.source, tt.period.Name())
+		equals(t, tt.native, s)
+		mapped[tt.source]++
+	}
+	for _, src := range Sources() {
+		equals(t, len(SupportedPeriods(src)), mapped[src])
+	}
+}
@@ -0,0 +1,67 @@
+package quote
+
+import "fmt"
+
+// SpreadOp - how two legs are combined into a synthetic series
+type SpreadOp int
+
+const (
+	// Ratio - a / b
+	Ratio SpreadOp = iota
+	// Difference - a - b
+	Difference
+)
+
+// apply - combine x of the first leg with y of the second
+func (op SpreadOp) apply(x, y float64) float64 {
+	if op == Ratio {
+		return x / y
+	}
+	return x - y
+}
+
+// NewSpreadQuote - synthetic ratio or difference series of a and b, bar by
+// bar over the dates both quotes have.
+//
+// Open and Close are computed from the corresponding fields of the two legs.
+// The highs and lows of the legs need not occur at the same time, so High and
+// Low are not derived from them; they are the larger and smaller of the
+// synthetic Open and Close. Volume is the smaller of the two legs' volumes.
+// The symbol is name, or "a/b" ("a-b" for Difference) when name is empty.
+func NewSpreadQuote(a, b Quote, op SpreadOp, name string) (Quote, error) {
+
+	period, err := alignPeriod(a, b)
+	if err != nil {
+		return NewQuote("", 0), err
+	}
+	if name == "" {
+		sep := "/"
+		if op == Difference {
+			sep = "-"
+		}
+		name = a.Symbol + sep + b.Symbol
+	}
+
+	ia, ib, _, _ := alignDates(a, b, period)
+	q := NewQuote(name, len(ia))
+	q.Precision = a.Precision
+	for bar := range ia {
+		i, j := ia[bar], ib[bar]
+		if op == Ratio && (b.Open[j] == 0 || b.Close[j] == 0) {
+			return NewQuote("", 0), fmt.Errorf("zero price in %s on %s", b.Symbol, b.Date[j].Format("2006-01-02 15:04"))
+		}
+		q.Date[bar] = a.Date[i]
+		q.Open[bar] = op.apply(a.Open[i], b.Open[j])
+		q.Close[bar] = op.apply(a.Close[i], b.Close[j])
+		q.High[bar] = q.Open[bar]
+		q.Low[bar] = q.Close[bar]
+		if q.Close[bar] > q.Open[bar] {
+			q.High[bar], q.Low[bar] = q.Close[bar], q.Open[bar]
+		}
+		q.Volume[bar] = a.Volume[i]
+		if b.Volume[j] < q.Volume[bar] {
+			q.Volume[bar] = b.Volume[j]
+		}
+	}
+	return q, nil
+}
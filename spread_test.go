@@ -0,0 +1,66 @@
+package quote
+
+import (
+	"testing"
+	"time"
+)
+
+func spreadLeg(symbol string, dates []time.Time, open, close, volume []float64) Quote {
+	q := NewQuote(symbol, len(dates))
+	copy(q.Date, dates)
+	copy(q.Open, open)
+	copy(q.Close, close)
+	copy(q.Volume, volume)
+	for bar := range dates {
+		q.High[bar] = q.Open[bar] + 5
+		q.Low[bar] = q.Close[bar] - 5
+	}
+	return q
+}
+
+func TestNewSpreadQuote(t *testing.T) {
+	d := []time.Time{day(2023, 1, 3), day(2023, 1, 4), day(2023, 1, 5), day(2023, 1, 6)}
+	aapl := spreadLeg("aapl", d, []float64{100, 110, 120, 130}, []float64{110, 100, 130, 120}, []float64{10, 20, 30, 40})
+	// msft is missing the 5th
+	msft := spreadLeg("msft", []time.Time{d[0], d[1], d[3]}, []float64{50, 40, 65}, []float64{55, 50, 60}, []float64{15, 5, 50})
+
+	r, err := NewSpreadQuote(aapl, msft, Ratio, "")
+	ok(t, err)
+	equals(t, "aapl/msft", r.Symbol)
+	equals(t, []time.Time{d[0], d[1], d[3]}, r.Date)
+	equals(t, []float64{2, 2.75, 2}, r.Open)
+	equals(t, []float64{2, 2, 2}, r.Close)
+	equals(t, []float64{2, 2.75, 2}, r.High)
+	equals(t, []float64{2, 2, 2}, r.Low)
+	equals(t, []float64{10, 5, 40}, r.Volume)
+
+	s, err := NewSpreadQuote(aapl, msft, Difference, "cal")
+	ok(t, err)
+	equals(t, "cal", s.Symbol)
+	equals(t, []float64{50, 70, 65}, s.Open)
+	equals(t, []float64{55, 50, 60}, s.Close)
+	equals(t, []float64{55, 70, 65}, s.High)
+	equals(t, []float64{50, 50, 60}, s.Low)
+}
+
+func TestNewSpreadQuoteErrors(t *testing.T) {
+	daily := cleanDaily("spy", 5)
+	intraday := cleanDaily("qqq", 5)
+	for bar := range intraday.Date {
+		intraday.Date[bar] = day(2023, 1, 3).Add(time.Duration(bar) * 5 * time.Minute)
+	}
+	_, err := NewSpreadQuote(daily, intraday, Ratio, "")
+	assert(t, err != nil, "expected daily vs intraday error")
+
+	weekly := cleanDaily("qqq", 5)
+	for bar := range weekly.Date {
+		weekly.Date[bar] = day(2023, 1, 2).AddDate(0, 0, 7*bar)
+	}
+	_, err = NewSpreadQuote(daily, weekly, Difference, "")
+	assert(t, err != nil, "expected daily vs weekly error")
+
+	zero := cleanDaily("qqq", 5)
+	zero.Close[2] = 0
+	_, err = NewSpreadQuote(daily, zero, Ratio, "")
+	assert(t, err != nil, "expected zero price error")
+}
@@ -0,0 +1,29 @@
+package quote
+
+import "fmt"
+
+// SQLiteWriter - writer of Quote.WriteSQLite and Quotes.WriteSQLite, set by
+// importing github.com/markcheno/go-quote/sqlite. The SQLite driver is kept
+// in that module so that this package has no external dependencies.
+var SQLiteWriter func(dbPath string, quotes Quotes) error
+
+// WriteSQLite - write Quote struct to the bars table of the SQLite database
+// at dbPath, see Quotes.WriteSQLite
+func (q Quote) WriteSQLite(dbPath string) error {
+	return Quotes{q}.WriteSQLite(dbPath)
+}
+
+// WriteSQLite - write Quotes structure to the bars table (symbol, ts in unix
+// milliseconds, open, high, low, close, volume) of the SQLite database at
+// dbPath, quotes.db if empty, in a single transaction. Bars replace those of
+// the same symbol and date, so writing a download again updates rather than
+// duplicates them. Needs SQLiteWriter.
+func (q Quotes) WriteSQLite(dbPath string) error {
+	if SQLiteWriter == nil {
+		return fmt.Errorf("sqlite output requires an import of github.com/markcheno/go-quote/sqlite")
+	}
+	if dbPath == "" {
+		dbPath = "quotes.db"
+	}
+	return SQLiteWriter(dbPath, q)
+}
@@ -0,0 +1,8 @@
+module github.com/markcheno/go-quote/sqlite
+
+go 1.16
+
+require (
+	github.com/markcheno/go-quote v0.0.0-20261015063413-e39b8b02ada4
+	modernc.org/sqlite v1.14.8
+)
@@ -0,0 +1,178 @@
+/*
+Package sqlite writes and reads go-quote bars in a SQLite database, a
+lightweight local store for backtests
+
+It lives in its own module so that the core quote package stays free of
+external dependencies. The pure-Go modernc.org/sqlite driver is used, so no
+cgo is needed. Importing the package sets quote.SQLiteWriter to WriteQuotes,
+so that Quote.WriteSQLite and Quotes.WriteSQLite write through it.
+
+Licensed under terms of MIT license (see LICENSE)
+*/
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/markcheno/go-quote"
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// createBars - the bars table, one row per symbol and bar start in unix
+// milliseconds
+const createBars = `CREATE TABLE IF NOT EXISTS bars (
+	symbol TEXT NOT NULL,
+	ts INTEGER NOT NULL,
+	open REAL,
+	high REAL,
+	low REAL,
+	close REAL,
+	volume REAL,
+	PRIMARY KEY (symbol, ts)
+)`
+
+// createSymbolIndex - index of the bars table by symbol
+const createSymbolIndex = `CREATE INDEX IF NOT EXISTS bars_symbol ON bars (symbol)`
+
+// begin - start the transaction of a write, replaceable in tests
+var begin = func(db *sql.DB) (*sql.Tx, error) { return db.Begin() }
+
+func init() {
+	quote.SQLiteWriter = WriteQuotes
+}
+
+// ErrSymbolNotFound - a symbol without any bars in the database
+type ErrSymbolNotFound struct {
+	Symbol string
+}
+
+func (e *ErrSymbolNotFound) Error() string {
+	return fmt.Sprintf("sqlite: symbol %s not found", e.Symbol)
+}
+
+// WriteQuote - write the bars of q to the bars table of the database at
+// dbPath, see WriteQuotes
+func WriteQuote(dbPath string, q quote.Quote) error {
+	return WriteQuotes(dbPath, quote.Quotes{q})
+}
+
+// WriteQuotes - write the bars of all quotes to the bars table of the
+// database at dbPath, creating the database and table if needed. Bars
+// replace those of the same symbol and date, so writing a refreshed download
+// again updates rather than duplicates rows. All bars are written in a
+// single transaction.
+func WriteQuotes(dbPath string, quotes quote.Quotes) error {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	for _, stmt := range []string{createBars, createSymbolIndex} {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	tx, err := begin(db)
+	if err != nil {
+		return err
+	}
+	insert, err := tx.Prepare("INSERT OR REPLACE INTO bars (symbol, ts, open, high, low, close, volume) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer insert.Close()
+	for _, q := range quotes {
+		for bar := range q.Close {
+			_, err := insert.Exec(q.Symbol, millis(q.Date[bar]), q.Open[bar], q.High[bar], q.Low[bar], q.Close[bar], q.Volume[bar])
+			if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("sqlite: %s %v: %v", q.Symbol, q.Date[bar], err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// NewQuoteFromSQLite - bars of symbol dated from <= date < to from the bars
+// table of the database at dbPath, in date order. Returns an
+// *ErrSymbolNotFound if the table has no bars of symbol at all, and an empty
+// quote if it has bars but none in the range.
+func NewQuoteFromSQLite(dbPath, symbol string, from, to time.Time) (quote.Quote, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return quote.NewQuote("", 0), err
+	}
+	defer db.Close()
+	return readQuote(db, symbol, from, to)
+}
+
+// NewQuotesFromSQLite - bars of each of symbols dated from <= date < to,
+// stopping at the first symbol that is not found or fails to read
+func NewQuotesFromSQLite(dbPath string, symbols []string, from, to time.Time) (quote.Quotes, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return quote.Quotes{}, err
+	}
+	defer db.Close()
+
+	quotes := quote.Quotes{}
+	for _, symbol := range symbols {
+		q, err := readQuote(db, symbol, from, to)
+		if err != nil {
+			return quotes, err
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes, nil
+}
+
+// readQuote - bars of symbol in the range from the open database
+func readQuote(db *sql.DB, symbol string, from, to time.Time) (quote.Quote, error) {
+	rows, err := db.Query(
+		"SELECT ts, open, high, low, close, volume FROM bars WHERE symbol = ? AND ts >= ? AND ts < ? ORDER BY ts",
+		symbol, millis(from), millis(to))
+	if err != nil {
+		return quote.NewQuote("", 0), err
+	}
+	defer rows.Close()
+
+	q := quote.NewQuote(symbol, 0)
+	for rows.Next() {
+		var ts int64
+		var open, high, low, close, volume sql.NullFloat64
+		if err := rows.Scan(&ts, &open, &high, &low, &close, &volume); err != nil {
+			return quote.NewQuote("", 0), err
+		}
+		q.Date = append(q.Date, time.Unix(0, ts*int64(time.Millisecond)).UTC())
+		q.Open = append(q.Open, open.Float64)
+		q.High = append(q.High, high.Float64)
+		q.Low = append(q.Low, low.Float64)
+		q.Close = append(q.Close, close.Float64)
+		q.Volume = append(q.Volume, volume.Float64)
+	}
+	if err := rows.Err(); err != nil {
+		return quote.NewQuote("", 0), err
+	}
+	if len(q.Date) > 0 {
+		return q, nil
+	}
+
+	var found int
+	err = db.QueryRow("SELECT 1 FROM bars WHERE symbol = ? LIMIT 1", symbol).Scan(&found)
+	if err == sql.ErrNoRows {
+		return quote.NewQuote("", 0), &ErrSymbolNotFound{Symbol: symbol}
+	}
+	if err != nil {
+		return quote.NewQuote("", 0), err
+	}
+	return q, nil
+}
+
+// millis - t in unix milliseconds
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,212 @@
+package sqlite
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markcheno/go-quote"
+)
+
+func day(y, m, d int) time.Time {
+	return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+}
+
+// testDB - a database holding three spy bars and one aapl bar, returning
+// its path and a cleanup func
+func testDB(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "bars.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(createBars); err != nil {
+		t.Fatal(err)
+	}
+	// inserted out of order, read back by ts
+	for _, b := range []struct {
+		symbol string
+		date   time.Time
+		close  float64
+	}{
+		{"spy", day(2023, 1, 4), 102},
+		{"spy", day(2023, 1, 3), 101},
+		{"aapl", day(2023, 1, 3), 125},
+		{"spy", day(2023, 1, 5), 103},
+	} {
+		_, err := db.Exec("INSERT INTO bars VALUES (?, ?, ?, ?, ?, ?, ?)",
+			b.symbol, millis(b.date), b.close-1, b.close+1, b.close-2, b.close, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestNewQuoteFromSQLite(t *testing.T) {
+	path, cleanup := testDB(t)
+	defer cleanup()
+
+	q, err := NewQuoteFromSQLite(path, "spy", day(2023, 1, 1), day(2023, 1, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Date) != 2 || !q.Date[0].Equal(day(2023, 1, 3)) || !q.Date[1].Equal(day(2023, 1, 4)) {
+		t.Errorf("unexpected dates %v", q.Date)
+	}
+	if q.Symbol != "spy" || q.Open[0] != 100 || q.High[0] != 102 || q.Low[0] != 99 || q.Close[1] != 102 || q.Volume[1] != 1000 {
+		t.Errorf("unexpected quote %+v", q)
+	}
+
+	q, err = NewQuoteFromSQLite(path, "spy", day(2024, 1, 1), day(2025, 1, 1))
+	if err != nil || len(q.Date) != 0 {
+		t.Errorf("out of range: %d bars, error %v", len(q.Date), err)
+	}
+
+	_, err = NewQuoteFromSQLite(path, "msft", day(2023, 1, 1), day(2024, 1, 1))
+	if e, ok := err.(*ErrSymbolNotFound); !ok || e.Symbol != "msft" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestNewQuotesFromSQLite(t *testing.T) {
+	path, cleanup := testDB(t)
+	defer cleanup()
+
+	quotes, err := NewQuotesFromSQLite(path, []string{"aapl", "spy"}, day(2023, 1, 1), day(2024, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 2 || quotes[0].Symbol != "aapl" || len(quotes[0].Close) != 1 || len(quotes[1].Close) != 3 {
+		t.Errorf("unexpected quotes %+v", quotes)
+	}
+
+	_, err = NewQuotesFromSQLite(path, []string{"spy", "msft"}, day(2023, 1, 1), day(2024, 1, 1))
+	if _, ok := err.(*ErrSymbolNotFound); !ok {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// countBars - rows of the bars table
+func countBars(t *testing.T, path string) int {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM bars").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestWriteQuotesIdempotent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bars.db")
+
+	spy := quote.NewQuote("spy", 3)
+	for bar := range spy.Date {
+		spy.Date[bar] = day(2023, 1, 3+bar)
+		spy.Close[bar] = float64(100 + bar)
+	}
+	aapl := quote.NewQuote("aapl", 1)
+	aapl.Date[0] = day(2023, 1, 3)
+	aapl.Close[0] = 125
+	if err := WriteQuotes(path, quote.Quotes{spy, aapl}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a refreshed download revises the last bar and adds one
+	spy.Close[2] = 105
+	spy.Date = append(spy.Date, day(2023, 1, 6))
+	spy.Open = append(spy.Open, 0)
+	spy.High = append(spy.High, 0)
+	spy.Low = append(spy.Low, 0)
+	spy.Close = append(spy.Close, 106)
+	spy.Volume = append(spy.Volume, 0)
+	for i := 0; i < 2; i++ {
+		if err := WriteQuote(path, spy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := countBars(t, path); n != 5 {
+		t.Errorf("%d rows after rewrites, want 5", n)
+	}
+	q, err := NewQuoteFromSQLite(path, "spy", day(2023, 1, 1), day(2024, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Close) != 4 || q.Close[2] != 105 || q.Close[3] != 106 {
+		t.Errorf("unexpected closes %v", q.Close)
+	}
+}
+
+func TestQuotesWriteSQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bars.db")
+
+	spy := quote.NewQuote("spy", 2)
+	spy.Date[0], spy.Date[1] = day(2023, 1, 3), day(2023, 1, 4)
+	aapl := quote.NewQuote("aapl", 1)
+	aapl.Date[0] = day(2023, 1, 3)
+	for i := 0; i < 2; i++ {
+		if err := (quote.Quotes{spy, aapl}).WriteSQLite(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := spy.WriteSQLite(path); err != nil {
+		t.Fatal(err)
+	}
+	if n := countBars(t, path); n != 3 {
+		t.Errorf("%d rows, want 3", n)
+	}
+}
+
+func TestWriteQuotesOneTransaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bars.db")
+
+	txs := 0
+	saved := begin
+	begin = func(db *sql.DB) (*sql.Tx, error) {
+		txs++
+		return saved(db)
+	}
+	defer func() { begin = saved }()
+
+	q := quote.NewQuote("btcusdt", 10000)
+	for bar := range q.Date {
+		q.Date[bar] = day(2023, 1, 1).Add(time.Duration(bar) * time.Minute)
+		q.Close[bar] = float64(bar)
+	}
+	if err := WriteQuote(path, q); err != nil {
+		t.Fatal(err)
+	}
+	if txs != 1 {
+		t.Errorf("%d transactions, want 1", txs)
+	}
+	if n := countBars(t, path); n != 10000 {
+		t.Errorf("%d rows, want 10000", n)
+	}
+}
@@ -0,0 +1,24 @@
+package quote
+
+import "testing"
+
+func TestWriteSQLite(t *testing.T) {
+	saved := SQLiteWriter
+	defer func() { SQLiteWriter = saved }()
+
+	SQLiteWriter = nil
+	assert(t, cleanDaily("spy", 3).WriteSQLite("bars.db") != nil, "sqlite written without a writer")
+
+	var written []string
+	var symbols [][]string
+	SQLiteWriter = func(dbPath string, quotes Quotes) error {
+		written = append(written, dbPath)
+		symbols = append(symbols, quotes.Symbols())
+		return nil
+	}
+	ok(t, cleanDaily("spy", 3).WriteSQLite(""))
+	ok(t, Quotes{cleanDaily("spy", 3), cleanDaily("aapl", 3)}.WriteSQLite("bars.db"))
+	// every symbol goes to the same database
+	equals(t, []string{"quotes.db", "bars.db"}, written)
+	equals(t, [][]string{{"spy"}, {"spy", "aapl"}}, symbols)
+}
@@ -0,0 +1,212 @@
+package quote
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"time"
+)
+
+// equity session length, used to annualize intraday equity bars
+const equitySession = 6*time.Hour + 30*time.Minute
+
+// QuoteStats - summary statistics of a quote
+type QuoteStats struct {
+	Symbol         string
+	Bars           int
+	First          time.Time
+	Last           time.Time
+	LastClose      float64
+	AvgVolume      float64
+	CAGR           float64 // compound annual growth rate of close
+	Volatility     float64 // annualized standard deviation of close to close returns
+	MaxDrawdown    float64 // largest peak to trough fall of close, as a positive fraction
+	PeakDate       time.Time
+	TroughDate     time.Time
+	Sharpe         float64 // annualized mean excess return over Volatility
+	PeriodsPerYear float64 // annualization factor used
+	Benchmark      string  // symbol Beta was computed against, empty if none
+	Beta           float64 // full-sample Beta against Benchmark
+}
+
+// StatsOptions - options for Quote.StatsWithOptions
+type StatsOptions struct {
+	RiskFreeRate   float64   // annual risk free rate, e.g. 0.02
+	PeriodsPerYear float64   // annualization factor, inferred with Quote.PeriodsPerYear when zero
+	Dividends      Dividends // when set, statistics are computed on the TotalReturn series
+}
+
+// PeriodsPerYear - annualization factor inferred from the bar spacing of q.
+// Daily equity bars give 252 and daily bars that include weekends (crypto)
+// 365; weekly and monthly bars give 52 and 12. Intraday bars are scaled from
+// 365*24 hours a year when they include weekends and from 252 sessions of 6.5
+// hours otherwise. Zero for quotes with fewer than two bars.
+func (q Quote) PeriodsPerYear() float64 {
+	spacing := q.barSpacing()
+	if spacing == 0 {
+		return 0
+	}
+	weekends := false
+	for _, d := range q.Date {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			weekends = true
+			break
+		}
+	}
+	day := 24 * time.Hour
+	switch {
+	case spacing >= 28*day:
+		return 12
+	case spacing >= 6*day:
+		return 52
+	case spacing >= 20*time.Hour:
+		days := math.Round(float64(spacing) / float64(day))
+		if weekends {
+			return 365 / days
+		}
+		return 252 / days
+	case weekends:
+		return float64(365*day) / float64(spacing)
+	}
+	return 252 * float64(equitySession) / float64(spacing)
+}
+
+// Stats - summary statistics of q with the annualization factor inferred from
+// its bar spacing and riskFreeRate as the annual risk free rate for Sharpe
+func (q Quote) Stats(riskFreeRate float64) QuoteStats {
+	return q.StatsWithOptions(StatsOptions{RiskFreeRate: riskFreeRate})
+}
+
+// StatsWithOptions - summary statistics of q
+func (q Quote) StatsWithOptions(opts StatsOptions) QuoteStats {
+
+	s := QuoteStats{Symbol: q.Symbol, Bars: len(q.Close)}
+	if opts.Dividends != nil {
+		q = TotalReturn(q, opts.Dividends)
+	}
+	n := len(q.Close)
+	if n == 0 {
+		return s
+	}
+	s.First, s.Last = q.Date[0], q.Date[n-1]
+	s.LastClose = q.Close[n-1]
+	for _, v := range q.Volume {
+		s.AvgVolume += v
+	}
+	s.AvgVolume /= float64(n)
+
+	s.PeriodsPerYear = opts.PeriodsPerYear
+	if s.PeriodsPerYear == 0 {
+		s.PeriodsPerYear = q.PeriodsPerYear()
+	}
+
+	years := s.Last.Sub(s.First).Hours() / (24 * 365.25)
+	if years > 0 && q.Close[0] > 0 {
+		s.CAGR = math.Pow(q.Close[n-1]/q.Close[0], 1/years) - 1
+	}
+
+	// drawdown
+	peak := 0
+	for bar := 1; bar < n; bar++ {
+		if q.Close[bar] > q.Close[peak] {
+			peak = bar
+		} else if q.Close[peak] > 0 {
+			if dd := 1 - q.Close[bar]/q.Close[peak]; dd > s.MaxDrawdown {
+				s.MaxDrawdown = dd
+				s.PeakDate, s.TroughDate = q.Date[peak], q.Date[bar]
+			}
+		}
+	}
+
+	// returns
+	var m comoments
+	for bar := 1; bar < n; bar++ {
+		if q.Close[bar-1] != 0 {
+			r := q.Close[bar]/q.Close[bar-1] - 1
+			m.add(r, r)
+		}
+	}
+	if m.n > 1 {
+		s.Volatility = math.Sqrt(m.sxx/(m.n-1)) * math.Sqrt(s.PeriodsPerYear)
+		if s.Volatility > 0 {
+			s.Sharpe = (m.meanX*s.PeriodsPerYear - opts.RiskFreeRate) / s.Volatility
+		}
+	}
+	return s
+}
+
+// StatsTable - summary statistics of several quotes
+type StatsTable []QuoteStats
+
+// Stats - summary statistics of each quote, see Quote.Stats
+func (q Quotes) Stats(riskFreeRate float64) StatsTable {
+	table := make(StatsTable, len(q))
+	for i, quote := range q {
+		table[i] = quote.Stats(riskFreeRate)
+	}
+	return table
+}
+
+// hasBeta - true if any row has a beta against a benchmark
+func (t StatsTable) hasBeta() bool {
+	for _, s := range t {
+		if s.Benchmark != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// formatBeta - beta of s for a table cell, empty when not computed
+func formatBeta(s QuoteStats, format string) string {
+	if s.Benchmark == "" || math.IsNaN(s.Beta) {
+		return ""
+	}
+	return fmt.Sprintf(format, s.Beta)
+}
+
+// String - render statistics as an aligned text table, with a beta column
+// when betas were computed
+func (t StatsTable) String() string {
+	var buffer bytes.Buffer
+	beta := t.hasBeta()
+	format := "%-12s %8s %-16s %-16s %12s %8s %8s %8s %8s %14s"
+	header := fmt.Sprintf(format, "symbol", "bars", "first", "last", "close", "cagr", "maxdd", "vol", "sharpe", "avgvolume")
+	if beta {
+		header += fmt.Sprintf(" %6s", "beta")
+	}
+	buffer.WriteString(header + "\n")
+	for _, s := range t {
+		row := fmt.Sprintf(format, s.Symbol, fmt.Sprint(s.Bars), formatQualityDate(s.First), formatQualityDate(s.Last),
+			fmt.Sprintf("%.*f", getPrecision(s.Symbol), s.LastClose), fmt.Sprintf("%.1f%%", 100*s.CAGR),
+			fmt.Sprintf("%.1f%%", 100*s.MaxDrawdown), fmt.Sprintf("%.1f%%", 100*s.Volatility),
+			fmt.Sprintf("%.2f", s.Sharpe), fmt.Sprintf("%.0f", s.AvgVolume))
+		if beta {
+			row += fmt.Sprintf(" %6s", formatBeta(s, "%.2f"))
+		}
+		buffer.WriteString(row + "\n")
+	}
+	return buffer.String()
+}
+
+// CSV - render statistics as csv, with benchmark and beta columns when betas were computed
+func (t StatsTable) CSV() string {
+	var buffer bytes.Buffer
+	beta := t.hasBeta()
+	header := "symbol,bars,first,last,last_close,cagr,volatility,max_drawdown,peak,trough,sharpe,avg_volume,periods_per_year"
+	if beta {
+		header += ",benchmark,beta"
+	}
+	buffer.WriteString(header + "\n")
+	for _, s := range t {
+		buffer.WriteString(fmt.Sprintf("%s,%d,%s,%s,%.*f,%.6f,%.6f,%.6f,%s,%s,%.4f,%.2f,%g",
+			s.Symbol, s.Bars, formatQualityDate(s.First), formatQualityDate(s.Last), getPrecision(s.Symbol), s.LastClose,
+			s.CAGR, s.Volatility, s.MaxDrawdown, formatQualityDate(s.PeakDate), formatQualityDate(s.TroughDate),
+			s.Sharpe, s.AvgVolume, s.PeriodsPerYear))
+		if beta {
+			buffer.WriteString("," + s.Benchmark + "," + formatBeta(s, "%.4f"))
+		}
+		buffer.WriteString("\n")
+	}
+	return buffer.String()
+}
@@ -0,0 +1,66 @@
+package quote
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	q := NewQuote("spy", 4)
+	q.Date = []time.Time{day(2021, 1, 1), day(2021, 5, 1), day(2021, 9, 1), day(2022, 1, 1)}
+	q.Close = []float64{100, 110, 99, 121}
+	q.Volume = []float64{10, 20, 30, 40}
+
+	s := q.StatsWithOptions(StatsOptions{RiskFreeRate: 0.02, PeriodsPerYear: 3})
+	equals(t, 4, s.Bars)
+	equals(t, day(2022, 1, 1), s.Last)
+	equals(t, 121.0, s.LastClose)
+	equals(t, 25.0, s.AvgVolume)
+	// 365 days of 365.25 day years
+	near(t, math.Pow(1.21, 365.25/365)-1, s.CAGR, "cagr")
+	// returns 0.1, -0.1, 0.2222, sample standard deviation 0.162668
+	near(t, 0.162668*math.Sqrt(3), s.Volatility, "volatility")
+	near(t, 0.717737, s.Sharpe, "sharpe")
+	near(t, 0.1, s.MaxDrawdown, "max drawdown")
+	equals(t, day(2021, 5, 1), s.PeakDate)
+	equals(t, day(2021, 9, 1), s.TroughDate)
+	equals(t, 3.0, s.PeriodsPerYear)
+
+	// a dividend raises the total return
+	tr := q.StatsWithOptions(StatsOptions{PeriodsPerYear: 3, Dividends: Dividends{{Date: day(2021, 9, 1), Amount: 9.9}}})
+	assert(t, tr.CAGR > s.CAGR, "total return cagr %v not above %v", tr.CAGR, s.CAGR)
+	near(t, 1-99*1.1/110, tr.MaxDrawdown, "total return max drawdown")
+
+	table := Quotes{q, NewQuote("empty", 0)}.Stats(0)
+	equals(t, 2, len(table))
+	equals(t, 0, table[1].Bars)
+	csv := table.CSV()
+	assert(t, strings.HasPrefix(csv, "symbol,bars,first,last,last_close,cagr"), "bad csv header %q", csv)
+	assert(t, strings.Contains(table.String(), "spy"), "missing symbol")
+}
+
+func TestPeriodsPerYear(t *testing.T) {
+	bars := func(start time.Time, step time.Duration, n int, weekdays bool) Quote {
+		q := NewQuote("x", 0)
+		for d := start; len(q.Date) < n; d = d.Add(step) {
+			if weekdays && (d.Weekday() == time.Saturday || d.Weekday() == time.Sunday) {
+				continue
+			}
+			q.PushBar(Bar{Date: d, Close: 1})
+		}
+		return q
+	}
+	monday := day(2023, 1, 2)
+	equals(t, 252.0, cleanDaily("spy", 20).PeriodsPerYear())
+	equals(t, 365.0, bars(monday, 24*time.Hour, 20, false).PeriodsPerYear())
+	equals(t, 52.0, bars(monday, 7*24*time.Hour, 20, false).PeriodsPerYear())
+	equals(t, 8760.0, bars(monday, time.Hour, 200, false).PeriodsPerYear())
+	equals(t, 252.0*78, bars(monday.Add(14*time.Hour+30*time.Minute), 5*time.Minute, 50, true).PeriodsPerYear())
+
+	monthly := NewQuote("x", 3)
+	monthly.Date = []time.Time{day(2023, 1, 31), day(2023, 2, 28), day(2023, 3, 31)}
+	equals(t, 12.0, monthly.PeriodsPerYear())
+	equals(t, 0.0, NewQuote("x", 1).PeriodsPerYear())
+}
@@ -0,0 +1,148 @@
+package quote
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stooqURL - base url of Stooq
+var stooqURL = "https://stooq.com"
+
+// stooqPeriods - periods of the Stooq csv download
+var stooqPeriods = []Period{Daily}
+
+// NoDataError - a source that has no data for a symbol in the requested range,
+// which for some sources also means the symbol is unknown
+type NoDataError struct {
+	Source string
+	Symbol string
+}
+
+func (e *NoDataError) Error() string {
+	return fmt.Sprintf("%s: no data for %s", e.Source, e.Symbol)
+}
+
+// stooqSymbol - the Stooq name of symbol. Names with a market suffix such as
+// aapl.us or vod.uk, indices such as ^spx and six letter currency pairs such
+// as eurusd are used as they are, other symbols are taken as US stocks.
+func stooqSymbol(symbol string) string {
+	s := strings.ToLower(symbol)
+	if strings.Contains(s, ".") || strings.HasPrefix(s, "^") || fxPair.MatchString(s) {
+		return s
+	}
+	return s + ".us"
+}
+
+// NewQuoteFromStooq - Stooq daily historical prices for a symbol, adjusted
+// for splits and dividends, no api key needed. Indices have no volume, Volume
+// is zero for them. Returns a
+// *NoDataError when Stooq has no data, e.g. for an unknown symbol.
+func NewQuoteFromStooq(symbol, startDate, endDate string) (Quote, error) {
+	return NewQuoteFromStooqCtx(context.Background(), symbol, startDate, endDate)
+}
+
+// NewQuoteFromStooqCtx - NewQuoteFromStooq, canceled with ctx
+func NewQuoteFromStooqCtx(ctx context.Context, symbol, startDate, endDate string) (Quote, error) {
+
+	from := ParseDateString(startDate)
+	to := ParseDateString(endDate)
+
+	url := fmt.Sprintf(
+		"%s/q/d/l/?s=%s&d1=%s&d2=%s&i=d",
+		stooqURL,
+		url.QueryEscape(stooqSymbol(symbol)),
+		from.Format("20060102"),
+		to.Format("20060102"))
+
+	if err := waitRate(ctx, "stooq"); err != nil {
+		return NewQuote("", 0), err
+	}
+	client := httpClient()
+	resp, err := getContext(ctx, client, url)
+	if err != nil {
+		Log.Printf("stooq error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return NewQuote("", 0), fmt.Errorf("stooq %s: %s", symbol, resp.Status)
+	}
+	contents, _ := ioutil.ReadAll(resp.Body)
+
+	quote, err := parseStooq(symbol, string(contents))
+	if err != nil {
+		Log.Printf("stooq error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	return quote.withMeta("stooq", Daily, true), nil
+}
+
+// parseStooq - bars of a Stooq csv download, Date,Open,High,Low,Close with
+// an optional Volume column that may also be empty
+func parseStooq(symbol, contents string) (Quote, error) {
+
+	if strings.HasPrefix(strings.TrimSpace(contents), "No data") {
+		return NewQuote("", 0), &NoDataError{Source: "stooq", Symbol: symbol}
+	}
+
+	r := csv.NewReader(strings.NewReader(contents))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return NewQuote("", 0), err
+	}
+	if len(rows) == 0 || len(rows[0]) < 5 || rows[0][0] != "Date" {
+		return NewQuote("", 0), fmt.Errorf("stooq %s: unexpected response", symbol)
+	}
+
+	quote := NewQuote(symbol, len(rows)-1)
+	for bar, row := range rows[1:] {
+		if len(row) < 5 {
+			return NewQuote("", 0), fmt.Errorf("stooq %s: short row %d", symbol, bar+2)
+		}
+		if quote.Date[bar], err = time.Parse("2006-01-02", row[0]); err != nil {
+			return NewQuote("", 0), err
+		}
+		for i, col := range []*[]float64{&quote.Open, &quote.High, &quote.Low, &quote.Close} {
+			if (*col)[bar], err = strconv.ParseFloat(row[i+1], 64); err != nil {
+				return NewQuote("", 0), err
+			}
+		}
+		if len(row) > 5 && row[5] != "" {
+			quote.Volume[bar], _ = strconv.ParseFloat(row[5], 64)
+		}
+	}
+	return quote, nil
+}
+
+// NewQuotesFromStooqSyms - create a list of prices from symbols in string array
+func NewQuotesFromStooqSyms(symbols []string, startDate, endDate string) (Quotes, error) {
+	return NewQuotesFromStooqSymsCtx(context.Background(), symbols, startDate, endDate)
+}
+
+// NewQuotesFromStooqSymsCtx - NewQuotesFromStooqSyms, canceled with ctx.
+// The quotes downloaded before are returned with the wrapped error of ctx.
+func NewQuotesFromStooqSymsCtx(ctx context.Context, symbols []string, startDate, endDate string) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, symbol := range symbols {
+		quote, err := NewQuoteFromStooqCtx(ctx, symbol, startDate, endDate)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "stooq after %d of %d symbols", i, len(symbols))
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else {
+			Log.Println("error downloading " + symbol)
+		}
+		delayContext(ctx, "stooq")
+	}
+	return quotes, nil
+}
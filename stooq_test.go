@@ -0,0 +1,60 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// stooqSPX - Stooq download of ^spx, without volume on the last row
+const stooqSPX = `Date,Open,High,Low,Close,Volume
+2023-03-01,3963.34,3971.73,3939.05,3951.39,2345678900
+2023-03-02,3938.68,3990.84,3928.16,3981.35,2212345600
+2023-03-03,3998.02,4048.29,3995.17,4045.64,
+`
+
+func TestStooqSymbol(t *testing.T) {
+	equals(t, "aapl.us", stooqSymbol("AAPL"))
+	equals(t, "brk-b.us", stooqSymbol("brk-b"))
+	equals(t, "vod.uk", stooqSymbol("vod.uk"))
+	equals(t, "^spx", stooqSymbol("^SPX"))
+	equals(t, "eurusd", stooqSymbol("EURUSD"))
+}
+
+func TestParseStooq(t *testing.T) {
+	q, err := parseStooq("^spx", stooqSPX)
+	ok(t, err)
+	equals(t, []time.Time{day(2023, 3, 1), day(2023, 3, 2), day(2023, 3, 3)}, q.Date)
+	equals(t, []float64{3951.39, 3981.35, 4045.64}, q.Close)
+	equals(t, []float64{2345678900, 2212345600, 0}, q.Volume)
+
+	// indices may also come without the volume column
+	q, err = parseStooq("^spx", "Date,Open,High,Low,Close\n2023-03-01,3963.34,3971.73,3939.05,3951.39\n")
+	ok(t, err)
+	equals(t, []float64{0}, q.Volume)
+
+	_, err = parseStooq("nope", "No data")
+	noData, isNoData := err.(*NoDataError)
+	assert(t, isNoData, "unexpected error %v", err)
+	equals(t, "nope", noData.Symbol)
+	equals(t, "stooq: no data for nope", err.Error())
+
+	_, err = parseStooq("x", "Date,Open,High,Low,Close\n2023-03-01,a,1,1,1\n")
+	assert(t, err != nil, "expected parse error")
+}
+
+func TestStooq(t *testing.T) {
+	var query string
+	fakeServer(t, &stooqURL, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		fmt.Fprint(w, stooqSPX)
+	})
+
+	q, err := NewQuoteFromStooq("^SPX", "2023-03-01", "2023-03-03")
+	ok(t, err)
+	equals(t, "s=%5Espx&d1=20230301&d2=20230303&i=d", query)
+	equals(t, "^SPX", q.Symbol)
+	equals(t, 3, len(q.Date))
+	equals(t, "stooq", q.Meta.Source)
+}
@@ -0,0 +1,113 @@
+package quote
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// buffered - write to w with write through a buffer, flushed at the end
+func buffered(w io.Writer, write func(w io.Writer) error) error {
+	bw := bufio.NewWriter(w)
+	if err := write(bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// jsonField - a member of the json object of a Quote
+type jsonField struct {
+	name  string
+	value interface{}
+}
+
+// jsonFields - the members of the json object of q in the order and with
+// the omissions of json.Marshal
+func (q Quote) jsonFields() []jsonField {
+	fields := []jsonField{{"symbol", q.Symbol}, {"date", q.Date}}
+	for i, col := range q.columns() {
+		if i >= 5 && len(*col) == 0 {
+			continue
+		}
+		fields = append(fields, jsonField{columnName(i), *col})
+	}
+	return fields
+}
+
+// writeJSON - q as json.Marshal or, with indent, json.MarshalIndent would
+// nested at prefix, without holding more than a column in memory
+func (q Quote) writeJSON(w io.Writer, indent bool, prefix string) error {
+	open, sep, end := "{", ":", "}"
+	inner := prefix
+	if indent {
+		inner = prefix + "  "
+		open, sep, end = "{\n"+inner, ": ", "\n"+prefix+"}"
+	}
+	if _, err := io.WriteString(w, open); err != nil {
+		return err
+	}
+	for i, field := range q.jsonFields() {
+		var value []byte
+		var err error
+		if indent {
+			value, err = json.MarshalIndent(field.value, inner, "  ")
+		} else {
+			value, err = json.Marshal(field.value)
+		}
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			comma := ","
+			if indent {
+				comma = ",\n" + inner
+			}
+			if _, err = io.WriteString(w, comma); err != nil {
+				return err
+			}
+		}
+		if _, err = io.WriteString(w, `"`+field.name+`"`+sep); err != nil {
+			return err
+		}
+		if _, err = w.Write(value); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, end)
+	return err
+}
+
+// writeJSON - q as a json array of its quotes in output order, as
+// json.Marshal or json.MarshalIndent would
+func (q Quotes) writeJSON(w io.Writer, indent bool) error {
+	q = q.inOutputOrder()
+	if q == nil {
+		_, err := io.WriteString(w, "null")
+		return err
+	}
+	if len(q) == 0 {
+		_, err := io.WriteString(w, "[]")
+		return err
+	}
+	open, comma, end := "[", ",", "]"
+	prefix := ""
+	if indent {
+		prefix = "  "
+		open, comma, end = "[\n"+prefix, ",\n"+prefix, "\n]"
+	}
+	if _, err := io.WriteString(w, open); err != nil {
+		return err
+	}
+	for i, quote := range q {
+		if i > 0 {
+			if _, err := io.WriteString(w, comma); err != nil {
+				return err
+			}
+		}
+		if err := quote.writeJSON(w, indent, prefix); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, end)
+	return err
+}
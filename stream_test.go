@@ -0,0 +1,71 @@
+package quote
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestWriteJSONTo(t *testing.T) {
+	quotes := partitionFixture()
+	quotes[0].Trades = []float64{1, 2, 3, 4}
+	quotes[1].AdjClose = []float64{21000}
+	quotes = append(quotes, Quote{Symbol: "nil"})
+	quotes.SortBySymbol()
+
+	for _, indent := range []bool{false, true} {
+		marshal := json.Marshal
+		if indent {
+			marshal = func(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", "  ") }
+		}
+		for _, q := range quotes {
+			want, err := marshal(q)
+			ok(t, err)
+			var b bytes.Buffer
+			ok(t, q.WriteJSONTo(&b, indent))
+			equals(t, string(want), b.String())
+			equals(t, string(want), q.JSON(indent))
+		}
+		for _, q := range []Quotes{quotes, quotes[:1], {}, nil} {
+			want, err := marshal(q)
+			ok(t, err)
+			var b bytes.Buffer
+			ok(t, q.WriteJSONTo(&b, indent))
+			equals(t, string(want), b.String())
+		}
+	}
+
+	nan := quotes[0]
+	nan.Close = []float64{math.NaN(), 0, 0, 0}
+	assert(t, nan.WriteJSONTo(&bytes.Buffer{}, false) != nil, "NaN written")
+	equals(t, "", nan.JSON(false))
+}
+
+// failWriter - fails every write
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriteToErrors(t *testing.T) {
+	quotes := partitionFixture()
+	var big Quote
+	for i := 0; i < 10000; i++ {
+		big.PushBar(Bar{Date: day(2000, 1, 1).AddDate(0, 0, i), Close: 1})
+	}
+	quotes = append(quotes, big)
+	assert(t, quotes.WriteCSVTo(failWriter{}) != nil, "csv error lost")
+	assert(t, quotes.WriteJSONTo(failWriter{}, true) != nil, "json error lost")
+	assert(t, quotes.WriteHighstockTo(failWriter{}) != nil, "highstock error lost")
+	assert(t, big.WriteCSVTo(failWriter{}) != nil, "csv error lost")
+
+	var b bytes.Buffer
+	ok(t, quotes.WriteHighstockTo(&b))
+	equals(t, quotes.Highstock(), b.String())
+	b.Reset()
+	ok(t, quotes.WriteCSVTo(&b))
+	equals(t, quotes.CSV(), b.String())
+}
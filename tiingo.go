@@ -0,0 +1,50 @@
+package quote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TiingoError - a request Tiingo answered with an error status, with the
+// detail message of its error body. Check for it with errors.As.
+type TiingoError struct {
+	Status int    // http status code
+	Detail string // e.g. "Invalid token." or "Ticker 'XXX' not found"
+}
+
+func (e *TiingoError) Error() string {
+	return fmt.Sprintf("tiingo %d: %s", e.Status, e.Detail)
+}
+
+// Fatal - true if the error applies to every request made with the same
+// token, a rejected token or an exceeded rate limit, rather than to one
+// symbol
+func (e *TiingoError) Fatal() bool {
+	return e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden || e.Status == http.StatusTooManyRequests
+}
+
+// tiingoError - the TiingoError of a response with status and body contents.
+// The detail is taken from a {"detail":"Error: ..."} envelope or a bare
+// "Error: ..." string, or the status text when the body has neither.
+func tiingoError(status int, contents []byte) *TiingoError {
+	var envelope struct {
+		Detail string `json:"detail"`
+	}
+	var msg string
+	if json.Unmarshal(contents, &envelope) == nil && envelope.Detail != "" {
+		msg = envelope.Detail
+	} else if json.Unmarshal(contents, &msg) != nil || msg == "" {
+		msg = http.StatusText(status)
+	}
+	return &TiingoError{Status: status, Detail: strings.TrimPrefix(msg, "Error: ")}
+}
+
+// tiingoFatal - true if err is a TiingoError that stops a multi-symbol
+// download
+func tiingoFatal(err error) bool {
+	var te *TiingoError
+	return errors.As(err, &te) && te.Fatal()
+}
@@ -0,0 +1,185 @@
+package quote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTiingoCrypto - tiingo crypto endpoint serving 5 minute bars between
+// first and last, returning at most limit bars per request
+func fakeTiingoCrypto(first, last time.Time, limit int, requests *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		start, _ := time.Parse("2006-1-2", r.URL.Query().Get("startDate"))
+		end, _ := time.Parse("2006-1-2", r.URL.Query().Get("endDate"))
+		end = end.Add(24 * time.Hour) // endDate is inclusive
+
+		type bar struct {
+			Date   string  `json:"date"`
+			Open   float64 `json:"open"`
+			High   float64 `json:"high"`
+			Low    float64 `json:"low"`
+			Close  float64 `json:"close"`
+			Volume float64 `json:"volume"`
+		}
+		var bars []bar
+		for d := start; d.Before(end) && !d.After(last) && len(bars) < limit; d = d.Add(5 * time.Minute) {
+			if d.Before(first) {
+				continue
+			}
+			c := float64(d.Unix()%1000) + 100
+			bars = append(bars, bar{d.Format(time.RFC3339), c, c + 1, c - 1, c, 10})
+		}
+		data, _ := json.Marshal([]map[string]interface{}{{"ticker": "btcusd", "priceData": bars}})
+		w.Write(data)
+	}
+}
+
+func TestTiingoCryptoChunked(t *testing.T) {
+	first := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(2023, 3, 1, 23, 55, 0, 0, time.UTC)
+	requests := 0
+	fakeServer(t, &tiingoURL, fakeTiingoCrypto(first, last, 1000, &requests))
+
+	q, err := NewQuoteFromTiingoCrypto("btcusd", "2023-01-01", "2023-03-01", Min5, "token")
+	ok(t, err)
+
+	// every 5 minute bar from first to last, once and in order
+	want := int(last.Sub(first)/(5*time.Minute)) + 1
+	equals(t, want, len(q.Date))
+	for bar := 1; bar < len(q.Date); bar++ {
+		if q.Date[bar].Sub(q.Date[bar-1]) != 5*time.Minute {
+			t.Fatalf("bar %d: %v follows %v", bar, q.Date[bar], q.Date[bar-1])
+		}
+	}
+	equals(t, first, q.Date[0].UTC())
+	equals(t, last, q.Date[len(q.Date)-1].UTC())
+	assert(t, requests > want/1000, "only %d requests", requests)
+}
+
+func TestTiingoCryptoChunkedDataEndsEarly(t *testing.T) {
+	// no data in the last days of the range must not loop or lose bars
+	first := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(2023, 1, 5, 12, 0, 0, 0, time.UTC)
+	requests := 0
+	fakeServer(t, &tiingoURL, fakeTiingoCrypto(first, last, 100000, &requests))
+
+	q, err := NewQuoteFromTiingoCrypto("btcusd", "2023-01-01", "2023-01-20", Min5, "token")
+	ok(t, err)
+	equals(t, int(last.Sub(first)/(5*time.Minute))+1, len(q.Date))
+	assert(t, requests <= 3, "%d requests", requests)
+}
+
+func TestTiingoCryptoChunk(t *testing.T) {
+	equals(t, 30*24*time.Hour, tiingoCryptoChunk(Min5, tiingoCryptoBars))
+	equals(t, 6*24*time.Hour, tiingoCryptoChunk(Min1, tiingoCryptoBars))
+	equals(t, 24*time.Hour, tiingoCryptoChunk(Min1, 10))
+}
+
+// tiingoWeekly - Tiingo prices resampled weekly from 2023-03-01 to
+// 2023-03-22, the first and last weeks cut by the range
+const tiingoWeekly = `[
+{"date":"2023-03-03T00:00:00.000Z","close":404.19,"high":404.4,"low":393.64,"open":395.41,"volume":236453561,"adjClose":396.83,"adjHigh":397.03,"adjLow":386.47,"adjOpen":388.21,"adjVolume":236453561,"divCash":0.0,"splitFactor":1.0},
+{"date":"2023-03-10T00:00:00.000Z","close":385.91,"high":407.45,"low":384.32,"open":405.81,"volume":532876341,"adjClose":378.88,"adjHigh":400.03,"adjLow":377.32,"adjOpen":398.42,"adjVolume":532876341,"divCash":0.0,"splitFactor":1.0},
+{"date":"2023-03-17T00:00:00.000Z","close":389.99,"high":396.47,"low":380.65,"open":381.81,"volume":685497468,"adjClose":385.08,"adjHigh":391.48,"adjLow":375.86,"adjOpen":377.0,"adjVolume":685497468,"divCash":1.506,"splitFactor":1.0},
+{"date":"2023-03-22T00:00:00.000Z","close":393.74,"high":402.49,"low":390.35,"open":390.8,"volume":325040264,"adjClose":388.78,"adjHigh":397.42,"adjLow":385.43,"adjOpen":385.88,"adjVolume":325040264,"divCash":0.0,"splitFactor":1.0}
+]`
+
+func TestTiingoWeekly(t *testing.T) {
+	var query url.Values
+	fakeServer(t, &tiingoURL, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, tiingoWeekly)
+	})
+
+	q, err := NewQuoteFromTiingo("spy", "2023-03-01", "2023-03-22", Weekly, "token")
+	ok(t, err)
+	equals(t, "weekly", query.Get("resampleFreq"))
+	equals(t, 4, len(q.Date))
+	equals(t, []time.Time{day(2023, 3, 3), day(2023, 3, 10), day(2023, 3, 17), day(2023, 3, 22)}, q.Date)
+
+	// adjusted columns, the partial last week as given
+	equals(t, []float64{388.21, 398.42, 377.0, 385.88}, q.Open)
+	equals(t, []float64{396.83, 378.88, 385.08, 388.78}, q.Close)
+	equals(t, 325040264.0, q.Volume[3])
+	equals(t, Weekly, q.Meta.Period)
+	equals(t, true, q.Meta.Adjusted)
+
+	_, err = NewQuoteFromTiingo("spy", "2023-03-01", "2023-03-22", Daily, "token")
+	ok(t, err)
+	equals(t, "", query.Get("resampleFreq"))
+
+	_, err = NewQuoteFromTiingo("spy", "2023-03-01", "2023-03-22", Min60, "token")
+	assert(t, err != nil, "expected invalid period error")
+}
+
+func TestTiingoError(t *testing.T) {
+	equals(t, &TiingoError{401, "Invalid token."}, tiingoError(401, []byte(`{"detail":"Invalid token."}`)))
+	equals(t, &TiingoError{404, "Ticker 'zz' not found"}, tiingoError(404, []byte(`"Error: Ticker 'zz' not found"`)))
+	equals(t, &TiingoError{502, "Bad Gateway"}, tiingoError(502, []byte(`<html>`)))
+}
+
+func TestTiingoErrorStatus(t *testing.T) {
+	requests := 0
+	fakeServer(t, &tiingoURL, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.Header.Get("Authorization") != "Token good":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"detail":"Invalid token."}`)
+		case strings.Contains(r.URL.Path, "/busy/"):
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"detail":"Error: You have run over your hourly request allocation."}`)
+		case strings.Contains(r.URL.Path, "/nope/"):
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"detail":"Error: Ticker 'NOPE' not found"}`)
+		default:
+			fmt.Fprint(w, tiingoDividendDaily)
+		}
+	})
+
+	tests := []struct {
+		symbol, token string
+		status        int
+		detail        string
+		fatal         bool
+	}{
+		{"spy", "bad", 401, "Invalid token.", true},
+		{"nope", "good", 404, "Ticker 'NOPE' not found", false},
+		{"busy", "good", 429, "You have run over your hourly request allocation.", true},
+	}
+	for _, tt := range tests {
+		q, err := NewQuoteFromTiingo(tt.symbol, "2023-03-13", "2023-03-17", Daily, tt.token)
+		var te *TiingoError
+		assert(t, errors.As(err, &te), "%s: unexpected error %v", tt.symbol, err)
+		equals(t, tt.status, te.Status)
+		equals(t, tt.detail, te.Detail)
+		equals(t, tt.fatal, te.Fatal())
+		equals(t, 0, len(q.Date))
+	}
+
+	// not found symbols are skipped
+	quotes, err := NewQuotesFromTiingoSyms([]string{"spy", "nope", "qqq"}, "2023-03-13", "2023-03-17", Daily, "good")
+	ok(t, err)
+	equals(t, 2, len(quotes))
+
+	// a bad token stops at the first symbol
+	requests = 0
+	quotes, err = NewQuotesFromTiingoSyms([]string{"spy", "nope", "qqq"}, "2023-03-13", "2023-03-17", Daily, "bad")
+	assert(t, tiingoFatal(err), "unexpected error %v", err)
+	equals(t, 0, len(quotes))
+	equals(t, 1, requests)
+
+	// and so does the rate limit, keeping the quotes so far
+	requests = 0
+	quotes, err = NewQuotesFromTiingoSyms([]string{"spy", "busy", "qqq"}, "2023-03-13", "2023-03-17", Daily, "good")
+	assert(t, tiingoFatal(err), "unexpected error %v", err)
+	equals(t, 1, len(quotes))
+	equals(t, 2, requests)
+}
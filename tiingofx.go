@@ -0,0 +1,120 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// tiingoFXPeriods - resample frequencies of the Tiingo forex api, the same as
+// for crypto, see tiingoCryptoFreqs
+var tiingoFXPeriods = []Period{Min1, Min3, Min5, Min15, Min30, Min60, Hour2, Hour4, Hour6, Hour8, Hour12, Daily}
+
+// fxPair - a currency pair as Tiingo names it, e.g. eurusd
+var fxPair = regexp.MustCompile(`^[a-zA-Z]{6}$`)
+
+// NewQuoteFromTiingoForex - Tiingo forex historical prices for a currency pair
+// such as eurusd. Forex bars have no volume, Volume is all zeros.
+func NewQuoteFromTiingoForex(pair, startDate, endDate string, period Period, token string) (Quote, error) {
+	return NewQuoteFromTiingoForexCtx(context.Background(), pair, startDate, endDate, period, token)
+}
+
+// NewQuoteFromTiingoForexCtx - NewQuoteFromTiingoForex, canceled with ctx
+func NewQuoteFromTiingoForexCtx(ctx context.Context, pair, startDate, endDate string, period Period, token string) (Quote, error) {
+
+	if err := ValidatePeriod("tiingo-fx", period); err != nil {
+		Log.Printf("tiingo error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	if !fxPair.MatchString(pair) {
+		return NewQuote("", 0), fmt.Errorf("invalid forex pair '%s', must be six letters like eurusd", pair)
+	}
+
+	from := ParseDateString(startDate)
+	to := ParseDateString(endDate)
+
+	url := fmt.Sprintf(
+		"%s/tiingo/fx/%s/prices?startDate=%s&endDate=%s&resampleFreq=%s",
+		tiingoURL,
+		strings.ToLower(pair),
+		url.QueryEscape(from.Format("2006-1-2")),
+		url.QueryEscape(to.Format("2006-1-2")),
+		tiingoCryptoFreqs[period])
+
+	if err := waitRate(ctx, "tiingo-fx"); err != nil {
+		return NewQuote("", 0), err
+	}
+	client := httpClient()
+	req, _ := newRequest(ctx, url)
+	req.Header.Set("Authorization", fmt.Sprintf("Token %s", token))
+	resp, err := client.Do(req)
+	if err != nil {
+		Log.Printf("tiingo error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	defer resp.Body.Close()
+	contents, _ := ioutil.ReadAll(resp.Body)
+
+	type fxBar struct {
+		Date  string  `json:"date"` // "2023-03-01T00:00:00.000Z"
+		Open  float64 `json:"open"`
+		High  float64 `json:"high"`
+		Low   float64 `json:"low"`
+		Close float64 `json:"close"`
+	}
+	var bars []fxBar
+	if resp.StatusCode != http.StatusOK {
+		err := tiingoError(resp.StatusCode, contents)
+		Log.Printf("tiingo error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+	if err := json.Unmarshal(contents, &bars); err != nil {
+		Log.Printf("tiingo error: %v\n", err)
+		return NewQuote("", 0), err
+	}
+
+	quote := NewQuote(pair, len(bars))
+	for bar, b := range bars {
+		quote.Date[bar], _ = time.Parse(time.RFC3339, b.Date)
+		quote.Open[bar] = b.Open
+		quote.High[bar] = b.High
+		quote.Low[bar] = b.Low
+		quote.Close[bar] = b.Close
+	}
+	return quote.withMeta("tiingo-fx", period, false), nil
+}
+
+// NewQuotesFromTiingoForexSyms - create a list of prices from pairs in string
+// array, stopping at the first fatal TiingoError
+func NewQuotesFromTiingoForexSyms(pairs []string, startDate, endDate string, period Period, token string) (Quotes, error) {
+	return NewQuotesFromTiingoForexSymsCtx(context.Background(), pairs, startDate, endDate, period, token)
+}
+
+// NewQuotesFromTiingoForexSymsCtx - NewQuotesFromTiingoForexSyms,
+// canceled with ctx. The quotes downloaded before are returned with the
+// wrapped error of ctx.
+func NewQuotesFromTiingoForexSymsCtx(ctx context.Context, pairs []string, startDate, endDate string, period Period, token string) (Quotes, error) {
+
+	quotes := Quotes{}
+	for i, pair := range pairs {
+		quote, err := NewQuoteFromTiingoForexCtx(ctx, pair, startDate, endDate, period, token)
+		if err != nil && ctx.Err() != nil {
+			return quotes, interrupted(ctx, "tiingo fx after %d of %d symbols", i, len(pairs))
+		}
+		if err == nil {
+			quotes = append(quotes, quote)
+		} else if tiingoFatal(err) {
+			return quotes, err
+		} else {
+			Log.Println("error downloading " + pair)
+		}
+		delayContext(ctx, "tiingo-fx")
+	}
+	return quotes, nil
+}
@@ -0,0 +1,56 @@
+package quote
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tiingoFXHourly - Tiingo forex prices for eurusd at 1hour
+const tiingoFXHourly = `[
+{"date":"2023-03-01T00:00:00.000Z","ticker":"eurusd","open":1.05792,"high":1.05881,"low":1.05764,"close":1.05846},
+{"date":"2023-03-01T01:00:00.000Z","ticker":"eurusd","open":1.05846,"high":1.05902,"low":1.05812,"close":1.05871},
+{"date":"2023-03-01T02:00:00.000Z","ticker":"eurusd","open":1.05871,"high":1.05893,"low":1.05798,"close":1.05823}
+]`
+
+func TestTiingoForex(t *testing.T) {
+	var path, freq string
+	fakeServer(t, &tiingoURL, func(w http.ResponseWriter, r *http.Request) {
+		path, freq = r.URL.Path, r.URL.Query().Get("resampleFreq")
+		if !strings.HasPrefix(path, "/tiingo/fx/eurusd/") {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"detail":"Error: Ticker 'xxxyyy' not found"}`)
+			return
+		}
+		fmt.Fprint(w, tiingoFXHourly)
+	})
+
+	q, err := NewQuoteFromTiingoForex("EURUSD", "2023-03-01", "2023-03-01", Min60, "token")
+	ok(t, err)
+	equals(t, "/tiingo/fx/eurusd/prices", path)
+	equals(t, "1hour", freq)
+	equals(t, 3, len(q.Date))
+	equals(t, time.Date(2023, 3, 1, 2, 0, 0, 0, time.UTC), q.Date[2].UTC())
+	equals(t, 1.05871, q.Close[1])
+	equals(t, []float64{0, 0, 0}, q.Volume)
+	equals(t, "USD", q.Meta.Currency)
+
+	// zero volume is written and read back
+	back, err := NewQuoteFromCSV("EURUSD", q.CSV())
+	ok(t, err)
+	equals(t, q.Volume, back.Volume)
+	equals(t, q.Close, back.Close)
+
+	_, err = NewQuoteFromTiingoForex("xxxyyy", "2023-03-01", "2023-03-01", Daily, "token")
+	assert(t, err != nil, "expected not found error")
+	equals(t, "tiingo 404: Ticker 'xxxyyy' not found", err.Error())
+
+	for _, pair := range []string{"eur", "eur/usd", "eurusd1"} {
+		_, err = NewQuoteFromTiingoForex(pair, "2023-03-01", "2023-03-01", Daily, "token")
+		assert(t, err != nil && strings.Contains(err.Error(), "invalid forex pair"), "%s: %v", pair, err)
+	}
+	_, err = NewQuoteFromTiingoForex("eurusd", "2023-03-01", "2023-03-01", Weekly, "token")
+	assert(t, err != nil, "expected invalid period error")
+}
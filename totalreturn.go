@@ -0,0 +1,79 @@
+package quote
+
+import (
+	"sort"
+	"time"
+)
+
+// Dividend - cash dividend per share, dated on its ex-dividend date
+type Dividend struct {
+	Date   time.Time
+	Amount float64
+}
+
+// Dividends - list of dividends
+type Dividends []Dividend
+
+// sameOrAfterDay - true if t falls on or after the calendar day of d
+func sameOrAfterDay(t, d time.Time) bool {
+	ty, tm, td := t.Date()
+	dy, dm, dd := d.Date()
+	if ty != dy {
+		return ty > dy
+	}
+	if tm != dm {
+		return tm > dm
+	}
+	return td >= dd
+}
+
+// TotalReturn - total return index of q with divs reinvested.
+//
+// On each ex-dividend date the dividend is reinvested at that day's close, so
+// the index starts at the first close and compounds (close+dividend)/prevclose
+// forward. Open, High and Low are scaled by the same factor as Close (the
+// number of shares held), so bars keep their shape. Dividends dated on a
+// non-trading day roll to the next bar; dividends on or before the first bar
+// or after the last bar are ignored with a logged warning. The returned
+// quote's symbol is suffixed with "_tr".
+func TotalReturn(q Quote, divs Dividends) Quote {
+
+	numrows := len(q.Close)
+	tr := NewQuote(q.Symbol+"_tr", numrows)
+	tr.Precision = q.Precision
+	copy(tr.Date, q.Date)
+	copy(tr.Volume, q.Volume)
+	tr.Trades = copyExtra(q.Trades)
+	tr.OpenInterest = copyExtra(q.OpenInterest)
+	if numrows == 0 {
+		return tr
+	}
+
+	// dividend paid on each bar
+	paid := make([]float64, numrows)
+	sorted := append(Dividends(nil), divs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+	bar := 0
+	for _, div := range sorted {
+		for bar < numrows && !sameOrAfterDay(q.Date[bar], div.Date) {
+			bar++
+		}
+		if bar == 0 || bar == numrows {
+			Log.Printf("%s dividend %.4f on %s outside price range, ignored\n", q.Symbol, div.Amount, div.Date.Format("2006-01-02"))
+			continue
+		}
+		paid[bar] += div.Amount
+	}
+
+	shares := 1.0
+	for bar := 0; bar < numrows; bar++ {
+		if paid[bar] != 0 && q.Close[bar] != 0 {
+			shares *= 1 + paid[bar]/q.Close[bar]
+		}
+		tr.Open[bar] = q.Open[bar] * shares
+		tr.High[bar] = q.High[bar] * shares
+		tr.Low[bar] = q.Low[bar] * shares
+		tr.Close[bar] = q.Close[bar] * shares
+	}
+	return tr
+}
@@ -0,0 +1,76 @@
+package quote
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// spyQuarter - SPY-like closes for a quarter with one ex-dividend date
+func spyQuarter() (Quote, Dividends) {
+	closes := []float64{
+		382.43, 383.76, 379.38, 388.08, 387.86, 390.58, 395.52, 396.96, 398.50, 395.88,
+		400.63, 406.48, 407.26, 404.75, 400.02, 401.12, 405.65, 404.19, 408.28, 412.35,
+		410.08, 413.98, 409.06, 412.64, 415.10, 408.28, 407.20, 404.41, 396.38, 392.10,
+		397.81, 395.15, 398.51, 400.47, 392.70, 393.74, 389.99, 396.49, 389.28, 385.36,
+		394.74, 396.82, 399.40, 393.74, 397.24, 398.22, 395.09, 401.81, 403.70, 405.42,
+	}
+	q := NewQuote("spy", len(closes))
+	d := day(2023, 1, 3)
+	for bar, c := range closes {
+		for d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			d = d.AddDate(0, 0, 1)
+		}
+		q.Date[bar] = d
+		q.Open[bar] = c - 1
+		q.High[bar] = c + 2
+		q.Low[bar] = c - 3
+		q.Close[bar] = c
+		q.Volume[bar] = 80e6
+		d = d.AddDate(0, 0, 1)
+	}
+	// ex-dividend on the 37th trading day
+	return q, Dividends{{Date: q.Date[36], Amount: 1.506}}
+}
+
+func TestTotalReturn(t *testing.T) {
+	q, divs := spyQuarter()
+	tr := TotalReturn(q, divs)
+
+	equals(t, "spy_tr", tr.Symbol)
+	equals(t, len(q.Close), len(tr.Close))
+	equals(t, q.Volume, tr.Volume)
+	// unchanged before the ex-date
+	equals(t, q.Close[:36], tr.Close[:36])
+
+	// reinvested at the ex-date close: shares grow by 1+div/close
+	shares := 1 + 1.506/q.Close[36]
+	for bar := 36; bar < len(q.Close); bar++ {
+		assert(t, math.Abs(tr.Close[bar]-q.Close[bar]*shares) < 1e-9, "bar %d close %v", bar, tr.Close[bar])
+		assert(t, math.Abs(tr.Open[bar]-q.Open[bar]*shares) < 1e-9, "bar %d open %v", bar, tr.Open[bar])
+	}
+
+	// compare with a provider style adjusted close, which scales history by 1-div/prevclose
+	adjRatio := q.Close[49] / (q.Close[0] * (1 - 1.506/q.Close[35]))
+	trRatio := tr.Close[49] / tr.Close[0]
+	assert(t, math.Abs(trRatio-adjRatio) < 1e-4, "total return %v vs adjusted %v", trRatio, adjRatio)
+}
+
+func TestTotalReturnDividendDates(t *testing.T) {
+	q, _ := spyQuarter()
+
+	// a saturday dividend rolls to monday
+	saturday := day(2023, 1, 7)
+	equals(t, time.Saturday, saturday.Weekday())
+	tr := TotalReturn(q, Dividends{{Date: saturday, Amount: 1}})
+	equals(t, q.Close[3], tr.Close[3])
+	equals(t, day(2023, 1, 9), tr.Date[4])
+	assert(t, tr.Close[4] > q.Close[4], "dividend did not roll to the next bar")
+
+	// dividends outside the price range are ignored
+	tr = TotalReturn(q, Dividends{{Date: day(2022, 12, 15), Amount: 1}, {Date: day(2024, 1, 1), Amount: 1}})
+	equals(t, q.Close, tr.Close)
+
+	empty := TotalReturn(NewQuote("x", 0), Dividends{{Date: day(2023, 1, 1), Amount: 1}})
+	equals(t, 0, len(empty.Close))
+}
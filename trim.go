@@ -0,0 +1,48 @@
+package quote
+
+import "time"
+
+// TrimPolicy - which bars Trim removes
+type TrimPolicy int
+
+const (
+	// TrimZeros - leading and trailing bars with a zero open, high, low and
+	// close, e.g. padding before a listing
+	TrimZeros TrimPolicy = 1 << iota
+	// TrimIncomplete - a last bar whose period has not ended yet, e.g. today's
+	// daily bar or the current minute
+	TrimIncomplete
+	// TrimBoth - TrimIncomplete, then TrimZeros
+	TrimBoth = TrimZeros | TrimIncomplete
+)
+
+// Trim - copy of q without the bars policy says, and the number of bars
+// removed. A bar is incomplete while the current time is before the end of
+// its period, that of q's metadata or else inferred; a quote of unknown
+// period has no incomplete bars. A quote of zero bars only trims to none.
+func (q Quote) Trim(policy TrimPolicy) (Quote, int) {
+	return q.trimAt(policy, time.Now())
+}
+
+// trimAt - Trim at the time now
+func (q Quote) trimAt(policy TrimPolicy, now time.Time) (Quote, int) {
+	start, end := 0, len(q.Date)
+	if policy&TrimIncomplete != 0 && end > 0 {
+		period := q.periodOrInfer("")
+		if period.Duration() > 0 && now.Before(periodEnd(q.Date[end-1], period)) {
+			end--
+		}
+	}
+	if policy&TrimZeros != 0 {
+		zero := func(bar int) bool {
+			return q.Open[bar] == 0 && q.High[bar] == 0 && q.Low[bar] == 0 && q.Close[bar] == 0
+		}
+		for start < end && zero(start) {
+			start++
+		}
+		for end > start && zero(end-1) {
+			end--
+		}
+	}
+	return q.slice(start, end).copy(), len(q.Date) - (end - start)
+}
@@ -0,0 +1,70 @@
+package quote
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrimZeros(t *testing.T) {
+	q := closesFixture(0, 0, 10, 0, 11, 0)
+	q.Volume[0] = 5 // padding with volume is still padding
+
+	trimmed, n := q.trimAt(TrimZeros, day(2024, 1, 1))
+	equals(t, 3, n)
+	equals(t, []float64{10, 0, 11}, trimmed.Close)
+	equals(t, q.Date[2:5], trimmed.Date)
+	trimmed.Close[0] = 1
+	equals(t, 10.0, q.Close[2])
+
+	// bars with only some prices zero are kept
+	q.High[0] = 1
+	trimmed, n = q.trimAt(TrimZeros, day(2024, 1, 1))
+	equals(t, 1, n)
+	equals(t, q.Date[:5], trimmed.Date)
+
+	// a quote of zeros only trims to none
+	zeros := closesFixture(0, 0, 0)
+	trimmed, n = zeros.Trim(TrimBoth)
+	equals(t, 3, n)
+	equals(t, 0, trimmed.Len())
+	trimmed, n = NewQuote("spy", 0).Trim(TrimBoth)
+	equals(t, 0, n)
+	equals(t, 0, trimmed.Len())
+}
+
+func TestTrimIncomplete(t *testing.T) {
+	// daily bars from the 2nd to the 4th of january
+	q := closesFixture(10, 11, 12)
+
+	trimmed, n := q.trimAt(TrimIncomplete, time.Date(2023, 1, 4, 15, 0, 0, 0, time.UTC))
+	equals(t, 1, n)
+	equals(t, q.Date[:2], trimmed.Date)
+	trimmed, n = q.trimAt(TrimIncomplete, day(2023, 1, 5))
+	equals(t, 0, n)
+	equals(t, q, trimmed)
+	// only the last bar
+	trimmed, n = q.trimAt(TrimIncomplete, day(2023, 1, 2))
+	equals(t, 1, n)
+	equals(t, 2, trimmed.Len())
+
+	// the current minute of intraday bars
+	m1 := resampleFixture(time.Date(2023, 5, 2, 9, 30, 0, 0, time.UTC), Min1, 3)
+	_, n = m1.trimAt(TrimIncomplete, time.Date(2023, 5, 2, 9, 32, 59, 0, time.UTC))
+	equals(t, 1, n)
+	_, n = m1.trimAt(TrimIncomplete, time.Date(2023, 5, 2, 9, 33, 0, 0, time.UTC))
+	equals(t, 0, n)
+
+	// zeros before an incomplete bar go with it
+	q = closesFixture(10, 0, 12)
+	trimmed, n = q.trimAt(TrimBoth, time.Date(2023, 1, 4, 15, 0, 0, 0, time.UTC))
+	equals(t, 2, n)
+	equals(t, []float64{10}, trimmed.Close)
+	_, n = q.trimAt(TrimZeros, time.Date(2023, 1, 4, 15, 0, 0, 0, time.UTC))
+	equals(t, 0, n)
+
+	// without a period no bar is known to be incomplete
+	one := NewQuote("spy", 0)
+	one.PushBar(Bar{Date: day(2023, 1, 2), Close: 1})
+	_, n = one.trimAt(TrimIncomplete, day(2023, 1, 2))
+	equals(t, 0, n)
+}
@@ -0,0 +1,44 @@
+package quote
+
+import "time"
+
+// IntradayLocation - location the dates of intraday bars are converted to at
+// download, e.g. time.UTC or the exchange's, nil to keep the dates as each
+// source returns them. Daily and longer bars keep their dates.
+var IntradayLocation *time.Location
+
+// ConvertTZ - copy of q with every date in loc, UTC if nil, and loc recorded
+// as the Timezone of its metadata. The instants of the bars are unchanged,
+// only their clock time, so daily bars dated midnight UTC move to the
+// previous evening west of Greenwich.
+func (q Quote) ConvertTZ(loc *time.Location) Quote {
+	if loc == nil {
+		loc = time.UTC
+	}
+	c := q.copy()
+	for bar, date := range c.Date {
+		c.Date[bar] = date.In(loc)
+	}
+	if c.Meta != nil {
+		c.Meta.Timezone = loc.String()
+	}
+	return c
+}
+
+// ConvertTZ - ConvertTZ of each member of q
+func (q Quotes) ConvertTZ(loc *time.Location) Quotes {
+	out := make(Quotes, len(q))
+	for i, quote := range q {
+		out[i] = quote.ConvertTZ(loc)
+	}
+	return out
+}
+
+// inIntradayLocation - q converted to IntradayLocation if set and period
+// is shorter than a day
+func (q Quote) inIntradayLocation(period Period) Quote {
+	if IntradayLocation == nil || period.Duration() == 0 || period.Duration() >= 24*time.Hour {
+		return q
+	}
+	return q.ConvertTZ(IntradayLocation)
+}
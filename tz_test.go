@@ -0,0 +1,98 @@
+package quote
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// springForward - hourly utc bars over the night new york clocks skip 02:00
+func springForward() Quote {
+	q := NewQuote("BTC-USD", 0)
+	for h := 0; h < 6; h++ {
+		c := 100 + float64(h)
+		q.PushBar(Bar{Date: time.Date(2023, 3, 12, 4+h, 0, 0, 0, time.UTC), Open: c, High: c, Low: c, Close: c, Volume: 1})
+	}
+	return q.withMeta("coinbase", Min60, false)
+}
+
+func TestConvertTZ(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	ok(t, err)
+	q := springForward()
+	equals(t, "UTC", q.Meta.Timezone)
+
+	local := q.ConvertTZ(ny)
+	equals(t, "America/New_York", local.Meta.Timezone)
+	equals(t, "UTC", q.Meta.Timezone)
+	hours := make([]int, local.Len())
+	for bar, d := range local.Date {
+		assert(t, d.Equal(q.Date[bar]), "bar %d moved from %v to %v", bar, q.Date[bar], d)
+		equals(t, ny, d.Location())
+		hours[bar] = d.Hour()
+	}
+	// 02:00 does not exist that night, the bars are still an hour apart
+	equals(t, []int{23, 0, 1, 3, 4, 5}, hours)
+	equals(t, time.Hour, local.Date[3].Sub(local.Date[2]))
+	equals(t, time.UTC, q.Date[0].Location())
+
+	utc := local.ConvertTZ(nil)
+	equals(t, q.Date, utc.Date)
+	equals(t, "UTC", utc.Meta.Timezone)
+
+	quotes := Quotes{q, q}.ConvertTZ(ny)
+	equals(t, local, quotes[1])
+}
+
+func TestCSVOffset(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	ok(t, err)
+	local := springForward().ConvertTZ(ny)
+
+	opts := DefaultCSVOptions
+	opts.Offset = true
+	csv, err := local.CSVWithOptions(opts)
+	ok(t, err)
+	lines := strings.Split(csv, "\n")
+	equals(t, "2023-03-11 23:00-05:00", lines[1][:22])
+	equals(t, "2023-03-12 01:00-05:00", lines[3][:22])
+	equals(t, "2023-03-12 03:00-04:00", lines[4][:22])
+
+	read, err := NewQuoteFromCSVWithOptions("BTC-USD", csv, opts)
+	ok(t, err)
+	for bar, d := range read.Date {
+		assert(t, d.Equal(local.Date[bar]), "bar %d read as %v, not %v", bar, d, local.Date[bar])
+	}
+	_, offset := read.Date[4].Zone()
+	equals(t, -4*3600, offset)
+	_, err = NewQuoteFromCSVWithOptions("BTC-USD", "datetime,open,high,low,close,volume\n2023-03-12 03:00,1,1,1,1,1\n", opts)
+	assert(t, err != nil, "date without offset read")
+
+	// daily dates of a layout read back before their offset
+	opts.Layout = CSVLayoutBacktrader
+	daily := csvLayoutFixture()
+	csv, err = daily.CSVWithOptions(opts)
+	ok(t, err)
+	equals(t, "2023-01-30+00:00", csv[strings.Index(csv, "\n")+1:][:16])
+	read, err = NewQuoteFromCSVWithOptions("aapl", csv, opts)
+	ok(t, err)
+	equals(t, daily.Date, read.Date)
+
+}
+
+func TestIntradayLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	ok(t, err)
+	IntradayLocation = ny
+	defer func() { IntradayLocation = nil }()
+
+	q := springForward()
+	equals(t, "America/New_York", q.Meta.Timezone)
+	equals(t, ny, q.Date[0].Location())
+	equals(t, time.Date(2023, 3, 12, 4, 0, 0, 0, time.UTC), q.Date[0].UTC())
+
+	// daily bars keep their dates
+	daily := csvLayoutFixture().withMeta("yahoo", Daily, true)
+	equals(t, "UTC", daily.Meta.Timezone)
+	equals(t, day(2023, 1, 30), daily.Date[0])
+}
@@ -0,0 +1,80 @@
+package quote
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ValidationError - a bar of a quote failing Validate
+type ValidationError struct {
+	Symbol string
+	Bar    int // index of the bar, -1 for the quote as a whole
+	Date   time.Time
+	Reason string
+}
+
+func (e ValidationError) Error() string {
+	if e.Bar < 0 {
+		return fmt.Sprintf("%s: %s", e.Symbol, e.Reason)
+	}
+	return fmt.Sprintf("%s bar %d (%s): %s", e.Symbol, e.Bar, e.Date.Format(time.RFC3339), e.Reason)
+}
+
+// ohlcProblems - reasons bar has inconsistent or negative prices or volume,
+// nil if it has none
+func (q Quote) ohlcProblems(bar int) []string {
+	var problems []string
+	for i, v := range []float64{q.Open[bar], q.High[bar], q.Low[bar], q.Close[bar], q.Volume[bar]} {
+		if v < 0 {
+			problems = append(problems, fmt.Sprintf("negative %s %g", columnName(i), v))
+		}
+	}
+	o, h, l, c := q.Open[bar], q.High[bar], q.Low[bar], q.Close[bar]
+	if h < math.Max(o, c) {
+		problems = append(problems, fmt.Sprintf("high %g below open %g or close %g", h, o, c))
+	}
+	if l > math.Min(o, c) {
+		problems = append(problems, fmt.Sprintf("low %g above open %g or close %g", l, o, c))
+	}
+	if l > h {
+		problems = append(problems, fmt.Sprintf("low %g above high %g", l, h))
+	}
+	return problems
+}
+
+// Validate - findings of bars with a high below the open or close, a low
+// above them or above the high, negative prices or volume, a zero date or a
+// date not after that of the previous bar, in bar order. Empty if q is valid.
+func (q Quote) Validate() []ValidationError {
+	var findings []ValidationError
+	if err := q.checkColumns(); err != nil {
+		return []ValidationError{{Symbol: q.Symbol, Bar: -1, Reason: err.Error()}}
+	}
+	for bar, date := range q.Date {
+		add := func(reason string) {
+			findings = append(findings, ValidationError{Symbol: q.Symbol, Bar: bar, Date: date, Reason: reason})
+		}
+		if date.IsZero() {
+			add("zero date")
+		} else if bar > 0 && !q.Date[bar-1].IsZero() && !date.After(q.Date[bar-1]) {
+			add(fmt.Sprintf("date not after previous bar at %s", q.Date[bar-1].Format(time.RFC3339)))
+		}
+		for _, reason := range q.ohlcProblems(bar) {
+			add(reason)
+		}
+	}
+	return findings
+}
+
+// Validate - the Validate findings of each member of q by symbol, only
+// symbols with findings
+func (q Quotes) Validate() map[string][]ValidationError {
+	findings := map[string][]ValidationError{}
+	for _, quote := range q {
+		if f := quote.Validate(); len(f) > 0 {
+			findings[quote.Symbol] = append(findings[quote.Symbol], f...)
+		}
+	}
+	return findings
+}
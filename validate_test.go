@@ -0,0 +1,74 @@
+package quote
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validateReasons - bar and reason of each finding
+func validateReasons(findings []ValidationError) []string {
+	reasons := make([]string, len(findings))
+	for i, f := range findings {
+		reasons[i] = f.Error()[strings.Index(f.Error(), "bar"):]
+	}
+	return reasons
+}
+
+func TestValidate(t *testing.T) {
+	spy := partitionFixture()[0]
+	equals(t, 0, len(spy.Validate()))
+
+	// each bar corrupted a different way
+	bad := csvLayoutFixture()
+	bad.PushBar(Bar{Date: day(2023, 2, 2), Open: 146, High: 145, Low: 144, Close: 145.5, Volume: 1})
+	bad.PushBar(Bar{Date: day(2023, 2, 3), Open: 146, High: 147, Low: 146.5, Close: 145.5, Volume: 1})
+	bad.PushBar(Bar{Date: day(2023, 2, 6), Open: 146, High: 145, Low: 147, Close: 146, Volume: -5})
+	bad.PushBar(Bar{Date: day(2023, 2, 6), Open: 146, High: 147, Low: 145, Close: 146, Volume: 1})
+	bad.PushBar(Bar{Date: day(2023, 2, 1), Open: 146, High: 147, Low: 145, Close: 146, Volume: 1})
+	bad.PushBar(Bar{Open: 146, High: 147, Low: 145, Close: 146, Volume: 1})
+	bad.PushBar(Bar{Date: day(2023, 2, 8), Open: -1, High: 0, Low: -2, Close: -1, Volume: 0})
+	equals(t, []string{
+		"bar 3 (2023-02-02T00:00:00Z): high 145 below open 146 or close 145.5",
+		"bar 4 (2023-02-03T00:00:00Z): low 146.5 above open 146 or close 145.5",
+		"bar 5 (2023-02-06T00:00:00Z): negative volume -5",
+		"bar 5 (2023-02-06T00:00:00Z): high 145 below open 146 or close 146",
+		"bar 5 (2023-02-06T00:00:00Z): low 147 above open 146 or close 146",
+		"bar 5 (2023-02-06T00:00:00Z): low 147 above high 145",
+		"bar 6 (2023-02-06T00:00:00Z): date not after previous bar at 2023-02-06T00:00:00Z",
+		"bar 7 (2023-02-01T00:00:00Z): date not after previous bar at 2023-02-06T00:00:00Z",
+		"bar 8 (0001-01-01T00:00:00Z): zero date",
+		"bar 9 (2023-02-08T00:00:00Z): negative open -1",
+		"bar 9 (2023-02-08T00:00:00Z): negative low -2",
+		"bar 9 (2023-02-08T00:00:00Z): negative close -1",
+	}, validateReasons(bad.Validate()))
+	findings := bad.Validate()
+	equals(t, "aapl", findings[0].Symbol)
+	equals(t, 3, findings[0].Bar)
+	equals(t, day(2023, 2, 2), findings[0].Date)
+	equals(t, "high 145 below open 146 or close 145.5", findings[0].Reason)
+
+	// columns of different lengths are a finding of the whole quote
+	bad.Volume = bad.Volume[:2]
+	findings = bad.Validate()
+	equals(t, 1, len(findings))
+	equals(t, -1, findings[0].Bar)
+	assert(t, strings.HasPrefix(findings[0].Error(), "aapl: "), "unexpected finding %v", findings[0])
+}
+
+func TestValidateQuotes(t *testing.T) {
+	quotes := partitionFixture()
+	quotes[1].Open[0], quotes[1].High[0], quotes[1].Low[0] = 22000, 22000, 22000
+	equals(t, map[string][]ValidationError{}, quotes.Validate())
+
+	quotes[0].High[1] = 0
+	quotes[1].Date[0] = time.Time{}
+	extra := NewQuote("spy", 1)
+	extra.Date[0], extra.Volume[0] = day(2023, 5, 1), -1
+	quotes = append(quotes, extra)
+	findings := quotes.Validate()
+	equals(t, 2, len(findings))
+	equals(t, 3, len(findings["spy"]))
+	equals(t, "negative volume -1", findings["spy"][2].Reason)
+	equals(t, []ValidationError{{Symbol: "BTC/USD", Bar: 0, Reason: "zero date"}}, findings["BTC/USD"])
+}
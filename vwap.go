@@ -0,0 +1,80 @@
+package quote
+
+import (
+	"math"
+	"time"
+)
+
+// VWAPOptions - options of VWAPWithOptions
+type VWAPOptions struct {
+	// ResetDaily - restart the cumulative VWAP of window 0 at the first bar
+	// of each day, for intraday bars
+	ResetDaily bool
+	// Location - time zone of the days of ResetDaily, that of the bars if nil
+	Location *time.Location
+}
+
+// DefaultVWAPOptions - options of VWAP, cumulative from the first bar
+var DefaultVWAPOptions = VWAPOptions{}
+
+// TypicalPrice - (high+low+close)/3 of each bar of q
+func (q Quote) TypicalPrice() []float64 {
+	tp := make([]float64, len(q.Close))
+	for bar := range q.Close {
+		tp[bar] = (q.High[bar] + q.Low[bar] + q.Close[bar]) / 3
+	}
+	return tp
+}
+
+// VWAP - volume weighted average TypicalPrice of each bar of q over the
+// window bars ending at it, or cumulative from the first bar for a window of
+// 0, see VWAPWithOptions
+func (q Quote) VWAP(window int) []float64 {
+	return q.VWAPWithOptions(window, DefaultVWAPOptions)
+}
+
+// VWAPWithOptions - VWAP, the cumulative VWAP of window 0 restarted daily as
+// opts. The first window-1 bars, bars without volume in their window and all
+// bars of a negative window are NaN.
+func (q Quote) VWAPWithOptions(window int, opts VWAPOptions) []float64 {
+	vwap := make([]float64, len(q.Close))
+	tp := q.TypicalPrice()
+	var pv, v float64
+	traded := 0 // bars with volume in the window
+	var day time.Time
+	for bar := range q.Close {
+		if window == 0 && opts.ResetDaily {
+			t := q.Date[bar]
+			if opts.Location != nil {
+				t = t.In(opts.Location)
+			}
+			y, m, d := t.Date()
+			if start := time.Date(y, m, d, 0, 0, 0, 0, t.Location()); !start.Equal(day) {
+				day = start
+				pv, v, traded = 0, 0, 0
+			}
+		}
+		pv += tp[bar] * q.Volume[bar]
+		v += q.Volume[bar]
+		if q.Volume[bar] != 0 {
+			traded++
+		}
+		if window > 0 && bar >= window {
+			pv -= tp[bar-window] * q.Volume[bar-window]
+			v -= q.Volume[bar-window]
+			if q.Volume[bar-window] != 0 {
+				traded--
+			}
+		}
+		if traded == 0 {
+			// drop what subtracting fractional volumes leaves, e.g. 1e-17
+			pv, v = 0, 0
+		}
+		if window < 0 || bar < window-1 || v <= 0 {
+			vwap[bar] = math.NaN()
+			continue
+		}
+		vwap[bar] = pv / v
+	}
+	return vwap
+}
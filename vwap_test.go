@@ -0,0 +1,84 @@
+package quote
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// vwapFixture - five daily bars of typical price 11 to 15, the third without
+// volume
+func vwapFixture() Quote {
+	q := NewQuote("spy", 0)
+	for i, v := range []float64{100, 200, 0, 100, 200} {
+		f := float64(i)
+		q.PushBar(Bar{Date: day(2023, 1, 2).AddDate(0, 0, i), Open: 11 + f, High: 12 + f, Low: 10 + f, Close: 11 + f, Volume: v})
+	}
+	return q
+}
+
+// nearNaN - got is want, NaN where want is
+func nearNaN(t *testing.T, want, got []float64, what string) {
+	t.Helper()
+	equals(t, len(want), len(got))
+	for i := range want {
+		if math.IsNaN(want[i]) {
+			assert(t, math.IsNaN(got[i]), "%s %d: want NaN got %v", what, i, got[i])
+			continue
+		}
+		near(t, want[i], got[i], what)
+	}
+}
+
+func TestTypicalPrice(t *testing.T) {
+	q := vwapFixture()
+	q.Close[1] = 11.5
+	equals(t, []float64{11, 35.5 / 3, 13, 14, 15}, q.TypicalPrice())
+	equals(t, []float64{}, NewQuote("spy", 0).TypicalPrice())
+}
+
+func TestVWAP(t *testing.T) {
+	q := vwapFixture()
+	nan := math.NaN()
+
+	// (11*100+12*200)/300, the bar without volume leaves it, (3500+14*100)/400
+	nearNaN(t, []float64{11, 35.0 / 3, 35.0 / 3, 12.25, 79.0 / 6}, q.VWAP(0), "cumulative vwap")
+	// warm-up, then (12*200+13*0)/200, (13*0+14*100)/100, (1400+15*200)/300
+	nearNaN(t, []float64{nan, 35.0 / 3, 12, 14, 44.0 / 3}, q.VWAP(2), "2 bar vwap")
+	nearNaN(t, []float64{nan, nan, 35.0 / 3, 38.0 / 3, 44.0 / 3}, q.VWAP(3), "3 bar vwap")
+	// a window without volume
+	nearNaN(t, []float64{11, 12, nan, 14, 15}, q.VWAP(1), "1 bar vwap")
+	nearNaN(t, []float64{nan, nan, nan, nan, nan}, q.VWAP(6), "vwap of a longer window")
+	nearNaN(t, []float64{nan, nan, nan, nan, nan}, q.VWAP(-1), "vwap of a negative window")
+
+	// no volume on the first bar
+	q.Volume[0] = 0
+	nearNaN(t, []float64{nan, 12, 12, 38.0 / 3, 13.6}, q.VWAP(0), "vwap from a bar without volume")
+}
+
+func TestVWAPFractionalVolume(t *testing.T) {
+	// crypto volumes, then bars without volume where the running total of a
+	// window does not return to exactly 0
+	q := NewQuote("btc", 0)
+	for i, v := range []float64{0.1, 0.2, 0.3, 0, 0, 0, 0.7} {
+		q.PushBar(Bar{Date: day(2023, 1, 2).AddDate(0, 0, i), Open: 10, High: 10, Low: 10, Close: 10, Volume: v})
+	}
+	nan := math.NaN()
+	nearNaN(t, []float64{nan, 10, 10, 10, nan, nan, 10}, q.VWAP(2), "2 bar vwap")
+	nearNaN(t, []float64{10, 10, 10, nan, nan, nan, 10}, q.VWAP(1), "1 bar vwap")
+}
+
+func TestVWAPResetDaily(t *testing.T) {
+	// typical prices 1/12, 1+1/12, ... one minute apart around midnight
+	q := resampleFixture(time.Date(2023, 5, 1, 23, 58, 0, 0, time.UTC), Min1, 4)
+	tp := 1.0 / 12
+
+	nearNaN(t, []float64{tp, 0.5 + tp, 1 + tp, 1.5 + tp}, q.VWAP(0), "cumulative vwap")
+	daily := q.VWAPWithOptions(0, VWAPOptions{ResetDaily: true})
+	nearNaN(t, []float64{tp, 0.5 + tp, 2 + tp, 2.5 + tp}, daily, "daily vwap")
+
+	// 21:58 to 22:01 in UTC-2, a single day
+	loc := time.FixedZone("UTC-2", -2*60*60)
+	daily = q.VWAPWithOptions(0, VWAPOptions{ResetDaily: true, Location: loc})
+	nearNaN(t, q.VWAP(0), daily, "daily vwap in UTC-2")
+}
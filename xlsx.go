@@ -0,0 +1,239 @@
+package quote
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xlsxMaxSheetName - longest worksheet name Excel accepts
+const xlsxMaxSheetName = 31
+
+// xlsx cell styles, indexes of cellXfs in xlsxStyles
+const (
+	xlsxStyleHeader   = 1
+	xlsxStyleDate     = 2
+	xlsxStyleDateTime = 3
+	xlsxStylePrice2   = 4
+	xlsxStylePrice8   = 5
+	xlsxStyleVolume   = 6
+)
+
+// xlsxStyles - bold headers, dates, prices with 2 or 8 decimals (the
+// precisions of getPrecision) and volume with thousands separators
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="4"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm"/><numFmt numFmtId="166" formatCode="0.00"/><numFmt numFmtId="167" formatCode="0.00000000"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="7"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="167" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>
+<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>
+</styleSheet>`
+
+// xlsxEpoch - day zero of Excel serial dates
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// xlsxSerial - Excel serial date of the wall clock time of t
+func xlsxSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return wall.Sub(xlsxEpoch).Hours() / 24
+}
+
+// xlsxSheetNames - a worksheet name for each quote, its symbol without the
+// characters Excel rejects, at most 31 characters and unique ignoring case
+func xlsxSheetNames(quotes Quotes) []string {
+	names := make([]string, len(quotes))
+	used := map[string]bool{"history": true} // reserved by Excel
+	for i, q := range quotes {
+		base := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`:\/?*[]`, r) || r < 0x20 {
+				return '_'
+			}
+			return r
+		}, q.Symbol)
+		base = strings.Trim(base, "'")
+		if base == "" {
+			base = "Sheet"
+		}
+		name := xlsxTruncate(base, xlsxMaxSheetName)
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			suffix := fmt.Sprintf(" (%d)", n)
+			name = xlsxTruncate(base, xlsxMaxSheetName-len(suffix)) + suffix
+		}
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+// xlsxTruncate - the first max runes of s
+func xlsxTruncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) > max {
+		r = r[:max]
+	}
+	return string(r)
+}
+
+// xlsxColumn - column letters of the 0 based column index col
+func xlsxColumn(col int) string {
+	s := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		s = string(rune('A'+(col-1)%26)) + s
+	}
+	return s
+}
+
+// xlsxEscape - s escaped for xml text and attributes
+func xlsxEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// xlsxSheet - worksheet xml of q, a header row then a row per bar with the
+// date as a date cell and optional columns after volume
+func xlsxSheet(q Quote) string {
+	present := q.extrasPresent()
+	header := []string{"date", "open", "high", "low", "close", "volume"}
+	for i, x := range extraColumns {
+		if present[i] {
+			header = append(header, x.name)
+		}
+	}
+	dateStyle := xlsxStyleDate
+	if q.intraday() {
+		dateStyle = xlsxStyleDateTime
+	}
+	priceStyle := xlsxStylePrice2
+	if p := q.precision(0); p > 2 || p == FullPrecision {
+		priceStyle = xlsxStylePrice8
+	}
+
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<cols><col min="1" max="1" width="18" customWidth="1"/></cols><sheetData>`)
+	b.WriteString(`<row r="1">`)
+	for col, name := range header {
+		fmt.Fprintf(&b, `<c r="%s1" t="inlineStr" s="%d"><is><t>%s</t></is></c>`, xlsxColumn(col), xlsxStyleHeader, name)
+	}
+	b.WriteString(`</row>`)
+	num := func(col, row int, v float64, style int) {
+		fmt.Fprintf(&b, `<c r="%s%d" s="%d"><v>%s</v></c>`, xlsxColumn(col), row, style, strconv.FormatFloat(v, 'g', -1, 64))
+	}
+	for bar := range q.Close {
+		row := bar + 2
+		fmt.Fprintf(&b, `<row r="%d">`, row)
+		num(0, row, xlsxSerial(q.Date[bar]), dateStyle)
+		num(1, row, q.Open[bar], priceStyle)
+		num(2, row, q.High[bar], priceStyle)
+		num(3, row, q.Low[bar], priceStyle)
+		num(4, row, q.Close[bar], priceStyle)
+		num(5, row, q.Volume[bar], xlsxStyleVolume)
+		col := 6
+		for i, x := range extraColumns {
+			if !present[i] {
+				continue
+			}
+			style := 0
+			if x.digits < 0 {
+				style = priceStyle
+			}
+			num(col, row, (*x.col(&q))[bar], style)
+			col++
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// writeXLSX - write quotes as an xlsx workbook with a worksheet per quote
+func (q Quotes) writeXLSX(w io.Writer) error {
+	names := xlsxSheetNames(q)
+	var types, sheets, rels bytes.Buffer
+	for i, name := range names {
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xlsxEscape(name), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(names)+1)
+
+	const decl = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", decl + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			types.String() + `</Types>`},
+		{"_rels/.rels", decl + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", decl + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", decl + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, part := range parts {
+		f, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(f, part.body); err != nil {
+			return err
+		}
+	}
+	for i, quote := range q {
+		f, err := archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(f, xlsxSheet(quote)); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// WriteXLSX - write Quote struct to a single sheet Excel workbook
+func (q Quote) WriteXLSX(filename string) error {
+	if filename == "" {
+		if q.Symbol != "" {
+			filename = q.Symbol + ".xlsx"
+		} else {
+			filename = "quote.xlsx"
+		}
+	}
+	return Quotes{q}.WriteXLSX(filename)
+}
+
+// WriteXLSX - write Quotes structure to an Excel workbook with a worksheet
+// per quote named after its symbol. Dates are date cells and prices and
+// volume are numbers. A quote without bars gets a sheet of only the header.
+func (q Quotes) WriteXLSX(filename string) error {
+	if filename == "" {
+		filename = "quotes.xlsx"
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err = q.writeXLSX(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
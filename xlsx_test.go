@@ -0,0 +1,130 @@
+package quote
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// xlsxRow - cells of a worksheet row as read back
+type xlsxRow struct {
+	Cells []struct {
+		Ref    string `xml:"r,attr"`
+		Style  int    `xml:"s,attr"`
+		Type   string `xml:"t,attr"`
+		Value  string `xml:"v"`
+		Inline string `xml:"is>t"`
+	} `xml:"c"`
+}
+
+// readXLSX - the parts of an xlsx file
+func readXLSX(t *testing.T, filename string) map[string][]byte {
+	archive, err := zip.OpenReader(filename)
+	ok(t, err)
+	defer archive.Close()
+	parts := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		ok(t, err)
+		parts[f.Name], err = ioutil.ReadAll(r)
+		ok(t, err)
+		r.Close()
+	}
+	return parts
+}
+
+func TestWriteXLSX(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xlsx")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	spy := partitionFixture()[0]
+	long := NewQuote("A:VERY/LONG*SYMBOL[NAME]-OF-MORE-THAN-31", 0)
+	filename := filepath.Join(dir, "quotes.xlsx")
+	ok(t, Quotes{spy, NewQuote("SPY", 0), long, NewQuote("", 0)}.WriteXLSX(filename))
+	parts := readXLSX(t, filename)
+
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	ok(t, xml.Unmarshal(parts["xl/workbook.xml"], &wb))
+	names := []string{}
+	for _, s := range wb.Sheets {
+		names = append(names, s.Name)
+	}
+	equals(t, []string{"spy", "SPY (2)", "A_VERY_LONG_SYMBOL_NAME_-OF-MOR", "Sheet"}, names)
+	for _, part := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels", "xl/styles.xml",
+		"xl/worksheets/sheet1.xml", "xl/worksheets/sheet4.xml"} {
+		_, found := parts[part]
+		assert(t, found, "missing part %s", part)
+	}
+
+	var sheet struct {
+		Rows []xlsxRow `xml:"sheetData>row"`
+	}
+	ok(t, xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet))
+	equals(t, 5, len(sheet.Rows))
+	header := []string{}
+	for _, c := range sheet.Rows[0].Cells {
+		header = append(header, c.Inline)
+	}
+	equals(t, []string{"date", "open", "high", "low", "close", "volume"}, header)
+
+	// 2023-01-30 is serial 44956, the second bar is intraday
+	first, second := sheet.Rows[1].Cells, sheet.Rows[2].Cells
+	equals(t, "A2", first[0].Ref)
+	equals(t, "44956", first[0].Value)
+	equals(t, xlsxStyleDateTime, first[0].Style)
+	equals(t, "", first[0].Type)
+	equals(t, "100", first[1].Value)
+	equals(t, xlsxStylePrice2, first[1].Style)
+	equals(t, "1000", first[5].Value)
+	equals(t, xlsxStyleVolume, first[5].Style)
+	serial := xlsxSerial(time.Date(2023, 1, 31, 23, 59, 0, 0, time.UTC))
+	assert(t, math.Abs(serial-(44957+1439/1440.0)) < 1e-9, "serial %v", serial)
+	equals(t, "F3", second[5].Ref)
+
+	// empty quotes get only the header
+	var empty struct {
+		Rows []xlsxRow `xml:"sheetData>row"`
+	}
+	ok(t, xml.Unmarshal(parts["xl/worksheets/sheet2.xml"], &empty))
+	equals(t, 1, len(empty.Rows))
+}
+
+func TestWriteXLSXQuote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xlsx")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	btc := partitionFixture()[1]
+	btc.AdjClose = []float64{21900}
+	filename := filepath.Join(dir, "btc.xlsx")
+	ok(t, btc.WriteXLSX(filename))
+	parts := readXLSX(t, filename)
+	_, found := parts["xl/worksheets/sheet2.xml"]
+	assert(t, !found, "more than one sheet")
+
+	var sheet struct {
+		Rows []xlsxRow `xml:"sheetData>row"`
+	}
+	ok(t, xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet))
+	equals(t, 2, len(sheet.Rows))
+	equals(t, "adjclose", sheet.Rows[0].Cells[6].Inline)
+	equals(t, "21900", sheet.Rows[1].Cells[6].Value)
+	equals(t, xlsxStylePrice8, sheet.Rows[1].Cells[6].Style)
+	equals(t, xlsxStyleDate, sheet.Rows[1].Cells[0].Style)
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for col, want := range map[int]string{0: "A", 5: "F", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		equals(t, want, xlsxColumn(col))
+	}
+}
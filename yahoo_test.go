@@ -0,0 +1,407 @@
+package quote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeYahooChart - yahoo v8 chart endpoint serving bars of the requested
+// interval from first on, every fifth bar without prices, recording the
+// requested ranges
+func fakeYahooChart(first time.Time, ranges *[][2]time.Time) http.HandlerFunc {
+	steps := map[string]time.Duration{"1m": time.Minute, "5m": 5 * time.Minute, "1d": 24 * time.Hour}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/test/getcrumb" {
+			w.Write([]byte("crumb"))
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, "/v8/finance/chart/") {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/v8/finance/chart/NOPE" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"chart":{"result":null,"error":{"code":"Not Found","description":"No data found, symbol may be delisted"}}}`))
+			return
+		}
+		q := r.URL.Query()
+		p1, _ := strconv.ParseInt(q.Get("period1"), 10, 64)
+		p2, _ := strconv.ParseInt(q.Get("period2"), 10, 64)
+		start, end := time.Unix(p1, 0).UTC(), time.Unix(p2, 0).UTC()
+		*ranges = append(*ranges, [2]time.Time{start, end})
+		step := steps[q.Get("interval")]
+
+		var ts []int64
+		var open, close, volume, adj []interface{}
+		n := 0
+		for d := first; d.Before(end); d = d.Add(step) {
+			if d.Before(start) {
+				continue
+			}
+			if step == 24*time.Hour {
+				// daily bars are stamped at the 09:30 new york open
+				ts = append(ts, d.Add(14*time.Hour+30*time.Minute).Unix())
+			} else {
+				ts = append(ts, d.Unix())
+			}
+			c := float64(d.Unix()%1000) + 100
+			if n%5 == 4 {
+				open, close, volume, adj = append(open, nil), append(close, nil), append(volume, nil), append(adj, nil)
+			} else {
+				open, close, volume, adj = append(open, c-1), append(close, c), append(volume, 10), append(adj, c/2)
+			}
+			n++
+		}
+		prices := map[string]interface{}{"open": open, "high": close, "low": open, "close": close, "volume": volume}
+		indicators := map[string]interface{}{"quote": []interface{}{prices}}
+		if step == 24*time.Hour {
+			indicators["adjclose"] = []interface{}{map[string]interface{}{"adjclose": adj}}
+		}
+		result := map[string]interface{}{
+			"meta":       map[string]interface{}{"gmtoffset": -18000},
+			"timestamp":  ts,
+			"indicators": indicators,
+		}
+		data, _ := json.Marshal(map[string]interface{}{"chart": map[string]interface{}{"result": []interface{}{result}, "error": nil}})
+		w.Write(data)
+	}
+}
+
+// useYahoo - fakeServer for Yahoo requests, cookie and crumb included, with
+// a fresh package session
+func useYahoo(t *testing.T, handler http.HandlerFunc) {
+	server := fakeServer(t, &yahooURL, handler)
+	saved := yahooCookieURL
+	yahooCookieURL = server.URL + "/cookie"
+	yahooSession.s = nil
+	t.Cleanup(func() {
+		yahooCookieURL = saved
+		yahooSession.s = nil
+	})
+}
+
+// withYahoo - useYahoo with fakeYahooChart, returning its requested ranges
+func withYahoo(t *testing.T, first time.Time) *[][2]time.Time {
+	ranges := &[][2]time.Time{}
+	useYahoo(t, fakeYahooChart(first, ranges))
+	return ranges
+}
+
+func TestYahooChartIntraday(t *testing.T) {
+	ranges := withYahoo(t, day(2024, 1, 2))
+
+	q, err := NewQuoteFromYahoo("aapl", "2024-01-02", "2024-01-09", Min5, true)
+	ok(t, err)
+	equals(t, 1, len(*ranges))
+	// the end date is inclusive, bars without prices are skipped
+	equals(t, 8*24*12-8*24*12/5, len(q.Close))
+	equals(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), q.Date[0])
+	equals(t, time.Date(2024, 1, 9, 23, 55, 0, 0, time.UTC), q.Date[len(q.Date)-1])
+	equals(t, 99.0+float64(q.Date[1].Unix()%1000), q.Open[1])
+	assert(t, q.AdjClose == nil, "intraday quote has adjusted closes")
+	equals(t, Min5, q.Meta.Period)
+}
+
+func TestYahooChartMin1Chunked(t *testing.T) {
+	ranges := withYahoo(t, day(2024, 1, 1))
+
+	q, err := NewQuoteFromYahoo("aapl", "2024-01-01", "2024-01-20", Min1, false)
+	ok(t, err)
+	// 20 days in 7 day windows
+	equals(t, 3, len(*ranges))
+	for _, r := range *ranges {
+		assert(t, r[1].Sub(r[0]) <= 7*24*time.Hour, "window %v too long", r)
+	}
+	equals(t, 20*24*60*4/5, len(q.Close))
+	for bar := 1; bar < len(q.Date); bar++ {
+		assert(t, q.Date[bar].After(q.Date[bar-1]), "bar %d out of order", bar)
+	}
+}
+
+func TestYahooChartDaily(t *testing.T) {
+	withYahoo(t, day(2024, 1, 2))
+
+	raw, adjusted, err := NewQuotePairFromYahoo("spy", "2024-01-02", "2024-01-06", Daily)
+	ok(t, err)
+	// dated on the trading day at midnight utc, as the csv download was
+	equals(t, []time.Time{day(2024, 1, 2), day(2024, 1, 3), day(2024, 1, 4), day(2024, 1, 5)}, raw.Date)
+	equals(t, raw.AdjClose, adjusted.Close)
+	equals(t, raw.Close[0]/2, adjusted.Close[0])
+	equals(t, false, raw.Meta.Adjusted)
+}
+
+func TestYahooChartError(t *testing.T) {
+	withYahoo(t, day(2024, 1, 2))
+
+	q, err := NewQuoteFromYahoo("NOPE", "2024-01-02", "2024-01-09", Daily, true)
+	assert(t, err != nil, "no error for chart.error response")
+	assert(t, strings.Contains(err.Error(), "No data found"), "unexpected error %v", err)
+	equals(t, 0, len(q.Close))
+}
+
+// yahooNullChart - daily chart response with null rows interleaved: a leading
+// null row, a holiday with every column null and a bar missing only its open
+const yahooNullChart = `{"chart":{"result":[{
+"meta":{"gmtoffset":-18000},
+"timestamp":[1704205800,1704292200,1704378600,1704465000,1704724200],
+"indicators":{
+"quote":[{
+"open":[null,10,null,null,12],
+"high":[null,11,null,12.5,13],
+"low":[null,9,null,11,11.5],
+"close":[null,10.5,null,12,12.5],
+"volume":[null,100,null,200,300]}],
+"adjclose":[{"adjclose":[null,5.25,null,6,6.25]}]}}],"error":null}}`
+
+func TestYahooChartNulls(t *testing.T) {
+	useYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(yahooNullChart))
+	})
+
+	q, err := NewQuoteFromYahoo("spy", "2024-01-02", "2024-01-08", Daily, false)
+	ok(t, err)
+	equals(t, []time.Time{day(2024, 1, 3), day(2024, 1, 8)}, q.Date)
+	equals(t, []float64{10.5, 12.5}, q.Close)
+	equals(t, []float64{5.25, 6.25}, q.AdjClose)
+
+	q, err = NewQuoteFromYahooWithOptions("spy", "2024-01-02", "2024-01-08", Daily, false, YahooOptions{NullPolicy: FillForward})
+	ok(t, err)
+	equals(t, []time.Time{day(2024, 1, 3), day(2024, 1, 4), day(2024, 1, 5), day(2024, 1, 8)}, q.Date)
+	equals(t, []float64{10.5, 10.5, 10.5, 12.5}, q.Close)
+	equals(t, []float64{10, 10.5, 10.5, 12}, q.Open)
+	equals(t, []float64{100, 0, 0, 300}, q.Volume)
+	equals(t, []float64{5.25, 5.25, 5.25, 6.25}, q.AdjClose)
+	for bar, c := range q.Close {
+		assert(t, c != 0 && q.Open[bar] != 0, "bar %d has zero prices", bar)
+	}
+
+	_, err = NewQuoteFromYahooWithOptions("spy", "2024-01-02", "2024-01-08", Daily, false, YahooOptions{NullPolicy: ErrorOnNulls})
+	assert(t, err != nil, "no error for null rows")
+	assert(t, strings.Contains(err.Error(), "2024-01-02"), "unexpected error %v", err)
+}
+
+func TestYahooSessionCrumb(t *testing.T) {
+	var mu sync.Mutex
+	crumbs, rejected := 0, 0
+	var ranges [][2]time.Time
+	chart := fakeYahooChart(day(2024, 1, 2), &ranges)
+	useYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/cookie":
+			http.SetCookie(w, &http.Cookie{Name: "A3", Value: "session", Path: "/"})
+			http.NotFound(w, r)
+		case r.URL.Path == "/v1/test/getcrumb":
+			if _, err := r.Cookie("A3"); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			crumbs++
+			fmt.Fprintf(w, "crumb%d", crumbs)
+		case r.URL.Query().Get("crumb") != fmt.Sprintf("crumb%d", crumbs) || r.URL.Path == "/v8/finance/chart/EXPIRE" && crumbs == 1:
+			rejected++
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			r.URL.Path = "/v8/finance/chart/x"
+			chart(w, r)
+		}
+	})
+
+	// concurrent downloads share one crumb
+	s := NewYahooSession()
+	var wg sync.WaitGroup
+	bars := make([]int, 3)
+	errs := make([]error, 3)
+	for i, sym := range []string{"spy", "aapl", "msft"} {
+		wg.Add(1)
+		go func(i int, sym string) {
+			defer wg.Done()
+			q, err := s.Quote(sym, "2024-01-02", "2024-01-05", Daily, true)
+			bars[i], errs[i] = len(q.Close), err
+		}(i, sym)
+	}
+	wg.Wait()
+	for i := range bars {
+		ok(t, errs[i])
+		equals(t, 4, bars[i])
+	}
+	equals(t, 1, crumbs)
+	equals(t, 0, rejected)
+
+	// a rejected crumb is refreshed once and the request retried
+	_, err := s.Quote("EXPIRE", "2024-01-02", "2024-01-05", Daily, true)
+	ok(t, err)
+	equals(t, 2, crumbs)
+	equals(t, 1, rejected)
+	_, err = s.Quote("spy", "2024-01-02", "2024-01-05", Daily, true)
+	ok(t, err)
+	equals(t, 2, crumbs)
+
+	// the package session is separate
+	_, err = NewQuoteFromYahoo("spy", "2024-01-02", "2024-01-05", Daily, true)
+	ok(t, err)
+	equals(t, 3, crumbs)
+}
+
+// yahooPrePostChart - 5 minute bars of 2024-01-02 from 04:00 to 19:55 new
+// york time with the trading periods returned by includePrePost=true
+const yahooPrePostChart = `{"chart":{"result":[{
+"meta":{"gmtoffset":-18000,"tradingPeriods":{
+"pre":[[{"start":1704186000,"end":1704205800,"gmtoffset":-18000}]],
+"regular":[[{"start":1704205800,"end":1704229200,"gmtoffset":-18000}]],
+"post":[[{"start":1704229200,"end":1704243600,"gmtoffset":-18000}]]}},
+"timestamp":[1704186000,1704204000,1704205800,1704228900,1704229200,1704243300],
+"indicators":{"quote":[{
+"open":[1,2,3,4,5,6],"high":[1,2,3,4,5,6],"low":[1,2,3,4,5,6],"close":[1,2,3,4,5,6],"volume":[1,1,1,1,1,1]}]}}],"error":null}}`
+
+func TestYahooChartPrePost(t *testing.T) {
+	var prePost []string
+	useYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v8/finance/chart/") {
+			prePost = append(prePost, r.URL.Query().Get("includePrePost"))
+		}
+		w.Write([]byte(yahooPrePostChart))
+	})
+	opts := YahooOptions{PrePost: true}
+
+	q, err := NewQuoteFromYahooWithOptions("spy", "2024-01-02", "2024-01-02", Min5, false, opts)
+	ok(t, err)
+	equals(t, []string{"true"}, prePost)
+	equals(t, 6, len(q.Close))
+	equals(t, []float64{SessionPre, SessionPre, SessionRegular, SessionRegular, SessionPost, SessionPost}, q.Session)
+	assert(t, strings.HasSuffix(strings.Split(q.CSV(), "\n")[0], ",session"), "no session column in %q", q.CSV())
+
+	// without the option or for daily bars there is no session column
+	q, err = NewQuoteFromYahoo("spy", "2024-01-02", "2024-01-02", Min5, false)
+	ok(t, err)
+	assert(t, q.Session == nil, "session column without PrePost")
+	q, err = NewQuoteFromYahooWithOptions("spy", "2024-01-02", "2024-01-02", Daily, false, opts)
+	ok(t, err)
+	assert(t, q.Session == nil, "session column for daily bars")
+	equals(t, []string{"true", "false", "false"}, prePost)
+
+	// through a SourceSpec, as the cli downloads
+	from, to := ParseDateString("2024-01-02"), ParseDateString("2024-01-02")
+	q, err = NewQuoteFromSource(context.Background(), SourceSpec{Name: "yahoo", PrePost: true}, "spy", from, to, Min5)
+	ok(t, err)
+	equals(t, "true", prePost[len(prePost)-1])
+	equals(t, 6, len(q.Session))
+}
+
+// yahooAAPLSplit2020 - daily chart response around the 2020-08-31 4:1 AAPL
+// split, raw prices with the provider's split and dividend adjusted closes
+const yahooAAPLSplit2020 = `{"chart":{"result":[{
+"meta":{"gmtoffset":-14400},
+"timestamp":[1598535000,1598621400,1598880600,1598967000],
+"indicators":{
+"quote":[{
+"open":[508.57,504.05,127.58,132.76],
+"high":[509.94,505.77,131.0,134.8],
+"low":[495.33,498.31,126.0,130.53],
+"close":[500.04,499.23,129.04,134.18],
+"volume":[38888100,46907500,225702700,151948100]}],
+"adjclose":[{"adjclose":[121.2597,121.0633,125.1688,130.1546]}]}}],"error":null}}`
+
+func TestYahooChartSplit(t *testing.T) {
+	useYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(yahooAAPLSplit2020))
+	})
+
+	raw, err := NewQuoteFromYahoo("aapl", "2020-08-27", "2020-09-01", Daily, false)
+	ok(t, err)
+	adj, err := NewQuoteFromYahoo("aapl", "2020-08-27", "2020-09-01", Daily, true)
+	ok(t, err)
+
+	tests := []struct {
+		date                  time.Time
+		open, close           float64 // raw
+		adjOpen, adjHigh      float64
+		adjLow, adjClose, vol float64
+	}{
+		{day(2020, 8, 27), 508.57, 500.04, 123.3282, 123.6604, 120.1175, 121.2597, 38888100},
+		{day(2020, 8, 28), 504.05, 499.23, 122.2321, 122.6492, 120.8401, 121.0633, 46907500},
+		{day(2020, 8, 31), 127.58, 129.04, 123.7526, 127.07, 122.22, 125.1688, 225702700},
+		{day(2020, 9, 1), 132.76, 134.18, 128.7772, 130.756, 126.6141, 130.1546, 151948100},
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-3 }
+	for bar, tt := range tests {
+		equals(t, tt.date, raw.Date[bar])
+		equals(t, tt.date, adj.Date[bar])
+		// raw prices are untouched and keep the provider's adjusted close
+		equals(t, tt.open, raw.Open[bar])
+		equals(t, tt.close, raw.Close[bar])
+		equals(t, tt.adjClose, raw.AdjClose[bar])
+		// every leg of an adjusted bar is scaled by the same ratio
+		assert(t, near(tt.adjOpen, adj.Open[bar]), "%v open %v, want %v", tt.date, adj.Open[bar], tt.adjOpen)
+		assert(t, near(tt.adjHigh, adj.High[bar]), "%v high %v, want %v", tt.date, adj.High[bar], tt.adjHigh)
+		assert(t, near(tt.adjLow, adj.Low[bar]), "%v low %v, want %v", tt.date, adj.Low[bar], tt.adjLow)
+		equals(t, tt.adjClose, adj.Close[bar])
+		equals(t, tt.vol, adj.Volume[bar])
+		equals(t, raw.AdjClose[bar], adj.AdjClose[bar])
+	}
+	// no 4:1 jump across the split in the adjusted series
+	assert(t, math.Abs(adj.Close[2]/adj.Close[1]-1) < 0.05, "adjusted split jump %v", adj.Close[2]/adj.Close[1])
+}
+
+func TestYahooBothSeries(t *testing.T) {
+	requests := 0
+	useYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v8/finance/chart/") {
+			requests++
+		}
+		w.Write([]byte(yahooAAPLSplit2020))
+	})
+
+	both, err := NewQuoteFromYahoo("aapl", "2020-08-27", "2020-09-01", Daily, false)
+	ok(t, err)
+	equals(t, 1, requests)
+	raw, err := NewQuoteFromYahoo("aapl", "2020-08-27", "2020-09-01", Daily, false)
+	ok(t, err)
+	adj, err := NewQuoteFromYahoo("aapl", "2020-08-27", "2020-09-01", Daily, true)
+	ok(t, err)
+
+	// the single download yields the series of both downloads
+	equals(t, raw.Unadjusted().Bars(), both.Unadjusted().Bars())
+	assert(t, both.Unadjusted().AdjClose == nil, "unadjusted series carries adjusted closes")
+	equals(t, adj.Bars(), both.Adjusted().Bars())
+	equals(t, adj.AdjClose, both.Adjusted().AdjClose)
+	equals(t, true, both.Adjusted().Meta.Adjusted)
+	equals(t, false, both.Meta.Adjusted)
+	for bar := range both.Date {
+		equals(t, adj.Open[bar], both.AdjOpen[bar])
+		equals(t, adj.High[bar], both.AdjHigh[bar])
+		equals(t, adj.Low[bar], both.AdjLow[bar])
+	}
+}
+
+func TestYahooYears(t *testing.T) {
+	q := NewQuote("spy", 0)
+	for m := 0; m < 15; m++ {
+		c := float64(100 + m)
+		q.PushBar(Bar{Date: time.Date(2023, time.Month(11+m), 1, 0, 0, 0, 0, time.UTC), Open: c - 1, High: c + 5, Low: c - 5, Close: c, Volume: 10})
+		q.setExtra(&q.AdjClose, m, c/2)
+	}
+	q = q.withAdjustedPrices()
+	y := q.years()
+	equals(t, []time.Time{day(2023, 11, 1), day(2024, 1, 1), day(2025, 1, 1)}, y.Date)
+	equals(t, []float64{99, 101, 113}, y.Open)
+	equals(t, []float64{106, 118, 119}, y.High)
+	equals(t, []float64{95, 97, 109}, y.Low)
+	equals(t, []float64{101, 113, 114}, y.Close)
+	equals(t, []float64{20, 120, 10}, y.Volume)
+	equals(t, []float64{50.5, 56.5, 57}, y.AdjClose)
+	equals(t, []float64{49.5, 50.5, 56.5}, y.AdjOpen)
+	equals(t, []float64{53, 59, 59.5}, y.AdjHigh)
+	equals(t, []float64{47.5, 48.5, 54.5}, y.AdjLow)
+	equals(t, 15, len(q.Close))
+	ok(t, ValidatePeriod("yahoo", Yearly))
+}
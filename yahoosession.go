@@ -0,0 +1,151 @@
+package quote
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// yahooCookieURL - page that sets the Yahoo session cookie
+var yahooCookieURL = "https://fc.yahoo.com"
+
+// YahooSession - Yahoo cookie and crumb shared by all requests made through
+// it. The crumb is fetched on first use and refreshed when Yahoo rejects a
+// request with 401 or 403. Safe for concurrent use.
+type YahooSession struct {
+	mu     sync.Mutex
+	client *http.Client // nil for HTTPClient
+	jar    http.CookieJar
+	crumb  string
+}
+
+// YahooSessionOption - option of NewYahooSession
+type YahooSessionOption func(*YahooSession)
+
+// WithHTTPClient - make the requests of the session with c instead of
+// HTTPClient. A copy of c with the session cookie jar is used if c has no
+// jar, c itself is not modified.
+func WithHTTPClient(c *http.Client) YahooSessionOption {
+	return func(s *YahooSession) {
+		s.client = c
+	}
+}
+
+// NewYahooSession - a session without cookie or crumb, both are obtained
+// by its first request
+func NewYahooSession(opts ...YahooSessionOption) *YahooSession {
+	jar, _ := cookiejar.New(nil)
+	s := &YahooSession{jar: jar}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// httpClient - the client of s, with the session cookie jar unless it has
+// its own
+func (s *YahooSession) httpClient() *http.Client {
+	c := s.client
+	if c == nil {
+		c = httpClient()
+	}
+	if c.Jar != nil {
+		return c
+	}
+	withJar := *c
+	withJar.Jar = s.jar
+	return &withJar
+}
+
+// yahooSession - package session of NewQuoteFromYahoo, created on first use
+var yahooSession struct {
+	sync.Mutex
+	s *YahooSession
+}
+
+// defaultYahooSession - the package session
+func defaultYahooSession() *YahooSession {
+	yahooSession.Lock()
+	defer yahooSession.Unlock()
+	if yahooSession.s == nil {
+		yahooSession.s = NewYahooSession()
+	}
+	return yahooSession.s
+}
+
+// Quote - Yahoo historical prices for a symbol, see NewQuoteFromYahoo
+func (s *YahooSession) Quote(symbol, startDate, endDate string, period Period, adjustQuote bool) (Quote, error) {
+	opts := DefaultYahooOptions
+	opts.Session = s
+	return NewQuoteFromYahooWithOptions(symbol, startDate, endDate, period, adjustQuote, opts)
+}
+
+// get - GET url with the session cookies and user agent, canceled with ctx
+func (s *YahooSession) get(ctx context.Context, url string) (*http.Response, error) {
+	if err := waitRate(ctx, "yahoo"); err != nil {
+		return nil, err
+	}
+	req, err := newRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; U; Linux i686) Gecko/20071127 Firefox/2.0.0.11")
+	return s.httpClient().Do(req)
+}
+
+// getCrumb - the session crumb, fetching a new cookie and crumb if there is
+// none yet or the current one is stale
+func (s *YahooSession) getCrumb(ctx context.Context, stale string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.crumb != "" && s.crumb != stale {
+		return s.crumb, nil
+	}
+
+	// the cookie page answers 404 but sets the cookie
+	if resp, err := s.get(ctx, yahooCookieURL); err == nil {
+		resp.Body.Close()
+	} else {
+		Log.Printf("yahoo cookie error: %v\n", err)
+	}
+
+	resp, err := s.get(ctx, yahooURL+"/v1/test/getcrumb")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	crumb := strings.TrimSpace(string(body))
+	if resp.StatusCode != http.StatusOK || crumb == "" {
+		return "", fmt.Errorf("yahoo crumb: %s", resp.Status)
+	}
+	s.crumb = crumb
+	return crumb, nil
+}
+
+// request - GET url with the session crumb appended, retried once with a
+// fresh crumb when rejected
+func (s *YahooSession) request(ctx context.Context, u string) (*http.Response, error) {
+	stale := ""
+	for attempt := 0; ; attempt++ {
+		crumb, err := s.getCrumb(ctx, stale)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.get(ctx, u+"&crumb="+url.QueryEscape(crumb))
+		if err != nil {
+			return nil, err
+		}
+		rejected := resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden
+		if !rejected || attempt > 0 {
+			return resp, nil
+		}
+		resp.Body.Close()
+		stale = crumb
+	}
+}